@@ -0,0 +1,56 @@
+// Command auth-token issues bearer tokens for installs running with
+// AUTH_MODE=local, where there is no Supabase Auth to sign users in.
+//
+//	go run ./cmd/auth-token -email detective@example.com
+//	go run ./cmd/auth-token -user 6f1c... -ttl 720h
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+
+	"github.com/sherlockos/backend/internal/auth"
+	"github.com/sherlockos/backend/pkg/config"
+)
+
+func main() {
+	userFlag := flag.String("user", "", "user ID (a new one is generated if empty)")
+	email := flag.String("email", "", "email to put in the token")
+	ttl := flag.Duration("ttl", 24*time.Hour, "how long the token is valid")
+	flag.Parse()
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	cfg := config.Load()
+	if cfg.AuthLocalSecret == "" {
+		log.Fatal("AUTH_LOCAL_SECRET is required")
+	}
+	if cfg.AuthMode != auth.ModeLocal {
+		log.Printf("Warning: AUTH_MODE is %s; the server won't accept local tokens", cfg.AuthMode)
+	}
+
+	userID := uuid.New()
+	if *userFlag != "" {
+		id, err := uuid.Parse(*userFlag)
+		if err != nil {
+			log.Fatalf("Invalid user ID: %v", err)
+		}
+		userID = id
+	}
+
+	token, err := auth.NewLocalIssuer(cfg.AuthLocalSecret).Issue(auth.User{ID: userID, Email: *email}, *ttl)
+	if err != nil {
+		log.Fatalf("Failed to issue token: %v", err)
+	}
+
+	log.Printf("Token for user %s, valid until %s", userID, time.Now().Add(*ttl).Format(time.RFC3339))
+	fmt.Println(token)
+}
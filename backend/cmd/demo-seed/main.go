@@ -15,6 +15,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 
+	"github.com/sherlockos/backend/internal/auth"
 	"github.com/sherlockos/backend/internal/clients"
 	"github.com/sherlockos/backend/internal/db"
 	"github.com/sherlockos/backend/internal/models"
@@ -49,6 +50,9 @@ func main() {
 	// Create repository
 	repo := db.NewRepository(database)
 
+	// With authentication on, the demo case belongs to the seeding user
+	token, owner := demoUser(cfg)
+
 	// Initialize storage client
 	storageClient := clients.NewSupabaseStorageClient(cfg.SupabaseURL, cfg.SupabaseSecretKey)
 
@@ -73,6 +77,7 @@ func main() {
 		ID:          caseID,
 		Title:       "Demo Crime Scene Investigation",
 		Description: "A demonstration case using crime scene photos to test the full SherlockOS pipeline.",
+		CreatedBy:   owner,
 		CreatedAt:   time.Now(),
 	}
 
@@ -145,6 +150,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to create commit model: %v", err)
 	}
+	uploadCommit.CreatedBy = owner
 	err = repo.CreateCommit(ctx, uploadCommit)
 	if err != nil {
 		log.Fatalf("Failed to create upload commit: %v", err)
@@ -170,6 +176,9 @@ func main() {
 		log.Fatalf("Failed to create request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -207,6 +216,9 @@ func main() {
 		log.Fatalf("Failed to create reconstruction request: %v", err)
 	}
 	reconReq.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		reconReq.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	reconResp, err := http.DefaultClient.Do(reconReq)
 	if err != nil {
@@ -265,3 +277,35 @@ func main() {
 		}
 	}
 }
+
+// demoUser returns the bearer token and user the demo is seeded as: the user
+// of DEMO_TOKEN, or with local auth a new user the seed signs a token for.
+// With authentication disabled there is none.
+func demoUser(cfg *config.Config) (string, *uuid.UUID) {
+	if cfg.AuthMode == auth.ModeDisabled {
+		return "", nil
+	}
+
+	if token := os.Getenv("DEMO_TOKEN"); token != "" {
+		verifier, err := auth.NewVerifier(cfg.AuthMode, cfg.SupabaseJWTSecret, cfg.AuthLocalSecret)
+		if err != nil {
+			log.Fatalf("Invalid auth configuration: %v", err)
+		}
+		user, err := verifier.Verify(context.Background(), token)
+		if err != nil {
+			log.Fatalf("Invalid DEMO_TOKEN: %v", err)
+		}
+		return token, &user.ID
+	}
+
+	if cfg.AuthMode != auth.ModeLocal {
+		log.Fatal("DEMO_TOKEN is required when authentication is enabled")
+	}
+	userID := uuid.New()
+	token, err := auth.NewLocalIssuer(cfg.AuthLocalSecret).Issue(auth.User{ID: userID}, 24*time.Hour)
+	if err != nil {
+		log.Fatalf("Failed to issue demo token: %v", err)
+	}
+	log.Printf("Seeding as new local user %s", userID)
+	return token, &userID
+}
@@ -6,18 +6,24 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 
 	"github.com/sherlockos/backend/internal/api"
+	"github.com/sherlockos/backend/internal/auth"
 	"github.com/sherlockos/backend/internal/clients"
 	"github.com/sherlockos/backend/internal/db"
+	"github.com/sherlockos/backend/internal/lifecycle"
+	"github.com/sherlockos/backend/internal/models"
 	"github.com/sherlockos/backend/internal/queue"
+	"github.com/sherlockos/backend/internal/ratelimit"
 	"github.com/sherlockos/backend/internal/workers"
 	"github.com/sherlockos/backend/pkg/config"
 )
@@ -58,27 +64,46 @@ func main() {
 		log.Println("Supabase storage client initialized")
 	}
 
-	// Initialize AI clients and workers if Gemini API key is available
-	var workerManager *workers.Manager
-	if cfg.GeminiAPIKey != "" {
-		// Initialize Gemini API clients
-		reasoningClient := clients.NewGeminiReasoningClient(cfg.GeminiAPIKey)
-		profileClient := clients.NewGeminiProfileClient(cfg.GeminiAPIKey)
-		imageGenClient := clients.NewGeminiImageGenClient(cfg.GeminiAPIKey, storageClient)
+	// Resolve per-job LLM providers (Gemini or an OpenAI-compatible local server)
+	reasoningLLM, reasoningOK := newLLMJobConfig("reasoning", cfg.LLMReasoning)
+	profileLLM, profileOK := newLLMJobConfig("profile", cfg.LLMProfile)
+	sceneAnalysisLLM, sceneAnalysisOK := newLLMJobConfig("scene_analysis", cfg.LLMSceneAnalysis)
+
+	// Caps on estimated AI spending, for jobs created through the API and by workers
+	budgets := models.Budgets{
+		CaseUSD:                cfg.CaseBudgetUSD,
+		OrganisationMonthlyUSD: cfg.MonthlyBudgetUSD,
+	}
 
+	// Initialize AI clients and workers if Gemini or a local LLM provider is available
+	var workerManager *workers.Manager
+	if cfg.GeminiAPIKey != "" || reasoningOK || profileOK || sceneAnalysisOK {
 		// Initialize worker manager
-		workerManager = workers.NewManager(database, jobQueue, workers.DefaultManagerConfig())
+		managerConfig := workers.DefaultManagerConfig()
+		managerConfig.Budgets = budgets
+		workerManager = workers.NewManager(database, jobQueue, managerConfig)
 
-		// Register Gemini-based workers (always available when GEMINI_API_KEY is set)
-		workerManager.Register(workers.NewReasoningWorker(database, jobQueue, reasoningClient))
-		workerManager.Register(workers.NewProfileWorker(database, jobQueue, profileClient))
-		workerManager.Register(workers.NewImageGenWorker(database, jobQueue, imageGenClient))
-		log.Println("Gemini workers registered (reasoning, profile, imagegen)")
+		// Register LLM-based workers
+		if reasoningOK {
+			workerManager.Register(workers.NewReasoningWorkerWithGrounding(database, jobQueue, clients.NewReasoningClient(reasoningLLM), cfg.ReasoningMinGrounding))
+			log.Printf("Reasoning worker registered (%s)", cfg.LLMReasoning.Provider)
+		}
+		if profileOK {
+			workerManager.Register(workers.NewProfileWorker(database, jobQueue, clients.NewProfileClient(profileLLM)))
+			log.Printf("Profile worker registered (%s)", cfg.LLMProfile.Provider)
+		}
+
+		// Image generation is Gemini-only (Nano Banana)
+		if cfg.GeminiAPIKey != "" {
+			imageGenClient := clients.NewGeminiImageGenClient(cfg.GeminiAPIKey, storageClient)
+			workerManager.Register(workers.NewImageGenWorkerWithStorage(database, jobQueue, imageGenClient, storageClient))
+			log.Println("Image generation worker registered (Gemini)")
+		}
 
 		// Initialize reconstruction client (Modal HunyuanWorld-Mirror) - NO MOCK FALLBACK
 		if cfg.ModalMirrorURL != "" && storageClient != nil {
 			reconstructionClient := clients.NewModalReconstructionClient(cfg.ModalMirrorURL, storageClient)
-			workerManager.Register(workers.NewReconstructionWorker(database, jobQueue, reconstructionClient))
+			workerManager.Register(workers.NewReconstructionWorkerWithStorage(database, jobQueue, reconstructionClient, storageClient))
 			log.Println("Reconstruction worker registered (Modal HunyuanWorld-Mirror)")
 		} else {
 			log.Println("WARNING: Reconstruction worker DISABLED - MODAL_MIRROR_URL not set or storage not configured")
@@ -95,11 +120,11 @@ func main() {
 			log.Println("  → replay jobs will fail with 'service not available' error")
 		}
 
-		// Register scene analysis worker (Gemini 3 Pro Vision)
-		if storageClient != nil {
-			sceneAnalysisClient := clients.NewGeminiSceneAnalysisClient(cfg.GeminiAPIKey, storageClient)
+		// Register scene analysis worker (vision model)
+		if sceneAnalysisOK && storageClient != nil {
+			sceneAnalysisClient := clients.NewSceneAnalysisClient(sceneAnalysisLLM, storageClient)
 			workerManager.Register(workers.NewSceneAnalysisWorker(database, jobQueue, sceneAnalysisClient))
-			log.Println("Scene analysis worker registered (Gemini 3 Pro Vision)")
+			log.Printf("Scene analysis worker registered (%s)", cfg.LLMSceneAnalysis.Provider)
 		}
 
 		// Register 3D asset worker (Hunyuan3D-2 via Replicate)
@@ -111,16 +136,51 @@ func main() {
 			log.Println("Warning: REPLICATE_API_TOKEN not set, 3D asset worker disabled")
 		}
 
-		// Register export worker (HTML report generation)
-		workerManager.Register(workers.NewExportWorker(database, jobQueue, storageClient))
-		log.Println("Export worker registered (HTML report generation)")
+		// Register export worker (HTML / PDF reports and case bundles)
+		workerManager.Register(workers.NewExportWorkerWithTemplates(database, jobQueue, storageClient, cfg.ReportTemplateDir))
+		log.Println("Export worker registered (report and bundle export)")
 
 		// Start workers
 		workerManager.Start(context.Background())
 		log.Println("Workers started")
 	} else {
-		if cfg.GeminiAPIKey == "" {
-			log.Println("Warning: GEMINI_API_KEY not set, AI workers disabled")
+		log.Println("Warning: GEMINI_API_KEY not set and no LLM provider configured, AI workers disabled")
+	}
+
+	// Purge deleted cases once their retention window passes
+	var purger *lifecycle.Purger
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	if database != nil {
+		retention := time.Duration(cfg.CaseRetentionDays) * 24 * time.Hour
+		purger = lifecycle.NewPurger(db.NewRepository(database), storageClient, retention)
+		go purger.Run(purgeCtx, lifecycle.DefaultInterval)
+		log.Printf("Case purger started (retention %d days)", cfg.CaseRetentionDays)
+	}
+
+	// Authentication
+	verifier, err := auth.NewVerifier(cfg.AuthMode, cfg.SupabaseJWTSecret, cfg.AuthLocalSecret)
+	if err != nil {
+		log.Fatalf("Invalid auth configuration: %v", err)
+	}
+	if verifier == nil {
+		log.Println("WARNING: Authentication DISABLED - set SUPABASE_JWT_SECRET or AUTH_LOCAL_SECRET to require bearer tokens")
+	} else {
+		log.Printf("Authentication enabled (%s tokens)", cfg.AuthMode)
+	}
+
+	// Cases from before authentication have no members, so only a lead
+	// assigned here can reach them
+	if cfg.AuthBootstrapLead != "" {
+		leadID, err := uuid.Parse(cfg.AuthBootstrapLead)
+		if err != nil {
+			log.Fatalf("Invalid AUTH_BOOTSTRAP_LEAD: %v", err)
+		}
+		n, err := db.NewRepository(database).LeadOrphanCases(context.Background(), leadID)
+		if err != nil {
+			log.Printf("Warning: failed to assign cases without members to %s: %v", leadID, err)
+		} else if n > 0 {
+			log.Printf("Made %s lead of %d cases without members", leadID, n)
 		}
 	}
 
@@ -137,7 +197,7 @@ func main() {
 	// CORS configuration
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   cfg.AllowedOrigins,
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
@@ -152,12 +212,22 @@ func main() {
 
 	// API routes
 	r.Route("/v1", func(r chi.Router) {
-		api.RegisterRoutesWithQueue(r, database, jobQueue)
+		routeOpts := api.RouteOptions{
+			Queue:   jobQueue,
+			Storage: storageClient,
+			Purger:  purger,
+			Auth:    verifier,
+
+			UserJobLimit: ratelimit.NewLimiter(cfg.JobRateUserPerMinute, cfg.JobRateUserBurst),
+			CaseJobLimit: ratelimit.NewLimiter(cfg.JobRateCasePerMinute, cfg.JobRateCaseBurst),
+			Budgets:      budgets,
+		}
+		api.RegisterRoutesWithOptions(r, database, routeOpts)
 
 		// Portrait chat route (needs direct access to Gemini client)
 		if cfg.GeminiAPIKey != "" {
 			imageGenClient := clients.NewGeminiImageGenClient(cfg.GeminiAPIKey, storageClient)
-			api.RegisterPortraitRoutes(r, imageGenClient)
+			api.RegisterPortraitRoutes(r, database, imageGenClient, routeOpts)
 		}
 	})
 
@@ -184,6 +254,8 @@ func main() {
 	<-quit
 	log.Println("Shutting down server...")
 
+	stopPurge()
+
 	// Stop workers first
 	if workerManager != nil {
 		log.Println("Stopping workers...")
@@ -200,3 +272,24 @@ func main() {
 
 	log.Println("Server exited")
 }
+
+// newLLMJobConfig builds the LLM provider for a job type from config.
+// Returns false if the provider is not configured or invalid.
+func newLLMJobConfig(job string, c config.LLMConfig) (clients.LLMJobConfig, bool) {
+	if !c.Enabled() {
+		if c.Provider == config.LLMProviderOpenAI && c.Endpoint != "" && c.Model == "" {
+			log.Printf("Warning: %s LLM provider disabled: openai needs a model (set LLM_MODEL or LLM_%s_MODEL)", job, strings.ToUpper(job))
+		}
+		return clients.LLMJobConfig{}, false
+	}
+	provider, err := clients.NewLLMProvider(c.Provider, c.Endpoint, c.APIKey)
+	if err != nil {
+		log.Printf("Warning: %s LLM provider disabled: %v", job, err)
+		return clients.LLMJobConfig{}, false
+	}
+	return clients.LLMJobConfig{
+		Provider:       provider,
+		Model:          c.Model,
+		ThinkingBudget: c.ThinkingBudget,
+	}, true
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/auth"
+	"github.com/sherlockos/backend/internal/clients"
+	"github.com/sherlockos/backend/internal/db"
+	"github.com/sherlockos/backend/internal/media"
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// assetBucket is the storage bucket holding uploaded case files
+const assetBucket = "case-assets"
+
+// maxIngestFiles limits how many files a single ingest request may process
+const maxIngestFiles = 50
+
+// AssetHandler handles asset ingest requests
+type AssetHandler struct {
+	repo       *db.Repository
+	storage    clients.StorageClient
+	thumbnails *clients.Thumbnailer
+}
+
+// NewAssetHandler creates a new asset handler
+func NewAssetHandler(database *db.DB, storage clients.StorageClient) *AssetHandler {
+	var repo *db.Repository
+	if database != nil {
+		repo = db.NewRepository(database)
+	}
+	var thumbnails *clients.Thumbnailer
+	if storage != nil {
+		thumbnails = clients.NewThumbnailer(storage, media.DetectFrameExtractor())
+	}
+	return &AssetHandler{repo: repo, storage: storage, thumbnails: thumbnails}
+}
+
+// IngestRequest lists uploaded storage keys to register as case assets
+type IngestRequest struct {
+	StorageKeys []string `json:"storage_keys"`
+}
+
+// Ingest handles POST /v1/cases/{caseId}/assets/ingest
+// It reads EXIF / MP4 container metadata from uploaded scans, stores it on the
+// asset records and records capture times as timeline anchors in an upload_scan commit.
+func (h *AssetHandler) Ingest(w http.ResponseWriter, r *http.Request) {
+	caseIDStr := chi.URLParam(r, "caseId")
+	if caseIDStr == "" {
+		BadRequest(w, "Case ID is required")
+		return
+	}
+
+	caseID, err := uuid.Parse(caseIDStr)
+	if err != nil {
+		BadRequest(w, "Invalid case ID format")
+		return
+	}
+
+	var req IngestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		BadRequest(w, "Invalid request body")
+		return
+	}
+
+	if len(req.StorageKeys) == 0 {
+		BadRequest(w, "At least one storage key is required")
+		return
+	}
+	if len(req.StorageKeys) > maxIngestFiles {
+		BadRequest(w, fmt.Sprintf("At most %d storage keys may be ingested per request", maxIngestFiles))
+		return
+	}
+
+	casePrefix := "cases/" + caseID.String() + "/"
+	for _, key := range req.StorageKeys {
+		if !strings.HasPrefix(key, casePrefix) {
+			BadRequest(w, "Storage key does not belong to this case: "+key)
+			return
+		}
+	}
+
+	if !requireWritableCase(w, r, h.repo, caseID) {
+		return
+	}
+
+	if h.storage == nil {
+		ServiceUnavailable(w, "Storage not configured")
+		return
+	}
+
+	var assets []map[string]interface{}
+	var failures []map[string]interface{}
+	var anchors []models.TimelineAnchor
+
+	for _, key := range req.StorageKeys {
+		data, contentType, err := h.storage.Download(r.Context(), assetBucket, key)
+		if err != nil {
+			failures = append(failures, map[string]interface{}{"storage_key": key, "error": "download failed: " + err.Error()})
+			continue
+		}
+
+		meta, err := media.Extract(data)
+		if err != nil {
+			failures = append(failures, map[string]interface{}{"storage_key": key, "error": err.Error()})
+			continue
+		}
+
+		kind := models.AssetKindScanImage
+		if meta.MediaType == media.MediaTypeVideo {
+			kind = models.AssetKindScanVideo
+		}
+
+		asset := models.NewAsset(caseID, kind, key)
+		for k, v := range meta.ToAssetMetadata() {
+			asset.SetMetadata(k, v)
+		}
+		asset.SetMetadata("content_type", contentType)
+		asset.SetMetadata("size_bytes", len(data))
+		if thumbKey := h.createThumbnail(r, key, data, meta); thumbKey != "" {
+			asset.SetMetadata("thumbnail_key", thumbKey)
+		}
+
+		if h.repo != nil {
+			if err := h.repo.UpsertAssetByStorageKey(r.Context(), asset); err != nil {
+				InternalError(w, "Failed to save asset")
+				return
+			}
+		}
+
+		if anchor := meta.TimelineAnchor(key); anchor != nil {
+			anchors = append(anchors, *anchor)
+		}
+
+		assets = append(assets, map[string]interface{}{
+			"id":          asset.ID.String(),
+			"kind":        asset.Kind,
+			"storage_key": asset.StorageKey,
+			"metadata":    asset.Metadata,
+		})
+	}
+
+	sort.Slice(anchors, func(i, j int) bool {
+		return anchors[i].Time.Before(anchors[j].Time)
+	})
+
+	result := map[string]interface{}{
+		"assets":           assets,
+		"timeline_anchors": anchors,
+		"failed":           failures,
+	}
+
+	if len(assets) > 0 && h.repo != nil {
+		commit, err := h.createUploadScanCommit(r, caseID, assets, anchors)
+		if err != nil {
+			if errors.Is(err, db.ErrCaseClosed) {
+				Conflict(w, "Case does not accept changes", nil)
+				return
+			}
+			InternalError(w, "Failed to save commit")
+			return
+		}
+		result["commit_id"] = commit.ID.String()
+		setAuditTarget(r, "commit", commit.ID.String())
+	}
+
+	Success(w, http.StatusCreated, result, nil)
+}
+
+// createThumbnail stores a preview for a scan image, or a poster frame for a
+// video when a local decoder is available. Failures are logged, not fatal.
+func (h *AssetHandler) createThumbnail(r *http.Request, key string, data []byte, meta *media.CaptureMetadata) string {
+	var thumbKey string
+	var err error
+	if meta.MediaType == media.MediaTypeVideo {
+		thumbKey, err = h.thumbnails.ForVideo(r.Context(), key, data, meta.DurationSeconds)
+	} else {
+		thumbKey, err = h.thumbnails.ForImage(r.Context(), key, data)
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to create thumbnail for %s: %v\n", key, err)
+		return ""
+	}
+	return thumbKey
+}
+
+// createUploadScanCommit records the ingested assets and their timeline anchors
+func (h *AssetHandler) createUploadScanCommit(r *http.Request, caseID uuid.UUID, assets []map[string]interface{}, anchors []models.TimelineAnchor) (*models.Commit, error) {
+	assetRefs := make([]map[string]interface{}, 0, len(assets))
+	for _, a := range assets {
+		assetRefs = append(assetRefs, map[string]interface{}{
+			"asset_id":    a["id"],
+			"kind":        a["kind"],
+			"storage_key": a["storage_key"],
+		})
+	}
+
+	payload := map[string]interface{}{
+		"assets":           assetRefs,
+		"timeline_anchors": anchors,
+	}
+
+	summary := fmt.Sprintf("Ingested %d scan file(s)", len(assets))
+	if len(anchors) > 0 {
+		summary += fmt.Sprintf(" with %d capture time anchor(s)", len(anchors))
+	}
+
+	commit, err := models.NewCommit(caseID, models.CommitTypeUploadScan, summary, payload)
+	if err != nil {
+		return nil, err
+	}
+	commit.CreatedBy = auth.UserID(r.Context())
+
+	latestCommit, _ := h.repo.GetLatestCommit(r.Context(), caseID)
+	if latestCommit != nil {
+		commit.SetParent(latestCommit.ID)
+	}
+
+	if err := h.repo.CreateCommit(r.Context(), commit); err != nil {
+		return nil, err
+	}
+	return commit, nil
+}
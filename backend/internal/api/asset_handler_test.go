@@ -0,0 +1,130 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/sherlockos/backend/internal/clients"
+)
+
+func TestAssetHandler_Ingest(t *testing.T) {
+	scanKey := "cases/" + testCaseID + "/scans/batch/scan1.png"
+	storage := &clients.MockStorageClient{
+		DownloadFunc: func(ctx context.Context, bucket, key string) ([]byte, string, error) {
+			switch key {
+			case "cases/" + testCaseID + "/scans/batch/missing.jpg":
+				return nil, "", errors.New("object not found")
+			case "cases/" + testCaseID + "/scans/batch/notes.txt":
+				return []byte("witness notes"), "text/plain", nil
+			}
+			return (&clients.MockStorageClient{}).Download(ctx, bucket, key)
+		},
+	}
+
+	tests := []struct {
+		name       string
+		storage    clients.StorageClient
+		body       interface{}
+		wantStatus int
+		wantErr    string
+		wantAssets int
+		wantFailed int
+	}{
+		{
+			name:       "invalid JSON",
+			storage:    storage,
+			body:       "not json",
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Invalid request body",
+		},
+		{
+			name:       "empty storage keys",
+			storage:    storage,
+			body:       IngestRequest{},
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "At least one storage key is required",
+		},
+		{
+			name:       "key from another case",
+			storage:    storage,
+			body:       IngestRequest{StorageKeys: []string{"cases/other/scans/a.jpg"}},
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Storage key does not belong to this case: cases/other/scans/a.jpg",
+		},
+		{
+			name:       "storage not configured",
+			storage:    nil,
+			body:       IngestRequest{StorageKeys: []string{scanKey}},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:    "mixed results",
+			storage: storage,
+			body: IngestRequest{StorageKeys: []string{
+				scanKey,
+				"cases/" + testCaseID + "/scans/batch/missing.jpg",
+				"cases/" + testCaseID + "/scans/batch/notes.txt",
+			}},
+			wantStatus: http.StatusCreated,
+			wantAssets: 1,
+			wantFailed: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAssetHandler(nil, tt.storage)
+			r := chi.NewRouter()
+			r.Post("/v1/cases/{caseId}/assets/ingest", handler.Ingest)
+
+			var body []byte
+			if str, ok := tt.body.(string); ok {
+				body = []byte(str)
+			} else {
+				body, _ = json.Marshal(tt.body)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/cases/"+testCaseID+"/assets/ingest", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Ingest() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if tt.wantErr != "" {
+				if msg := getErrorMessage(w.Body.Bytes()); msg != tt.wantErr {
+					t.Errorf("Ingest() error = %q, want %q", msg, tt.wantErr)
+				}
+			}
+
+			if tt.wantStatus == http.StatusCreated {
+				data := getData(w.Body.Bytes())
+				assets, _ := data["assets"].([]interface{})
+				failed, _ := data["failed"].([]interface{})
+				if len(assets) != tt.wantAssets {
+					t.Errorf("Ingest() assets = %d, want %d", len(assets), tt.wantAssets)
+				}
+				if len(failed) != tt.wantFailed {
+					t.Errorf("Ingest() failed = %d, want %d", len(failed), tt.wantFailed)
+				}
+				if len(assets) > 0 {
+					asset := assets[0].(map[string]interface{})
+					meta := asset["metadata"].(map[string]interface{})
+					if meta["media_type"] != "image" || meta["camera_intrinsics"] == nil {
+						t.Errorf("Ingest() asset metadata = %v, want image with camera_intrinsics", meta)
+					}
+					if meta["thumbnail_key"] != "cases/"+testCaseID+"/scans/batch/scan1_thumb.jpg" {
+						t.Errorf("Ingest() thumbnail_key = %v", meta["thumbnail_key"])
+					}
+				}
+			}
+		})
+	}
+}
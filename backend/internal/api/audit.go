@@ -0,0 +1,278 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/auth"
+	"github.com/sherlockos/backend/internal/db"
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// auditWriteTimeout bounds writing an audit entry after the response
+const auditWriteTimeout = 5 * time.Second
+
+// auditStore is where the audit middleware records entries
+type auditStore interface {
+	CreateAuditEntry(ctx context.Context, e *models.AuditEntry) error
+}
+
+// auditActions names the audited routes, by method and pattern relative to
+// the API root. Every mutating request is audited; reads are audited when
+// they return evidence or the audit log itself.
+var auditActions = map[string]string{
+	"POST /cases":                             "case.create",
+	"POST /cases/import":                      "case.import",
+	"PATCH /cases/{caseId}":                   "case.update",
+	"DELETE /cases/{caseId}":                  "case.delete",
+	"POST /cases/{caseId}/restore":            "case.restore",
+	"PUT /cases/{caseId}/members/{userId}":    "member.set",
+	"DELETE /cases/{caseId}/members/{userId}": "member.remove",
+	"POST /cases/{caseId}/upload-intent":      "upload.create",
+	"POST /cases/{caseId}/assets/ingest":      "asset.ingest",
+	"POST /cases/{caseId}/jobs":               "job.create",
+	"POST /cases/{caseId}/witness-statements": "statement.create",
+	"POST /cases/{caseId}/branches":           "branch.create",
+	"POST /cases/{caseId}/reasoning":          "reasoning.create",
+	"POST /cases/{caseId}/export":             "export.create",
+	"GET /cases/{caseId}/snapshot":            "scene.read",
+	"GET /cases/{caseId}/timeline":            "timeline.read",
+	"GET /cases/{caseId}/pointcloud":          "pointcloud.read",
+	"GET /cases/{caseId}/scene/query":         "scene.query",
+	"GET /cases/{caseId}/floorplan.svg":       "floorplan.read",
+	"GET /cases/{caseId}/floorplan.png":       "floorplan.read",
+	"GET /cases/{caseId}/scene.glb":           "scene.export",
+	"GET /cases/{caseId}/audit":               "audit.read",
+	"GET /jobs/{jobId}":                       "job.read",
+	"GET /search":                             "search",
+}
+
+// auditAction returns the action a request is recorded as, or "" if it
+// isn't audited. Mutating routes missing from auditActions are recorded by
+// method and pattern.
+func auditAction(method, pattern string) string {
+	if action, ok := auditActions[method+" "+pattern]; ok {
+		return action
+	}
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return method + " " + pattern
+	}
+	return ""
+}
+
+// auditNote carries what a handler learns about the request's case and
+// target back to the audit middleware
+type auditNote struct {
+	caseID     *uuid.UUID
+	targetType string
+	targetID   string
+	detail     string
+}
+
+type auditNoteKey struct{}
+
+func auditNoteFrom(r *http.Request) *auditNote {
+	note, _ := r.Context().Value(auditNoteKey{}).(*auditNote)
+	return note
+}
+
+// setAuditCase records the case of a request whose route has no case ID,
+// such as a new case or a job looked up by ID
+func setAuditCase(r *http.Request, caseID uuid.UUID) {
+	if note := auditNoteFrom(r); note != nil {
+		note.caseID = &caseID
+	}
+}
+
+// setAuditTarget records the entity a request created or acted on, in
+// place of the one named by the route
+func setAuditTarget(r *http.Request, targetType, targetID string) {
+	if note := auditNoteFrom(r); note != nil {
+		note.targetType, note.targetID = targetType, targetID
+	}
+}
+
+// setAuditDetail adds a note to the request's audit entry
+func setAuditDetail(r *http.Request, detail string) {
+	if note := auditNoteFrom(r); note != nil {
+		note.detail = detail
+	}
+}
+
+// Audit records audited requests in the audit log once they are handled:
+// who made them, the request ID, route, case, action, target and outcome.
+// Requests refused by Authenticate never reach it, as they have no actor.
+func Audit(store auditStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rctx := chi.RouteContext(r.Context())
+			if rctx == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// Patterns are named relative to where the API is mounted
+			root := strings.TrimSuffix(rctx.RoutePattern(), "/*")
+			mounted := len(rctx.RoutePatterns)
+
+			note := &auditNote{}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			r = r.WithContext(context.WithValue(r.Context(), auditNoteKey{}, note))
+			next.ServeHTTP(ww, r)
+
+			if len(rctx.RoutePatterns) == mounted {
+				return // no route matched
+			}
+			pattern := rctx.RoutePattern()
+			action := auditAction(r.Method, strings.TrimPrefix(pattern, root))
+			if action == "" {
+				return
+			}
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			entry := models.NewAuditEntry(models.AuditActorUser, auth.UserID(r.Context()), action, models.AuditOutcomeFor(status))
+			entry.RequestID = middleware.GetReqID(r.Context())
+			entry.Method = r.Method
+			entry.Route = pattern
+			entry.Status = status
+			entry.Detail = note.detail
+			fillAuditTarget(entry, rctx, note)
+
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), auditWriteTimeout)
+			defer cancel()
+			if err := store.CreateAuditEntry(ctx, entry); err != nil {
+				log.Printf("Failed to record audit entry for %s %s: %v", r.Method, pattern, err)
+			}
+		})
+	}
+}
+
+// fillAuditTarget sets an entry's case and target from the handler's note,
+// or else from the route's parameters
+func fillAuditTarget(e *models.AuditEntry, rctx *chi.Context, note *auditNote) {
+	e.CaseID = note.caseID
+	if e.CaseID == nil {
+		if id, err := uuid.Parse(rctx.URLParam("caseId")); err == nil {
+			e.CaseID = &id
+		}
+	}
+
+	switch {
+	case note.targetType != "":
+		e.SetTarget(note.targetType, note.targetID)
+	case rctx.URLParam("userId") != "":
+		e.SetTarget("member", rctx.URLParam("userId"))
+	case rctx.URLParam("jobId") != "":
+		e.SetTarget("job", rctx.URLParam("jobId"))
+	case e.CaseID != nil:
+		e.SetTarget("case", e.CaseID.String())
+	}
+}
+
+// AuditHandler serves case audit logs
+type AuditHandler struct {
+	repo *db.Repository
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(database *db.DB) *AuditHandler {
+	var repo *db.Repository
+	if database != nil {
+		repo = db.NewRepository(database)
+	}
+	return &AuditHandler{repo: repo}
+}
+
+// List handles GET /v1/cases/{caseId}/audit
+// It returns the case's audit log, newest first. ?actor= (a user ID),
+// ?action= and ?outcome= filter entries; ?from= and ?to= take RFC 3339
+// times or dates, with ?to= dates including the whole day; ?limit= and
+// ?cursor= page through the log.
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	caseID, err := uuid.Parse(chi.URLParam(r, "caseId"))
+	if err != nil {
+		BadRequest(w, "Invalid case ID format")
+		return
+	}
+
+	q, err := parseAuditQuery(caseID, r.URL.Query())
+	if err != nil {
+		BadRequest(w, "Invalid query: "+err.Error())
+		return
+	}
+
+	if h.repo == nil {
+		Success(w, http.StatusOK, []interface{}{}, &Meta{Total: 0})
+		return
+	}
+
+	entries, err := h.repo.ListAuditEntries(r.Context(), q)
+	if err != nil {
+		InternalError(w, "Failed to retrieve audit log")
+		return
+	}
+
+	var nextCursor string
+	if len(entries) == q.Limit {
+		nextCursor = entries[len(entries)-1].CreatedAt.Format(time.RFC3339Nano)
+	}
+
+	Success(w, http.StatusOK, entries, &Meta{Cursor: nextCursor})
+}
+
+// parseAuditQuery reads ?actor=, ?action=, ?outcome=, ?from=, ?to=,
+// ?cursor= and ?limit=
+func parseAuditQuery(caseID uuid.UUID, values url.Values) (*models.AuditQuery, error) {
+	q := &models.AuditQuery{
+		CaseID:  caseID,
+		Action:  values.Get("action"),
+		Outcome: models.AuditOutcome(values.Get("outcome")),
+	}
+
+	if s := values.Get("actor"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("actor must be a user ID")
+		}
+		q.ActorID = &id
+	}
+
+	var err error
+	if q.From, err = parseSearchTime(values.Get("from"), false); err != nil {
+		return nil, fmt.Errorf("from %v", err)
+	}
+	if q.To, err = parseSearchTime(values.Get("to"), true); err != nil {
+		return nil, fmt.Errorf("to %v", err)
+	}
+	if s := values.Get("cursor"); s != "" {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("cursor must be an RFC 3339 time")
+		}
+		q.Cursor = &t
+	}
+	if s := values.Get("limit"); s != "" {
+		if q.Limit, err = strconv.Atoi(s); err != nil || q.Limit < 1 {
+			return nil, fmt.Errorf("limit must be between 1 and %d", models.MaxAuditLimit)
+		}
+	}
+
+	q.SetDefaults()
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
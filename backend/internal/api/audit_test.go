@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/auth"
+	"github.com/sherlockos/backend/internal/models"
+)
+
+type fakeAuditStore struct {
+	entries []*models.AuditEntry
+}
+
+func (s *fakeAuditStore) CreateAuditEntry(ctx context.Context, e *models.AuditEntry) error {
+	s.entries = append(s.entries, e)
+	return nil
+}
+
+func TestAudit(t *testing.T) {
+	store := &fakeAuditStore{}
+	user := &auth.User{ID: uuid.New()}
+	caseID, jobID := uuid.New(), uuid.New()
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Route("/v1", func(r chi.Router) {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+			})
+		})
+		r.Use(Audit(store))
+		r.Get("/cases", func(w http.ResponseWriter, r *http.Request) {})
+		r.Post("/cases/{caseId}/jobs", func(w http.ResponseWriter, r *http.Request) {
+			setAuditTarget(r, "job", jobID.String())
+			w.WriteHeader(http.StatusAccepted)
+		})
+		r.Get("/cases/{caseId}/snapshot", func(w http.ResponseWriter, r *http.Request) {
+			Forbidden(w, "no access")
+		})
+		r.Get("/jobs/{jobId}", func(w http.ResponseWriter, r *http.Request) {
+			setAuditCase(r, caseID)
+			NotFound(w, "Job not found")
+		})
+	})
+
+	requests := []struct{ method, path string }{
+		{http.MethodGet, "/v1/cases"},
+		{http.MethodPost, "/v1/cases/" + caseID.String() + "/jobs"},
+		{http.MethodGet, "/v1/cases/" + caseID.String() + "/snapshot"},
+		{http.MethodGet, "/v1/jobs/" + jobID.String()},
+		{http.MethodDelete, "/v1/nowhere"},
+	}
+	for _, req := range requests {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	if len(store.entries) != 3 {
+		t.Fatalf("recorded %d entries, want 3: %+v", len(store.entries), store.entries)
+	}
+
+	created := store.entries[0]
+	if created.Action != "job.create" || created.Outcome != models.AuditOutcomeSuccess || created.Status != http.StatusAccepted {
+		t.Errorf("job creation = %+v", created)
+	}
+	if created.ActorType != models.AuditActorUser || created.ActorID == nil || *created.ActorID != user.ID {
+		t.Errorf("job creation actor = %v %v", created.ActorType, created.ActorID)
+	}
+	if created.CaseID == nil || *created.CaseID != caseID || created.TargetType != "job" || created.TargetID != jobID.String() {
+		t.Errorf("job creation target = %v %s %s", created.CaseID, created.TargetType, created.TargetID)
+	}
+	if created.Route != "/v1/cases/{caseId}/jobs" || created.Method != http.MethodPost || created.RequestID == "" {
+		t.Errorf("job creation request = %s %s %q", created.Method, created.Route, created.RequestID)
+	}
+
+	read := store.entries[1]
+	if read.Action != "scene.read" || read.Outcome != models.AuditOutcomeDenied || read.TargetType != "case" {
+		t.Errorf("denied read = %+v", read)
+	}
+
+	job := store.entries[2]
+	if job.Action != "job.read" || job.Outcome != models.AuditOutcomeFailed || job.CaseID == nil || *job.CaseID != caseID ||
+		job.TargetType != "job" || job.TargetID != jobID.String() {
+		t.Errorf("job read = %+v", job)
+	}
+}
+
+func TestAuditActions_CoverRoutes(t *testing.T) {
+	r := chi.NewRouter()
+	RegisterRoutes(r, nil)
+
+	err := chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if method == http.MethodGet {
+			return nil
+		}
+		pattern := strings.TrimSuffix(strings.ReplaceAll(route, "/*/", "/"), "/")
+		if _, ok := auditActions[method+" "+pattern]; !ok {
+			t.Errorf("%s %s has no audit action", method, pattern)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseAuditQuery(t *testing.T) {
+	caseID := uuid.New()
+	actor := uuid.New()
+
+	q, err := parseAuditQuery(caseID, url.Values{
+		"actor":   {actor.String()},
+		"action":  {"job.create"},
+		"outcome": {"denied"},
+		"from":    {"2026-10-01"},
+		"to":      {"2026-10-18"},
+		"cursor":  {"2026-10-18T09:30:00.123456Z"},
+	})
+	if err != nil {
+		t.Fatalf("parseAuditQuery() error = %v", err)
+	}
+	if q.CaseID != caseID || *q.ActorID != actor || q.Action != "job.create" || q.Outcome != models.AuditOutcomeDenied {
+		t.Errorf("filters = %+v", q)
+	}
+	if q.To.Day() != 19 || q.Cursor.Nanosecond() != 123456000 || q.Limit != models.DefaultAuditLimit {
+		t.Errorf("to = %v, cursor = %v, limit = %d", q.To, q.Cursor, q.Limit)
+	}
+
+	for _, bad := range []url.Values{
+		{"actor": {"someone"}},
+		{"outcome": {"partial"}},
+		{"limit": {"0"}},
+		{"limit": {"1000"}},
+		{"cursor": {"yesterday"}},
+		{"from": {"2026-10-18"}, "to": {"2026-10-01"}},
+	} {
+		if _, err := parseAuditQuery(caseID, bad); err == nil {
+			t.Errorf("parseAuditQuery(%v) should fail", bad)
+		}
+	}
+}
+
+func TestAuditHandler_List_NoDB(t *testing.T) {
+	h := NewAuditHandler(nil)
+	r := chi.NewRouter()
+	r.Get("/v1/cases/{caseId}/audit", h.List)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/cases/"+uuid.New().String()+"/audit?outcome=denied", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/cases/not-a-uuid/audit", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status of an invalid case ID = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+}
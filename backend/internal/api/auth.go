@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/auth"
+	"github.com/sherlockos/backend/internal/db"
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// Authenticate rejects requests without a valid bearer token and carries the
+// token's user into the request context
+func Authenticate(v auth.Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="sherlockos"`)
+				Unauthorized(w, "Missing bearer token")
+				return
+			}
+
+			user, err := v.Verify(r.Context(), token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="sherlockos", error="invalid_token"`)
+				if errors.Is(err, auth.ErrExpiredToken) {
+					Unauthorized(w, "Token expired")
+				} else {
+					Unauthorized(w, "Invalid token")
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+		})
+	}
+}
+
+// bearerToken reads the token from the Authorization header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// RequireCaseRole only lets members with at least the given role through to
+// routes under /cases/{caseId}. Without a signed-in user (authentication
+// disabled) or a database, every request is let through.
+func RequireCaseRole(repo *db.Repository, required models.CaseRole) func(http.Handler) http.Handler {
+	return requireCaseRole(rolesOf(repo), required)
+}
+
+// caseRoles looks up users' roles on cases
+type caseRoles interface {
+	GetCaseRole(ctx context.Context, caseID, userID uuid.UUID) (models.CaseRole, error)
+}
+
+// rolesOf returns the repository's case roles, or nil without a database
+func rolesOf(repo *db.Repository) caseRoles {
+	if repo == nil {
+		return nil
+	}
+	return repo
+}
+
+func requireCaseRole(roles caseRoles, required models.CaseRole) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			caseID, err := uuid.Parse(chi.URLParam(r, "caseId"))
+			if err != nil {
+				BadRequest(w, "Invalid case ID format")
+				return
+			}
+			if !authorizeCase(w, r, roles, caseID, required, "Case not found") {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// authorizeCase checks the signed-in user's role on a case. Users who aren't
+// members get a 404 with the notFound message, so that they can't tell
+// which cases exist; members whose role is too low get a 403.
+func authorizeCase(w http.ResponseWriter, r *http.Request, roles caseRoles, caseID uuid.UUID, required models.CaseRole, notFound string) bool {
+	user := auth.FromContext(r.Context())
+	if user == nil || roles == nil {
+		return true
+	}
+
+	role, err := roles.GetCaseRole(r.Context(), caseID, user.ID)
+	if err != nil {
+		log.Printf("Failed to check access to case %s: %v", caseID, err)
+		InternalError(w, "Failed to check case access")
+		return false
+	}
+	if role == "" {
+		NotFound(w, notFound)
+		return false
+	}
+	if !role.Includes(required) {
+		Forbidden(w, "This requires the "+string(required)+" role on the case")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/auth"
+	"github.com/sherlockos/backend/internal/models"
+)
+
+func TestAuthenticate(t *testing.T) {
+	issuer := auth.NewLocalIssuer("test-secret")
+	user := auth.User{ID: uuid.New(), Email: "lead@example.com"}
+	token, err := issuer.Issue(user, time.Hour)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	expired, _ := issuer.Issue(user, -time.Hour)
+
+	var seen *auth.User
+	handler := Authenticate(issuer.Verifier())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = auth.FromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantErr    string
+	}{
+		{name: "valid token", header: "Bearer " + token, wantStatus: http.StatusNoContent},
+		{name: "lowercase scheme", header: "bearer " + token, wantStatus: http.StatusNoContent},
+		{name: "no header", header: "", wantStatus: http.StatusUnauthorized, wantErr: "Missing bearer token"},
+		{name: "basic auth", header: "Basic dXNlcjpwYXNz", wantStatus: http.StatusUnauthorized, wantErr: "Missing bearer token"},
+		{name: "garbage token", header: "Bearer not.a.token", wantStatus: http.StatusUnauthorized, wantErr: "Invalid token"},
+		{name: "expired token", header: "Bearer " + expired, wantStatus: http.StatusUnauthorized, wantErr: "Token expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = nil
+			req := httptest.NewRequest(http.MethodGet, "/v1/cases", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if errMsg := getErrorMessage(w.Body.Bytes()); errMsg != tt.wantErr {
+				t.Errorf("error = %v, want %v", errMsg, tt.wantErr)
+			}
+			if tt.wantStatus == http.StatusNoContent && (seen == nil || seen.ID != user.ID) {
+				t.Errorf("user in context = %+v, want %s", seen, user.ID)
+			}
+		})
+	}
+}
+
+func TestRegisterRoutes_RequiresToken(t *testing.T) {
+	issuer := auth.NewLocalIssuer("test-secret")
+	r := chi.NewRouter()
+	RegisterRoutesWithOptions(r, nil, RouteOptions{Auth: issuer.Verifier()})
+
+	req := httptest.NewRequest(http.MethodGet, "/cases/"+testCaseID, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("without a token status = %v, want 401", w.Code)
+	}
+
+	token, _ := issuer.Issue(auth.User{ID: uuid.New()}, time.Hour)
+	req = httptest.NewRequest(http.MethodGet, "/cases/"+testCaseID, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// Without a database there are no memberships to check
+	if w.Code != http.StatusNotFound {
+		t.Errorf("with a token status = %v, want 404", w.Code)
+	}
+}
+
+func TestRequireCaseRole_InvalidCaseID(t *testing.T) {
+	r := chi.NewRouter()
+	r.With(RequireCaseRole(nil, models.CaseRoleViewer)).Get("/cases/{caseId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	for _, tt := range []struct {
+		caseID     string
+		wantStatus int
+	}{
+		{caseID: "case_123", wantStatus: http.StatusBadRequest},
+		{caseID: testCaseID, wantStatus: http.StatusNoContent},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/cases/"+tt.caseID, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.wantStatus {
+			t.Errorf("case %s status = %v, want %v", tt.caseID, w.Code, tt.wantStatus)
+		}
+	}
+}
+
+// memberStore keeps case memberships in memory
+type memberStore map[uuid.UUID]map[uuid.UUID]models.CaseRole
+
+func (s memberStore) GetCaseRole(ctx context.Context, caseID, userID uuid.UUID) (models.CaseRole, error) {
+	return s[caseID][userID], nil
+}
+
+func TestRequireCaseRole_OrphanCase(t *testing.T) {
+	issuer := auth.NewLocalIssuer("test-secret")
+	lead, other := uuid.New(), uuid.New()
+	leadToken, _ := issuer.Issue(auth.User{ID: lead}, time.Hour)
+	otherToken, _ := issuer.Issue(auth.User{ID: other}, time.Hour)
+
+	// A case from before authentication has no members until
+	// AUTH_BOOTSTRAP_LEAD leads it
+	orphan, led := uuid.New(), uuid.New()
+	store := memberStore{led: {lead: models.CaseRoleLead}}
+
+	r := chi.NewRouter()
+	r.Use(Authenticate(issuer.Verifier()))
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	r.With(requireCaseRole(store, models.CaseRoleViewer)).Get("/cases/{caseId}", ok)
+	r.With(requireCaseRole(store, models.CaseRoleLead)).Patch("/cases/{caseId}", ok)
+
+	for _, tt := range []struct {
+		name   string
+		method string
+		caseID uuid.UUID
+		token  string
+		want   int
+	}{
+		{"orphan case", http.MethodGet, orphan, leadToken, http.StatusNotFound},
+		{"lead reads", http.MethodGet, led, leadToken, http.StatusNoContent},
+		{"lead updates", http.MethodPatch, led, leadToken, http.StatusNoContent},
+		{"other user", http.MethodGet, led, otherToken, http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(tt.method, "/cases/"+tt.caseID.String(), nil)
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %v, want %v", tt.name, w.Code, tt.want)
+		}
+	}
+}
+
+func TestMemberHandler_Set(t *testing.T) {
+	handler := NewMemberHandler(nil)
+
+	r := chi.NewRouter()
+	r.Put("/v1/cases/{caseId}/members/{userId}", handler.Set)
+
+	tests := []struct {
+		name       string
+		userID     string
+		body       string
+		wantStatus int
+		wantErr    string
+	}{
+		{
+			name:       "invalid user ID",
+			userID:     "someone",
+			body:       `{"role":"viewer"}`,
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Invalid user ID format",
+		},
+		{
+			name:       "unknown role",
+			userID:     testCommitID,
+			body:       `{"role":"owner"}`,
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Invalid member: role must be lead, investigator or viewer",
+		},
+		{
+			name:       "valid but not found (DB not connected)",
+			userID:     testCommitID,
+			body:       `{"role":"investigator"}`,
+			wantStatus: http.StatusNotFound,
+			wantErr:    "Case not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/v1/cases/"+testCaseID+"/members/"+tt.userID, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Set() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if errMsg := getErrorMessage(w.Body.Bytes()); errMsg != tt.wantErr {
+				t.Errorf("Set() error = %v, want %v", errMsg, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/auth"
+	"github.com/sherlockos/backend/internal/bundle"
+	"github.com/sherlockos/backend/internal/clients"
+	"github.com/sherlockos/backend/internal/db"
+)
+
+// maxBundleSize limits the size of an uploaded bundle archive
+const maxBundleSize = 512 << 20
+
+// BundleHandler handles case bundle imports
+type BundleHandler struct {
+	repo    *db.Repository
+	storage clients.StorageClient
+}
+
+// NewBundleHandler creates a new bundle handler
+func NewBundleHandler(database *db.DB, storage clients.StorageClient) *BundleHandler {
+	var repo *db.Repository
+	if database != nil {
+		repo = db.NewRepository(database)
+	}
+	return &BundleHandler{repo: repo, storage: storage}
+}
+
+// Import handles POST /v1/cases/import
+// The body is a zip produced by a bundle export. The archive is verified
+// against its manifest, given fresh IDs and written as a new case; the
+// response maps the bundle's IDs and storage keys to the new ones.
+func (h *BundleHandler) Import(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBundleSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			Error(w, http.StatusRequestEntityTooLarge, ErrInvalidRequest,
+				fmt.Sprintf("Bundle is larger than %d bytes", maxBundleSize), nil)
+			return
+		}
+		BadRequest(w, "Invalid request body")
+		return
+	}
+
+	b, manifest, err := bundle.Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		BadRequest(w, "Invalid bundle: "+err.Error())
+		return
+	}
+	if len(b.Objects) > 0 && h.storage == nil {
+		ServiceUnavailable(w, "Storage not configured")
+		return
+	}
+
+	imported, remapping, err := b.Remap(uuid.New())
+	if err != nil {
+		BadRequest(w, "Invalid bundle: "+err.Error())
+		return
+	}
+
+	// Files go up first so the case never references one that isn't stored
+	var uploaded []string
+	for _, obj := range imported.Objects {
+		if err := h.storage.Upload(r.Context(), assetBucket, obj.StorageKey, obj.Data, obj.ContentType); err != nil {
+			h.removeObjects(r.Context(), uploaded)
+			InternalError(w, "Failed to store bundle files")
+			return
+		}
+		uploaded = append(uploaded, obj.StorageKey)
+	}
+
+	if h.repo != nil {
+		err := h.repo.ImportCase(r.Context(), &db.CaseImport{
+			Case:     &imported.Case,
+			Branches: imported.Branches,
+			Commits:  imported.Commits,
+			Snapshot: imported.Snapshot,
+			Profile:  imported.Profile,
+			Assets:   imported.Assets,
+			Lead:     auth.UserID(r.Context()),
+		})
+		if err != nil {
+			h.removeObjects(r.Context(), uploaded)
+			InternalError(w, "Failed to import case")
+			return
+		}
+	}
+
+	var missing []string
+	for _, key := range manifest.MissingObjects {
+		if to, ok := remapping.Keys[key]; ok {
+			key = to
+		}
+		missing = append(missing, key)
+	}
+
+	setAuditCase(r, imported.Case.ID)
+	setAuditDetail(r, "imported from case "+manifest.CaseID.String())
+	Success(w, http.StatusCreated, map[string]interface{}{
+		"case_id":         imported.Case.ID,
+		"source_case_id":  manifest.CaseID,
+		"commits":         len(imported.Commits),
+		"branches":        len(imported.Branches),
+		"assets":          len(imported.Assets),
+		"files":           len(imported.Objects),
+		"missing_objects": missing,
+		"remapping":       remapping,
+	}, nil)
+}
+
+// removeObjects deletes files stored by an import that didn't complete
+func (h *BundleHandler) removeObjects(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := h.storage.Delete(ctx, assetBucket, key); err != nil {
+			fmt.Printf("Warning: failed to remove %s after a failed import: %v\n", key, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/bundle"
+	"github.com/sherlockos/backend/internal/clients"
+	"github.com/sherlockos/backend/internal/models"
+)
+
+func testBundleArchive(t *testing.T) []byte {
+	t.Helper()
+	caseID := uuid.New()
+	asset := models.NewAsset(caseID, models.AssetKindScanImage, "cases/"+caseID.String()+"/scans/a.png")
+	commit := models.Commit{ID: uuid.New(), CaseID: caseID, Type: models.CommitTypeUploadScan, Summary: "Scans",
+		Payload: []byte(`{"storage_key":"` + asset.StorageKey + `"}`), CreatedAt: time.Now()}
+	b := &bundle.Bundle{
+		Case:    models.Case{ID: caseID, Title: "Imported", CreatedAt: time.Now()},
+		Commits: []models.Commit{commit},
+		Assets:  []models.Asset{*asset},
+		Objects: []bundle.Object{
+			{StorageKey: asset.StorageKey, ContentType: "image/png", Data: []byte("scan")},
+			{StorageKey: "portraits/p.png", ContentType: "image/png", Data: []byte("portrait")},
+		},
+	}
+	var buf bytes.Buffer
+	if err := b.Write(&buf, time.Now(), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBundleHandler_Import(t *testing.T) {
+	archive := testBundleArchive(t)
+
+	tests := []struct {
+		name        string
+		body        []byte
+		failUpload  bool
+		noStorage   bool
+		wantStatus  int
+		wantErr     string
+		wantDeleted int
+	}{
+		{
+			name:       "not a zip",
+			body:       []byte("not a zip"),
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Invalid bundle: not a zip archive: zip: not a valid zip file",
+		},
+		{
+			name:       "storage not configured",
+			body:       archive,
+			noStorage:  true,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:        "upload fails",
+			body:        archive,
+			failUpload:  true,
+			wantStatus:  http.StatusInternalServerError,
+			wantDeleted: 1,
+		},
+		{
+			name:       "imported",
+			body:       archive,
+			wantStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var uploaded, deleted []string
+			storage := &clients.MockStorageClient{
+				UploadFunc: func(ctx context.Context, bucket, key string, data []byte, contentType string) error {
+					if tt.failUpload && len(uploaded) == 1 {
+						return errors.New("storage unavailable")
+					}
+					uploaded = append(uploaded, key)
+					return nil
+				},
+				DeleteFunc: func(ctx context.Context, bucket, key string) error {
+					deleted = append(deleted, key)
+					return nil
+				},
+			}
+			handler := NewBundleHandler(nil, storage)
+			if tt.noStorage {
+				handler = NewBundleHandler(nil, nil)
+			}
+			r := chi.NewRouter()
+			r.Post("/v1/cases/import", handler.Import)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/cases/import", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Import() status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantErr != "" {
+				if msg := getErrorMessage(w.Body.Bytes()); msg != tt.wantErr {
+					t.Errorf("Import() error = %q, want %q", msg, tt.wantErr)
+				}
+			}
+			if len(deleted) != tt.wantDeleted {
+				t.Errorf("Import() deleted %v, want %d files", deleted, tt.wantDeleted)
+			}
+
+			if tt.wantStatus == http.StatusCreated {
+				data := getData(w.Body.Bytes())
+				caseID, _ := data["case_id"].(string)
+				if caseID == "" || caseID == data["source_case_id"] || data["commits"] != float64(1) || data["files"] != float64(2) {
+					t.Errorf("Import() data = %v", data)
+				}
+				prefix := "cases/" + caseID + "/"
+				if len(uploaded) != 2 || uploaded[0] != prefix+"scans/a.png" || uploaded[1] != prefix+"imported/portraits/p.png" {
+					t.Errorf("Import() uploaded %v", uploaded)
+				}
+				remapping, _ := data["remapping"].(map[string]interface{})
+				if keys, _ := remapping["storage_keys"].(map[string]interface{}); len(keys) != 2 {
+					t.Errorf("Import() remapping = %v", remapping)
+				}
+			}
+		})
+	}
+}
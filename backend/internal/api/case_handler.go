@@ -2,6 +2,8 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -9,15 +11,19 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/sherlockos/backend/internal/auth"
 	"github.com/sherlockos/backend/internal/db"
+	"github.com/sherlockos/backend/internal/lifecycle"
 	"github.com/sherlockos/backend/internal/models"
 	"github.com/sherlockos/backend/internal/queue"
 )
 
 // CaseHandler handles case-related API requests
 type CaseHandler struct {
-	repo  *db.Repository
-	queue queue.JobQueue
+	repo    *db.Repository
+	queue   queue.JobQueue
+	purger  *lifecycle.Purger
+	budgets models.Budgets
 }
 
 // NewCaseHandler creates a new case handler
@@ -38,6 +44,73 @@ func NewCaseHandlerWithQueue(database *db.DB, q queue.JobQueue) *CaseHandler {
 	return &CaseHandler{repo: repo, queue: q}
 }
 
+// NewCaseHandlerWithLifecycle creates a new case handler that can purge
+// deleted cases
+func NewCaseHandlerWithLifecycle(database *db.DB, q queue.JobQueue, purger *lifecycle.Purger) *CaseHandler {
+	h := NewCaseHandlerWithQueue(database, q)
+	h.purger = purger
+	return h
+}
+
+// NewCaseHandlerWithBudgets creates a new case handler that can purge deleted
+// cases and refuses witness statements whose profile job would take spending
+// past budgets
+func NewCaseHandlerWithBudgets(database *db.DB, q queue.JobQueue, purger *lifecycle.Purger, budgets models.Budgets) *CaseHandler {
+	h := NewCaseHandlerWithLifecycle(database, q, purger)
+	h.budgets = budgets
+	return h
+}
+
+// retention returns how long deleted cases are kept before being purged
+func (h *CaseHandler) retention() time.Duration {
+	if h.purger != nil {
+		return h.purger.Retention()
+	}
+	return models.DefaultCaseRetention
+}
+
+// caseResponse converts a case to its response format
+func caseResponse(c *models.Case, retention time.Duration) map[string]interface{} {
+	resp := map[string]interface{}{
+		"id":          c.ID.String(),
+		"title":       c.Title,
+		"description": c.Description,
+		"status":      c.Status,
+		"created_at":  c.CreatedAt.Format(time.RFC3339),
+		"updated_at":  c.UpdatedAt.Format(time.RFC3339),
+	}
+	if c.DeletedAt != nil {
+		resp["deleted_at"] = c.DeletedAt.Format(time.RFC3339)
+		resp["purge_after"] = c.PurgeAfter(retention).Format(time.RFC3339)
+	}
+	return resp
+}
+
+// requireWritableCase writes an error response and returns false unless the
+// case exists and accepts new jobs and commits. Without a database there is
+// nothing to check.
+func requireWritableCase(w http.ResponseWriter, r *http.Request, repo *db.Repository, caseID uuid.UUID) bool {
+	if repo == nil {
+		return true
+	}
+	c, err := repo.GetCase(r.Context(), caseID)
+	if err != nil {
+		InternalError(w, "Failed to retrieve case")
+		return false
+	}
+	if c == nil {
+		NotFound(w, "Case not found")
+		return false
+	}
+	if !c.AcceptsChanges() {
+		Conflict(w, "Case is "+string(c.Status)+" and does not accept changes", map[string]interface{}{
+			"status": c.Status,
+		})
+		return false
+	}
+	return true
+}
+
 // CreateCaseRequest represents the request body for creating a case
 type CreateCaseRequest struct {
 	Title       string `json:"title"`
@@ -62,8 +135,9 @@ func (h *CaseHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create case model
+	// Create case model; its creator becomes its lead
 	c := models.NewCase(req.Title, req.Description)
+	c.CreatedBy = auth.UserID(r.Context())
 
 	// Save to database if repo is available
 	if h.repo != nil {
@@ -78,22 +152,30 @@ func (h *CaseHandler) Create(w http.ResponseWriter, r *http.Request) {
 	_ = snapshot // Will be created with first commit
 	}
 
-	Success(w, http.StatusCreated, map[string]interface{}{
-		"id":          c.ID.String(),
-		"title":       c.Title,
-		"description": c.Description,
-		"created_at":  c.CreatedAt.Format(time.RFC3339),
-	}, nil)
+	setAuditCase(r, c.ID)
+	Success(w, http.StatusCreated, caseResponse(c, h.retention()), nil)
 }
 
 // List handles GET /v1/cases
+// ?status= keeps only cases with that status. Deleted cases are not listed,
+// and signed-in users only see cases they are a member of.
 func (h *CaseHandler) List(w http.ResponseWriter, r *http.Request) {
+	var status *models.CaseStatus
+	if s := r.URL.Query().Get("status"); s != "" {
+		st := models.CaseStatus(s)
+		if !st.IsValid() {
+			BadRequest(w, "Invalid status: must be open, under_review, closed or archived")
+			return
+		}
+		status = &st
+	}
+
 	if h.repo == nil {
 		Success(w, http.StatusOK, []interface{}{}, nil)
 		return
 	}
 
-	cases, err := h.repo.ListCases(r.Context(), 100, nil)
+	cases, err := h.repo.ListCases(r.Context(), 100, nil, status, auth.UserID(r.Context()))
 	if err != nil {
 		InternalError(w, "Failed to list cases")
 		return
@@ -102,12 +184,7 @@ func (h *CaseHandler) List(w http.ResponseWriter, r *http.Request) {
 	// Convert to response format
 	result := make([]map[string]interface{}, 0, len(cases))
 	for _, c := range cases {
-		result = append(result, map[string]interface{}{
-			"id":          c.ID.String(),
-			"title":       c.Title,
-			"description": c.Description,
-			"created_at":  c.CreatedAt.Format(time.RFC3339),
-		})
+		result = append(result, caseResponse(c, h.retention()))
 	}
 
 	Success(w, http.StatusOK, result, nil)
@@ -142,14 +219,193 @@ func (h *CaseHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	Success(w, http.StatusOK, caseResponse(c, h.retention()), nil)
+}
+
+// Update handles PATCH /v1/cases/{caseId}
+// It changes the title, description or status of a case that isn't deleted.
+func (h *CaseHandler) Update(w http.ResponseWriter, r *http.Request) {
+	caseIDStr := chi.URLParam(r, "caseId")
+	if caseIDStr == "" {
+		BadRequest(w, "Case ID is required")
+		return
+	}
+
+	caseID, err := uuid.Parse(caseIDStr)
+	if err != nil {
+		BadRequest(w, "Invalid case ID format")
+		return
+	}
+
+	var req models.CaseUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		BadRequest(w, "Invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		BadRequest(w, "Invalid update: "+err.Error())
+		return
+	}
+
+	if h.repo == nil {
+		NotFound(w, "Case not found")
+		return
+	}
+
+	c, err := h.repo.GetCase(r.Context(), caseID)
+	if err != nil {
+		InternalError(w, "Failed to retrieve case")
+		return
+	}
+	if c == nil {
+		NotFound(w, "Case not found")
+		return
+	}
+
+	req.Apply(c)
+	ok, err := h.repo.UpdateCase(r.Context(), c)
+	if err != nil {
+		InternalError(w, "Failed to update case")
+		return
+	}
+	if !ok {
+		NotFound(w, "Case not found")
+		return
+	}
+
+	Success(w, http.StatusOK, caseResponse(c, h.retention()), nil)
+}
+
+// Delete handles DELETE /v1/cases/{caseId}
+// The case is hidden and can be restored until its retention window passes,
+// after which it is purged with its stored files. ?purge=true purges it
+// right away.
+func (h *CaseHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	caseIDStr := chi.URLParam(r, "caseId")
+	if caseIDStr == "" {
+		BadRequest(w, "Case ID is required")
+		return
+	}
+
+	caseID, err := uuid.Parse(caseIDStr)
+	if err != nil {
+		BadRequest(w, "Invalid case ID format")
+		return
+	}
+
+	purge := false
+	if s := r.URL.Query().Get("purge"); s != "" {
+		if purge, err = strconv.ParseBool(s); err != nil {
+			BadRequest(w, "Invalid purge value: must be true or false")
+			return
+		}
+	}
+
+	if h.repo == nil {
+		NotFound(w, "Case not found")
+		return
+	}
+
+	if purge {
+		h.purge(w, r, caseID)
+		return
+	}
+
+	deletedAt, err := h.repo.SoftDeleteCase(r.Context(), caseID)
+	if err != nil {
+		InternalError(w, "Failed to delete case")
+		return
+	}
+	if deletedAt == nil {
+		NotFound(w, "Case not found")
+		return
+	}
+
 	Success(w, http.StatusOK, map[string]interface{}{
-		"id":          c.ID.String(),
-		"title":       c.Title,
-		"description": c.Description,
-		"created_at":  c.CreatedAt.Format(time.RFC3339),
+		"id":          caseID.String(),
+		"deleted_at":  deletedAt.Format(time.RFC3339),
+		"purge_after": deletedAt.Add(h.retention()).Format(time.RFC3339),
+	}, nil)
+}
+
+// purge permanently deletes a case and its stored files
+func (h *CaseHandler) purge(w http.ResponseWriter, r *http.Request, caseID uuid.UUID) {
+	if h.purger == nil {
+		ServiceUnavailable(w, "Case purging is not configured")
+		return
+	}
+
+	c, err := h.repo.GetCaseWithDeleted(r.Context(), caseID)
+	if err != nil {
+		InternalError(w, "Failed to retrieve case")
+		return
+	}
+	if c == nil {
+		NotFound(w, "Case not found")
+		return
+	}
+
+	removed, err := h.purger.Purge(r.Context(), caseID)
+	if errors.Is(err, lifecycle.ErrNoStorage) {
+		ServiceUnavailable(w, "Storage not configured")
+		return
+	}
+	if err != nil {
+		InternalError(w, "Failed to purge case")
+		return
+	}
+
+	setAuditDetail(r, "purged")
+	Success(w, http.StatusOK, map[string]interface{}{
+		"id":              caseID.String(),
+		"purged":          true,
+		"objects_deleted": removed,
 	}, nil)
 }
 
+// Restore handles POST /v1/cases/{caseId}/restore
+// It brings back a deleted case that hasn't been purged yet.
+func (h *CaseHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	caseIDStr := chi.URLParam(r, "caseId")
+	if caseIDStr == "" {
+		BadRequest(w, "Case ID is required")
+		return
+	}
+
+	caseID, err := uuid.Parse(caseIDStr)
+	if err != nil {
+		BadRequest(w, "Invalid case ID format")
+		return
+	}
+
+	if h.repo == nil {
+		NotFound(w, "Case not found")
+		return
+	}
+
+	c, err := h.repo.GetCaseWithDeleted(r.Context(), caseID)
+	if err != nil {
+		InternalError(w, "Failed to retrieve case")
+		return
+	}
+	if c == nil {
+		NotFound(w, "Case not found")
+		return
+	}
+	if c.DeletedAt == nil {
+		Conflict(w, "Case is not deleted", nil)
+		return
+	}
+
+	if _, err := h.repo.RestoreCase(r.Context(), caseID); err != nil {
+		InternalError(w, "Failed to restore case")
+		return
+	}
+	c.DeletedAt = nil
+
+	Success(w, http.StatusOK, caseResponse(c, h.retention()), nil)
+}
+
 // GetSnapshot handles GET /v1/cases/{caseId}/snapshot
 func (h *CaseHandler) GetSnapshot(w http.ResponseWriter, r *http.Request) {
 	caseIDStr := chi.URLParam(r, "caseId")
@@ -301,6 +557,10 @@ func (h *CaseHandler) CreateUploadIntent(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if !requireWritableCase(w, r, h.repo, caseID) {
+		return
+	}
+
 	// Generate batch ID
 	batchID := uuid.New().String()
 
@@ -367,6 +627,10 @@ func (h *CaseHandler) SubmitWitnessStatements(w http.ResponseWriter, r *http.Req
 		}
 	}
 
+	if !requireWritableCase(w, r, h.repo, caseID) {
+		return
+	}
+
 	// Create commit for witness statements
 	payload := map[string]interface{}{
 		"statements": req.Statements,
@@ -377,6 +641,7 @@ func (h *CaseHandler) SubmitWitnessStatements(w http.ResponseWriter, r *http.Req
 		InternalError(w, "Failed to create commit")
 		return
 	}
+	commit.CreatedBy = auth.UserID(r.Context())
 
 	// Get parent commit
 	if h.repo != nil {
@@ -385,18 +650,31 @@ func (h *CaseHandler) SubmitWitnessStatements(w http.ResponseWriter, r *http.Req
 			commit.SetParent(latestCommit.ID)
 		}
 
-		if err := h.repo.CreateCommit(r.Context(), commit); err != nil {
-			InternalError(w, "Failed to save commit")
-			return
-		}
-
-		// Create profile job
+		// Create profile job first, so that statements aren't saved when
+		// profiling them would go over budget
 		profileJob, _ := models.NewJob(caseID, models.JobTypeProfile, map[string]interface{}{
 			"case_id":    caseID.String(),
 			"statements": req.Statements,
 			"commit_id":  commit.ID.String(),
 		})
-		h.repo.CreateJob(r.Context(), profileJob)
+		profileJob.CreatedBy = commit.CreatedBy
+		if !saveJobWithinBudget(w, r, h.repo, h.budgets, profileJob, "Failed to create profile job") {
+			return
+		}
+
+		if err := h.repo.CreateCommit(r.Context(), commit); err != nil {
+			// Without its statements the job has nothing to do
+			if jobErr := h.repo.UpdateJobError(r.Context(), profileJob.ID, "witness statements were not saved"); jobErr != nil {
+				log.Printf("Failed to fail profile job %s: %v", profileJob.ID, jobErr)
+			}
+			if errors.Is(err, db.ErrCaseClosed) {
+				Conflict(w, "Case does not accept changes", nil)
+				return
+			}
+			InternalError(w, "Failed to save commit")
+			return
+		}
+		setAuditTarget(r, "commit", commit.ID.String())
 
 		// Enqueue profile job for processing
 		if h.queue != nil {
@@ -454,6 +732,10 @@ func (h *CaseHandler) CreateBranch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !requireWritableCase(w, r, h.repo, caseID) {
+		return
+	}
+
 	// Parse base commit ID
 	var baseCommitID uuid.UUID
 	if req.BaseCommitID != "" {
@@ -480,6 +762,7 @@ func (h *CaseHandler) CreateBranch(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	setAuditTarget(r, "branch", branch.ID.String())
 	Success(w, http.StatusCreated, map[string]interface{}{
 		"id":             branch.ID.String(),
 		"name":           branch.Name,
@@ -7,8 +7,11 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+
+	"github.com/sherlockos/backend/internal/models"
 )
 
 const testCaseID = "550e8400-e29b-41d4-a716-446655440000"
@@ -177,6 +180,180 @@ func TestCaseHandler_Get(t *testing.T) {
 	}
 }
 
+func TestCaseHandler_List_Status(t *testing.T) {
+	handler := NewCaseHandler(nil)
+
+	for _, tt := range []struct {
+		query      string
+		wantStatus int
+	}{
+		{query: "", wantStatus: http.StatusOK},
+		{query: "?status=closed", wantStatus: http.StatusOK},
+		{query: "?status=deleted", wantStatus: http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/cases"+tt.query, nil)
+		w := httptest.NewRecorder()
+		handler.List(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("List(%q) status = %v, want %v", tt.query, w.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestCaseHandler_Update(t *testing.T) {
+	handler := NewCaseHandler(nil)
+
+	r := chi.NewRouter()
+	r.Patch("/v1/cases/{caseId}", handler.Update)
+
+	tests := []struct {
+		name       string
+		caseID     string
+		body       string
+		wantStatus int
+		wantErr    string
+	}{
+		{
+			name:       "invalid UUID format",
+			caseID:     "case_123",
+			body:       `{"title":"Renamed"}`,
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Invalid case ID format",
+		},
+		{
+			name:       "invalid JSON",
+			caseID:     testCaseID,
+			body:       "not json",
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Invalid request body",
+		},
+		{
+			name:       "empty update",
+			caseID:     testCaseID,
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Invalid update: nothing to update",
+		},
+		{
+			name:       "unknown status",
+			caseID:     testCaseID,
+			body:       `{"status":"solved"}`,
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Invalid update: status must be open, under_review, closed or archived",
+		},
+		{
+			name:       "valid update but not found (DB not connected)",
+			caseID:     testCaseID,
+			body:       `{"status":"closed"}`,
+			wantStatus: http.StatusNotFound,
+			wantErr:    "Case not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/v1/cases/"+tt.caseID, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Update() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if errMsg := getErrorMessage(w.Body.Bytes()); errMsg != tt.wantErr {
+				t.Errorf("Update() error = %v, want %v", errMsg, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCaseHandler_DeleteAndRestore(t *testing.T) {
+	handler := NewCaseHandler(nil)
+
+	r := chi.NewRouter()
+	r.Delete("/v1/cases/{caseId}", handler.Delete)
+	r.Post("/v1/cases/{caseId}/restore", handler.Restore)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantErr    string
+	}{
+		{
+			name:       "delete with invalid UUID",
+			method:     http.MethodDelete,
+			path:       "/v1/cases/case_123",
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Invalid case ID format",
+		},
+		{
+			name:       "delete with invalid purge flag",
+			method:     http.MethodDelete,
+			path:       "/v1/cases/" + testCaseID + "?purge=soon",
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Invalid purge value: must be true or false",
+		},
+		{
+			name:       "delete not found (DB not connected)",
+			method:     http.MethodDelete,
+			path:       "/v1/cases/" + testCaseID,
+			wantStatus: http.StatusNotFound,
+			wantErr:    "Case not found",
+		},
+		{
+			name:       "restore with invalid UUID",
+			method:     http.MethodPost,
+			path:       "/v1/cases/case_123/restore",
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Invalid case ID format",
+		},
+		{
+			name:       "restore not found (DB not connected)",
+			method:     http.MethodPost,
+			path:       "/v1/cases/" + testCaseID + "/restore",
+			wantStatus: http.StatusNotFound,
+			wantErr:    "Case not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if errMsg := getErrorMessage(w.Body.Bytes()); errMsg != tt.wantErr {
+				t.Errorf("error = %v, want %v", errMsg, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCaseResponse(t *testing.T) {
+	c := models.NewCase("Warehouse break-in", "")
+	resp := caseResponse(c, models.DefaultCaseRetention)
+	if resp["status"] != models.CaseStatusOpen {
+		t.Errorf("status = %v, want open", resp["status"])
+	}
+	if _, ok := resp["deleted_at"]; ok {
+		t.Error("deleted_at set for a case that isn't deleted")
+	}
+
+	deletedAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	c.DeletedAt = &deletedAt
+	resp = caseResponse(c, models.DefaultCaseRetention)
+	if resp["purge_after"] != "2026-10-31T00:00:00Z" {
+		t.Errorf("purge_after = %v, want 2026-10-31T00:00:00Z", resp["purge_after"])
+	}
+}
+
 func TestCaseHandler_GetSnapshot(t *testing.T) {
 	handler := NewCaseHandler(nil)
 
@@ -2,7 +2,9 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"time"
@@ -10,6 +12,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/sherlockos/backend/internal/auth"
 	"github.com/sherlockos/backend/internal/db"
 	"github.com/sherlockos/backend/internal/models"
 	"github.com/sherlockos/backend/internal/queue"
@@ -18,8 +21,9 @@ import (
 
 // JobHandler handles job-related API requests
 type JobHandler struct {
-	repo  *db.Repository
-	queue queue.JobQueue
+	repo    *db.Repository
+	queue   queue.JobQueue
+	budgets models.Budgets
 }
 
 // NewJobHandler creates a new job handler
@@ -40,6 +44,14 @@ func NewJobHandlerWithQueue(database *db.DB, q queue.JobQueue) *JobHandler {
 	return &JobHandler{repo: repo, queue: q}
 }
 
+// NewJobHandlerWithBudgets creates a new job handler that refuses jobs whose
+// estimated cost would take spending past budgets
+func NewJobHandlerWithBudgets(database *db.DB, q queue.JobQueue, budgets models.Budgets) *JobHandler {
+	h := NewJobHandlerWithQueue(database, q)
+	h.budgets = budgets
+	return h
+}
+
 // CreateJobRequest represents the request body for creating a job
 type CreateJobRequest struct {
 	Type  models.JobType         `json:"type"`
@@ -75,6 +87,10 @@ func (h *JobHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !requireWritableCase(w, r, h.repo, caseID) {
+		return
+	}
+
 	// Check if a worker is available for this job type
 	registry := workers.GetGlobalRegistry()
 	if !registry.IsAvailable(req.Type) {
@@ -86,15 +102,13 @@ func (h *JobHandler) Create(w http.ResponseWriter, r *http.Request) {
 	// Check idempotency key for existing job
 	if h.repo != nil && idempotencyKey != "" {
 		existingJob, _ := h.repo.GetJobByIdempotencyKey(r.Context(), idempotencyKey)
+		if existingJob != nil && existingJob.CaseID != caseID {
+			Conflict(w, "Idempotency key already used for another case", nil)
+			return
+		}
 		if existingJob != nil {
 			// Return existing job
-			Success(w, http.StatusOK, map[string]interface{}{
-				"job_id":     existingJob.ID.String(),
-				"type":       existingJob.Type,
-				"status":     existingJob.Status,
-				"progress":   existingJob.Progress,
-				"created_at": existingJob.CreatedAt.Format(time.RFC3339),
-			}, nil)
+			Success(w, http.StatusOK, jobSummary(existingJob), nil)
 			return
 		}
 	}
@@ -113,16 +127,18 @@ func (h *JobHandler) Create(w http.ResponseWriter, r *http.Request) {
 		InternalError(w, "Failed to create job")
 		return
 	}
+	if _, err := models.EstimateJobCost(job.Type, job.Input); err != nil {
+		BadRequest(w, fmt.Sprintf("Invalid job input: %v", err))
+		return
+	}
+	job.CreatedBy = auth.UserID(r.Context())
 	if idempotencyKey != "" {
 		job.SetIdempotencyKey(idempotencyKey)
 	}
 
 	// Save to database if repo is available
-	if h.repo != nil {
-		if err := h.repo.CreateJob(r.Context(), job); err != nil {
-			InternalError(w, "Failed to save job")
-			return
-		}
+	if !h.saveJob(w, r, job, "Failed to save job") {
+		return
 	}
 
 	// Enqueue job for processing
@@ -133,13 +149,59 @@ func (h *JobHandler) Create(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	Success(w, http.StatusAccepted, map[string]interface{}{
-		"job_id":     job.ID.String(),
-		"type":       job.Type,
-		"status":     job.Status,
-		"progress":   job.Progress,
-		"created_at": job.CreatedAt.Format(time.RFC3339),
-	}, nil)
+	setAuditTarget(r, "job", job.ID.String())
+	Success(w, http.StatusAccepted, jobSummary(job), nil)
+}
+
+// saveJob saves a new job if there is a database, refusing it when its
+// estimated cost would take spending past a budget. It writes the error
+// response and returns false if the job wasn't saved.
+func (h *JobHandler) saveJob(w http.ResponseWriter, r *http.Request, job *models.Job, failure string) bool {
+	if h.repo == nil {
+		return true
+	}
+	return saveJobWithinBudget(w, r, h.repo, h.budgets, job, failure)
+}
+
+// saveJobWithinBudget saves a new job unless its estimated cost would take
+// spending past a budget, answering 402 then. It writes the error response
+// and returns false if the job wasn't saved.
+func saveJobWithinBudget(w http.ResponseWriter, r *http.Request, repo *db.Repository, budgets models.Budgets, job *models.Job, failure string) bool {
+	err := repo.CreateJobWithinBudget(r.Context(), job, budgets)
+	if err == nil {
+		return true
+	}
+
+	var budgetErr *models.BudgetExceededError
+	if errors.As(err, &budgetErr) {
+		budgetExceeded(w, "Job refused", budgetErr)
+		return false
+	}
+	log.Printf("%s %s: %v", failure, job.ID, err)
+	InternalError(w, failure)
+	return false
+}
+
+// budgetExceeded answers 402 with the budget a request would go over
+func budgetExceeded(w http.ResponseWriter, refused string, e *models.BudgetExceededError) {
+	Error(w, http.StatusPaymentRequired, ErrBudgetExceeded, refused+": "+e.Error(), map[string]interface{}{
+		"scope":              e.Scope,
+		"budget_usd":         e.LimitUSD,
+		"spent_usd":          e.SpentUSD,
+		"estimated_cost_usd": e.EstimateUSD,
+	})
+}
+
+// jobSummary is the response to creating a job
+func jobSummary(job *models.Job) map[string]interface{} {
+	return map[string]interface{}{
+		"job_id":             job.ID.String(),
+		"type":               job.Type,
+		"status":             job.Status,
+		"progress":           job.Progress,
+		"estimated_cost_usd": job.EstimatedCost,
+		"created_at":         job.CreatedAt.Format(time.RFC3339),
+	}
 }
 
 // Get handles GET /v1/jobs/{jobId}
@@ -171,6 +233,10 @@ func (h *JobHandler) Get(w http.ResponseWriter, r *http.Request) {
 		NotFound(w, "Job not found")
 		return
 	}
+	setAuditCase(r, job.CaseID)
+	if !authorizeCase(w, r, rolesOf(h.repo), job.CaseID, models.CaseRoleViewer, "Job not found") {
+		return
+	}
 
 	response := map[string]interface{}{
 		"job_id":     job.ID.String(),
@@ -188,10 +254,19 @@ func (h *JobHandler) Get(w http.ResponseWriter, r *http.Request) {
 	if job.Error != "" {
 		response["error"] = job.Error
 	}
+	response["estimated_cost_usd"] = job.EstimatedCost
+	if job.ActualCost != nil {
+		response["cost_usd"] = *job.ActualCost
+	}
 
 	Success(w, http.StatusOK, response, nil)
 }
 
+// CreateReasoningRequest represents the optional request body for a reasoning run
+type CreateReasoningRequest struct {
+	ConstraintsOverride []models.Constraint `json:"constraints_override,omitempty"`
+}
+
 // CreateReasoning handles POST /v1/cases/{caseId}/reasoning
 func (h *JobHandler) CreateReasoning(w http.ResponseWriter, r *http.Request) {
 	caseIDStr := chi.URLParam(r, "caseId")
@@ -206,6 +281,25 @@ func (h *JobHandler) CreateReasoning(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The body is optional; it may carry extra constraints for this run
+	var req CreateReasoningRequest
+	if r.Body != nil && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			BadRequest(w, "Invalid request body")
+			return
+		}
+	}
+	for _, c := range req.ConstraintsOverride {
+		if err := c.Validate(); err != nil {
+			BadRequest(w, fmt.Sprintf("Invalid constraint %s: %v", c.ID, err))
+			return
+		}
+	}
+
+	if !requireWritableCase(w, r, h.repo, caseID) {
+		return
+	}
+
 	// Get current scenegraph to include in job input
 	var scenegraph *models.SceneGraph
 	if h.repo != nil {
@@ -219,20 +313,22 @@ func (h *JobHandler) CreateReasoning(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create reasoning job with SceneGraph input
-	job, err := models.NewJob(caseID, models.JobTypeReasoning, map[string]interface{}{
+	input := map[string]interface{}{
 		"case_id":    caseID.String(),
 		"scenegraph": scenegraph,
-	})
+	}
+	if len(req.ConstraintsOverride) > 0 {
+		input["constraints_override"] = req.ConstraintsOverride
+	}
+	job, err := models.NewJob(caseID, models.JobTypeReasoning, input)
 	if err != nil {
 		InternalError(w, "Failed to create reasoning job")
 		return
 	}
+	job.CreatedBy = auth.UserID(r.Context())
 
-	if h.repo != nil {
-		if err := h.repo.CreateJob(r.Context(), job); err != nil {
-			InternalError(w, "Failed to save reasoning job")
-			return
-		}
+	if !h.saveJob(w, r, job, "Failed to save reasoning job") {
+		return
 	}
 
 	// Enqueue job for processing
@@ -240,16 +336,12 @@ func (h *JobHandler) CreateReasoning(w http.ResponseWriter, r *http.Request) {
 		h.queue.Enqueue(r.Context(), job)
 	}
 
-	Success(w, http.StatusAccepted, map[string]interface{}{
-		"job_id":     job.ID.String(),
-		"type":       job.Type,
-		"status":     job.Status,
-		"progress":   job.Progress,
-		"created_at": job.CreatedAt.Format(time.RFC3339),
-	}, nil)
+	setAuditTarget(r, "job", job.ID.String())
+	Success(w, http.StatusAccepted, jobSummary(job), nil)
 }
 
-// CreateExport handles POST /v1/cases/{caseId}/export
+// CreateExport handles POST /v1/cases/{caseId}/export with an optional
+// {"format": "html" | "pdf"} body
 func (h *JobHandler) CreateExport(w http.ResponseWriter, r *http.Request) {
 	caseIDStr := chi.URLParam(r, "caseId")
 	if caseIDStr == "" {
@@ -263,21 +355,56 @@ func (h *JobHandler) CreateExport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The body is optional; exports are PDF unless another format is asked
+	// for, or HTML when a template is named
+	var input models.ExportInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && err != io.EOF {
+		BadRequest(w, "Invalid request body")
+		return
+	}
+	if input.Format == "" && input.Template == "" {
+		input.Format = models.ExportFormatPDF
+	}
+	input.SetDefaults()
+	if err := input.Validate(); err != nil {
+		BadRequest(w, fmt.Sprintf("Invalid export: %v", err))
+		return
+	}
+
+	// Check the branch or commit to report on belongs to the case
+	if h.repo != nil && input.BranchID != nil {
+		branch, err := h.repo.GetBranch(r.Context(), *input.BranchID)
+		if err != nil {
+			InternalError(w, "Failed to get branch")
+			return
+		}
+		if branch == nil || branch.CaseID != caseID {
+			NotFound(w, "Branch not found")
+			return
+		}
+	}
+	if h.repo != nil && input.CommitID != nil {
+		commit, err := h.repo.GetCommit(r.Context(), *input.CommitID)
+		if err != nil {
+			InternalError(w, "Failed to get commit")
+			return
+		}
+		if commit == nil || commit.CaseID != caseID {
+			NotFound(w, "Commit not found")
+			return
+		}
+	}
+
 	// Create export job
-	job, err := models.NewJob(caseID, models.JobTypeExport, map[string]interface{}{
-		"format": "pdf",
-	})
+	job, err := models.NewJob(caseID, models.JobTypeExport, input)
 	if err != nil {
 		InternalError(w, "Failed to create export job")
 		return
 	}
+	job.CreatedBy = auth.UserID(r.Context())
 
-	if h.repo != nil {
-		if err := h.repo.CreateJob(r.Context(), job); err != nil {
-			log.Printf("Failed to save export job: %v", err)
-			InternalError(w, "Failed to save export job")
-			return
-		}
+	if !h.saveJob(w, r, job, "Failed to save export job") {
+		return
 	}
 
 	// Enqueue job for processing
@@ -285,11 +412,46 @@ func (h *JobHandler) CreateExport(w http.ResponseWriter, r *http.Request) {
 		h.queue.Enqueue(r.Context(), job)
 	}
 
-	Success(w, http.StatusAccepted, map[string]interface{}{
-		"job_id":     job.ID.String(),
-		"type":       job.Type,
-		"status":     job.Status,
-		"progress":   job.Progress,
-		"created_at": job.CreatedAt.Format(time.RFC3339),
+	setAuditTarget(r, "job", job.ID.String())
+	Success(w, http.StatusAccepted, jobSummary(job), nil)
+}
+
+// Spend handles GET /v1/cases/{caseId}/spend
+// It returns what has been spent on the case's jobs and, for the current
+// month, on every case, alongside the budgets they count against. Budgets
+// of 0 are unlimited.
+func (h *JobHandler) Spend(w http.ResponseWriter, r *http.Request) {
+	caseID, err := uuid.Parse(chi.URLParam(r, "caseId"))
+	if err != nil {
+		BadRequest(w, "Invalid case ID format")
+		return
+	}
+
+	periodStart := models.BudgetPeriodStart(time.Now())
+	caseSpend := &models.Spend{ByJobType: map[models.JobType]float64{}}
+	orgSpend := &models.Spend{ByJobType: map[models.JobType]float64{}}
+	if h.repo != nil {
+		if caseSpend, err = h.repo.GetCaseSpend(r.Context(), caseID); err != nil {
+			log.Printf("Failed to get spend of case %s: %v", caseID, err)
+			InternalError(w, "Failed to retrieve spend")
+			return
+		}
+		if orgSpend, err = h.repo.GetSpendSince(r.Context(), periodStart); err != nil {
+			log.Printf("Failed to get spend since %s: %v", periodStart, err)
+			InternalError(w, "Failed to retrieve spend")
+			return
+		}
+	}
+
+	Success(w, http.StatusOK, map[string]interface{}{
+		"case": map[string]interface{}{
+			"spend":      caseSpend,
+			"budget_usd": h.budgets.CaseUSD,
+		},
+		"organisation": map[string]interface{}{
+			"spend":        orgSpend,
+			"budget_usd":   h.budgets.OrganisationMonthlyUSD,
+			"period_start": periodStart.Format(time.RFC3339),
+		},
 	}, nil)
 }
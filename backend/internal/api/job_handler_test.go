@@ -3,8 +3,10 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
@@ -238,6 +240,7 @@ func TestJobHandler_CreateReasoning(t *testing.T) {
 	tests := []struct {
 		name       string
 		caseID     string
+		body       string
 		wantStatus int
 		wantErr    string
 	}{
@@ -246,6 +249,19 @@ func TestJobHandler_CreateReasoning(t *testing.T) {
 			caseID:     testCaseID,
 			wantStatus: http.StatusAccepted,
 		},
+		{
+			name:       "with constraint overrides",
+			caseID:     testCaseID,
+			body:       `{"constraints_override":[{"id":"h","type":"height_range","params":{"min_cm":170,"max_cm":185},"confidence":0.7}]}`,
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "invalid constraint params",
+			caseID:     testCaseID,
+			body:       `{"constraints_override":[{"id":"h","type":"height_range","params":{"min_cm":190,"max_cm":170},"confidence":0.7}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Invalid constraint h",
+		},
 		{
 			name:       "invalid case ID",
 			caseID:     "invalid",
@@ -256,7 +272,11 @@ func TestJobHandler_CreateReasoning(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req := httptest.NewRequest(http.MethodPost, "/v1/cases/"+tt.caseID+"/reasoning", nil)
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/v1/cases/"+tt.caseID+"/reasoning", body)
 			w := httptest.NewRecorder()
 
 			r.ServeHTTP(w, req)
@@ -299,6 +319,7 @@ func TestJobHandler_CreateExport(t *testing.T) {
 	tests := []struct {
 		name       string
 		caseID     string
+		body       string
 		wantStatus int
 		wantErr    string
 	}{
@@ -307,6 +328,53 @@ func TestJobHandler_CreateExport(t *testing.T) {
 			caseID:     testCaseID,
 			wantStatus: http.StatusAccepted,
 		},
+		{
+			name:       "html format",
+			caseID:     testCaseID,
+			body:       `{"format": "html"}`,
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "template with sections and redaction",
+			caseID:     testCaseID,
+			body:       `{"template": "court", "sections": ["timeline", "custody_log"], "evidence_tiers": ["high"], "redaction": {"witness_names": true}}`,
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "template for a pdf",
+			caseID:     testCaseID,
+			body:       `{"format": "pdf", "template": "court"}`,
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Invalid export: templates only apply to html exports",
+		},
+		{
+			name:       "unknown section",
+			caseID:     testCaseID,
+			body:       `{"sections": ["appendix"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Invalid export: invalid section: appendix",
+		},
+		{
+			name:       "branch and commit",
+			caseID:     testCaseID,
+			body:       `{"branch_id": "` + testCaseID + `", "commit_id": "` + testCaseID + `"}`,
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Invalid export: branch_id and commit_id are mutually exclusive",
+		},
+		{
+			name:       "unknown format",
+			caseID:     testCaseID,
+			body:       `{"format": "docx"}`,
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Invalid export: format must be html, pdf, bundle or gltf",
+		},
+		{
+			name:       "gltf with sections",
+			caseID:     testCaseID,
+			body:       `{"format": "gltf", "sections": ["scene"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "Invalid export: report options don't apply to gltf exports",
+		},
 		{
 			name:       "invalid case ID",
 			caseID:     "invalid",
@@ -317,7 +385,7 @@ func TestJobHandler_CreateExport(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req := httptest.NewRequest(http.MethodPost, "/v1/cases/"+tt.caseID+"/export", nil)
+			req := httptest.NewRequest(http.MethodPost, "/v1/cases/"+tt.caseID+"/export", strings.NewReader(tt.body))
 			w := httptest.NewRecorder()
 
 			r.ServeHTTP(w, req)
@@ -326,6 +394,12 @@ func TestJobHandler_CreateExport(t *testing.T) {
 				t.Errorf("CreateExport() status = %v, want %v", w.Code, tt.wantStatus)
 			}
 
+			if tt.wantErr != "" {
+				if errMsg := getErrorMessage(w.Body.Bytes()); errMsg != tt.wantErr {
+					t.Errorf("CreateExport() error = %v, want %v", errMsg, tt.wantErr)
+				}
+			}
+
 			if tt.wantStatus == http.StatusAccepted {
 				var result Response
 				json.NewDecoder(w.Body).Decode(&result)
@@ -371,3 +445,42 @@ func TestJobType_Validation(t *testing.T) {
 		t.Error("Invalid JobType should not be valid")
 	}
 }
+
+func TestJobHandler_Spend(t *testing.T) {
+	handler := NewJobHandlerWithBudgets(nil, nil, models.Budgets{CaseUSD: 25, OrganisationMonthlyUSD: 500})
+
+	r := chi.NewRouter()
+	r.Get("/v1/cases/{caseId}/spend", handler.Spend)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/cases/"+testCaseID+"/spend", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Spend() status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	var result struct {
+		Data struct {
+			Case struct {
+				Spend     models.Spend `json:"spend"`
+				BudgetUSD float64      `json:"budget_usd"`
+			} `json:"case"`
+			Organisation struct {
+				BudgetUSD   float64 `json:"budget_usd"`
+				PeriodStart string  `json:"period_start"`
+			} `json:"organisation"`
+		} `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&result)
+	if result.Data.Case.BudgetUSD != 25 || result.Data.Case.Spend.TotalUSD != 0 || result.Data.Organisation.BudgetUSD != 500 {
+		t.Errorf("Spend() = %+v", result.Data)
+	}
+	if !strings.HasSuffix(result.Data.Organisation.PeriodStart, "-01T00:00:00Z") {
+		t.Errorf("Spend() period_start = %q, want the start of the month", result.Data.Organisation.PeriodStart)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/cases/not-a-uuid/spend", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Spend() status of an invalid case ID = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+}
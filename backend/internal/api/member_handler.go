@@ -0,0 +1,168 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/auth"
+	"github.com/sherlockos/backend/internal/db"
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// MemberHandler handles case membership requests
+type MemberHandler struct {
+	repo *db.Repository
+}
+
+// NewMemberHandler creates a new member handler
+func NewMemberHandler(database *db.DB) *MemberHandler {
+	var repo *db.Repository
+	if database != nil {
+		repo = db.NewRepository(database)
+	}
+	return &MemberHandler{repo: repo}
+}
+
+// SetMemberRequest represents the request body for adding a member or
+// changing their role
+type SetMemberRequest struct {
+	Role models.CaseRole `json:"role"`
+}
+
+// memberResponse converts a membership to its response format
+func memberResponse(m *models.CaseMember) map[string]interface{} {
+	resp := map[string]interface{}{
+		"user_id":    m.UserID.String(),
+		"role":       m.Role,
+		"created_at": m.CreatedAt.Format(time.RFC3339),
+		"updated_at": m.UpdatedAt.Format(time.RFC3339),
+	}
+	if m.AddedBy != nil {
+		resp["added_by"] = m.AddedBy.String()
+	}
+	return resp
+}
+
+// List handles GET /v1/cases/{caseId}/members
+func (h *MemberHandler) List(w http.ResponseWriter, r *http.Request) {
+	caseIDStr := chi.URLParam(r, "caseId")
+	if caseIDStr == "" {
+		BadRequest(w, "Case ID is required")
+		return
+	}
+
+	caseID, err := uuid.Parse(caseIDStr)
+	if err != nil {
+		BadRequest(w, "Invalid case ID format")
+		return
+	}
+
+	if h.repo == nil {
+		Success(w, http.StatusOK, []interface{}{}, nil)
+		return
+	}
+
+	members, err := h.repo.ListCaseMembers(r.Context(), caseID)
+	if err != nil {
+		InternalError(w, "Failed to list members")
+		return
+	}
+
+	result := make([]map[string]interface{}, 0, len(members))
+	for _, m := range members {
+		result = append(result, memberResponse(m))
+	}
+
+	Success(w, http.StatusOK, result, nil)
+}
+
+// Set handles PUT /v1/cases/{caseId}/members/{userId}
+// It adds the user to the case or changes their role. A case always keeps
+// at least one lead.
+func (h *MemberHandler) Set(w http.ResponseWriter, r *http.Request) {
+	caseID, userID, ok := parseMemberPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req SetMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		BadRequest(w, "Invalid request body")
+		return
+	}
+
+	m := models.NewCaseMember(caseID, userID, req.Role, auth.UserID(r.Context()))
+	if err := m.Validate(); err != nil {
+		BadRequest(w, "Invalid member: "+err.Error())
+		return
+	}
+
+	if h.repo == nil {
+		NotFound(w, "Case not found")
+		return
+	}
+
+	if err := h.repo.SetCaseMember(r.Context(), m); err != nil {
+		if errors.Is(err, db.ErrLastLead) {
+			Conflict(w, "A case must keep at least one lead", nil)
+			return
+		}
+		InternalError(w, "Failed to save member")
+		return
+	}
+
+	Success(w, http.StatusOK, memberResponse(m), nil)
+}
+
+// Remove handles DELETE /v1/cases/{caseId}/members/{userId}
+func (h *MemberHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	caseID, userID, ok := parseMemberPath(w, r)
+	if !ok {
+		return
+	}
+
+	if h.repo == nil {
+		NotFound(w, "Member not found")
+		return
+	}
+
+	removed, err := h.repo.RemoveCaseMember(r.Context(), caseID, userID)
+	if errors.Is(err, db.ErrLastLead) {
+		Conflict(w, "A case must keep at least one lead", nil)
+		return
+	}
+	if err != nil {
+		InternalError(w, "Failed to remove member")
+		return
+	}
+	if !removed {
+		NotFound(w, "Member not found")
+		return
+	}
+
+	Success(w, http.StatusOK, map[string]interface{}{
+		"case_id": caseID.String(),
+		"user_id": userID.String(),
+		"removed": true,
+	}, nil)
+}
+
+// parseMemberPath reads the case and user IDs of a member route
+func parseMemberPath(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	caseID, err := uuid.Parse(chi.URLParam(r, "caseId"))
+	if err != nil {
+		BadRequest(w, "Invalid case ID format")
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		BadRequest(w, "Invalid user ID format")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return caseID, userID, true
+}
@@ -2,15 +2,22 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 
+	"github.com/sherlockos/backend/internal/auth"
 	"github.com/sherlockos/backend/internal/clients"
+	"github.com/sherlockos/backend/internal/db"
+	"github.com/sherlockos/backend/internal/models"
 )
 
 // PortraitHandler handles portrait generation via multi-turn chat
 type PortraitHandler struct {
 	imageClient *clients.GeminiImageGenClient
+	repo        *db.Repository
+	budgets     models.Budgets
 }
 
 // NewPortraitHandler creates a new portrait handler
@@ -18,6 +25,18 @@ func NewPortraitHandler(imageClient *clients.GeminiImageGenClient) *PortraitHand
 	return &PortraitHandler{imageClient: imageClient}
 }
 
+// NewPortraitHandlerWithBudgets creates a new portrait handler that charges
+// each generated image to the month's spending and refuses it past the
+// organisation budget
+func NewPortraitHandlerWithBudgets(database *db.DB, imageClient *clients.GeminiImageGenClient, budgets models.Budgets) *PortraitHandler {
+	h := NewPortraitHandler(imageClient)
+	if database != nil {
+		h.repo = db.NewRepository(database)
+	}
+	h.budgets = budgets
+	return h
+}
+
 // PortraitChatRequest is the request body for portrait chat
 type PortraitChatRequest struct {
 	Messages []clients.PortraitChatMessage `json:"messages"`
@@ -49,9 +68,15 @@ func (h *PortraitHandler) Chat(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	charge, ok := h.charge(w, r)
+	if !ok {
+		return
+	}
+
 	// Call Gemini multi-turn image generation
 	text, imageB64, err := h.imageClient.GeneratePortraitChat(r.Context(), req.Messages)
 	if err != nil {
+		h.refund(r, charge)
 		InternalError(w, "Portrait generation failed: "+err.Error())
 		return
 	}
@@ -61,3 +86,40 @@ func (h *PortraitHandler) Chat(w http.ResponseWriter, r *http.Request) {
 		"image_base64": imageB64,
 	}, nil)
 }
+
+// charge records the cost of the turn's image if there is a database,
+// refusing it when it would take the month's spending past the organisation
+// budget. It writes the error response and returns false if it wasn't
+// charged.
+func (h *PortraitHandler) charge(w http.ResponseWriter, r *http.Request) (*models.UsageCharge, bool) {
+	if h.repo == nil {
+		return nil, true
+	}
+
+	// Each turn generates one 1K image
+	charge := models.NewUsageCharge(models.JobTypeImageGen, models.UsageSourcePortraitChat, models.ImageCostUSD("1k"))
+	charge.CreatedBy = auth.UserID(r.Context())
+	err := h.repo.CreateUsageChargeWithinBudget(r.Context(), charge, h.budgets)
+	if err == nil {
+		return charge, true
+	}
+
+	var budgetErr *models.BudgetExceededError
+	if errors.As(err, &budgetErr) {
+		budgetExceeded(w, "Portrait refused", budgetErr)
+		return nil, false
+	}
+	log.Printf("Failed to charge portrait chat: %v", err)
+	InternalError(w, "Failed to record portrait cost")
+	return nil, false
+}
+
+// refund removes the charge for a turn whose image wasn't generated
+func (h *PortraitHandler) refund(r *http.Request, charge *models.UsageCharge) {
+	if charge == nil {
+		return
+	}
+	if err := h.repo.DeleteUsageCharge(r.Context(), charge.ID); err != nil {
+		log.Printf("Failed to refund portrait chat charge %s: %v", charge.ID, err)
+	}
+}
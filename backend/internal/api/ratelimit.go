@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/sherlockos/backend/internal/auth"
+	"github.com/sherlockos/backend/internal/ratelimit"
+)
+
+// LimitJobs throttles job creation per user and per case, answering 429
+// with a Retry-After header once either runs out. Without a signed-in user
+// (authentication disabled), requests are limited per client address. A nil
+// limiter doesn't limit.
+func LimitJobs(perUser, perCase *ratelimit.Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ok, wait := perUser.Allow(requesterKey(r)); !ok {
+				TooManyRequests(w, "Too many jobs created; try again later", wait)
+				return
+			}
+			if ok, wait := perCase.Allow(chi.URLParam(r, "caseId")); !ok {
+				TooManyRequests(w, "Too many jobs created for this case; try again later", wait)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requesterKey identifies who made a request: the signed-in user, or else
+// the client's address
+func requesterKey(r *http.Request) string {
+	if id := auth.UserID(r.Context()); id != nil {
+		return "user:" + id.String()
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "addr:" + host
+}
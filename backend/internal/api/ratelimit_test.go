@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/auth"
+	"github.com/sherlockos/backend/internal/ratelimit"
+)
+
+func TestLimitJobs(t *testing.T) {
+	perUser := ratelimit.NewLimiter(1, 2)
+	perCase := ratelimit.NewLimiter(1, 3)
+	alice, bob := &auth.User{ID: uuid.New()}, &auth.User{ID: uuid.New()}
+
+	r := chi.NewRouter()
+	r.With(LimitJobs(perUser, perCase)).Post("/cases/{caseId}/jobs", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	create := func(user *auth.User, caseID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/cases/"+caseID+"/jobs", nil)
+		if user != nil {
+			req = req.WithContext(auth.WithUser(req.Context(), user))
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	caseA, caseB := uuid.NewString(), uuid.NewString()
+	for i := 0; i < 2; i++ {
+		if w := create(alice, caseA); w.Code != http.StatusAccepted {
+			t.Fatalf("job %d status = %v, want %v", i+1, w.Code, http.StatusAccepted)
+		}
+	}
+	w := create(alice, caseB)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("past the user's burst: status = %v, Retry-After = %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	if w := create(bob, caseA); w.Code != http.StatusAccepted {
+		t.Errorf("another user's job status = %v, want %v", w.Code, http.StatusAccepted)
+	}
+	if w := create(nil, caseA); w.Code != http.StatusTooManyRequests {
+		t.Errorf("past the case's burst: status = %v, want %v", w.Code, http.StatusTooManyRequests)
+	}
+	if w := create(nil, caseB); w.Code != http.StatusAccepted {
+		t.Errorf("anonymous job on another case status = %v, want %v", w.Code, http.StatusAccepted)
+	}
+}
+
+func TestRegisterRoutes_WitnessStatementsLimited(t *testing.T) {
+	r := chi.NewRouter()
+	RegisterRoutesWithOptions(r, nil, RouteOptions{CaseJobLimit: ratelimit.NewLimiter(1, 1)})
+
+	// Each submission starts a profile job, so it counts against job limits
+	submit := func() int {
+		body := `{"statements":[{"source_name":"Neighbour","content":"Saw a van","credibility":0.7}]}`
+		req := httptest.NewRequest(http.MethodPost, "/cases/"+testCaseID+"/witness-statements", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if got := submit(); got != http.StatusCreated {
+		t.Fatalf("first submission status = %v, want %v", got, http.StatusCreated)
+	}
+	if got := submit(); got != http.StatusTooManyRequests {
+		t.Errorf("past the case's burst: status = %v, want %v", got, http.StatusTooManyRequests)
+	}
+}
+
+func TestRegisterPortraitRoutes_Limited(t *testing.T) {
+	r := chi.NewRouter()
+	RegisterPortraitRoutes(r, nil, nil, RouteOptions{UserJobLimit: ratelimit.NewLimiter(1, 1)})
+
+	// Each turn generates an image, so it counts against the user's job limit
+	chat := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/portrait/chat", strings.NewReader(`{"messages":[{"role":"user","content":"Tall, grey coat"}]}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	// Without an image client the first turn is let through but can't be served
+	if got := chat(); got != http.StatusServiceUnavailable {
+		t.Fatalf("first turn status = %v, want %v", got, http.StatusServiceUnavailable)
+	}
+	if got := chat(); got != http.StatusTooManyRequests {
+		t.Errorf("past the user's burst: status = %v, want %v", got, http.StatusTooManyRequests)
+	}
+}
+
+func TestLimitJobs_Disabled(t *testing.T) {
+	handler := LimitJobs(nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for i := 0; i < 100; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/cases/x/jobs", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %v without limits", w.Code)
+		}
+	}
+}
@@ -2,7 +2,10 @@ package api
 
 import (
 	"encoding/json"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // ErrorCode represents API error codes
@@ -15,6 +18,7 @@ const (
 	ErrNotFound          ErrorCode = "NOT_FOUND"
 	ErrConflict          ErrorCode = "CONFLICT"
 	ErrRateLimited       ErrorCode = "RATE_LIMITED"
+	ErrBudgetExceeded     ErrorCode = "BUDGET_EXCEEDED"
 	ErrJobFailed         ErrorCode = "JOB_FAILED"
 	ErrModelUnavailable  ErrorCode = "MODEL_UNAVAILABLE"
 	ErrServiceUnavailable ErrorCode = "SERVICE_UNAVAILABLE"
@@ -99,3 +103,25 @@ func InternalError(w http.ResponseWriter, message string) {
 func ServiceUnavailable(w http.ResponseWriter, message string) {
 	Error(w, http.StatusServiceUnavailable, ErrServiceUnavailable, message, nil)
 }
+
+// Unauthorized writes a 401 error
+func Unauthorized(w http.ResponseWriter, message string) {
+	Error(w, http.StatusUnauthorized, ErrUnauthorized, message, nil)
+}
+
+// Forbidden writes a 403 error
+func Forbidden(w http.ResponseWriter, message string) {
+	Error(w, http.StatusForbidden, ErrForbidden, message, nil)
+}
+
+// TooManyRequests writes a 429 error telling the client when to retry
+func TooManyRequests(w http.ResponseWriter, message string, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	Error(w, http.StatusTooManyRequests, ErrRateLimited, message, map[string]interface{}{
+		"retry_after_seconds": seconds,
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestJSON(t *testing.T) {
@@ -189,6 +190,26 @@ func TestInternalError(t *testing.T) {
 	}
 }
 
+func TestTooManyRequests(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	TooManyRequests(w, "Too many jobs", 1500*time.Millisecond)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("TooManyRequests() status = %v, want %v", w.Code, http.StatusTooManyRequests)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("TooManyRequests() Retry-After = %q, want 2", got)
+	}
+
+	var result ErrorResponse
+	json.NewDecoder(w.Body).Decode(&result)
+
+	if result.Error.Code != ErrRateLimited {
+		t.Errorf("TooManyRequests() code = %v, want %v", result.Error.Code, ErrRateLimited)
+	}
+}
+
 func TestErrorCode_Values(t *testing.T) {
 	// Verify error code string values match expected format
 	codes := map[ErrorCode]string{
@@ -198,6 +219,7 @@ func TestErrorCode_Values(t *testing.T) {
 		ErrNotFound:         "NOT_FOUND",
 		ErrConflict:         "CONFLICT",
 		ErrRateLimited:      "RATE_LIMITED",
+		ErrBudgetExceeded:   "BUDGET_EXCEEDED",
 		ErrJobFailed:        "JOB_FAILED",
 		ErrModelUnavailable: "MODEL_UNAVAILABLE",
 		ErrInternalError:    "INTERNAL_ERROR",
@@ -3,9 +3,13 @@ package api
 import (
 	"github.com/go-chi/chi/v5"
 
+	"github.com/sherlockos/backend/internal/auth"
 	"github.com/sherlockos/backend/internal/clients"
 	"github.com/sherlockos/backend/internal/db"
+	"github.com/sherlockos/backend/internal/lifecycle"
+	"github.com/sherlockos/backend/internal/models"
 	"github.com/sherlockos/backend/internal/queue"
+	"github.com/sherlockos/backend/internal/ratelimit"
 )
 
 // RegisterRoutes sets up all API routes
@@ -15,40 +19,114 @@ func RegisterRoutes(r chi.Router, database *db.DB) {
 
 // RegisterRoutesWithQueue sets up all API routes with queue support
 func RegisterRoutesWithQueue(r chi.Router, database *db.DB, q queue.JobQueue) {
-	// Initialize handlers
-	caseHandler := NewCaseHandlerWithQueue(database, q)
-	var jobHandler *JobHandler
-	if q != nil {
-		jobHandler = NewJobHandlerWithQueue(database, q)
-	} else {
-		jobHandler = NewJobHandler(database)
+	RegisterRoutesWithOptions(r, database, RouteOptions{Queue: q})
+}
+
+// RouteOptions holds the optional dependencies used by API handlers
+type RouteOptions struct {
+	Queue   queue.JobQueue
+	Storage clients.StorageClient
+	Purger  *lifecycle.Purger // purges deleted cases; nil disables ?purge=true
+	Auth    auth.Verifier     // verifies bearer tokens; nil leaves every route open
+
+	// Job creation limits per user and per case; nil limiters don't limit
+	UserJobLimit *ratelimit.Limiter
+	CaseJobLimit *ratelimit.Limiter
+	Budgets      models.Budgets // caps on estimated job spending
+}
+
+// RegisterRoutesWithOptions sets up all API routes with the given dependencies.
+// With an auth verifier every route needs a bearer token, and routes under a
+// case need the right role on it. With a database, mutating and
+// evidence-reading requests are recorded in the audit log. Creating jobs is
+// rate limited and refused once it would go over budget.
+func RegisterRoutesWithOptions(r chi.Router, database *db.DB, opts RouteOptions) {
+	q := opts.Queue
+	if opts.Auth != nil {
+		r.Use(Authenticate(opts.Auth))
+	}
+
+	var repo *db.Repository
+	if database != nil {
+		repo = db.NewRepository(database)
+		r.Use(Audit(repo))
 	}
 
+	// Initialize handlers
+	caseHandler := NewCaseHandlerWithBudgets(database, q, opts.Purger, opts.Budgets)
+	jobHandler := NewJobHandlerWithBudgets(database, q, opts.Budgets)
+	assetHandler := NewAssetHandler(database, opts.Storage)
+	sceneHandler := NewSceneHandler(database, opts.Storage)
+	bundleHandler := NewBundleHandler(database, opts.Storage)
+	searchHandler := NewSearchHandler(database)
+	memberHandler := NewMemberHandler(database)
+	auditHandler := NewAuditHandler(database)
+
+	// Search
+	r.Get("/search", searchHandler.Search)
+
 	// Cases
 	r.Route("/cases", func(r chi.Router) {
 		r.Get("/", caseHandler.List)
 		r.Post("/", caseHandler.Create)
-		r.Get("/{caseId}", caseHandler.Get)
-		r.Get("/{caseId}/snapshot", caseHandler.GetSnapshot)
-		r.Get("/{caseId}/timeline", caseHandler.GetTimeline)
-		r.Post("/{caseId}/upload-intent", caseHandler.CreateUploadIntent)
-		r.Post("/{caseId}/jobs", jobHandler.Create)
-		r.Post("/{caseId}/witness-statements", caseHandler.SubmitWitnessStatements)
-		r.Post("/{caseId}/branches", caseHandler.CreateBranch)
-		r.Post("/{caseId}/reasoning", jobHandler.CreateReasoning)
-		r.Post("/{caseId}/export", jobHandler.CreateExport)
+		r.Post("/import", bundleHandler.Import)
+
+		// Reading a case
+		r.Group(func(r chi.Router) {
+			r.Use(RequireCaseRole(repo, models.CaseRoleViewer))
+			r.Get("/{caseId}", caseHandler.Get)
+			r.Get("/{caseId}/snapshot", caseHandler.GetSnapshot)
+			r.Get("/{caseId}/timeline", caseHandler.GetTimeline)
+			r.Get("/{caseId}/pointcloud", sceneHandler.PointCloud)
+			r.Get("/{caseId}/scene/query", sceneHandler.Query)
+			r.Get("/{caseId}/floorplan.svg", sceneHandler.FloorPlanSVG)
+			r.Get("/{caseId}/floorplan.png", sceneHandler.FloorPlanPNG)
+			r.Get("/{caseId}/scene.glb", sceneHandler.SceneGLB)
+			r.Get("/{caseId}/members", memberHandler.List)
+			r.Get("/{caseId}/spend", jobHandler.Spend)
+		})
+
+		// Working on a case
+		r.Group(func(r chi.Router) {
+			r.Use(RequireCaseRole(repo, models.CaseRoleInvestigator))
+			r.Post("/{caseId}/upload-intent", caseHandler.CreateUploadIntent)
+			r.Post("/{caseId}/assets/ingest", assetHandler.Ingest)
+			r.Post("/{caseId}/branches", caseHandler.CreateBranch)
+			r.Get("/{caseId}/audit", auditHandler.List)
+
+			// Creating jobs
+			r.Group(func(r chi.Router) {
+				r.Use(LimitJobs(opts.UserJobLimit, opts.CaseJobLimit))
+				r.Post("/{caseId}/jobs", jobHandler.Create)
+				r.Post("/{caseId}/reasoning", jobHandler.CreateReasoning)
+				r.Post("/{caseId}/export", jobHandler.CreateExport)
+				r.Post("/{caseId}/witness-statements", caseHandler.SubmitWitnessStatements)
+			})
+		})
+
+		// Managing a case
+		r.Group(func(r chi.Router) {
+			r.Use(RequireCaseRole(repo, models.CaseRoleLead))
+			r.Patch("/{caseId}", caseHandler.Update)
+			r.Delete("/{caseId}", caseHandler.Delete)
+			r.Post("/{caseId}/restore", caseHandler.Restore)
+			r.Put("/{caseId}/members/{userId}", memberHandler.Set)
+			r.Delete("/{caseId}/members/{userId}", memberHandler.Remove)
+		})
 	})
 
-	// Jobs
+	// Jobs (access is checked against the job's case)
 	r.Route("/jobs", func(r chi.Router) {
 		r.Get("/{jobId}", jobHandler.Get)
 	})
 }
 
-// RegisterPortraitRoutes registers portrait generation routes
-func RegisterPortraitRoutes(r chi.Router, imageClient *clients.GeminiImageGenClient) {
-	portraitHandler := NewPortraitHandler(imageClient)
+// RegisterPortraitRoutes registers portrait generation routes. Each chat turn
+// generates an image, so it counts against the user's job rate limit and is
+// charged to the organisation budget.
+func RegisterPortraitRoutes(r chi.Router, database *db.DB, imageClient *clients.GeminiImageGenClient, opts RouteOptions) {
+	portraitHandler := NewPortraitHandlerWithBudgets(database, imageClient, opts.Budgets)
 	r.Route("/portrait", func(r chi.Router) {
-		r.Post("/chat", portraitHandler.Chat)
+		r.With(LimitJobs(opts.UserJobLimit, nil)).Post("/chat", portraitHandler.Chat)
 	})
 }
@@ -0,0 +1,608 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/clients"
+	"github.com/sherlockos/backend/internal/db"
+	"github.com/sherlockos/backend/internal/floorplan"
+	"github.com/sherlockos/backend/internal/gltf"
+	"github.com/sherlockos/backend/internal/models"
+	"github.com/sherlockos/backend/internal/pointcloud"
+	"github.com/sherlockos/backend/internal/report"
+	"github.com/sherlockos/backend/internal/spatial"
+)
+
+// SceneHandler serves derived scene data such as the full point cloud, the
+// floor plan, the glTF export and spatial queries over the scene's objects
+type SceneHandler struct {
+	repo    *db.Repository
+	storage clients.StorageClient
+}
+
+// NewSceneHandler creates a new scene handler
+func NewSceneHandler(database *db.DB, storage clients.StorageClient) *SceneHandler {
+	var repo *db.Repository
+	if database != nil {
+		repo = db.NewRepository(database)
+	}
+	return &SceneHandler{repo: repo, storage: storage}
+}
+
+// PointCloud handles GET /v1/cases/{caseId}/pointcloud
+// It streams the full point cloud of the current snapshot as binary PLY, or as
+// the quantized format with ?format=sqpc. Stored PLY files support Range requests.
+func (h *SceneHandler) PointCloud(w http.ResponseWriter, r *http.Request) {
+	caseIDStr := chi.URLParam(r, "caseId")
+	if caseIDStr == "" {
+		BadRequest(w, "Case ID is required")
+		return
+	}
+
+	caseID, err := uuid.Parse(caseIDStr)
+	if err != nil {
+		BadRequest(w, "Invalid case ID format")
+		return
+	}
+
+	format := models.PointCloudFormatPLY
+	if f := r.URL.Query().Get("format"); f != "" {
+		format = models.PointCloudFormat(f)
+		if !format.IsValid() {
+			BadRequest(w, fmt.Sprintf("Unsupported point cloud format: %s", f))
+			return
+		}
+	}
+
+	if h.repo == nil {
+		NotFound(w, "Point cloud not found")
+		return
+	}
+
+	snapshot, err := h.repo.GetSceneSnapshot(r.Context(), caseID)
+	if err != nil {
+		InternalError(w, "Failed to retrieve snapshot")
+		return
+	}
+	if snapshot == nil || snapshot.Scenegraph == nil || snapshot.Scenegraph.PointCloud == nil {
+		NotFound(w, "Point cloud not found")
+		return
+	}
+
+	h.servePointCloud(w, r, snapshot.Scenegraph.PointCloud, format)
+}
+
+// servePointCloud writes the full cloud in the requested format. Clouds from
+// before binary storage are encoded from their inline points.
+func (h *SceneHandler) servePointCloud(w http.ResponseWriter, r *http.Request, pc *models.PointCloud, format models.PointCloudFormat) {
+	filename := "pointcloud." + pointcloud.FileExtension(format)
+
+	full := pc
+	if pc.IsStored() {
+		if h.storage == nil {
+			ServiceUnavailable(w, "Storage not configured")
+			return
+		}
+		data, _, err := h.storage.Download(r.Context(), assetBucket, pc.AssetKey)
+		if err != nil {
+			InternalError(w, "Failed to download point cloud")
+			return
+		}
+
+		storedFormat := pc.Format
+		if storedFormat == "" {
+			storedFormat = models.PointCloudFormatPLY
+		}
+		if storedFormat == format {
+			w.Header().Set("Content-Type", pointcloud.ContentType(format))
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+			w.Header().Set("X-Point-Count", fmt.Sprint(pc.Count))
+			http.ServeContent(w, r, filename, time.Time{}, bytes.NewReader(data))
+			return
+		}
+
+		full, err = pointcloud.Decode(bytes.NewReader(data), storedFormat)
+		if err != nil {
+			InternalError(w, "Failed to decode stored point cloud")
+			return
+		}
+	}
+
+	if len(full.Positions) == 0 {
+		NotFound(w, "Point cloud not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", pointcloud.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("X-Point-Count", fmt.Sprint(len(full.Positions)))
+	w.WriteHeader(http.StatusOK)
+	if err := pointcloud.Encode(w, full, format); err != nil {
+		log.Printf("Failed to stream point cloud %s: %v", r.URL.Path, err)
+	}
+}
+
+// Scene query modes
+const (
+	sceneQueryRadius  = "radius"
+	sceneQueryBox     = "box"
+	sceneQueryRay     = "ray"
+	sceneQueryNearest = "nearest"
+)
+
+// defaultNearestK and maxNearestK bound k for nearest queries
+const (
+	defaultNearestK = 5
+	maxNearestK     = 100
+)
+
+// SceneQueryResult is the response of a scene query
+type SceneQueryResult struct {
+	Mode     string        `json:"mode"`
+	CommitID string        `json:"commit_id,omitempty"`
+	Count    int           `json:"count"`
+	Hits     []spatial.Hit `json:"hits"`
+}
+
+// Query handles GET /v1/cases/{caseId}/scene/query
+// It runs a spatial query against the current snapshot, or the scene as of
+// ?commit_id=. Modes:
+//   - radius: items within ?radius= meters of ?center=x,y,z or ?object=<id>
+//   - box: items overlapping ?min=x,y,z&max=x,y,z or the box of ?object=<id>
+//   - ray: items along ?origin=&direction= (up to ?max_distance=), or between
+//     the objects ?from=<id>&to=<id>
+//   - nearest: the ?k= items closest to ?center= or ?object=
+//
+// Results can be narrowed with comma-separated ?kinds=, ?types= and ?states=.
+func (h *SceneHandler) Query(w http.ResponseWriter, r *http.Request) {
+	caseIDStr := chi.URLParam(r, "caseId")
+	if caseIDStr == "" {
+		BadRequest(w, "Case ID is required")
+		return
+	}
+
+	caseID, err := uuid.Parse(caseIDStr)
+	if err != nil {
+		BadRequest(w, "Invalid case ID format")
+		return
+	}
+
+	sg, commitID, ok := h.sceneAt(w, r, caseID)
+	if !ok {
+		return
+	}
+
+	result, err := runSceneQuery(sg, r.URL.Query())
+	if err != nil {
+		BadRequest(w, "Invalid query: "+err.Error())
+		return
+	}
+	if commitID != uuid.Nil {
+		result.CommitID = commitID.String()
+	}
+	Success(w, http.StatusOK, result, nil)
+}
+
+// sceneAt resolves the scene a request asks for: as of ?commit_id=, or the
+// current snapshot. The commit ID is nil when the case has no snapshot yet.
+// It writes an error response and returns false when the scene can't be had.
+func (h *SceneHandler) sceneAt(w http.ResponseWriter, r *http.Request, caseID uuid.UUID) (*models.SceneGraph, uuid.UUID, bool) {
+	var commitID uuid.UUID
+	if s := r.URL.Query().Get("commit_id"); s != "" {
+		var err error
+		if commitID, err = uuid.Parse(s); err != nil {
+			BadRequest(w, "Invalid commit ID format")
+			return nil, commitID, false
+		}
+	}
+
+	if h.repo == nil {
+		NotFound(w, "Scene not found")
+		return nil, commitID, false
+	}
+
+	var sg *models.SceneGraph
+	if commitID != uuid.Nil {
+		commit, err := h.repo.GetCommit(r.Context(), commitID)
+		if err != nil {
+			InternalError(w, "Failed to retrieve commit")
+			return nil, commitID, false
+		}
+		if commit == nil || commit.CaseID != caseID {
+			NotFound(w, "Commit not found")
+			return nil, commitID, false
+		}
+		if sg, err = h.repo.ReplayToCommit(r.Context(), caseID, commitID); err != nil {
+			InternalError(w, "Failed to replay scene to commit")
+			return nil, commitID, false
+		}
+	} else {
+		snapshot, err := h.repo.GetSceneSnapshot(r.Context(), caseID)
+		if err != nil {
+			InternalError(w, "Failed to retrieve snapshot")
+			return nil, commitID, false
+		}
+		if snapshot == nil || snapshot.Scenegraph == nil {
+			sg = models.NewEmptySceneGraph()
+		} else {
+			sg = snapshot.Scenegraph
+			commitID = snapshot.CommitID
+		}
+	}
+
+	return sg, commitID, true
+}
+
+// FloorPlanSVG handles GET /v1/cases/{caseId}/floorplan.svg
+// It draws a top-down floor plan of the current snapshot, or the scene as of
+// ?commit_id=, with the trajectories of the latest reasoning result on that
+// history. ?width= sets the width in pixels; ?labels=false and
+// ?trajectories=false leave those out.
+func (h *SceneHandler) FloorPlanSVG(w http.ResponseWriter, r *http.Request) {
+	h.serveFloorPlan(w, r, floorplan.SVGContentType)
+}
+
+// FloorPlanPNG handles GET /v1/cases/{caseId}/floorplan.png
+// It serves the same floor plan as FloorPlanSVG as a PNG image.
+func (h *SceneHandler) FloorPlanPNG(w http.ResponseWriter, r *http.Request) {
+	h.serveFloorPlan(w, r, floorplan.PNGContentType)
+}
+
+func (h *SceneHandler) serveFloorPlan(w http.ResponseWriter, r *http.Request, contentType string) {
+	caseIDStr := chi.URLParam(r, "caseId")
+	if caseIDStr == "" {
+		BadRequest(w, "Case ID is required")
+		return
+	}
+
+	caseID, err := uuid.Parse(caseIDStr)
+	if err != nil {
+		BadRequest(w, "Invalid case ID format")
+		return
+	}
+
+	opts, err := parseFloorPlanOptions(r.URL.Query())
+	if err != nil {
+		BadRequest(w, "Invalid query: "+err.Error())
+		return
+	}
+
+	sg, commitID, ok := h.sceneAt(w, r, caseID)
+	if !ok {
+		return
+	}
+
+	var trajectories []models.Trajectory
+	if opts.Trajectories {
+		if trajectories, err = h.trajectoriesAt(r, caseID, commitID); err != nil {
+			InternalError(w, "Failed to retrieve timeline")
+			return
+		}
+	}
+
+	plan, err := floorplan.Build(sg, trajectories, opts)
+	if errors.Is(err, floorplan.ErrEmptyScene) {
+		NotFound(w, "Scene has no geometry to draw")
+		return
+	}
+	if err != nil {
+		BadRequest(w, "Invalid query: "+err.Error())
+		return
+	}
+
+	body := plan.SVG()
+	if contentType == floorplan.PNGContentType {
+		if body, err = plan.PNG(); err != nil {
+			InternalError(w, "Failed to render floor plan")
+			return
+		}
+	}
+	w.Header().Set("Content-Type", contentType)
+	if commitID != uuid.Nil {
+		w.Header().Set("X-Commit-ID", commitID.String())
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// trajectoriesAt returns the trajectories of the latest reasoning result in
+// the history leading to a commit, or none without a commit
+func (h *SceneHandler) trajectoriesAt(r *http.Request, caseID, commitID uuid.UUID) ([]models.Trajectory, error) {
+	if commitID == uuid.Nil {
+		return nil, nil
+	}
+	chain, err := h.repo.GetCommitChain(r.Context(), caseID, commitID)
+	if err != nil {
+		return nil, err
+	}
+	commits := make([]models.Commit, len(chain))
+	for i, c := range chain {
+		commits[i] = *c
+	}
+	return report.LatestTrajectories(commits), nil
+}
+
+// SceneGLB handles GET /v1/cases/{caseId}/scene.glb
+// It exports the current snapshot, or the scene as of ?commit_id=, as a
+// glTF 2.0 binary: proxy boxes for objects, evidence markers, uncertainty
+// regions and the trajectories of the latest reasoning result, with
+// references to the case's generated models. ?trajectories=false leaves the
+// trajectories out.
+func (h *SceneHandler) SceneGLB(w http.ResponseWriter, r *http.Request) {
+	caseIDStr := chi.URLParam(r, "caseId")
+	if caseIDStr == "" {
+		BadRequest(w, "Case ID is required")
+		return
+	}
+
+	caseID, err := uuid.Parse(caseIDStr)
+	if err != nil {
+		BadRequest(w, "Invalid case ID format")
+		return
+	}
+
+	withTrajectories := true
+	if s := r.URL.Query().Get("trajectories"); s != "" {
+		if withTrajectories, err = strconv.ParseBool(s); err != nil {
+			BadRequest(w, "Invalid query: trajectories must be true or false")
+			return
+		}
+	}
+
+	sg, commitID, ok := h.sceneAt(w, r, caseID)
+	if !ok {
+		return
+	}
+
+	in := gltf.Input{Scene: sg, CommitID: commitID}
+	if withTrajectories {
+		if in.Trajectories, err = h.trajectoriesAt(r, caseID, commitID); err != nil {
+			InternalError(w, "Failed to retrieve timeline")
+			return
+		}
+	}
+	kind := models.AssetKindEvidenceModel
+	assets, err := h.repo.GetAssetsByCase(r.Context(), caseID, &kind)
+	if err != nil {
+		InternalError(w, "Failed to retrieve assets")
+		return
+	}
+	for _, a := range assets {
+		in.Models = append(in.Models, *a)
+	}
+
+	body, err := gltf.Encode(in)
+	if err != nil {
+		InternalError(w, "Failed to export scene")
+		return
+	}
+	w.Header().Set("Content-Type", gltf.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="scene.%s"`, gltf.FileExtension))
+	if commitID != uuid.Nil {
+		w.Header().Set("X-Commit-ID", commitID.String())
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// parseFloorPlanOptions reads ?width=, ?labels= and ?trajectories=
+func parseFloorPlanOptions(values url.Values) (floorplan.Options, error) {
+	opts := floorplan.DefaultOptions()
+	if s := values.Get("width"); s != "" {
+		width, err := strconv.Atoi(s)
+		if err != nil || width < floorplan.MinWidth || width > floorplan.MaxWidth {
+			return opts, fmt.Errorf("width must be between %d and %d", floorplan.MinWidth, floorplan.MaxWidth)
+		}
+		opts.Width = width
+	}
+	for name, flag := range map[string]*bool{"labels": &opts.Labels, "trajectories": &opts.Trajectories} {
+		if s := values.Get(name); s != "" {
+			v, err := strconv.ParseBool(s)
+			if err != nil {
+				return opts, fmt.Errorf("%s must be true or false", name)
+			}
+			*flag = v
+		}
+	}
+	return opts, nil
+}
+
+// runSceneQuery indexes a scene and runs the query described by values
+func runSceneQuery(sg *models.SceneGraph, values url.Values) (*SceneQueryResult, error) {
+	filter, err := parseSceneFilter(values)
+	if err != nil {
+		return nil, err
+	}
+	idx := spatial.NewIndex(sg)
+
+	// Queries around an object use its box and leave it out of the results
+	objectBox := func(param string) (models.BoundingBox, bool, error) {
+		id := values.Get(param)
+		if id == "" {
+			return models.BoundingBox{}, false, nil
+		}
+		item, ok := idx.Lookup(spatial.KindObject, id)
+		if !ok {
+			return models.BoundingBox{}, false, fmt.Errorf("unknown object: %s", id)
+		}
+		filter.Exclude = append(filter.Exclude, id)
+		return item.BBox, true, nil
+	}
+	// around reads the query region from ?object= or ?center=
+	around := func() (models.BoundingBox, error) {
+		box, ok, err := objectBox("object")
+		if err != nil || ok {
+			return box, err
+		}
+		if values.Get("center") == "" {
+			return box, errors.New("center or object is required")
+		}
+		p, err := parseVec3(values, "center")
+		return spatial.PointBox(p), err
+	}
+
+	mode := values.Get("mode")
+	var hits []spatial.Hit
+	switch mode {
+	case sceneQueryRadius:
+		box, err := around()
+		if err != nil {
+			return nil, err
+		}
+		radius, err := parsePositiveFloat(values, "radius")
+		if err != nil {
+			return nil, err
+		}
+		hits = idx.Radius(box, radius, filter)
+
+	case sceneQueryBox:
+		box, ok, err := objectBox("object")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if box.Min, err = parseVec3(values, "min"); err != nil {
+				return nil, err
+			}
+			if box.Max, err = parseVec3(values, "max"); err != nil {
+				return nil, err
+			}
+			if err := box.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid box: %v", err)
+			}
+		}
+		hits = idx.Box(box, filter)
+
+	case sceneQueryRay:
+		var origin, dir [3]float64
+		var maxDist float64
+		from, fromOK, err := objectBox("from")
+		if err != nil {
+			return nil, err
+		}
+		to, toOK, err := objectBox("to")
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case fromOK && toOK:
+			origin = spatial.Centre(from)
+			end := spatial.Centre(to)
+			dir = [3]float64{end[0] - origin[0], end[1] - origin[1], end[2] - origin[2]}
+			maxDist = math.Sqrt(dir[0]*dir[0] + dir[1]*dir[1] + dir[2]*dir[2])
+		case fromOK || toOK:
+			return nil, errors.New("from and to must be given together")
+		default:
+			if origin, err = parseVec3(values, "origin"); err != nil {
+				return nil, err
+			}
+			if dir, err = parseVec3(values, "direction"); err != nil {
+				return nil, err
+			}
+			if values.Get("max_distance") != "" {
+				if maxDist, err = parsePositiveFloat(values, "max_distance"); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if dir == [3]float64{} {
+			return nil, errors.New("direction must not be zero")
+		}
+		hits = idx.Ray(origin, dir, maxDist, filter)
+
+	case sceneQueryNearest:
+		box, err := around()
+		if err != nil {
+			return nil, err
+		}
+		k := defaultNearestK
+		if s := values.Get("k"); s != "" {
+			if k, err = strconv.Atoi(s); err != nil || k < 1 || k > maxNearestK {
+				return nil, fmt.Errorf("k must be between 1 and %d", maxNearestK)
+			}
+		}
+		hits = idx.Nearest(box, k, filter)
+
+	default:
+		return nil, fmt.Errorf("mode must be one of %s, %s, %s, %s", sceneQueryRadius, sceneQueryBox, sceneQueryRay, sceneQueryNearest)
+	}
+
+	if hits == nil {
+		hits = []spatial.Hit{}
+	}
+	return &SceneQueryResult{Mode: mode, Count: len(hits), Hits: hits}, nil
+}
+
+// parseSceneFilter reads ?kinds=, ?types= and ?states=
+func parseSceneFilter(values url.Values) (spatial.Filter, error) {
+	var f spatial.Filter
+	for _, s := range splitList(values.Get("kinds")) {
+		k := spatial.ItemKind(s)
+		if !k.IsValid() {
+			return f, fmt.Errorf("invalid kind: %s", s)
+		}
+		f.Kinds = append(f.Kinds, k)
+	}
+	for _, s := range splitList(values.Get("types")) {
+		t := models.ObjectType(s)
+		if !t.IsValid() {
+			return f, fmt.Errorf("invalid object type: %s", s)
+		}
+		f.Types = append(f.Types, t)
+	}
+	for _, s := range splitList(values.Get("states")) {
+		st := models.ObjectState(s)
+		if !st.IsValid() {
+			return f, fmt.Errorf("invalid object state: %s", s)
+		}
+		f.States = append(f.States, st)
+	}
+	return f, nil
+}
+
+// parseVec3 reads a comma-separated x,y,z parameter
+func parseVec3(values url.Values, name string) ([3]float64, error) {
+	var v [3]float64
+	parts := strings.Split(values.Get(name), ",")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("%s must be x,y,z", name)
+	}
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return v, fmt.Errorf("%s must be x,y,z", name)
+		}
+		v[i] = f
+	}
+	return v, nil
+}
+
+func parsePositiveFloat(values url.Values, name string) (float64, error) {
+	f, err := strconv.ParseFloat(values.Get(name), 64)
+	if err != nil || !(f > 0) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("%s must be a positive number", name)
+	}
+	return f, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
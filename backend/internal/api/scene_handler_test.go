@@ -0,0 +1,296 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/sherlockos/backend/internal/clients"
+	"github.com/sherlockos/backend/internal/floorplan"
+	"github.com/sherlockos/backend/internal/models"
+	"github.com/sherlockos/backend/internal/pointcloud"
+)
+
+func TestSceneHandler_PointCloud_Validation(t *testing.T) {
+	handler := NewSceneHandler(nil, nil)
+
+	tests := []struct {
+		name       string
+		caseID     string
+		query      string
+		wantStatus int
+	}{
+		{"invalid case id", "not-a-uuid", "", http.StatusBadRequest},
+		{"unsupported format", testCaseID, "?format=las", http.StatusBadRequest},
+		{"no database", testCaseID, "?format=sqpc", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/cases/"+tt.caseID+"/pointcloud"+tt.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("caseId", tt.caseID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := httptest.NewRecorder()
+
+			handler.PointCloud(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("PointCloud() status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSceneHandler_ServePointCloud(t *testing.T) {
+	full := &models.PointCloud{
+		Positions: [][]float64{{0, 0, 0}, {1, 2, 3}, {4, 5, 6}},
+		Colors:    [][]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+		Count:     3,
+	}
+	stored, _ := pointcloud.Marshal(full, models.PointCloudFormatPLY)
+	key := "cases/" + testCaseID + "/pointclouds/job.ply"
+
+	storage := &clients.MockStorageClient{
+		DownloadFunc: func(ctx context.Context, bucket, k string) ([]byte, string, error) {
+			if k != key {
+				return nil, "", errors.New("object not found")
+			}
+			return stored, "application/ply", nil
+		},
+	}
+	reference := &models.PointCloud{
+		Positions: full.Positions[:1], // preview
+		Count:     3,
+		AssetKey:  key,
+		Format:    models.PointCloudFormatPLY,
+	}
+
+	tests := []struct {
+		name       string
+		storage    clients.StorageClient
+		pc         *models.PointCloud
+		format     models.PointCloudFormat
+		rangeHdr   string
+		wantStatus int
+		wantPoints int
+	}{
+		{"stored ply", storage, reference, models.PointCloudFormatPLY, "", http.StatusOK, 3},
+		{"stored ply range", storage, reference, models.PointCloudFormatPLY, "bytes=0-2", http.StatusPartialContent, 0},
+		{"stored ply as quantized", storage, reference, models.PointCloudFormatQuantized, "", http.StatusOK, 3},
+		{"inline legacy cloud", nil, full, models.PointCloudFormatPLY, "", http.StatusOK, 3},
+		{"stored without storage", nil, reference, models.PointCloudFormatPLY, "", http.StatusServiceUnavailable, 0},
+		{"missing object", storage, &models.PointCloud{AssetKey: "gone.ply", Count: 3}, models.PointCloudFormatPLY, "", http.StatusInternalServerError, 0},
+		{"empty inline cloud", nil, &models.PointCloud{}, models.PointCloudFormatPLY, "", http.StatusNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &SceneHandler{storage: tt.storage}
+			req := httptest.NewRequest(http.MethodGet, "/cases/"+testCaseID+"/pointcloud", nil)
+			if tt.rangeHdr != "" {
+				req.Header.Set("Range", tt.rangeHdr)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.servePointCloud(rr, req, tt.pc, tt.format)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("servePointCloud() status = %d, want %d: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.rangeHdr != "" && rr.Body.String() != "ply" {
+				t.Errorf("range body = %q, want %q", rr.Body.String(), "ply")
+			}
+			if tt.wantPoints == 0 {
+				return
+			}
+
+			got, err := pointcloud.Decode(bytes.NewReader(rr.Body.Bytes()), tt.format)
+			if err != nil {
+				t.Fatalf("response does not decode as %s: %v", tt.format, err)
+			}
+			if len(got.Positions) != tt.wantPoints {
+				t.Errorf("response points = %d, want %d", len(got.Positions), tt.wantPoints)
+			}
+			if rr.Header().Get("Content-Type") != pointcloud.ContentType(tt.format) {
+				t.Errorf("Content-Type = %q", rr.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestSceneHandler_Query_Validation(t *testing.T) {
+	handler := NewSceneHandler(nil, nil)
+
+	tests := []struct {
+		name       string
+		caseID     string
+		query      string
+		wantStatus int
+	}{
+		{"invalid case id", "not-a-uuid", "?mode=radius", http.StatusBadRequest},
+		{"invalid commit id", testCaseID, "?mode=radius&commit_id=abc", http.StatusBadRequest},
+		{"no database", testCaseID, "?mode=radius&center=0,0,0&radius=1", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/cases/"+tt.caseID+"/scene/query"+tt.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("caseId", tt.caseID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := httptest.NewRecorder()
+
+			handler.Query(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Query() status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRunSceneQuery(t *testing.T) {
+	sg := models.NewEmptySceneGraph()
+	sg.Objects = []models.SceneObject{
+		{ID: "body", Type: models.ObjectTypePersonMarker, State: models.ObjectStateVisible,
+			BBox: models.BoundingBox{Min: [3]float64{0, 0, 0}, Max: [3]float64{1.8, 0.3, 0.5}}},
+		{ID: "knife", Type: models.ObjectTypeWeapon, State: models.ObjectStateSuspicious,
+			BBox: models.BoundingBox{Min: [3]float64{2, 0, 0}, Max: [3]float64{2.3, 0.05, 0.1}}},
+		{ID: "door", Type: models.ObjectTypeDoor, State: models.ObjectStateVisible,
+			BBox: models.BoundingBox{Min: [3]float64{0, 0, 5}, Max: [3]float64{1, 2, 5.1}}},
+		{ID: "chair", Type: models.ObjectTypeFurniture, State: models.ObjectStateVisible,
+			BBox: models.BoundingBox{Min: [3]float64{3, 0, 4.9}, Max: [3]float64{3.5, 1.5, 5.3}}},
+		{ID: "window", Type: models.ObjectTypeWindow, State: models.ObjectStateVisible,
+			BBox: models.BoundingBox{Min: [3]float64{6, 0.8, 5}, Max: [3]float64{7, 2, 5.1}}},
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    []string
+		wantErr bool
+	}{
+		{"radius around an object", "mode=radius&object=body&radius=2", []string{"knife"}, false},
+		{"radius from a point", "mode=radius&center=3.2,0.5,5&radius=0.1", []string{"chair"}, false},
+		{"box", "mode=box&min=0,0,0&max=5,2,1&types=weapon", []string{"knife"}, false},
+		{"ray between objects", "mode=ray&from=door&to=window", []string{"chair"}, false},
+		{"ray from origin", "mode=ray&origin=0.1,0.01,0.05&direction=1,0,0&max_distance=2", []string{"body", "knife"}, false},
+		{"nearest with state filter", "mode=nearest&center=0,0,0&k=2&states=visible", []string{"body", "door"}, false},
+		{"unknown mode", "mode=cone", nil, true},
+		{"unknown object", "mode=radius&object=ghost&radius=1", nil, true},
+		{"missing radius", "mode=radius&center=0,0,0", nil, true},
+		{"bad vector", "mode=nearest&center=0,0", nil, true},
+		{"inverted box", "mode=box&min=1,1,1&max=0,0,0", nil, true},
+		{"half a segment", "mode=ray&from=door", nil, true},
+		{"k too large", "mode=nearest&center=0,0,0&k=1000", nil, true},
+		{"invalid type", "mode=box&min=0,0,0&max=1,1,1&types=spaceship", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			result, err := runSceneQuery(sg, values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runSceneQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got []string
+			for _, h := range result.Hits {
+				got = append(got, h.ID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") || result.Count != len(tt.want) {
+				t.Errorf("runSceneQuery() hits = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSceneHandler_FloorPlan_Validation(t *testing.T) {
+	handler := NewSceneHandler(nil, nil)
+	r := chi.NewRouter()
+	r.Get("/cases/{caseId}/floorplan.svg", handler.FloorPlanSVG)
+	r.Get("/cases/{caseId}/floorplan.png", handler.FloorPlanPNG)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantErr    string
+	}{
+		{"invalid case id", "/cases/not-a-uuid/floorplan.svg", http.StatusBadRequest, "Invalid case ID format"},
+		{"width too small", "/cases/" + testCaseID + "/floorplan.svg?width=10", http.StatusBadRequest, "Invalid query: width must be between 200 and 4096"},
+		{"bad flag", "/cases/" + testCaseID + "/floorplan.png?labels=maybe", http.StatusBadRequest, "Invalid query: labels must be true or false"},
+		{"invalid commit id", "/cases/" + testCaseID + "/floorplan.svg?commit_id=abc", http.StatusBadRequest, "Invalid commit ID format"},
+		{"no database", "/cases/" + testCaseID + "/floorplan.png?width=400&trajectories=false", http.StatusNotFound, "Scene not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("FloorPlan() status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if msg := getErrorMessage(rr.Body.Bytes()); msg != tt.wantErr {
+				t.Errorf("FloorPlan() error = %q, want %q", msg, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSceneHandler_SceneGLB_Validation(t *testing.T) {
+	handler := NewSceneHandler(nil, nil)
+	r := chi.NewRouter()
+	r.Get("/cases/{caseId}/scene.glb", handler.SceneGLB)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantErr    string
+	}{
+		{"invalid case id", "/cases/not-a-uuid/scene.glb", http.StatusBadRequest, "Invalid case ID format"},
+		{"bad flag", "/cases/" + testCaseID + "/scene.glb?trajectories=maybe", http.StatusBadRequest, "Invalid query: trajectories must be true or false"},
+		{"invalid commit id", "/cases/" + testCaseID + "/scene.glb?commit_id=abc", http.StatusBadRequest, "Invalid commit ID format"},
+		{"no database", "/cases/" + testCaseID + "/scene.glb?trajectories=false", http.StatusNotFound, "Scene not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("SceneGLB() status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if msg := getErrorMessage(rr.Body.Bytes()); msg != tt.wantErr {
+				t.Errorf("SceneGLB() error = %q, want %q", msg, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseFloorPlanOptions(t *testing.T) {
+	values, _ := url.ParseQuery("width=1200&labels=false")
+	opts, err := parseFloorPlanOptions(values)
+	if err != nil {
+		t.Fatalf("parseFloorPlanOptions() error = %v", err)
+	}
+	if opts.Width != 1200 || opts.Labels || !opts.Trajectories {
+		t.Errorf("parseFloorPlanOptions() = %+v", opts)
+	}
+	if opts, _ := parseFloorPlanOptions(url.Values{}); opts != floorplan.DefaultOptions() {
+		t.Errorf("parseFloorPlanOptions() defaults = %+v", opts)
+	}
+}
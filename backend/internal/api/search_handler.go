@@ -0,0 +1,113 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/sherlockos/backend/internal/auth"
+	"github.com/sherlockos/backend/internal/db"
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// SearchHandler handles full-text search across cases
+type SearchHandler struct {
+	repo *db.Repository
+}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler(database *db.DB) *SearchHandler {
+	var repo *db.Repository
+	if database != nil {
+		repo = db.NewRepository(database)
+	}
+	return &SearchHandler{repo: repo}
+}
+
+// SearchResult is the response of a case search
+type SearchResult struct {
+	Query string                    `json:"query"`
+	Total int                       `json:"total"` // hits across all cases
+	Cases []models.CaseSearchResult `json:"cases"`
+}
+
+// Search handles GET /v1/search
+// It searches case titles and descriptions, commit summaries and payloads,
+// witness statements, evidence cards and object labels for ?q=, and groups
+// the matches by case, best first. ?commit_type= (repeated or comma
+// separated) keeps matches in commits of those types; ?from= and ?to= take
+// RFC 3339 times or dates, with ?to= dates including the whole day;
+// ?limit= caps the number of hits. Signed-in users only find cases they are
+// a member of.
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	q, err := parseSearchQuery(r.URL.Query())
+	if err != nil {
+		BadRequest(w, "Invalid query: "+err.Error())
+		return
+	}
+	q.MemberID = auth.UserID(r.Context())
+	setAuditDetail(r, q.Text)
+
+	if h.repo == nil {
+		Success(w, http.StatusOK, SearchResult{Query: q.Text, Cases: []models.CaseSearchResult{}}, nil)
+		return
+	}
+
+	hits, err := h.repo.SearchCases(r.Context(), q)
+	if err != nil {
+		InternalError(w, "Failed to search cases")
+		return
+	}
+
+	Success(w, http.StatusOK, SearchResult{Query: q.Text, Total: len(hits), Cases: models.GroupSearchHits(hits)}, nil)
+}
+
+// parseSearchQuery reads ?q=, ?commit_type=, ?from=, ?to= and ?limit=
+func parseSearchQuery(values url.Values) (*models.SearchQuery, error) {
+	q := &models.SearchQuery{Text: values.Get("q")}
+	for _, v := range values["commit_type"] {
+		for _, t := range splitList(v) {
+			q.CommitTypes = append(q.CommitTypes, models.CommitType(t))
+		}
+	}
+
+	var err error
+	if q.From, err = parseSearchTime(values.Get("from"), false); err != nil {
+		return nil, fmt.Errorf("from %v", err)
+	}
+	if q.To, err = parseSearchTime(values.Get("to"), true); err != nil {
+		return nil, fmt.Errorf("to %v", err)
+	}
+	if s := values.Get("limit"); s != "" {
+		if q.Limit, err = strconv.Atoi(s); err != nil || q.Limit < 1 {
+			return nil, fmt.Errorf("limit must be between 1 and %d", models.MaxSearchLimit)
+		}
+	}
+
+	q.SetDefaults()
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// parseSearchTime reads an RFC 3339 time or a date. A date that ends a
+// range means the end of that day.
+func parseSearchTime(s string, end bool) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return nil, fmt.Errorf("must be an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
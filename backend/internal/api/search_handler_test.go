@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+func TestSearchHandler_Search(t *testing.T) {
+	handler := NewSearchHandler(nil)
+	r := chi.NewRouter()
+	r.Get("/search", handler.Search)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantErr    string
+	}{
+		{"missing query", "/search", http.StatusBadRequest, "Invalid query: q is required"},
+		{"unknown commit type", "/search?q=jacket&commit_type=note", http.StatusBadRequest, "Invalid query: invalid commit type: note"},
+		{"bad date", "/search?q=jacket&from=yesterday", http.StatusBadRequest, "Invalid query: from must be an RFC 3339 time or a YYYY-MM-DD date"},
+		{"bad limit", "/search?q=jacket&limit=0", http.StatusBadRequest, "Invalid query: limit must be between 1 and 200"},
+		{"no database", "/search?q=red+jacket", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Search() status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if tt.wantErr != "" {
+				if msg := getErrorMessage(rr.Body.Bytes()); msg != tt.wantErr {
+					t.Errorf("Search() error = %q, want %q", msg, tt.wantErr)
+				}
+				return
+			}
+			data := getData(rr.Body.Bytes())
+			if data["query"] != "red jacket" {
+				t.Errorf("Search() data = %v", data)
+			}
+			if cases, ok := data["cases"].([]interface{}); !ok || len(cases) != 0 {
+				t.Errorf("Search() cases = %v, want an empty list", data["cases"])
+			}
+		})
+	}
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	values, _ := url.ParseQuery("q=red+jacket&commit_type=witness_statement,upload_scan&commit_type=manual_edit&from=2026-10-01&to=2026-10-17&limit=20")
+	q, err := parseSearchQuery(values)
+	if err != nil {
+		t.Fatalf("parseSearchQuery() error = %v", err)
+	}
+	wantTypes := []models.CommitType{models.CommitTypeWitnessStatement, models.CommitTypeUploadScan, models.CommitTypeManualEdit}
+	if len(q.CommitTypes) != len(wantTypes) {
+		t.Fatalf("CommitTypes = %v, want %v", q.CommitTypes, wantTypes)
+	}
+	for i, want := range wantTypes {
+		if q.CommitTypes[i] != want {
+			t.Errorf("CommitTypes[%d] = %s, want %s", i, q.CommitTypes[i], want)
+		}
+	}
+	// A closing date covers the whole day
+	if !q.From.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) || !q.To.Equal(time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("range = %v to %v", q.From, q.To)
+	}
+	if q.Limit != 20 {
+		t.Errorf("Limit = %d, want 20", q.Limit)
+	}
+
+	values, _ = url.ParseQuery("q=jacket&to=2026-10-17T12:00:00Z")
+	if q, err = parseSearchQuery(values); err != nil || q.From != nil || q.To.Hour() != 12 || q.Limit != models.DefaultSearchLimit {
+		t.Errorf("parseSearchQuery() = %+v, %v", q, err)
+	}
+}
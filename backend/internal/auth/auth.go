@@ -0,0 +1,65 @@
+// Package auth verifies bearer tokens and carries the signed-in user
+// through request and job contexts.
+//
+// Tokens are HS256 JWTs. In a hosted install they are issued by Supabase Auth
+// and checked against the project's JWT secret; offline installs sign their
+// own with a local secret (see LocalIssuer).
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// Auth modes
+const (
+	ModeSupabase = "supabase"
+	ModeLocal    = "local"
+	ModeDisabled = "disabled"
+)
+
+// Verification errors
+var (
+	ErrMissingToken = errors.New("missing bearer token")
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token expired")
+)
+
+// User is the signed-in user a request or job runs as
+type User struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email,omitempty"`
+}
+
+// Verifier checks a bearer token and returns the user it was issued to
+type Verifier interface {
+	Verify(ctx context.Context, token string) (*User, error)
+}
+
+type contextKey struct{}
+
+// WithUser returns a copy of ctx carrying the user
+func WithUser(ctx context.Context, u *User) context.Context {
+	return context.WithValue(ctx, contextKey{}, u)
+}
+
+// FromContext returns the user carried by ctx, or nil when the request or
+// job runs without one (authentication disabled, or a job queued before
+// users existed)
+func FromContext(ctx context.Context) *User {
+	u, _ := ctx.Value(contextKey{}).(*User)
+	return u
+}
+
+// UserID returns the ID of the user carried by ctx, or nil; it's what
+// created_by fields are set from
+func UserID(ctx context.Context) *uuid.UUID {
+	u := FromContext(ctx)
+	if u == nil {
+		return nil
+	}
+	id := u.ID
+	return &id
+}
@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SupabaseAudience is the audience Supabase Auth puts on tokens of signed-in
+// users
+const SupabaseAudience = "authenticated"
+
+// LocalIssuerName is the issuer of tokens signed by a LocalIssuer
+const LocalIssuerName = "sherlockos"
+
+// clockSkew is how far token times may be off from ours
+const clockSkew = time.Minute
+
+// Claims are the JWT claims SherlockOS reads
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Email     string   `json:"email,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+// Audience is a JWT audience, which may be a single string or a list
+type Audience []string
+
+// UnmarshalJSON accepts both forms of the aud claim
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*a = Audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// MarshalJSON writes a single audience as a string
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// Contains reports whether aud is one of the audiences
+func (a Audience) Contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
+
+// HMACVerifier verifies HS256 tokens signed with a shared secret
+type HMACVerifier struct {
+	secret   []byte
+	issuer   string // required iss; empty accepts any
+	audience string // required aud; empty accepts any
+	now      func() time.Time
+}
+
+// NewHMACVerifier creates a verifier for tokens signed with secret. An empty
+// issuer or audience isn't checked.
+func NewHMACVerifier(secret, issuer, audience string) *HMACVerifier {
+	return &HMACVerifier{secret: []byte(secret), issuer: issuer, audience: audience, now: time.Now}
+}
+
+// NewSupabaseVerifier creates a verifier for Supabase Auth access tokens,
+// signed with the project's JWT secret
+func NewSupabaseVerifier(jwtSecret string) *HMACVerifier {
+	return NewHMACVerifier(jwtSecret, "", SupabaseAudience)
+}
+
+// Verify checks the token's signature, times, issuer and audience
+func (v *HMACVerifier) Verify(ctx context.Context, token string) (*User, error) {
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+	claims, err := parseHS256(token, v.secret)
+	if err != nil {
+		return nil, err
+	}
+
+	now := v.now()
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(clockSkew)) {
+		return nil, ErrExpiredToken
+	}
+	if claims.NotBefore != 0 && now.Add(clockSkew).Before(time.Unix(claims.NotBefore, 0)) {
+		return nil, fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if v.audience != "" && !claims.Audience.Contains(v.audience) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+
+	id, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: subject is not a user ID", ErrInvalidToken)
+	}
+	return &User{ID: id, Email: claims.Email}, nil
+}
+
+// LocalIssuer signs tokens for offline installs that have no Supabase Auth
+type LocalIssuer struct {
+	secret []byte
+	now    func() time.Time
+}
+
+// NewLocalIssuer creates an issuer signing with secret
+func NewLocalIssuer(secret string) *LocalIssuer {
+	return &LocalIssuer{secret: []byte(secret), now: time.Now}
+}
+
+// Issue signs a token for the user, valid for ttl
+func (i *LocalIssuer) Issue(u User, ttl time.Duration) (string, error) {
+	now := i.now()
+	return signHS256(Claims{
+		Subject:   u.ID.String(),
+		Email:     u.Email,
+		Issuer:    LocalIssuerName,
+		Audience:  Audience{SupabaseAudience},
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}, i.secret)
+}
+
+// Verifier returns a verifier for the tokens this issuer signs
+func (i *LocalIssuer) Verifier() *HMACVerifier {
+	return NewHMACVerifier(string(i.secret), LocalIssuerName, SupabaseAudience)
+}
+
+// NewVerifier creates the verifier for an auth mode. The disabled mode has
+// no verifier.
+func NewVerifier(mode, supabaseJWTSecret, localSecret string) (Verifier, error) {
+	switch mode {
+	case ModeSupabase:
+		if supabaseJWTSecret == "" {
+			return nil, errors.New("supabase auth needs SUPABASE_JWT_SECRET")
+		}
+		return NewSupabaseVerifier(supabaseJWTSecret), nil
+	case ModeLocal:
+		if localSecret == "" {
+			return nil, errors.New("local auth needs AUTH_LOCAL_SECRET")
+		}
+		return NewLocalIssuer(localSecret).Verifier(), nil
+	case ModeDisabled:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown auth mode %q", mode)
+	}
+}
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// signHS256 encodes and signs claims as a compact JWT
+func signHS256(claims Claims, secret []byte) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(hmacSHA256(signingInput, secret)), nil
+}
+
+// parseHS256 checks a compact JWT's HS256 signature and decodes its claims
+func parseHS256(token string, secret []byte) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Alg != "HS256" {
+		return nil, fmt.Errorf("%w: unsupported algorithm", ErrInvalidToken)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, hmacSHA256(parts[0]+"."+parts[1], secret)) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	return &claims, nil
+}
+
+func hmacSHA256(input string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestLocalIssuer_RoundTrip(t *testing.T) {
+	issuer := NewLocalIssuer("offline-secret")
+	user := User{ID: uuid.New(), Email: "detective@example.com"}
+
+	token, err := issuer.Issue(user, time.Hour)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	got, err := issuer.Verifier().Verify(context.Background(), token)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if got.ID != user.ID || got.Email != user.Email {
+		t.Errorf("Verify() = %+v, want %+v", got, user)
+	}
+}
+
+func TestHMACVerifier_Rejects(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	issuer := NewLocalIssuer("offline-secret")
+	issuer.now = func() time.Time { return now }
+	user := User{ID: uuid.New()}
+	token, _ := issuer.Issue(user, time.Hour)
+
+	sign := func(c Claims) string {
+		s, _ := signHS256(c, []byte("offline-secret"))
+		return s
+	}
+	exp := now.Add(time.Hour).Unix()
+
+	tests := []struct {
+		name     string
+		verifier *HMACVerifier
+		token    string
+		want     error
+	}{
+		{name: "empty", verifier: issuer.Verifier(), token: "", want: ErrMissingToken},
+		{name: "malformed", verifier: issuer.Verifier(), token: "abc.def", want: ErrInvalidToken},
+		{name: "wrong secret", verifier: NewLocalIssuer("other").Verifier(), token: token, want: ErrInvalidToken},
+		{name: "tampered", verifier: issuer.Verifier(), token: token[:len(token)-2] + "xx", want: ErrInvalidToken},
+		{name: "wrong issuer", verifier: NewHMACVerifier("offline-secret", "elsewhere", ""), token: token, want: ErrInvalidToken},
+		{name: "wrong audience", verifier: NewHMACVerifier("offline-secret", "", "service_role"), token: token, want: ErrInvalidToken},
+		{name: "subject not a UUID", verifier: NewSupabaseVerifier("offline-secret"), token: sign(Claims{Subject: "anon", Audience: Audience{SupabaseAudience}, ExpiresAt: exp}), want: ErrInvalidToken},
+		{name: "no expiry", verifier: NewSupabaseVerifier("offline-secret"), token: sign(Claims{Subject: user.ID.String(), Audience: Audience{SupabaseAudience}}), want: ErrExpiredToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.verifier.now = func() time.Time { return now }
+			if _, err := tt.verifier.Verify(context.Background(), tt.token); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// Expired once the skew allowance has passed
+	v := issuer.Verifier()
+	v.now = func() time.Time { return now.Add(time.Hour + 2*clockSkew) }
+	if _, err := v.Verify(context.Background(), token); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("Verify() of an expired token error = %v", err)
+	}
+}
+
+func TestSupabaseVerifier_AudienceList(t *testing.T) {
+	id := uuid.New()
+	token, _ := signHS256(Claims{
+		Subject:   id.String(),
+		Audience:  Audience{"authenticated", "storage"},
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}, []byte("project-secret"))
+	if !strings.Contains(token, ".") {
+		t.Fatal("signHS256() returned no token")
+	}
+
+	got, err := NewSupabaseVerifier("project-secret").Verify(context.Background(), token)
+	if err != nil || got.ID != id {
+		t.Errorf("Verify() = %v, %v", got, err)
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	if _, err := NewVerifier(ModeSupabase, "", ""); err == nil {
+		t.Error("supabase mode without a secret should fail")
+	}
+	if _, err := NewVerifier(ModeLocal, "", ""); err == nil {
+		t.Error("local mode without a secret should fail")
+	}
+	if v, err := NewVerifier(ModeDisabled, "", ""); v != nil || err != nil {
+		t.Errorf("disabled mode = %v, %v", v, err)
+	}
+	if _, err := NewVerifier("oauth", "", ""); err == nil {
+		t.Error("unknown mode should fail")
+	}
+}
+
+func TestUserContext(t *testing.T) {
+	if UserID(context.Background()) != nil {
+		t.Error("UserID() of a context without a user should be nil")
+	}
+	u := &User{ID: uuid.New()}
+	ctx := WithUser(context.Background(), u)
+	if FromContext(ctx) != u || *UserID(ctx) != u.ID {
+		t.Error("user not carried by context")
+	}
+}
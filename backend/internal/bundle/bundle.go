@@ -0,0 +1,363 @@
+// Package bundle packs a whole case into a zip archive for moving it between
+// environments: the case, its commits in parent order, branches, scene
+// snapshot, suspect profile, asset records and the stored asset files, with
+// a manifest of SHA-256 hashes. Read verifies an archive against its manifest
+// before anything is imported, and Remap gives the case fresh IDs.
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// FormatVersion is the bundle layout this package writes and reads
+const FormatVersion = 1
+
+// MaxUnpackedSize limits the total size of the files in a bundle, so a small
+// archive can't expand without bound
+const MaxUnpackedSize = 1 << 30
+
+// Paths within the archive. Stored files go under objectsDir at their
+// storage key.
+const (
+	ManifestPath = "manifest.json"
+	casePath     = "case.json"
+	commitsPath  = "commits.json"
+	branchesPath = "branches.json"
+	snapshotPath = "snapshot.json"
+	profilePath  = "suspect_profile.json"
+	assetsPath   = "assets.json"
+	objectsDir   = "objects/"
+)
+
+// Object is a stored file belonging to the case, such as a scan or a mesh
+type Object struct {
+	StorageKey  string
+	ContentType string
+	Data        []byte
+}
+
+// Bundle is everything that makes up a case
+type Bundle struct {
+	Case     models.Case
+	Commits  []models.Commit // parents before children
+	Branches []models.Branch
+	Snapshot *models.SceneSnapshot
+	Profile  *models.SuspectProfile
+	Assets   []models.Asset
+	Objects  []Object
+}
+
+// Manifest lists every file in the archive with its hash
+type Manifest struct {
+	FormatVersion  int       `json:"format_version"`
+	CaseID         uuid.UUID `json:"case_id"`
+	ExportedAt     time.Time `json:"exported_at"`
+	Files          []File    `json:"files"`
+	MissingObjects []string  `json:"missing_objects,omitempty"` // asset files that couldn't be fetched at export
+}
+
+// File is one file in the archive. Stored files also record where they live.
+type File struct {
+	Path        string `json:"path"`
+	SHA256      string `json:"sha256"`
+	Size        int64  `json:"size"`
+	StorageKey  string `json:"storage_key,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// SortCommits orders commits so every parent comes before its children, and
+// otherwise oldest first
+func SortCommits(commits []models.Commit) []models.Commit {
+	sorted := append([]models.Commit{}, commits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+		}
+		return sorted[i].ID.String() < sorted[j].ID.String()
+	})
+
+	present := map[uuid.UUID]bool{}
+	for _, c := range sorted {
+		present[c.ID] = true
+	}
+	children := map[uuid.UUID][]int{}
+	var ready []int
+	for i, c := range sorted {
+		if c.ParentCommitID != nil && present[*c.ParentCommitID] {
+			children[*c.ParentCommitID] = append(children[*c.ParentCommitID], i)
+		} else {
+			ready = append(ready, i)
+		}
+	}
+
+	// Kahn's algorithm, always taking the oldest commit that is ready
+	out := make([]models.Commit, 0, len(sorted))
+	for len(ready) > 0 {
+		sort.Ints(ready)
+		i := ready[0]
+		ready = ready[1:]
+		out = append(out, sorted[i])
+		ready = append(ready, children[sorted[i].ID]...)
+	}
+	return out
+}
+
+// Write writes the bundle as a zip archive. missing lists asset files that
+// couldn't be fetched, recorded in the manifest so the gap is visible.
+func (b *Bundle) Write(w io.Writer, exportedAt time.Time, missing []string) error {
+	manifest := Manifest{FormatVersion: FormatVersion, CaseID: b.Case.ID, ExportedAt: exportedAt, MissingObjects: missing}
+	zw := zip.NewWriter(w)
+
+	add := func(f File, data []byte) error {
+		sum := sha256.Sum256(data)
+		f.SHA256, f.Size = hex.EncodeToString(sum[:]), int64(len(data))
+		manifest.Files = append(manifest.Files, f)
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.Path, Method: zip.Deflate, Modified: exportedAt})
+		if err != nil {
+			return err
+		}
+		_, err = fw.Write(data)
+		return err
+	}
+
+	sorted := *b
+	sorted.Commits = SortCommits(b.Commits)
+	if sorted.Branches == nil {
+		sorted.Branches = []models.Branch{}
+	}
+	if sorted.Assets == nil {
+		sorted.Assets = []models.Asset{}
+	}
+	for _, doc := range sorted.documents() {
+		data, err := json.MarshalIndent(doc.v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", doc.path, err)
+		}
+		if !doc.required && string(data) == "null" {
+			continue
+		}
+		if err := add(File{Path: doc.path}, data); err != nil {
+			return err
+		}
+	}
+	for _, obj := range b.Objects {
+		f := File{Path: objectsDir + obj.StorageKey, StorageKey: obj.StorageKey, ContentType: obj.ContentType}
+		if err := add(f, obj.Data); err != nil {
+			return err
+		}
+	}
+
+	// The manifest goes last, once every hash is known
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	fw, err := zw.Create(ManifestPath)
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// document is one of the JSON files describing the case
+type document struct {
+	path     string
+	v        interface{}
+	required bool
+}
+
+func (b *Bundle) documents() []document {
+	return []document{
+		{casePath, &b.Case, true},
+		{commitsPath, &b.Commits, true},
+		{branchesPath, &b.Branches, true},
+		{assetsPath, &b.Assets, true},
+		{snapshotPath, &b.Snapshot, false},
+		{profilePath, &b.Profile, false},
+	}
+}
+
+// Read opens a bundle and verifies it: every file listed in the manifest must
+// be present with the recorded size and hash, nothing else may be in the
+// archive, and every reference between commits, branches, the snapshot and
+// the profile must resolve within the bundle
+func Read(r io.ReaderAt, size int64) (*Bundle, *Manifest, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("not a zip archive: %w", err)
+	}
+	entries := map[string]*zip.File{}
+	for _, f := range zr.File {
+		if _, dup := entries[f.Name]; dup {
+			return nil, nil, fmt.Errorf("duplicate file %s", f.Name)
+		}
+		entries[f.Name] = f
+	}
+
+	mf, ok := entries[ManifestPath]
+	if !ok {
+		return nil, nil, errors.New("missing manifest.json")
+	}
+	raw, err := readEntry(mf, 16<<20)
+	if err != nil {
+		return nil, nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if manifest.FormatVersion != FormatVersion {
+		return nil, nil, fmt.Errorf("unsupported bundle format version %d", manifest.FormatVersion)
+	}
+
+	var total int64
+	for _, f := range manifest.Files {
+		if f.Size < 0 || f.Size > MaxUnpackedSize {
+			return nil, nil, fmt.Errorf("invalid size for %s", f.Path)
+		}
+		total += f.Size
+	}
+	if total > MaxUnpackedSize {
+		return nil, nil, fmt.Errorf("bundle unpacks to %d bytes, more than the %d allowed", total, MaxUnpackedSize)
+	}
+
+	files := map[string][]byte{}
+	b := &Bundle{}
+	for _, f := range manifest.Files {
+		entry, ok := entries[f.Path]
+		if !ok {
+			return nil, nil, fmt.Errorf("missing file %s", f.Path)
+		}
+		if _, dup := files[f.Path]; dup {
+			return nil, nil, fmt.Errorf("manifest lists %s twice", f.Path)
+		}
+		data, err := readEntry(entry, f.Size)
+		if err != nil {
+			return nil, nil, err
+		}
+		sum := sha256.Sum256(data)
+		if int64(len(data)) != f.Size || hex.EncodeToString(sum[:]) != f.SHA256 {
+			return nil, nil, fmt.Errorf("integrity check failed for %s", f.Path)
+		}
+		files[f.Path] = data
+
+		if f.StorageKey != "" {
+			if f.Path != objectsDir+f.StorageKey {
+				return nil, nil, fmt.Errorf("%s does not match its storage key", f.Path)
+			}
+			b.Objects = append(b.Objects, Object{StorageKey: f.StorageKey, ContentType: f.ContentType, Data: data})
+		}
+	}
+	for name := range entries {
+		if _, ok := files[name]; !ok && name != ManifestPath {
+			return nil, nil, fmt.Errorf("file %s is not in the manifest", name)
+		}
+	}
+
+	for _, doc := range b.documents() {
+		data, ok := files[doc.path]
+		if !ok {
+			if doc.required {
+				return nil, nil, fmt.Errorf("missing %s", doc.path)
+			}
+			continue
+		}
+		if err := json.Unmarshal(data, doc.v); err != nil {
+			return nil, nil, fmt.Errorf("invalid %s: %w", doc.path, err)
+		}
+	}
+
+	if err := b.check(manifest.CaseID); err != nil {
+		return nil, nil, err
+	}
+	return b, &manifest, nil
+}
+
+// readEntry decompresses a file, reading no more than limit bytes
+func readEntry(f *zip.File, limit int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	if n > limit {
+		return nil, fmt.Errorf("%s is larger than recorded", f.Name)
+	}
+	return buf.Bytes(), nil
+}
+
+// check verifies that everything belongs to the case and that references
+// resolve within the bundle
+func (b *Bundle) check(caseID uuid.UUID) error {
+	if b.Case.ID != caseID {
+		return errors.New("case.json does not match the manifest")
+	}
+	if err := b.Case.Validate(); err != nil {
+		return fmt.Errorf("invalid case: %w", err)
+	}
+
+	commits := map[uuid.UUID]bool{}
+	branches := map[uuid.UUID]bool{}
+	for _, br := range b.Branches {
+		if br.CaseID != caseID {
+			return fmt.Errorf("branch %s belongs to another case", br.ID)
+		}
+		branches[br.ID] = true
+	}
+	for _, c := range b.Commits {
+		if c.CaseID != caseID {
+			return fmt.Errorf("commit %s belongs to another case", c.ID)
+		}
+		if commits[c.ID] {
+			return fmt.Errorf("commit %s appears twice", c.ID)
+		}
+		if c.ParentCommitID != nil && !commits[*c.ParentCommitID] {
+			return fmt.Errorf("commit %s comes before its parent %s", c.ID, *c.ParentCommitID)
+		}
+		if c.BranchID != nil && !branches[*c.BranchID] {
+			return fmt.Errorf("commit %s is on unknown branch %s", c.ID, *c.BranchID)
+		}
+		commits[c.ID] = true
+	}
+	for _, br := range b.Branches {
+		if br.BaseCommitID != uuid.Nil && !commits[br.BaseCommitID] {
+			return fmt.Errorf("branch %s is based on unknown commit %s", br.ID, br.BaseCommitID)
+		}
+	}
+	if s := b.Snapshot; s != nil && (s.CaseID != caseID || !commits[s.CommitID]) {
+		return errors.New("snapshot does not match the case's commits")
+	}
+	if p := b.Profile; p != nil && (p.CaseID != caseID || !commits[p.CommitID]) {
+		return errors.New("suspect profile does not match the case's commits")
+	}
+	for _, a := range b.Assets {
+		if a.CaseID != caseID {
+			return fmt.Errorf("asset %s belongs to another case", a.ID)
+		}
+		if err := a.Validate(); err != nil {
+			return fmt.Errorf("invalid asset %s: %w", a.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+var exportedAt = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// testBundle builds a small case with a branch, two commits, a snapshot, a
+// profile and one scan, with the commits listed newest first
+func testBundle(t *testing.T) *Bundle {
+	t.Helper()
+	caseID := uuid.New()
+	root := models.Commit{ID: uuid.New(), CaseID: caseID, Type: models.CommitTypeUploadScan,
+		Summary: "Scans", Payload: json.RawMessage(`{}`), CreatedAt: exportedAt.Add(-2 * time.Hour)}
+	branch := models.Branch{ID: uuid.New(), CaseID: caseID, Name: "alt", BaseCommitID: root.ID, CreatedAt: root.CreatedAt}
+	asset := models.Asset{ID: uuid.New(), CaseID: caseID, Kind: models.AssetKindScanImage,
+		StorageKey: "cases/" + caseID.String() + "/scans/a.png", CreatedAt: root.CreatedAt}
+	payload, _ := json.Marshal(map[string]interface{}{"asset_id": asset.ID, "storage_key": asset.StorageKey})
+	child := models.Commit{ID: uuid.New(), CaseID: caseID, ParentCommitID: &root.ID, BranchID: &branch.ID,
+		Type: models.CommitTypeWitnessStatement, Summary: "Statement", Payload: payload,
+		CreatedAt: root.CreatedAt} // same time as its parent, so only the parent link orders them
+
+	return &Bundle{
+		Case:     models.Case{ID: caseID, Title: "Warehouse break-in", CreatedAt: exportedAt.Add(-24 * time.Hour)},
+		Commits:  []models.Commit{child, root},
+		Branches: []models.Branch{branch},
+		Snapshot: &models.SceneSnapshot{CaseID: caseID, CommitID: child.ID, Scenegraph: models.NewEmptySceneGraph()},
+		Profile:  &models.SuspectProfile{CaseID: caseID, CommitID: child.ID, PortraitAssetKey: "portraits/p.png"},
+		Assets:   []models.Asset{asset},
+		Objects: []Object{
+			{StorageKey: asset.StorageKey, ContentType: "image/png", Data: []byte("scan")},
+			{StorageKey: "portraits/p.png", ContentType: "image/png", Data: []byte("portrait")},
+		},
+	}
+}
+
+func write(t *testing.T, b *Bundle) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := b.Write(&buf, exportedAt, []string{"cases/x/lost.png"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func read(data []byte) (*Bundle, *Manifest, error) {
+	return Read(bytes.NewReader(data), int64(len(data)))
+}
+
+// rezip copies an archive, letting edit change or drop each file
+func rezip(t *testing.T, data []byte, edit func(name string, body []byte) []byte, extra ...string) []byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		body, _ := io.ReadAll(rc)
+		rc.Close()
+		if body = edit(f.Name, body); body == nil {
+			continue
+		}
+		fw, _ := zw.Create(f.Name)
+		fw.Write(body)
+	}
+	for _, name := range extra {
+		fw, _ := zw.Create(name)
+		fw.Write([]byte("extra"))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestWriteRead(t *testing.T) {
+	src := testBundle(t)
+	b, manifest, err := read(write(t, src))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if manifest.CaseID != src.Case.ID || len(manifest.MissingObjects) != 1 || len(manifest.Files) != 8 {
+		t.Errorf("manifest = %+v", manifest)
+	}
+	if len(b.Commits) != 2 || b.Commits[0].ID != src.Commits[1].ID {
+		t.Error("commits should be written parents first")
+	}
+	if b.Case.Title != src.Case.Title || len(b.Branches) != 1 || b.Snapshot == nil || b.Profile == nil || len(b.Assets) != 1 {
+		t.Errorf("Read() = %+v", b)
+	}
+	if len(b.Objects) != 2 || string(b.Objects[0].Data) != "scan" || b.Objects[1].StorageKey != "portraits/p.png" {
+		t.Errorf("Objects = %+v", b.Objects)
+	}
+
+	// Optional documents are left out rather than written as null
+	src.Snapshot, src.Profile = nil, nil
+	b, manifest, err = read(write(t, src))
+	if err != nil || b.Snapshot != nil || b.Profile != nil || len(manifest.Files) != 6 {
+		t.Errorf("Read() without snapshot = %+v, %v", b, err)
+	}
+}
+
+func TestRead_Rejects(t *testing.T) {
+	data := write(t, testBundle(t))
+	keep := func(name string, body []byte) []byte { return body }
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"not a zip", []byte("nope"), "not a zip archive"},
+		{"tampered file", rezip(t, data, func(name string, body []byte) []byte {
+			if name == casePath {
+				return bytes.Replace(body, []byte("Warehouse"), []byte("Boathouse"), 1)
+			}
+			return body
+		}), "integrity check failed for case.json"},
+		{"missing file", rezip(t, data, func(name string, body []byte) []byte {
+			if name == commitsPath {
+				return nil
+			}
+			return body
+		}), "missing file commits.json"},
+		{"unlisted file", rezip(t, data, keep, "objects/extra.bin"), "file objects/extra.bin is not in the manifest"},
+		{"no manifest", rezip(t, data, func(name string, body []byte) []byte {
+			if name == ManifestPath {
+				return nil
+			}
+			return body
+		}), "missing manifest.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := read(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Read() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	b := testBundle(t)
+	b.Commits = SortCommits(b.Commits)
+	if err := b.check(b.Case.ID); err != nil {
+		t.Fatalf("check() error = %v", err)
+	}
+
+	orphan := uuid.New()
+	b.Branches[0].BaseCommitID = orphan
+	if err := b.check(b.Case.ID); err == nil || !strings.Contains(err.Error(), "based on unknown commit") {
+		t.Errorf("check() error = %v", err)
+	}
+
+	b = testBundle(t) // newest first, so the child precedes its parent
+	if err := b.check(b.Case.ID); err == nil || !strings.Contains(err.Error(), "comes before its parent") {
+		t.Errorf("check() error = %v", err)
+	}
+}
+
+func TestRemap(t *testing.T) {
+	src := testBundle(t)
+	src.Commits = SortCommits(src.Commits)
+	caseID := uuid.New()
+	out, m, err := src.Remap(caseID)
+	if err != nil {
+		t.Fatalf("Remap() error = %v", err)
+	}
+	if err := out.check(caseID); err != nil {
+		t.Fatalf("remapped bundle is inconsistent: %v", err)
+	}
+
+	oldCase := src.Case.ID.String()
+	for _, c := range out.Commits {
+		if c.ID == src.Commits[0].ID || c.ID == src.Commits[1].ID {
+			t.Error("commit kept its ID")
+		}
+		if strings.Contains(string(c.Payload), oldCase) || strings.Contains(string(c.Payload), src.Assets[0].ID.String()) {
+			t.Errorf("payload still references old IDs: %s", c.Payload)
+		}
+	}
+	var payload map[string]string
+	json.Unmarshal(out.Commits[1].Payload, &payload)
+	if payload["asset_id"] != out.Assets[0].ID.String() || payload["storage_key"] != out.Assets[0].StorageKey {
+		t.Errorf("payload = %v, asset = %+v", payload, out.Assets[0])
+	}
+
+	prefix := "cases/" + caseID.String() + "/"
+	if out.Assets[0].StorageKey != prefix+"scans/a.png" {
+		t.Errorf("asset key = %s", out.Assets[0].StorageKey)
+	}
+	if out.Profile.PortraitAssetKey != prefix+"imported/portraits/p.png" || out.Objects[1].StorageKey != out.Profile.PortraitAssetKey {
+		t.Errorf("portrait key = %s, object key = %s", out.Profile.PortraitAssetKey, out.Objects[1].StorageKey)
+	}
+	if m.IDs[oldCase] != caseID.String() || m.Keys["portraits/p.png"] != out.Profile.PortraitAssetKey {
+		t.Errorf("Remapping = %+v", m)
+	}
+
+	// The source bundle is left untouched
+	if src.Case.ID.String() != oldCase || src.Profile.PortraitAssetKey != "portraits/p.png" {
+		t.Error("Remap() modified the source bundle")
+	}
+}
@@ -0,0 +1,103 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var uuidPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// Remapping records the IDs and storage keys a bundle was given on import
+type Remapping struct {
+	IDs  map[string]string `json:"ids"`
+	Keys map[string]string `json:"storage_keys"`
+}
+
+// Remap returns a copy of the bundle under a new case ID, with fresh IDs for
+// every commit, branch and asset. References to those IDs are rewritten
+// wherever they appear, including inside commit payloads, the scene graph and
+// asset metadata. Storage keys are moved under the new case: the old case ID
+// in a key is replaced, and keys without one are placed under
+// cases/<new id>/imported/.
+func (b *Bundle) Remap(caseID uuid.UUID) (*Bundle, *Remapping, error) {
+	m := &Remapping{IDs: map[string]string{b.Case.ID.String(): caseID.String()}, Keys: map[string]string{}}
+	for _, c := range b.Commits {
+		m.IDs[c.ID.String()] = uuid.New().String()
+	}
+	for _, br := range b.Branches {
+		m.IDs[br.ID.String()] = uuid.New().String()
+	}
+	for _, a := range b.Assets {
+		m.IDs[a.ID.String()] = uuid.New().String()
+	}
+
+	casePrefix := "cases/" + caseID.String() + "/"
+	var keys []string
+	for _, a := range b.Assets {
+		keys = append(keys, a.StorageKey)
+	}
+	for _, obj := range b.Objects {
+		keys = append(keys, obj.StorageKey)
+	}
+	if b.Profile != nil && b.Profile.PortraitAssetKey != "" {
+		keys = append(keys, b.Profile.PortraitAssetKey)
+	}
+	// Keys outside the case's folder can't be found by rewriting IDs, so
+	// they're replaced wherever they appear as a whole JSON string
+	moved := map[string]string{}
+	for _, key := range keys {
+		if _, done := m.Keys[key]; done {
+			continue
+		}
+		renamed := m.ids(key)
+		if !strings.HasPrefix(renamed, casePrefix) {
+			moved[renamed] = casePrefix + "imported/" + renamed
+			renamed = moved[renamed]
+		}
+		m.Keys[key] = renamed
+	}
+
+	rewrite := func(in, out interface{}) error {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		data = uuidPattern.ReplaceAllFunc(data, func(id []byte) []byte {
+			return []byte(m.ids(string(id)))
+		})
+		for from, to := range moved {
+			quotedFrom, _ := json.Marshal(from)
+			quotedTo, _ := json.Marshal(to)
+			data = bytes.ReplaceAll(data, quotedFrom, quotedTo)
+		}
+		return json.Unmarshal(data, out)
+	}
+
+	out := &Bundle{}
+	src, dst := b.documents(), out.documents()
+	for i := range src {
+		if err := rewrite(src[i].v, dst[i].v); err != nil {
+			return nil, nil, fmt.Errorf("failed to remap %s: %w", src[i].path, err)
+		}
+	}
+	for _, obj := range b.Objects {
+		obj.StorageKey = m.Keys[obj.StorageKey]
+		out.Objects = append(out.Objects, obj)
+	}
+	return out, m, nil
+}
+
+// ids replaces every remapped ID in s
+func (m *Remapping) ids(s string) string {
+	return uuidPattern.ReplaceAllStringFunc(s, func(id string) string {
+		if to, ok := m.IDs[strings.ToLower(id)]; ok {
+			return to
+		}
+		return id
+	})
+}
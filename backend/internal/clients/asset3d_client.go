@@ -24,20 +24,30 @@ const (
 type ReplicateAsset3DClient struct {
 	apiToken   string
 	storage    StorageClient
+	thumbnails *Thumbnailer
 	httpClient *http.Client
+
+	pollInterval time.Duration // delay between prediction status checks
 }
 
 // NewReplicateAsset3DClient creates a new 3D asset generation client
 func NewReplicateAsset3DClient(apiToken string, storage StorageClient) *ReplicateAsset3DClient {
 	return &ReplicateAsset3DClient{
-		apiToken: apiToken,
-		storage:  storage,
+		apiToken:   apiToken,
+		storage:    storage,
+		thumbnails: NewThumbnailer(storage, nil),
 		httpClient: &http.Client{
 			Timeout: 300 * time.Second, // 3D generation can take 2-5 minutes
 		},
+		pollInterval: 5 * time.Second,
 	}
 }
 
+// SetTransport implements HTTPTransportSetter
+func (c *ReplicateAsset3DClient) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = rt
+}
+
 // Generate3DAsset creates a 3D model from an evidence photo
 func (c *ReplicateAsset3DClient) Generate3DAsset(ctx context.Context, input models.Asset3DInput) (*models.Asset3DOutput, error) {
 	startTime := time.Now()
@@ -72,9 +82,15 @@ func (c *ReplicateAsset3DClient) Generate3DAsset(ctx context.Context, input mode
 		format = "glb"
 	}
 
+	// 5. Thumbnail: prefer the rendered preview from the model, fall back to the source photo
+	thumbnailKey, err := c.createThumbnail(ctx, meshAssetKey, result.ThumbnailURL, imageData)
+	if err != nil {
+		fmt.Printf("Warning: failed to create 3D asset thumbnail: %v\n", err)
+	}
+
 	return &models.Asset3DOutput{
 		MeshAssetKey:   meshAssetKey,
-		ThumbnailKey:   "", // TODO: Generate thumbnail from mesh
+		ThumbnailKey:   thumbnailKey,
 		Format:         format,
 		HasTexture:     input.WithTexture,
 		VertexCount:    0, // Replicate doesn't return this
@@ -175,14 +191,13 @@ type predictionResult struct {
 }
 
 func (c *ReplicateAsset3DClient) waitForCompletion(ctx context.Context, predictionID string) (*predictionResult, error) {
-	pollInterval := 5 * time.Second
 	maxAttempts := 60 // 5 minutes max
 
 	for attempt := 0; attempt < maxAttempts; attempt++ {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
-		case <-time.After(pollInterval):
+		case <-time.After(c.pollInterval):
 		}
 
 		url := fmt.Sprintf("%s/predictions/%s", replicateBaseURL, predictionID)
@@ -265,26 +280,45 @@ func (c *ReplicateAsset3DClient) parseOutput(output interface{}) (*predictionRes
 	return result, nil
 }
 
-func (c *ReplicateAsset3DClient) downloadAndUploadMesh(ctx context.Context, meshURL string, input models.Asset3DInput) (string, error) {
-	// Download mesh from Replicate's temporary URL
-	req, err := http.NewRequestWithContext(ctx, "GET", meshURL, nil)
+// createThumbnail builds the preview for a generated mesh from the model's rendered
+// thumbnail URL, or from the source evidence photo when none is returned
+func (c *ReplicateAsset3DClient) createThumbnail(ctx context.Context, meshAssetKey, thumbnailURL string, sourceImage []byte) (string, error) {
+	imageData := sourceImage
+	if thumbnailURL != "" {
+		if data, err := c.download(ctx, thumbnailURL); err == nil {
+			imageData = data
+		} else {
+			fmt.Printf("Warning: failed to download rendered thumbnail: %v (using source image)\n", err)
+		}
+	}
+	return c.thumbnails.ForImage(ctx, meshAssetKey, imageData)
+}
+
+// download fetches a file from one of Replicate's temporary output URLs
+func (c *ReplicateAsset3DClient) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create download request: %w", err)
+		return nil, fmt.Errorf("failed to create download request: %w", err)
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to download mesh: %w", err)
+		return nil, fmt.Errorf("failed to download: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("mesh download returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("download returned status %d", resp.StatusCode)
 	}
 
-	meshData, err := io.ReadAll(resp.Body)
+	return io.ReadAll(resp.Body)
+}
+
+func (c *ReplicateAsset3DClient) downloadAndUploadMesh(ctx context.Context, meshURL string, input models.Asset3DInput) (string, error) {
+	// Download mesh from Replicate's temporary URL
+	meshData, err := c.download(ctx, meshURL)
 	if err != nil {
-		return "", fmt.Errorf("failed to read mesh data: %w", err)
+		return "", fmt.Errorf("failed to download mesh: %w", err)
 	}
 
 	// Determine file extension and content type
@@ -0,0 +1,318 @@
+package clients
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Every client that calls an external HTTP API accepts a replacement transport
+var (
+	_ HTTPTransportSetter = (*GeminiClient)(nil)
+	_ HTTPTransportSetter = (*GeminiProvider)(nil)
+	_ HTTPTransportSetter = (*OpenAICompatibleProvider)(nil)
+	_ HTTPTransportSetter = (*GeminiReasoningClient)(nil)
+	_ HTTPTransportSetter = (*GeminiProfileClient)(nil)
+	_ HTTPTransportSetter = (*GeminiSceneAnalysisClient)(nil)
+	_ HTTPTransportSetter = (*ModalReconstructionClient)(nil)
+	_ HTTPTransportSetter = (*ModalReplayClient)(nil)
+	_ HTTPTransportSetter = (*ReplicateAsset3DClient)(nil)
+	_ HTTPTransportSetter = (*SupabaseStorageClient)(nil)
+)
+
+// Cassette modes
+const (
+	CassetteModeReplay = "replay"
+	CassetteModeRecord = "record"
+)
+
+// CassetteErrorTimeout marks a recorded interaction that failed with a client timeout
+const CassetteErrorTimeout = "timeout"
+
+// Cassette is a recorded sequence of HTTP interactions with an external service
+type Cassette struct {
+	Interactions []CassetteInteraction `json:"interactions"`
+}
+
+// CassetteInteraction is one request and the response (or transport error) it produced
+type CassetteInteraction struct {
+	Request  CassetteRequest   `json:"request"`
+	Response *CassetteResponse `json:"response,omitempty"`
+	Error    string            `json:"error,omitempty"` // "timeout" or a transport error message
+}
+
+// CassetteRequest identifies a request. Query strings are not recorded so
+// API keys passed as parameters never reach fixtures.
+type CassetteRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"` // scheme://host/path
+	Body   string `json:"body,omitempty"`
+}
+
+// CassetteResponse is a recorded HTTP response. Binary bodies are stored in BodyBase64.
+type CassetteResponse struct {
+	Status     int               `json:"status"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"body,omitempty"`
+	BodyBase64 string            `json:"body_base64,omitempty"`
+}
+
+// recordedHeaders are the response headers kept when recording
+var recordedHeaders = []string{"Content-Type", "Retry-After"}
+
+// maxRecordedRequestBody limits how much of a request body is stored (image payloads are large)
+const maxRecordedRequestBody = 4096
+
+// CassetteTransport is an http.RoundTripper that replays interactions from a
+// cassette file, or records live interactions to one. Inject it into a client
+// with SetTransport to exercise real request building and response parsing
+// without network access.
+type CassetteTransport struct {
+	mode string
+	path string
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+// LoadCassette opens a cassette file for replay
+func LoadCassette(path string) (*CassetteTransport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+
+	return &CassetteTransport{
+		mode:     CassetteModeReplay,
+		path:     path,
+		cassette: cassette,
+		used:     make([]bool, len(cassette.Interactions)),
+	}, nil
+}
+
+// NewCassetteRecorder records interactions made through next (http.DefaultTransport
+// if nil). Call Save to write the cassette once the exchange is complete.
+func NewCassetteRecorder(path string, next http.RoundTripper) *CassetteTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &CassetteTransport{
+		mode: CassetteModeRecord,
+		path: path,
+		next: next,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *CassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	t.mu.Lock()
+	t.requests = append(t.requests, req)
+	t.bodies = append(t.bodies, body)
+	t.mu.Unlock()
+
+	if t.mode == CassetteModeRecord {
+		return t.record(req, body)
+	}
+	return t.replay(req)
+}
+
+func (t *CassetteTransport) replay(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	url := cassetteURL(req)
+	for i, in := range t.cassette.Interactions {
+		if t.used[i] || !strings.EqualFold(in.Request.Method, req.Method) || in.Request.URL != url {
+			continue
+		}
+		t.used[i] = true
+
+		if in.Error == CassetteErrorTimeout {
+			return nil, &cassetteTimeoutError{url: url}
+		}
+		if in.Error != "" {
+			return nil, fmt.Errorf("cassette: %s", in.Error)
+		}
+		if in.Response == nil {
+			return nil, fmt.Errorf("cassette: interaction %d for %s %s has no response", i, req.Method, url)
+		}
+		return in.Response.toHTTP(req)
+	}
+
+	return nil, fmt.Errorf("cassette %s: no unused interaction for %s %s", filepath.Base(t.path), req.Method, url)
+}
+
+func (t *CassetteTransport) record(req *http.Request, body []byte) (*http.Response, error) {
+	recorded := CassetteInteraction{
+		Request: CassetteRequest{
+			Method: req.Method,
+			URL:    cassetteURL(req),
+		},
+	}
+	if len(body) > 0 && len(body) <= maxRecordedRequestBody && utf8.Valid(body) {
+		recorded.Request.Body = string(body)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		recorded.Error = err.Error()
+		if isTimeout(err) {
+			recorded.Error = CassetteErrorTimeout
+		}
+		t.append(recorded)
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	recorded.Response = &CassetteResponse{Status: resp.StatusCode}
+	for _, h := range recordedHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			if recorded.Response.Headers == nil {
+				recorded.Response.Headers = map[string]string{}
+			}
+			recorded.Response.Headers[h] = v
+		}
+	}
+	if utf8.Valid(respBody) {
+		recorded.Response.Body = string(respBody)
+	} else {
+		recorded.Response.BodyBase64 = base64.StdEncoding.EncodeToString(respBody)
+	}
+
+	t.append(recorded)
+	return resp, nil
+}
+
+func (t *CassetteTransport) append(in CassetteInteraction) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cassette.Interactions = append(t.cassette.Interactions, in)
+}
+
+// Save writes recorded interactions to the cassette file
+func (t *CassetteTransport) Save() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	data, err := json.MarshalIndent(t.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	return os.WriteFile(t.path, append(data, '\n'), 0o644)
+}
+
+// Requests returns the requests sent through the transport, in order
+func (t *CassetteTransport) Requests() []*http.Request {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*http.Request(nil), t.requests...)
+}
+
+// RequestBody returns the body of the i-th request sent through the transport
+func (t *CassetteTransport) RequestBody(i int) []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if i < 0 || i >= len(t.bodies) {
+		return nil
+	}
+	return t.bodies[i]
+}
+
+// Unused returns the number of replay interactions that were never requested
+func (t *CassetteTransport) Unused() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := 0
+	for _, u := range t.used {
+		if !u {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *CassetteResponse) toHTTP(req *http.Request) (*http.Response, error) {
+	body := []byte(r.Body)
+	if r.BodyBase64 != "" {
+		decoded, err := base64.StdEncoding.DecodeString(r.BodyBase64)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: invalid body_base64: %w", err)
+		}
+		body = decoded
+	}
+
+	header := http.Header{}
+	for k, v := range r.Headers {
+		header.Set(k, v)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// cassetteURL strips the query string and credentials from a request URL
+func cassetteURL(req *http.Request) string {
+	return req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+}
+
+// cassetteTimeoutError reproduces a client timeout; it satisfies net.Error
+type cassetteTimeoutError struct {
+	url string
+}
+
+func (e *cassetteTimeoutError) Error() string {
+	return "cassette: request to " + e.url + " timed out (Client.Timeout exceeded while awaiting headers)"
+}
+
+func (e *cassetteTimeoutError) Timeout() bool   { return true }
+func (e *cassetteTimeoutError) Temporary() bool { return true }
+
+// isTimeout reports whether err is a network timeout
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,105 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadCassette opens a recorded fixture from testdata/cassettes
+func loadCassette(t *testing.T, name string) *CassetteTransport {
+	t.Helper()
+	cassette, err := LoadCassette(filepath.Join("testdata", "cassettes", name+".json"))
+	if err != nil {
+		t.Fatalf("LoadCassette(%s) error = %v", name, err)
+	}
+	return cassette
+}
+
+func TestCassetteTransport_RecordThenReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "not-recorded")
+		if r.URL.Path == "/binary" {
+			w.Write([]byte{0xff, 0xfe, 0x00})
+			return
+		}
+		w.Write([]byte(`{"path": "` + r.URL.Path + `"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "roundtrip.json")
+	recorder := NewCassetteRecorder(path, nil)
+	client := &http.Client{Transport: recorder}
+
+	for _, p := range []string{"/a?key=secret", "/binary"} {
+		resp, err := client.Post(server.URL+p, "application/json", strings.NewReader(`{"q": 1}`))
+		if err != nil {
+			t.Fatalf("record POST %s error = %v", p, err)
+		}
+		resp.Body.Close()
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	replay, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("LoadCassette() error = %v", err)
+	}
+	if strings.Contains(replay.cassette.Interactions[0].Request.URL, "secret") {
+		t.Error("recorded URL should not include the query string")
+	}
+	if _, ok := replay.cassette.Interactions[0].Response.Headers["X-Request-Id"]; ok {
+		t.Error("only allow-listed response headers should be recorded")
+	}
+
+	client = &http.Client{Transport: replay}
+	resp, err := client.Post(server.URL+"/binary", "application/json", nil)
+	if err != nil {
+		t.Fatalf("replay POST /binary error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "\xff\xfe\x00" {
+		t.Errorf("replayed binary body = %q", body)
+	}
+
+	resp, err = client.Post(server.URL+"/a?key=other", "application/json", nil)
+	if err != nil {
+		t.Fatalf("replay POST /a error = %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `{"path": "/a"}` || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("replayed response = %q (%v)", body, resp.Header)
+	}
+
+	// Each interaction is served once
+	if _, err := client.Post(server.URL+"/a", "application/json", nil); err == nil {
+		t.Error("replaying an exhausted interaction should fail")
+	}
+	if replay.Unused() != 0 {
+		t.Errorf("Unused() = %d, want 0", replay.Unused())
+	}
+}
+
+func TestCassetteTransport_Timeout(t *testing.T) {
+	cassette := loadCassette(t, "gemini_timeout")
+	client := &http.Client{Transport: cassette}
+
+	req, _ := http.NewRequestWithContext(context.Background(), "POST",
+		"https://generativelanguage.googleapis.com/v1beta/models/gemini-2.5-flash:generateContent?key=k", nil)
+	_, err := client.Do(req)
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("Do() error = %v, want a timeout net.Error", err)
+	}
+}
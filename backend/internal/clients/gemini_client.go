@@ -47,41 +47,67 @@ func NewGeminiClient(apiKey string) *GeminiClient {
 	}
 }
 
+// SetTransport implements HTTPTransportSetter
+func (c *GeminiClient) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = rt
+}
+
+// setProviderTransport swaps the HTTP transport of an LLM provider that supports it
+func setProviderTransport(provider LLMProvider, rt http.RoundTripper) {
+	if s, ok := provider.(HTTPTransportSetter); ok {
+		s.SetTransport(rt)
+	}
+}
+
 // ============================================
 // REASONING CLIENT IMPLEMENTATION
 // ============================================
 
-// GeminiReasoningClient implements ReasoningClient using Gemini 2.5 Flash with Thinking
+// GeminiReasoningClient implements ReasoningClient. It defaults to Gemini 2.5 Flash
+// with Thinking, but generation goes through any configured LLMProvider.
 type GeminiReasoningClient struct {
-	*GeminiClient
+	llm LLMJobConfig
 }
 
-// NewGeminiReasoningClient creates a new reasoning client
+// NewGeminiReasoningClient creates a new reasoning client backed by the Gemini API
 func NewGeminiReasoningClient(apiKey string) *GeminiReasoningClient {
-	return &GeminiReasoningClient{
-		GeminiClient: NewGeminiClient(apiKey),
-	}
+	return NewReasoningClient(LLMJobConfig{Provider: NewGeminiProvider(apiKey)})
+}
+
+// NewReasoningClient creates a reasoning client using the given provider configuration
+func NewReasoningClient(llm LLMJobConfig) *GeminiReasoningClient {
+	return &GeminiReasoningClient{llm: llm}
 }
 
-// Reason generates trajectory hypotheses using Gemini
+// SetTransport implements HTTPTransportSetter
+func (c *GeminiReasoningClient) SetTransport(rt http.RoundTripper) {
+	setProviderTransport(c.llm.Provider, rt)
+}
+
+// Reason generates trajectory hypotheses using the configured LLM provider
 func (c *GeminiReasoningClient) Reason(ctx context.Context, input models.ReasoningInput) (*models.ReasoningOutput, error) {
 	startTime := time.Now()
 
 	// Build prompt
 	prompt := c.buildReasoningPrompt(input)
 
-	// Make API request using Gemini 2.5 Flash with thinking
-	response, err := c.generateContent(ctx, prompt, input.ThinkingBudget, geminiReasoningModel)
+	// Make API request (Gemini 2.5 Flash with thinking by default), validated
+	// against the ReasoningOutput schema
+	output := &models.ReasoningOutput{}
+	resp, err := generateStructured(ctx, c.llm, GenerateRequest{
+		Model:           c.llm.model(geminiReasoningModel),
+		Prompt:          prompt,
+		Temperature:     0.7,
+		TopP:            0.95,
+		MaxOutputTokens: 8192,
+		ThinkingBudget:  c.llm.thinkingBudget(input.ThinkingBudget),
+	}, SchemaReasoningOutput, reasoningOutputSchema(), output, nil)
 	if err != nil {
-		return nil, fmt.Errorf("gemini API error: %w", err)
-	}
-
-	// Parse response into trajectories
-	output, err := c.parseReasoningResponse(response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, c.llm.wrapError(err)
 	}
 
+	output.ModelStats.ThinkingTokens = resp.ThinkingTokens
+	output.ModelStats.OutputTokens = resp.OutputTokens
 	output.ModelStats.LatencyMs = time.Since(startTime).Milliseconds()
 	return output, nil
 }
@@ -141,57 +167,53 @@ Respond with valid JSON in this exact structure:
 	return prompt
 }
 
-func (c *GeminiReasoningClient) parseReasoningResponse(response string) (*models.ReasoningOutput, error) {
-	// Extract JSON from response (handle markdown code blocks)
-	jsonStr := extractJSON(response)
-
-	var output models.ReasoningOutput
-	if err := json.Unmarshal([]byte(jsonStr), &output); err != nil {
-		// If parsing fails, create a minimal valid response
-		output = models.ReasoningOutput{
-			Trajectories: []models.Trajectory{
-				{
-					ID:                uuid.New().String(),
-					Rank:              1,
-					OverallConfidence: 0.5,
-					Segments:          []models.TrajectorySegment{},
-				},
-			},
-			ThinkingSummary: "Response parsing incomplete: " + response[:min(200, len(response))],
-			ModelStats:      models.ModelStats{},
-		}
-	}
-
-	return &output, nil
-}
-
 // ============================================
 // PROFILE CLIENT IMPLEMENTATION
 // ============================================
 
-// GeminiProfileClient implements ProfileClient using Gemini
+// GeminiProfileClient implements ProfileClient. It defaults to Gemini 2.5 Flash,
+// but generation goes through any configured LLMProvider.
 type GeminiProfileClient struct {
-	*GeminiClient
+	llm LLMJobConfig
 }
 
-// NewGeminiProfileClient creates a new profile extraction client
+// NewGeminiProfileClient creates a new profile client backed by the Gemini API
 func NewGeminiProfileClient(apiKey string) *GeminiProfileClient {
-	return &GeminiProfileClient{
-		GeminiClient: NewGeminiClient(apiKey),
-	}
+	return NewProfileClient(LLMJobConfig{Provider: NewGeminiProvider(apiKey)})
+}
+
+// NewProfileClient creates a profile client using the given provider configuration
+func NewProfileClient(llm LLMJobConfig) *GeminiProfileClient {
+	return &GeminiProfileClient{llm: llm}
+}
+
+// SetTransport implements HTTPTransportSetter
+func (c *GeminiProfileClient) SetTransport(rt http.RoundTripper) {
+	setProviderTransport(c.llm.Provider, rt)
 }
 
 // ExtractProfile extracts suspect attributes from witness statements
 func (c *GeminiProfileClient) ExtractProfile(ctx context.Context, statements []models.WitnessStatementInput, existing *models.SuspectAttributes) (*models.SuspectAttributes, error) {
 	prompt := c.buildProfilePrompt(statements, existing)
 
-	// Use profile model for extraction
-	response, err := c.generateContent(ctx, prompt, 4096, geminiProfileModel)
+	// Use profile model for extraction, validated against the SuspectAttributes schema
+	attrs := models.NewEmptySuspectAttributes()
+	_, err := generateStructured(ctx, c.llm, GenerateRequest{
+		Model:           c.llm.model(geminiProfileModel),
+		Prompt:          prompt,
+		Temperature:     0.7,
+		TopP:            0.95,
+		MaxOutputTokens: 8192,
+		ThinkingBudget:  c.llm.thinkingBudget(4096),
+	}, SchemaSuspectAttributes, suspectAttributesSchema(), attrs, func() error { return attrs.Validate() })
 	if err != nil {
-		return nil, fmt.Errorf("gemini API error: %w", err)
+		return nil, c.llm.wrapError(err)
 	}
 
-	return c.parseProfileResponse(response, statements)
+	if attrs.DistinctiveFeatures == nil {
+		attrs.DistinctiveFeatures = []models.FeatureAttribute{}
+	}
+	return attrs, nil
 }
 
 func (c *GeminiProfileClient) buildProfilePrompt(statements []models.WitnessStatementInput, existing *models.SuspectAttributes) string {
@@ -224,72 +246,16 @@ Respond with valid JSON:
   "age_range": {"min": 25, "max": 35, "confidence": 0.7},
   "height_range_cm": {"min": 170, "max": 180, "confidence": 0.8},
   "build": {"value": "athletic", "confidence": 0.6},
-  "hair_color": {"value": "dark brown", "confidence": 0.7},
-  "hair_style": {"value": "short", "confidence": 0.5},
-  "eye_color": {"value": "brown", "confidence": 0.4},
   "skin_tone": {"value": "medium", "confidence": 0.6},
+  "hair": {"style": "short", "color": "dark brown", "confidence": 0.7},
   "facial_hair": {"value": "stubble", "confidence": 0.5},
-  "distinguishing_features": [{"value": "scar on left cheek", "confidence": 0.8}],
-  "clothing_description": {"value": "dark hoodie, jeans", "confidence": 0.7},
-  "conflicts": [
-    {"attribute": "hair_color", "values": ["brown", "black"], "source_indices": [0, 1]}
-  ]
+  "glasses": {"value": "none", "confidence": 0.4},
+  "distinctive_features": [{"description": "scar on left cheek", "confidence": 0.8}]
 }
 
 Only include attributes that are mentioned in the statements.`, strings.Join(stmtTexts, "\n\n"), string(existingJSON))
 }
 
-func (c *GeminiProfileClient) parseProfileResponse(response string, statements []models.WitnessStatementInput) (*models.SuspectAttributes, error) {
-	jsonStr := extractJSON(response)
-
-	var parsed struct {
-		AgeRange            *models.RangeAttribute  `json:"age_range"`
-		HeightRangeCm       *models.RangeAttribute  `json:"height_range_cm"`
-		Build               *models.StringAttribute `json:"build"`
-		HairColor           string                  `json:"hair_color"`
-		HairStyle           string                  `json:"hair_style"`
-		SkinTone            *models.StringAttribute `json:"skin_tone"`
-		FacialHair          *models.StringAttribute `json:"facial_hair"`
-		Glasses             *models.StringAttribute `json:"glasses"`
-		DistinctiveFeatures []struct {
-			Description string  `json:"description"`
-			Confidence  float64 `json:"confidence"`
-		} `json:"distinctive_features"`
-	}
-
-	if err := json.Unmarshal([]byte(jsonStr), &parsed); err != nil {
-		// Return empty attributes on parse failure
-		return models.NewEmptySuspectAttributes(), nil
-	}
-
-	attrs := models.NewEmptySuspectAttributes()
-	attrs.AgeRange = parsed.AgeRange
-	attrs.HeightRangeCm = parsed.HeightRangeCm
-	attrs.Build = parsed.Build
-	attrs.SkinTone = parsed.SkinTone
-	attrs.FacialHair = parsed.FacialHair
-	attrs.Glasses = parsed.Glasses
-
-	// Combine hair color and style into HairAttribute
-	if parsed.HairColor != "" || parsed.HairStyle != "" {
-		attrs.Hair = &models.HairAttribute{
-			Color:      parsed.HairColor,
-			Style:      parsed.HairStyle,
-			Confidence: 0.7, // Default confidence
-		}
-	}
-
-	// Convert distinctive features
-	for _, f := range parsed.DistinctiveFeatures {
-		attrs.DistinctiveFeatures = append(attrs.DistinctiveFeatures, models.FeatureAttribute{
-			Description: f.Description,
-			Confidence:  f.Confidence,
-		})
-	}
-
-	return attrs, nil
-}
-
 // ============================================
 // IMAGE GENERATION CLIENT IMPLEMENTATION
 // ============================================
@@ -297,7 +263,8 @@ func (c *GeminiProfileClient) parseProfileResponse(response string, statements [
 // GeminiImageGenClient implements ImageGenClient using Gemini
 type GeminiImageGenClient struct {
 	*GeminiClient
-	storage StorageClient
+	storage    StorageClient
+	thumbnails *Thumbnailer
 }
 
 // NewGeminiImageGenClient creates a new image generation client
@@ -305,6 +272,7 @@ func NewGeminiImageGenClient(apiKey string, storage StorageClient) *GeminiImageG
 	return &GeminiImageGenClient{
 		GeminiClient: NewGeminiClient(apiKey),
 		storage:      storage,
+		thumbnails:   NewThumbnailer(storage, nil),
 	}
 }
 
@@ -320,37 +288,45 @@ func (c *GeminiImageGenClient) Generate(ctx context.Context, input models.ImageG
 	prompt := c.buildImagePrompt(input)
 	model := input.GetModelForResolution()
 
+	// Evidence boards are laid out over a reference image, usually the
+	// scene's floor plan
+	var reference []byte
+	if input.GenType == models.ImageGenTypeEvidenceBoard && input.ReferenceImageKey != "" && c.storage != nil {
+		data, _, err := c.storage.Download(ctx, "case-assets", input.ReferenceImageKey)
+		if err != nil {
+			fmt.Printf("Warning: failed to download reference image %s: %v\n", input.ReferenceImageKey, err)
+		} else {
+			reference = data
+			prompt += " Use the attached top-down floor plan as the board's centrepiece: keep its layout, and pin each clue to its numbered marker."
+		}
+	}
+
 	// Make image generation request
-	imageData, err := c.generateImage(ctx, prompt, model)
+	imageData, err := c.generateImage(ctx, prompt, model, reference)
 	if err != nil {
 		return nil, fmt.Errorf("image generation failed: %w", err)
 	}
 
 	// Determine dimensions based on resolution
 	width, height := 1024, 1024
-	cost := 0.04
+	cost := models.ImageCostUSD(input.Resolution)
 	if input.Resolution == "2k" {
 		width, height = 2048, 2048
-		cost = 0.134
 	} else if input.Resolution == "4k" {
 		width, height = 4096, 4096
-		cost = 0.24
 	}
 
 	// Generate asset key
 	assetID := uuid.New().String()
 	assetKey := fmt.Sprintf("cases/%s/generated/%s.png", input.CaseID, assetID)
-	thumbnailKey := fmt.Sprintf("cases/%s/generated/%s_thumb.png", input.CaseID, assetID)
+	var thumbnailKey string
 
 	// Upload to Supabase Storage (if available)
 	if c.storage != nil {
 		if err := c.storage.Upload(ctx, "case-assets", assetKey, imageData, "image/png"); err != nil {
 			fmt.Printf("Warning: Failed to upload image to storage: %v (image was generated successfully)\n", err)
-		} else {
-			// For now, use the same image as thumbnail (TODO: generate actual thumbnail)
-			if err := c.storage.Upload(ctx, "case-assets", thumbnailKey, imageData, "image/png"); err != nil {
-				fmt.Printf("Warning: failed to upload thumbnail: %v\n", err)
-			}
+		} else if thumbnailKey, err = c.thumbnails.ForImage(ctx, assetKey, imageData); err != nil {
+			fmt.Printf("Warning: failed to create thumbnail: %v\n", err)
 		}
 	}
 
@@ -371,13 +347,11 @@ func (c *GeminiImageGenClient) generatePOVImages(ctx context.Context, input mode
 
 	// Determine dimensions based on resolution
 	width, height := 1024, 1024
-	costPerImage := 0.04
+	costPerImage := models.ImageCostUSD(input.Resolution)
 	if input.Resolution == "2k" {
 		width, height = 2048, 2048
-		costPerImage = 0.134
 	} else if input.Resolution == "4k" {
 		width, height = 4096, 4096
-		costPerImage = 0.24
 	}
 
 	var generatedImages []models.GeneratedImage
@@ -392,7 +366,7 @@ func (c *GeminiImageGenClient) generatePOVImages(ctx context.Context, input mode
 		prompt := c.buildScenePOVPrompt(povInput)
 
 		// Generate the image
-		imageData, err := c.generateImage(ctx, prompt, model)
+		imageData, err := c.generateImage(ctx, prompt, model, nil)
 		if err != nil {
 			// Log error but continue with other views
 			fmt.Printf("Warning: Failed to generate %s view: %v\n", viewAngle, err)
@@ -402,7 +376,7 @@ func (c *GeminiImageGenClient) generatePOVImages(ctx context.Context, input mode
 		// Generate asset keys
 		assetID := uuid.New().String()
 		assetKey := fmt.Sprintf("cases/%s/generated/pov/%s_%s.png", input.CaseID, viewAngle, assetID)
-		thumbnailKey := fmt.Sprintf("cases/%s/generated/pov/%s_%s_thumb.png", input.CaseID, viewAngle, assetID)
+		var thumbnailKey string
 
 		// Upload to Supabase Storage (if available)
 		uploaded := false
@@ -411,9 +385,8 @@ func (c *GeminiImageGenClient) generatePOVImages(ctx context.Context, input mode
 				fmt.Printf("Warning: Failed to upload %s view to storage: %v (image was generated successfully)\n", viewAngle, err)
 			} else {
 				uploaded = true
-				// For now, use the same image as thumbnail (TODO: generate actual thumbnail)
-				if err := c.storage.Upload(ctx, "case-assets", thumbnailKey, imageData, "image/png"); err != nil {
-					fmt.Printf("Warning: failed to upload thumbnail for %s view: %v\n", viewAngle, err)
+				if thumbnailKey, err = c.thumbnails.ForImage(ctx, assetKey, imageData); err != nil {
+					fmt.Printf("Warning: failed to create thumbnail for %s view: %v\n", viewAngle, err)
 				}
 			}
 		}
@@ -569,15 +542,27 @@ func (c *GeminiImageGenClient) buildPortraitPrompt(attrs *models.SuspectAttribut
 	return strings.Join(parts, "\n")
 }
 
-func (c *GeminiImageGenClient) generateImage(ctx context.Context, prompt, model string) ([]byte, error) {
+// generateImage asks the model for one image, optionally showing it a
+// reference PNG alongside the prompt
+func (c *GeminiImageGenClient) generateImage(ctx context.Context, prompt, model string, reference []byte) ([]byte, error) {
 	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s", geminiBaseURL, model, c.apiKey)
 
+	parts := []map[string]interface{}{
+		{"text": prompt},
+	}
+	if len(reference) > 0 {
+		parts = append(parts, map[string]interface{}{
+			"inlineData": map[string]interface{}{
+				"mimeType": "image/png",
+				"data":     base64.StdEncoding.EncodeToString(reference),
+			},
+		})
+	}
+
 	reqBody := map[string]interface{}{
 		"contents": []map[string]interface{}{
 			{
-				"parts": []map[string]interface{}{
-					{"text": prompt},
-				},
+				"parts": parts,
 			},
 		},
 		"generationConfig": map[string]interface{}{
@@ -638,75 +623,6 @@ func (c *GeminiImageGenClient) generateImage(ctx context.Context, prompt, model
 // SHARED HELPERS
 // ============================================
 
-func (c *GeminiClient) generateContent(ctx context.Context, prompt string, thinkingBudget int, model string) (string, error) {
-	if model == "" {
-		model = geminiReasoningModel
-	}
-	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s", geminiBaseURL, model, c.apiKey)
-
-	reqBody := map[string]interface{}{
-		"contents": []map[string]interface{}{
-			{
-				"parts": []map[string]interface{}{
-					{"text": prompt},
-				},
-			},
-		},
-		"generationConfig": map[string]interface{}{
-			"temperature":     0.7,
-			"topP":            0.95,
-			"maxOutputTokens": 8192,
-		},
-	}
-
-	// Add thinking config if budget specified
-	if thinkingBudget > 0 {
-		reqBody["generationConfig"].(map[string]interface{})["thinkingConfig"] = map[string]interface{}{
-			"thinkingBudget": thinkingBudget,
-		}
-	}
-
-	jsonBody, _ := json.Marshal(reqBody)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	// Parse response
-	var result struct {
-		Candidates []struct {
-			Content struct {
-				Parts []struct {
-					Text string `json:"text"`
-				} `json:"parts"`
-			} `json:"content"`
-		} `json:"candidates"`
-	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("empty response from API")
-	}
-
-	return result.Candidates[0].Content.Parts[0].Text, nil
-}
-
 // extractJSON extracts JSON from a response that may contain markdown code blocks
 func extractJSON(response string) string {
 	// Try to find JSON in code block
@@ -2,6 +2,7 @@ package clients
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/sherlockos/backend/internal/models"
 )
@@ -83,6 +84,32 @@ type SceneAnalysisClient interface {
 	AnalyzeScene(ctx context.Context, input models.SceneAnalysisInput) (*models.SceneAnalysisOutput, error)
 }
 
+// ============================================
+// LLM TEXT / VISION GENERATION
+// ============================================
+
+// LLMProvider defines a provider-neutral text generation backend used by the
+// reasoning, profile and scene analysis clients
+// Implementations: Gemini REST API, OpenAI-compatible chat completions (vLLM, llama.cpp)
+type LLMProvider interface {
+	// Name identifies the provider (e.g. "gemini", "openai")
+	Name() string
+
+	// Generate produces a completion for a prompt with optional inline images
+	Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error)
+}
+
+// ============================================
+// HTTP TRANSPORT
+// ============================================
+
+// HTTPTransportSetter is implemented by every client that calls an external
+// HTTP API. Tests inject a CassetteTransport to replay recorded responses.
+type HTTPTransportSetter interface {
+	// SetTransport replaces the transport of the client's HTTP client, keeping its timeout
+	SetTransport(rt http.RoundTripper)
+}
+
 // ============================================
 // STORAGE
 // ============================================
@@ -0,0 +1,400 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sherlockos/backend/pkg/config"
+)
+
+// LLMImage is an inline image attached to a generation request
+type LLMImage struct {
+	MIMEType string
+	Data     []byte
+}
+
+// GenerateRequest is a provider-neutral text/vision generation request
+type GenerateRequest struct {
+	Model           string
+	Prompt          string
+	Images          []LLMImage // sent before the prompt
+	Temperature     float64
+	TopP            float64
+	MaxOutputTokens int
+	ThinkingBudget  int  // 0 disables thinking
+	JSONResponse    bool // ask the provider for a JSON-only response
+
+	// ResponseSchema constrains the output in providers that support schema mode
+	ResponseSchema *JSONSchema
+	SchemaName     string
+}
+
+// GenerateResponse is the text returned by a provider along with token usage
+type GenerateResponse struct {
+	Text           string
+	Model          string
+	ThinkingTokens int
+	OutputTokens   int
+}
+
+// LLMJobConfig selects the provider, model and thinking budget used for one job type
+type LLMJobConfig struct {
+	Provider LLMProvider
+	Model    string // empty uses the client's default model
+
+	// ThinkingBudget caps the thinking tokens requested by a job.
+	// 0 keeps the job's own budget, a negative value disables thinking.
+	ThinkingBudget int
+}
+
+// model returns the configured model or the given default
+func (c LLMJobConfig) model(defaultModel string) string {
+	if c.Model != "" {
+		return c.Model
+	}
+	return defaultModel
+}
+
+// thinkingBudget applies the configured cap to a requested budget
+func (c LLMJobConfig) thinkingBudget(requested int) int {
+	switch {
+	case c.ThinkingBudget < 0:
+		return 0
+	case c.ThinkingBudget > 0 && (requested <= 0 || requested > c.ThinkingBudget):
+		return c.ThinkingBudget
+	}
+	return requested
+}
+
+// wrapError labels provider failures; schema failures are returned as-is so
+// callers can detect them with errors.As
+func (c LLMJobConfig) wrapError(err error) error {
+	var structured *StructuredOutputError
+	if errors.As(err, &structured) {
+		return err
+	}
+	return fmt.Errorf("%s API error: %w", c.Provider.Name(), err)
+}
+
+// NewLLMProvider builds a provider by name. endpoint overrides the provider's
+// base URL and is required for OpenAI-compatible servers; apiKey is optional
+// for local servers.
+func NewLLMProvider(name, endpoint, apiKey string) (LLMProvider, error) {
+	switch strings.ToLower(name) {
+	case "", config.LLMProviderGemini:
+		if apiKey == "" {
+			return nil, fmt.Errorf("gemini provider requires an API key")
+		}
+		p := NewGeminiProvider(apiKey)
+		if endpoint != "" {
+			p.baseURL = strings.TrimRight(endpoint, "/")
+		}
+		return p, nil
+	case config.LLMProviderOpenAI:
+		if endpoint == "" {
+			return nil, fmt.Errorf("openai provider requires an endpoint")
+		}
+		return NewOpenAICompatibleProvider(endpoint, apiKey), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider: %s", name)
+	}
+}
+
+// ============================================
+// GEMINI PROVIDER
+// ============================================
+
+// GeminiProvider implements LLMProvider using the Gemini generateContent REST API
+type GeminiProvider struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewGeminiProvider creates a Gemini provider
+func NewGeminiProvider(apiKey string) *GeminiProvider {
+	return &GeminiProvider{
+		apiKey:  apiKey,
+		baseURL: geminiBaseURL,
+		httpClient: &http.Client{
+			Timeout: 180 * time.Second, // Vision requests need the longer timeout
+		},
+	}
+}
+
+// Name implements LLMProvider
+func (p *GeminiProvider) Name() string {
+	return config.LLMProviderGemini
+}
+
+// SetTransport implements HTTPTransportSetter
+func (p *GeminiProvider) SetTransport(rt http.RoundTripper) {
+	p.httpClient.Transport = rt
+}
+
+// Generate implements LLMProvider
+func (p *GeminiProvider) Generate(ctx context.Context, in GenerateRequest) (*GenerateResponse, error) {
+	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s", p.baseURL, in.Model, p.apiKey)
+
+	// Images first, then the text prompt
+	var parts []map[string]interface{}
+	for _, img := range in.Images {
+		parts = append(parts, map[string]interface{}{
+			"inlineData": map[string]interface{}{
+				"mimeType": img.MIMEType,
+				"data":     base64.StdEncoding.EncodeToString(img.Data),
+			},
+		})
+	}
+	parts = append(parts, map[string]interface{}{"text": in.Prompt})
+
+	genConfig := map[string]interface{}{
+		"temperature":     in.Temperature,
+		"topP":            in.TopP,
+		"maxOutputTokens": in.MaxOutputTokens,
+	}
+	if in.JSONResponse {
+		genConfig["responseMimeType"] = "application/json"
+	}
+	if in.ResponseSchema != nil {
+		genConfig["responseSchema"] = in.ResponseSchema.geminiSchema()
+	}
+	if in.ThinkingBudget > 0 {
+		genConfig["thinkingConfig"] = map[string]interface{}{
+			"thinkingBudget": in.ThinkingBudget,
+		}
+	}
+
+	reqBody := map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{"parts": parts},
+		},
+		"generationConfig": genConfig,
+	}
+
+	body, err := postJSON(ctx, p.httpClient, url, nil, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text    string `json:"text"`
+					Thought bool   `json:"thought"`
+				} `json:"parts"`
+			} `json:"content"`
+		} `json:"candidates"`
+		UsageMetadata struct {
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+			ThoughtsTokenCount   int `json:"thoughtsTokenCount"`
+		} `json:"usageMetadata"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if len(result.Candidates) == 0 {
+		return nil, fmt.Errorf("empty response from API")
+	}
+
+	var text strings.Builder
+	for _, part := range result.Candidates[0].Content.Parts {
+		if !part.Thought {
+			text.WriteString(part.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return nil, fmt.Errorf("empty response from API")
+	}
+
+	return &GenerateResponse{
+		Text:           text.String(),
+		Model:          in.Model,
+		ThinkingTokens: result.UsageMetadata.ThoughtsTokenCount,
+		OutputTokens:   result.UsageMetadata.CandidatesTokenCount,
+	}, nil
+}
+
+// ============================================
+// OPENAI-COMPATIBLE PROVIDER
+// ============================================
+
+// OpenAICompatibleProvider implements LLMProvider against an OpenAI-compatible
+// /chat/completions endpoint, e.g. a local vLLM or llama.cpp server
+type OpenAICompatibleProvider struct {
+	endpoint   string // base URL including the API version, e.g. http://localhost:8000/v1
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewOpenAICompatibleProvider creates a provider for an OpenAI-compatible server.
+// apiKey may be empty for servers that do not require authentication.
+func NewOpenAICompatibleProvider(endpoint, apiKey string) *OpenAICompatibleProvider {
+	return &OpenAICompatibleProvider{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		apiKey:   apiKey,
+		httpClient: &http.Client{
+			Timeout: 300 * time.Second, // Local models are often slower than hosted APIs
+		},
+	}
+}
+
+// Name implements LLMProvider
+func (p *OpenAICompatibleProvider) Name() string {
+	return config.LLMProviderOpenAI
+}
+
+// SetTransport implements HTTPTransportSetter
+func (p *OpenAICompatibleProvider) SetTransport(rt http.RoundTripper) {
+	p.httpClient.Transport = rt
+}
+
+// Generate implements LLMProvider
+func (p *OpenAICompatibleProvider) Generate(ctx context.Context, in GenerateRequest) (*GenerateResponse, error) {
+	var content interface{} = in.Prompt
+	if len(in.Images) > 0 {
+		var parts []map[string]interface{}
+		for _, img := range in.Images {
+			parts = append(parts, map[string]interface{}{
+				"type": "image_url",
+				"image_url": map[string]interface{}{
+					"url": "data:" + img.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(img.Data),
+				},
+			})
+		}
+		parts = append(parts, map[string]interface{}{"type": "text", "text": in.Prompt})
+		content = parts
+	}
+
+	reqBody := map[string]interface{}{
+		"model": in.Model,
+		"messages": []map[string]interface{}{
+			{"role": "user", "content": content},
+		},
+		"temperature": in.Temperature,
+		"top_p":       in.TopP,
+		"max_tokens":  in.MaxOutputTokens,
+	}
+	if in.ResponseSchema != nil {
+		// Guided decoding in vLLM / llama.cpp; hosted APIs enforce it natively
+		reqBody["response_format"] = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   in.SchemaName,
+				"schema": in.ResponseSchema,
+			},
+		}
+	} else if in.JSONResponse {
+		reqBody["response_format"] = map[string]interface{}{"type": "json_object"}
+	}
+	if effort := reasoningEffort(in.ThinkingBudget); effort != "" {
+		reqBody["reasoning_effort"] = effort
+	}
+
+	headers := map[string]string{}
+	if p.apiKey != "" {
+		headers["Authorization"] = "Bearer " + p.apiKey
+	}
+
+	body, err := postJSON(ctx, p.httpClient, p.endpoint+"/chat/completions", headers, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage struct {
+			CompletionTokens        int `json:"completion_tokens"`
+			CompletionTokensDetails struct {
+				ReasoningTokens int `json:"reasoning_tokens"`
+			} `json:"completion_tokens_details"`
+		} `json:"usage"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if len(result.Choices) == 0 || result.Choices[0].Message.Content == "" {
+		return nil, fmt.Errorf("empty response from API")
+	}
+
+	model := result.Model
+	if model == "" {
+		model = in.Model
+	}
+
+	return &GenerateResponse{
+		Text:           result.Choices[0].Message.Content,
+		Model:          model,
+		ThinkingTokens: result.Usage.CompletionTokensDetails.ReasoningTokens,
+		OutputTokens:   result.Usage.CompletionTokens,
+	}, nil
+}
+
+// reasoningEffort maps a Gemini-style thinking token budget onto the
+// OpenAI reasoning_effort levels. Returns "" when thinking is disabled.
+func reasoningEffort(budget int) string {
+	switch {
+	case budget <= 0:
+		return ""
+	case budget <= 2048:
+		return "low"
+	case budget <= 8192:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// ============================================
+// SHARED HTTP HELPER
+// ============================================
+
+// postJSON sends a JSON body and returns the response body, treating any
+// non-200 status as an error
+func postJSON(ctx context.Context, httpClient *http.Client, url string, headers map[string]string, payload interface{}) ([]byte, error) {
+	jsonBody, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return body, nil
+}
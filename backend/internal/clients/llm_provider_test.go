@@ -0,0 +1,236 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sherlockos/backend/internal/models"
+	"github.com/sherlockos/backend/pkg/config"
+)
+
+func TestGeminiProvider_Generate(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/models/gemini-2.5-flash:generateContent") {
+			t.Errorf("request path = %v", r.URL.Path)
+		}
+		if r.URL.Query().Get("key") != "test-key" {
+			t.Errorf("request key = %v", r.URL.Query().Get("key"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{
+			"candidates": [{"content": {"parts": [{"text": "hidden", "thought": true}, {"text": "{\"ok\": true}"}]}}],
+			"usageMetadata": {"candidatesTokenCount": 12, "thoughtsTokenCount": 340}
+		}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewLLMProvider(config.LLMProviderGemini, server.URL, "test-key")
+	if err != nil {
+		t.Fatalf("NewLLMProvider() error = %v", err)
+	}
+
+	resp, err := provider.Generate(context.Background(), GenerateRequest{
+		Model:          "gemini-2.5-flash",
+		Prompt:         "describe",
+		Images:         []LLMImage{{MIMEType: "image/png", Data: []byte{1, 2, 3}}},
+		ThinkingBudget: 1024,
+		JSONResponse:   true,
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if resp.Text != `{"ok": true}` {
+		t.Errorf("Generate() Text = %q, thought parts should be skipped", resp.Text)
+	}
+	if resp.ThinkingTokens != 340 || resp.OutputTokens != 12 {
+		t.Errorf("Generate() usage = %d/%d", resp.ThinkingTokens, resp.OutputTokens)
+	}
+
+	genConfig := got["generationConfig"].(map[string]interface{})
+	if genConfig["responseMimeType"] != "application/json" {
+		t.Errorf("generationConfig.responseMimeType = %v", genConfig["responseMimeType"])
+	}
+	if genConfig["thinkingConfig"].(map[string]interface{})["thinkingBudget"] != float64(1024) {
+		t.Errorf("generationConfig.thinkingConfig = %v", genConfig["thinkingConfig"])
+	}
+	parts := got["contents"].([]interface{})[0].(map[string]interface{})["parts"].([]interface{})
+	if len(parts) != 2 || parts[0].(map[string]interface{})["inlineData"] == nil {
+		t.Errorf("contents parts = %v, want image then text", parts)
+	}
+}
+
+func TestOpenAICompatibleProvider_Generate(t *testing.T) {
+	var got map[string]interface{}
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("request path = %v", r.URL.Path)
+		}
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{
+			"model": "qwen2.5-vl-7b",
+			"choices": [{"message": {"role": "assistant", "content": "{\"objects\": []}"}}],
+			"usage": {"completion_tokens": 30, "completion_tokens_details": {"reasoning_tokens": 8}}
+		}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewLLMProvider(config.LLMProviderOpenAI, server.URL+"/v1/", "")
+	if err != nil {
+		t.Fatalf("NewLLMProvider() error = %v", err)
+	}
+
+	resp, err := provider.Generate(context.Background(), GenerateRequest{
+		Model:          "local",
+		Prompt:         "analyze",
+		Images:         []LLMImage{{MIMEType: "image/jpeg", Data: []byte("jpg")}},
+		ThinkingBudget: 16000,
+		JSONResponse:   true,
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if resp.Text != `{"objects": []}` || resp.Model != "qwen2.5-vl-7b" {
+		t.Errorf("Generate() = %+v", resp)
+	}
+	if resp.ThinkingTokens != 8 || resp.OutputTokens != 30 {
+		t.Errorf("Generate() usage = %d/%d", resp.ThinkingTokens, resp.OutputTokens)
+	}
+	if auth != "" {
+		t.Errorf("Authorization header = %q, want none without API key", auth)
+	}
+
+	if got["reasoning_effort"] != "high" {
+		t.Errorf("reasoning_effort = %v, want high", got["reasoning_effort"])
+	}
+	if got["response_format"].(map[string]interface{})["type"] != "json_object" {
+		t.Errorf("response_format = %v", got["response_format"])
+	}
+	content := got["messages"].([]interface{})[0].(map[string]interface{})["content"].([]interface{})
+	imagePart := content[0].(map[string]interface{})["image_url"].(map[string]interface{})
+	if imagePart["url"] != "data:image/jpeg;base64,anBn" {
+		t.Errorf("image_url = %v", imagePart["url"])
+	}
+}
+
+func TestOpenAICompatibleProvider_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Authorization header = %q", r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("model loading"))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAICompatibleProvider(server.URL, "secret")
+	_, err := provider.Generate(context.Background(), GenerateRequest{Model: "m", Prompt: "p"})
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Generate() error = %v, want status 503", err)
+	}
+}
+
+func TestNewLLMProvider_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		endpoint string
+		apiKey   string
+	}{
+		{"gemini without key", config.LLMProviderGemini, "", ""},
+		{"openai without endpoint", config.LLMProviderOpenAI, "", "key"},
+		{"unknown provider", "anthropic", "http://x", "key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewLLMProvider(tt.provider, tt.endpoint, tt.apiKey); err == nil {
+				t.Error("NewLLMProvider() expected error")
+			}
+		})
+	}
+}
+
+func TestLLMJobConfig_ThinkingBudget(t *testing.T) {
+	tests := []struct {
+		name      string
+		cap       int
+		requested int
+		want      int
+	}{
+		{"no cap keeps request", 0, 8192, 8192},
+		{"cap lowers request", 2048, 8192, 2048},
+		{"cap above request", 16000, 8192, 8192},
+		{"cap applies to unset request", 1024, 0, 1024},
+		{"negative disables", -1, 8192, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := LLMJobConfig{ThinkingBudget: tt.cap}
+			if got := cfg.thinkingBudget(tt.requested); got != tt.want {
+				t.Errorf("thinkingBudget(%d) = %d, want %d", tt.requested, got, tt.want)
+			}
+		})
+	}
+}
+
+// stubLLMProvider records the last request and returns fixed text
+type stubLLMProvider struct {
+	text string
+	last GenerateRequest
+}
+
+func (s *stubLLMProvider) Name() string { return "stub" }
+
+func (s *stubLLMProvider) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	s.last = req
+	return &GenerateResponse{Text: s.text, Model: req.Model, ThinkingTokens: 5, OutputTokens: 7}, nil
+}
+
+func TestReasoningClient_UsesConfiguredProvider(t *testing.T) {
+	stub := &stubLLMProvider{text: "```json\n{\"trajectories\": [{\"id\": \"t1\", \"rank\": 1, \"overall_confidence\": 0.8, \"segments\": []}], " +
+		"\"uncertainty_areas\": [], \"next_step_suggestions\": []}\n```"}
+	client := NewReasoningClient(LLMJobConfig{Provider: stub, Model: "llama-3.3-70b", ThinkingBudget: -1})
+
+	output, err := client.Reason(context.Background(), models.ReasoningInput{MaxTrajectories: 1, ThinkingBudget: 8192})
+	if err != nil {
+		t.Fatalf("Reason() error = %v", err)
+	}
+
+	if stub.last.Model != "llama-3.3-70b" || stub.last.ThinkingBudget != 0 {
+		t.Errorf("Reason() request model/budget = %v/%v", stub.last.Model, stub.last.ThinkingBudget)
+	}
+	if len(output.Trajectories) != 1 || output.Trajectories[0].ID != "t1" {
+		t.Errorf("Reason() trajectories = %+v", output.Trajectories)
+	}
+	if output.ModelStats.ThinkingTokens != 5 || output.ModelStats.OutputTokens != 7 {
+		t.Errorf("Reason() ModelStats = %+v", output.ModelStats)
+	}
+}
+
+func TestSceneAnalysisClient_DefaultModel(t *testing.T) {
+	stub := &stubLLMProvider{text: `{"detected_objects": [], "potential_evidence": [], "scene_description": "empty room"}`}
+	storage := &MockStorageClient{}
+	client := NewSceneAnalysisClient(LLMJobConfig{Provider: stub}, storage)
+
+	output, err := client.AnalyzeScene(context.Background(), models.SceneAnalysisInput{ImageKeys: []string{"cases/x/scans/a.png"}})
+	if err != nil {
+		t.Fatalf("AnalyzeScene() error = %v", err)
+	}
+
+	if output.ModelUsed != geminiSceneAnalysisModel {
+		t.Errorf("AnalyzeScene() ModelUsed = %v, want %v", output.ModelUsed, geminiSceneAnalysisModel)
+	}
+	if !stub.last.JSONResponse || len(stub.last.Images) != 1 || stub.last.Images[0].MIMEType != "image/png" {
+		t.Errorf("AnalyzeScene() request = %+v", stub.last)
+	}
+}
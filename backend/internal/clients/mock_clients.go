@@ -112,22 +112,20 @@ func (m *MockImageGenClient) Generate(ctx context.Context, input models.ImageGen
 
 	// Determine model based on resolution (Nano Banana series)
 	modelUsed := "gemini-2.5-flash-image" // Nano Banana
-	cost := 0.04
+	cost := models.ImageCostUSD(input.Resolution)
 	width, height := 1024, 1024
 
 	if input.Resolution == "2k" {
 		modelUsed = "gemini-3-pro-image-preview" // Nano Banana Pro
-		cost = 0.134
 		width, height = 2048, 2048
 	} else if input.Resolution == "4k" {
 		modelUsed = "gemini-3-pro-image-preview" // Nano Banana Pro
-		cost = 0.24
 		width, height = 4096, 4096
 	}
 
 	output := &models.ImageGenOutput{
 		AssetKey:       "cases/" + input.CaseID + "/generated/" + uuid.New().String() + ".png",
-		ThumbnailKey:   "cases/" + input.CaseID + "/generated/" + uuid.New().String() + "_thumb.png",
+		ThumbnailKey:   "cases/" + input.CaseID + "/generated/" + uuid.New().String() + "_thumb.jpg",
 		Width:          width,
 		Height:         height,
 		ModelUsed:      modelUsed,
@@ -142,7 +140,7 @@ func (m *MockImageGenClient) Generate(ctx context.Context, input models.ImageGen
 			output.GeneratedImages = append(output.GeneratedImages, models.GeneratedImage{
 				ViewAngle:    angle,
 				AssetKey:     "cases/" + input.CaseID + "/generated/pov/" + angle + "_" + uuid.New().String() + ".png",
-				ThumbnailKey: "cases/" + input.CaseID + "/generated/pov/" + angle + "_" + uuid.New().String() + "_thumb.png",
+				ThumbnailKey: "cases/" + input.CaseID + "/generated/pov/" + angle + "_" + uuid.New().String() + "_thumb.jpg",
 				Width:        width,
 				Height:       height,
 			})
@@ -207,7 +205,7 @@ func (m *MockReplayClient) GenerateReplay(ctx context.Context, input models.Repl
 
 	return &models.ReplayOutput{
 		VideoAssetKey:  "cases/" + input.CaseID + "/replay/" + uuid.New().String() + ".mp4",
-		ThumbnailKey:   "cases/" + input.CaseID + "/replay/" + uuid.New().String() + "_thumb.png",
+		ThumbnailKey:   "cases/" + input.CaseID + "/replay/" + uuid.New().String() + "_thumb.jpg",
 		FrameCount:     frameCount,
 		FPS:            24,
 		DurationMs:     int64(frameCount * 1000 / 24),
@@ -240,7 +238,7 @@ func (m *MockAsset3DClient) Generate3DAsset(ctx context.Context, input models.As
 	// Default mock response
 	return &models.Asset3DOutput{
 		MeshAssetKey:   "cases/" + input.CaseID + "/models/" + uuid.New().String() + "." + format,
-		ThumbnailKey:   "cases/" + input.CaseID + "/models/" + uuid.New().String() + "_thumb.png",
+		ThumbnailKey:   "cases/" + input.CaseID + "/models/" + uuid.New().String() + "_thumb.jpg",
 		Format:         format,
 		HasTexture:     input.WithTexture,
 		VertexCount:    15000,
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sherlockos/backend/internal/media"
 	"github.com/sherlockos/backend/internal/models"
 	"golang.org/x/image/draw"
 )
@@ -41,6 +42,11 @@ func NewModalReconstructionClient(baseURL string, storage StorageClient) *ModalR
 	}
 }
 
+// SetTransport implements HTTPTransportSetter
+func (c *ModalReconstructionClient) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = rt
+}
+
 // modalReconstructionRequest is the request format for the Modal API
 type modalReconstructionRequest struct {
 	CaseID             string      `json:"case_id"`
@@ -75,6 +81,7 @@ type modalReconstructionResponse struct {
 	MeshAssetKey       *string `json:"mesh_asset_key"`
 	PointcloudAssetKey *string `json:"pointcloud_asset_key"`
 	GaussianAssetKey   *string `json:"gaussian_asset_key"`
+	CameraPoses        []models.CameraPose `json:"camera_poses"`
 	UncertaintyRegions []struct {
 		ID     string                 `json:"id"`
 		BBox   map[string]interface{} `json:"bbox"`
@@ -133,6 +140,10 @@ func (c *ModalReconstructionClient) Reconstruct(ctx context.Context, input model
 		reqBody.ScanAssetKeys = encodedImages
 	}
 
+	if len(input.CameraPoses) > 0 {
+		reqBody.CameraPoses = input.CameraPoses
+	}
+
 	if input.ExistingScenegraph != nil {
 		reqBody.ExistingScenegraph = input.ExistingScenegraph
 	}
@@ -284,6 +295,9 @@ func (c *ModalReconstructionClient) convertToOutput(resp modalReconstructionResp
 		output.GaussianAssetKey = *resp.GaussianAssetKey
 	}
 
+	// Pass through solved camera poses so detections can be placed in 3D
+	output.CameraPoses = resp.CameraPoses
+
 	// Pass through point cloud data if present
 	if resp.PointCloud != nil && resp.PointCloud.Count > 0 {
 		output.PointCloud = &models.PointCloud{
@@ -353,6 +367,7 @@ func (c *ModalReconstructionClient) convertToOutput(resp modalReconstructionResp
 type ModalReplayClient struct {
 	baseURL    string
 	storage    StorageClient
+	thumbnails *Thumbnailer
 	httpClient *http.Client
 }
 
@@ -360,14 +375,20 @@ type ModalReplayClient struct {
 // baseURL should be like "https://ykzou1214--hy-worldplay"
 func NewModalReplayClient(baseURL string, storage StorageClient) *ModalReplayClient {
 	return &ModalReplayClient{
-		baseURL: baseURL,
-		storage: storage,
+		baseURL:    baseURL,
+		storage:    storage,
+		thumbnails: NewThumbnailer(storage, media.DetectFrameExtractor()),
 		httpClient: &http.Client{
 			Timeout: 600 * time.Second, // Video generation can take 5-10 minutes
 		},
 	}
 }
 
+// SetTransport implements HTTPTransportSetter
+func (c *ModalReplayClient) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = rt
+}
+
 // modalReplayRequest is the request format for the Modal WorldPlay API
 type modalReplayRequest struct {
 	Prompt            string  `json:"prompt"`
@@ -474,7 +495,7 @@ func (c *ModalReplayClient) GenerateReplay(ctx context.Context, input models.Rep
 	}
 
 	// 8. Save video to storage
-	videoAssetKey, err := c.saveVideo(ctx, modalResp.VideoBase64, input.CaseID)
+	videoAssetKey, videoData, err := c.saveVideo(ctx, modalResp.VideoBase64, input.CaseID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to save video: %w", err)
 	}
@@ -486,13 +507,23 @@ func (c *ModalReplayClient) GenerateReplay(ctx context.Context, input models.Rep
 	if frameCount == 0 {
 		frameCount = 125
 	}
+	durationMs := int64(frameCount * 1000 / 24)
+
+	// 9. Poster frame (only when a local video decoder is available)
+	var thumbnailKey string
+	if videoData != nil {
+		thumbnailKey, err = c.thumbnails.ForVideo(ctx, videoAssetKey, videoData, float64(durationMs)/1000)
+		if err != nil {
+			fmt.Printf("Warning: failed to create replay poster frame: %v\n", err)
+		}
+	}
 
 	return &models.ReplayOutput{
 		VideoAssetKey:  videoAssetKey,
-		ThumbnailKey:   "", // TODO: Extract thumbnail from video
+		ThumbnailKey:   thumbnailKey,
 		FrameCount:     frameCount,
 		FPS:            24,
-		DurationMs:     int64(frameCount * 1000 / 24),
+		DurationMs:     durationMs,
 		Resolution:     resolution,
 		ModelUsed:      "hy-world-1.5",
 		GenerationTime: time.Since(startTime).Milliseconds(),
@@ -534,16 +565,17 @@ func (c *ModalReplayClient) buildPoseString(input models.ReplayInput) string {
 	return fmt.Sprintf("w-%d", frameCount/4)
 }
 
-func (c *ModalReplayClient) saveVideo(ctx context.Context, videoBase64 string, caseID string) (string, error) {
+// saveVideo uploads the generated video and returns its storage key and decoded bytes
+func (c *ModalReplayClient) saveVideo(ctx context.Context, videoBase64 string, caseID string) (string, []byte, error) {
 	if c.storage == nil {
 		// Return a placeholder key if no storage
-		return fmt.Sprintf("cases/%s/replay/%s.mp4", caseID, uuid.New().String()), nil
+		return fmt.Sprintf("cases/%s/replay/%s.mp4", caseID, uuid.New().String()), nil, nil
 	}
 
 	// Decode base64 video
 	videoData, err := base64.StdEncoding.DecodeString(videoBase64)
 	if err != nil {
-		return "", fmt.Errorf("failed to decode video: %w", err)
+		return "", nil, fmt.Errorf("failed to decode video: %w", err)
 	}
 
 	// Generate storage key
@@ -551,10 +583,10 @@ func (c *ModalReplayClient) saveVideo(ctx context.Context, videoBase64 string, c
 
 	// Upload to storage
 	if err := c.storage.Upload(ctx, "case-assets", assetKey, videoData, "video/mp4"); err != nil {
-		return "", fmt.Errorf("failed to upload video: %w", err)
+		return "", nil, fmt.Errorf("failed to upload video: %w", err)
 	}
 
-	return assetKey, nil
+	return assetKey, videoData, nil
 }
 
 // ============================================
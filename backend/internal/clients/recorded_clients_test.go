@@ -0,0 +1,445 @@
+package clients
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// Tests in this file replay recorded HTTP fixtures through the real clients,
+// covering request building and response parsing without network access.
+
+func TestGeminiReasoningClient_Recorded(t *testing.T) {
+	cassette := loadCassette(t, "gemini_reasoning_success")
+	client := NewGeminiReasoningClient("test-key")
+	client.SetTransport(cassette)
+
+	output, err := client.Reason(context.Background(), models.ReasoningInput{
+		CaseID:          "case_123",
+		MaxTrajectories: 2,
+		ThinkingBudget:  8192,
+	})
+	if err != nil {
+		t.Fatalf("Reason() error = %v", err)
+	}
+
+	if len(output.Trajectories) != 2 || output.Trajectories[0].ID != "traj_001" {
+		t.Fatalf("Reason() trajectories = %+v", output.Trajectories)
+	}
+	if len(output.Trajectories[0].Segments) != 2 {
+		t.Errorf("Reason() first trajectory segments = %d, want 2", len(output.Trajectories[0].Segments))
+	}
+	if output.ModelStats.ThinkingTokens != 2210 || output.ModelStats.OutputTokens != 612 {
+		t.Errorf("Reason() ModelStats = %+v", output.ModelStats)
+	}
+
+	// The request carried the API key and thinking budget
+	req := cassette.Requests()[0]
+	if req.URL.Query().Get("key") != "test-key" {
+		t.Errorf("request key = %q", req.URL.Query().Get("key"))
+	}
+	var body map[string]interface{}
+	json.Unmarshal(cassette.RequestBody(0), &body)
+	thinking := body["generationConfig"].(map[string]interface{})["thinkingConfig"].(map[string]interface{})
+	if thinking["thinkingBudget"] != float64(8192) {
+		t.Errorf("request thinkingBudget = %v", thinking["thinkingBudget"])
+	}
+	schema, _ := body["generationConfig"].(map[string]interface{})["responseSchema"].(map[string]interface{})
+	if schema["type"] != "OBJECT" || schema["properties"] == nil {
+		t.Errorf("request responseSchema = %v", schema)
+	}
+}
+
+func TestGeminiReasoningClient_RecordedRepair(t *testing.T) {
+	cassette := loadCassette(t, "gemini_reasoning_repaired")
+	client := NewGeminiReasoningClient("test-key")
+	client.SetTransport(cassette)
+
+	output, err := client.Reason(context.Background(), models.ReasoningInput{MaxTrajectories: 1})
+	if err != nil {
+		t.Fatalf("Reason() error = %v", err)
+	}
+
+	if len(cassette.Requests()) != 2 {
+		t.Fatalf("requests = %d, want 2 (original + repair)", len(cassette.Requests()))
+	}
+	if got := output.Trajectories[0].OverallConfidence; got != 0.72 {
+		t.Errorf("Reason() overall_confidence = %v, want repaired value 0.72", got)
+	}
+	// Usage is summed across both attempts
+	if output.ModelStats.ThinkingTokens != 1810 || output.ModelStats.OutputTokens != 478 {
+		t.Errorf("Reason() ModelStats = %+v", output.ModelStats)
+	}
+
+	// The repair prompt names the violations
+	var body struct {
+		Contents []struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"contents"`
+	}
+	json.Unmarshal(cassette.RequestBody(1), &body)
+	prompt := body.Contents[0].Parts[0].Text
+	for _, want := range []string{"Correction Required", "overall_confidence: 72 is above the maximum 1", `"strong" is not one of`} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("repair prompt missing %q", want)
+		}
+	}
+}
+
+func TestGeminiReasoningClient_RecordedFailures(t *testing.T) {
+	tests := []struct {
+		cassette string
+		check    func(t *testing.T, err error)
+	}{
+		{
+			cassette: "gemini_reasoning_malformed_json",
+			check: func(t *testing.T, err error) {
+				if !strings.Contains(err.Error(), "failed to parse response") {
+					t.Errorf("error = %v, want parse failure", err)
+				}
+			},
+		},
+		{
+			cassette: "gemini_rate_limited",
+			check: func(t *testing.T, err error) {
+				if !strings.Contains(err.Error(), "status 429") || !strings.Contains(err.Error(), "RESOURCE_EXHAUSTED") {
+					t.Errorf("error = %v, want 429 RESOURCE_EXHAUSTED", err)
+				}
+			},
+		},
+		{
+			cassette: "gemini_timeout",
+			check: func(t *testing.T, err error) {
+				var netErr net.Error
+				if !errors.As(err, &netErr) || !netErr.Timeout() {
+					t.Errorf("error = %v, want timeout", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cassette, func(t *testing.T) {
+			client := NewGeminiReasoningClient("test-key")
+			client.SetTransport(loadCassette(t, tt.cassette))
+
+			_, err := client.Reason(context.Background(), models.ReasoningInput{MaxTrajectories: 1})
+			if err == nil {
+				t.Fatal("Reason() expected error")
+			}
+			tt.check(t, err)
+		})
+	}
+}
+
+func TestOpenAIReasoningClient_Recorded(t *testing.T) {
+	cassette := loadCassette(t, "openai_reasoning_success")
+	provider := NewOpenAICompatibleProvider("http://localhost:8000/v1", "")
+	provider.SetTransport(cassette)
+	client := NewReasoningClient(LLMJobConfig{Provider: provider, Model: "Qwen/Qwen3-32B"})
+
+	output, err := client.Reason(context.Background(), models.ReasoningInput{MaxTrajectories: 2, ThinkingBudget: 8192})
+	if err != nil {
+		t.Fatalf("Reason() error = %v", err)
+	}
+	if len(output.Trajectories) != 2 {
+		t.Errorf("Reason() trajectories = %d, want 2", len(output.Trajectories))
+	}
+	if output.ModelStats.ThinkingTokens != 410 {
+		t.Errorf("Reason() ThinkingTokens = %d, want 410", output.ModelStats.ThinkingTokens)
+	}
+
+	var body map[string]interface{}
+	json.Unmarshal(cassette.RequestBody(0), &body)
+	if body["model"] != "Qwen/Qwen3-32B" || body["reasoning_effort"] != "medium" {
+		t.Errorf("request model/effort = %v/%v", body["model"], body["reasoning_effort"])
+	}
+	format, _ := body["response_format"].(map[string]interface{})
+	if format["type"] != "json_schema" {
+		t.Errorf("request response_format = %v, want json_schema", format)
+	}
+}
+
+func TestGeminiProfileClient_Recorded(t *testing.T) {
+	client := NewGeminiProfileClient("test-key")
+	client.SetTransport(loadCassette(t, "gemini_profile_success"))
+
+	attrs, err := client.ExtractProfile(context.Background(), []models.WitnessStatementInput{
+		{SourceName: "Neighbor", Content: "Tall guy, maybe 30, short dark hair, scar over his eyebrow.", Credibility: 0.8},
+	}, nil)
+	if err != nil {
+		t.Fatalf("ExtractProfile() error = %v", err)
+	}
+
+	if attrs.AgeRange == nil || attrs.AgeRange.Min != 25 || attrs.AgeRange.Max != 35 {
+		t.Errorf("ExtractProfile() AgeRange = %+v", attrs.AgeRange)
+	}
+	if attrs.Hair == nil || attrs.Hair.Color != "dark brown" {
+		t.Errorf("ExtractProfile() Hair = %+v", attrs.Hair)
+	}
+	if len(attrs.DistinctiveFeatures) != 1 {
+		t.Errorf("ExtractProfile() DistinctiveFeatures = %+v", attrs.DistinctiveFeatures)
+	}
+}
+
+func TestGeminiSceneAnalysisClient_Recorded(t *testing.T) {
+	cassette := loadCassette(t, "gemini_scene_analysis_success")
+	client := NewGeminiSceneAnalysisClient("test-key", &MockStorageClient{})
+	client.SetTransport(cassette)
+
+	output, err := client.AnalyzeScene(context.Background(), models.SceneAnalysisInput{
+		CaseID:    "case_123",
+		ImageKeys: []string{"cases/case_123/scans/a.jpg", "cases/case_123/scans/b.jpg"},
+		Mode:      "full_analysis",
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeScene() error = %v", err)
+	}
+
+	if len(output.DetectedObjects) != 3 {
+		t.Fatalf("AnalyzeScene() objects = %d, want 3", len(output.DetectedObjects))
+	}
+	if output.DetectedObjects[2].ID == "" {
+		t.Error("AnalyzeScene() should assign IDs to objects without one")
+	}
+	if output.ModelUsed != "gemini-2.0-flash-001" {
+		t.Errorf("AnalyzeScene() ModelUsed = %v", output.ModelUsed)
+	}
+
+	var body struct {
+		Contents []struct {
+			Parts []map[string]interface{} `json:"parts"`
+		} `json:"contents"`
+	}
+	json.Unmarshal(cassette.RequestBody(0), &body)
+	if parts := body.Contents[0].Parts; len(parts) != 3 || parts[2]["text"] == nil {
+		t.Errorf("request parts = %d, want 2 images then the prompt", len(parts))
+	}
+}
+
+func TestGeminiImageGenClient_Recorded(t *testing.T) {
+	var uploads []string
+	storage := &MockStorageClient{
+		UploadFunc: func(ctx context.Context, bucket, key string, data []byte, contentType string) error {
+			uploads = append(uploads, key)
+			return nil
+		},
+	}
+	client := NewGeminiImageGenClient("test-key", storage)
+	client.SetTransport(loadCassette(t, "gemini_imagegen_success"))
+
+	output, err := client.Generate(context.Background(), models.ImageGenInput{
+		CaseID:  "case_123",
+		GenType: models.ImageGenTypePortrait,
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if output.ModelUsed != "gemini-2.5-flash-image" {
+		t.Errorf("Generate() ModelUsed = %v", output.ModelUsed)
+	}
+	if len(uploads) != 2 || output.ThumbnailKey != uploads[1] {
+		t.Errorf("Generate() uploads = %v, thumbnail = %v", uploads, output.ThumbnailKey)
+	}
+}
+
+func TestGeminiImageGenClient_RecordedEvidenceBoard(t *testing.T) {
+	var downloaded string
+	storage := &MockStorageClient{
+		DownloadFunc: func(ctx context.Context, bucket, key string) ([]byte, string, error) {
+			downloaded = key
+			return []byte("floor plan png"), "image/png", nil
+		},
+	}
+	client := NewGeminiImageGenClient("test-key", storage)
+	cassette := loadCassette(t, "gemini_imagegen_success")
+	client.SetTransport(cassette)
+
+	_, err := client.Generate(context.Background(), models.ImageGenInput{
+		CaseID:            "case_123",
+		GenType:           models.ImageGenTypeEvidenceBoard,
+		ReferenceImageKey: "cases/case_123/floorplans/plan.png",
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if downloaded != "cases/case_123/floorplans/plan.png" {
+		t.Errorf("downloaded %q, want the reference image", downloaded)
+	}
+
+	// The floor plan goes to the model inline, after the prompt
+	var body struct {
+		Contents []struct {
+			Parts []struct {
+				Text       string `json:"text"`
+				InlineData *struct {
+					MimeType string `json:"mimeType"`
+					Data     string `json:"data"`
+				} `json:"inlineData"`
+			} `json:"parts"`
+		} `json:"contents"`
+	}
+	json.Unmarshal(cassette.RequestBody(0), &body)
+	parts := body.Contents[0].Parts
+	if len(parts) != 2 || !strings.Contains(parts[0].Text, "floor plan") || parts[1].InlineData == nil ||
+		parts[1].InlineData.Data != base64.StdEncoding.EncodeToString([]byte("floor plan png")) {
+		t.Errorf("request parts = %+v", parts)
+	}
+}
+
+func TestModalReconstructionClient_Recorded(t *testing.T) {
+	cassette := loadCassette(t, "modal_reconstruct_success")
+	client := NewModalReconstructionClient("https://test--sherlock-mirror", &MockStorageClient{})
+	client.SetTransport(cassette)
+
+	output, err := client.Reconstruct(context.Background(), models.ReconstructionInput{
+		CaseID:        "case_123",
+		ScanAssetKeys: []string{"cases/case_123/scans/a.png", "cases/case_123/scans/b.png"},
+	})
+	if err != nil {
+		t.Fatalf("Reconstruct() error = %v", err)
+	}
+
+	if len(output.Objects) != 1 || output.Objects[0].Object == nil {
+		t.Fatalf("Reconstruct() objects = %+v", output.Objects)
+	}
+	obj := output.Objects[0].Object
+	if obj.Pose.Position != [3]float64{1.5, 0, -0.5} || obj.BBox.Max != [3]float64{2, 0.8, 0} {
+		t.Errorf("Reconstruct() object pose/bbox = %+v / %+v", obj.Pose, obj.BBox)
+	}
+	if output.PointCloud == nil || output.PointCloud.Count != 3 {
+		t.Errorf("Reconstruct() PointCloud = %+v", output.PointCloud)
+	}
+	if output.GaussianAssetKey != "cases/test/reconstruction/scene.ply" {
+		t.Errorf("Reconstruct() GaussianAssetKey = %v", output.GaussianAssetKey)
+	}
+	if len(output.UncertaintyRegions) != 1 || output.UncertaintyRegions[0].Level != models.UncertaintyLevel("high") {
+		t.Errorf("Reconstruct() UncertaintyRegions = %+v", output.UncertaintyRegions)
+	}
+
+	var body struct {
+		CaseID        string   `json:"case_id"`
+		ScanAssetKeys []string `json:"scan_asset_keys"`
+	}
+	json.Unmarshal(cassette.RequestBody(0), &body)
+	if body.CaseID != "case_123" || len(body.ScanAssetKeys) != 2 {
+		t.Errorf("request case_id/images = %v/%d", body.CaseID, len(body.ScanAssetKeys))
+	}
+}
+
+func TestModalReconstructionClient_RecordedFailures(t *testing.T) {
+	tests := []struct {
+		cassette string
+		want     string
+	}{
+		{"modal_reconstruct_malformed_json", "failed to parse response"},
+		{"modal_reconstruct_rate_limited", "status 429"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cassette, func(t *testing.T) {
+			client := NewModalReconstructionClient("https://test--sherlock-mirror", &MockStorageClient{})
+			client.SetTransport(loadCassette(t, tt.cassette))
+
+			_, err := client.Reconstruct(context.Background(), models.ReconstructionInput{
+				CaseID:        "case_123",
+				ScanAssetKeys: []string{"cases/case_123/scans/a.png"},
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Reconstruct() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestModalReplayClient_Recorded(t *testing.T) {
+	client := NewModalReplayClient("https://test--hy-worldplay", &MockStorageClient{})
+	client.SetTransport(loadCassette(t, "modal_replay_success"))
+
+	output, err := client.GenerateReplay(context.Background(), models.ReplayInput{
+		CaseID:     "case_123",
+		FrameCount: 48,
+	})
+	if err != nil {
+		t.Fatalf("GenerateReplay() error = %v", err)
+	}
+
+	if output.FrameCount != 48 || output.DurationMs != 2000 {
+		t.Errorf("GenerateReplay() frames/duration = %d/%d", output.FrameCount, output.DurationMs)
+	}
+	if !strings.HasPrefix(output.VideoAssetKey, "cases/case_123/replay/") {
+		t.Errorf("GenerateReplay() VideoAssetKey = %v", output.VideoAssetKey)
+	}
+}
+
+func TestModalReplayClient_RecordedTimeout(t *testing.T) {
+	client := NewModalReplayClient("https://test--hy-worldplay", &MockStorageClient{})
+	client.SetTransport(loadCassette(t, "modal_replay_timeout"))
+
+	_, err := client.GenerateReplay(context.Background(), models.ReplayInput{CaseID: "case_123"})
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("GenerateReplay() error = %v, want timeout", err)
+	}
+}
+
+func TestReplicateAsset3DClient_Recorded(t *testing.T) {
+	cassette := loadCassette(t, "replicate_asset3d_success")
+	var meshUpload []byte
+	storage := &MockStorageClient{
+		UploadFunc: func(ctx context.Context, bucket, key string, data []byte, contentType string) error {
+			if strings.HasSuffix(key, ".glb") {
+				meshUpload = data
+			}
+			return nil
+		},
+	}
+	client := NewReplicateAsset3DClient("test-token", storage)
+	client.SetTransport(cassette)
+	client.pollInterval = time.Millisecond
+
+	output, err := client.Generate3DAsset(context.Background(), models.Asset3DInput{
+		CaseID:      "case_123",
+		ImageKey:    "cases/case_123/evidence/knife.png",
+		WithTexture: true,
+	})
+	if err != nil {
+		t.Fatalf("Generate3DAsset() error = %v", err)
+	}
+
+	if !strings.HasPrefix(string(meshUpload), "glTF") {
+		t.Errorf("uploaded mesh = %q, want recorded GLB bytes", meshUpload)
+	}
+	if output.Format != "glb" || output.ThumbnailKey == "" {
+		t.Errorf("Generate3DAsset() = %+v", output)
+	}
+	if cassette.Unused() != 0 {
+		t.Errorf("cassette has %d unused interactions", cassette.Unused())
+	}
+	if auth := cassette.Requests()[0].Header.Get("Authorization"); auth != "Bearer test-token" {
+		t.Errorf("request Authorization = %q", auth)
+	}
+}
+
+func TestReplicateAsset3DClient_RecordedRateLimit(t *testing.T) {
+	client := NewReplicateAsset3DClient("test-token", &MockStorageClient{})
+	client.SetTransport(loadCassette(t, "replicate_rate_limited"))
+
+	_, err := client.Generate3DAsset(context.Background(), models.Asset3DInput{
+		CaseID:   "case_123",
+		ImageKey: "cases/case_123/evidence/knife.png",
+	})
+	if err == nil || !strings.Contains(err.Error(), "status 429") {
+		t.Errorf("Generate3DAsset() error = %v, want status 429", err)
+	}
+}
@@ -1,12 +1,8 @@
 package clients
 
 import (
-	"bytes"
 	"context"
-	"encoding/base64"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -22,30 +18,31 @@ const (
 	geminiSceneAnalysisModel = "gemini-2.0-flash-001"
 )
 
-// imagePayload holds base64 encoded image data
-type imagePayload struct {
-	base64Data string
-	mimeType   string
-}
-
-// GeminiSceneAnalysisClient implements SceneAnalysisClient using Gemini 2.0 Flash
+// GeminiSceneAnalysisClient implements SceneAnalysisClient. It defaults to Gemini 2.0
+// Flash, but generation goes through any configured vision-capable LLMProvider.
 type GeminiSceneAnalysisClient struct {
-	apiKey     string
-	storage    StorageClient
-	httpClient *http.Client
+	llm     LLMJobConfig
+	storage StorageClient
 }
 
-// NewGeminiSceneAnalysisClient creates a new scene analysis client
+// NewGeminiSceneAnalysisClient creates a new scene analysis client backed by the Gemini API
 func NewGeminiSceneAnalysisClient(apiKey string, storage StorageClient) *GeminiSceneAnalysisClient {
+	return NewSceneAnalysisClient(LLMJobConfig{Provider: NewGeminiProvider(apiKey)}, storage)
+}
+
+// NewSceneAnalysisClient creates a scene analysis client using the given provider configuration
+func NewSceneAnalysisClient(llm LLMJobConfig, storage StorageClient) *GeminiSceneAnalysisClient {
 	return &GeminiSceneAnalysisClient{
-		apiKey:  apiKey,
+		llm:     llm,
 		storage: storage,
-		httpClient: &http.Client{
-			Timeout: 180 * time.Second, // Vision models need longer timeout
-		},
 	}
 }
 
+// SetTransport implements HTTPTransportSetter
+func (c *GeminiSceneAnalysisClient) SetTransport(rt http.RoundTripper) {
+	setProviderTransport(c.llm.Provider, rt)
+}
+
 // AnalyzeScene processes images and returns detected objects/evidence
 func (c *GeminiSceneAnalysisClient) AnalyzeScene(ctx context.Context, input models.SceneAnalysisInput) (*models.SceneAnalysisOutput, error) {
 	startTime := time.Now()
@@ -59,29 +56,42 @@ func (c *GeminiSceneAnalysisClient) AnalyzeScene(ctx context.Context, input mode
 	// 2. Build analysis prompt
 	prompt := c.buildAnalysisPrompt(input)
 
-	// 3. Make API request with images
-	response, err := c.analyzeWithVision(ctx, images, prompt)
+	// 3. Make API request with images, constrained to the output schema
+	output := &models.SceneAnalysisOutput{}
+	resp, err := generateStructured(ctx, c.llm, GenerateRequest{
+		Model:           c.llm.model(geminiSceneAnalysisModel),
+		Prompt:          prompt,
+		Images:          images,
+		Temperature:     0.2, // Low temperature for more deterministic output
+		TopP:            0.95,
+		MaxOutputTokens: 8192,
+		ThinkingBudget:  c.llm.thinkingBudget(0),
+	}, SchemaSceneAnalysisOutput, sceneAnalysisOutputSchema(), output, nil)
 	if err != nil {
-		return nil, fmt.Errorf("gemini vision API error: %w", err)
+		return nil, c.llm.wrapError(err)
 	}
 
-	// 4. Parse response
-	output, err := c.parseAnalysisResponse(response, input.ImageKeys)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	// 4. Assign IDs and source image keys to objects
+	for i := range output.DetectedObjects {
+		if output.DetectedObjects[i].ID == "" {
+			output.DetectedObjects[i].ID = uuid.New().String()
+		}
+		if output.DetectedObjects[i].SourceImageKey == "" && len(input.ImageKeys) > 0 {
+			output.DetectedObjects[i].SourceImageKey = input.ImageKeys[0]
+		}
 	}
 
 	output.AnalysisTime = time.Since(startTime).Milliseconds()
-	output.ModelUsed = geminiSceneAnalysisModel
+	output.ModelUsed = resp.Model
 	return output, nil
 }
 
-func (c *GeminiSceneAnalysisClient) fetchImages(ctx context.Context, keys []string) ([]imagePayload, error) {
+func (c *GeminiSceneAnalysisClient) fetchImages(ctx context.Context, keys []string) ([]LLMImage, error) {
 	if c.storage == nil {
 		return nil, fmt.Errorf("storage client not configured")
 	}
 
-	var images []imagePayload
+	var images []LLMImage
 	for _, key := range keys {
 		// Parse bucket and path from key
 		// Key format: "cases/{caseId}/scans/{batchId}/{filename}"
@@ -105,9 +115,9 @@ func (c *GeminiSceneAnalysisClient) fetchImages(ctx context.Context, keys []stri
 			}
 		}
 
-		images = append(images, imagePayload{
-			base64Data: base64.StdEncoding.EncodeToString(data),
-			mimeType:   mimeType,
+		images = append(images, LLMImage{
+			MIMEType: mimeType,
+			Data:     data,
 		})
 	}
 
@@ -127,7 +137,7 @@ Analyze the provided crime scene images and:
 ## Output Format
 Respond with valid JSON in this exact structure:
 {
-  "objects": [
+  "detected_objects": [
     {
       "id": "obj_001",
       "type": "furniture|door|window|wall|evidence_item|weapon|footprint|bloodstain|vehicle|person_marker|other",
@@ -162,115 +172,3 @@ Be thorough and precise. Mark items as suspicious if they appear disturbed, out
 
 	return basePrompt
 }
-
-func (c *GeminiSceneAnalysisClient) analyzeWithVision(ctx context.Context, images []imagePayload, prompt string) (string, error) {
-	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s", geminiBaseURL, geminiSceneAnalysisModel, c.apiKey)
-
-	// Build parts array with images first, then text prompt
-	var parts []map[string]interface{}
-
-	// Add images
-	for _, img := range images {
-		parts = append(parts, map[string]interface{}{
-			"inlineData": map[string]interface{}{
-				"mimeType": img.mimeType,
-				"data":     img.base64Data,
-			},
-		})
-	}
-
-	// Add text prompt (must come after images)
-	parts = append(parts, map[string]interface{}{
-		"text": prompt,
-	})
-
-	reqBody := map[string]interface{}{
-		"contents": []map[string]interface{}{
-			{"parts": parts},
-		},
-		"generationConfig": map[string]interface{}{
-			"temperature":      0.2,  // Low temperature for more deterministic output
-			"topP":             0.95,
-			"maxOutputTokens":  8192,
-			"responseMimeType": "application/json",
-		},
-	}
-
-	jsonBody, _ := json.Marshal(reqBody)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	// Parse response
-	var result struct {
-		Candidates []struct {
-			Content struct {
-				Parts []struct {
-					Text string `json:"text"`
-				} `json:"parts"`
-			} `json:"content"`
-		} `json:"candidates"`
-	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("empty response from API")
-	}
-
-	return result.Candidates[0].Content.Parts[0].Text, nil
-}
-
-func (c *GeminiSceneAnalysisClient) parseAnalysisResponse(response string, imageKeys []string) (*models.SceneAnalysisOutput, error) {
-	jsonStr := extractJSON(response)
-
-	var rawOutput struct {
-		Objects           []models.DetectedObject `json:"objects"`
-		PotentialEvidence []string                `json:"potential_evidence"`
-		SceneDescription  string                  `json:"scene_description"`
-		Anomalies         []string                `json:"anomalies"`
-	}
-
-	if err := json.Unmarshal([]byte(jsonStr), &rawOutput); err != nil {
-		// Return partial result on parse failure
-		return &models.SceneAnalysisOutput{
-			SceneDescription:  response[:min(500, len(response))],
-			DetectedObjects:   []models.DetectedObject{},
-			PotentialEvidence: []string{},
-			Anomalies:         []string{},
-		}, nil
-	}
-
-	// Assign IDs and source image keys to objects
-	for i := range rawOutput.Objects {
-		if rawOutput.Objects[i].ID == "" {
-			rawOutput.Objects[i].ID = uuid.New().String()
-		}
-		if rawOutput.Objects[i].SourceImageKey == "" && len(imageKeys) > 0 {
-			rawOutput.Objects[i].SourceImageKey = imageKeys[0]
-		}
-	}
-
-	return &models.SceneAnalysisOutput{
-		DetectedObjects:   rawOutput.Objects,
-		PotentialEvidence: rawOutput.PotentialEvidence,
-		SceneDescription:  rawOutput.SceneDescription,
-		Anomalies:         rawOutput.Anomalies,
-	}, nil
-}
@@ -0,0 +1,336 @@
+package clients
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSONSchema is the subset of JSON Schema used to constrain and validate
+// structured LLM output. It is also translated into each provider's
+// response-schema format.
+type JSONSchema struct {
+	Type       string                 `json:"type,omitempty"` // empty accepts any value
+	Properties map[string]*JSONSchema `json:"properties,omitempty"`
+	Required   []string               `json:"required,omitempty"`
+	Items      *JSONSchema            `json:"items,omitempty"`
+	Enum       []string               `json:"enum,omitempty"`
+	Minimum    *float64               `json:"minimum,omitempty"`
+	Maximum    *float64               `json:"maximum,omitempty"`
+	MinItems   *int                   `json:"minItems,omitempty"`
+	MaxItems   *int                   `json:"maxItems,omitempty"`
+
+	order []string // property declaration order, kept for stable prompts and provider schemas
+}
+
+// Schema types
+const (
+	SchemaTypeObject  = "object"
+	SchemaTypeArray   = "array"
+	SchemaTypeString  = "string"
+	SchemaTypeNumber  = "number"
+	SchemaTypeInteger = "integer"
+	SchemaTypeBoolean = "boolean"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaFor derives a schema from a Go value's type using its json tags.
+// Fields tagged omitempty and pointer fields are optional; all others are required.
+func SchemaFor(v interface{}) *JSONSchema {
+	return schemaForType(reflect.TypeOf(v))
+}
+
+func schemaForType(t reflect.Type) *JSONSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: SchemaTypeString}
+	case reflect.Bool:
+		return &JSONSchema{Type: SchemaTypeBoolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: SchemaTypeInteger}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: SchemaTypeNumber}
+	case reflect.Slice:
+		return &JSONSchema{Type: SchemaTypeArray, Items: schemaForType(t.Elem())}
+	case reflect.Array:
+		n := t.Len()
+		return &JSONSchema{Type: SchemaTypeArray, Items: schemaForType(t.Elem()), MinItems: &n, MaxItems: &n}
+	case reflect.Map:
+		return &JSONSchema{Type: SchemaTypeObject}
+	case reflect.Struct:
+		if t == timeType {
+			return &JSONSchema{Type: SchemaTypeString}
+		}
+		s := &JSONSchema{Type: SchemaTypeObject, Properties: map[string]*JSONSchema{}}
+		addStructFields(s, t)
+		return s
+	default:
+		return &JSONSchema{}
+	}
+}
+
+func addStructFields(s *JSONSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			addStructFields(s, f.Type)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s.Properties[name] = schemaForType(f.Type)
+		s.order = append(s.order, name)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// Property returns the schema at a property path, descending through array items.
+// Returns nil if the path does not exist.
+func (s *JSONSchema) Property(path ...string) *JSONSchema {
+	cur := s
+	for _, name := range path {
+		for cur != nil && cur.Type == SchemaTypeArray {
+			cur = cur.Items
+		}
+		if cur == nil || cur.Properties[name] == nil {
+			return nil
+		}
+		cur = cur.Properties[name]
+	}
+	return cur
+}
+
+// DropProperty removes a property (e.g. a server-populated field) wherever it appears
+func (s *JSONSchema) DropProperty(name string) *JSONSchema {
+	s.walk(func(n *JSONSchema) {
+		if _, ok := n.Properties[name]; !ok {
+			return
+		}
+		delete(n.Properties, name)
+		n.Required = removeString(n.Required, name)
+		n.order = removeString(n.order, name)
+	})
+	return s
+}
+
+// Bound sets an inclusive numeric range on every property with one of the given names
+func (s *JSONSchema) Bound(min, max float64, names ...string) *JSONSchema {
+	s.walk(func(n *JSONSchema) {
+		for _, name := range names {
+			if p := n.Properties[name]; p != nil && (p.Type == SchemaTypeNumber || p.Type == SchemaTypeInteger) {
+				lo, hi := min, max
+				p.Minimum, p.Maximum = &lo, &hi
+			}
+		}
+	})
+	return s
+}
+
+// walk visits every schema node
+func (s *JSONSchema) walk(fn func(*JSONSchema)) {
+	if s == nil {
+		return
+	}
+	fn(s)
+	for _, p := range s.Properties {
+		p.walk(fn)
+	}
+	s.Items.walk(fn)
+}
+
+// Validate checks a decoded JSON value (from json.Unmarshal into interface{})
+// and returns one message per violation, prefixed with its path
+func (s *JSONSchema) Validate(v interface{}) []string {
+	var problems []string
+	s.validate("$", v, &problems)
+	return problems
+}
+
+// maxSchemaProblems caps how many violations are reported (and fed back to the model)
+const maxSchemaProblems = 20
+
+func (s *JSONSchema) validate(path string, v interface{}, problems *[]string) {
+	if len(*problems) >= maxSchemaProblems {
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	switch s.Type {
+	case "":
+		return
+	case SchemaTypeObject:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			fail("expected object, got %s", jsonTypeName(v))
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		for _, name := range s.propertyOrder() {
+			if val, ok := obj[name]; ok && val != nil {
+				s.Properties[name].validate(path+"."+name, val, problems)
+			}
+		}
+	case SchemaTypeArray:
+		arr, ok := v.([]interface{})
+		if !ok {
+			fail("expected array, got %s", jsonTypeName(v))
+			return
+		}
+		if s.MinItems != nil && len(arr) < *s.MinItems {
+			fail("expected at least %d items, got %d", *s.MinItems, len(arr))
+		}
+		if s.MaxItems != nil && len(arr) > *s.MaxItems {
+			fail("expected at most %d items, got %d", *s.MaxItems, len(arr))
+		}
+		if s.Items != nil {
+			for i, item := range arr {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, problems)
+			}
+		}
+	case SchemaTypeString:
+		str, ok := v.(string)
+		if !ok {
+			fail("expected string, got %s", jsonTypeName(v))
+			return
+		}
+		if len(s.Enum) > 0 && !containsString(s.Enum, str) {
+			fail("%q is not one of %s", str, strings.Join(s.Enum, ", "))
+		}
+	case SchemaTypeNumber, SchemaTypeInteger:
+		num, ok := v.(float64)
+		if !ok {
+			fail("expected %s, got %s", s.Type, jsonTypeName(v))
+			return
+		}
+		if s.Type == SchemaTypeInteger && num != math.Trunc(num) {
+			fail("expected integer, got %v", num)
+		}
+		if s.Minimum != nil && num < *s.Minimum {
+			fail("%v is below the minimum %v", num, *s.Minimum)
+		}
+		if s.Maximum != nil && num > *s.Maximum {
+			fail("%v is above the maximum %v", num, *s.Maximum)
+		}
+	case SchemaTypeBoolean:
+		if _, ok := v.(bool); !ok {
+			fail("expected boolean, got %s", jsonTypeName(v))
+		}
+	}
+}
+
+// propertyOrder returns property names in declaration order
+func (s *JSONSchema) propertyOrder() []string {
+	if len(s.order) == len(s.Properties) {
+		return s.order
+	}
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	return names
+}
+
+// geminiSchema converts the schema to Gemini's OpenAPI-style responseSchema
+func (s *JSONSchema) geminiSchema() map[string]interface{} {
+	out := map[string]interface{}{}
+	if s.Type != "" {
+		out["type"] = strings.ToUpper(s.Type)
+	}
+	if len(s.Properties) > 0 {
+		props := map[string]interface{}{}
+		for name, p := range s.Properties {
+			props[name] = p.geminiSchema()
+		}
+		out["properties"] = props
+		out["propertyOrdering"] = s.propertyOrder()
+	}
+	if len(s.Required) > 0 {
+		out["required"] = s.Required
+	}
+	if s.Items != nil {
+		out["items"] = s.Items.geminiSchema()
+	}
+	if len(s.Enum) > 0 {
+		out["enum"] = s.Enum
+		out["format"] = "enum"
+	}
+	if s.Minimum != nil {
+		out["minimum"] = *s.Minimum
+	}
+	if s.Maximum != nil {
+		out["maximum"] = *s.Maximum
+	}
+	if s.MinItems != nil {
+		out["minItems"] = *s.MinItems
+	}
+	if s.MaxItems != nil {
+		out["maxItems"] = *s.MaxItems
+	}
+	return out
+}
+
+func jsonTypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(list []string, s string) []string {
+	out := list[:0]
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
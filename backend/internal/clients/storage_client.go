@@ -28,6 +28,11 @@ func NewSupabaseStorageClient(supabaseURL, secretKey string) *SupabaseStorageCli
 	}
 }
 
+// SetTransport implements HTTPTransportSetter
+func (c *SupabaseStorageClient) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = rt
+}
+
 // GenerateUploadURL creates a presigned URL for uploading
 func (c *SupabaseStorageClient) GenerateUploadURL(ctx context.Context, bucket, key string, expiresIn int) (string, error) {
 	url := fmt.Sprintf("%s/storage/v1/object/upload/sign/%s/%s", c.supabaseURL, bucket, key)
@@ -0,0 +1,184 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// maxRepairAttempts bounds how many times an invalid response is sent back
+// to the model for correction before giving up
+const maxRepairAttempts = 2
+
+// maxEchoedResponse limits how much of an invalid response is quoted in a repair prompt
+const maxEchoedResponse = 4000
+
+// Schema names used in provider requests and errors
+const (
+	SchemaReasoningOutput     = "reasoning_output"
+	SchemaSuspectAttributes   = "suspect_attributes"
+	SchemaSceneAnalysisOutput = "scene_analysis_output"
+)
+
+// StructuredOutputError is returned when a model keeps producing output that
+// does not match the expected schema. No placeholder result is fabricated;
+// the job should be retried.
+type StructuredOutputError struct {
+	Schema   string
+	Problems []string
+	Attempts int
+	Response string // last raw response, truncated
+}
+
+func (e *StructuredOutputError) Error() string {
+	problems := e.Problems
+	if len(problems) > 3 {
+		problems = append(problems[:3:3], fmt.Sprintf("and %d more", len(e.Problems)-3))
+	}
+	return fmt.Sprintf("model output does not match %s schema after %d attempt(s): %s",
+		e.Schema, e.Attempts, strings.Join(problems, "; "))
+}
+
+// Retryable reports that the request may succeed on a later attempt
+func (e *StructuredOutputError) Retryable() bool {
+	return true
+}
+
+// reasoningOutputSchema is derived from models.ReasoningOutput. Feasibility,
+// constraint reports, scores and grounding are added by the server after reasoning.
+func reasoningOutputSchema() *JSONSchema {
+	s := SchemaFor(models.ReasoningOutput{}).
+		DropProperty("model_stats").
+		DropProperty("feasibility").
+		DropProperty("constraint_report").
+		DropProperty("score").
+		DropProperty("scoring").
+		DropProperty("grounding").
+		Bound(0, 1, "overall_confidence", "confidence", "weight")
+	s.Property("trajectories", "segments", "evidence_refs", "relevance").Enum = []string{"supports", "contradicts", "neutral"}
+	s.Property("uncertainty_areas", "level").Enum = []string{
+		string(models.UncertaintyLevelLow), string(models.UncertaintyLevelMedium), string(models.UncertaintyLevelHigh),
+	}
+	s.Property("next_step_suggestions", "priority").Enum = []string{"high", "medium", "low"}
+	return s
+}
+
+// suspectAttributesSchema is derived from models.SuspectAttributes.
+// Source attribution is added by the server, not the model.
+func suspectAttributesSchema() *JSONSchema {
+	return SchemaFor(models.SuspectAttributes{}).
+		DropProperty("supporting_sources").
+		DropProperty("conflict_sources").
+		Bound(0, 1, "confidence")
+}
+
+// sceneAnalysisOutputSchema is derived from models.SceneAnalysisOutput.
+// Timing, model and source image fields are filled in by the client, and
+// merged sources by the worker.
+func sceneAnalysisOutputSchema() *JSONSchema {
+	s := SchemaFor(models.SceneAnalysisOutput{}).
+		DropProperty("analysis_time_ms").
+		DropProperty("model_used").
+		DropProperty("source_image_key").
+		DropProperty("sources").
+		Bound(0, 1, "confidence", "x", "y", "width", "height")
+	// Object IDs are assigned by the client when the model leaves them out
+	objects := s.Property("detected_objects").Items
+	objects.Required = removeString(objects.Required, "id")
+	return s
+}
+
+// generateStructured requests JSON matching schema and decodes it into out.
+// Invalid responses are re-prompted with the validation problems up to
+// maxRepairAttempts times; check (optional) adds semantic validation after
+// decoding. Token usage is summed across attempts.
+func generateStructured(ctx context.Context, llm LLMJobConfig, req GenerateRequest, schemaName string, schema *JSONSchema, out interface{}, check func() error) (*GenerateResponse, error) {
+	req.SchemaName = schemaName
+	req.ResponseSchema = schema
+	req.JSONResponse = true
+	basePrompt := req.Prompt
+
+	total := &GenerateResponse{}
+	var problems []string
+	var lastText string
+
+	for attempt := 1; attempt <= maxRepairAttempts+1; attempt++ {
+		resp, err := llm.Provider.Generate(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		total.Model = resp.Model
+		total.ThinkingTokens += resp.ThinkingTokens
+		total.OutputTokens += resp.OutputTokens
+
+		lastText = resp.Text
+		problems = decodeStructured(resp.Text, schema, out, check)
+		if len(problems) == 0 {
+			total.Text = resp.Text
+			return total, nil
+		}
+
+		req.Prompt = basePrompt + repairInstructions(problems, resp.Text)
+	}
+
+	return nil, &StructuredOutputError{
+		Schema:   schemaName,
+		Problems: problems,
+		Attempts: maxRepairAttempts + 1,
+		Response: truncate(lastText, maxEchoedResponse),
+	}
+}
+
+// decodeStructured validates a response against schema and decodes it into out,
+// returning the problems found (none on success)
+func decodeStructured(text string, schema *JSONSchema, out interface{}, check func() error) []string {
+	jsonStr := extractJSON(text)
+
+	var generic interface{}
+	if err := json.Unmarshal([]byte(jsonStr), &generic); err != nil {
+		return []string{"response is not valid JSON: " + err.Error()}
+	}
+	if problems := schema.Validate(generic); len(problems) > 0 {
+		return problems
+	}
+
+	// Reset out so fields from a previous invalid attempt don't leak through
+	v := reflect.ValueOf(out).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	if err := json.Unmarshal([]byte(jsonStr), out); err != nil {
+		return []string{"response does not decode: " + err.Error()}
+	}
+
+	if check != nil {
+		if err := check(); err != nil {
+			return []string{err.Error()}
+		}
+	}
+	return nil
+}
+
+// repairInstructions asks the model to correct its previous response
+func repairInstructions(problems []string, previous string) string {
+	return fmt.Sprintf(`
+
+## Correction Required
+Your previous response was rejected because it does not match the required JSON schema:
+- %s
+
+Previous response:
+%s
+
+Respond again with only a corrected JSON object. Do not invent evidence or values to satisfy the schema; use empty arrays where nothing applies.`,
+		strings.Join(problems, "\n- "), truncate(previous, maxEchoedResponse))
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,190 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// sequenceLLMProvider returns one canned response per call
+type sequenceLLMProvider struct {
+	texts    []string
+	requests []GenerateRequest
+}
+
+func (s *sequenceLLMProvider) Name() string { return "sequence" }
+
+func (s *sequenceLLMProvider) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	s.requests = append(s.requests, req)
+	text := s.texts[len(s.texts)-1]
+	if len(s.requests) <= len(s.texts) {
+		text = s.texts[len(s.requests)-1]
+	}
+	return &GenerateResponse{Text: text, Model: req.Model, OutputTokens: 10}, nil
+}
+
+func TestSchemaFor(t *testing.T) {
+	s := SchemaFor(models.ReasoningOutput{})
+
+	if s.Type != SchemaTypeObject {
+		t.Fatalf("SchemaFor() type = %v, want object", s.Type)
+	}
+	if !containsString(s.Required, "trajectories") || containsString(s.Required, "thinking_summary") {
+		t.Errorf("SchemaFor() required = %v", s.Required)
+	}
+	from := s.Property("trajectories", "segments", "from_position")
+	if from == nil || from.Type != SchemaTypeArray || *from.MinItems != 3 || *from.MaxItems != 3 {
+		t.Errorf("from_position schema = %+v, want fixed-length array", from)
+	}
+	if rank := s.Property("trajectories", "rank"); rank == nil || rank.Type != SchemaTypeInteger {
+		t.Errorf("rank schema = %+v", rank)
+	}
+	// Pointer fields are optional
+	if seg := s.Property("trajectories", "segments").Items; containsString(seg.Required, "time_estimate") {
+		t.Errorf("segment required = %v, time_estimate should be optional", seg.Required)
+	}
+}
+
+func TestReasoningOutputSchema_DropsServerFields(t *testing.T) {
+	s := reasoningOutputSchema()
+	for _, path := range [][]string{
+		{"scoring"},
+		{"trajectories", "score"},
+		{"trajectories", "constraint_report"},
+		{"trajectories", "segments", "feasibility"},
+		{"grounding"},
+		{"trajectories", "grounding"},
+	} {
+		if p := s.Property(path...); p != nil {
+			t.Errorf("schema property %v should be dropped", path)
+		}
+	}
+}
+
+func TestJSONSchema_Validate(t *testing.T) {
+	s := reasoningOutputSchema()
+
+	tests := []struct {
+		name string
+		json string
+		want []string
+	}{
+		{
+			name: "valid",
+			json: `{"trajectories": [], "uncertainty_areas": [], "next_step_suggestions": []}`,
+		},
+		{
+			name: "missing and mistyped",
+			json: `{"trajectories": {}, "uncertainty_areas": []}`,
+			want: []string{`$: missing required property "next_step_suggestions"`, "$.trajectories: expected array, got object"},
+		},
+		{
+			name: "nested bounds and enums",
+			json: `{"trajectories": [{"id": "t", "rank": 1.5, "overall_confidence": 2, "segments": []}],
+				"uncertainty_areas": [], "next_step_suggestions": [{"type": "analyze", "description": "x", "priority": "urgent"}]}`,
+			want: []string{
+				"$.trajectories[0].rank: expected integer, got 1.5",
+				"$.trajectories[0].overall_confidence: 2 is above the maximum 1",
+				`$.next_step_suggestions[0].priority: "urgent" is not one of high, medium, low`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			if err := json.Unmarshal([]byte(tt.json), &v); err != nil {
+				t.Fatal(err)
+			}
+			got := s.Validate(v)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONSchema_GeminiSchema(t *testing.T) {
+	g := suspectAttributesSchema().geminiSchema()
+
+	if g["type"] != "OBJECT" {
+		t.Errorf("type = %v, want OBJECT", g["type"])
+	}
+	props := g["properties"].(map[string]interface{})
+	if _, ok := props["supporting_sources"]; ok {
+		t.Error("server-populated supporting_sources should be dropped")
+	}
+	order := g["propertyOrdering"].([]string)
+	if len(order) == 0 || order[0] != "age_range" {
+		t.Errorf("propertyOrdering = %v", order)
+	}
+	conf := props["age_range"].(map[string]interface{})["properties"].(map[string]interface{})["confidence"].(map[string]interface{})
+	if conf["minimum"] != 0.0 || conf["maximum"] != 1.0 {
+		t.Errorf("confidence bounds = %v", conf)
+	}
+}
+
+func TestGenerateStructured_Repair(t *testing.T) {
+	stub := &sequenceLLMProvider{texts: []string{
+		"not json at all",
+		`{"detected_objects": [], "potential_evidence": [], "scene_description": "hallway"}`,
+	}}
+
+	var out models.SceneAnalysisOutput
+	resp, err := generateStructured(context.Background(), LLMJobConfig{Provider: stub},
+		GenerateRequest{Prompt: "analyze"}, SchemaSceneAnalysisOutput, sceneAnalysisOutputSchema(), &out, nil)
+	if err != nil {
+		t.Fatalf("generateStructured() error = %v", err)
+	}
+
+	if out.SceneDescription != "hallway" || resp.OutputTokens != 20 {
+		t.Errorf("generateStructured() out = %+v, tokens = %d", out, resp.OutputTokens)
+	}
+	if len(stub.requests) != 2 {
+		t.Fatalf("requests = %d, want 2", len(stub.requests))
+	}
+	first, second := stub.requests[0], stub.requests[1]
+	if first.ResponseSchema == nil || first.SchemaName != SchemaSceneAnalysisOutput || !first.JSONResponse {
+		t.Errorf("first request schema = %v/%v", first.SchemaName, first.ResponseSchema)
+	}
+	if !strings.HasPrefix(second.Prompt, "analyze") || !strings.Contains(second.Prompt, "not valid JSON") {
+		t.Errorf("repair prompt = %q", second.Prompt)
+	}
+}
+
+func TestGenerateStructured_Exhausted(t *testing.T) {
+	stub := &sequenceLLMProvider{texts: []string{`{"trajectories": [{"id": "fake"}]}`}}
+	client := NewReasoningClient(LLMJobConfig{Provider: stub})
+
+	output, err := client.Reason(context.Background(), models.ReasoningInput{MaxTrajectories: 1})
+	if output != nil {
+		t.Errorf("Reason() output = %+v, want nil", output)
+	}
+
+	var structured *StructuredOutputError
+	if !errors.As(err, &structured) {
+		t.Fatalf("Reason() error = %v, want *StructuredOutputError", err)
+	}
+	if !structured.Retryable() || structured.Attempts != maxRepairAttempts+1 || len(stub.requests) != maxRepairAttempts+1 {
+		t.Errorf("error attempts = %d, requests = %d", structured.Attempts, len(stub.requests))
+	}
+	if structured.Schema != SchemaReasoningOutput || len(structured.Problems) == 0 {
+		t.Errorf("error = %+v", structured)
+	}
+}
+
+func TestProfileClient_RejectsInvalidRange(t *testing.T) {
+	stub := &sequenceLLMProvider{texts: []string{`{"age_range": {"min": 40, "max": 30, "confidence": 0.5}}`}}
+	client := NewProfileClient(LLMJobConfig{Provider: stub})
+
+	_, err := client.ExtractProfile(context.Background(), []models.WitnessStatementInput{{Content: "older man"}}, nil)
+
+	var structured *StructuredOutputError
+	if !errors.As(err, &structured) || !strings.Contains(structured.Problems[0], "age_range") {
+		t.Errorf("ExtractProfile() error = %v, want age_range range violation", err)
+	}
+}
@@ -0,0 +1,64 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sherlockos/backend/internal/media"
+)
+
+// Thumbnailer renders fixed-size previews for image and video assets and
+// stores them next to the original in the case-assets bucket
+type Thumbnailer struct {
+	storage StorageClient
+	frames  media.FrameExtractor
+}
+
+// NewThumbnailer creates a thumbnailer. frames may be nil, in which case
+// video poster frames are not generated.
+func NewThumbnailer(storage StorageClient, frames media.FrameExtractor) *Thumbnailer {
+	return &Thumbnailer{storage: storage, frames: frames}
+}
+
+// ForImage creates and uploads a thumbnail for an image asset, returning its storage key
+func (t *Thumbnailer) ForImage(ctx context.Context, assetKey string, imageData []byte) (string, error) {
+	if t == nil || t.storage == nil {
+		return "", fmt.Errorf("storage client not configured")
+	}
+
+	thumb, err := media.GenerateThumbnail(imageData)
+	if err != nil {
+		return "", err
+	}
+	return t.upload(ctx, assetKey, thumb)
+}
+
+// ForVideo extracts a poster frame from a video asset and uploads its thumbnail.
+// Returns an empty key without error when no local decoder is available.
+func (t *Thumbnailer) ForVideo(ctx context.Context, assetKey string, videoData []byte, durationSeconds float64) (string, error) {
+	if t == nil || t.frames == nil {
+		return "", nil
+	}
+	if t.storage == nil {
+		return "", fmt.Errorf("storage client not configured")
+	}
+
+	frame, err := t.frames.ExtractFrame(ctx, videoData, media.PosterFrameOffset(durationSeconds))
+	if err != nil {
+		return "", fmt.Errorf("failed to extract poster frame: %w", err)
+	}
+
+	thumb, err := media.GenerateThumbnail(frame)
+	if err != nil {
+		return "", err
+	}
+	return t.upload(ctx, assetKey, thumb)
+}
+
+func (t *Thumbnailer) upload(ctx context.Context, assetKey string, thumb []byte) (string, error) {
+	key := media.ThumbnailKey(assetKey)
+	if err := t.storage.Upload(ctx, "case-assets", key, thumb, media.ThumbnailContentType); err != nil {
+		return "", fmt.Errorf("failed to upload thumbnail: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,93 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/sherlockos/backend/internal/media"
+)
+
+// stubFrameExtractor returns a fixed frame, standing in for a local video decoder
+type stubFrameExtractor struct {
+	frame []byte
+	err   error
+	at    float64
+}
+
+func (s *stubFrameExtractor) ExtractFrame(ctx context.Context, video []byte, atSeconds float64) ([]byte, error) {
+	s.at = atSeconds
+	return s.frame, s.err
+}
+
+func testPNG(w, h int) []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)))
+	return buf.Bytes()
+}
+
+func TestThumbnailer_ForImage(t *testing.T) {
+	var uploadedKey, uploadedType string
+	storage := &MockStorageClient{
+		UploadFunc: func(ctx context.Context, bucket, key string, data []byte, contentType string) error {
+			uploadedKey, uploadedType = key, contentType
+			return nil
+		},
+	}
+
+	thumbKey, err := NewThumbnailer(storage, nil).ForImage(context.Background(), "cases/1/generated/a.png", testPNG(640, 480))
+	if err != nil {
+		t.Fatalf("ForImage() error = %v", err)
+	}
+	if thumbKey != "cases/1/generated/a_thumb.jpg" || uploadedKey != thumbKey {
+		t.Errorf("ForImage() key = %q, uploaded %q", thumbKey, uploadedKey)
+	}
+	if uploadedType != media.ThumbnailContentType {
+		t.Errorf("ForImage() content type = %q, want %q", uploadedType, media.ThumbnailContentType)
+	}
+}
+
+func TestThumbnailer_ForImageErrors(t *testing.T) {
+	if _, err := NewThumbnailer(nil, nil).ForImage(context.Background(), "k.png", testPNG(4, 4)); err == nil {
+		t.Error("ForImage() expected error without storage")
+	}
+
+	failing := &MockStorageClient{
+		UploadFunc: func(ctx context.Context, bucket, key string, data []byte, contentType string) error {
+			return errors.New("bucket missing")
+		},
+	}
+	if _, err := NewThumbnailer(failing, nil).ForImage(context.Background(), "k.png", testPNG(4, 4)); err == nil {
+		t.Error("ForImage() expected error when upload fails")
+	}
+}
+
+func TestThumbnailer_ForVideo(t *testing.T) {
+	storage := &MockStorageClient{}
+
+	// No local decoder: no poster frame, no error
+	key, err := NewThumbnailer(storage, nil).ForVideo(context.Background(), "cases/1/replay/v.mp4", []byte("video"), 5)
+	if err != nil || key != "" {
+		t.Errorf("ForVideo() without extractor = %q, %v; want empty key and nil error", key, err)
+	}
+
+	frames := &stubFrameExtractor{frame: testPNG(1280, 720)}
+	key, err = NewThumbnailer(storage, frames).ForVideo(context.Background(), "cases/1/replay/v.mp4", []byte("video"), 5)
+	if err != nil {
+		t.Fatalf("ForVideo() error = %v", err)
+	}
+	if key != "cases/1/replay/v_thumb.jpg" {
+		t.Errorf("ForVideo() key = %q", key)
+	}
+	if frames.at != 1 {
+		t.Errorf("ForVideo() extracted frame at %vs, want 1s", frames.at)
+	}
+
+	frames.err = errors.New("decoder crashed")
+	if _, err := NewThumbnailer(storage, frames).ForVideo(context.Background(), "v.mp4", nil, 5); err == nil {
+		t.Error("ForVideo() expected error when frame extraction fails")
+	}
+}
@@ -0,0 +1,253 @@
+// Package constraints checks trajectory and suspect hypotheses against a
+// scene's typed constraints.
+package constraints
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// Subject types recorded on reports
+const (
+	SubjectTrajectory = "trajectory"
+	SubjectSuspect    = "suspect"
+)
+
+const (
+	// tolerance is how far, in meters, a route may stray outside the passable
+	// area or into an obstacle before it counts as a violation
+	tolerance = 0.3
+	// sampleStep is the spacing, in meters, of the points checked along a route
+	sampleStep = 0.25
+	// doorReach is how close a route must pass to a door to count as using it
+	doorReach = 0.3
+	// minBlockingHeight is the height below which objects don't stop a door swinging
+	minBlockingHeight = 0.1
+	// maxObstacleShare skips objects covering more than this share of the
+	// scene's floor, which are scene-level proxies rather than obstacles
+	maxObstacleShare = 0.5
+)
+
+// timeLayouts are the formats accepted in segment time estimates. Times of
+// day alone can't be placed against a dated window and are ignored.
+var timeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05"}
+
+// Evaluator checks hypotheses against every constraint of one scene
+type Evaluator struct {
+	constraints []models.Constraint
+	objects     map[string]models.SceneObject
+	scene       *models.SceneGraph
+}
+
+// NewEvaluator prepares the scene's constraints. extra constraints, such as a
+// reasoning job's overrides, are evaluated after the scene's own.
+func NewEvaluator(sg *models.SceneGraph, extra []models.Constraint) *Evaluator {
+	if sg == nil {
+		sg = models.NewEmptySceneGraph()
+	}
+	e := &Evaluator{
+		objects: make(map[string]models.SceneObject, len(sg.Objects)),
+		scene:   sg,
+	}
+	e.constraints = append(e.constraints, sg.Constraints...)
+	e.constraints = append(e.constraints, extra...)
+	for _, obj := range sg.Objects {
+		if obj.State != models.ObjectStateRemoved {
+			e.objects[obj.ID] = obj
+		}
+	}
+	return e
+}
+
+// Constraints returns the constraints the evaluator checks
+func (e *Evaluator) Constraints() []models.Constraint {
+	return e.constraints
+}
+
+// EvaluateTrajectory checks a trajectory against every constraint
+func (e *Evaluator) EvaluateTrajectory(t models.Trajectory) *models.ConstraintReport {
+	report := models.NewConstraintReport(SubjectTrajectory, t.ID)
+	for _, c := range e.constraints {
+		result := e.check(c, func(params models.ConstraintParams) (models.ConstraintStatus, string) {
+			switch p := params.(type) {
+			case *models.PassableAreaParams:
+				return checkPassable(p, t)
+			case *models.DoorDirectionParams:
+				return e.checkDoor(p, t)
+			case *models.TimeWindowParams:
+				return e.checkTimeWindow(p, t)
+			}
+			return models.ConstraintStatusNotApplicable, "does not constrain movement"
+		})
+		report.Add(result)
+	}
+	return report
+}
+
+// EvaluateSuspect checks a suspect profile's attributes against every constraint
+func (e *Evaluator) EvaluateSuspect(attrs *models.SuspectAttributes) *models.ConstraintReport {
+	report := models.NewConstraintReport(SubjectSuspect, "")
+	for _, c := range e.constraints {
+		result := e.check(c, func(params models.ConstraintParams) (models.ConstraintStatus, string) {
+			if p, ok := params.(*models.HeightRangeParams); ok {
+				return checkHeight(p, attrs)
+			}
+			return models.ConstraintStatusNotApplicable, "does not constrain the suspect's appearance"
+		})
+		report.Add(result)
+	}
+	return report
+}
+
+// Apply attaches a constraint report to each trajectory and returns the
+// number of trajectories that violate at least one constraint
+func (e *Evaluator) Apply(trajectories []models.Trajectory) int {
+	violating := 0
+	for i := range trajectories {
+		trajectories[i].ConstraintReport = e.EvaluateTrajectory(trajectories[i])
+		if trajectories[i].ConstraintReport.Violated > 0 {
+			violating++
+		}
+	}
+	return violating
+}
+
+// check decodes a constraint's params and runs the type-specific check on them
+func (e *Evaluator) check(c models.Constraint, fn func(models.ConstraintParams) (models.ConstraintStatus, string)) models.ConstraintResult {
+	result := models.ConstraintResult{
+		ConstraintID: c.ID,
+		Type:         c.Type,
+		Confidence:   c.Confidence,
+	}
+	params, err := c.TypedParams()
+	switch {
+	case err != nil:
+		result.Status = models.ConstraintStatusUnevaluated
+		result.Detail = "invalid params: " + err.Error()
+	case params == nil:
+		result.Status = models.ConstraintStatusUnevaluated
+		result.Detail = "custom constraints are not evaluated automatically"
+	default:
+		result.Status, result.Detail = fn(params)
+	}
+	return result
+}
+
+// checkPassable requires every point along the stated route to stay on the
+// passable area and out of its obstacles
+func checkPassable(p *models.PassableAreaParams, t models.Trajectory) (models.ConstraintStatus, string) {
+	if len(t.Segments) == 0 {
+		return models.ConstraintStatusNotApplicable, "trajectory has no segments"
+	}
+	for _, seg := range t.Segments {
+		route := segmentRoute(seg)
+		from, to := route[0], route[len(route)-1]
+		for _, pt := range sampleRoute(route) {
+			if !pointInPolygon(pt, p.Polygon) && polygonDistance(pt, p.Polygon) > tolerance {
+				return models.ConstraintStatusViolated,
+					fmt.Sprintf("segment %s leaves the passable area at (%.1f, %.1f)", seg.ID, pt[0], pt[1])
+			}
+			for _, o := range p.Obstacles {
+				// Routes may start or end at an obstacle, such as evidence on a table
+				if pointInPolygon(from, o.Polygon) || pointInPolygon(to, o.Polygon) {
+					continue
+				}
+				if pointInPolygon(pt, o.Polygon) && polygonDistance(pt, o.Polygon) > tolerance {
+					return models.ConstraintStatusViolated,
+						fmt.Sprintf("segment %s passes through obstacle %s at (%.1f, %.1f)", seg.ID, o.ObjectID, pt[0], pt[1])
+				}
+			}
+		}
+	}
+	return models.ConstraintStatusSatisfied, "route stays on the passable area"
+}
+
+// checkDoor requires the door's swing area on the stated side to be clear
+// when the trajectory passes through the door
+func (e *Evaluator) checkDoor(p *models.DoorDirectionParams, t models.Trajectory) (models.ConstraintStatus, string) {
+	door, ok := e.objects[p.ObjectID]
+	if !ok {
+		return models.ConstraintStatusUnevaluated, "door " + p.ObjectID + " is not in the scene"
+	}
+	if !passesThrough(t, door.BBox) {
+		return models.ConstraintStatusNotApplicable, "trajectory does not pass through door " + p.ObjectID
+	}
+
+	swing := swingArea(door.BBox, p.Direction, e.roomCenter())
+	if blocker, ok := e.blocking(swing, door.ID); ok {
+		return models.ConstraintStatusViolated,
+			fmt.Sprintf("door %s cannot swing %s: %s is in the way", p.ObjectID, p.Direction, blocker)
+	}
+	return models.ConstraintStatusSatisfied, fmt.Sprintf("door %s can swing %s", p.ObjectID, p.Direction)
+}
+
+// checkTimeWindow requires timed segments to overlap the window. With an
+// object, only segments ending at that object are checked.
+func (e *Evaluator) checkTimeWindow(p *models.TimeWindowParams, t models.Trajectory) (models.ConstraintStatus, string) {
+	start, end, _ := p.Window()
+
+	var target *models.BoundingBox
+	if p.ObjectID != "" {
+		obj, ok := e.objects[p.ObjectID]
+		if !ok {
+			return models.ConstraintStatusUnevaluated, "object " + p.ObjectID + " is not in the scene"
+		}
+		target = &obj.BBox
+	}
+
+	relevant, timed := 0, 0
+	for _, seg := range t.Segments {
+		if target != nil && !nearBox([2]float64{seg.ToPosition[0], seg.ToPosition[2]}, *target, tolerance) {
+			continue
+		}
+		relevant++
+		from, to, ok := segmentTimes(seg.TimeEstimate)
+		if !ok {
+			continue
+		}
+		timed++
+		if to.Before(start) || from.After(end) {
+			return models.ConstraintStatusViolated,
+				fmt.Sprintf("segment %s (%s – %s) falls outside the window", seg.ID, seg.TimeEstimate.Start, seg.TimeEstimate.End)
+		}
+	}
+
+	switch {
+	case relevant == 0:
+		return models.ConstraintStatusNotApplicable, "trajectory does not reach object " + p.ObjectID
+	case timed == 0:
+		return models.ConstraintStatusUnevaluated, "no segment has a dated time estimate"
+	}
+	return models.ConstraintStatusSatisfied, fmt.Sprintf("%d timed segments fall within the window", timed)
+}
+
+// checkHeight requires the suspect's estimated height range to overlap the constraint's
+func checkHeight(p *models.HeightRangeParams, attrs *models.SuspectAttributes) (models.ConstraintStatus, string) {
+	if attrs == nil || attrs.HeightRangeCm == nil {
+		return models.ConstraintStatusUnevaluated, "no height estimate for the suspect"
+	}
+	h := attrs.HeightRangeCm
+	if h.Max < p.MinCm || h.Min > p.MaxCm {
+		return models.ConstraintStatusViolated,
+			fmt.Sprintf("estimated height %.0f–%.0f cm is outside %.0f–%.0f cm", h.Min, h.Max, p.MinCm, p.MaxCm)
+	}
+	return models.ConstraintStatusSatisfied,
+		fmt.Sprintf("estimated height %.0f–%.0f cm overlaps %.0f–%.0f cm", h.Min, h.Max, p.MinCm, p.MaxCm)
+}
+
+// segmentTimes parses a segment's time estimate, if both ends carry a date
+func segmentTimes(est *models.TimeEstimate) (time.Time, time.Time, bool) {
+	if est == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	for _, layout := range timeLayouts {
+		start, err1 := time.Parse(layout, est.Start)
+		end, err2 := time.Parse(layout, est.End)
+		if err1 == nil && err2 == nil {
+			return start, end, true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
@@ -0,0 +1,220 @@
+package constraints
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// room is a 6 m × 4 m scene with a door in the front wall at z=0, a cabinet
+// just inside it and a sofa in the middle of the floor
+func room(t *testing.T, constraints ...models.Constraint) *models.SceneGraph {
+	t.Helper()
+	sg := models.NewEmptySceneGraph()
+	sg.Bounds = models.BoundingBox{Min: [3]float64{0, 0, 0}, Max: [3]float64{6, 2.6, 4}}
+	sg.Objects = []models.SceneObject{
+		{ID: "door", Type: models.ObjectTypeDoor, BBox: models.BoundingBox{Min: [3]float64{1, 0, -0.05}, Max: [3]float64{1.9, 2, 0.05}}},
+		{ID: "cabinet", Type: models.ObjectTypeFurniture, BBox: models.BoundingBox{Min: [3]float64{1.2, 0, 0.2}, Max: [3]float64{1.7, 1.2, 0.6}}},
+		{ID: "sofa", Type: models.ObjectTypeFurniture, BBox: models.BoundingBox{Min: [3]float64{3, 0, 1.5}, Max: [3]float64{5, 0.8, 2.5}}},
+		{ID: "safe", Type: models.ObjectTypeFurniture, BBox: models.BoundingBox{Min: [3]float64{5.4, 0, 3.4}, Max: [3]float64{5.9, 1, 3.9}}},
+	}
+	sg.Constraints = constraints
+
+	// Round-trip through JSON so params arrive as they do from the database
+	data, err := json.Marshal(sg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded models.SceneGraph
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return &decoded
+}
+
+func constraint(t *testing.T, id string, ctype models.ConstraintType, params models.ConstraintParams) models.Constraint {
+	t.Helper()
+	c, err := models.NewConstraint(id, ctype, "", params, 0.8)
+	if err != nil {
+		t.Fatalf("NewConstraint(%s) error = %v", id, err)
+	}
+	return *c
+}
+
+func trajectory(id string, segments ...models.TrajectorySegment) models.Trajectory {
+	return models.Trajectory{ID: id, Rank: 1, OverallConfidence: 0.8, Segments: segments}
+}
+
+func segment(id string, from, to [3]float64) models.TrajectorySegment {
+	return models.TrajectorySegment{ID: id, FromPosition: from, ToPosition: to, Confidence: 0.8}
+}
+
+func statusOf(t *testing.T, report *models.ConstraintReport, id string) models.ConstraintResult {
+	t.Helper()
+	for _, r := range report.Results {
+		if r.ConstraintID == id {
+			return r
+		}
+	}
+	t.Fatalf("no result for constraint %s in %+v", id, report.Results)
+	return models.ConstraintResult{}
+}
+
+func TestEvaluateTrajectory_PassableArea(t *testing.T) {
+	floor := constraint(t, "floor", models.ConstraintTypePassableArea, &models.PassableAreaParams{
+		Polygon: [][2]float64{{0, 0}, {6, 0}, {6, 4}, {0, 4}},
+		Obstacles: []models.PassableObstacle{
+			{ObjectID: "sofa", Polygon: [][2]float64{{3, 1.5}, {5, 1.5}, {5, 2.5}, {3, 2.5}}},
+			{ObjectID: "safe", Polygon: [][2]float64{{5.4, 3.4}, {5.9, 3.4}, {5.9, 3.9}, {5.4, 3.9}}},
+		},
+	})
+	e := NewEvaluator(room(t, floor), nil)
+
+	tests := []struct {
+		name string
+		traj models.Trajectory
+		want models.ConstraintStatus
+	}{
+		{"around the sofa", trajectory("a", segment("s1", [3]float64{1, 0, 1}, [3]float64{5.5, 0, 1})), models.ConstraintStatusSatisfied},
+		{"through the sofa", trajectory("b", segment("s1", [3]float64{2, 0, 2}, [3]float64{5.5, 0, 2})), models.ConstraintStatusViolated},
+		{"outside the room", trajectory("c", segment("s1", [3]float64{1, 0, 1}, [3]float64{8, 0, 1})), models.ConstraintStatusViolated},
+		{"just past the wall", trajectory("d", segment("s1", [3]float64{0.5, 0, -0.2}, [3]float64{2, 0, 1})), models.ConstraintStatusSatisfied},
+		{"ending at the safe", trajectory("e", segment("s1", [3]float64{2, 0, 3.65}, [3]float64{5.65, 0, 3.65})), models.ConstraintStatusSatisfied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := statusOf(t, e.EvaluateTrajectory(tt.traj), "floor")
+			if got.Status != tt.want {
+				t.Errorf("status = %s (%s), want %s", got.Status, got.Detail, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateTrajectory_DoorDirection(t *testing.T) {
+	enter := trajectory("enter", segment("s1", [3]float64{1.45, 0, -1}, [3]float64{2.5, 0, 1}))
+
+	// The cabinet stands in the inward swing area, so the door can't open into the room
+	inward := constraint(t, "door-in", models.ConstraintTypeDoorDirection,
+		&models.DoorDirectionParams{ObjectID: "door", Direction: models.DoorDirectionInward})
+	outward := constraint(t, "door-out", models.ConstraintTypeDoorDirection,
+		&models.DoorDirectionParams{ObjectID: "door", Direction: models.DoorDirectionOutward})
+	missing := constraint(t, "door-missing", models.ConstraintTypeDoorDirection,
+		&models.DoorDirectionParams{ObjectID: "back-door", Direction: models.DoorDirectionInward})
+	e := NewEvaluator(room(t, inward, outward, missing), nil)
+
+	report := e.EvaluateTrajectory(enter)
+	if got := statusOf(t, report, "door-in"); got.Status != models.ConstraintStatusViolated {
+		t.Errorf("inward = %s (%s), want violated", got.Status, got.Detail)
+	}
+	if got := statusOf(t, report, "door-out"); got.Status != models.ConstraintStatusSatisfied {
+		t.Errorf("outward = %s (%s), want satisfied", got.Status, got.Detail)
+	}
+	if got := statusOf(t, report, "door-missing"); got.Status != models.ConstraintStatusUnevaluated {
+		t.Errorf("missing door = %s, want unevaluated", got.Status)
+	}
+
+	elsewhere := trajectory("elsewhere", segment("s1", [3]float64{3, 0, 3}, [3]float64{5, 0, 3}))
+	if got := statusOf(t, e.EvaluateTrajectory(elsewhere), "door-in"); got.Status != models.ConstraintStatusNotApplicable {
+		t.Errorf("trajectory avoiding the door = %s, want not_applicable", got.Status)
+	}
+}
+
+func TestEvaluateTrajectory_TimeWindow(t *testing.T) {
+	window := constraint(t, "window", models.ConstraintTypeTimeWindow, &models.TimeWindowParams{
+		StartISO: "2024-01-15T22:00:00Z", EndISO: "2024-01-15T23:00:00Z",
+	})
+	atSafe := constraint(t, "at-safe", models.ConstraintTypeTimeWindow, &models.TimeWindowParams{
+		StartISO: "2024-01-15T22:30:00Z", EndISO: "2024-01-15T22:45:00Z", ObjectID: "safe",
+	})
+	e := NewEvaluator(room(t), []models.Constraint{window, atSafe})
+
+	timed := func(id string, to [3]float64, start, end string) models.TrajectorySegment {
+		seg := segment(id, [3]float64{1, 0, 1}, to)
+		seg.TimeEstimate = &models.TimeEstimate{Start: start, End: end}
+		return seg
+	}
+
+	inside := trajectory("inside",
+		timed("s1", [3]float64{3, 0, 1}, "2024-01-15T22:10:00Z", "2024-01-15T22:20:00Z"),
+		timed("s2", [3]float64{5.65, 0, 3.65}, "2024-01-15T22:35:00Z", "2024-01-15T22:40:00Z"))
+	report := e.EvaluateTrajectory(inside)
+	if got := statusOf(t, report, "window"); got.Status != models.ConstraintStatusSatisfied {
+		t.Errorf("window = %s (%s), want satisfied", got.Status, got.Detail)
+	}
+	if got := statusOf(t, report, "at-safe"); got.Status != models.ConstraintStatusSatisfied {
+		t.Errorf("at-safe = %s (%s), want satisfied", got.Status, got.Detail)
+	}
+
+	late := trajectory("late",
+		timed("s1", [3]float64{3, 0, 1}, "2024-01-15T22:10:00Z", "2024-01-15T22:20:00Z"),
+		timed("s2", [3]float64{5.65, 0, 3.65}, "2024-01-15T22:50:00Z", "2024-01-15T22:55:00Z"))
+	report = e.EvaluateTrajectory(late)
+	if got := statusOf(t, report, "window"); got.Status != models.ConstraintStatusSatisfied {
+		t.Errorf("window = %s (%s), want satisfied", got.Status, got.Detail)
+	}
+	if got := statusOf(t, report, "at-safe"); got.Status != models.ConstraintStatusViolated {
+		t.Errorf("at-safe = %s (%s), want violated", got.Status, got.Detail)
+	}
+
+	clockOnly := trajectory("clock", timed("s1", [3]float64{3, 0, 1}, "22:10", "22:20"))
+	report = e.EvaluateTrajectory(clockOnly)
+	if got := statusOf(t, report, "window"); got.Status != models.ConstraintStatusUnevaluated {
+		t.Errorf("window with clock times = %s, want unevaluated", got.Status)
+	}
+	if got := statusOf(t, report, "at-safe"); got.Status != models.ConstraintStatusNotApplicable {
+		t.Errorf("at-safe for a route avoiding the safe = %s, want not_applicable", got.Status)
+	}
+}
+
+func TestEvaluateSuspect(t *testing.T) {
+	height := constraint(t, "height", models.ConstraintTypeHeightRange, &models.HeightRangeParams{MinCm: 175, MaxCm: 190})
+	custom := models.Constraint{ID: "note", Type: models.ConstraintTypeCustom, Confidence: 0.5}
+	floor := constraint(t, "floor", models.ConstraintTypePassableArea, &models.PassableAreaParams{
+		Polygon: [][2]float64{{0, 0}, {6, 0}, {6, 4}, {0, 4}},
+	})
+	e := NewEvaluator(room(t, height, custom, floor), nil)
+
+	tall := &models.SuspectAttributes{HeightRangeCm: &models.RangeAttribute{Min: 180, Max: 188, Confidence: 0.7}}
+	report := e.EvaluateSuspect(tall)
+	if report.SubjectType != SubjectSuspect || report.Satisfied != 1 || report.Violated != 0 || report.Score != 1 {
+		t.Errorf("tall suspect report = %+v", report)
+	}
+	if got := statusOf(t, report, "note"); got.Status != models.ConstraintStatusUnevaluated {
+		t.Errorf("custom = %s, want unevaluated", got.Status)
+	}
+	if got := statusOf(t, report, "floor"); got.Status != models.ConstraintStatusNotApplicable {
+		t.Errorf("passable area = %s, want not_applicable", got.Status)
+	}
+
+	short := &models.SuspectAttributes{HeightRangeCm: &models.RangeAttribute{Min: 155, Max: 165, Confidence: 0.7}}
+	report = e.EvaluateSuspect(short)
+	if report.Violated != 1 || report.Score != 0 {
+		t.Errorf("short suspect report = %+v", report)
+	}
+
+	if got := statusOf(t, e.EvaluateSuspect(nil), "height"); got.Status != models.ConstraintStatusUnevaluated {
+		t.Errorf("no profile = %s, want unevaluated", got.Status)
+	}
+}
+
+func TestApply(t *testing.T) {
+	floor := constraint(t, "floor", models.ConstraintTypePassableArea, &models.PassableAreaParams{
+		Polygon: [][2]float64{{0, 0}, {6, 0}, {6, 4}, {0, 4}},
+	})
+	e := NewEvaluator(room(t, floor), nil)
+	trajectories := []models.Trajectory{
+		trajectory("ok", segment("s1", [3]float64{1, 0, 1}, [3]float64{5, 0, 1})),
+		trajectory("out", segment("s1", [3]float64{1, 0, 1}, [3]float64{9, 0, 1})),
+	}
+
+	if got := e.Apply(trajectories); got != 1 {
+		t.Errorf("Apply() = %d violating, want 1", got)
+	}
+	for _, traj := range trajectories {
+		if traj.ConstraintReport == nil || traj.ConstraintReport.SubjectID != traj.ID {
+			t.Errorf("trajectory %s report = %+v", traj.ID, traj.ConstraintReport)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package constraints
+
+import (
+	"math"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// segmentRoute returns a segment's stated route on the floor plane
+func segmentRoute(seg models.TrajectorySegment) [][2]float64 {
+	route := [][2]float64{{seg.FromPosition[0], seg.FromPosition[2]}}
+	for _, w := range seg.Waypoints {
+		route = append(route, [2]float64{w[0], w[2]})
+	}
+	return append(route, [2]float64{seg.ToPosition[0], seg.ToPosition[2]})
+}
+
+// sampleRoute returns points along a route no more than sampleStep apart,
+// including every route point
+func sampleRoute(route [][2]float64) [][2]float64 {
+	out := [][2]float64{route[0]}
+	for i := 1; i < len(route); i++ {
+		a, b := route[i-1], route[i]
+		steps := int(math.Ceil(math.Hypot(b[0]-a[0], b[1]-a[1]) / sampleStep))
+		for s := 1; s <= steps; s++ {
+			t := float64(s) / float64(steps)
+			out = append(out, [2]float64{a[0] + t*(b[0]-a[0]), a[1] + t*(b[1]-a[1])})
+		}
+	}
+	return out
+}
+
+// passesThrough reports whether any point along the trajectory comes within
+// doorReach of the box's footprint
+func passesThrough(t models.Trajectory, bb models.BoundingBox) bool {
+	for _, seg := range t.Segments {
+		for _, p := range sampleRoute(segmentRoute(seg)) {
+			if nearBox(p, bb, doorReach) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// swingArea returns the floor rectangle a door sweeps when opening towards
+// the room centre (inward) or away from it (outward)
+func swingArea(door models.BoundingBox, dir models.DoorDirection, center [2]float64) [4]float64 {
+	minX, maxX := door.Min[0], door.Max[0]
+	minZ, maxZ := door.Min[2], door.Max[2]
+	mid := [2]float64{(minX + maxX) / 2, (minZ + maxZ) / 2}
+
+	// The door spans its longer horizontal axis and swings across the other
+	if maxX-minX >= maxZ-minZ {
+		width := maxX - minX
+		sign := math.Copysign(1, center[1]-mid[1])
+		if dir == models.DoorDirectionOutward {
+			sign = -sign
+		}
+		if sign > 0 {
+			return [4]float64{minX, maxZ, maxX, maxZ + width}
+		}
+		return [4]float64{minX, minZ - width, maxX, minZ}
+	}
+	width := maxZ - minZ
+	sign := math.Copysign(1, center[0]-mid[0])
+	if dir == models.DoorDirectionOutward {
+		sign = -sign
+	}
+	if sign > 0 {
+		return [4]float64{maxX, minZ, maxX + width, maxZ}
+	}
+	return [4]float64{minX - width, minZ, minX, maxZ}
+}
+
+// blocking returns the first object standing in the rectangle [minX, minZ, maxX, maxZ]
+func (e *Evaluator) blocking(area [4]float64, doorID string) (string, bool) {
+	b := e.scene.Bounds
+	sceneArea := (b.Max[0] - b.Min[0]) * (b.Max[2] - b.Min[2])
+	for _, obj := range e.scene.Objects {
+		if obj.ID == doorID || obj.State == models.ObjectStateRemoved || isZeroBox(obj.BBox) {
+			continue
+		}
+		switch obj.Type {
+		case models.ObjectTypeFurniture, models.ObjectTypeVehicle, models.ObjectTypeOther:
+		default:
+			continue
+		}
+		bb := obj.BBox
+		if bb.Max[1]-math.Max(bb.Min[1], 0) < minBlockingHeight {
+			continue
+		}
+		size := (bb.Max[0] - bb.Min[0]) * (bb.Max[2] - bb.Min[2])
+		if sceneArea > 0 && size > maxObstacleShare*sceneArea {
+			continue
+		}
+		// Touching edges don't block; the overlap must be real
+		if bb.Min[0] < area[2]-0.01 && bb.Max[0] > area[0]+0.01 &&
+			bb.Min[2] < area[3]-0.01 && bb.Max[2] > area[1]+0.01 {
+			return obj.ID, true
+		}
+	}
+	return "", false
+}
+
+// roomCenter is the centre of the scene's floor
+func (e *Evaluator) roomCenter() [2]float64 {
+	b := e.scene.Bounds
+	return [2]float64{(b.Min[0] + b.Max[0]) / 2, (b.Min[2] + b.Max[2]) / 2}
+}
+
+func nearBox(p [2]float64, bb models.BoundingBox, margin float64) bool {
+	return p[0] >= bb.Min[0]-margin && p[0] <= bb.Max[0]+margin &&
+		p[1] >= bb.Min[2]-margin && p[1] <= bb.Max[2]+margin
+}
+
+func isZeroBox(bb models.BoundingBox) bool {
+	return bb.Min == [3]float64{} && bb.Max == [3]float64{}
+}
+
+func pointInPolygon(p [2]float64, poly [][2]float64) bool {
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a[1] > p[1]) != (b[1] > p[1]) &&
+			p[0] < (b[0]-a[0])*(p[1]-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// polygonDistance returns the distance from p to the polygon's boundary
+func polygonDistance(p [2]float64, poly [][2]float64) float64 {
+	d := math.Inf(1)
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		d = math.Min(d, segmentDistance(p, poly[j], poly[i]))
+	}
+	return d
+}
+
+// segmentDistance returns the distance from p to the segment ab
+func segmentDistance(p, a, b [2]float64) float64 {
+	dx, dz := b[0]-a[0], b[1]-a[1]
+	l2 := dx*dx + dz*dz
+	t := 0.0
+	if l2 > 0 {
+		t = math.Max(0, math.Min(1, ((p[0]-a[0])*dx+(p[1]-a[1])*dz)/l2))
+	}
+	return math.Hypot(p[0]-(a[0]+t*dx), p[1]-(a[1]+t*dz))
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -26,86 +27,504 @@ func NewRepository(db *DB) *Repository {
 // CASES
 // ============================================
 
-// CreateCase creates a new case
+// caseColumns are the columns scanned by scanCase
+const caseColumns = `id, title, COALESCE(description, ''), status, created_by, created_at, updated_at, deleted_at`
+
+func scanCase(row pgx.Row) (*models.Case, error) {
+	var c models.Case
+	err := row.Scan(&c.ID, &c.Title, &c.Description, &c.Status, &c.CreatedBy, &c.CreatedAt, &c.UpdatedAt, &c.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// CreateCase creates a new case. Its creator, if any, becomes its lead.
 func (r *Repository) CreateCase(ctx context.Context, c *models.Case) error {
+	if c.Status == "" {
+		c.Status = models.CaseStatusOpen
+	}
+	if c.UpdatedAt.IsZero() {
+		c.UpdatedAt = c.CreatedAt
+	}
+
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
-		INSERT INTO cases (id, title, description, created_by, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO cases (id, title, description, status, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
-	_, err := r.db.Pool.Exec(ctx, query, c.ID, c.Title, c.Description, c.CreatedBy, c.CreatedAt)
-	return err
+	if _, err := tx.Exec(ctx, query, c.ID, c.Title, c.Description, c.Status, c.CreatedBy, c.CreatedAt, c.UpdatedAt); err != nil {
+		return err
+	}
+	if c.CreatedBy != nil {
+		if err := insertCaseLead(ctx, tx, c.ID, *c.CreatedBy); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
 }
 
-// GetCase retrieves a case by ID
+// GetCase retrieves a case by ID. Deleted cases are not returned.
 func (r *Repository) GetCase(ctx context.Context, id uuid.UUID) (*models.Case, error) {
+	c, err := scanCase(r.db.Pool.QueryRow(ctx, `SELECT `+caseColumns+` FROM cases WHERE id = $1 AND deleted_at IS NULL`, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// GetCaseWithDeleted retrieves a case by ID whether or not it is deleted
+func (r *Repository) GetCaseWithDeleted(ctx context.Context, id uuid.UUID) (*models.Case, error) {
+	c, err := scanCase(r.db.Pool.QueryRow(ctx, `SELECT `+caseColumns+` FROM cases WHERE id = $1`, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// ListCases returns cases that aren't deleted with pagination, optionally
+// only those with the given status and those the given user is a member of
+func (r *Repository) ListCases(ctx context.Context, limit int, cursor *time.Time, status *models.CaseStatus, memberID *uuid.UUID) ([]*models.Case, error) {
 	query := `
-		SELECT id, title, description, created_by, created_at
-		FROM cases WHERE id = $1
+		SELECT ` + caseColumns + `
+		FROM cases
+		WHERE deleted_at IS NULL
+			AND ($1::timestamptz IS NULL OR created_at < $1)
+			AND ($2::case_status IS NULL OR status = $2)
+			AND ($4::uuid IS NULL OR EXISTS (
+				SELECT 1 FROM case_members m WHERE m.case_id = cases.id AND m.user_id = $4
+			))
+		ORDER BY created_at DESC LIMIT $3
 	`
-	var c models.Case
-	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
-		&c.ID, &c.Title, &c.Description, &c.CreatedBy, &c.CreatedAt,
-	)
+	rows, err := r.db.Pool.Query(ctx, query, cursor, status, limit, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cases []*models.Case
+	for rows.Next() {
+		c, err := scanCase(rows)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// UpdateCase saves a case's title, description and status. It returns false
+// if the case doesn't exist or is deleted.
+func (r *Repository) UpdateCase(ctx context.Context, c *models.Case) (bool, error) {
+	query := `
+		UPDATE cases SET title = $2, description = $3, status = $4
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING updated_at
+	`
+	err := r.db.Pool.QueryRow(ctx, query, c.ID, c.Title, c.Description, c.Status).Scan(&c.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SoftDeleteCase marks a case deleted, returning the time it was deleted. A
+// case that is already deleted keeps its original time. It returns nil if the
+// case doesn't exist.
+func (r *Repository) SoftDeleteCase(ctx context.Context, id uuid.UUID) (*time.Time, error) {
+	query := `
+		UPDATE cases SET deleted_at = COALESCE(deleted_at, now())
+		WHERE id = $1
+		RETURNING deleted_at
+	`
+	var deletedAt time.Time
+	err := r.db.Pool.QueryRow(ctx, query, id).Scan(&deletedAt)
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	return &c, nil
+	return &deletedAt, nil
 }
 
-// ListCases returns all cases with pagination
-func (r *Repository) ListCases(ctx context.Context, limit int, cursor *time.Time) ([]*models.Case, error) {
-	var query string
-	var args []interface{}
+// RestoreCase clears a deleted case's deletion. It returns false if the
+// case doesn't exist or isn't deleted.
+func (r *Repository) RestoreCase(ctx context.Context, id uuid.UUID) (bool, error) {
+	tag, err := r.db.Pool.Exec(ctx, `UPDATE cases SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
 
-	if cursor != nil {
-		query = `
-			SELECT id, title, description, created_by, created_at
-			FROM cases WHERE created_at < $1
-			ORDER BY created_at DESC LIMIT $2
-		`
-		args = []interface{}{cursor, limit}
-	} else {
-		query = `
-			SELECT id, title, description, created_by, created_at
-			FROM cases ORDER BY created_at DESC LIMIT $1
-		`
-		args = []interface{}{limit}
+// ListCasesDeletedBefore returns the IDs of cases deleted before the given
+// time, oldest first
+func (r *Repository) ListCasesDeletedBefore(ctx context.Context, before time.Time, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT id FROM cases
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1
+		ORDER BY deleted_at LIMIT $2
+	`
+	rows, err := r.db.Pool.Query(ctx, query, before, limit)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	rows, err := r.db.Pool.Query(ctx, query, args...)
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// StoredObject is a file in storage that belongs to a case
+type StoredObject struct {
+	Bucket string
+	Key    string
+}
+
+// GetCaseStorageObjects lists the stored files a case refers to: its assets
+// and their thumbnails, the suspect portrait, and files named in job outputs
+// such as exported reports
+func (r *Repository) GetCaseStorageObjects(ctx context.Context, caseID uuid.UUID) ([]StoredObject, error) {
+	query := `
+		SELECT 'case-assets', storage_key FROM assets WHERE case_id = $1
+		UNION
+		SELECT 'case-assets', metadata->>'thumbnail_key' FROM assets
+		WHERE case_id = $1 AND metadata->>'thumbnail_key' <> ''
+		UNION
+		SELECT 'case-assets', portrait_asset_key FROM suspect_profiles
+		WHERE case_id = $1 AND portrait_asset_key <> ''
+		UNION
+		SELECT CASE WHEN o.key = 'report_asset_key' THEN 'assets' ELSE 'case-assets' END, o.value #>> '{}'
+		FROM jobs j
+		CROSS JOIN LATERAL jsonb_each(CASE jsonb_typeof(j.output) WHEN 'object' THEN j.output ELSE '{}'::jsonb END) AS o
+		WHERE j.case_id = $1 AND o.key LIKE '%\_key' AND jsonb_typeof(o.value) = 'string'
+			AND o.value #>> '{}' LIKE 'cases/' || $1::text || '/%'
+	`
+	rows, err := r.db.Pool.Query(ctx, query, caseID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var cases []*models.Case
+	var objects []StoredObject
 	for rows.Next() {
-		var c models.Case
-		if err := rows.Scan(&c.ID, &c.Title, &c.Description, &c.CreatedBy, &c.CreatedAt); err != nil {
+		var o StoredObject
+		if err := rows.Scan(&o.Bucket, &o.Key); err != nil {
 			return nil, err
 		}
-		cases = append(cases, &c)
+		objects = append(objects, o)
 	}
-	return cases, nil
+	return objects, nil
+}
+
+// PurgeCase permanently deletes a case and, through cascades, everything
+// recorded under it. Stored files must be removed first.
+func (r *Repository) PurgeCase(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.Pool.Exec(ctx, `DELETE FROM cases WHERE id = $1`, id)
+	return err
+}
+
+// ErrCaseClosed is returned when writing to a case that is closed, archived
+// or deleted
+var ErrCaseClosed = errors.New("case does not accept changes")
+
+// lockOpenCase holds the case row until tx ends, so it can't be closed or
+// deleted meanwhile, and returns ErrCaseClosed unless it accepts changes
+func lockOpenCase(ctx context.Context, tx pgx.Tx, caseID uuid.UUID) error {
+	var status models.CaseStatus
+	var deletedAt *time.Time
+	err := tx.QueryRow(ctx, `SELECT status, deleted_at FROM cases WHERE id = $1 FOR SHARE`, caseID).Scan(&status, &deletedAt)
+	if err == pgx.ErrNoRows {
+		return ErrCaseClosed
+	}
+	if err != nil {
+		return err
+	}
+	c := models.Case{Status: status, DeletedAt: deletedAt}
+	if !c.AcceptsChanges() {
+		return ErrCaseClosed
+	}
+	return nil
+}
+
+// ============================================
+// CASE MEMBERS
+// ============================================
+
+// ErrLastLead is returned when a change would leave a case without a lead
+var ErrLastLead = errors.New("a case must keep at least one lead")
+
+// insertCaseLead makes a user the lead of a case they created or imported
+func insertCaseLead(ctx context.Context, tx pgx.Tx, caseID, userID uuid.UUID) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO case_members (case_id, user_id, role, added_by)
+		VALUES ($1, $2, 'lead', $2)
+	`, caseID, userID)
+	return err
+}
+
+// LeadOrphanCases makes a user the lead of every case without members, such
+// as cases created before authentication was turned on, and returns how many
+// cases that was. Deleted cases are included so that they can be restored.
+func (r *Repository) LeadOrphanCases(ctx context.Context, userID uuid.UUID) (int64, error) {
+	query := `
+		INSERT INTO case_members (case_id, user_id, role, added_by)
+		SELECT c.id, $1, 'lead', $1 FROM cases c
+		WHERE NOT EXISTS (SELECT 1 FROM case_members m WHERE m.case_id = c.id)
+		ON CONFLICT DO NOTHING
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// GetCaseRole returns a user's role on a case, or "" if they aren't a member.
+// Deleted cases keep their members so that leads can restore them.
+func (r *Repository) GetCaseRole(ctx context.Context, caseID, userID uuid.UUID) (models.CaseRole, error) {
+	var role models.CaseRole
+	err := r.db.Pool.QueryRow(ctx, `SELECT role FROM case_members WHERE case_id = $1 AND user_id = $2`, caseID, userID).Scan(&role)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
+
+// ListCaseMembers returns a case's members, leads first
+func (r *Repository) ListCaseMembers(ctx context.Context, caseID uuid.UUID) ([]*models.CaseMember, error) {
+	query := `
+		SELECT case_id, user_id, role, added_by, created_at, updated_at
+		FROM case_members WHERE case_id = $1
+		ORDER BY role, created_at
+	`
+	rows, err := r.db.Pool.Query(ctx, query, caseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []*models.CaseMember
+	for rows.Next() {
+		var m models.CaseMember
+		if err := rows.Scan(&m.CaseID, &m.UserID, &m.Role, &m.AddedBy, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, &m)
+	}
+	return members, rows.Err()
+}
+
+// SetCaseMember adds a member or changes their role. Demoting the last lead
+// returns ErrLastLead.
+func (r *Repository) SetCaseMember(ctx context.Context, m *models.CaseMember) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if m.Role != models.CaseRoleLead {
+		if err := checkOtherLead(ctx, tx, m.CaseID, m.UserID); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		INSERT INTO case_members (case_id, user_id, role, added_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (case_id, user_id) DO UPDATE SET role = EXCLUDED.role
+		RETURNING added_by, created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query, m.CaseID, m.UserID, m.Role, m.AddedBy, m.CreatedAt, m.UpdatedAt).Scan(&m.AddedBy, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// RemoveCaseMember removes a user from a case, returning false if they
+// weren't a member. Removing the last lead returns ErrLastLead.
+func (r *Repository) RemoveCaseMember(ctx context.Context, caseID, userID uuid.UUID) (bool, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkOtherLead(ctx, tx, caseID, userID); err != nil {
+		return false, err
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM case_members WHERE case_id = $1 AND user_id = $2`, caseID, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, tx.Commit(ctx)
+}
+
+// checkOtherLead returns ErrLastLead if userID is the case's only lead. The
+// case's leads stay locked until the transaction ends, so concurrent changes
+// can't both remove a lead.
+func checkOtherLead(ctx context.Context, tx pgx.Tx, caseID, userID uuid.UUID) error {
+	rows, err := tx.Query(ctx, `SELECT user_id FROM case_members WHERE case_id = $1 AND role = 'lead' FOR UPDATE`, caseID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	isLead, others := false, 0
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		if id == userID {
+			isLead = true
+		} else {
+			others++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if isLead && others == 0 {
+		return ErrLastLead
+	}
+	return nil
+}
+
+// ============================================
+// AUDIT LOG
+// ============================================
+
+const insertAuditEntry = `
+	INSERT INTO audit_log (id, case_id, actor_type, actor_id, request_id, job_id, method, route,
+		action, target_type, target_id, outcome, status, detail, created_at)
+	VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, NULLIF($7, ''), NULLIF($8, ''),
+		$9, NULLIF($10, ''), NULLIF($11, ''), $12, NULLIF($13, 0), NULLIF($14, ''), $15)
+`
+
+func auditEntryArgs(e *models.AuditEntry) []interface{} {
+	return []interface{}{
+		e.ID, e.CaseID, e.ActorType, e.ActorID, e.RequestID, e.JobID, e.Method, e.Route,
+		e.Action, e.TargetType, e.TargetID, e.Outcome, e.Status, e.Detail, e.CreatedAt,
+	}
+}
+
+// CreateAuditEntry appends an entry to the audit log
+func (r *Repository) CreateAuditEntry(ctx context.Context, e *models.AuditEntry) error {
+	_, err := r.db.Pool.Exec(ctx, insertAuditEntry, auditEntryArgs(e)...)
+	return err
+}
+
+const selectAuditEntries = `
+	SELECT id, case_id, actor_type, actor_id, coalesce(request_id, ''), job_id, coalesce(method, ''),
+		coalesce(route, ''), action, coalesce(target_type, ''), coalesce(target_id, ''), outcome,
+		coalesce(status, 0), coalesce(detail, ''), created_at
+	FROM audit_log
+`
+
+// ListAuditEntries returns a case's audit log, newest first
+func (r *Repository) ListAuditEntries(ctx context.Context, q *models.AuditQuery) ([]*models.AuditEntry, error) {
+	query := selectAuditEntries + `
+		WHERE case_id = $1
+			AND ($2::uuid IS NULL OR actor_id = $2)
+			AND ($3 = '' OR action = $3)
+			AND ($4 = '' OR outcome::text = $4)
+			AND ($5::timestamptz IS NULL OR created_at >= $5)
+			AND ($6::timestamptz IS NULL OR created_at < $6)
+			AND ($7::timestamptz IS NULL OR created_at < $7)
+		ORDER BY created_at DESC LIMIT $8
+	`
+	rows, err := r.db.Pool.Query(ctx, query, q.CaseID, q.ActorID, q.Action, string(q.Outcome), q.From, q.To, q.Cursor, q.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanAuditEntries(rows)
+}
+
+// GetAuditTrail returns a case's whole audit log up to a time, oldest first
+func (r *Repository) GetAuditTrail(ctx context.Context, caseID uuid.UUID, before time.Time) ([]*models.AuditEntry, error) {
+	query := selectAuditEntries + `
+		WHERE case_id = $1 AND created_at < $2
+		ORDER BY created_at
+	`
+	rows, err := r.db.Pool.Query(ctx, query, caseID, before)
+	if err != nil {
+		return nil, err
+	}
+	return scanAuditEntries(rows)
+}
+
+func scanAuditEntries(rows pgx.Rows) ([]*models.AuditEntry, error) {
+	defer rows.Close()
+
+	entries := []*models.AuditEntry{}
+	for rows.Next() {
+		var e models.AuditEntry
+		if err := rows.Scan(
+			&e.ID, &e.CaseID, &e.ActorType, &e.ActorID, &e.RequestID, &e.JobID, &e.Method,
+			&e.Route, &e.Action, &e.TargetType, &e.TargetID, &e.Outcome,
+			&e.Status, &e.Detail, &e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &e)
+	}
+	return entries, rows.Err()
 }
 
 // ============================================
 // COMMITS
 // ============================================
 
-// CreateCommit creates a new commit
+// CreateCommit creates a new commit. It returns ErrCaseClosed if the case is
+// closed, archived or deleted.
 func (r *Repository) CreateCommit(ctx context.Context, c *models.Commit) error {
+	return r.CreateCommitWithAudit(ctx, c, nil)
+}
+
+// CreateCommitWithAudit creates a commit and its audit log entry together,
+// so that no commit goes unrecorded. It returns ErrCaseClosed if the case is
+// closed, archived or deleted.
+func (r *Repository) CreateCommitWithAudit(ctx context.Context, c *models.Commit, e *models.AuditEntry) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockOpenCase(ctx, tx, c.CaseID); err != nil {
+		return err
+	}
 	query := `
 		INSERT INTO commits (id, case_id, parent_commit_id, branch_id, type, summary, payload, created_by, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
-	_, err := r.db.Pool.Exec(ctx, query,
+	if _, err := tx.Exec(ctx, query,
 		c.ID, c.CaseID, c.ParentCommitID, c.BranchID, c.Type, c.Summary, c.Payload, c.CreatedBy, c.CreatedAt,
-	)
-	return err
+	); err != nil {
+		return err
+	}
+	if e != nil {
+		if _, err := tx.Exec(ctx, insertAuditEntry, auditEntryArgs(e)...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
 }
 
 // GetCommit retrieves a commit by ID
@@ -165,6 +584,49 @@ func (r *Repository) GetCommitsByCase(ctx context.Context, caseID uuid.UUID, lim
 	return commits, nil
 }
 
+// CommitCursor marks a position in a case's commits, newest first. Commits
+// can share a timestamp, so the ID breaks ties.
+type CommitCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// GetCommitsPage returns up to limit of a case's commits, newest first,
+// after the cursor if one is given. Unlike GetCommitsByCase, paging never
+// skips commits created at the same time.
+func (r *Repository) GetCommitsPage(ctx context.Context, caseID uuid.UUID, limit int, after *CommitCursor) ([]*models.Commit, error) {
+	query := `
+		SELECT id, case_id, parent_commit_id, branch_id, type, summary, payload, created_by, created_at
+		FROM commits WHERE case_id = $1
+		ORDER BY created_at DESC, id DESC LIMIT $2
+	`
+	args := []interface{}{caseID, limit}
+	if after != nil {
+		query = `
+			SELECT id, case_id, parent_commit_id, branch_id, type, summary, payload, created_by, created_at
+			FROM commits WHERE case_id = $1 AND (created_at, id) < ($3, $4)
+			ORDER BY created_at DESC, id DESC LIMIT $2
+		`
+		args = append(args, after.CreatedAt, after.ID)
+	}
+
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var commits []*models.Commit
+	for rows.Next() {
+		var c models.Commit
+		if err := rows.Scan(&c.ID, &c.CaseID, &c.ParentCommitID, &c.BranchID, &c.Type, &c.Summary, &c.Payload, &c.CreatedBy, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		commits = append(commits, &c)
+	}
+	return commits, rows.Err()
+}
+
 // GetLatestCommit returns the most recent commit for a case
 func (r *Repository) GetLatestCommit(ctx context.Context, caseID uuid.UUID) (*models.Commit, error) {
 	query := `
@@ -242,37 +704,202 @@ func (r *Repository) GetBranchesByCase(ctx context.Context, caseID uuid.UUID) ([
 	return branches, nil
 }
 
+// GetBranchHead returns the latest commit made on a branch, or nil if the
+// branch has none yet
+func (r *Repository) GetBranchHead(ctx context.Context, branchID uuid.UUID) (*models.Commit, error) {
+	query := `
+		SELECT id, case_id, parent_commit_id, branch_id, type, summary, payload, created_by, created_at
+		FROM commits WHERE branch_id = $1
+		ORDER BY created_at DESC LIMIT 1
+	`
+	var c models.Commit
+	err := r.db.Pool.QueryRow(ctx, query, branchID).Scan(
+		&c.ID, &c.CaseID, &c.ParentCommitID, &c.BranchID, &c.Type, &c.Summary, &c.Payload, &c.CreatedBy, &c.CreatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
 // ============================================
 // JOBS
 // ============================================
 
-// CreateJob creates a new job
-func (r *Repository) CreateJob(ctx context.Context, j *models.Job) error {
-	query := `
-		INSERT INTO jobs (id, case_id, type, status, progress, input, output, error, idempotency_key, retry_count, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-	`
+const insertJob = `
+	INSERT INTO jobs (id, case_id, type, status, progress, input, output, error, idempotency_key, retry_count, created_by, estimated_cost_usd, cost_usd, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+`
+
+func jobArgs(j *models.Job) []interface{} {
 	// Use nil for empty idempotency key to allow multiple jobs without keys
 	var idempotencyKey interface{}
 	if j.IdempotencyKey != "" {
 		idempotencyKey = j.IdempotencyKey
 	}
-	_, err := r.db.Pool.Exec(ctx, query,
-		j.ID, j.CaseID, j.Type, j.Status, j.Progress, j.Input, j.Output, j.Error, idempotencyKey, j.RetryCount, j.CreatedAt, j.UpdatedAt,
-	)
+	return []interface{}{
+		j.ID, j.CaseID, j.Type, j.Status, j.Progress, j.Input, j.Output, j.Error, idempotencyKey, j.RetryCount, j.CreatedBy, j.EstimatedCost, j.ActualCost, j.CreatedAt, j.UpdatedAt,
+	}
+}
+
+// CreateJob creates a new job
+func (r *Repository) CreateJob(ctx context.Context, j *models.Job) error {
+	_, err := r.db.Pool.Exec(ctx, insertJob, jobArgs(j)...)
+	return err
+}
+
+// jobBudgetLock is the advisory lock that serialises budget checks
+const jobBudgetLock = 0x5348424a // "SHBJ"
+
+// CreateJobWithinBudget creates a job unless its estimated cost would take
+// the case's or this month's spending past a budget, returning a
+// *models.BudgetExceededError. Budget checks run one at a time, so jobs
+// created together can't overspend between them.
+func (r *Repository) CreateJobWithinBudget(ctx context.Context, j *models.Job, b models.Budgets) error {
+	if b.CaseUSD <= 0 && b.OrganisationMonthlyUSD <= 0 {
+		return r.CreateJob(ctx, j)
+	}
+
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, jobBudgetLock); err != nil {
+		return err
+	}
+	var caseSpent, orgSpent float64
+	query := `
+		SELECT
+			coalesce(sum(` + jobSpend + `) FILTER (WHERE case_id = $1), 0),
+			coalesce(sum(` + jobSpend + `) FILTER (WHERE created_at >= $2), 0)
+				+ (SELECT coalesce(sum(cost_usd), 0) FROM usage_charges WHERE created_at >= $2)
+		FROM jobs WHERE case_id = $1 OR created_at >= $2
+	`
+	if err := tx.QueryRow(ctx, query, j.CaseID, models.BudgetPeriodStart(time.Now())).Scan(&caseSpent, &orgSpent); err != nil {
+		return err
+	}
+	if err := b.Check(caseSpent, orgSpent, j.EstimatedCost); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, insertJob, jobArgs(j)...); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// jobSpend is what a job counts toward spending: its actual cost once
+// known, else its estimate unless it failed
+const jobSpend = `coalesce(cost_usd, CASE WHEN status = 'failed' THEN 0 ELSE estimated_cost_usd END)`
+
+// CreateUsageChargeWithinBudget records a usage charge unless it would take
+// this month's spending past the organisation budget, returning a
+// *models.BudgetExceededError. Usage charges don't belong to a case, so the
+// case budget doesn't apply.
+func (r *Repository) CreateUsageChargeWithinBudget(ctx context.Context, c *models.UsageCharge, b models.Budgets) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if b.OrganisationMonthlyUSD > 0 {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, jobBudgetLock); err != nil {
+			return err
+		}
+		var orgSpent float64
+		query := `
+			SELECT coalesce((SELECT sum(` + jobSpend + `) FROM jobs WHERE created_at >= $1), 0)
+				+ (SELECT coalesce(sum(cost_usd), 0) FROM usage_charges WHERE created_at >= $1)
+		`
+		if err := tx.QueryRow(ctx, query, models.BudgetPeriodStart(time.Now())).Scan(&orgSpent); err != nil {
+			return err
+		}
+		org := models.Budgets{OrganisationMonthlyUSD: b.OrganisationMonthlyUSD}
+		if err := org.Check(0, orgSpent, c.CostUSD); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		INSERT INTO usage_charges (id, type, source, cost_usd, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	if _, err := tx.Exec(ctx, query, c.ID, c.Type, c.Source, c.CostUSD, c.CreatedBy, c.CreatedAt); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// DeleteUsageCharge removes a usage charge for a call that didn't go
+// through, so that it doesn't count toward spending
+func (r *Repository) DeleteUsageCharge(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.Pool.Exec(ctx, `DELETE FROM usage_charges WHERE id = $1`, id)
+	return err
+}
+
+// GetCaseSpend returns what has been spent on a case's jobs
+func (r *Repository) GetCaseSpend(ctx context.Context, caseID uuid.UUID) (*models.Spend, error) {
+	query := `SELECT type, coalesce(sum(` + jobSpend + `), 0) FROM jobs WHERE case_id = $1 GROUP BY type`
+	return r.getSpend(ctx, query, caseID)
+}
+
+// GetSpendSince returns what has been spent on jobs created and usage
+// charged since a time, across all cases
+func (r *Repository) GetSpendSince(ctx context.Context, since time.Time) (*models.Spend, error) {
+	query := `
+		SELECT type, coalesce(sum(usd), 0) FROM (
+			SELECT type, ` + jobSpend + ` AS usd FROM jobs WHERE created_at >= $1
+			UNION ALL
+			SELECT type, cost_usd FROM usage_charges WHERE created_at >= $1
+		) spend GROUP BY type
+	`
+	return r.getSpend(ctx, query, since)
+}
+
+func (r *Repository) getSpend(ctx context.Context, query string, arg interface{}) (*models.Spend, error) {
+	rows, err := r.db.Pool.Query(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	spend := &models.Spend{ByJobType: map[models.JobType]float64{}}
+	for rows.Next() {
+		var t models.JobType
+		var usd float64
+		if err := rows.Scan(&t, &usd); err != nil {
+			return nil, err
+		}
+		if usd > 0 {
+			spend.ByJobType[t] = usd
+			spend.TotalUSD += usd
+		}
+	}
+	return spend, rows.Err()
+}
+
+// UpdateJobCost records what a job actually cost, in USD
+func (r *Repository) UpdateJobCost(ctx context.Context, id uuid.UUID, costUSD float64) error {
+	_, err := r.db.Pool.Exec(ctx, `UPDATE jobs SET cost_usd = $2 WHERE id = $1`, id, costUSD)
 	return err
 }
 
 // GetJob retrieves a job by ID
 func (r *Repository) GetJob(ctx context.Context, id uuid.UUID) (*models.Job, error) {
 	query := `
-		SELECT id, case_id, type, status, progress, input, output, error, idempotency_key, retry_count, created_at, updated_at
+		SELECT id, case_id, type, status, progress, input, output, error, idempotency_key, retry_count, created_by, estimated_cost_usd, cost_usd, created_at, updated_at
 		FROM jobs WHERE id = $1
 	`
 	var j models.Job
 	var idempotencyKey *string
 	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
-		&j.ID, &j.CaseID, &j.Type, &j.Status, &j.Progress, &j.Input, &j.Output, &j.Error, &idempotencyKey, &j.RetryCount, &j.CreatedAt, &j.UpdatedAt,
+		&j.ID, &j.CaseID, &j.Type, &j.Status, &j.Progress, &j.Input, &j.Output, &j.Error, &idempotencyKey, &j.RetryCount, &j.CreatedBy, &j.EstimatedCost, &j.ActualCost, &j.CreatedAt, &j.UpdatedAt,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -289,12 +916,12 @@ func (r *Repository) GetJob(ctx context.Context, id uuid.UUID) (*models.Job, err
 // GetJobByIdempotencyKey retrieves a job by idempotency key
 func (r *Repository) GetJobByIdempotencyKey(ctx context.Context, key string) (*models.Job, error) {
 	query := `
-		SELECT id, case_id, type, status, progress, input, output, error, idempotency_key, retry_count, created_at, updated_at
+		SELECT id, case_id, type, status, progress, input, output, error, idempotency_key, retry_count, created_by, estimated_cost_usd, cost_usd, created_at, updated_at
 		FROM jobs WHERE idempotency_key = $1
 	`
 	var j models.Job
 	err := r.db.Pool.QueryRow(ctx, query, key).Scan(
-		&j.ID, &j.CaseID, &j.Type, &j.Status, &j.Progress, &j.Input, &j.Output, &j.Error, &j.IdempotencyKey, &j.RetryCount, &j.CreatedAt, &j.UpdatedAt,
+		&j.ID, &j.CaseID, &j.Type, &j.Status, &j.Progress, &j.Input, &j.Output, &j.Error, &j.IdempotencyKey, &j.RetryCount, &j.CreatedBy, &j.EstimatedCost, &j.ActualCost, &j.CreatedAt, &j.UpdatedAt,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -340,7 +967,7 @@ func (r *Repository) UpdateJobHeartbeat(ctx context.Context, id uuid.UUID) error
 // GetQueuedJobs returns jobs in queued status for a specific type
 func (r *Repository) GetQueuedJobs(ctx context.Context, jobType models.JobType, limit int) ([]*models.Job, error) {
 	query := `
-		SELECT id, case_id, type, status, progress, input, output, error, COALESCE(idempotency_key, ''), retry_count, created_at, updated_at
+		SELECT id, case_id, type, status, progress, input, output, error, COALESCE(idempotency_key, ''), retry_count, created_by, estimated_cost_usd, cost_usd, created_at, updated_at
 		FROM jobs WHERE type = $1 AND status = 'queued'
 		ORDER BY created_at ASC LIMIT $2
 	`
@@ -353,7 +980,7 @@ func (r *Repository) GetQueuedJobs(ctx context.Context, jobType models.JobType,
 	var jobs []*models.Job
 	for rows.Next() {
 		var j models.Job
-		if err := rows.Scan(&j.ID, &j.CaseID, &j.Type, &j.Status, &j.Progress, &j.Input, &j.Output, &j.Error, &j.IdempotencyKey, &j.RetryCount, &j.CreatedAt, &j.UpdatedAt); err != nil {
+		if err := rows.Scan(&j.ID, &j.CaseID, &j.Type, &j.Status, &j.Progress, &j.Input, &j.Output, &j.Error, &j.IdempotencyKey, &j.RetryCount, &j.CreatedBy, &j.EstimatedCost, &j.ActualCost, &j.CreatedAt, &j.UpdatedAt); err != nil {
 			return nil, err
 		}
 		jobs = append(jobs, &j)
@@ -364,7 +991,7 @@ func (r *Repository) GetQueuedJobs(ctx context.Context, jobType models.JobType,
 // GetZombieJobs returns jobs that are running but haven't been updated recently
 func (r *Repository) GetZombieJobs(ctx context.Context, timeout time.Duration) ([]*models.Job, error) {
 	query := `
-		SELECT id, case_id, type, status, progress, input, output, error, COALESCE(idempotency_key, ''), retry_count, created_at, updated_at
+		SELECT id, case_id, type, status, progress, input, output, error, COALESCE(idempotency_key, ''), retry_count, created_by, estimated_cost_usd, cost_usd, created_at, updated_at
 		FROM jobs WHERE status = 'running' AND updated_at < NOW() - $1::interval
 	`
 	rows, err := r.db.Pool.Query(ctx, query, fmt.Sprintf("%d seconds", int(timeout.Seconds())))
@@ -376,7 +1003,7 @@ func (r *Repository) GetZombieJobs(ctx context.Context, timeout time.Duration) (
 	var jobs []*models.Job
 	for rows.Next() {
 		var j models.Job
-		if err := rows.Scan(&j.ID, &j.CaseID, &j.Type, &j.Status, &j.Progress, &j.Input, &j.Output, &j.Error, &j.IdempotencyKey, &j.RetryCount, &j.CreatedAt, &j.UpdatedAt); err != nil {
+		if err := rows.Scan(&j.ID, &j.CaseID, &j.Type, &j.Status, &j.Progress, &j.Input, &j.Output, &j.Error, &j.IdempotencyKey, &j.RetryCount, &j.CreatedBy, &j.EstimatedCost, &j.ActualCost, &j.CreatedAt, &j.UpdatedAt); err != nil {
 			return nil, err
 		}
 		jobs = append(jobs, &j)
@@ -412,6 +1039,15 @@ func (r *Repository) UpsertSceneSnapshot(ctx context.Context, ss *models.SceneSn
 	if err != nil {
 		return err
 	}
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockOpenCase(ctx, tx, ss.CaseID); err != nil {
+		return err
+	}
 	query := `
 		INSERT INTO scene_snapshots (case_id, commit_id, scenegraph, updated_at)
 		VALUES ($1, $2, $3, $4)
@@ -420,8 +1056,10 @@ func (r *Repository) UpsertSceneSnapshot(ctx context.Context, ss *models.SceneSn
 			scenegraph = EXCLUDED.scenegraph,
 			updated_at = EXCLUDED.updated_at
 	`
-	_, err = r.db.Pool.Exec(ctx, query, ss.CaseID, ss.CommitID, sgJSON, ss.UpdatedAt)
-	return err
+	if _, err := tx.Exec(ctx, query, ss.CaseID, ss.CommitID, sgJSON, ss.UpdatedAt); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 // GetSceneSnapshot retrieves scene snapshot for a case
@@ -572,6 +1210,295 @@ func (r *Repository) GetAssetsByCase(ctx context.Context, caseID uuid.UUID, kind
 	return assets, nil
 }
 
+// GetAssetByStorageKey retrieves an asset by its storage key
+func (r *Repository) GetAssetByStorageKey(ctx context.Context, storageKey string) (*models.Asset, error) {
+	query := `
+		SELECT id, case_id, kind, storage_key, metadata, created_at
+		FROM assets WHERE storage_key = $1
+	`
+	var a models.Asset
+	var metaJSON []byte
+	err := r.db.Pool.QueryRow(ctx, query, storageKey).Scan(&a.ID, &a.CaseID, &a.Kind, &a.StorageKey, &metaJSON, &a.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(metaJSON, &a.Metadata); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// UpsertAssetByStorageKey creates an asset, or merges metadata into the existing
+// asset with the same storage key. The stored asset ID is written back to a.
+func (r *Repository) UpsertAssetByStorageKey(ctx context.Context, a *models.Asset) error {
+	metaJSON, err := json.Marshal(a.Metadata)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO assets (id, case_id, kind, storage_key, metadata, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (storage_key) DO UPDATE SET metadata = assets.metadata || EXCLUDED.metadata
+		RETURNING id, created_at
+	`
+	return r.db.Pool.QueryRow(ctx, query, a.ID, a.CaseID, a.Kind, a.StorageKey, metaJSON, a.CreatedAt).Scan(&a.ID, &a.CreatedAt)
+}
+
+// ============================================
+// REPORT TEMPLATES
+// ============================================
+
+// GetReportTemplate retrieves a report template by name
+func (r *Repository) GetReportTemplate(ctx context.Context, name string) (*models.ReportTemplate, error) {
+	query := `
+		SELECT name, COALESCE(description, ''), body, created_at, updated_at
+		FROM report_templates WHERE name = $1
+	`
+	var t models.ReportTemplate
+	err := r.db.Pool.QueryRow(ctx, query, name).Scan(&t.Name, &t.Description, &t.Body, &t.CreatedAt, &t.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ============================================
+// SEARCH
+// ============================================
+
+// searchQuery finds matches in case titles and descriptions, commit
+// summaries and payloads, witness statements, and the evidence cards and
+// object labels of each case's current scene. Witness statement commits are
+// matched statement by statement rather than as a whole. The vectors for
+// cases and commits match the expression indexes in the search migration.
+const searchQuery = `
+	WITH q AS (SELECT websearch_to_tsquery('english', $1) AS query),
+	hits AS (
+		SELECT c.id AS case_id, 'case' AS source, NULL::uuid AS commit_id, NULL::text AS commit_type, NULL::text AS ref_id,
+			c.title || E'\n' || coalesce(c.description, '') AS body,
+			c.created_at AS at,
+			ts_rank(to_tsvector('english', c.title || ' ' || coalesce(c.description, '')), q.query) AS rank
+		FROM cases c, q
+		WHERE to_tsvector('english', c.title || ' ' || coalesce(c.description, '')) @@ q.query
+
+		UNION ALL
+		SELECT c.case_id, 'commit', c.id, c.type::text, NULL,
+			c.summary || E'\n' || coalesce((
+				SELECT string_agg(v #>> '{}', ' ')
+				FROM jsonb_path_query(c.payload, 'strict $.** ? (@.type() == "string")') v
+			), ''),
+			c.created_at,
+			ts_rank(to_tsvector('english', c.summary) || jsonb_to_tsvector('english', c.payload, '["string"]'), q.query)
+		FROM commits c, q
+		WHERE c.type <> 'witness_statement'
+			AND (to_tsvector('english', c.summary) || jsonb_to_tsvector('english', c.payload, '["string"]')) @@ q.query
+
+		UNION ALL
+		SELECT c.case_id, 'statement', c.id, c.type::text, (s.n - 1)::text,
+			coalesce(s.st->>'source_name', '') || E'\n' || coalesce(s.st->>'content', ''),
+			c.created_at,
+			ts_rank(to_tsvector('english', coalesce(s.st->>'source_name', '') || ' ' || coalesce(s.st->>'content', '')), q.query)
+		FROM commits c
+		CROSS JOIN q
+		CROSS JOIN LATERAL jsonb_array_elements(
+			CASE jsonb_typeof(c.payload->'statements') WHEN 'array' THEN c.payload->'statements' ELSE '[]'::jsonb END
+		) WITH ORDINALITY AS s(st, n)
+		WHERE c.type = 'witness_statement'
+			AND (to_tsvector('english', c.summary) || jsonb_to_tsvector('english', c.payload, '["string"]')) @@ q.query
+			AND to_tsvector('english', coalesce(s.st->>'source_name', '') || ' ' || coalesce(s.st->>'content', '')) @@ q.query
+
+		UNION ALL
+		SELECT ss.case_id, 'evidence', ss.commit_id, NULL, e->>'id',
+			coalesce(e->>'title', '') || E'\n' || coalesce(e->>'description', ''),
+			ss.updated_at,
+			ts_rank(to_tsvector('english', coalesce(e->>'title', '') || ' ' || coalesce(e->>'description', '')), q.query)
+		FROM scene_snapshots ss
+		CROSS JOIN q
+		CROSS JOIN LATERAL jsonb_array_elements(
+			CASE jsonb_typeof(ss.scenegraph->'evidence') WHEN 'array' THEN ss.scenegraph->'evidence' ELSE '[]'::jsonb END
+		) AS e
+		WHERE to_tsvector('english', coalesce(e->>'title', '') || ' ' || coalesce(e->>'description', '')) @@ q.query
+
+		UNION ALL
+		SELECT ss.case_id, 'object', ss.commit_id, NULL, o->>'id',
+			coalesce(o->>'label', ''),
+			ss.updated_at,
+			ts_rank(to_tsvector('english', coalesce(o->>'label', '')), q.query)
+		FROM scene_snapshots ss
+		CROSS JOIN q
+		CROSS JOIN LATERAL jsonb_array_elements(
+			CASE jsonb_typeof(ss.scenegraph->'objects') WHEN 'array' THEN ss.scenegraph->'objects' ELSE '[]'::jsonb END
+		) AS o
+		WHERE to_tsvector('english', coalesce(o->>'label', '')) @@ q.query
+	)
+	SELECT h.case_id, cs.title, h.source, h.commit_id, coalesce(h.commit_type, ''), coalesce(h.ref_id, ''),
+		ts_headline('english', h.body, q.query, 'StartSel=«, StopSel=», MaxWords=30, MinWords=10, MaxFragments=2'),
+		h.rank, h.at
+	FROM hits h
+	JOIN cases cs ON cs.id = h.case_id AND cs.deleted_at IS NULL
+	CROSS JOIN q
+	WHERE ($2::text[] IS NULL OR h.commit_type = ANY($2::text[]))
+		AND ($6::uuid IS NULL OR EXISTS (
+			SELECT 1 FROM case_members m WHERE m.case_id = h.case_id AND m.user_id = $6
+		))
+		AND ($3::timestamptz IS NULL OR h.at >= $3)
+		AND ($4::timestamptz IS NULL OR h.at < $4)
+	ORDER BY h.rank DESC, h.at DESC
+	LIMIT $5
+`
+
+// SearchCases runs a full-text search across every case, best matches
+// first. Snippets mark matched words with models.SearchHighlightStart and
+// models.SearchHighlightStop. Only commit and statement matches have a
+// commit type, so filtering by type leaves out the other sources.
+func (r *Repository) SearchCases(ctx context.Context, q *models.SearchQuery) ([]*models.SearchHit, error) {
+	var types []string
+	for _, t := range q.CommitTypes {
+		types = append(types, string(t))
+	}
+
+	rows, err := r.db.Pool.Query(ctx, searchQuery, q.Text, types, q.From, q.To, q.Limit, q.MemberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []*models.SearchHit
+	for rows.Next() {
+		var h models.SearchHit
+		var rank float32
+		if err := rows.Scan(&h.CaseID, &h.CaseTitle, &h.Source, &h.CommitID, &h.CommitType, &h.RefID, &h.Snippet, &rank, &h.At); err != nil {
+			return nil, err
+		}
+		h.Rank = float64(rank)
+		hits = append(hits, &h)
+	}
+	return hits, rows.Err()
+}
+
+// ============================================
+// CASE IMPORT
+// ============================================
+
+// CaseImport is a complete case to be written at once, as restored from a
+// bundle. Commits must be ordered parents first.
+type CaseImport struct {
+	Case     *models.Case
+	Branches []models.Branch
+	Commits  []models.Commit
+	Snapshot *models.SceneSnapshot
+	Profile  *models.SuspectProfile
+	Assets   []models.Asset
+	Lead     *uuid.UUID // user made lead of the imported case
+}
+
+// ImportCase writes a case and everything in it in a single transaction, so a
+// failed import leaves nothing behind
+func (r *Repository) ImportCase(ctx context.Context, imp *CaseImport) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	c := imp.Case
+	status := c.Status
+	if !status.IsValid() {
+		status = models.CaseStatusOpen
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO cases (id, title, description, status, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, c.ID, c.Title, c.Description, status, c.CreatedBy, c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert case: %w", err)
+	}
+	if imp.Lead != nil {
+		if err := insertCaseLead(ctx, tx, c.ID, *imp.Lead); err != nil {
+			return fmt.Errorf("failed to add case lead: %w", err)
+		}
+	}
+
+	// Branches and commits reference each other, so branches go in without
+	// their base commit and get it once the commits exist
+	for _, b := range imp.Branches {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO branches (id, case_id, name, created_at)
+			VALUES ($1, $2, $3, $4)
+		`, b.ID, b.CaseID, b.Name, b.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert branch %s: %w", b.ID, err)
+		}
+	}
+	for _, cm := range imp.Commits {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO commits (id, case_id, parent_commit_id, branch_id, type, summary, payload, created_by, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, cm.ID, cm.CaseID, cm.ParentCommitID, cm.BranchID, cm.Type, cm.Summary, cm.Payload, cm.CreatedBy, cm.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert commit %s: %w", cm.ID, err)
+		}
+	}
+	for _, b := range imp.Branches {
+		if b.BaseCommitID == uuid.Nil {
+			continue
+		}
+		_, err = tx.Exec(ctx, `UPDATE branches SET base_commit_id = $2 WHERE id = $1`, b.ID, b.BaseCommitID)
+		if err != nil {
+			return fmt.Errorf("failed to set base commit of branch %s: %w", b.ID, err)
+		}
+	}
+
+	if ss := imp.Snapshot; ss != nil {
+		sgJSON, err := json.Marshal(ss.Scenegraph)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO scene_snapshots (case_id, commit_id, scenegraph, updated_at)
+			VALUES ($1, $2, $3, $4)
+		`, ss.CaseID, ss.CommitID, sgJSON, ss.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert scene snapshot: %w", err)
+		}
+	}
+	if sp := imp.Profile; sp != nil {
+		attrsJSON, err := json.Marshal(sp.Attributes)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO suspect_profiles (case_id, commit_id, attributes, portrait_asset_key, updated_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`, sp.CaseID, sp.CommitID, attrsJSON, sp.PortraitAssetKey, sp.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert suspect profile: %w", err)
+		}
+	}
+
+	for _, a := range imp.Assets {
+		metaJSON, err := json.Marshal(a.Metadata)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO assets (id, case_id, kind, storage_key, metadata, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, a.ID, a.CaseID, a.Kind, a.StorageKey, metaJSON, a.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert asset %s: %w", a.ID, err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
 // ============================================
 // COMMIT DIFF & REPLAY
 // ============================================
@@ -770,6 +1697,11 @@ func (r *Repository) ReplayToCommit(ctx context.Context, caseID, targetCommitID
 	return sg, nil
 }
 
+// GetCommitChain returns a commit and its ancestors, oldest first
+func (r *Repository) GetCommitChain(ctx context.Context, caseID, commitID uuid.UUID) ([]*models.Commit, error) {
+	return r.getCommitChain(ctx, caseID, commitID)
+}
+
 // getCommitChain retrieves all commits from the beginning to the target commit
 func (r *Repository) getCommitChain(ctx context.Context, caseID, targetCommitID uuid.UUID) ([]*models.Commit, error) {
 	// Get all commits for the case ordered by creation time
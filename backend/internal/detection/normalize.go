@@ -0,0 +1,191 @@
+// Package detection normalizes scene analysis output: model labels are mapped
+// onto the scene's enums, objects and evidence get IDs derived from their
+// content, and sightings of one object in several images are merged.
+package detection
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// typeSynonyms maps words a vision model uses onto object types
+var typeSynonyms = map[string]models.ObjectType{
+	"table": models.ObjectTypeFurniture, "desk": models.ObjectTypeFurniture, "chair": models.ObjectTypeFurniture,
+	"sofa": models.ObjectTypeFurniture, "couch": models.ObjectTypeFurniture, "bed": models.ObjectTypeFurniture,
+	"cabinet": models.ObjectTypeFurniture, "shelf": models.ObjectTypeFurniture, "bookshelf": models.ObjectTypeFurniture,
+	"dresser": models.ObjectTypeFurniture, "wardrobe": models.ObjectTypeFurniture, "stool": models.ObjectTypeFurniture,
+	"doorway": models.ObjectTypeDoor, "entrance": models.ObjectTypeDoor, "gate": models.ObjectTypeDoor,
+	"windowpane": models.ObjectTypeWindow, "pane": models.ObjectTypeWindow, "skylight": models.ObjectTypeWindow,
+	"knife": models.ObjectTypeWeapon, "gun": models.ObjectTypeWeapon, "firearm": models.ObjectTypeWeapon,
+	"pistol": models.ObjectTypeWeapon, "rifle": models.ObjectTypeWeapon, "bat": models.ObjectTypeWeapon,
+	"shoeprint": models.ObjectTypeFootprint, "bootprint": models.ObjectTypeFootprint, "footprints": models.ObjectTypeFootprint,
+	"blood": models.ObjectTypeBloodstain, "bloodstains": models.ObjectTypeBloodstain, "spatter": models.ObjectTypeBloodstain,
+	"car": models.ObjectTypeVehicle, "truck": models.ObjectTypeVehicle, "van": models.ObjectTypeVehicle,
+	"motorcycle": models.ObjectTypeVehicle, "bicycle": models.ObjectTypeVehicle,
+	"person": models.ObjectTypePersonMarker, "body": models.ObjectTypePersonMarker, "victim": models.ObjectTypePersonMarker,
+	"evidence": models.ObjectTypeEvidenceItem, "item": models.ObjectTypeEvidenceItem, "clue": models.ObjectTypeEvidenceItem,
+}
+
+// labelStopwords are dropped when canonicalizing labels
+var labelStopwords = map[string]bool{"a": true, "an": true, "the": true, "of": true, "on": true, "in": true}
+
+// NormalizeType maps a model's type string onto an ObjectType. Unknown types
+// are looked up word by word in the type and then the label; anything still
+// unmatched is "other".
+func NormalizeType(raw, label string) models.ObjectType {
+	ws := words(raw)
+	if t := models.ObjectType(strings.Join(ws, "_")); t.IsValid() {
+		return t
+	}
+	// "shoe print" and "shoe-print" are written as one word in the synonyms
+	if t, ok := typeSynonyms[strings.Join(ws, "")]; ok {
+		return t
+	}
+	for _, text := range []string{raw, label} {
+		ws := words(text)
+		// Later words name the object ("kitchen knife", "wooden table")
+		for i := len(ws) - 1; i >= 0; i-- {
+			if t, ok := typeSynonyms[ws[i]]; ok {
+				return t
+			}
+			if t := models.ObjectType(ws[i]); t.IsValid() {
+				return t
+			}
+		}
+	}
+	return models.ObjectTypeOther
+}
+
+// ObjectState returns the state for a detection
+func ObjectState(d models.DetectedObject) models.ObjectState {
+	if d.IsSuspicious {
+		return models.ObjectStateSuspicious
+	}
+	return models.ObjectStateVisible
+}
+
+// ObjectID derives a stable ID from an object's type, label and ordinal among
+// same-labelled objects in one image, so repeated analyses and other images
+// of the same object map to the same ID
+func ObjectID(t models.ObjectType, label string, ordinal int) string {
+	key := string(t) + "|" + CanonicalLabel(label)
+	if ordinal > 0 {
+		key += "|" + strconv.Itoa(ordinal)
+	}
+	return "obj_" + digest(key)
+}
+
+// EvidenceID derives a stable ID from the text of a potential evidence item
+func EvidenceID(text string) string {
+	return "ev_" + digest(CanonicalLabel(text))
+}
+
+// CanonicalLabel lowercases a label and drops punctuation and filler words
+func CanonicalLabel(label string) string {
+	var kept []string
+	for _, w := range words(label) {
+		if !labelStopwords[w] {
+			kept = append(kept, w)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// Normalize maps every detection's type onto the enum, assigns stable IDs and
+// merges detections that share an ID into one with a source per image. The
+// merged detection keeps the highest confidence, is suspicious if any
+// sighting was, and keeps the first sighting's image as SourceImageKey.
+func Normalize(detected []models.DetectedObject) []models.DetectedObject {
+	var out []models.DetectedObject
+	index := map[string]int{}
+	ordinals := map[string]int{} // image|type|label -> count so far
+
+	for _, d := range detected {
+		t := NormalizeType(d.Type, d.Label)
+		seen := d.SourceImageKey + "|" + string(t) + "|" + CanonicalLabel(d.Label)
+		ordinal := ordinals[seen]
+		ordinals[seen]++
+
+		id := ObjectID(t, d.Label, ordinal)
+		sources := d.Sources
+		if len(sources) == 0 {
+			sources = []models.DetectionSource{{
+				ImageKey:            d.SourceImageKey,
+				Confidence:          d.Confidence,
+				BoundingBox:         d.BoundingBox,
+				PositionDescription: d.PositionDescription,
+			}}
+		}
+
+		i, ok := index[id]
+		if !ok {
+			d.ID = id
+			d.Type = string(t)
+			d.Sources = append([]models.DetectionSource(nil), sources...)
+			index[id] = len(out)
+			out = append(out, d)
+			continue
+		}
+
+		m := &out[i]
+		m.Sources = append(m.Sources, sources...)
+		if d.Confidence > m.Confidence {
+			m.Confidence = d.Confidence
+		}
+		m.IsSuspicious = m.IsSuspicious || d.IsSuspicious
+		m.Notes = joinNotes(m.Notes, d.Notes)
+		if m.PositionDescription == "" {
+			m.PositionDescription = d.PositionDescription
+		}
+		if m.BoundingBox == nil {
+			m.BoundingBox = d.BoundingBox
+		}
+	}
+	return out
+}
+
+// ImageKeys returns the distinct images a detection was seen in
+func ImageKeys(d models.DetectedObject) []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, s := range d.Sources {
+		if s.ImageKey != "" && !seen[s.ImageKey] {
+			seen[s.ImageKey] = true
+			keys = append(keys, s.ImageKey)
+		}
+	}
+	if len(keys) == 0 && d.SourceImageKey != "" {
+		keys = []string{d.SourceImageKey}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinNotes(a, b string) string {
+	switch {
+	case b == "" || strings.Contains(a, b):
+		return a
+	case a == "":
+		return b
+	}
+	return a + "; " + b
+}
+
+// words splits text into lowercase alphanumeric words
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		// Marks keep accents and vowel signs in their word
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+	})
+}
+
+func digest(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return hex.EncodeToString(sum[:6])
+}
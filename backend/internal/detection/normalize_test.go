@@ -0,0 +1,172 @@
+package detection
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+func TestNormalizeType(t *testing.T) {
+	tests := []struct {
+		raw, label string
+		want       models.ObjectType
+	}{
+		{"furniture", "Wooden Table", models.ObjectTypeFurniture},
+		{"Evidence Item", "Broken Glass", models.ObjectTypeEvidenceItem},
+		{"blood-stain", "", models.ObjectTypeBloodstain},
+		{"shoe print", "", models.ObjectTypeFootprint},
+		{"kitchen knife", "", models.ObjectTypeWeapon},
+		{"object", "Wooden chair", models.ObjectTypeFurniture},
+		{"thing", "Potted plant", models.ObjectTypeOther},
+	}
+	for _, tt := range tests {
+		if got := NormalizeType(tt.raw, tt.label); got != tt.want {
+			t.Errorf("NormalizeType(%q, %q) = %v, want %v", tt.raw, tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestObjectState(t *testing.T) {
+	if got := ObjectState(models.DetectedObject{IsSuspicious: true}); got != models.ObjectStateSuspicious {
+		t.Errorf("suspicious state = %v", got)
+	}
+	if got := ObjectState(models.DetectedObject{}); got != models.ObjectStateVisible || !got.IsValid() {
+		t.Errorf("default state = %v", got)
+	}
+}
+
+func TestNormalize_StableIDs(t *testing.T) {
+	first := Normalize([]models.DetectedObject{{ID: "a", Type: "furniture", Label: "Wooden Table", SourceImageKey: "img1"}})
+	second := Normalize([]models.DetectedObject{{ID: "b", Type: "table", Label: "the wooden table", SourceImageKey: "img2"}})
+
+	if first[0].ID != second[0].ID {
+		t.Errorf("IDs differ across runs: %s, %s", first[0].ID, second[0].ID)
+	}
+	if first[0].Type != string(models.ObjectTypeFurniture) {
+		t.Errorf("Type = %s, want furniture", first[0].Type)
+	}
+	if EvidenceID("Broken glass") != EvidenceID("broken glass.") {
+		t.Error("EvidenceID() differs for the same text")
+	}
+}
+
+func TestCanonicalLabel_NonASCII(t *testing.T) {
+	tests := map[string]string{
+		"Couteau ensanglanté.": "couteau ensanglanté",
+		"Кухонный НОЖ":         "кухонный нож",
+		"血痕 (床)":               "血痕 床",
+		"Ébène, Çà":            "ébène çà",
+	}
+	for label, want := range tests {
+		if got := CanonicalLabel(label); got != want {
+			t.Errorf("CanonicalLabel(%q) = %q, want %q", label, got, want)
+		}
+	}
+
+	objects := Normalize([]models.DetectedObject{
+		{Type: "other", Label: "血痕", SourceImageKey: "img1"},
+		{Type: "other", Label: "割れたガラス", SourceImageKey: "img1"},
+	})
+	if len(objects) != 2 || objects[0].ID == objects[1].ID {
+		t.Errorf("differently labelled objects were merged: %+v", objects)
+	}
+
+	sg := models.NewEmptySceneGraph()
+	MergeEvidence(sg, []string{"Сломанное стекло", "血痕"}, "c1", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	if len(sg.Evidence) != 2 {
+		t.Errorf("evidence = %+v, want a card per item", sg.Evidence)
+	}
+}
+
+func TestNormalize_MergesAcrossImages(t *testing.T) {
+	detected := []models.DetectedObject{
+		{Type: "weapon", Label: "Knife", Confidence: 0.7, SourceImageKey: "img1", Notes: "on counter",
+			BoundingBox: &models.BBox{X: 0.1, Y: 0.1, Width: 0.1, Height: 0.1}},
+		{Type: "weapon", Label: "Knife", Confidence: 0.9, SourceImageKey: "img2", IsSuspicious: true, Notes: "blood on blade"},
+		// A second knife in the same image is a different object
+		{Type: "weapon", Label: "Knife", Confidence: 0.6, SourceImageKey: "img2"},
+	}
+
+	out := Normalize(detected)
+	if len(out) != 2 {
+		t.Fatalf("Normalize() returned %d objects, want 2", len(out))
+	}
+	merged := out[0]
+	if len(merged.Sources) != 2 || merged.Sources[0].ImageKey != "img1" || merged.Sources[1].ImageKey != "img2" {
+		t.Errorf("merged sources = %+v", merged.Sources)
+	}
+	if merged.Confidence != 0.9 || !merged.IsSuspicious || merged.SourceImageKey != "img1" {
+		t.Errorf("merged detection = %+v", merged)
+	}
+	if merged.Notes != "on counter; blood on blade" {
+		t.Errorf("merged notes = %q", merged.Notes)
+	}
+	if out[1].ID == merged.ID {
+		t.Error("second knife in one image shares the first's ID")
+	}
+	if keys := ImageKeys(merged); len(keys) != 2 {
+		t.Errorf("ImageKeys() = %v", keys)
+	}
+}
+
+func TestMergeObjects_KeepsPlacementAndSources(t *testing.T) {
+	sg := models.NewEmptySceneGraph()
+	MergeObjects(sg, Normalize([]models.DetectedObject{{Type: "furniture", Label: "Table", Confidence: 0.8, SourceImageKey: "img1"}}), "c1")
+	if err := sg.Validate(); err != nil {
+		t.Fatalf("scene invalid after merge: %v", err)
+	}
+
+	// Reconstruction places the object; the snapshot is stored as JSON
+	sg.Objects[0].Pose.Position = [3]float64{2, 0, 3}
+	sg.Objects[0].EvidenceIDs = []string{"ev"}
+	data, _ := json.Marshal(sg)
+	var stored models.SceneGraph
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+
+	MergeObjects(&stored, Normalize([]models.DetectedObject{{Type: "furniture", Label: "Table", Confidence: 0.6, SourceImageKey: "img2"}}), "c2")
+
+	if len(stored.Objects) != 1 {
+		t.Fatalf("objects = %d, want 1", len(stored.Objects))
+	}
+	obj := stored.Objects[0]
+	if obj.Pose.Position != [3]float64{2, 0, 3} || len(obj.EvidenceIDs) != 1 || obj.Confidence != 0.8 {
+		t.Errorf("merged object lost its placement: %+v", obj)
+	}
+	if len(obj.SourceCommitIDs) != 2 {
+		t.Errorf("SourceCommitIDs = %v", obj.SourceCommitIDs)
+	}
+	keys, _ := obj.Metadata["source_image_keys"].([]string)
+	if len(keys) != 2 || keys[0] != "img1" || keys[1] != "img2" {
+		t.Errorf("source_image_keys = %v", obj.Metadata["source_image_keys"])
+	}
+}
+
+func TestMergeEvidence(t *testing.T) {
+	sg := models.NewEmptySceneGraph()
+	// Evidence stored before IDs were content-derived keeps its ID
+	sg.Evidence = []models.EvidenceCard{{ID: "evidence_1", Title: "Broken glass", Confidence: 0.8, CreatedAt: "2024-01-01T00:00:00Z"}}
+	now := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	MergeEvidence(sg, []string{"Blood spatter", "Broken glass"}, "c1", now)
+	MergeEvidence(sg, []string{"Broken glass", "blood spatter"}, "c2", now)
+
+	if len(sg.Evidence) != 2 {
+		t.Fatalf("evidence = %+v, want 2 cards", sg.Evidence)
+	}
+	if sg.Evidence[0].ID != "evidence_1" || sg.Evidence[0].CreatedAt != "2024-01-01T00:00:00Z" {
+		t.Errorf("legacy card = %+v", sg.Evidence[0])
+	}
+	if sg.Evidence[1].ID != EvidenceID("Blood spatter") || len(sg.Evidence[1].Sources) != 2 {
+		t.Errorf("new card = %+v", sg.Evidence[1])
+	}
+	if len(sg.Evidence[0].Sources) != 2 {
+		t.Errorf("legacy card sources = %+v", sg.Evidence[0].Sources)
+	}
+	if err := sg.Validate(); err != nil {
+		t.Errorf("scene invalid after merge: %v", err)
+	}
+}
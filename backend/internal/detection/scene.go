@@ -0,0 +1,199 @@
+package detection
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// evidenceConfidence is the confidence given to evidence suggested by scene analysis
+const evidenceConfidence = 0.8
+
+// SceneObject converts a normalized detection into a scene object. Pose and
+// bounds are placeholders until reconstruction lifts it into 3D.
+func SceneObject(d models.DetectedObject, commitID string) models.SceneObject {
+	obj := models.SceneObject{
+		ID:         d.ID,
+		Type:       NormalizeType(d.Type, d.Label),
+		Label:      d.Label,
+		State:      ObjectState(d),
+		Confidence: d.Confidence,
+		Pose:       models.NewDefaultPose(),
+		BBox: models.BoundingBox{
+			Min: [3]float64{0, 0, 0},
+			Max: [3]float64{1, 1, 1},
+		},
+		EvidenceIDs:     []string{},
+		SourceCommitIDs: []string{},
+		Metadata: map[string]interface{}{
+			"notes":                d.Notes,
+			"is_suspicious":        d.IsSuspicious,
+			"position_description": d.PositionDescription,
+			"source_image_key":     d.SourceImageKey,
+			"source_image_keys":    ImageKeys(d),
+			"sources":              d.Sources,
+		},
+	}
+	if commitID != "" {
+		obj.SourceCommitIDs = append(obj.SourceCommitIDs, commitID)
+	}
+	return obj
+}
+
+// MergeObjects adds normalized detections to the scene. A detection whose ID
+// is already in the scene updates that object: its sightings are added to the
+// existing ones, and its pose, bounds and links are kept.
+func MergeObjects(sg *models.SceneGraph, detected []models.DetectedObject, commitID string) {
+	for _, d := range detected {
+		obj := SceneObject(d, commitID)
+
+		i := findObject(sg.Objects, obj.ID)
+		if i < 0 {
+			sg.Objects = append(sg.Objects, obj)
+			continue
+		}
+
+		existing := sg.Objects[i]
+		obj.Pose = existing.Pose
+		obj.BBox = existing.BBox
+		obj.MeshRef = existing.MeshRef
+		if existing.EvidenceIDs != nil {
+			obj.EvidenceIDs = existing.EvidenceIDs
+		}
+		obj.SourceCommitIDs = appendUnique(existing.SourceCommitIDs, commitID)
+		if existing.Confidence > obj.Confidence {
+			obj.Confidence = existing.Confidence
+		}
+
+		// Keep metadata from other stages and earlier sightings
+		metadata := map[string]interface{}{}
+		for k, v := range existing.Metadata {
+			metadata[k] = v
+		}
+		sources := append(Sources(existing), d.Sources...)
+		keys := ImageKeys(models.DetectedObject{Sources: sources})
+		for k, v := range obj.Metadata {
+			metadata[k] = v
+		}
+		metadata["sources"] = sources
+		metadata["source_image_keys"] = keys
+		obj.Metadata = metadata
+
+		sg.Objects[i] = obj
+	}
+}
+
+// MergeEvidence adds scene analysis's potential evidence as evidence cards
+// with content-derived IDs. Items already in the scene, by ID or title, are
+// kept with their ID and creation time and gain this commit as a source.
+func MergeEvidence(sg *models.SceneGraph, items []string, commitID string, now time.Time) {
+	for _, item := range items {
+		if CanonicalLabel(item) == "" {
+			continue
+		}
+		card := models.EvidenceCard{
+			ID:          EvidenceID(item),
+			ObjectIDs:   []string{},
+			Title:       item,
+			Description: fmt.Sprintf("Potential evidence: %s", item),
+			Confidence:  evidenceConfidence,
+			Sources:     []models.EvidenceSource{},
+			CreatedAt:   now.UTC().Format(time.RFC3339),
+		}
+
+		i := -1
+		for j, existing := range sg.Evidence {
+			if existing.ID == card.ID || existing.Title == card.Title {
+				i = j
+				break
+			}
+		}
+		if i >= 0 {
+			existing := sg.Evidence[i]
+			card = existing
+			if card.Sources == nil {
+				card.Sources = []models.EvidenceSource{}
+			}
+		}
+		if commitID != "" && !hasSource(card.Sources, commitID) {
+			card.Sources = append(card.Sources, models.EvidenceSource{
+				Type:        models.EvidenceSourceTypeInference,
+				CommitID:    commitID,
+				Description: "scene analysis",
+			})
+		}
+
+		if i >= 0 {
+			sg.Evidence[i] = card
+		} else {
+			sg.Evidence = append(sg.Evidence, card)
+		}
+	}
+}
+
+// Sources reads the sightings stored on a scene object, whether they are
+// still typed or came back from JSON
+func Sources(obj models.SceneObject) []models.DetectionSource {
+	metadata := obj.Metadata
+	switch v := metadata["sources"].(type) {
+	case []models.DetectionSource:
+		return append([]models.DetectionSource(nil), v...)
+	case []interface{}:
+		var out []models.DetectionSource
+		for _, item := range v {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			s := models.DetectionSource{}
+			s.ImageKey, _ = m["image_key"].(string)
+			s.Confidence, _ = m["confidence"].(float64)
+			s.PositionDescription, _ = m["position_description"].(string)
+			if bb, ok := m["bounding_box"].(map[string]interface{}); ok {
+				s.BoundingBox = &models.BBox{}
+				s.BoundingBox.X, _ = bb["x"].(float64)
+				s.BoundingBox.Y, _ = bb["y"].(float64)
+				s.BoundingBox.Width, _ = bb["width"].(float64)
+				s.BoundingBox.Height, _ = bb["height"].(float64)
+			}
+			out = append(out, s)
+		}
+		return out
+	}
+	if key, ok := metadata["source_image_key"].(string); ok && key != "" {
+		return []models.DetectionSource{{ImageKey: key}}
+	}
+	return nil
+}
+
+func findObject(objects []models.SceneObject, id string) int {
+	for i, obj := range objects {
+		if obj.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func hasSource(sources []models.EvidenceSource, commitID string) bool {
+	for _, s := range sources {
+		if s.CommitID == commitID {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(list []string, v string) []string {
+	out := append([]string{}, list...)
+	if v == "" {
+		return out
+	}
+	for _, s := range out {
+		if s == v {
+			return out
+		}
+	}
+	return append(out, v)
+}
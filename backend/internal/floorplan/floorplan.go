@@ -0,0 +1,464 @@
+// Package floorplan draws a top-down plan of a scene: walls, openings and
+// object footprints, uncertainty regions, evidence markers and trajectory
+// polylines. Plans render headlessly to SVG or PNG so reports and the
+// evidence board can embed them without a browser or GPU.
+package floorplan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// Output width limits, in pixels
+const (
+	DefaultWidth = 800
+	MinWidth     = 200
+	MaxWidth     = 4096
+)
+
+const (
+	margin        = 32.0 // pixels around the scene
+	maxAspect     = 3.0  // the plan is at most this many times taller than wide
+	markerRadius  = 9.0  // pixels
+	labelOffset   = 10.0 // pixels between a small object and its label
+	minFootprint  = 0.3  // meters, for objects placed without a box
+	wallThickness = 0.15 // meters, when a wall doesn't record its own
+)
+
+// ErrEmptyScene is returned when a scene has nothing to draw
+var ErrEmptyScene = errors.New("scene has no geometry to draw")
+
+// Options controls how a plan is drawn
+type Options struct {
+	Width        int  // output width in pixels; the height follows the scene
+	Labels       bool // label objects and trajectories
+	Trajectories bool // draw the trajectories passed to Build
+}
+
+// DefaultOptions returns options for a labelled plan with trajectories
+func DefaultOptions() Options {
+	return Options{Width: DefaultWidth, Labels: true, Trajectories: true}
+}
+
+// Marker is a numbered evidence marker on the plan
+type Marker struct {
+	Number     int
+	EvidenceID string
+	Title      string
+	Tier       models.EvidenceTier
+}
+
+// Plan is a scene projected onto the floor, with X to the right and Z down
+type Plan struct {
+	Width   int
+	Height  int
+	Scale   float64  // pixels per meter
+	Markers []Marker // evidence markers in number order
+	shapes  []shape
+	minX    float64
+	minZ    float64
+}
+
+// shapeKind is what a shape draws
+type shapeKind int
+
+const (
+	shapePolygon shapeKind = iota
+	shapeLine
+	shapeCircle
+	shapeText
+)
+
+// shape is one drawing primitive in pixel coordinates. Both renderers draw
+// the same shapes, so SVG and PNG output match.
+type shape struct {
+	kind   shapeKind
+	class  string // SVG class, such as "wall" or "evidence"
+	title  string // SVG tooltip
+	points []point
+	radius float64
+	fill   color.NRGBA // no fill when transparent
+	stroke color.NRGBA // no outline when transparent
+	width  float64
+	dashed bool
+	text   string
+}
+
+type point [2]float64
+
+// Colours
+var (
+	background  = color.NRGBA{0xff, 0xff, 0xff, 0xff}
+	gridColour  = color.NRGBA{0xe5, 0xe7, 0xeb, 0xff}
+	wallColour  = color.NRGBA{0x37, 0x41, 0x51, 0xff}
+	doorColour  = color.NRGBA{0xb4, 0x53, 0x09, 0xff}
+	glassColour = color.NRGBA{0x38, 0xbd, 0xf8, 0xff}
+	objectFill  = color.NRGBA{0xd1, 0xd5, 0xdb, 0xff}
+	objectEdge  = color.NRGBA{0x6b, 0x72, 0x80, 0xff}
+	evidenceObj = color.NRGBA{0xfd, 0xe6, 0x8a, 0xff}
+	alertColour = color.NRGBA{0xdc, 0x26, 0x26, 0xff}
+	labelColour = color.NRGBA{0x11, 0x18, 0x27, 0xff}
+	white       = color.NRGBA{0xff, 0xff, 0xff, 0xff}
+
+	tierColours = map[models.EvidenceTier]color.NRGBA{
+		models.EvidenceTierHigh:   {0x16, 0xa3, 0x4a, 0xff},
+		models.EvidenceTierMedium: {0xd9, 0x77, 0x06, 0xff},
+		models.EvidenceTierLow:    {0xdc, 0x26, 0x26, 0xff},
+	}
+	uncertaintyColours = map[models.UncertaintyLevel]color.NRGBA{
+		models.UncertaintyLevelLow:    {0xfb, 0xbf, 0x24, 0x33},
+		models.UncertaintyLevelMedium: {0xf9, 0x73, 0x16, 0x40},
+		models.UncertaintyLevelHigh:   {0xef, 0x44, 0x44, 0x4d},
+	}
+	// trajectoryColours are used in rank order, repeating after the last
+	trajectoryColours = []color.NRGBA{
+		{0x25, 0x63, 0xeb, 0xff},
+		{0x93, 0x33, 0xea, 0xff},
+		{0x0d, 0x94, 0x88, 0xff},
+		{0xdb, 0x27, 0x77, 0xff},
+	}
+)
+
+// Build lays out the plan of a scene. Trajectories are drawn in rank order
+// when opts.Trajectories is set. It returns ErrEmptyScene when the scene has
+// no objects, uncertainty regions or trajectories.
+func Build(sg *models.SceneGraph, trajectories []models.Trajectory, opts Options) (*Plan, error) {
+	if opts.Width == 0 {
+		opts.Width = DefaultWidth
+	}
+	if opts.Width < MinWidth || opts.Width > MaxWidth {
+		return nil, fmt.Errorf("width must be between %d and %d", MinWidth, MaxWidth)
+	}
+	if !opts.Trajectories {
+		trajectories = nil
+	}
+	if sg == nil {
+		sg = models.NewEmptySceneGraph()
+	}
+
+	minX, minZ, maxX, maxZ, ok := extent(sg, trajectories)
+	if !ok {
+		return nil, ErrEmptyScene
+	}
+	// Keep a tiny scene from filling the page at an absurd scale
+	w, h := math.Max(maxX-minX, 1), math.Max(maxZ-minZ, 1)
+	cx, cz := (minX+maxX)/2, (minZ+maxZ)/2
+	minX, minZ = cx-w/2, cz-h/2
+
+	inner := float64(opts.Width) - 2*margin
+	scale := math.Min(inner/w, inner*maxAspect/h)
+	p := &Plan{
+		Width:  opts.Width,
+		Height: int(math.Ceil(h*scale + 2*margin)),
+		Scale:  scale,
+		// Centre the scene horizontally when the height limit narrows it
+		minX: minX - (inner/scale-w)/2,
+		minZ: minZ,
+	}
+
+	p.drawGrid()
+	p.drawUncertainty(sg.UncertaintyRegions)
+	p.drawObjects(sg.Objects, opts.Labels)
+	p.drawTrajectories(trajectories, opts.Labels)
+	p.drawMarkers(sg)
+	p.drawScaleBar()
+	return p, nil
+}
+
+// px maps a floor position in meters to pixels
+func (p *Plan) px(x, z float64) point {
+	return point{margin + (x-p.minX)*p.Scale, margin + (z-p.minZ)*p.Scale}
+}
+
+func (p *Plan) add(s shape) {
+	p.shapes = append(p.shapes, s)
+}
+
+// extent returns the floor area covered by the scene's objects, uncertainty
+// regions and trajectories. The scene bounds are left out: new scenes carry
+// placeholder bounds that would shrink a small room to a corner.
+func extent(sg *models.SceneGraph, trajectories []models.Trajectory) (minX, minZ, maxX, maxZ float64, ok bool) {
+	minX, minZ = math.Inf(1), math.Inf(1)
+	maxX, maxZ = math.Inf(-1), math.Inf(-1)
+	grow := func(x, z float64) {
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minZ, maxZ = math.Min(minZ, z), math.Max(maxZ, z)
+		ok = true
+	}
+	for _, obj := range sg.Objects {
+		for _, c := range footprint(obj) {
+			grow(c[0], c[1])
+		}
+	}
+	for _, r := range sg.UncertaintyRegions {
+		grow(r.BBox.Min[0], r.BBox.Min[2])
+		grow(r.BBox.Max[0], r.BBox.Max[2])
+	}
+	for _, t := range trajectories {
+		for _, pt := range route(t) {
+			grow(pt[0], pt[2])
+		}
+	}
+	return
+}
+
+// footprint returns the floor rectangle of an object's world-space box, or a
+// small square around its position when it has none
+func footprint(obj models.SceneObject) [4]point {
+	minX, minZ := math.Min(obj.BBox.Min[0], obj.BBox.Max[0]), math.Min(obj.BBox.Min[2], obj.BBox.Max[2])
+	maxX, maxZ := math.Max(obj.BBox.Min[0], obj.BBox.Max[0]), math.Max(obj.BBox.Min[2], obj.BBox.Max[2])
+	if obj.BBox.Min == obj.BBox.Max {
+		x, z := obj.Pose.Position[0], obj.Pose.Position[2]
+		minX, minZ, maxX, maxZ = x-minFootprint/2, z-minFootprint/2, x+minFootprint/2, z+minFootprint/2
+	}
+	return [4]point{{minX, minZ}, {maxX, minZ}, {maxX, maxZ}, {minX, maxZ}}
+}
+
+// route is the path a trajectory traces: each segment's start, waypoints
+// and end, without repeating points shared by consecutive segments
+func route(t models.Trajectory) [][3]float64 {
+	var pts [][3]float64
+	for _, seg := range t.Segments {
+		for _, pt := range append(append([][3]float64{seg.FromPosition}, seg.Waypoints...), seg.ToPosition) {
+			if n := len(pts); n > 0 && pts[n-1] == pt {
+				continue
+			}
+			pts = append(pts, pt)
+		}
+	}
+	return pts
+}
+
+// drawGrid draws a light grid with a line every meter, or every five meters
+// for large scenes
+func (p *Plan) drawGrid() {
+	step := 1.0
+	if float64(p.Width)/p.Scale > 40 {
+		step = 5
+	}
+	maxX := p.minX + (float64(p.Width)-2*margin)/p.Scale
+	maxZ := p.minZ + (float64(p.Height)-2*margin)/p.Scale
+	for x := math.Ceil(p.minX/step) * step; x <= maxX; x += step {
+		p.add(shape{kind: shapeLine, class: "grid", points: []point{p.px(x, p.minZ), p.px(x, maxZ)}, stroke: gridColour, width: 1})
+	}
+	for z := math.Ceil(p.minZ/step) * step; z <= maxZ; z += step {
+		p.add(shape{kind: shapeLine, class: "grid", points: []point{p.px(p.minX, z), p.px(maxX, z)}, stroke: gridColour, width: 1})
+	}
+}
+
+func (p *Plan) drawUncertainty(regions []models.UncertaintyRegion) {
+	for _, r := range regions {
+		fill, ok := uncertaintyColours[r.Level]
+		if !ok {
+			fill = uncertaintyColours[models.UncertaintyLevelMedium]
+		}
+		edge := fill
+		edge.A = 0xcc
+		p.add(shape{
+			kind: shapePolygon, class: "uncertainty " + string(r.Level), title: r.Reason,
+			points: p.rect(r.BBox.Min[0], r.BBox.Min[2], r.BBox.Max[0], r.BBox.Max[2]),
+			fill:   fill, stroke: edge, width: 1.5, dashed: true,
+		})
+	}
+}
+
+// drawObjects draws object footprints, then walls, then the doors and
+// windows set into them
+func (p *Plan) drawObjects(objects []models.SceneObject, labels bool) {
+	var walls, openings []models.SceneObject
+	for _, obj := range objects {
+		switch obj.Type {
+		case models.ObjectTypeWall:
+			walls = append(walls, obj)
+			continue
+		case models.ObjectTypeDoor, models.ObjectTypeWindow:
+			openings = append(openings, obj)
+			continue
+		}
+
+		fill, edge := objectFill, objectEdge
+		switch obj.Type {
+		case models.ObjectTypeEvidenceItem, models.ObjectTypeWeapon, models.ObjectTypeFootprint, models.ObjectTypeBloodstain:
+			fill = evidenceObj
+		}
+		s := shape{kind: shapePolygon, class: "object " + string(obj.Type), title: obj.Label, fill: fill, stroke: edge, width: 1}
+		switch obj.State {
+		case models.ObjectStateSuspicious:
+			s.stroke, s.width = alertColour, 2
+		case models.ObjectStateOccluded:
+			s.fill.A, s.stroke.A = 0x80, 0x80
+		case models.ObjectStateRemoved:
+			s.fill, s.dashed = color.NRGBA{}, true
+		}
+		c := footprint(obj)
+		s.points = p.rect(c[0][0], c[0][1], c[2][0], c[2][1])
+		p.add(s)
+	}
+
+	for _, w := range walls {
+		if seg, ok := wallSegment(w); ok {
+			thickness := metaFloat(w, "thickness", wallThickness)
+			p.add(shape{
+				kind: shapeLine, class: "wall", title: w.Label,
+				points: []point{p.px(seg[0][0], seg[0][1]), p.px(seg[1][0], seg[1][1])},
+				stroke: wallColour, width: math.Max(thickness*p.Scale, 2),
+			})
+			continue
+		}
+		c := footprint(w)
+		p.add(shape{kind: shapePolygon, class: "wall", title: w.Label, points: p.rect(c[0][0], c[0][1], c[2][0], c[2][1]), fill: wallColour})
+	}
+
+	for _, o := range openings {
+		colour := doorColour
+		if o.Type == models.ObjectTypeWindow {
+			colour = glassColour
+		}
+		c := footprint(o)
+		p.add(shape{
+			kind: shapePolygon, class: string(o.Type), title: o.Label,
+			points: p.rect(c[0][0], c[0][1], c[2][0], c[2][1]), fill: white, stroke: colour, width: 2,
+		})
+	}
+
+	if !labels {
+		return
+	}
+	for _, obj := range objects {
+		if obj.Type == models.ObjectTypeWall {
+			continue
+		}
+		// Small objects are labelled underneath, clear of evidence markers
+		c := footprint(obj)
+		at := p.px((c[0][0]+c[2][0])/2, (c[0][1]+c[2][1])/2)
+		if bottom := p.px(c[2][0], c[2][1])[1]; bottom-at[1] < 2*markerRadius {
+			at[1] = bottom + labelOffset
+		}
+		p.add(shape{kind: shapeText, class: "label", points: []point{at}, text: obj.Label, fill: labelColour})
+	}
+}
+
+func (p *Plan) drawTrajectories(trajectories []models.Trajectory, labels bool) {
+	// Lower-ranked routes go underneath
+	for i := len(trajectories) - 1; i >= 0; i-- {
+		t := trajectories[i]
+		pts := route(t)
+		if len(pts) == 0 {
+			continue
+		}
+		colour := trajectoryColours[i%len(trajectoryColours)]
+		line := make([]point, len(pts))
+		for j, pt := range pts {
+			line[j] = p.px(pt[0], pt[2])
+		}
+		width := 2.0
+		if i == 0 {
+			width = 3
+		}
+		title := fmt.Sprintf("Trajectory %d (%.0f%%)", t.Rank, t.OverallConfidence*100)
+		class := fmt.Sprintf("trajectory rank-%d", t.Rank)
+		if len(line) > 1 {
+			p.add(shape{kind: shapeLine, class: class, title: title, points: line, stroke: colour, width: width, dashed: i > 0})
+			p.add(shape{kind: shapePolygon, class: class, points: arrowhead(line[len(line)-2], line[len(line)-1], width*3+6), fill: colour})
+		}
+		p.add(shape{kind: shapeCircle, class: class, points: line[:1], radius: width + 2, fill: colour})
+		if labels {
+			p.add(shape{kind: shapeText, class: "label", points: []point{{line[0][0], line[0][1] - labelOffset - width}}, text: fmt.Sprintf("#%d", t.Rank), fill: colour})
+		}
+	}
+}
+
+// drawMarkers numbers each evidence card anchored to an object and places
+// its marker at the centre of the footprints of the objects it refers to
+func (p *Plan) drawMarkers(sg *models.SceneGraph) {
+	footprints := map[string][4]point{}
+	for _, obj := range sg.Objects {
+		footprints[obj.ID] = footprint(obj)
+	}
+	for _, ev := range sg.Evidence {
+		minX, minZ := math.Inf(1), math.Inf(1)
+		maxX, maxZ := math.Inf(-1), math.Inf(-1)
+		anchored := false
+		for _, id := range ev.ObjectIDs {
+			if c, ok := footprints[id]; ok {
+				minX, minZ = math.Min(minX, c[0][0]), math.Min(minZ, c[0][1])
+				maxX, maxZ = math.Max(maxX, c[2][0]), math.Max(maxZ, c[2][1])
+				anchored = true
+			}
+		}
+		if !anchored {
+			continue
+		}
+		m := Marker{Number: len(p.Markers) + 1, EvidenceID: ev.ID, Title: ev.Title, Tier: models.EvidenceTierFor(ev.Confidence)}
+		p.Markers = append(p.Markers, m)
+
+		at := p.px((minX+maxX)/2, (minZ+maxZ)/2)
+		p.add(shape{kind: shapeCircle, class: "evidence " + string(m.Tier), title: ev.Title, points: []point{at}, radius: markerRadius, fill: tierColours[m.Tier], stroke: white, width: 2})
+		p.add(shape{kind: shapeText, class: "evidence-number", points: []point{at}, text: fmt.Sprint(m.Number), fill: white})
+	}
+}
+
+// drawScaleBar draws a bar of a round length in the bottom-left margin
+func (p *Plan) drawScaleBar() {
+	meters := niceLength(100 / p.Scale)
+	x, y := margin, float64(p.Height)-margin/2
+	end := x + meters*p.Scale
+	p.add(shape{kind: shapeLine, class: "scale", points: []point{{x, y}, {end, y}}, stroke: labelColour, width: 2})
+	p.add(shape{kind: shapeText, class: "scale", points: []point{{end + 24, y}}, text: fmt.Sprintf("%g m", meters), fill: labelColour})
+}
+
+// rect returns the pixel corners of a floor rectangle
+func (p *Plan) rect(x0, z0, x1, z1 float64) []point {
+	return []point{p.px(x0, z0), p.px(x1, z0), p.px(x1, z1), p.px(x0, z1)}
+}
+
+// arrowhead returns a triangle of the given size pointing along from → to
+func arrowhead(from, to point, size float64) []point {
+	dx, dy := to[0]-from[0], to[1]-from[1]
+	n := math.Hypot(dx, dy)
+	if n == 0 {
+		return nil
+	}
+	dx, dy = dx/n, dy/n
+	back := point{to[0] - dx*size, to[1] - dy*size}
+	return []point{to, {back[0] - dy*size/2, back[1] + dx*size/2}, {back[0] + dy*size/2, back[1] - dx*size/2}}
+}
+
+// niceLength rounds a length in meters down to 1, 2 or 5 times a power of ten
+func niceLength(v float64) float64 {
+	pow := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, f := range []float64{5, 2, 1} {
+		if f*pow <= v {
+			return f * pow
+		}
+	}
+	return pow
+}
+
+// wallSegment reads the floor segment a wall was extracted from
+func wallSegment(obj models.SceneObject) ([2][2]float64, bool) {
+	var seg [2][2]float64
+	v, ok := obj.Metadata["segment"]
+	if !ok {
+		return seg, false
+	}
+	// Metadata holds layout points before storage and plain arrays after
+	data, err := json.Marshal(v)
+	if err != nil || json.Unmarshal(data, &seg) != nil {
+		return seg, false
+	}
+	return seg, seg[0] != seg[1]
+}
+
+// metaFloat reads a number from an object's metadata
+func metaFloat(obj models.SceneObject, key string, fallback float64) float64 {
+	if v, ok := obj.Metadata[key].(float64); ok && v > 0 {
+		return v
+	}
+	return fallback
+}
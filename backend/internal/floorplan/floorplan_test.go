@@ -0,0 +1,235 @@
+package floorplan
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// testScene is a 6 × 4 m room with a door, a table, a knife on the floor,
+// one evidence card on the knife and a region the scan couldn't resolve
+func testScene() *models.SceneGraph {
+	sg := models.NewEmptySceneGraph()
+	sg.Bounds = models.BoundingBox{Min: [3]float64{0, 0, 0}, Max: [3]float64{6, 3, 4}}
+	wall := func(id string, x0, z0, x1, z1 float64) models.SceneObject {
+		return models.SceneObject{
+			ID: id, Type: models.ObjectTypeWall, Label: "Wall " + id, State: models.ObjectStateVisible,
+			BBox:     models.BoundingBox{Min: [3]float64{x0 - 0.1, 0, z0 - 0.1}, Max: [3]float64{x1 + 0.1, 3, z1 + 0.1}},
+			Metadata: map[string]interface{}{"segment": [2][2]float64{{x0, z0}, {x1, z1}}, "thickness": 0.2},
+		}
+	}
+	sg.Objects = []models.SceneObject{
+		wall("n", 0, 0, 6, 0), wall("e", 6, 0, 6, 4), wall("s", 6, 4, 0, 4), wall("w", 0, 4, 0, 0),
+		{ID: "door", Type: models.ObjectTypeDoor, Label: "Door", State: models.ObjectStateVisible,
+			BBox: models.BoundingBox{Min: [3]float64{2, 0, 3.9}, Max: [3]float64{3, 2.1, 4.1}}},
+		{ID: "table", Type: models.ObjectTypeFurniture, Label: "Table", State: models.ObjectStateVisible,
+			BBox: models.BoundingBox{Min: [3]float64{1, 0, 1}, Max: [3]float64{2.5, 0.8, 2}}},
+		{ID: "knife", Type: models.ObjectTypeWeapon, Label: "Knife", State: models.ObjectStateSuspicious,
+			Pose: models.Pose{Position: [3]float64{4.5, 0, 1.5}}},
+	}
+	sg.Evidence = []models.EvidenceCard{
+		{ID: "ev-1", ObjectIDs: []string{"knife"}, Title: "Knife <bloodied>", Confidence: 0.9},
+		{ID: "ev-2", ObjectIDs: []string{"missing"}, Title: "Unplaced", Confidence: 0.4},
+	}
+	sg.UncertaintyRegions = []models.UncertaintyRegion{
+		{ID: "u1", BBox: models.BoundingBox{Min: [3]float64{4, 0, 2.5}, Max: [3]float64{5.5, 2, 3.5}}, Level: models.UncertaintyLevelHigh, Reason: "Occluded by shelving"},
+	}
+	return sg
+}
+
+func testTrajectories() []models.Trajectory {
+	return []models.Trajectory{
+		{ID: "t1", Rank: 1, OverallConfidence: 0.7, Segments: []models.TrajectorySegment{
+			{FromPosition: [3]float64{2.5, 0, 4.5}, ToPosition: [3]float64{4.5, 0, 1.5}, Waypoints: [][3]float64{{3.5, 0, 3}}},
+			{FromPosition: [3]float64{4.5, 0, 1.5}, ToPosition: [3]float64{2.5, 0, 4.5}},
+		}},
+		{ID: "t2", Rank: 2, OverallConfidence: 0.3, Segments: []models.TrajectorySegment{
+			{FromPosition: [3]float64{2.5, 0, 4.5}, ToPosition: [3]float64{1, 0, 3}},
+		}},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	p, err := Build(testScene(), testTrajectories(), DefaultOptions())
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	// Walls are 0.2 m thick and the trajectories reach z = 4.5, so the plan
+	// covers 6.2 × 4.6 m
+	if p.Width != DefaultWidth || math.Abs(p.Scale-(DefaultWidth-2*margin)/6.2) > 1e-9 || math.Abs(float64(p.Height)-(4.6*p.Scale+2*margin)) >= 1 {
+		t.Errorf("Build() size = %d × %d at %g px/m", p.Width, p.Height, p.Scale)
+	}
+	if len(p.Markers) != 1 || p.Markers[0].Number != 1 || p.Markers[0].EvidenceID != "ev-1" || p.Markers[0].Tier != models.EvidenceTierHigh {
+		t.Errorf("Markers = %+v, want only the anchored card", p.Markers)
+	}
+
+	counts := map[string]int{}
+	for _, s := range p.shapes {
+		counts[strings.Fields(s.class + " x")[0]]++
+	}
+	// Two routes draw a line, an arrowhead and a start dot each
+	if counts["wall"] != 4 || counts["door"] != 1 || counts["object"] != 2 || counts["uncertainty"] != 1 || counts["evidence"] != 1 || counts["trajectory"] != 6 {
+		t.Errorf("shape counts = %v", counts)
+	}
+
+	// The knife has no box, so it gets a small square around its position
+	for _, s := range p.shapes {
+		if s.class == "object weapon" {
+			c := p.px(4.5, 1.5)
+			if s.points[0][0] >= c[0] || s.points[2][0] <= c[0] || s.stroke != alertColour {
+				t.Errorf("knife footprint = %v, stroke %v", s.points, s.stroke)
+			}
+		}
+	}
+}
+
+func TestBuild_Options(t *testing.T) {
+	opts := Options{Width: 400}
+	p, err := Build(testScene(), testTrajectories(), opts)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	for _, s := range p.shapes {
+		if strings.HasPrefix(s.class, "trajectory") || s.class == "label" {
+			t.Fatalf("unexpected %s shape with trajectories and labels off", s.class)
+		}
+	}
+	if math.Abs(float64(p.Height)-(4.2*p.Scale+2*margin)) >= 1 {
+		t.Errorf("Height = %d, want the room's 4.2 m without trajectories", p.Height)
+	}
+
+	if _, err := Build(testScene(), nil, Options{Width: 50}); err == nil {
+		t.Error("Build() accepted a width below the minimum")
+	}
+	if _, err := Build(models.NewEmptySceneGraph(), nil, DefaultOptions()); !errors.Is(err, ErrEmptyScene) {
+		t.Errorf("Build() empty scene error = %v, want ErrEmptyScene", err)
+	}
+	if _, err := Build(nil, nil, DefaultOptions()); !errors.Is(err, ErrEmptyScene) {
+		t.Errorf("Build() nil scene error = %v, want ErrEmptyScene", err)
+	}
+}
+
+func TestBuild_TallScene(t *testing.T) {
+	sg := models.NewEmptySceneGraph()
+	sg.Objects = []models.SceneObject{{ID: "hall", Type: models.ObjectTypeOther, Label: "Hall", State: models.ObjectStateVisible,
+		BBox: models.BoundingBox{Min: [3]float64{0, 0, 0}, Max: [3]float64{1, 1, 30}}}}
+	p, err := Build(sg, nil, DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if max := int((DefaultWidth-2*margin)*maxAspect + 2*margin); p.Height > max+1 {
+		t.Errorf("Height = %d, want at most %d", p.Height, max)
+	}
+	// The narrow hall is centred rather than drawn against the left edge
+	for _, s := range p.shapes {
+		if s.class == "object other" {
+			if mid := (s.points[0][0] + s.points[1][0]) / 2; mid < float64(p.Width)/2-1 || mid > float64(p.Width)/2+1 {
+				t.Errorf("hall centre x = %g, want %d", mid, p.Width/2)
+			}
+		}
+	}
+}
+
+func TestWallSegment(t *testing.T) {
+	// Segments read back from stored JSON are plain arrays
+	var stored models.SceneObject
+	data, _ := json.Marshal(testScene().Objects[0])
+	json.Unmarshal(data, &stored)
+	seg, ok := wallSegment(stored)
+	if !ok || seg != [2][2]float64{{0, 0}, {6, 0}} {
+		t.Errorf("wallSegment() = %v, %v", seg, ok)
+	}
+	if _, ok := wallSegment(models.SceneObject{}); ok {
+		t.Error("wallSegment() found a segment without metadata")
+	}
+}
+
+func TestSVG(t *testing.T) {
+	p, err := Build(testScene(), testTrajectories(), DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := p.SVG()
+
+	// Well-formed XML, with text escaped
+	dec := xml.NewDecoder(bytes.NewReader(out))
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("SVG is not well-formed: %v\n%s", err, out)
+		}
+	}
+	s := string(out)
+	for _, want := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" width="800"`,
+		`class="evidence high"`,
+		`<title>Knife &lt;bloodied&gt;</title>`,
+		`class="uncertainty high"`,
+		`fill-opacity=`,
+		`stroke-dasharray=`,
+		`class="trajectory rank-1"`,
+		`class="scale"`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("SVG missing %s", want)
+		}
+	}
+}
+
+func TestPNG(t *testing.T) {
+	p, err := Build(testScene(), testTrajectories(), DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := p.PNG()
+	if err != nil {
+		t.Fatalf("PNG() error = %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("PNG() output doesn't decode: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != p.Width || b.Dy() != p.Height {
+		t.Errorf("PNG size = %v, want %d × %d", b, p.Width, p.Height)
+	}
+
+	// A wall's middle is wall-coloured and the room's empty corner isn't
+	mid := p.px(3, 0)
+	if r, g, b, _ := img.At(int(mid[0]), int(mid[1])).RGBA(); r>>8 != uint32(wallColour.R) || g>>8 != uint32(wallColour.G) || b>>8 != uint32(wallColour.B) {
+		t.Errorf("wall pixel = %v, want %v", img.At(int(mid[0]), int(mid[1])), wallColour)
+	}
+	// The knife has no box, so its marker sits on its position
+	marker := p.px(4.5, 1.5)
+	if r, g, b, _ := img.At(int(marker[0])+5, int(marker[1])).RGBA(); r>>8 != 0x16 || g>>8 != 0xa3 || b>>8 != 0x4a {
+		t.Errorf("marker pixel = %v, want the high-tier green", img.At(int(marker[0])+5, int(marker[1])))
+	}
+}
+
+func TestDashes(t *testing.T) {
+	// An L of 10 + 10 split into dashes of 5 with gaps of 2
+	got := dashes([]point{{0, 0}, {10, 0}, {10, 10}}, 5, 2)
+	if len(got) != 3 {
+		t.Fatalf("dashes() = %v, want 3 dashes", got)
+	}
+	// The second dash turns the corner
+	if d := got[1]; len(d) != 3 || d[0] != (point{7, 0}) || d[1] != (point{10, 0}) || d[2] != (point{10, 2}) {
+		t.Errorf("second dash = %v", got[1])
+	}
+}
+
+func TestNiceLength(t *testing.T) {
+	for v, want := range map[float64]float64{0.7: 0.5, 1: 1, 1.9: 1, 3: 2, 7.5: 5, 42: 20} {
+		if got := niceLength(v); got != want {
+			t.Errorf("niceLength(%g) = %g, want %g", v, got, want)
+		}
+	}
+}
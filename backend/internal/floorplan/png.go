@@ -0,0 +1,209 @@
+package floorplan
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// PNGContentType is the media type of PNG output
+const PNGContentType = "image/png"
+
+// circleSegments is how many sides approximate a circle
+const circleSegments = 24
+
+// PNG renders the plan as an anti-aliased PNG image
+func (p *Plan) PNG() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, p.Image()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Image rasterizes the plan
+func (p *Plan) Image() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, p.Width, p.Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	z := vector.NewRasterizer(p.Width, p.Height)
+
+	fill := func(c color.NRGBA, polys ...[]point) {
+		if c.A == 0 || len(polys) == 0 {
+			return
+		}
+		z.Reset(p.Width, p.Height)
+		for _, poly := range polys {
+			addPolygon(z, poly)
+		}
+		z.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{})
+	}
+
+	for _, s := range p.shapes {
+		switch s.kind {
+		case shapePolygon:
+			fill(s.fill, s.points)
+			if s.stroke.A > 0 {
+				fill(s.stroke, strokePolys(s.points, s.width, true, s.dashed)...)
+			}
+		case shapeLine:
+			fill(s.stroke, strokePolys(s.points, s.width, false, s.dashed)...)
+		case shapeCircle:
+			if s.stroke.A > 0 {
+				fill(s.stroke, circle(s.points[0], s.radius+s.width/2))
+			}
+			fill(s.fill, circle(s.points[0], s.radius))
+		case shapeText:
+			drawText(img, s)
+		}
+	}
+	return img
+}
+
+// addPolygon adds a closed path, always wound the same way so overlapping
+// pieces of one stroke add up instead of cancelling out
+func addPolygon(z *vector.Rasterizer, poly []point) {
+	if len(poly) < 3 {
+		return
+	}
+	if signedArea(poly) < 0 {
+		rev := make([]point, len(poly))
+		for i, pt := range poly {
+			rev[len(poly)-1-i] = pt
+		}
+		poly = rev
+	}
+	z.MoveTo(float32(poly[0][0]), float32(poly[0][1]))
+	for _, pt := range poly[1:] {
+		z.LineTo(float32(pt[0]), float32(pt[1]))
+	}
+	z.ClosePath()
+}
+
+func signedArea(poly []point) float64 {
+	var a float64
+	for i, pt := range poly {
+		next := poly[(i+1)%len(poly)]
+		a += pt[0]*next[1] - next[0]*pt[1]
+	}
+	return a / 2
+}
+
+// strokePolys outlines a polyline as a quad per segment with round joins
+// and caps. Dashed lines are split into dashes first.
+func strokePolys(pts []point, width float64, closed, dashed bool) [][]point {
+	if closed && len(pts) > 1 {
+		pts = append(append([]point{}, pts...), pts[0])
+	}
+	runs := [][]point{pts}
+	if dashed {
+		runs = dashes(pts, width*3, width*2)
+	}
+
+	var polys [][]point
+	half := width / 2
+	for _, run := range runs {
+		for i, pt := range run {
+			polys = append(polys, circle(pt, half))
+			if i == 0 {
+				continue
+			}
+			prev := run[i-1]
+			dx, dy := pt[0]-prev[0], pt[1]-prev[1]
+			n := math.Hypot(dx, dy)
+			if n == 0 {
+				continue
+			}
+			nx, ny := -dy/n*half, dx/n*half
+			polys = append(polys, []point{
+				{prev[0] + nx, prev[1] + ny}, {pt[0] + nx, pt[1] + ny},
+				{pt[0] - nx, pt[1] - ny}, {prev[0] - nx, prev[1] - ny},
+			})
+		}
+	}
+	return polys
+}
+
+// dashes splits a polyline into dashes of length on separated by gaps of
+// length off, carrying the pattern across corners
+func dashes(pts []point, on, off float64) [][]point {
+	if on <= 0 || off <= 0 {
+		return [][]point{pts}
+	}
+	var out [][]point
+	var cur []point
+	drawing, left := true, on
+	for i := 1; i < len(pts); i++ {
+		a, b := pts[i-1], pts[i]
+		length := math.Hypot(b[0]-a[0], b[1]-a[1])
+		pos := 0.0
+		for pos < length {
+			at := func(t float64) point {
+				return point{a[0] + (b[0]-a[0])*t/length, a[1] + (b[1]-a[1])*t/length}
+			}
+			step := math.Min(left, length-pos)
+			if drawing {
+				if len(cur) == 0 {
+					cur = append(cur, at(pos))
+				}
+				cur = append(cur, at(pos+step))
+			}
+			pos += step
+			left -= step
+			if left <= 0 {
+				if drawing {
+					out = append(out, cur)
+					cur = nil
+					left = off
+				} else {
+					left = on
+				}
+				drawing = !drawing
+			}
+		}
+	}
+	if len(cur) > 1 {
+		out = append(out, cur)
+	}
+	return out
+}
+
+// circle approximates a circle as a polygon
+func circle(c point, r float64) []point {
+	pts := make([]point, circleSegments)
+	for i := range pts {
+		a := 2 * math.Pi * float64(i) / circleSegments
+		pts[i] = point{c[0] + r*math.Cos(a), c[1] + r*math.Sin(a)}
+	}
+	return pts
+}
+
+// drawText centres a label on its point. Labels and the scale get a white
+// backing so they stay legible over lines.
+func drawText(img *image.RGBA, s shape) {
+	face := basicfont.Face7x13
+	width := font.MeasureString(face, s.text).Ceil()
+	metrics := face.Metrics()
+	height := (metrics.Ascent + metrics.Descent).Ceil()
+	x := int(math.Round(s.points[0][0])) - width/2
+	top := int(math.Round(s.points[0][1])) - height/2
+
+	if s.class == "label" || s.class == "scale" {
+		back := image.Rect(x-2, top-1, x+width+2, top+height+1)
+		draw.Draw(img, back, image.NewUniform(color.NRGBA{0xff, 0xff, 0xff, 0xcc}), image.Point{}, draw.Over)
+	}
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(s.fill),
+		Face: face,
+		Dot:  fixed.P(x, top+metrics.Ascent.Ceil()),
+	}
+	d.DrawString(s.text)
+}
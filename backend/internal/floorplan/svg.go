@@ -0,0 +1,106 @@
+package floorplan
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// SVGContentType is the media type of SVG output
+const SVGContentType = "image/svg+xml"
+
+// SVG renders the plan as a standalone SVG document. Elements carry classes
+// such as "wall", "evidence high" or "trajectory rank-1", and titles that
+// show as tooltips, so the evidence board can style and inspect them.
+func (p *Plan) SVG() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Helvetica, Arial, sans-serif" font-size="11">`,
+		p.Width, p.Height, p.Width, p.Height)
+	buf.WriteString("\n")
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%"%s/>`+"\n", paint("fill", background))
+
+	for _, s := range p.shapes {
+		var el string
+		attrs := ""
+		switch s.kind {
+		case shapePolygon:
+			el = "polygon"
+			attrs = fmt.Sprintf(` points="%s"`, svgPoints(s.points))
+		case shapeLine:
+			el = "polyline"
+			attrs = fmt.Sprintf(` points="%s" fill="none" stroke-linecap="round" stroke-linejoin="round"`, svgPoints(s.points))
+		case shapeCircle:
+			el = "circle"
+			attrs = fmt.Sprintf(` cx="%s" cy="%s" r="%s"`, num(s.points[0][0]), num(s.points[0][1]), num(s.radius))
+		case shapeText:
+			el = "text"
+			attrs = fmt.Sprintf(` x="%s" y="%s" text-anchor="middle" dominant-baseline="central"`, num(s.points[0][0]), num(s.points[0][1]))
+			if s.class == "label" || s.class == "scale" {
+				attrs += ` stroke="#ffffff" stroke-width="3" paint-order="stroke"`
+			}
+		}
+		if s.class != "" {
+			attrs = fmt.Sprintf(` class="%s"`, escape(s.class)) + attrs
+		}
+		if s.kind != shapeLine {
+			attrs += paint("fill", s.fill)
+		}
+		if s.stroke.A > 0 {
+			attrs += paint("stroke", s.stroke) + fmt.Sprintf(` stroke-width="%s"`, num(s.width))
+			if s.dashed {
+				attrs += fmt.Sprintf(` stroke-dasharray="%s %s"`, num(s.width*3), num(s.width*2))
+			}
+		}
+
+		switch {
+		case s.kind == shapeText:
+			fmt.Fprintf(&buf, "<%s%s>%s</%s>\n", el, attrs, escape(s.text), el)
+		case s.title != "":
+			fmt.Fprintf(&buf, "<%s%s><title>%s</title></%s>\n", el, attrs, escape(s.title), el)
+		default:
+			fmt.Fprintf(&buf, "<%s%s/>\n", el, attrs)
+		}
+	}
+	buf.WriteString("</svg>\n")
+	return buf.Bytes()
+}
+
+// paint returns a fill or stroke attribute, with its opacity when the
+// colour is translucent
+func paint(attr string, c color.NRGBA) string {
+	if c.A == 0 {
+		return fmt.Sprintf(` %s="none"`, attr)
+	}
+	s := fmt.Sprintf(` %s="#%02x%02x%02x"`, attr, c.R, c.G, c.B)
+	if c.A < 0xff {
+		s += fmt.Sprintf(` %s-opacity="%s"`, attr, num(float64(c.A)/0xff))
+	}
+	return s
+}
+
+func svgPoints(pts []point) string {
+	parts := make([]string, len(pts))
+	for i, pt := range pts {
+		parts[i] = num(pt[0]) + "," + num(pt[1])
+	}
+	return strings.Join(parts, " ")
+}
+
+// num formats a coordinate to two decimal places without trailing zeros
+func num(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+func escape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,614 @@
+// Package gltf exports a scene as a single glTF 2.0 binary (GLB) for Blender
+// and courtroom presentation tools. Each object becomes a node at its pose
+// with proxy box geometry for its bounding box; evidence cards become markers
+// above the objects they refer to, and trajectories become line strips.
+// Generated models are referenced by storage key in node and scene extras
+// rather than embedded, so the file stays small.
+package gltf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// ContentType is the media type of GLB output
+const ContentType = "model/gltf-binary"
+
+// FileExtension is the extension of GLB files
+const FileExtension = "glb"
+
+const (
+	minSize      = 0.3  // meters, the proxy cube for objects placed without a box
+	markerSize   = 0.12 // meters, the radius of an evidence marker
+	markerHeight = 0.25 // meters between the top of an object and its marker
+)
+
+// Input is everything written to an exported scene
+type Input struct {
+	Scene        *models.SceneGraph
+	CommitID     uuid.UUID           // the commit the scene is as of, if any
+	Trajectories []models.Trajectory // drawn in rank order
+	Models       []models.Asset      // evidence_model assets to reference
+}
+
+// Colours as linear RGBA factors
+var (
+	wallColour     = [4]float64{0.35, 0.37, 0.40, 1}
+	doorColour     = [4]float64{0.45, 0.20, 0.02, 1}
+	glassColour    = [4]float64{0.04, 0.50, 0.93, 0.4}
+	objectColour   = [4]float64{0.64, 0.66, 0.70, 1}
+	evidenceColour = [4]float64{0.98, 0.79, 0.25, 1}
+	alertColour    = [4]float64{0.72, 0.02, 0.02, 1}
+
+	tierColours = map[models.EvidenceTier][4]float64{
+		models.EvidenceTierHigh:   {0.01, 0.37, 0.07, 1},
+		models.EvidenceTierMedium: {0.69, 0.18, 0.00, 1},
+		models.EvidenceTierLow:    {0.72, 0.02, 0.02, 1},
+	}
+	uncertaintyColours = map[models.UncertaintyLevel][4]float64{
+		models.UncertaintyLevelLow:    {0.96, 0.52, 0.02, 0.2},
+		models.UncertaintyLevelMedium: {0.95, 0.17, 0.01, 0.25},
+		models.UncertaintyLevelHigh:   {0.86, 0.06, 0.06, 0.3},
+	}
+	// trajectoryColours are used in rank order, repeating after the last
+	trajectoryColours = [][4]float64{
+		{0.02, 0.12, 0.83, 1},
+		{0.29, 0.03, 0.82, 1},
+		{0.00, 0.30, 0.25, 1},
+		{0.71, 0.02, 0.18, 1},
+	}
+)
+
+// Encode writes the scene as a GLB file
+func Encode(in Input) ([]byte, error) {
+	sg := in.Scene
+	if sg == nil {
+		sg = models.NewEmptySceneGraph()
+	}
+	b := &builder{materials: map[string]int{}}
+
+	root := node{Name: "Scene", Extras: b.sceneExtras(sg, in)}
+	groups := []struct {
+		name     string
+		children []int
+	}{
+		{"Objects", b.addObjects(sg.Objects, in.Models)},
+		{"Evidence", b.addMarkers(sg)},
+		{"Uncertainty", b.addUncertainty(sg.UncertaintyRegions)},
+		{"Trajectories", b.addTrajectories(in.Trajectories)},
+	}
+	for _, g := range groups {
+		if len(g.children) > 0 {
+			root.Children = append(root.Children, b.addNode(node{Name: g.name, Children: g.children}))
+		}
+	}
+	rootIndex := b.addNode(root)
+
+	b.doc.Asset = asset{Version: "2.0", Generator: "SherlockOS"}
+	b.doc.Scene = 0
+	b.doc.Scenes = []scene{{Name: "Scene", Nodes: []int{rootIndex}}}
+	return b.glb()
+}
+
+// sceneExtras records the commit, bounds and every generated model, whether
+// or not an object refers to it
+func (b *builder) sceneExtras(sg *models.SceneGraph, in Input) map[string]interface{} {
+	extras := map[string]interface{}{
+		"bounds": map[string]interface{}{"min": sg.Bounds.Min, "max": sg.Bounds.Max},
+	}
+	if in.CommitID != uuid.Nil {
+		extras["commit_id"] = in.CommitID.String()
+	}
+	if len(in.Models) > 0 {
+		refs := make([]map[string]interface{}, len(in.Models))
+		for i, a := range in.Models {
+			ref := map[string]interface{}{"asset_id": a.ID.String(), "storage_key": a.StorageKey}
+			for _, key := range []string{"item_type", "description", "format"} {
+				if v, ok := a.Metadata[key].(string); ok && v != "" {
+					ref[key] = v
+				}
+			}
+			refs[i] = ref
+		}
+		extras["evidence_models"] = refs
+	}
+	return extras
+}
+
+// addObjects adds a node per object at its pose, with a proxy box child
+func (b *builder) addObjects(objects []models.SceneObject, assets []models.Asset) []int {
+	byKey := map[string]models.Asset{}
+	for _, a := range assets {
+		byKey[a.StorageKey] = a
+		byKey[a.ID.String()] = a
+	}
+
+	var nodes []int
+	for _, obj := range objects {
+		extras := map[string]interface{}{
+			"id":         obj.ID,
+			"type":       string(obj.Type),
+			"state":      string(obj.State),
+			"confidence": obj.Confidence,
+		}
+		if len(obj.EvidenceIDs) > 0 {
+			extras["evidence_ids"] = obj.EvidenceIDs
+		}
+		if obj.MeshRef != "" {
+			extras["mesh_ref"] = obj.MeshRef
+			if a, ok := byKey[obj.MeshRef]; ok {
+				extras["mesh_asset_id"] = a.ID.String()
+				extras["mesh_storage_key"] = a.StorageKey
+			}
+		}
+
+		n := node{Name: obj.Label, Extras: extras}
+		n.Translation, n.Rotation = transform(obj.Pose)
+		if s := obj.Pose.Scale; s != [3]float64{} && s != [3]float64{1, 1, 1} {
+			extras["scale"] = s
+		}
+
+		// The box is in world space, so its corners are brought into the
+		// node's frame; the pose's scale isn't applied to the node because
+		// the box already has the object's size
+		lo, hi := objectBox(obj)
+		corners := boxCorners(lo, hi)
+		for i, c := range corners {
+			corners[i] = toLocal(obj.Pose, c)
+		}
+		mesh := b.addMesh(obj.Label, corners, boxIndices, modeTriangles, b.objectMaterial(obj))
+		proxy := b.addNode(node{Name: obj.Label + " (proxy)", Mesh: &mesh})
+		n.Children = []int{proxy}
+		nodes = append(nodes, b.addNode(n))
+	}
+	return nodes
+}
+
+// objectMaterial picks a material by the object's type and state
+func (b *builder) objectMaterial(obj models.SceneObject) int {
+	name, colour := "object", objectColour
+	switch obj.Type {
+	case models.ObjectTypeWall:
+		name, colour = "wall", wallColour
+	case models.ObjectTypeDoor:
+		name, colour = "door", doorColour
+	case models.ObjectTypeWindow:
+		name, colour = "window", glassColour
+	case models.ObjectTypeEvidenceItem, models.ObjectTypeWeapon, models.ObjectTypeFootprint, models.ObjectTypeBloodstain:
+		name, colour = "evidence item", evidenceColour
+	}
+	switch obj.State {
+	case models.ObjectStateSuspicious:
+		name, colour = "suspicious", alertColour
+	case models.ObjectStateOccluded:
+		name, colour[3] = name+" (occluded)", colour[3]*0.5
+	case models.ObjectStateRemoved:
+		name, colour[3] = name+" (removed)", colour[3]*0.2
+	}
+	return b.material(name, colour)
+}
+
+// addMarkers adds a marker above the objects each evidence card refers to,
+// numbered in card order like the floor plan's markers
+func (b *builder) addMarkers(sg *models.SceneGraph) []int {
+	boxes := map[string][2][3]float64{}
+	for _, obj := range sg.Objects {
+		lo, hi := objectBox(obj)
+		boxes[obj.ID] = [2][3]float64{lo, hi}
+	}
+
+	var nodes []int
+	meshes := map[models.EvidenceTier]int{}
+	for _, ev := range sg.Evidence {
+		lo := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+		hi := [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+		anchored := false
+		for _, id := range ev.ObjectIDs {
+			if box, ok := boxes[id]; ok {
+				for i := 0; i < 3; i++ {
+					lo[i], hi[i] = math.Min(lo[i], box[0][i]), math.Max(hi[i], box[1][i])
+				}
+				anchored = true
+			}
+		}
+		if !anchored {
+			continue
+		}
+
+		tier := models.EvidenceTierFor(ev.Confidence)
+		mesh, ok := meshes[tier]
+		if !ok {
+			mesh = b.addMesh("Evidence marker ("+string(tier)+")", markerVertices, markerIndices, modeTriangles,
+				b.material("evidence "+string(tier), tierColours[tier]))
+			meshes[tier] = mesh
+		}
+		number := len(nodes) + 1
+		nodes = append(nodes, b.addNode(node{
+			Name:        fmt.Sprintf("%d. %s", number, ev.Title),
+			Mesh:        &mesh,
+			Translation: []float64{(lo[0] + hi[0]) / 2, hi[1] + markerHeight, (lo[2] + hi[2]) / 2},
+			Extras: map[string]interface{}{
+				"number":      number,
+				"evidence_id": ev.ID,
+				"object_ids":  ev.ObjectIDs,
+				"confidence":  ev.Confidence,
+				"tier":        string(tier),
+			},
+		}))
+	}
+	return nodes
+}
+
+// addUncertainty adds a translucent box per uncertainty region
+func (b *builder) addUncertainty(regions []models.UncertaintyRegion) []int {
+	var nodes []int
+	for _, u := range regions {
+		colour, ok := uncertaintyColours[u.Level]
+		if !ok {
+			colour = uncertaintyColours[models.UncertaintyLevelMedium]
+		}
+		lo, hi := ordered(u.BBox)
+		mesh := b.addMesh("Uncertainty "+u.ID, boxCorners(lo, hi), boxIndices, modeTriangles,
+			b.material("uncertainty "+string(u.Level), colour))
+		nodes = append(nodes, b.addNode(node{
+			Name:   "Uncertainty " + u.ID,
+			Mesh:   &mesh,
+			Extras: map[string]interface{}{"id": u.ID, "level": string(u.Level), "reason": u.Reason},
+		}))
+	}
+	return nodes
+}
+
+// addTrajectories adds a line strip per trajectory along its segments and
+// waypoints
+func (b *builder) addTrajectories(trajectories []models.Trajectory) []int {
+	sorted := append([]models.Trajectory(nil), trajectories...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Rank < sorted[j].Rank })
+
+	var nodes []int
+	for i, t := range sorted {
+		pts := route(t)
+		if len(pts) < 2 {
+			continue
+		}
+		indices := make([]uint32, len(pts))
+		for j := range indices {
+			indices[j] = uint32(j)
+		}
+		name := fmt.Sprintf("Trajectory #%d", t.Rank)
+		mesh := b.addMesh(name, pts, indices, modeLineStrip,
+			b.material(fmt.Sprintf("trajectory %d", i%len(trajectoryColours)+1), trajectoryColours[i%len(trajectoryColours)]))
+		nodes = append(nodes, b.addNode(node{
+			Name:   name,
+			Mesh:   &mesh,
+			Extras: map[string]interface{}{"id": t.ID, "rank": t.Rank, "confidence": t.OverallConfidence},
+		}))
+	}
+	return nodes
+}
+
+// route is the path a trajectory traces: each segment's start, waypoints
+// and end, without repeating points shared by consecutive segments
+func route(t models.Trajectory) [][3]float64 {
+	var pts [][3]float64
+	for _, seg := range t.Segments {
+		for _, pt := range append(append([][3]float64{seg.FromPosition}, seg.Waypoints...), seg.ToPosition) {
+			if n := len(pts); n > 0 && pts[n-1] == pt {
+				continue
+			}
+			pts = append(pts, pt)
+		}
+	}
+	return pts
+}
+
+// objectBox returns an object's world-space box, or a small cube resting on
+// its position when it has none
+func objectBox(obj models.SceneObject) (lo, hi [3]float64) {
+	if obj.BBox.Min == obj.BBox.Max {
+		p := obj.Pose.Position
+		return [3]float64{p[0] - minSize/2, p[1], p[2] - minSize/2}, [3]float64{p[0] + minSize/2, p[1] + minSize, p[2] + minSize/2}
+	}
+	return ordered(obj.BBox)
+}
+
+func ordered(bb models.BoundingBox) (lo, hi [3]float64) {
+	for i := 0; i < 3; i++ {
+		lo[i], hi[i] = math.Min(bb.Min[i], bb.Max[i]), math.Max(bb.Min[i], bb.Max[i])
+	}
+	return lo, hi
+}
+
+// transform converts a pose to glTF translation and rotation. Poses store
+// quaternions as [w, x, y, z] and glTF as [x, y, z, w]; a zero or identity
+// rotation is left out.
+func transform(p models.Pose) (translation, rotation []float64) {
+	if p.Position != [3]float64{} {
+		translation = p.Position[:]
+	}
+	if q, ok := unitQuat(p.Rotation); ok && q != [4]float64{1, 0, 0, 0} {
+		rotation = []float64{q[1], q[2], q[3], q[0]}
+	}
+	return translation, rotation
+}
+
+// unitQuat normalises a [w, x, y, z] quaternion, reporting false for zero
+func unitQuat(q [4]float64) ([4]float64, bool) {
+	n := math.Sqrt(q[0]*q[0] + q[1]*q[1] + q[2]*q[2] + q[3]*q[3])
+	if n == 0 {
+		return q, false
+	}
+	return [4]float64{q[0] / n, q[1] / n, q[2] / n, q[3] / n}, true
+}
+
+// toLocal brings a world-space point into a pose's frame: the inverse of its
+// translation, then of its rotation
+func toLocal(p models.Pose, v [3]float64) [3]float64 {
+	d := [3]float64{v[0] - p.Position[0], v[1] - p.Position[1], v[2] - p.Position[2]}
+	q, ok := unitQuat(p.Rotation)
+	if !ok {
+		return d
+	}
+	// Rotating by the conjugate undoes the rotation
+	w, u := q[0], [3]float64{-q[1], -q[2], -q[3]}
+	t := cross(u, d)
+	for i := range t {
+		t[i] *= 2
+	}
+	c := cross(u, t)
+	return [3]float64{d[0] + w*t[0] + c[0], d[1] + w*t[1] + c[1], d[2] + w*t[2] + c[2]}
+}
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+// boxCorners lists a box's corners: the bottom face, then the top face
+func boxCorners(lo, hi [3]float64) [][3]float64 {
+	return [][3]float64{
+		{lo[0], lo[1], lo[2]}, {hi[0], lo[1], lo[2]}, {hi[0], lo[1], hi[2]}, {lo[0], lo[1], hi[2]},
+		{lo[0], hi[1], lo[2]}, {hi[0], hi[1], lo[2]}, {hi[0], hi[1], hi[2]}, {lo[0], hi[1], hi[2]},
+	}
+}
+
+// boxIndices are the twelve triangles of a box from boxCorners, wound
+// counter-clockwise seen from outside
+var boxIndices = []uint32{
+	0, 1, 2, 0, 2, 3, // bottom
+	4, 7, 6, 4, 6, 5, // top
+	0, 4, 5, 0, 5, 1, // front (-Z)
+	3, 2, 6, 3, 6, 7, // back (+Z)
+	0, 3, 7, 0, 7, 4, // left (-X)
+	1, 5, 6, 1, 6, 2, // right (+X)
+}
+
+// markerVertices and markerIndices are an octahedron centred on the origin
+var (
+	markerVertices = [][3]float64{
+		{markerSize, 0, 0}, {-markerSize, 0, 0},
+		{0, markerSize, 0}, {0, -markerSize, 0},
+		{0, 0, markerSize}, {0, 0, -markerSize},
+	}
+	markerIndices = []uint32{
+		0, 2, 4, 4, 2, 1, 1, 2, 5, 5, 2, 0,
+		4, 3, 0, 1, 3, 4, 5, 3, 1, 0, 3, 5,
+	}
+)
+
+// ============================================
+// DOCUMENT
+// ============================================
+
+// Primitive modes and component types from the glTF specification
+const (
+	modeLineStrip = 3
+	modeTriangles = 4
+
+	componentFloat  = 5126
+	componentUint32 = 5125
+
+	targetArrayBuffer        = 34962
+	targetElementArrayBuffer = 34963
+)
+
+type document struct {
+	Asset       asset        `json:"asset"`
+	Scene       int          `json:"scene"`
+	Scenes      []scene      `json:"scenes"`
+	Nodes       []node       `json:"nodes,omitempty"`
+	Meshes      []mesh       `json:"meshes,omitempty"`
+	Materials   []material   `json:"materials,omitempty"`
+	Accessors   []accessor   `json:"accessors,omitempty"`
+	BufferViews []bufferView `json:"bufferViews,omitempty"`
+	Buffers     []buffer     `json:"buffers,omitempty"`
+}
+
+type asset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator,omitempty"`
+}
+
+type scene struct {
+	Name  string `json:"name,omitempty"`
+	Nodes []int  `json:"nodes"`
+}
+
+type node struct {
+	Name        string                 `json:"name,omitempty"`
+	Mesh        *int                   `json:"mesh,omitempty"`
+	Children    []int                  `json:"children,omitempty"`
+	Translation []float64              `json:"translation,omitempty"`
+	Rotation    []float64              `json:"rotation,omitempty"`
+	Extras      map[string]interface{} `json:"extras,omitempty"`
+}
+
+type mesh struct {
+	Name       string      `json:"name,omitempty"`
+	Primitives []primitive `json:"primitives"`
+}
+
+type primitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    int            `json:"indices"`
+	Material   int            `json:"material"`
+	Mode       int            `json:"mode"`
+}
+
+type material struct {
+	Name                 string `json:"name"`
+	PBRMetallicRoughness pbr    `json:"pbrMetallicRoughness"`
+	AlphaMode            string `json:"alphaMode,omitempty"`
+	DoubleSided          bool   `json:"doubleSided,omitempty"`
+}
+
+type pbr struct {
+	BaseColorFactor [4]float64 `json:"baseColorFactor"`
+	MetallicFactor  float64    `json:"metallicFactor"`
+	RoughnessFactor float64    `json:"roughnessFactor"`
+}
+
+type accessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float64 `json:"min,omitempty"`
+	Max           []float64 `json:"max,omitempty"`
+}
+
+type bufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target,omitempty"`
+}
+
+type buffer struct {
+	ByteLength int `json:"byteLength"`
+}
+
+// builder accumulates the document and its binary buffer
+type builder struct {
+	doc       document
+	bin       bytes.Buffer
+	materials map[string]int
+}
+
+func (b *builder) addNode(n node) int {
+	b.doc.Nodes = append(b.doc.Nodes, n)
+	return len(b.doc.Nodes) - 1
+}
+
+// material returns the index of the named material, adding it the first
+// time. Translucent colours are blended and drawn from both sides.
+func (b *builder) material(name string, colour [4]float64) int {
+	if i, ok := b.materials[name]; ok {
+		return i
+	}
+	m := material{Name: name, PBRMetallicRoughness: pbr{BaseColorFactor: colour, RoughnessFactor: 0.9}}
+	if colour[3] < 1 {
+		m.AlphaMode, m.DoubleSided = "BLEND", true
+	}
+	b.doc.Materials = append(b.doc.Materials, m)
+	b.materials[name] = len(b.doc.Materials) - 1
+	return b.materials[name]
+}
+
+// addMesh writes the positions and indices of a single-primitive mesh.
+// Triangles have no normals, so viewers shade them flat.
+func (b *builder) addMesh(name string, positions [][3]float64, indices []uint32, mode, mat int) int {
+	lo := []float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+	hi := []float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+	data := make([]float32, 0, len(positions)*3)
+	for _, p := range positions {
+		for i := 0; i < 3; i++ {
+			// min and max must match the stored single-precision values
+			v := float32(p[i])
+			data = append(data, v)
+			lo[i], hi[i] = math.Min(lo[i], float64(v)), math.Max(hi[i], float64(v))
+		}
+	}
+	pos := b.addAccessor(data, len(positions), "VEC3", componentFloat, targetArrayBuffer)
+	b.doc.Accessors[pos].Min, b.doc.Accessors[pos].Max = lo, hi
+	idx := b.addAccessor(indices, len(indices), "SCALAR", componentUint32, targetElementArrayBuffer)
+
+	b.doc.Meshes = append(b.doc.Meshes, mesh{
+		Name: name,
+		Primitives: []primitive{{
+			Attributes: map[string]int{"POSITION": pos},
+			Indices:    idx,
+			Material:   mat,
+			Mode:       mode,
+		}},
+	})
+	return len(b.doc.Meshes) - 1
+}
+
+// addAccessor appends data to the binary buffer in its own view
+func (b *builder) addAccessor(data interface{}, count int, typ string, component, target int) int {
+	offset := b.bin.Len()
+	binary.Write(&b.bin, binary.LittleEndian, data)
+	b.doc.BufferViews = append(b.doc.BufferViews, bufferView{ByteOffset: offset, ByteLength: b.bin.Len() - offset, Target: target})
+	// Every component is four bytes, so views stay aligned without padding
+	b.doc.Accessors = append(b.doc.Accessors, accessor{
+		BufferView:    len(b.doc.BufferViews) - 1,
+		ComponentType: component,
+		Count:         count,
+		Type:          typ,
+	})
+	return len(b.doc.Accessors) - 1
+}
+
+// GLB framing from the glTF specification
+const (
+	glbMagic     = 0x46546C67 // "glTF"
+	glbVersion   = 2
+	chunkJSON    = 0x4E4F534A // "JSON"
+	chunkBIN     = 0x004E4942 // "BIN\0"
+	glbHeaderLen = 12
+	chunkHeadLen = 8
+)
+
+// glb frames the document and buffer as a GLB file: a header, the JSON chunk
+// padded with spaces and the binary chunk padded with zeros
+func (b *builder) glb() ([]byte, error) {
+	if b.bin.Len() > 0 {
+		b.doc.Buffers = []buffer{{ByteLength: b.bin.Len()}}
+	}
+	js, err := json.Marshal(b.doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode glTF: %w", err)
+	}
+	for len(js)%4 != 0 {
+		js = append(js, ' ')
+	}
+	bin := b.bin.Bytes()
+	for len(bin)%4 != 0 {
+		bin = append(bin, 0)
+	}
+
+	total := glbHeaderLen + chunkHeadLen + len(js)
+	if len(bin) > 0 {
+		total += chunkHeadLen + len(bin)
+	}
+	var out bytes.Buffer
+	out.Grow(total)
+	for _, v := range []uint32{glbMagic, glbVersion, uint32(total), uint32(len(js)), chunkJSON} {
+		binary.Write(&out, binary.LittleEndian, v)
+	}
+	out.Write(js)
+	if len(bin) > 0 {
+		binary.Write(&out, binary.LittleEndian, uint32(len(bin)))
+		binary.Write(&out, binary.LittleEndian, uint32(chunkBIN))
+		out.Write(bin)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,218 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// decode splits a GLB file into its document and binary chunk, checking the
+// framing along the way
+func decode(t *testing.T, data []byte) (document, []byte) {
+	t.Helper()
+	if len(data)%4 != 0 || len(data) < glbHeaderLen+chunkHeadLen {
+		t.Fatalf("GLB is %d bytes", len(data))
+	}
+	le := binary.LittleEndian
+	if le.Uint32(data) != glbMagic || le.Uint32(data[4:]) != glbVersion || int(le.Uint32(data[8:])) != len(data) {
+		t.Fatalf("bad GLB header % x", data[:12])
+	}
+	jsonLen := int(le.Uint32(data[12:]))
+	if le.Uint32(data[16:]) != chunkJSON {
+		t.Fatal("first chunk isn't JSON")
+	}
+	var doc document
+	if err := json.Unmarshal(data[20:20+jsonLen], &doc); err != nil {
+		t.Fatalf("JSON chunk doesn't decode: %v", err)
+	}
+	var bin []byte
+	if rest := data[20+jsonLen:]; len(rest) > 0 {
+		if le.Uint32(rest[4:]) != chunkBIN {
+			t.Fatal("second chunk isn't BIN")
+		}
+		bin = rest[8 : 8+le.Uint32(rest)]
+	}
+	return doc, bin
+}
+
+func testScene() *models.SceneGraph {
+	sg := models.NewEmptySceneGraph()
+	sg.Objects = []models.SceneObject{
+		{ID: "wall", Type: models.ObjectTypeWall, Label: "North wall", State: models.ObjectStateVisible,
+			BBox: models.BoundingBox{Min: [3]float64{0, 0, -0.1}, Max: [3]float64{6, 3, 0.1}}},
+		// A table turned a quarter turn about Y, centred on its box
+		{ID: "table", Type: models.ObjectTypeFurniture, Label: "Table", State: models.ObjectStateVisible,
+			Pose: models.Pose{Position: [3]float64{2, 0.4, 1.5}, Rotation: [4]float64{math.Sqrt2 / 2, 0, math.Sqrt2 / 2, 0}},
+			BBox: models.BoundingBox{Min: [3]float64{1, 0, 1}, Max: [3]float64{3, 0.8, 2}}},
+		{ID: "knife", Type: models.ObjectTypeWeapon, Label: "Knife", State: models.ObjectStateSuspicious,
+			Pose: models.Pose{Position: [3]float64{4.5, 0, 1.5}}, MeshRef: "cases/c/models/knife.glb"},
+	}
+	sg.Evidence = []models.EvidenceCard{
+		{ID: "ev-1", ObjectIDs: []string{"knife"}, Title: "Knife", Confidence: 0.9},
+		{ID: "ev-2", ObjectIDs: []string{"missing"}, Title: "Unplaced", Confidence: 0.4},
+	}
+	sg.UncertaintyRegions = []models.UncertaintyRegion{
+		{ID: "u1", BBox: models.BoundingBox{Min: [3]float64{4, 0, 2.5}, Max: [3]float64{5.5, 2, 3.5}}, Level: models.UncertaintyLevelHigh, Reason: "Occluded"},
+	}
+	return sg
+}
+
+func TestEncode(t *testing.T) {
+	commitID := uuid.New()
+	model := models.Asset{ID: uuid.New(), Kind: models.AssetKindEvidenceModel, StorageKey: "cases/c/models/knife.glb",
+		Metadata: map[string]interface{}{"item_type": "weapon"}}
+	data, err := Encode(Input{
+		Scene:    testScene(),
+		CommitID: commitID,
+		Trajectories: []models.Trajectory{
+			{ID: "t2", Rank: 2, Segments: []models.TrajectorySegment{{FromPosition: [3]float64{0, 0, 0}, ToPosition: [3]float64{1, 0, 1}}}},
+			{ID: "t1", Rank: 1, Segments: []models.TrajectorySegment{
+				{FromPosition: [3]float64{0, 0, 4}, ToPosition: [3]float64{4.5, 0, 1.5}, Waypoints: [][3]float64{{2, 0, 3}}},
+				{FromPosition: [3]float64{4.5, 0, 1.5}, ToPosition: [3]float64{0, 0, 4}},
+			}},
+		},
+		Models: []models.Asset{model},
+	})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	doc, bin := decode(t, data)
+
+	if doc.Asset.Version != "2.0" || len(doc.Scenes) != 1 || len(doc.Buffers) != 1 || doc.Buffers[0].ByteLength != len(bin) {
+		t.Fatalf("document = %+v", doc.Asset)
+	}
+	root := doc.Nodes[doc.Scenes[0].Nodes[0]]
+	if root.Extras["commit_id"] != commitID.String() {
+		t.Errorf("root extras = %v", root.Extras)
+	}
+	if refs, _ := root.Extras["evidence_models"].([]interface{}); len(refs) != 1 || refs[0].(map[string]interface{})["item_type"] != "weapon" {
+		t.Errorf("evidence_models = %v", root.Extras["evidence_models"])
+	}
+
+	groups := map[string][]int{}
+	for _, i := range root.Children {
+		groups[doc.Nodes[i].Name] = doc.Nodes[i].Children
+	}
+	if len(groups["Objects"]) != 3 || len(groups["Evidence"]) != 1 || len(groups["Uncertainty"]) != 1 || len(groups["Trajectories"]) != 2 {
+		t.Fatalf("groups = %v", groups)
+	}
+
+	// The knife references its generated model
+	knife := doc.Nodes[groups["Objects"][2]]
+	if knife.Extras["mesh_ref"] != model.StorageKey || knife.Extras["mesh_asset_id"] != model.ID.String() {
+		t.Errorf("knife extras = %v", knife.Extras)
+	}
+	// Its marker floats above the default cube at its position
+	marker := doc.Nodes[groups["Evidence"][0]]
+	if marker.Name != "1. Knife" || marker.Extras["tier"] != "high" ||
+		len(marker.Translation) != 3 || math.Abs(marker.Translation[1]-(minSize+markerHeight)) > 1e-9 {
+		t.Errorf("marker = %+v", marker)
+	}
+
+	// Trajectories come in rank order; the first retraces its route
+	first := doc.Nodes[groups["Trajectories"][0]]
+	if first.Name != "Trajectory #1" {
+		t.Errorf("first trajectory = %s", first.Name)
+	}
+	prim := doc.Meshes[*first.Mesh].Primitives[0]
+	if prim.Mode != modeLineStrip || doc.Accessors[prim.Attributes["POSITION"]].Count != 4 {
+		t.Errorf("trajectory primitive = %+v", prim)
+	}
+
+	// Uncertainty is translucent
+	u := doc.Nodes[groups["Uncertainty"][0]]
+	if mat := doc.Materials[doc.Meshes[*u.Mesh].Primitives[0].Material]; mat.AlphaMode != "BLEND" {
+		t.Errorf("uncertainty material = %+v", mat)
+	}
+
+	// Every view lies within the buffer, and index accessors stay in range
+	for _, v := range doc.BufferViews {
+		if v.ByteOffset%4 != 0 || v.ByteOffset+v.ByteLength > len(bin) {
+			t.Errorf("buffer view %+v outside %d-byte buffer", v, len(bin))
+		}
+	}
+	for _, m := range doc.Meshes {
+		p := m.Primitives[0]
+		count := doc.Accessors[p.Attributes["POSITION"]].Count
+		idx := doc.Accessors[p.Indices]
+		view := doc.BufferViews[idx.BufferView]
+		for i := 0; i < idx.Count; i++ {
+			if v := binary.LittleEndian.Uint32(bin[view.ByteOffset+4*i:]); int(v) >= count {
+				t.Errorf("mesh %s index %d out of range", m.Name, v)
+			}
+		}
+	}
+}
+
+// The proxy box of a rotated object, placed at its pose, covers its box in
+// world space
+func TestEncode_RotatedObject(t *testing.T) {
+	sg := testScene()
+	sg.Objects = sg.Objects[1:2]
+	sg.Evidence, sg.UncertaintyRegions = nil, nil
+	data, err := Encode(Input{Scene: sg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, bin := decode(t, data)
+
+	var table node
+	for _, n := range doc.Nodes {
+		if n.Name == "Table" {
+			table = n
+		}
+	}
+	if len(table.Rotation) != 4 || math.Abs(table.Rotation[1]-math.Sqrt2/2) > 1e-9 || math.Abs(table.Rotation[3]-math.Sqrt2/2) > 1e-9 {
+		t.Fatalf("rotation = %v, want [x y z w] order", table.Rotation)
+	}
+	proxy := doc.Nodes[table.Children[0]]
+	acc := doc.Accessors[doc.Meshes[*proxy.Mesh].Primitives[0].Attributes["POSITION"]]
+	view := doc.BufferViews[acc.BufferView]
+
+	pose := sg.Objects[0].Pose
+	lo, hi := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}, [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+	var v [3]float32
+	r := bytes.NewReader(bin[view.ByteOffset : view.ByteOffset+view.ByteLength])
+	for i := 0; i < acc.Count; i++ {
+		binary.Read(r, binary.LittleEndian, &v)
+		w := toWorld(pose, [3]float64{float64(v[0]), float64(v[1]), float64(v[2])})
+		for j := 0; j < 3; j++ {
+			lo[j], hi[j] = math.Min(lo[j], w[j]), math.Max(hi[j], w[j])
+		}
+	}
+	want := sg.Objects[0].BBox
+	for j := 0; j < 3; j++ {
+		if math.Abs(lo[j]-want.Min[j]) > 1e-5 || math.Abs(hi[j]-want.Max[j]) > 1e-5 {
+			t.Fatalf("proxy covers %v–%v, want %v", lo, hi, want)
+		}
+	}
+}
+
+// toWorld applies a pose's rotation and translation to a point
+func toWorld(p models.Pose, v [3]float64) [3]float64 {
+	q, _ := unitQuat(p.Rotation)
+	w, u := q[0], [3]float64{q[1], q[2], q[3]}
+	t := cross(u, v)
+	for i := range t {
+		t[i] *= 2
+	}
+	c := cross(u, t)
+	return [3]float64{v[0] + w*t[0] + c[0] + p.Position[0], v[1] + w*t[1] + c[1] + p.Position[1], v[2] + w*t[2] + c[2] + p.Position[2]}
+}
+
+func TestEncode_EmptyScene(t *testing.T) {
+	data, err := Encode(Input{})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	doc, bin := decode(t, data)
+	if len(bin) != 0 || len(doc.Buffers) != 0 || len(doc.Nodes) != 1 {
+		t.Errorf("empty scene = %+v with %d-byte buffer", doc, len(bin))
+	}
+}
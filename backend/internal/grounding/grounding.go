@@ -0,0 +1,123 @@
+// Package grounding resolves the evidence and object IDs a reasoning model
+// cites against the scene it was given. Unknown IDs are removed, and the share
+// that resolved is reported per trajectory and for the whole output.
+package grounding
+
+import (
+	"math"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// DefaultThreshold is the minimum share of references that must resolve
+const DefaultThreshold = 0.5
+
+// Reference kinds and the actions taken on unresolved references
+const (
+	KindEvidence = "evidence"
+	KindObject   = "object"
+
+	ActionDropped = "dropped"
+	ActionCleared = "cleared"
+)
+
+// Resolve checks every evidence reference and suggested object in output
+// against sg. References to unknown evidence are dropped, unknown object IDs
+// on otherwise valid references are cleared, and unknown suggested objects
+// are removed. Scenes with no objects or evidence can't ground anything, and
+// outputs without trajectories claim nothing, so their reports are not enforced.
+func Resolve(sg *models.SceneGraph, output *models.ReasoningOutput, threshold float64) *models.GroundingReport {
+	evidence := map[string]bool{}
+	objects := map[string]bool{}
+	if sg != nil {
+		for _, ev := range sg.Evidence {
+			evidence[ev.ID] = true
+		}
+		for _, obj := range sg.Objects {
+			objects[obj.ID] = true
+		}
+	}
+
+	report := &models.GroundingReport{
+		Threshold: threshold,
+		Enforced:  threshold > 0 && len(output.Trajectories) > 0 && (len(evidence) > 0 || len(objects) > 0),
+	}
+
+	for i := range output.Trajectories {
+		t := &output.Trajectories[i]
+		g := &models.TrajectoryGrounding{}
+		for j := range t.Segments {
+			seg := &t.Segments[j]
+			kept := seg.EvidenceRefs[:0]
+			for _, ref := range seg.EvidenceRefs {
+				unresolved := func(kind, id, action string) {
+					report.Unresolved = append(report.Unresolved, models.UnresolvedRef{
+						Kind: kind, ID: id, TrajectoryID: t.ID, SegmentID: seg.ID, Action: action,
+					})
+				}
+
+				// A reference must cite real evidence, or at least a real object
+				if ref.EvidenceID != "" || ref.ObjectID == "" {
+					g.References++
+					if !evidence[ref.EvidenceID] {
+						unresolved(KindEvidence, ref.EvidenceID, ActionDropped)
+						continue
+					}
+					g.Resolved++
+				}
+				if ref.ObjectID != "" {
+					g.References++
+					if !objects[ref.ObjectID] {
+						if ref.EvidenceID == "" {
+							unresolved(KindObject, ref.ObjectID, ActionDropped)
+							continue
+						}
+						unresolved(KindObject, ref.ObjectID, ActionCleared)
+						ref.ObjectID = ""
+					} else {
+						g.Resolved++
+					}
+				}
+				kept = append(kept, ref)
+			}
+			seg.EvidenceRefs = kept
+		}
+		g.Ratio = ratio(g.Resolved, g.References)
+		t.Grounding = g
+		report.References += g.References
+		report.Resolved += g.Resolved
+	}
+
+	for i := range output.NextStepSuggestions {
+		s := &output.NextStepSuggestions[i]
+		if len(s.RelatedObjectIDs) == 0 {
+			continue
+		}
+		kept := s.RelatedObjectIDs[:0]
+		for _, id := range s.RelatedObjectIDs {
+			report.References++
+			if !objects[id] {
+				report.Unresolved = append(report.Unresolved, models.UnresolvedRef{Kind: KindObject, ID: id, Action: ActionDropped})
+				continue
+			}
+			report.Resolved++
+			kept = append(kept, id)
+		}
+		s.RelatedObjectIDs = kept
+	}
+
+	report.Ratio = ratio(report.Resolved, report.References)
+	return report
+}
+
+// Passed reports whether the output is grounded well enough to keep
+func Passed(report *models.GroundingReport) bool {
+	return !report.Enforced || report.Ratio >= report.Threshold
+}
+
+func ratio(resolved, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(resolved)/float64(total)*100) / 100
+}
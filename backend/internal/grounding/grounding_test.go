@@ -0,0 +1,100 @@
+package grounding
+
+import (
+	"testing"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+func scene() *models.SceneGraph {
+	sg := models.NewEmptySceneGraph()
+	sg.Objects = []models.SceneObject{{ID: "door"}, {ID: "window"}}
+	sg.Evidence = []models.EvidenceCard{{ID: "ev-lock"}, {ID: "ev-print"}}
+	return sg
+}
+
+func output() *models.ReasoningOutput {
+	return &models.ReasoningOutput{
+		Trajectories: []models.Trajectory{
+			{ID: "t1", Segments: []models.TrajectorySegment{
+				{ID: "s1", EvidenceRefs: []models.EvidenceRef{
+					{EvidenceID: "ev-lock", ObjectID: "door", Relevance: "supports", Weight: 0.9},
+					{EvidenceID: "ev-print", ObjectID: "sofa", Relevance: "supports", Weight: 0.6},
+				}},
+				{ID: "s2", EvidenceRefs: []models.EvidenceRef{
+					{EvidenceID: "ev-blood", Relevance: "supports", Weight: 0.8},
+					{ObjectID: "window", Relevance: "neutral", Weight: 0.2},
+				}},
+			}},
+			{ID: "t2", Segments: []models.TrajectorySegment{
+				{ID: "s1", EvidenceRefs: []models.EvidenceRef{{EvidenceID: "ev-999", Relevance: "supports", Weight: 1}}},
+			}},
+		},
+		NextStepSuggestions: []models.Suggestion{
+			{Type: "analyze", RelatedObjectIDs: []string{"door", "garage"}},
+		},
+	}
+}
+
+func TestResolve(t *testing.T) {
+	out := output()
+	report := Resolve(scene(), out, DefaultThreshold)
+
+	// t1: ev-lock, door, ev-print, window resolve; sofa, ev-blood don't
+	t1 := out.Trajectories[0]
+	if g := t1.Grounding; g.References != 6 || g.Resolved != 4 || g.Ratio != 0.67 {
+		t.Errorf("t1 grounding = %+v", g)
+	}
+	if refs := t1.Segments[0].EvidenceRefs; len(refs) != 2 || refs[0].ObjectID != "door" || refs[1].ObjectID != "" {
+		t.Errorf("s1 refs = %+v, want the unknown object cleared", refs)
+	}
+	if refs := t1.Segments[1].EvidenceRefs; len(refs) != 1 || refs[0].ObjectID != "window" {
+		t.Errorf("s2 refs = %+v, want the unknown evidence dropped", refs)
+	}
+	if g := out.Trajectories[1].Grounding; g.Ratio != 0 || len(out.Trajectories[1].Segments[0].EvidenceRefs) != 0 {
+		t.Errorf("t2 grounding = %+v", g)
+	}
+	if ids := out.NextStepSuggestions[0].RelatedObjectIDs; len(ids) != 1 || ids[0] != "door" {
+		t.Errorf("suggestion objects = %v", ids)
+	}
+
+	// 4 of 6 + 0 of 1 + 1 of 2
+	if report.References != 9 || report.Resolved != 5 || report.Ratio != 0.56 || !report.Enforced {
+		t.Errorf("report = %+v", report)
+	}
+	if len(report.Unresolved) != 4 {
+		t.Fatalf("unresolved = %+v", report.Unresolved)
+	}
+	first := report.Unresolved[0]
+	if first != (models.UnresolvedRef{Kind: KindObject, ID: "sofa", TrajectoryID: "t1", SegmentID: "s1", Action: ActionCleared}) {
+		t.Errorf("unresolved[0] = %+v", first)
+	}
+	if !Passed(report) {
+		t.Error("Passed() = false at 0.56 with a 0.5 threshold")
+	}
+}
+
+func TestPassed(t *testing.T) {
+	tests := []struct {
+		name      string
+		sg        *models.SceneGraph
+		threshold float64
+		want      bool
+	}{
+		{"below threshold", scene(), 0.8, false},
+		{"disabled", scene(), 0, true},
+		{"nothing to resolve against", models.NewEmptySceneGraph(), 0.8, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Passed(Resolve(tt.sg, output(), tt.threshold)); got != tt.want {
+				t.Errorf("Passed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	empty := &models.ReasoningOutput{}
+	if report := Resolve(scene(), empty, 0.8); !Passed(report) || report.Enforced {
+		t.Errorf("output without trajectories: report = %+v", report)
+	}
+}
@@ -0,0 +1,372 @@
+// Package layout derives Tier 0 proxy geometry — walls, door and window
+// openings and the walkable floor — from a floor-aligned reconstruction.
+package layout
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// Source marks objects and constraints produced by layout extraction so a
+// later reconstruction can replace them
+const Source = "layout_extraction"
+
+// ErrInsufficientPoints is returned when the cloud is too sparse to extract structure
+var ErrInsufficientPoints = errors.New("not enough points to extract a room layout")
+
+// Options controls layout extraction. The input cloud must be Y-up with the
+// floor at y = 0, as produced by pointcloud.Process.
+type Options struct {
+	FloorBand     float64 // points below this height form the floor footprint
+	WallThreshold float64 // max distance of a wall point from its line
+	WallThickness float64 // thickness of the emitted wall proxies
+	MinWallLength float64
+	MinWallPoints int // absolute minimum support for a wall line
+	MaxWalls      int
+	Iterations    int     // RANSAC iterations per wall
+	CornerSnap    float64 // wall endpoints this close to an intersection are joined
+	CellSize      float64 // resolution of the opening occupancy grid
+	Seed          int64
+}
+
+// DefaultOptions suits room-scale reconstructions downsampled to a few centimetres
+func DefaultOptions() Options {
+	return Options{
+		FloorBand:     0.1,
+		WallThreshold: 0.05,
+		WallThickness: 0.1,
+		MinWallLength: 1.0,
+		MinWallPoints: 100,
+		MaxWalls:      8,
+		Iterations:    200,
+		CornerSnap:    0.3,
+		CellSize:      0.1,
+		Seed:          1,
+	}
+}
+
+// Wall is a vertical plane bounded by a floor-plan segment
+type Wall struct {
+	ID       string
+	Start    Point2
+	End      Point2
+	Normal   Point2 // unit, points into the room
+	Height   float64
+	Points   int     // supporting points
+	Coverage float64 // fraction of the wall surface columns observed
+}
+
+// Length returns the wall's length in meters
+func (w Wall) Length() float64 {
+	return math.Hypot(w.End[0]-w.Start[0], w.End[1]-w.Start[1])
+}
+
+// direction returns the unit vector from Start to End
+func (w Wall) direction() Point2 {
+	l := w.Length()
+	if l == 0 {
+		return Point2{1, 0}
+	}
+	return Point2{(w.End[0] - w.Start[0]) / l, (w.End[1] - w.Start[1]) / l}
+}
+
+// Opening is a door or window in a wall. Openings matched to a detected
+// object carry its ID; the rest were found as gaps in the wall surface.
+type Opening struct {
+	ID       string
+	Type     models.ObjectType // ObjectTypeDoor or ObjectTypeWindow
+	WallID   string
+	Start    Point2
+	End      Point2
+	Bottom   float64
+	Top      float64
+	ObjectID string
+}
+
+// Width returns the opening's width in meters
+func (o Opening) Width() float64 {
+	return math.Hypot(o.End[0]-o.Start[0], o.End[1]-o.Start[1])
+}
+
+// Layout is the extracted room structure
+type Layout struct {
+	Walls     []Wall
+	Openings  []Opening
+	Floor     []Point2 // convex floor outline, counter-clockwise
+	Height    float64
+	Thickness float64
+	Bounds    *models.BoundingBox // nil if neither walls nor floor were found
+}
+
+// FloorArea returns the area of the floor outline in square meters
+func (l *Layout) FloorArea() float64 {
+	if len(l.Floor) < 3 {
+		return 0
+	}
+	return PolygonArea(l.Floor)
+}
+
+// Extract derives walls, openings and the floor outline from a floor-aligned
+// point cloud. Detected door and window objects near a wall are linked to
+// the matching opening instead of producing a duplicate.
+func Extract(pc *models.PointCloud, detected []models.SceneObject, opts Options) (*Layout, error) {
+	if pc == nil || len(pc.Positions) < opts.MinWallPoints {
+		return nil, ErrInsufficientPoints
+	}
+	points := pc.Positions
+
+	heights := make([]float64, len(points))
+	for i, p := range points {
+		heights[i] = p[1]
+	}
+	l := &Layout{Height: percentile(heights, 0.98), Thickness: opts.WallThickness}
+	if l.Height <= opts.FloorBand {
+		return nil, ErrInsufficientPoints
+	}
+
+	var floor []Point2
+	var center Point2
+	for _, p := range points {
+		if p[1] < opts.FloorBand {
+			floor = append(floor, Point2{p[0], p[2]})
+		}
+		center[0] += p[0]
+		center[1] += p[2]
+	}
+	center[0] /= float64(len(points))
+	center[1] /= float64(len(points))
+	if len(floor) >= 3 {
+		hull := ConvexHull(floor)
+		for i := range hull {
+			hull[i] = roundPoint(hull[i])
+		}
+		if len(hull) >= 3 {
+			l.Floor = hull
+		}
+	}
+
+	l.Walls = extractWalls(points, l.Height, center, opts)
+	snapCorners(l.Walls, opts.CornerSnap)
+	for i := range l.Walls {
+		l.Walls[i].ID = uuid.New().String()
+		l.Openings = append(l.Openings, findOpenings(points, &l.Walls[i], opts)...)
+	}
+	l.Openings = linkDetected(l.Walls, l.Openings, detected)
+	for i := range l.Openings {
+		if l.Openings[i].ObjectID != "" {
+			l.Openings[i].ID = l.Openings[i].ObjectID
+		} else {
+			l.Openings[i].ID = uuid.New().String()
+		}
+	}
+
+	l.Bounds = l.computeBounds()
+	return l, nil
+}
+
+// extractWalls runs sequential 2D RANSAC on the floor-plan projection of the
+// points between waist height and just below the ceiling, where furniture
+// rarely reaches but walls always do
+func extractWalls(points [][]float64, height float64, center Point2, opts Options) []Wall {
+	low, high := math.Min(1.2, 0.5*height), 0.96*height
+	var band []Point2
+	for _, p := range points {
+		if p[1] >= low && p[1] <= high {
+			band = append(band, Point2{p[0], p[2]})
+		}
+	}
+	minSupport := opts.MinWallPoints
+	if s := len(band) / 50; s > minSupport {
+		minSupport = s
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	var walls []Wall
+	remaining := band
+	for len(walls) < opts.MaxWalls && len(remaining) >= 2 && len(remaining) >= minSupport {
+		normal, offset, count := ransacLine(remaining, opts.WallThreshold, opts.Iterations, rng)
+		if count < minSupport {
+			break
+		}
+		normal, offset = refitLine(remaining, normal, offset, opts.WallThreshold)
+
+		var inliers, rest []Point2
+		for _, p := range remaining {
+			if math.Abs(lineDistance(normal, offset, p)) <= opts.WallThreshold {
+				inliers = append(inliers, p)
+			} else {
+				rest = append(rest, p)
+			}
+		}
+		if len(inliers) == 0 {
+			break
+		}
+		remaining = rest
+		if len(inliers) < minSupport {
+			continue
+		}
+
+		// Orient the normal into the room
+		if lineDistance(normal, offset, center) < 0 {
+			normal, offset = Point2{-normal[0], -normal[1]}, -offset
+		}
+		dir := Point2{normal[1], -normal[0]}
+		ts := make([]float64, len(inliers))
+		for i, p := range inliers {
+			ts[i] = dir[0]*p[0] + dir[1]*p[1]
+		}
+		t0, t1 := percentile(ts, 0.01), percentile(ts, 0.99)
+		if t1-t0 < opts.MinWallLength {
+			continue
+		}
+		base := Point2{-offset * normal[0], -offset * normal[1]}
+		walls = append(walls, Wall{
+			Start:  Point2{base[0] + t0*dir[0], base[1] + t0*dir[1]},
+			End:    Point2{base[0] + t1*dir[0], base[1] + t1*dir[1]},
+			Normal: normal,
+			Height: height,
+			Points: len(inliers),
+		})
+	}
+	return walls
+}
+
+// ransacLine returns the line n·p + c = 0 through two sampled points with the most inliers
+func ransacLine(points []Point2, threshold float64, iterations int, rng *rand.Rand) (Point2, float64, int) {
+	var bestN Point2
+	var bestC float64
+	bestCount := 0
+	for it := 0; it < iterations; it++ {
+		a, b := points[rng.Intn(len(points))], points[rng.Intn(len(points))]
+		dx, dz := b[0]-a[0], b[1]-a[1]
+		l := math.Hypot(dx, dz)
+		if l < 1e-6 {
+			continue
+		}
+		n := Point2{-dz / l, dx / l}
+		c := -(n[0]*a[0] + n[1]*a[1])
+		count := 0
+		for _, p := range points {
+			if math.Abs(lineDistance(n, c, p)) <= threshold {
+				count++
+			}
+		}
+		if count > bestCount {
+			bestN, bestC, bestCount = n, c, count
+		}
+	}
+	return bestN, bestC, bestCount
+}
+
+// refitLine fits a least-squares line to the inliers of n·p + c = 0
+func refitLine(points []Point2, n Point2, c, threshold float64) (Point2, float64) {
+	var mean Point2
+	count := 0
+	for _, p := range points {
+		if math.Abs(lineDistance(n, c, p)) <= threshold {
+			mean[0] += p[0]
+			mean[1] += p[1]
+			count++
+		}
+	}
+	if count < 2 {
+		return n, c
+	}
+	mean[0] /= float64(count)
+	mean[1] /= float64(count)
+
+	var sxx, sxz, szz float64
+	for _, p := range points {
+		if math.Abs(lineDistance(n, c, p)) <= threshold {
+			dx, dz := p[0]-mean[0], p[1]-mean[1]
+			sxx += dx * dx
+			sxz += dx * dz
+			szz += dz * dz
+		}
+	}
+	angle := 0.5 * math.Atan2(2*sxz, sxx-szz) // principal direction
+	refit := Point2{-math.Sin(angle), math.Cos(angle)}
+	if refit[0]*n[0]+refit[1]*n[1] < 0 {
+		refit = Point2{-refit[0], -refit[1]}
+	}
+	return refit, -(refit[0]*mean[0] + refit[1]*mean[1])
+}
+
+func lineDistance(n Point2, c float64, p Point2) float64 {
+	return n[0]*p[0] + n[1]*p[1] + c
+}
+
+// snapCorners joins wall endpoints that nearly meet at the intersection of their lines
+func snapCorners(walls []Wall, tolerance float64) {
+	for i := range walls {
+		for j := i + 1; j < len(walls); j++ {
+			a, b := &walls[i], &walls[j]
+			da, db := a.direction(), b.direction()
+			det := da[0]*db[1] - da[1]*db[0]
+			if math.Abs(det) < 0.5 { // closer than 30° to parallel
+				continue
+			}
+			// a.Start + s·da = b.Start + u·db
+			dx, dz := b.Start[0]-a.Start[0], b.Start[1]-a.Start[1]
+			s := (dx*db[1] - dz*db[0]) / det
+			x := Point2{a.Start[0] + s*da[0], a.Start[1] + s*da[1]}
+
+			ea := nearestEnd(a, x, tolerance)
+			eb := nearestEnd(b, x, tolerance)
+			if ea != nil && eb != nil {
+				*ea, *eb = x, x
+			}
+		}
+	}
+}
+
+func nearestEnd(w *Wall, p Point2, tolerance float64) *Point2 {
+	ds := math.Hypot(w.Start[0]-p[0], w.Start[1]-p[1])
+	de := math.Hypot(w.End[0]-p[0], w.End[1]-p[1])
+	switch {
+	case ds <= de && ds <= tolerance:
+		return &w.Start
+	case de < ds && de <= tolerance:
+		return &w.End
+	}
+	return nil
+}
+
+// computeBounds encloses the floor outline and the walls from the floor to the ceiling
+func (l *Layout) computeBounds() *models.BoundingBox {
+	var pts []Point2
+	pts = append(pts, l.Floor...)
+	for _, w := range l.Walls {
+		off := Point2{w.Normal[0] * l.Thickness / 2, w.Normal[1] * l.Thickness / 2}
+		for _, p := range []Point2{w.Start, w.End} {
+			pts = append(pts, Point2{p[0] - off[0], p[1] - off[1]}, Point2{p[0] + off[0], p[1] + off[1]})
+		}
+	}
+	if len(pts) == 0 {
+		return nil
+	}
+	bb := &models.BoundingBox{
+		Min: [3]float64{pts[0][0], 0, pts[0][1]},
+		Max: [3]float64{pts[0][0], round3(l.Height), pts[0][1]},
+	}
+	for _, p := range pts[1:] {
+		bb.Min[0] = math.Min(bb.Min[0], p[0])
+		bb.Min[2] = math.Min(bb.Min[2], p[1])
+		bb.Max[0] = math.Max(bb.Max[0], p[0])
+		bb.Max[2] = math.Max(bb.Max[2], p[1])
+	}
+	return bb
+}
+
+// percentile returns the p-quantile (0..1) of values by nearest rank
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	i := int(math.Round(p * float64(len(sorted)-1)))
+	return sorted[i]
+}
@@ -0,0 +1,200 @@
+package layout
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// syntheticRoom builds a floor-aligned 5 m × 4 m room, 2.6 m high, with a
+// 0.9 m door in the z=0 wall, a 1.2 m window in the x=5 wall and a table
+func syntheticRoom() *models.PointCloud {
+	const step = 0.04
+	pc := &models.PointCloud{}
+	add := func(x, y, z float64) {
+		pc.Positions = append(pc.Positions, []float64{x, y, z})
+	}
+	for x := 0.0; x <= 5; x += step {
+		for z := 0.0; z <= 4; z += step {
+			add(x, 0, z)
+		}
+	}
+	for y := 0.0; y <= 2.6; y += step {
+		for x := 0.0; x <= 5; x += step {
+			if !(x > 1.5 && x < 2.4 && y < 2.0) { // door
+				add(x, y, 0)
+			}
+			add(x, y, 4)
+		}
+		for z := 0.0; z <= 4; z += step {
+			add(0, y, z)
+			if !(z > 1.0 && z < 2.2 && y > 0.9 && y < 1.8) { // window
+				add(5, y, z)
+			}
+		}
+	}
+	for x := 2.0; x <= 3; x += step { // table top
+		for z := 2.0; z <= 3; z += step {
+			add(x, 0.75, z)
+		}
+	}
+	pc.Count = len(pc.Positions)
+	return pc
+}
+
+func TestExtract_WallsOpeningsAndFloor(t *testing.T) {
+	l, err := Extract(syntheticRoom(), nil, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	if len(l.Walls) != 4 {
+		t.Fatalf("Extract() found %d walls, want 4", len(l.Walls))
+	}
+	var total float64
+	for _, w := range l.Walls {
+		total += w.Length()
+		// Normals point into the room
+		mid := w.pointAt(w.Length() / 2)
+		inward := Point2{mid[0] + w.Normal[0], mid[1] + w.Normal[1]}
+		if !PointInPolygon(inward, []Point2{{0, 0}, {5, 0}, {5, 4}, {0, 4}}) {
+			t.Errorf("wall %v→%v normal %v points out of the room", w.Start, w.End, w.Normal)
+		}
+	}
+	if math.Abs(total-18) > 0.4 {
+		t.Errorf("total wall length = %v, want ≈ 18", total)
+	}
+	if math.Abs(l.Height-2.6) > 0.1 {
+		t.Errorf("Height = %v, want ≈ 2.6", l.Height)
+	}
+	if a := l.FloorArea(); math.Abs(a-20) > 0.5 {
+		t.Errorf("FloorArea() = %v, want ≈ 20", a)
+	}
+	if b := l.Bounds; b == nil || b.Max[0]-b.Min[0] > 5.3 || b.Max[2]-b.Min[2] > 4.3 || b.Min[1] != 0 {
+		t.Errorf("Bounds = %+v, want the 5 × 4 m room", b)
+	}
+
+	var door, window *Opening
+	for i := range l.Openings {
+		switch l.Openings[i].Type {
+		case models.ObjectTypeDoor:
+			door = &l.Openings[i]
+		case models.ObjectTypeWindow:
+			window = &l.Openings[i]
+		}
+	}
+	if len(l.Openings) != 2 || door == nil || window == nil {
+		t.Fatalf("Openings = %+v, want one door and one window", l.Openings)
+	}
+	if math.Abs(door.Width()-0.9) > 0.21 || math.Abs(door.Start[1]) > 0.1 || math.Abs(door.Top-2.0) > 0.11 {
+		t.Errorf("door = %+v, want 0.9 m wide and 2 m high in the z=0 wall", *door)
+	}
+	if math.Abs(window.Width()-1.2) > 0.21 || math.Abs(window.Start[0]-5) > 0.1 ||
+		math.Abs(window.Bottom-0.9) > 0.11 || math.Abs(window.Top-1.8) > 0.11 {
+		t.Errorf("window = %+v, want 1.2 m wide from 0.9 to 1.8 m in the x=5 wall", *window)
+	}
+}
+
+func TestExtract_LinksDetectedOpenings(t *testing.T) {
+	detected := []models.SceneObject{{
+		ID:   "detected-window",
+		Type: models.ObjectTypeWindow,
+		BBox: models.BoundingBox{Min: [3]float64{4.95, 0.9, 1.0}, Max: [3]float64{5.05, 1.8, 2.2}},
+	}}
+	l, err := Extract(syntheticRoom(), detected, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	objects := l.SceneObjects()
+	walls, doors := 0, 0
+	for _, obj := range objects {
+		if err := obj.Validate(); err != nil {
+			t.Errorf("object %s invalid: %v", obj.Label, err)
+		}
+		if !IsDerived(obj) || obj.Metadata["tier"] != 0 {
+			t.Errorf("object %s metadata = %v", obj.Label, obj.Metadata)
+		}
+		switch obj.Type {
+		case models.ObjectTypeWall:
+			walls++
+			if h := obj.BBox.Max[1] - obj.BBox.Min[1]; math.Abs(h-l.Height) > 0.01 {
+				t.Errorf("wall height = %v", h)
+			}
+		case models.ObjectTypeDoor:
+			doors++
+		case models.ObjectTypeWindow:
+			t.Error("detected window should not be duplicated by a proxy")
+		}
+	}
+	if walls != 4 || doors != 1 {
+		t.Errorf("SceneObjects() = %d walls, %d doors", walls, doors)
+	}
+
+	linked := false
+	for _, o := range l.Openings {
+		if o.ObjectID == "detected-window" && o.ID == "detected-window" {
+			linked = true
+		}
+	}
+	if !linked {
+		t.Errorf("Openings = %+v, want the detected window linked", l.Openings)
+	}
+}
+
+func TestPassableArea(t *testing.T) {
+	l, err := Extract(syntheticRoom(), nil, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	objects := []models.SceneObject{
+		{ID: "table", Type: models.ObjectTypeFurniture, BBox: models.BoundingBox{Min: [3]float64{2, 0, 2}, Max: [3]float64{3, 0.75, 3}}},
+		{ID: "scene", Type: models.ObjectTypeOther, BBox: models.BoundingBox{Min: [3]float64{0, 0, 0}, Max: [3]float64{5, 2.6, 4}}},
+		{ID: "lamp", Type: models.ObjectTypeFurniture, BBox: models.BoundingBox{Min: [3]float64{1, 2.2, 1}, Max: [3]float64{1.3, 2.5, 1.3}}},
+		{ID: "knife", Type: models.ObjectTypeWeapon, BBox: models.BoundingBox{Min: [3]float64{1, 0, 3}, Max: [3]float64{1.2, 0.02, 3.1}}},
+	}
+
+	c, ok := l.PassableArea(objects)
+	if !ok {
+		t.Fatal("PassableArea() found no floor")
+	}
+	if err := c.Validate(); err != nil || c.Type != models.ConstraintTypePassableArea || !IsDerivedConstraint(c) {
+		t.Fatalf("PassableArea() = %+v (%v)", c, err)
+	}
+	obstacles := c.Params["obstacles"].([]map[string]interface{})
+	if len(obstacles) != 1 || obstacles[0]["object_id"] != "table" {
+		t.Errorf("obstacles = %v, want only the table", obstacles)
+	}
+	if polygon := c.Params["polygon"].([]Point2); len(polygon) < 4 {
+		t.Errorf("polygon = %v", polygon)
+	}
+}
+
+func TestExtract_TooFewPoints(t *testing.T) {
+	pc := &models.PointCloud{Positions: [][]float64{{0, 0, 0}, {1, 1, 1}}}
+	if _, err := Extract(pc, nil, DefaultOptions()); err != ErrInsufficientPoints {
+		t.Errorf("Extract() error = %v, want ErrInsufficientPoints", err)
+	}
+}
+
+func TestSceneBounds(t *testing.T) {
+	if got := SceneBounds(nil, nil); got != DefaultBounds() {
+		t.Errorf("SceneBounds(empty) = %+v, want default", got)
+	}
+
+	cloud := &models.BoundingBox{Min: [3]float64{-1, 0, -2}, Max: [3]float64{3, 2.5, 2}}
+	if got := SceneBounds(nil, cloud); got != *cloud {
+		t.Errorf("SceneBounds(cloud) = %+v, want the cloud bounds", got)
+	}
+
+	objects := []models.SceneObject{
+		{ID: "a", Pose: models.Pose{Position: [3]float64{-3, 0, -2}}},
+		{ID: "b", BBox: models.BoundingBox{Min: [3]float64{1, 0, 1}, Max: [3]float64{2, 0.8, 1.5}}},
+	}
+	got := SceneBounds(objects, nil)
+	want := models.BoundingBox{Min: [3]float64{-3, 0, -2}, Max: [3]float64{2, 1, 1.5}}
+	if got != want {
+		t.Errorf("SceneBounds(objects) = %+v, want tight bounds %+v", got, want)
+	}
+}
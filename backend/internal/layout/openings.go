@@ -0,0 +1,265 @@
+package layout
+
+import (
+	"math"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// Height bands, in meters above the floor, used to classify gaps in a wall
+// surface, and the widths that make a gap a plausible door or window
+const (
+	doorClearFrom   = 0.3
+	doorClearTo     = 1.8
+	windowClearFrom = 1.0
+	windowClearTo   = 1.6
+	sillFrom        = 0.2
+	sillTo          = 0.8
+
+	minDoorWidth   = 0.6
+	maxDoorWidth   = 1.6
+	minWindowWidth = 0.4
+	maxWindowWidth = 3.0
+
+	// maxLinkDistance is how far a detected door or window may sit from a wall
+	maxLinkDistance = 0.5
+	// defaultOpeningHalfWidth stands in for detected openings without a bbox
+	defaultOpeningHalfWidth = 0.4
+)
+
+// wallGrid is a column × row occupancy grid over a wall's surface
+type wallGrid struct {
+	cell     float64
+	occupied [][]bool
+}
+
+func (g wallGrid) rows() int {
+	if len(g.occupied) == 0 {
+		return 0
+	}
+	return len(g.occupied[0])
+}
+
+func (g wallGrid) row(height float64) int {
+	return int(height / g.cell)
+}
+
+// clear reports whether column col has no points between from and to
+func (g wallGrid) clear(col int, from, to float64) bool {
+	for r := g.row(from); r < g.rows() && float64(r)*g.cell < to; r++ {
+		if g.occupied[col][r] {
+			return false
+		}
+	}
+	return true
+}
+
+// gapExtent returns the bottom and top of the gap around [from, to) in columns c0..c1
+func (g wallGrid) gapExtent(c0, c1 int, from, to, height float64) (float64, float64) {
+	bottom, top := 0.0, height
+	for c := c0; c <= c1; c++ {
+		for r := g.row(from) - 1; r >= 0; r-- {
+			if g.occupied[c][r] {
+				bottom = math.Max(bottom, float64(r+1)*g.cell)
+				break
+			}
+		}
+		for r := g.row(to); r < g.rows(); r++ {
+			if g.occupied[c][r] {
+				top = math.Min(top, float64(r)*g.cell)
+				break
+			}
+		}
+	}
+	return bottom, top
+}
+
+// findOpenings bins the points near a wall into a grid along its length and
+// height and reports runs of columns that are empty where a door or window
+// would be. It also records how much of the wall was observed.
+func findOpenings(points [][]float64, w *Wall, opts Options) []Opening {
+	dir := w.direction()
+	length := w.Length()
+	cols := int(math.Ceil(length / opts.CellSize))
+	rows := int(math.Ceil(w.Height / opts.CellSize))
+	if cols < 3 || rows < 1 {
+		return nil
+	}
+
+	g := wallGrid{cell: opts.CellSize, occupied: make([][]bool, cols)}
+	for c := range g.occupied {
+		g.occupied[c] = make([]bool, rows)
+	}
+	reach := 2 * opts.WallThreshold
+	for _, p := range points {
+		dx, dz := p[0]-w.Start[0], p[2]-w.Start[1]
+		if math.Abs(dx*w.Normal[0]+dz*w.Normal[1]) > reach {
+			continue
+		}
+		t := dx*dir[0] + dz*dir[1]
+		if t < 0 || t >= length || p[1] < 0 || p[1] >= w.Height {
+			continue
+		}
+		c, r := int(t/g.cell), g.row(p[1])
+		if c < cols && r < rows {
+			g.occupied[c][r] = true
+		}
+	}
+
+	observed := 0
+	door := make([]bool, cols)
+	window := make([]bool, cols)
+	for c := 0; c < cols; c++ {
+		if !g.clear(c, 0, w.Height) {
+			observed++
+		}
+		door[c] = g.clear(c, doorClearFrom, math.Min(doorClearTo, w.Height))
+		window[c] = !door[c] && g.clear(c, windowClearFrom, windowClearTo) && !g.clear(c, sillFrom, sillTo)
+	}
+	w.Coverage = float64(observed) / float64(cols)
+
+	var openings []Opening
+	for _, run := range runs(door, minDoorWidth, maxDoorWidth, g.cell) {
+		_, top := g.gapExtent(run[0], run[1], doorClearFrom, doorClearTo, w.Height)
+		openings = append(openings, w.opening(models.ObjectTypeDoor, run, g.cell, 0, top))
+	}
+	for _, run := range runs(window, minWindowWidth, maxWindowWidth, g.cell) {
+		bottom, top := g.gapExtent(run[0], run[1], windowClearFrom, windowClearTo, w.Height)
+		openings = append(openings, w.opening(models.ObjectTypeWindow, run, g.cell, bottom, top))
+	}
+	return openings
+}
+
+// runs returns the [first, last] column of each run of set flags whose width
+// is within limits. Runs touching either end of the wall are where the wall
+// stops being observed, not openings, and are skipped.
+func runs(flags []bool, minWidth, maxWidth, cell float64) [][2]int {
+	var out [][2]int
+	for c := 0; c < len(flags); {
+		if !flags[c] {
+			c++
+			continue
+		}
+		start := c
+		for c < len(flags) && flags[c] {
+			c++
+		}
+		end := c - 1
+		width := float64(end-start+1) * cell
+		if start > 0 && end < len(flags)-1 && width >= minWidth-1e-9 && width <= maxWidth+1e-9 {
+			out = append(out, [2]int{start, end})
+		}
+	}
+	return out
+}
+
+func (w *Wall) opening(typ models.ObjectType, run [2]int, cell, bottom, top float64) Opening {
+	return Opening{
+		Type:   typ,
+		WallID: w.ID,
+		Start:  roundPoint(w.pointAt(float64(run[0]) * cell)),
+		End:    roundPoint(w.pointAt(float64(run[1]+1) * cell)),
+		Bottom: round3(bottom),
+		Top:    round3(top),
+	}
+}
+
+// pointAt returns the point t meters along the wall from its start
+func (w Wall) pointAt(t float64) Point2 {
+	dir := w.direction()
+	return Point2{w.Start[0] + t*dir[0], w.Start[1] + t*dir[1]}
+}
+
+// linkDetected attaches detected door and window objects to the nearest wall.
+// A detected object overlapping a geometric opening of the same type claims
+// it; otherwise it is added as an opening of its own.
+func linkDetected(walls []Wall, openings []Opening, detected []models.SceneObject) []Opening {
+	for _, obj := range detected {
+		if obj.Type != models.ObjectTypeDoor && obj.Type != models.ObjectTypeWindow {
+			continue
+		}
+		corners := footprint(obj)
+		center := Point2{(corners[0][0] + corners[2][0]) / 2, (corners[0][1] + corners[2][1]) / 2}
+
+		best, bestDist := -1, maxLinkDistance
+		for i, w := range walls {
+			dir := w.direction()
+			dx, dz := center[0]-w.Start[0], center[1]-w.Start[1]
+			t := dx*dir[0] + dz*dir[1]
+			if t < -maxLinkDistance || t > w.Length()+maxLinkDistance {
+				continue
+			}
+			if d := math.Abs(dx*w.Normal[0] + dz*w.Normal[1]); d < bestDist {
+				best, bestDist = i, d
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		w := walls[best]
+
+		t0, t1 := w.project(corners[:])
+		matched := false
+		for j := range openings {
+			o := &openings[j]
+			if o.WallID != w.ID || o.Type != obj.Type || o.ObjectID != "" {
+				continue
+			}
+			o0, o1 := w.project([]Point2{o.Start, o.End})
+			if t0 <= o1 && o0 <= t1 {
+				o.ObjectID = obj.ID
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		t0, t1 = math.Max(t0, 0), math.Min(t1, w.Length())
+		if t1 <= t0 {
+			continue
+		}
+		bottom, top := 0.0, w.Height
+		if !isZeroBox(obj.BBox) {
+			bottom, top = math.Max(obj.BBox.Min[1], 0), math.Min(obj.BBox.Max[1], w.Height)
+		}
+		openings = append(openings, Opening{
+			Type:     obj.Type,
+			WallID:   w.ID,
+			Start:    roundPoint(w.pointAt(t0)),
+			End:      roundPoint(w.pointAt(t1)),
+			Bottom:   round3(bottom),
+			Top:      round3(top),
+			ObjectID: obj.ID,
+		})
+	}
+	return openings
+}
+
+// project returns the range of positions of points along the wall
+func (w Wall) project(points []Point2) (float64, float64) {
+	dir := w.direction()
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		t := (p[0]-w.Start[0])*dir[0] + (p[1]-w.Start[1])*dir[1]
+		lo, hi = math.Min(lo, t), math.Max(hi, t)
+	}
+	return lo, hi
+}
+
+// footprint returns the floor-plan rectangle of an object's world-space bbox,
+// or a small square around its position when it has none
+func footprint(obj models.SceneObject) [4]Point2 {
+	minX, minZ, maxX, maxZ := obj.BBox.Min[0], obj.BBox.Min[2], obj.BBox.Max[0], obj.BBox.Max[2]
+	if isZeroBox(obj.BBox) {
+		p := obj.Pose.Position
+		h := defaultOpeningHalfWidth
+		minX, minZ, maxX, maxZ = p[0]-h, p[2]-h, p[0]+h, p[2]+h
+	}
+	return [4]Point2{{minX, minZ}, {maxX, minZ}, {maxX, maxZ}, {minX, maxZ}}
+}
+
+func isZeroBox(bb models.BoundingBox) bool {
+	return bb.Min == [3]float64{} && bb.Max == [3]float64{}
+}
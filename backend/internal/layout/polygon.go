@@ -0,0 +1,77 @@
+package layout
+
+import (
+	"math"
+	"sort"
+)
+
+// Point2 is a point on the floor plane: [x, z] in meters
+type Point2 [2]float64
+
+// ConvexHull returns the hull of points in counter-clockwise order (Andrew's monotone chain)
+func ConvexHull(points []Point2) []Point2 {
+	if len(points) < 3 {
+		return append([]Point2(nil), points...)
+	}
+	pts := append([]Point2(nil), points...)
+	sort.Slice(pts, func(i, j int) bool {
+		if pts[i][0] != pts[j][0] {
+			return pts[i][0] < pts[j][0]
+		}
+		return pts[i][1] < pts[j][1]
+	})
+
+	hull := make([]Point2, 0, 2*len(pts))
+	for _, p := range pts {
+		for len(hull) >= 2 && cross2(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(pts) - 2; i >= 0; i-- {
+		p := pts[i]
+		for len(hull) >= lower && cross2(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return hull[:len(hull)-1]
+}
+
+// PolygonArea returns the unsigned area of a simple polygon
+func PolygonArea(poly []Point2) float64 {
+	var a float64
+	for i := range poly {
+		j := (i + 1) % len(poly)
+		a += poly[i][0]*poly[j][1] - poly[j][0]*poly[i][1]
+	}
+	return math.Abs(a) / 2
+}
+
+// PointInPolygon reports whether p lies inside poly (even-odd rule)
+func PointInPolygon(p Point2, poly []Point2) bool {
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a[1] > p[1]) != (b[1] > p[1]) &&
+			p[0] < (b[0]-a[0])*(p[1]-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// cross2 is the z component of (b-a)×(c-a); positive for a left turn
+func cross2(a, b, c Point2) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+// roundPoint limits coordinates to millimetres for stable JSON
+func roundPoint(p Point2) Point2 {
+	return Point2{round3(p[0]), round3(p[1])}
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
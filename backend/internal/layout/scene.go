@@ -0,0 +1,231 @@
+package layout
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/sherlockos/backend/internal/models"
+)
+
+const (
+	// maxObstacleFloorShare drops "obstacles" that cover most of the floor,
+	// such as a whole-scene proxy object
+	maxObstacleFloorShare = 0.5
+	// maxObstacleBottom ignores objects hanging above head height
+	maxObstacleBottom = 1.5
+	// minExtent keeps fallback bounds from collapsing on a single point
+	minExtent = 1.0
+)
+
+// SceneObjects returns the walls and the openings not already represented by
+// a detected object as Tier 0 proxy objects with world-space extents
+func (l *Layout) SceneObjects() []models.SceneObject {
+	objects := make([]models.SceneObject, 0, len(l.Walls)+len(l.Openings))
+	for i, w := range l.Walls {
+		openingIDs := []string{}
+		for _, o := range l.Openings {
+			if o.WallID == w.ID {
+				openingIDs = append(openingIDs, o.ID)
+			}
+		}
+		obj := proxyObject(w.ID, models.ObjectTypeWall, fmt.Sprintf("Wall %d", i+1), w.Start, w.End, w.Normal, l.Thickness, 0, w.Height)
+		obj.Confidence = wallConfidence(w)
+		obj.Metadata["length"] = round3(w.Length())
+		obj.Metadata["height"] = round3(w.Height)
+		obj.Metadata["thickness"] = l.Thickness
+		obj.Metadata["normal"] = roundPoint(w.Normal)
+		obj.Metadata["segment"] = [2]Point2{roundPoint(w.Start), roundPoint(w.End)}
+		obj.Metadata["openings"] = openingIDs
+		objects = append(objects, obj)
+	}
+
+	for _, o := range l.Openings {
+		if o.ObjectID != "" {
+			continue
+		}
+		var normal Point2
+		for _, w := range l.Walls {
+			if w.ID == o.WallID {
+				normal = w.Normal
+			}
+		}
+		label := "Door opening"
+		if o.Type == models.ObjectTypeWindow {
+			label = "Window opening"
+		}
+		obj := proxyObject(o.ID, o.Type, label, o.Start, o.End, normal, l.Thickness, o.Bottom, o.Top)
+		obj.Confidence = 0.7
+		obj.Metadata["wall_id"] = o.WallID
+		obj.Metadata["width"] = round3(o.Width())
+		obj.Metadata["height"] = round3(o.Top - o.Bottom)
+		if o.Type == models.ObjectTypeWindow {
+			obj.Metadata["sill_height"] = o.Bottom
+		}
+		objects = append(objects, obj)
+	}
+	return objects
+}
+
+// proxyObject builds a vertical slab from start to end, posed at the bottom
+// of its midpoint with local +X along the segment
+func proxyObject(id string, typ models.ObjectType, label string, start, end, normal Point2, thickness, bottom, top float64) models.SceneObject {
+	mid := Point2{(start[0] + end[0]) / 2, (start[1] + end[1]) / 2}
+	yaw := math.Atan2(-(end[1] - start[1]), end[0]-start[0])
+
+	pose := models.NewDefaultPose()
+	pose.Position = [3]float64{round3(mid[0]), round3(bottom), round3(mid[1])}
+	pose.Rotation = [4]float64{math.Cos(yaw / 2), 0, math.Sin(yaw / 2), 0}
+
+	half := Point2{normal[0] * thickness / 2, normal[1] * thickness / 2}
+	bb := models.BoundingBox{
+		Min: [3]float64{math.Inf(1), round3(bottom), math.Inf(1)},
+		Max: [3]float64{math.Inf(-1), round3(top), math.Inf(-1)},
+	}
+	for _, p := range []Point2{start, end} {
+		for _, s := range []float64{-1, 1} {
+			x, z := round3(p[0]+s*half[0]), round3(p[1]+s*half[1])
+			bb.Min[0], bb.Max[0] = math.Min(bb.Min[0], x), math.Max(bb.Max[0], x)
+			bb.Min[2], bb.Max[2] = math.Min(bb.Min[2], z), math.Max(bb.Max[2], z)
+		}
+	}
+
+	return models.SceneObject{
+		ID:              id,
+		Type:            typ,
+		Label:           label,
+		Pose:            pose,
+		BBox:            bb,
+		State:           models.ObjectStateVisible,
+		EvidenceIDs:     []string{},
+		SourceCommitIDs: []string{},
+		Metadata: map[string]interface{}{
+			"tier":   0,
+			"source": Source,
+		},
+	}
+}
+
+// wallConfidence grows with how much of the wall surface was observed
+func wallConfidence(w Wall) float64 {
+	return math.Round((0.5+0.45*w.Coverage)*100) / 100
+}
+
+// PassableArea returns a passable_area constraint covering the floor outline,
+// listing the footprints of furniture-like obstacles standing on it. ok is
+// false when no floor was found.
+func (l *Layout) PassableArea(objects []models.SceneObject) (models.Constraint, bool) {
+	if len(l.Floor) < 3 {
+		return models.Constraint{}, false
+	}
+	area := l.FloorArea()
+
+	obstacles := []map[string]interface{}{}
+	for _, obj := range objects {
+		switch obj.Type {
+		case models.ObjectTypeFurniture, models.ObjectTypeVehicle, models.ObjectTypeOther:
+		default:
+			continue
+		}
+		if isZeroBox(obj.BBox) || obj.BBox.Min[1] > maxObstacleBottom {
+			continue
+		}
+		corners := footprint(obj)
+		size := (corners[2][0] - corners[0][0]) * (corners[2][1] - corners[0][1])
+		center := Point2{(corners[0][0] + corners[2][0]) / 2, (corners[0][1] + corners[2][1]) / 2}
+		if size > maxObstacleFloorShare*area || !PointInPolygon(center, l.Floor) {
+			continue
+		}
+		polygon := make([]Point2, len(corners))
+		for i, c := range corners {
+			polygon[i] = roundPoint(c)
+		}
+		obstacles = append(obstacles, map[string]interface{}{
+			"object_id": obj.ID,
+			"polygon":   polygon,
+		})
+	}
+
+	confidence := 0.6
+	if len(l.Walls) >= 3 {
+		confidence = 0.9
+	}
+	return models.Constraint{
+		ID:          uuid.New().String(),
+		Type:        models.ConstraintTypePassableArea,
+		Description: fmt.Sprintf("Walkable floor of %.1f m² bounded by %d walls, %d obstacles", area, len(l.Walls), len(obstacles)),
+		Params: map[string]interface{}{
+			"polygon":   l.Floor,
+			"obstacles": obstacles,
+			"area":      round3(area),
+			"source":    Source,
+		},
+		Confidence: confidence,
+	}, true
+}
+
+// IsDerived reports whether a scene object was produced by layout extraction
+func IsDerived(obj models.SceneObject) bool {
+	return obj.Metadata != nil && obj.Metadata["source"] == Source
+}
+
+// IsDerivedConstraint reports whether a constraint was produced by layout extraction
+func IsDerivedConstraint(c models.Constraint) bool {
+	return c.Params != nil && c.Params["source"] == Source
+}
+
+// DefaultBounds is the scene extent used before anything has been reconstructed
+func DefaultBounds() models.BoundingBox {
+	return models.BoundingBox{
+		Min: [3]float64{-7, 0, -6},
+		Max: [3]float64{7, 4, 6},
+	}
+}
+
+// SceneBounds returns the scene extent when no room layout is available: the
+// point cloud bounds if known, otherwise the tight union of object boxes and
+// positions. Nothing is padded beyond keeping each axis at least minExtent wide.
+func SceneBounds(objects []models.SceneObject, cloud *models.BoundingBox) models.BoundingBox {
+	if cloud != nil && !isZeroBox(*cloud) {
+		return ensureExtent(*cloud)
+	}
+	if len(objects) == 0 {
+		return DefaultBounds()
+	}
+
+	bb := models.BoundingBox{
+		Min: [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)},
+		Max: [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)},
+	}
+	include := func(p [3]float64) {
+		for i := 0; i < 3; i++ {
+			bb.Min[i] = math.Min(bb.Min[i], p[i])
+			bb.Max[i] = math.Max(bb.Max[i], p[i])
+		}
+	}
+	for _, obj := range objects {
+		if isZeroBox(obj.BBox) {
+			include(obj.Pose.Position)
+			continue
+		}
+		include(obj.BBox.Min)
+		include(obj.BBox.Max)
+	}
+	return ensureExtent(bb)
+}
+
+// ensureExtent widens degenerate axes about their center; the vertical axis
+// grows upward so the floor stays put
+func ensureExtent(bb models.BoundingBox) models.BoundingBox {
+	for i := 0; i < 3; i++ {
+		if bb.Max[i]-bb.Min[i] < minExtent {
+			if i == 1 {
+				bb.Max[1] = bb.Min[1] + minExtent
+				continue
+			}
+			center := (bb.Max[i] + bb.Min[i]) / 2
+			bb.Min[i], bb.Max[i] = center-minExtent/2, center+minExtent/2
+		}
+	}
+	return bb
+}
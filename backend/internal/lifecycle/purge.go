@@ -0,0 +1,123 @@
+// Package lifecycle purges deleted cases. Deleting a case only marks it;
+// once its retention window passes, the purger removes the case's stored
+// files and then the case itself with everything recorded under it.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/clients"
+	"github.com/sherlockos/backend/internal/db"
+)
+
+// DefaultInterval is how often Run looks for expired cases
+const DefaultInterval = time.Hour
+
+// sweepBatch is how many expired cases one sweep purges at most
+const sweepBatch = 50
+
+// ErrNoStorage is returned when a case has stored files but no storage
+// client is configured to delete them
+var ErrNoStorage = errors.New("storage not configured")
+
+// Store is the case data the purger reads and deletes
+type Store interface {
+	GetCaseStorageObjects(ctx context.Context, caseID uuid.UUID) ([]db.StoredObject, error)
+	ListCasesDeletedBefore(ctx context.Context, before time.Time, limit int) ([]uuid.UUID, error)
+	PurgeCase(ctx context.Context, id uuid.UUID) error
+}
+
+// Purger permanently deletes cases and their stored files
+type Purger struct {
+	store     Store
+	storage   clients.StorageClient
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewPurger creates a purger for cases deleted longer than retention ago
+func NewPurger(store Store, storage clients.StorageClient, retention time.Duration) *Purger {
+	return &Purger{store: store, storage: storage, retention: retention, now: time.Now}
+}
+
+// Retention returns how long deleted cases are kept
+func (p *Purger) Retention() time.Duration {
+	return p.retention
+}
+
+// Purge deletes a case's stored files, then the case. If any file can't be
+// deleted the case is kept, so that its remaining files can still be found
+// and a later purge can finish the job. It returns the number of files
+// deleted.
+func (p *Purger) Purge(ctx context.Context, caseID uuid.UUID) (int, error) {
+	objects, err := p.store.GetCaseStorageObjects(ctx, caseID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list stored files: %w", err)
+	}
+	if len(objects) > 0 && p.storage == nil {
+		return 0, ErrNoStorage
+	}
+
+	deleted := 0
+	var failed []string
+	for _, o := range objects {
+		if err := p.storage.Delete(ctx, o.Bucket, o.Key); err != nil {
+			log.Printf("Warning: failed to delete %s/%s: %v", o.Bucket, o.Key, err)
+			failed = append(failed, o.Key)
+			continue
+		}
+		deleted++
+	}
+	if len(failed) > 0 {
+		return deleted, fmt.Errorf("failed to delete %d of %d stored files", len(failed), len(objects))
+	}
+
+	if err := p.store.PurgeCase(ctx, caseID); err != nil {
+		return deleted, fmt.Errorf("failed to delete case: %w", err)
+	}
+	return deleted, nil
+}
+
+// PurgeExpired purges cases whose retention window has passed, returning
+// how many were purged. A case that fails is logged and left for the next
+// sweep.
+func (p *Purger) PurgeExpired(ctx context.Context) (int, error) {
+	ids, err := p.store.ListCasesDeletedBefore(ctx, p.now().Add(-p.retention), sweepBatch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired cases: %w", err)
+	}
+	purged := 0
+	for _, id := range ids {
+		if _, err := p.Purge(ctx, id); err != nil {
+			log.Printf("Warning: failed to purge case %s: %v", id, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// Run purges expired cases every interval until ctx is done
+func (p *Purger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := p.PurgeExpired(ctx); err != nil {
+			log.Printf("Warning: case purge failed: %v", err)
+		} else if n > 0 {
+			log.Printf("Purged %d deleted cases", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/clients"
+	"github.com/sherlockos/backend/internal/db"
+)
+
+// fakeStore holds deleted cases and their stored files in memory
+type fakeStore struct {
+	deletedAt map[uuid.UUID]time.Time
+	objects   map[uuid.UUID][]db.StoredObject
+	purged    []uuid.UUID
+}
+
+func (s *fakeStore) GetCaseStorageObjects(ctx context.Context, caseID uuid.UUID) ([]db.StoredObject, error) {
+	return s.objects[caseID], nil
+}
+
+func (s *fakeStore) ListCasesDeletedBefore(ctx context.Context, before time.Time, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for id, at := range s.deletedAt {
+		if at.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (s *fakeStore) PurgeCase(ctx context.Context, id uuid.UUID) error {
+	s.purged = append(s.purged, id)
+	delete(s.deletedAt, id)
+	return nil
+}
+
+func TestPurger_Purge(t *testing.T) {
+	caseID := uuid.New()
+	store := &fakeStore{objects: map[uuid.UUID][]db.StoredObject{caseID: {
+		{Bucket: "case-assets", Key: "cases/" + caseID.String() + "/scans/a.jpg"},
+		{Bucket: "assets", Key: "cases/" + caseID.String() + "/reports/report.pdf"},
+	}}}
+	var deleted []string
+	storage := &clients.MockStorageClient{DeleteFunc: func(ctx context.Context, bucket, key string) error {
+		deleted = append(deleted, bucket+"/"+key)
+		return nil
+	}}
+
+	n, err := NewPurger(store, storage, time.Hour).Purge(context.Background(), caseID)
+	if err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if n != 2 || len(deleted) != 2 || deleted[1] != "assets/cases/"+caseID.String()+"/reports/report.pdf" {
+		t.Errorf("Purge() deleted %d: %v", n, deleted)
+	}
+	if len(store.purged) != 1 || store.purged[0] != caseID {
+		t.Errorf("purged cases = %v", store.purged)
+	}
+}
+
+func TestPurger_Purge_KeepsCaseOnFailure(t *testing.T) {
+	caseID := uuid.New()
+	store := &fakeStore{objects: map[uuid.UUID][]db.StoredObject{caseID: {
+		{Bucket: "case-assets", Key: "a"}, {Bucket: "case-assets", Key: "b"},
+	}}}
+	storage := &clients.MockStorageClient{DeleteFunc: func(ctx context.Context, bucket, key string) error {
+		if key == "a" {
+			return errors.New("timeout")
+		}
+		return nil
+	}}
+
+	n, err := NewPurger(store, storage, time.Hour).Purge(context.Background(), caseID)
+	if err == nil || n != 1 {
+		t.Errorf("Purge() = %d, %v, want 1 deleted and an error", n, err)
+	}
+	if len(store.purged) != 0 {
+		t.Error("Purge() deleted the case although a file remains")
+	}
+
+	// Without storage, files can't be removed at all
+	if _, err := NewPurger(store, nil, time.Hour).Purge(context.Background(), caseID); !errors.Is(err, ErrNoStorage) {
+		t.Errorf("Purge() without storage error = %v, want ErrNoStorage", err)
+	}
+	// A case without files needs no storage
+	if _, err := NewPurger(store, nil, time.Hour).Purge(context.Background(), uuid.New()); err != nil {
+		t.Errorf("Purge() of a case without files error = %v", err)
+	}
+}
+
+func TestPurger_PurgeExpired(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	expired, recent := uuid.New(), uuid.New()
+	store := &fakeStore{deletedAt: map[uuid.UUID]time.Time{
+		expired: now.Add(-31 * 24 * time.Hour),
+		recent:  now.Add(-24 * time.Hour),
+	}}
+	p := NewPurger(store, &clients.MockStorageClient{}, 30*24*time.Hour)
+	p.now = func() time.Time { return now }
+
+	n, err := p.PurgeExpired(context.Background())
+	if err != nil {
+		t.Fatalf("PurgeExpired() error = %v", err)
+	}
+	if n != 1 || len(store.purged) != 1 || store.purged[0] != expired {
+		t.Errorf("PurgeExpired() = %d, purged %v", n, store.purged)
+	}
+}
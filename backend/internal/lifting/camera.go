@@ -0,0 +1,170 @@
+package lifting
+
+import (
+	"math"
+
+	"github.com/sherlockos/backend/internal/models"
+	"github.com/sherlockos/backend/internal/pointcloud"
+)
+
+// Camera is a pinhole camera with a world-to-camera transform. The image is
+// assumed to be centred on the principal point, so its size is 2cx × 2cy.
+type Camera struct {
+	AssetKey string
+
+	r              [3][3]float64
+	t              [3]float64
+	fx, fy, cx, cy float64
+}
+
+// NewCamera builds a camera from a pose. Poses without usable intrinsics or
+// extrinsics, such as the intrinsics-only poses seeded at ingest, are rejected.
+func NewCamera(pose models.CameraPose) (*Camera, bool) {
+	in, ex := pose.Intrinsics, pose.Extrinsics
+	if in.Fx <= 0 || in.Fy <= 0 || in.Cx <= 0 || in.Cy <= 0 || len(ex.Translation) != 3 {
+		return nil, false
+	}
+	c := &Camera{
+		AssetKey: pose.AssetKey,
+		t:        [3]float64{ex.Translation[0], ex.Translation[1], ex.Translation[2]},
+		fx:       in.Fx, fy: in.Fy, cx: in.Cx, cy: in.Cy,
+	}
+	switch len(ex.Rotation) {
+	case 9:
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				c.r[i][j] = ex.Rotation[i*3+j]
+			}
+		}
+	case 4:
+		c.r = quatMatrix([4]float64{ex.Rotation[0], ex.Rotation[1], ex.Rotation[2], ex.Rotation[3]})
+	default:
+		return nil, false
+	}
+	if math.Abs(det(c.r)-1) > 0.01 {
+		return nil, false
+	}
+	return c, true
+}
+
+// NewCameras builds a camera for every usable pose, keyed by asset key, and
+// moves it into the frame the point cloud was transformed into. Later poses
+// for the same asset replace earlier ones.
+func NewCameras(frame pointcloud.Transform, poses ...[]models.CameraPose) map[string]*Camera {
+	cameras := map[string]*Camera{}
+	for _, list := range poses {
+		for _, pose := range list {
+			if c, ok := NewCamera(pose); ok {
+				cameras[pose.AssetKey] = c.Transformed(frame)
+			}
+		}
+	}
+	return cameras
+}
+
+// Transformed returns the camera as seen in a world moved by t
+func (c *Camera) Transformed(t pointcloud.Transform) *Camera {
+	// x' = Q·x + s, so x_cam = R·Qᵀ·x' + (t - R·Qᵀ·s)
+	q := quatMatrix(t.Rotation)
+	out := *c
+	out.r = mul(c.r, transpose(q))
+	rs := apply(out.r, t.Translation)
+	out.t = [3]float64{c.t[0] - rs[0], c.t[1] - rs[1], c.t[2] - rs[2]}
+	return &out
+}
+
+// Pose returns the camera as a CameraPose with a row-major rotation matrix
+func (c *Camera) Pose() models.CameraPose {
+	rot := make([]float64, 0, 9)
+	for i := 0; i < 3; i++ {
+		rot = append(rot, c.r[i][0], c.r[i][1], c.r[i][2])
+	}
+	return models.CameraPose{
+		AssetKey:   c.AssetKey,
+		Intrinsics: models.CameraIntrinsics{Fx: c.fx, Fy: c.fy, Cx: c.cx, Cy: c.cy},
+		Extrinsics: models.CameraExtrinsics{Rotation: rot, Translation: []float64{c.t[0], c.t[1], c.t[2]}},
+	}
+}
+
+// Center returns the camera's position in world coordinates
+func (c *Camera) Center() [3]float64 {
+	p := apply(transpose(c.r), c.t)
+	return [3]float64{-p[0], -p[1], -p[2]}
+}
+
+// Ray returns the unit world-space direction through a normalized image point
+func (c *Camera) Ray(u, v float64) [3]float64 {
+	d := [3]float64{(u*2*c.cx - c.cx) / c.fx, (v*2*c.cy - c.cy) / c.fy, 1}
+	return normalize(apply(transpose(c.r), d))
+}
+
+// Project returns the normalized image coordinates and depth of a world point.
+// Points behind the camera are not visible.
+func (c *Camera) Project(p [3]float64) (u, v, depth float64, ok bool) {
+	x := apply(c.r, p)
+	x = [3]float64{x[0] + c.t[0], x[1] + c.t[1], x[2] + c.t[2]}
+	if x[2] <= 0 {
+		return 0, 0, 0, false
+	}
+	u = (c.fx*x[0]/x[2] + c.cx) / (2 * c.cx)
+	v = (c.fy*x[1]/x[2] + c.cy) / (2 * c.cy)
+	return u, v, x[2], true
+}
+
+// quatMatrix converts a [w, x, y, z] quaternion into a rotation matrix
+func quatMatrix(q [4]float64) [3][3]float64 {
+	n := math.Sqrt(q[0]*q[0] + q[1]*q[1] + q[2]*q[2] + q[3]*q[3])
+	if n == 0 {
+		return [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	}
+	w, x, y, z := q[0]/n, q[1]/n, q[2]/n, q[3]/n
+	return [3][3]float64{
+		{1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y)},
+		{2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x)},
+		{2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y)},
+	}
+}
+
+func mul(a, b [3][3]float64) [3][3]float64 {
+	var out [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				out[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return out
+}
+
+func transpose(m [3][3]float64) [3][3]float64 {
+	var out [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			out[i][j] = m[j][i]
+		}
+	}
+	return out
+}
+
+func apply(m [3][3]float64, v [3]float64) [3]float64 {
+	return [3]float64{
+		m[0][0]*v[0] + m[0][1]*v[1] + m[0][2]*v[2],
+		m[1][0]*v[0] + m[1][1]*v[1] + m[1][2]*v[2],
+		m[2][0]*v[0] + m[2][1]*v[1] + m[2][2]*v[2],
+	}
+}
+
+func det(m [3][3]float64) float64 {
+	return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+}
+
+func normalize(v [3]float64) [3]float64 {
+	l := math.Sqrt(v[0]*v[0] + v[1]*v[1] + v[2]*v[2])
+	if l == 0 {
+		return v
+	}
+	return [3]float64{v[0] / l, v[1] / l, v[2] / l}
+}
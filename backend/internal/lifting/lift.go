@@ -0,0 +1,292 @@
+// Package lifting places scene analysis detections in the reconstructed
+// scene. Each sighting's 2D box is back-projected through its image's camera;
+// the rays are triangulated and the point cloud inside every sighting's box
+// gives the object's position and extent.
+package lifting
+
+import (
+	"math"
+	"sort"
+
+	"github.com/sherlockos/backend/internal/detection"
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// Lifting methods
+const (
+	MethodPointCloud   = "point_cloud"
+	MethodTriangulated = "triangulated"
+)
+
+// Options tunes lifting
+type Options struct {
+	// MinPoints is how many cloud points a sighting's boxes must contain to
+	// size the object from the cloud
+	MinPoints int
+	// DepthBand keeps, for a single sighting, only points this many meters
+	// behind the nearest surface in its box
+	DepthBand float64
+	// ResidualScale is the residual in meters at which confidence drops to 1/e
+	ResidualScale float64
+	// MinExtent is the smallest box edge in meters
+	MinExtent float64
+}
+
+// DefaultOptions returns the options used by the reconstruction worker
+func DefaultOptions() Options {
+	return Options{MinPoints: 5, DepthBand: 0.75, ResidualScale: 0.25, MinExtent: 0.1}
+}
+
+// sighting is one detection box seen through a camera
+type sighting struct {
+	camera *Camera
+	box    models.BBox
+	origin [3]float64
+	dir    [3]float64
+}
+
+// Result is a detection placed in 3D
+type Result struct {
+	ObjectID    string
+	Position    [3]float64
+	BBox        models.BoundingBox
+	Residual    float64 // RMS distance in meters from Position to the sighting rays
+	Confidence  float64
+	Views       int
+	CloudPoints int
+	Method      string
+}
+
+// Lift places every object whose sightings have a box and a known camera.
+// Objects seen by no posed camera, or seen once with no cloud behind the box,
+// are left out.
+func Lift(objects []models.SceneObject, cameras map[string]*Camera, cloud [][]float64, opts Options) []Result {
+	var results []Result
+	for _, obj := range objects {
+		if r, ok := liftObject(obj, cameras, cloud, opts); ok {
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+// Apply moves an object to its lifted position and extent and records the
+// lifting residual as its confidence
+func (r Result) Apply(obj *models.SceneObject) {
+	obj.Pose.Position = r.Position
+	if obj.Pose.Rotation == [4]float64{} {
+		obj.Pose.Rotation = [4]float64{1, 0, 0, 0}
+	}
+	obj.BBox = r.BBox
+	obj.Confidence = r.Confidence
+	metadata := map[string]interface{}{}
+	for k, v := range obj.Metadata {
+		metadata[k] = v
+	}
+	metadata["lifting"] = map[string]interface{}{
+		"method":       r.Method,
+		"residual_m":   r.Residual,
+		"views":        r.Views,
+		"cloud_points": r.CloudPoints,
+	}
+	obj.Metadata = metadata
+}
+
+func liftObject(obj models.SceneObject, cameras map[string]*Camera, cloud [][]float64, opts Options) (Result, bool) {
+	var sightings []sighting
+	for _, src := range detection.Sources(obj) {
+		cam, ok := cameras[src.ImageKey]
+		if !ok || src.BoundingBox == nil || src.BoundingBox.Width <= 0 || src.BoundingBox.Height <= 0 {
+			continue
+		}
+		b := *src.BoundingBox
+		sightings = append(sightings, sighting{
+			camera: cam,
+			box:    b,
+			origin: cam.Center(),
+			dir:    cam.Ray(b.X+b.Width/2, b.Y+b.Height/2),
+		})
+	}
+	if len(sightings) == 0 {
+		return Result{}, false
+	}
+
+	r := Result{ObjectID: obj.ID, Views: len(sightings)}
+	points := supportingPoints(sightings, cloud, opts)
+	switch {
+	case len(points) >= opts.MinPoints:
+		r.Method = MethodPointCloud
+		r.CloudPoints = len(points)
+		r.Position, r.BBox = extent(points, opts.MinExtent)
+	case len(sightings) >= 2:
+		p, ok := triangulate(sightings)
+		if !ok {
+			return Result{}, false
+		}
+		r.Method = MethodTriangulated
+		r.Position = p
+		r.BBox = recentre(obj.BBox, p, opts.MinExtent)
+	default:
+		return Result{}, false
+	}
+
+	r.Residual = round2(residual(sightings, r.Position))
+	r.Confidence = math.Exp(-r.Residual / opts.ResidualScale)
+	// One sighting can't be triangulated; its residual only says how far the
+	// cloud is from the box's centre
+	if len(sightings) == 1 {
+		r.Confidence /= 2
+	}
+	r.Confidence = round2(r.Confidence)
+	return r, true
+}
+
+// supportingPoints returns the cloud points inside every sighting's box. With
+// a single sighting the box's frustum reaches past the object, so only the
+// points near the closest surface are kept.
+func supportingPoints(sightings []sighting, cloud [][]float64, opts Options) [][3]float64 {
+	var points [][3]float64
+	var depths []float64
+	for _, raw := range cloud {
+		if len(raw) < 3 {
+			continue
+		}
+		p := [3]float64{raw[0], raw[1], raw[2]}
+		inside := true
+		var depth float64
+		for _, s := range sightings {
+			u, v, d, ok := s.camera.Project(p)
+			if !ok || u < s.box.X || u > s.box.X+s.box.Width || v < s.box.Y || v > s.box.Y+s.box.Height {
+				inside = false
+				break
+			}
+			depth = d
+		}
+		if inside {
+			points = append(points, p)
+			depths = append(depths, depth)
+		}
+	}
+	if len(sightings) > 1 || len(points) == 0 {
+		return points
+	}
+
+	nearest := percentile(append([]float64(nil), depths...), 0.1)
+	kept := points[:0]
+	for i, p := range points {
+		if depths[i] <= nearest+opts.DepthBand {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// extent returns the per-axis median of the points and the box between their
+// 5th and 95th percentiles, at least minExtent on every side
+func extent(points [][3]float64, minExtent float64) ([3]float64, models.BoundingBox) {
+	var centre [3]float64
+	var bb models.BoundingBox
+	values := make([]float64, len(points))
+	for axis := 0; axis < 3; axis++ {
+		for i, p := range points {
+			values[i] = p[axis]
+		}
+		centre[axis] = percentile(values, 0.5)
+		bb.Min[axis] = math.Min(percentile(values, 0.05), centre[axis]-minExtent/2)
+		bb.Max[axis] = math.Max(percentile(values, 0.95), centre[axis]+minExtent/2)
+	}
+	return centre, bb
+}
+
+// recentre moves a box to be centred on p, keeping its size
+func recentre(bb models.BoundingBox, p [3]float64, minExtent float64) models.BoundingBox {
+	var out models.BoundingBox
+	for axis := 0; axis < 3; axis++ {
+		half := math.Max(bb.Max[axis]-bb.Min[axis], minExtent) / 2
+		out.Min[axis] = p[axis] - half
+		out.Max[axis] = p[axis] + half
+	}
+	return out
+}
+
+// triangulate returns the point closest to every sighting's ray in the least
+// squares sense. Near-parallel rays and points behind a camera fail.
+func triangulate(sightings []sighting) ([3]float64, bool) {
+	var a [3][3]float64
+	var b [3]float64
+	for _, s := range sightings {
+		m := perpendicular(s.dir)
+		mc := apply(m, s.origin)
+		for i := 0; i < 3; i++ {
+			b[i] += mc[i]
+			for j := 0; j < 3; j++ {
+				a[i][j] += m[i][j]
+			}
+		}
+	}
+	d := det(a)
+	if math.Abs(d) < 1e-6 {
+		return [3]float64{}, false
+	}
+	p := solve(a, b, d)
+	for _, s := range sightings {
+		if _, _, _, ok := s.camera.Project(p); !ok {
+			return [3]float64{}, false
+		}
+	}
+	return p, true
+}
+
+// residual is the RMS distance from p to the sighting rays
+func residual(sightings []sighting, p [3]float64) float64 {
+	var sum float64
+	for _, s := range sightings {
+		off := apply(perpendicular(s.dir), [3]float64{p[0] - s.origin[0], p[1] - s.origin[1], p[2] - s.origin[2]})
+		sum += off[0]*off[0] + off[1]*off[1] + off[2]*off[2]
+	}
+	return math.Sqrt(sum / float64(len(sightings)))
+}
+
+// perpendicular returns I - d·dᵀ, which keeps the part of a vector across d
+func perpendicular(d [3]float64) [3][3]float64 {
+	var m [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			m[i][j] = -d[i] * d[j]
+		}
+		m[i][i]++
+	}
+	return m
+}
+
+// solve solves a·x = b by Cramer's rule given det(a)
+func solve(a [3][3]float64, b [3]float64, d float64) [3]float64 {
+	var x [3]float64
+	for col := 0; col < 3; col++ {
+		m := a
+		for row := 0; row < 3; row++ {
+			m[row][col] = b[row]
+		}
+		x[col] = det(m) / d
+	}
+	return x
+}
+
+// percentile returns the q-th quantile of values, interpolating between
+// neighbours and sorting values in place
+func percentile(values []float64, q float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	pos := q * float64(len(values)-1)
+	i := int(pos)
+	if i+1 >= len(values) {
+		return values[i]
+	}
+	return values[i] + (pos-float64(i))*(values[i+1]-values[i])
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,193 @@
+package lifting
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sherlockos/backend/internal/models"
+	"github.com/sherlockos/backend/internal/pointcloud"
+)
+
+var intrinsics = models.CameraIntrinsics{Fx: 500, Fy: 500, Cx: 320, Cy: 240}
+
+// front looks along +Z from the origin; side looks along -X from (6, 0, 3)
+func cameras(t *testing.T) (front, side *Camera) {
+	t.Helper()
+	front, ok := NewCamera(models.CameraPose{
+		AssetKey:   "front.jpg",
+		Intrinsics: intrinsics,
+		Extrinsics: models.CameraExtrinsics{Rotation: []float64{1, 0, 0, 0}, Translation: []float64{0, 0, 0}},
+	})
+	if !ok {
+		t.Fatal("front camera rejected")
+	}
+	side, ok = NewCamera(models.CameraPose{
+		AssetKey:   "side.jpg",
+		Intrinsics: intrinsics,
+		Extrinsics: models.CameraExtrinsics{
+			Rotation:    []float64{0, 0, 1, 0, 1, 0, -1, 0, 0},
+			Translation: []float64{-3, 0, 6},
+		},
+	})
+	if !ok {
+		t.Fatal("side camera rejected")
+	}
+	return front, side
+}
+
+// cube returns points filling a 0.4 m cube centred on c
+func cube(c [3]float64) [][]float64 {
+	var points [][]float64
+	for x := -0.2; x <= 0.2; x += 0.05 {
+		for y := -0.2; y <= 0.2; y += 0.05 {
+			for z := -0.2; z <= 0.2; z += 0.05 {
+				points = append(points, []float64{c[0] + x, c[1] + y, c[2] + z})
+			}
+		}
+	}
+	return points
+}
+
+// boxFor returns the normalized image box around the projected points
+func boxFor(t *testing.T, cam *Camera, points [][]float64) *models.BBox {
+	t.Helper()
+	minU, minV, maxU, maxV := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, p := range points {
+		u, v, _, ok := cam.Project([3]float64{p[0], p[1], p[2]})
+		if !ok {
+			t.Fatalf("point %v behind %s", p, cam.AssetKey)
+		}
+		minU, maxU = math.Min(minU, u), math.Max(maxU, u)
+		minV, maxV = math.Min(minV, v), math.Max(maxV, v)
+	}
+	return &models.BBox{X: minU, Y: minV, Width: maxU - minU, Height: maxV - minV}
+}
+
+func detected(sources ...models.DetectionSource) models.SceneObject {
+	return models.SceneObject{
+		ID:       "obj_box",
+		Pose:     models.NewDefaultPose(),
+		BBox:     models.BoundingBox{Max: [3]float64{1, 1, 1}},
+		Metadata: map[string]interface{}{"sources": sources},
+	}
+}
+
+func near(a, b [3]float64, tol float64) bool {
+	return math.Abs(a[0]-b[0]) <= tol && math.Abs(a[1]-b[1]) <= tol && math.Abs(a[2]-b[2]) <= tol
+}
+
+func TestNewCamera(t *testing.T) {
+	if _, ok := NewCamera(models.CameraPose{Intrinsics: intrinsics}); ok {
+		t.Error("NewCamera() accepted a pose without extrinsics")
+	}
+	front, _ := cameras(t)
+	if c := front.Center(); c != [3]float64{} {
+		t.Errorf("Center() = %v", c)
+	}
+	u, v, depth, ok := front.Project([3]float64{0, 0, 2})
+	if !ok || u != 0.5 || v != 0.5 || depth != 2 {
+		t.Errorf("Project() = %v, %v, %v, %v", u, v, depth, ok)
+	}
+	if _, _, _, ok := front.Project([3]float64{0, 0, -1}); ok {
+		t.Error("Project() should reject points behind the camera")
+	}
+
+	// Moving the world moves the camera with it
+	frame := pointcloud.Transform{Rotation: [4]float64{math.Cos(math.Pi / 4), math.Sin(math.Pi / 4), 0, 0}, Translation: [3]float64{0, 1, 0}}
+	p := [3]float64{0.3, -0.2, 2}
+	moved := front.Transformed(frame)
+	u1, v1, _, _ := front.Project(p)
+	u2, v2, _, _ := moved.Project(frame.Apply(p))
+	if math.Abs(u1-u2) > 1e-9 || math.Abs(v1-v2) > 1e-9 {
+		t.Errorf("Transformed() projects to %v,%v, want %v,%v", u2, v2, u1, v1)
+	}
+	if _, ok := NewCamera(moved.Pose()); !ok {
+		t.Error("Pose() is not a valid camera pose")
+	}
+}
+
+func TestLift_TwoViewsWithCloud(t *testing.T) {
+	front, side := cameras(t)
+	centre := [3]float64{0, 0, 3}
+	object := cube(centre)
+	obj := detected(
+		models.DetectionSource{ImageKey: "front.jpg", BoundingBox: boxFor(t, front, object)},
+		models.DetectionSource{ImageKey: "side.jpg", BoundingBox: boxFor(t, side, object)},
+	)
+
+	results := Lift([]models.SceneObject{obj}, map[string]*Camera{"front.jpg": front, "side.jpg": side}, object, DefaultOptions())
+	if len(results) != 1 {
+		t.Fatalf("Lift() = %d results, want 1", len(results))
+	}
+	r := results[0]
+	if r.Method != MethodPointCloud || r.Views != 2 || r.CloudPoints != len(object) {
+		t.Errorf("result = %+v", r)
+	}
+	if !near(r.Position, centre, 0.01) {
+		t.Errorf("Position = %v, want %v", r.Position, centre)
+	}
+	if !near(r.BBox.Min, [3]float64{-0.2, -0.2, 2.8}, 0.05) || !near(r.BBox.Max, [3]float64{0.2, 0.2, 3.2}, 0.05) {
+		t.Errorf("BBox = %+v", r.BBox)
+	}
+	if r.Residual > 0.01 || r.Confidence < 0.95 {
+		t.Errorf("Residual = %v, Confidence = %v", r.Residual, r.Confidence)
+	}
+
+	r.Apply(&obj)
+	if obj.Pose.Position != r.Position || obj.Confidence != r.Confidence || obj.Metadata["lifting"] == nil {
+		t.Errorf("Apply() = %+v", obj)
+	}
+}
+
+func TestLift_SingleViewIgnoresBackground(t *testing.T) {
+	front, _ := cameras(t)
+	centre := [3]float64{0, 0, 3}
+	object := cube(centre)
+	cloud := append([][]float64{}, object...)
+	// A wall 3 m behind the object fills the rest of the box's frustum
+	for x := -1.0; x <= 1; x += 0.05 {
+		for y := -1.0; y <= 1; y += 0.05 {
+			cloud = append(cloud, []float64{x, y, 6})
+		}
+	}
+	obj := detected(models.DetectionSource{ImageKey: "front.jpg", BoundingBox: boxFor(t, front, object)})
+
+	results := Lift([]models.SceneObject{obj}, map[string]*Camera{"front.jpg": front}, cloud, DefaultOptions())
+	if len(results) != 1 {
+		t.Fatalf("Lift() = %d results, want 1", len(results))
+	}
+	r := results[0]
+	if r.CloudPoints != len(object) || !near(r.Position, centre, 0.01) {
+		t.Errorf("single view result = %+v, want only the cube's points", r)
+	}
+	if r.Confidence > 0.5 {
+		t.Errorf("single view confidence = %v, want at most 0.5", r.Confidence)
+	}
+}
+
+func TestLift_TriangulatesWithoutCloud(t *testing.T) {
+	front, side := cameras(t)
+	centre := [3]float64{0.5, -0.3, 3}
+	object := cube(centre)
+	obj := detected(
+		models.DetectionSource{ImageKey: "front.jpg", BoundingBox: boxFor(t, front, object)},
+		models.DetectionSource{ImageKey: "side.jpg", BoundingBox: boxFor(t, side, object)},
+		models.DetectionSource{ImageKey: "unposed.jpg", BoundingBox: &models.BBox{Width: 1, Height: 1}},
+	)
+	cams := map[string]*Camera{"front.jpg": front, "side.jpg": side}
+
+	results := Lift([]models.SceneObject{obj}, cams, nil, DefaultOptions())
+	if len(results) != 1 || results[0].Method != MethodTriangulated || results[0].Views != 2 {
+		t.Fatalf("Lift() = %+v", results)
+	}
+	if r := results[0]; !near(r.Position, centre, 0.05) || r.BBox.Max[0]-r.BBox.Min[0] != 1 {
+		t.Errorf("triangulated result = %+v", r)
+	}
+
+	// A single sighting with nothing behind it can't be placed
+	single := detected(models.DetectionSource{ImageKey: "front.jpg", BoundingBox: boxFor(t, front, object)})
+	unposed := detected(models.DetectionSource{ImageKey: "unposed.jpg", BoundingBox: &models.BBox{Width: 1, Height: 1}})
+	if results := Lift([]models.SceneObject{single, unposed}, cams, nil, DefaultOptions()); len(results) != 0 {
+		t.Errorf("Lift() = %+v, want nothing placed", results)
+	}
+}
@@ -0,0 +1,367 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrNoEXIF is returned when an image carries no EXIF block
+var ErrNoEXIF = errors.New("no EXIF data found")
+
+// EXIFData holds the subset of EXIF tags relevant to scene reconstruction
+type EXIFData struct {
+	Make                  string
+	Model                 string
+	LensModel             string
+	Orientation           int
+	DateTimeOriginal      *time.Time
+	TimezoneKnown         bool
+	FocalLengthMM         float64
+	FocalLength35mm       float64
+	PixelWidth            int
+	PixelHeight           int
+	FocalPlaneXResolution float64 // pixels per millimetre, normalized from the resolution unit
+	GPS                   *GPSLocation
+}
+
+// GPSLocation is a WGS84 position
+type GPSLocation struct {
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Altitude  *float64 `json:"altitude,omitempty"`
+}
+
+// EXIF tag IDs
+const (
+	tagMake                     = 0x010F
+	tagModel                    = 0x0110
+	tagOrientation              = 0x0112
+	tagDateTime                 = 0x0132
+	tagExifIFD                  = 0x8769
+	tagGPSIFD                   = 0x8825
+	tagDateTimeOriginal         = 0x9003
+	tagDateTimeDigitized        = 0x9004
+	tagOffsetTimeOriginal       = 0x9011
+	tagSubSecTimeOriginal       = 0x9291
+	tagFocalLength              = 0x920A
+	tagPixelXDimension          = 0xA002
+	tagPixelYDimension          = 0xA003
+	tagFocalPlaneXResolution    = 0xA20E
+	tagFocalPlaneResolutionUnit = 0xA210
+	tagFocalLengthIn35mmFilm    = 0xA405
+	tagLensModel                = 0xA434
+
+	tagGPSLatitudeRef  = 0x0001
+	tagGPSLatitude     = 0x0002
+	tagGPSLongitudeRef = 0x0003
+	tagGPSLongitude    = 0x0004
+	tagGPSAltitudeRef  = 0x0005
+	tagGPSAltitude     = 0x0006
+)
+
+// TIFF field types
+const (
+	tiffByte      = 1
+	tiffASCII     = 2
+	tiffShort     = 3
+	tiffLong      = 4
+	tiffRational  = 5
+	tiffUndefined = 7
+	tiffSLong     = 9
+	tiffSRational = 10
+)
+
+var tiffTypeSize = map[uint16]int{
+	tiffByte: 1, tiffASCII: 1, tiffShort: 2, tiffLong: 4,
+	tiffRational: 8, tiffUndefined: 1, tiffSLong: 4, tiffSRational: 8,
+}
+
+const exifTimeLayout = "2006:01:02 15:04:05"
+
+// ParseJPEGEXIF extracts EXIF data from a JPEG file
+func ParseJPEGEXIF(data []byte) (*EXIFData, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errors.New("not a JPEG file")
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, fmt.Errorf("invalid JPEG marker at offset %d", pos)
+		}
+		marker := data[pos+1]
+		// Fill bytes and standalone markers carry no length
+		if marker == 0xFF {
+			pos++
+			continue
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			pos += 2
+			continue
+		}
+		// Start of scan: no more metadata segments follow
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+
+		segLen := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if segLen < 2 || pos+2+segLen > len(data) {
+			return nil, errors.New("truncated JPEG segment")
+		}
+		segment := data[pos+4 : pos+2+segLen]
+
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return ParseTIFFEXIF(segment[6:])
+		}
+		pos += 2 + segLen
+	}
+
+	return nil, ErrNoEXIF
+}
+
+// ParseTIFFEXIF parses a raw TIFF-structured EXIF block (as found in JPEG APP1
+// segments and PNG eXIf chunks)
+func ParseTIFFEXIF(tiff []byte) (*EXIFData, error) {
+	if len(tiff) < 8 {
+		return nil, errors.New("EXIF block too short")
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, errors.New("invalid TIFF byte order")
+	}
+	if order.Uint16(tiff[2:4]) != 42 {
+		return nil, errors.New("invalid TIFF magic")
+	}
+
+	r := &tiffReader{data: tiff, order: order}
+	ifd0, err := r.readIFD(int(order.Uint32(tiff[4:8])))
+	if err != nil {
+		return nil, err
+	}
+
+	exif := &EXIFData{
+		Make:        r.ascii(ifd0[tagMake]),
+		Model:       r.ascii(ifd0[tagModel]),
+		Orientation: int(r.uint(ifd0[tagOrientation])),
+	}
+
+	var exifIFD map[uint16]tiffEntry
+	if e, ok := ifd0[tagExifIFD]; ok {
+		if exifIFD, err = r.readIFD(int(r.uint(e))); err != nil {
+			return nil, fmt.Errorf("failed to read Exif IFD: %w", err)
+		}
+	}
+
+	// Capture time: prefer DateTimeOriginal, then Digitized, then IFD0 DateTime
+	rawTime := r.ascii(exifIFD[tagDateTimeOriginal])
+	if rawTime == "" {
+		rawTime = r.ascii(exifIFD[tagDateTimeDigitized])
+	}
+	if rawTime == "" {
+		rawTime = r.ascii(ifd0[tagDateTime])
+	}
+	if rawTime != "" {
+		t, tzKnown, err := parseEXIFTime(rawTime, r.ascii(exifIFD[tagSubSecTimeOriginal]), r.ascii(exifIFD[tagOffsetTimeOriginal]))
+		if err == nil {
+			exif.DateTimeOriginal = &t
+			exif.TimezoneKnown = tzKnown
+		}
+	}
+
+	exif.LensModel = r.ascii(exifIFD[tagLensModel])
+	exif.FocalLengthMM = r.rational(exifIFD[tagFocalLength], 0)
+	exif.FocalLength35mm = float64(r.uint(exifIFD[tagFocalLengthIn35mmFilm]))
+	exif.PixelWidth = int(r.uint(exifIFD[tagPixelXDimension]))
+	exif.PixelHeight = int(r.uint(exifIFD[tagPixelYDimension]))
+
+	if res := r.rational(exifIFD[tagFocalPlaneXResolution], 0); res > 0 {
+		// Resolution unit: 2 = inch (default), 3 = centimetre, 4 = millimetre
+		switch r.uint(exifIFD[tagFocalPlaneResolutionUnit]) {
+		case 3:
+			exif.FocalPlaneXResolution = res / 10
+		case 4:
+			exif.FocalPlaneXResolution = res
+		default:
+			exif.FocalPlaneXResolution = res / 25.4
+		}
+	}
+
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		gpsIFD, err := r.readIFD(int(r.uint(e)))
+		if err == nil {
+			exif.GPS = r.gps(gpsIFD)
+		}
+	}
+
+	return exif, nil
+}
+
+// parseEXIFTime parses an EXIF timestamp with optional sub-second and offset tags.
+// EXIF times without an offset are wall-clock times; they are interpreted as UTC
+// and reported with tzKnown=false so callers can treat them as approximate.
+func parseEXIFTime(raw, subsec, offset string) (time.Time, bool, error) {
+	raw = strings.TrimSpace(raw)
+	loc := time.UTC
+	tzKnown := false
+	if offset = strings.TrimSpace(offset); offset != "" {
+		if t, err := time.Parse("-07:00", offset); err == nil {
+			_, secs := t.Zone()
+			loc = time.FixedZone(offset, secs)
+			tzKnown = true
+		}
+	}
+
+	t, err := time.ParseInLocation(exifTimeLayout, raw, loc)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if subsec = strings.TrimSpace(subsec); subsec != "" {
+		if d, err := time.ParseDuration("0." + subsec + "s"); err == nil {
+			t = t.Add(d)
+		}
+	}
+	return t.UTC(), tzKnown, nil
+}
+
+// tiffEntry is a single IFD entry with its value bytes resolved
+type tiffEntry struct {
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// readIFD reads all entries of the IFD at the given offset
+func (r *tiffReader) readIFD(offset int) (map[uint16]tiffEntry, error) {
+	if offset < 8 || offset+2 > len(r.data) {
+		return nil, errors.New("IFD offset out of range")
+	}
+	n := int(r.order.Uint16(r.data[offset : offset+2]))
+	if offset+2+n*12 > len(r.data) {
+		return nil, errors.New("IFD truncated")
+	}
+
+	entries := make(map[uint16]tiffEntry, n)
+	for i := 0; i < n; i++ {
+		e := r.data[offset+2+i*12 : offset+2+(i+1)*12]
+		tag := r.order.Uint16(e[0:2])
+		typ := r.order.Uint16(e[2:4])
+		count := r.order.Uint32(e[4:8])
+
+		size, ok := tiffTypeSize[typ]
+		if !ok || count > uint32(len(r.data)) {
+			continue
+		}
+		total := size * int(count)
+		var value []byte
+		if total <= 4 {
+			value = e[8 : 8+total]
+		} else {
+			off := int(r.order.Uint32(e[8:12]))
+			if off < 0 || off+total > len(r.data) {
+				continue
+			}
+			value = r.data[off : off+total]
+		}
+		entries[tag] = tiffEntry{typ: typ, count: count, value: value}
+	}
+	return entries, nil
+}
+
+// ascii returns a NUL-trimmed string value
+func (r *tiffReader) ascii(e tiffEntry) string {
+	if e.typ != tiffASCII && e.typ != tiffUndefined {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(e.value), "\x00"))
+}
+
+// uint returns the first integer value of a BYTE, SHORT or LONG entry
+func (r *tiffReader) uint(e tiffEntry) uint32 {
+	if e.count == 0 {
+		return 0
+	}
+	switch e.typ {
+	case tiffByte, tiffUndefined:
+		return uint32(e.value[0])
+	case tiffShort:
+		return uint32(r.order.Uint16(e.value[0:2]))
+	case tiffLong, tiffSLong:
+		return r.order.Uint32(e.value[0:4])
+	}
+	return 0
+}
+
+// rational returns the i-th RATIONAL value of an entry
+func (r *tiffReader) rational(e tiffEntry, i int) float64 {
+	if (e.typ != tiffRational && e.typ != tiffSRational) || uint32(i) >= e.count {
+		return 0
+	}
+	v := e.value[i*8 : i*8+8]
+	if e.typ == tiffSRational {
+		num := int32(r.order.Uint32(v[0:4]))
+		den := int32(r.order.Uint32(v[4:8]))
+		if den == 0 {
+			return 0
+		}
+		return float64(num) / float64(den)
+	}
+	num := r.order.Uint32(v[0:4])
+	den := r.order.Uint32(v[4:8])
+	if den == 0 {
+		return 0
+	}
+	return float64(num) / float64(den)
+}
+
+// gps converts a GPS IFD into decimal degrees
+func (r *tiffReader) gps(ifd map[uint16]tiffEntry) *GPSLocation {
+	lat, okLat := ifd[tagGPSLatitude]
+	lon, okLon := ifd[tagGPSLongitude]
+	if !okLat || !okLon || lat.count < 3 || lon.count < 3 {
+		return nil
+	}
+
+	toDegrees := func(e tiffEntry) float64 {
+		return r.rational(e, 0) + r.rational(e, 1)/60 + r.rational(e, 2)/3600
+	}
+
+	loc := &GPSLocation{
+		Latitude:  toDegrees(lat),
+		Longitude: toDegrees(lon),
+	}
+	if strings.EqualFold(r.ascii(ifd[tagGPSLatitudeRef]), "S") {
+		loc.Latitude = -loc.Latitude
+	}
+	if strings.EqualFold(r.ascii(ifd[tagGPSLongitudeRef]), "W") {
+		loc.Longitude = -loc.Longitude
+	}
+	if math.Abs(loc.Latitude) > 90 || math.Abs(loc.Longitude) > 180 {
+		return nil
+	}
+
+	if alt, ok := ifd[tagGPSAltitude]; ok {
+		a := r.rational(alt, 0)
+		// AltitudeRef 1 means below sea level
+		if r.uint(ifd[tagGPSAltitudeRef]) == 1 {
+			a = -a
+		}
+		loc.Altitude = &a
+	}
+	return loc
+}
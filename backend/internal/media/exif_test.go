@@ -0,0 +1,238 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"math"
+	"testing"
+	"time"
+)
+
+// tiffTestEntry describes an IFD entry for building test EXIF blocks
+type tiffTestEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+func asciiEntry(tag uint16, s string) tiffTestEntry {
+	b := append([]byte(s), 0)
+	return tiffTestEntry{tag: tag, typ: tiffASCII, count: uint32(len(b)), data: b}
+}
+
+func shortEntry(tag uint16, v uint16) tiffTestEntry {
+	b := make([]byte, 2)
+	binary.LittleEndian.PutUint16(b, v)
+	return tiffTestEntry{tag: tag, typ: tiffShort, count: 1, data: b}
+}
+
+func longEntry(tag uint16, v uint32) tiffTestEntry {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return tiffTestEntry{tag: tag, typ: tiffLong, count: 1, data: b}
+}
+
+func rationalEntry(tag uint16, vals ...[2]uint32) tiffTestEntry {
+	b := make([]byte, 8*len(vals))
+	for i, v := range vals {
+		binary.LittleEndian.PutUint32(b[i*8:], v[0])
+		binary.LittleEndian.PutUint32(b[i*8+4:], v[1])
+	}
+	return tiffTestEntry{tag: tag, typ: tiffRational, count: uint32(len(vals)), data: b}
+}
+
+// writeTestIFD writes an IFD at offset with out-of-line values placed after the entry table
+func writeTestIFD(buf []byte, offset int, entries []tiffTestEntry) {
+	le := binary.LittleEndian
+	le.PutUint16(buf[offset:], uint16(len(entries)))
+	dataPos := offset + 2 + len(entries)*12 + 4
+	for i, e := range entries {
+		p := offset + 2 + i*12
+		le.PutUint16(buf[p:], e.tag)
+		le.PutUint16(buf[p+2:], e.typ)
+		le.PutUint32(buf[p+4:], e.count)
+		if len(e.data) <= 4 {
+			copy(buf[p+8:], e.data)
+		} else {
+			le.PutUint32(buf[p+8:], uint32(dataPos))
+			copy(buf[dataPos:], e.data)
+			dataPos += len(e.data)
+		}
+	}
+}
+
+// buildTestTIFF builds a little-endian EXIF block with IFD0, Exif and GPS IFDs
+func buildTestTIFF(ifd0, exifIFD, gpsIFD []tiffTestEntry) []byte {
+	const exifOffset, gpsOffset = 300, 600
+	buf := make([]byte, 900)
+	copy(buf, "II")
+	binary.LittleEndian.PutUint16(buf[2:], 42)
+	binary.LittleEndian.PutUint32(buf[4:], 8)
+
+	if exifIFD != nil {
+		ifd0 = append(ifd0, longEntry(tagExifIFD, exifOffset))
+		writeTestIFD(buf, exifOffset, exifIFD)
+	}
+	if gpsIFD != nil {
+		ifd0 = append(ifd0, longEntry(tagGPSIFD, gpsOffset))
+		writeTestIFD(buf, gpsOffset, gpsIFD)
+	}
+	writeTestIFD(buf, 8, ifd0)
+	return buf
+}
+
+// buildTestJPEG encodes a small JPEG and splices the EXIF block in as an APP1 segment
+func buildTestJPEG(t *testing.T, w, h int, tiff []byte) []byte {
+	t.Helper()
+	var enc bytes.Buffer
+	if err := jpeg.Encode(&enc, image.NewRGBA(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+	if tiff == nil {
+		return enc.Bytes()
+	}
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	seg := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	seg = append(seg, payload...)
+
+	raw := enc.Bytes()
+	out := append([]byte{}, raw[:2]...)
+	out = append(out, seg...)
+	return append(out, raw[2:]...)
+}
+
+func sampleTIFF() []byte {
+	return buildTestTIFF(
+		[]tiffTestEntry{
+			asciiEntry(tagMake, "Apple"),
+			asciiEntry(tagModel, "iPhone 15 Pro"),
+			shortEntry(tagOrientation, 1),
+		},
+		[]tiffTestEntry{
+			asciiEntry(tagDateTimeOriginal, "2026:03:14 21:05:30"),
+			asciiEntry(tagOffsetTimeOriginal, "+01:00"),
+			asciiEntry(tagSubSecTimeOriginal, "25"),
+			rationalEntry(tagFocalLength, [2]uint32{6765, 1000}),
+			shortEntry(tagFocalLengthIn35mmFilm, 24),
+			asciiEntry(tagLensModel, "iPhone 15 Pro back camera"),
+		},
+		[]tiffTestEntry{
+			asciiEntry(tagGPSLatitudeRef, "N"),
+			rationalEntry(tagGPSLatitude, [2]uint32{51, 1}, [2]uint32{30, 1}, [2]uint32{36, 1}),
+			asciiEntry(tagGPSLongitudeRef, "W"),
+			rationalEntry(tagGPSLongitude, [2]uint32{0, 1}, [2]uint32{7, 1}, [2]uint32{30, 1}),
+			{tag: tagGPSAltitudeRef, typ: tiffByte, count: 1, data: []byte{0}},
+			rationalEntry(tagGPSAltitude, [2]uint32{355, 10}),
+		},
+	)
+}
+
+func TestParseJPEGEXIF(t *testing.T) {
+	data := buildTestJPEG(t, 64, 48, sampleTIFF())
+
+	exif, err := ParseJPEGEXIF(data)
+	if err != nil {
+		t.Fatalf("ParseJPEGEXIF() error = %v", err)
+	}
+
+	if exif.Make != "Apple" || exif.Model != "iPhone 15 Pro" {
+		t.Errorf("ParseJPEGEXIF() make/model = %q/%q, want Apple/iPhone 15 Pro", exif.Make, exif.Model)
+	}
+	if exif.LensModel != "iPhone 15 Pro back camera" {
+		t.Errorf("ParseJPEGEXIF() lens = %q", exif.LensModel)
+	}
+	if exif.FocalLength35mm != 24 {
+		t.Errorf("ParseJPEGEXIF() FocalLength35mm = %v, want 24", exif.FocalLength35mm)
+	}
+	if math.Abs(exif.FocalLengthMM-6.765) > 1e-9 {
+		t.Errorf("ParseJPEGEXIF() FocalLengthMM = %v, want 6.765", exif.FocalLengthMM)
+	}
+
+	wantTime := time.Date(2026, 3, 14, 20, 5, 30, 250_000_000, time.UTC)
+	if exif.DateTimeOriginal == nil || !exif.DateTimeOriginal.Equal(wantTime) {
+		t.Errorf("ParseJPEGEXIF() DateTimeOriginal = %v, want %v", exif.DateTimeOriginal, wantTime)
+	}
+	if !exif.TimezoneKnown {
+		t.Error("ParseJPEGEXIF() TimezoneKnown = false, want true")
+	}
+
+	if exif.GPS == nil {
+		t.Fatal("ParseJPEGEXIF() GPS = nil")
+	}
+	if math.Abs(exif.GPS.Latitude-51.51) > 1e-6 {
+		t.Errorf("ParseJPEGEXIF() latitude = %v, want 51.51", exif.GPS.Latitude)
+	}
+	if math.Abs(exif.GPS.Longitude+0.125) > 1e-6 {
+		t.Errorf("ParseJPEGEXIF() longitude = %v, want -0.125", exif.GPS.Longitude)
+	}
+	if exif.GPS.Altitude == nil || math.Abs(*exif.GPS.Altitude-35.5) > 1e-9 {
+		t.Errorf("ParseJPEGEXIF() altitude = %v, want 35.5", exif.GPS.Altitude)
+	}
+}
+
+func TestParseJPEGEXIF_NoEXIF(t *testing.T) {
+	data := buildTestJPEG(t, 16, 16, nil)
+	if _, err := ParseJPEGEXIF(data); err != ErrNoEXIF {
+		t.Errorf("ParseJPEGEXIF() error = %v, want ErrNoEXIF", err)
+	}
+}
+
+func TestParseJPEGEXIF_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not jpeg", []byte("hello world")},
+		{"truncated segment", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF, 0xFF, 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseJPEGEXIF(tt.data); err == nil {
+				t.Error("ParseJPEGEXIF() expected error")
+			}
+		})
+	}
+}
+
+func TestParseTIFFEXIF_LocalTimeWithoutOffset(t *testing.T) {
+	tiff := buildTestTIFF(nil, []tiffTestEntry{
+		asciiEntry(tagDateTimeOriginal, "2025:12:01 08:00:00"),
+	}, nil)
+
+	exif, err := ParseTIFFEXIF(tiff)
+	if err != nil {
+		t.Fatalf("ParseTIFFEXIF() error = %v", err)
+	}
+	want := time.Date(2025, 12, 1, 8, 0, 0, 0, time.UTC)
+	if exif.DateTimeOriginal == nil || !exif.DateTimeOriginal.Equal(want) {
+		t.Errorf("ParseTIFFEXIF() DateTimeOriginal = %v, want %v", exif.DateTimeOriginal, want)
+	}
+	if exif.TimezoneKnown {
+		t.Error("ParseTIFFEXIF() TimezoneKnown = true, want false without OffsetTimeOriginal")
+	}
+	if exif.GPS != nil {
+		t.Errorf("ParseTIFFEXIF() GPS = %v, want nil", exif.GPS)
+	}
+}
+
+func TestParseTIFFEXIF_FocalPlaneResolution(t *testing.T) {
+	tiff := buildTestTIFF(nil, []tiffTestEntry{
+		rationalEntry(tagFocalPlaneXResolution, [2]uint32{5000, 1}),
+		shortEntry(tagFocalPlaneResolutionUnit, 3), // centimetres
+	}, nil)
+
+	exif, err := ParseTIFFEXIF(tiff)
+	if err != nil {
+		t.Fatalf("ParseTIFFEXIF() error = %v", err)
+	}
+	if exif.FocalPlaneXResolution != 500 {
+		t.Errorf("ParseTIFFEXIF() FocalPlaneXResolution = %v, want 500 px/mm", exif.FocalPlaneXResolution)
+	}
+}
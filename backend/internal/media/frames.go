@@ -0,0 +1,80 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// FrameExtractor decodes a single frame from a video. Go has no native video
+// decoder, so implementations wrap a local tool; callers must treat a nil
+// extractor as "poster frames unavailable".
+type FrameExtractor interface {
+	// ExtractFrame returns the frame at the given offset as an encoded image
+	ExtractFrame(ctx context.Context, video []byte, atSeconds float64) ([]byte, error)
+}
+
+// FFmpegFrameExtractor extracts frames by shelling out to an ffmpeg binary
+type FFmpegFrameExtractor struct {
+	binary string
+}
+
+// DetectFrameExtractor returns an ffmpeg-backed extractor if ffmpeg is on PATH,
+// or nil if no local decoder is available
+func DetectFrameExtractor() FrameExtractor {
+	bin, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil
+	}
+	return &FFmpegFrameExtractor{binary: bin}
+}
+
+// ExtractFrame implements FrameExtractor
+func (f *FFmpegFrameExtractor) ExtractFrame(ctx context.Context, video []byte, atSeconds float64) ([]byte, error) {
+	tmp, err := os.CreateTemp("", "sherlock-frame-*.mp4")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(video); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to write temp video: %w", err)
+	}
+	tmp.Close()
+
+	cmd := exec.CommandContext(ctx, f.binary,
+		"-hide_banner", "-loglevel", "error",
+		"-ss", strconv.FormatFloat(atSeconds, 'f', 3, 64),
+		"-i", tmp.Name(),
+		"-frames:v", "1",
+		"-f", "image2", "-c:v", "png",
+		"pipe:1",
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %w: %s", err, stderr.String())
+	}
+	if stdout.Len() == 0 {
+		return nil, fmt.Errorf("ffmpeg produced no frame at %.3fs", atSeconds)
+	}
+	return stdout.Bytes(), nil
+}
+
+// PosterFrameOffset picks the frame offset used for a video's poster: one
+// second in (past fade-ins), or the midpoint of very short clips
+func PosterFrameOffset(durationSeconds float64) float64 {
+	if durationSeconds <= 0 {
+		return 0
+	}
+	if durationSeconds < 2 {
+		return durationSeconds / 2
+	}
+	return 1
+}
@@ -0,0 +1,246 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	_ "image/jpeg" // register decoders for DecodeConfig
+	_ "image/png"
+	"math"
+	"time"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// ErrUnsupportedFormat is returned for files that carry no metadata we can read
+var ErrUnsupportedFormat = errors.New("unsupported media format")
+
+// Media types reported in CaptureMetadata
+const (
+	MediaTypeImage = "image"
+	MediaTypeVideo = "video"
+)
+
+// Intrinsics sources, from most to least trustworthy
+const (
+	IntrinsicsSource35mm       = "exif_35mm_equivalent"
+	IntrinsicsSourceFocalPlane = "exif_focal_plane"
+	IntrinsicsSourceDefaultFOV = "default_fov"
+)
+
+// defaultHorizontalFOV is assumed when a camera reports no focal length (typical phone wide lens)
+const defaultHorizontalFOV = 65.0
+
+// fullFrameDiagonalMM is the diagonal of a 36x24mm frame, the reference for 35mm-equivalent focal lengths
+var fullFrameDiagonalMM = math.Hypot(36, 24)
+
+// CaptureMetadata is the normalized capture information for an uploaded scan
+type CaptureMetadata struct {
+	MediaType       string       `json:"media_type"`
+	CapturedAt      *time.Time   `json:"captured_at,omitempty"`
+	TimezoneKnown   bool         `json:"timezone_known"`
+	CameraMake      string       `json:"camera_make,omitempty"`
+	CameraModel     string       `json:"camera_model,omitempty"`
+	LensModel       string       `json:"lens_model,omitempty"`
+	FocalLengthMM   float64      `json:"focal_length_mm,omitempty"`
+	FocalLength35mm float64      `json:"focal_length_35mm,omitempty"`
+	Width           int          `json:"width,omitempty"`
+	Height          int          `json:"height,omitempty"`
+	Orientation     int          `json:"orientation,omitempty"`
+	GPS             *GPSLocation `json:"gps,omitempty"`
+	DurationSeconds float64      `json:"duration_seconds,omitempty"`
+	FrameRate       float64      `json:"frame_rate,omitempty"`
+
+	// focalPlaneXResolution is only used for intrinsics estimation
+	focalPlaneXResolution float64
+}
+
+// Extract reads capture metadata from JPEG, PNG or MP4 content
+func Extract(data []byte) (*CaptureMetadata, error) {
+	switch {
+	case len(data) >= 3 && data[0] == 0xFF && data[1] == 0xD8 && data[2] == 0xFF:
+		return extractImage(data, func() (*EXIFData, error) { return ParseJPEGEXIF(data) })
+	case bytes.HasPrefix(data, pngSignature):
+		return extractImage(data, func() (*EXIFData, error) { return parsePNGEXIF(data) })
+	case IsMP4(data):
+		return extractVideo(data)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// extractImage merges EXIF tags with the decoded image dimensions
+func extractImage(data []byte, parse func() (*EXIFData, error)) (*CaptureMetadata, error) {
+	meta := &CaptureMetadata{MediaType: MediaTypeImage}
+
+	exif, err := parse()
+	if err != nil && !errors.Is(err, ErrNoEXIF) {
+		return nil, err
+	}
+	if exif != nil {
+		meta.CapturedAt = exif.DateTimeOriginal
+		meta.TimezoneKnown = exif.TimezoneKnown
+		meta.CameraMake = exif.Make
+		meta.CameraModel = exif.Model
+		meta.LensModel = exif.LensModel
+		meta.FocalLengthMM = exif.FocalLengthMM
+		meta.FocalLength35mm = exif.FocalLength35mm
+		meta.Width = exif.PixelWidth
+		meta.Height = exif.PixelHeight
+		meta.Orientation = exif.Orientation
+		meta.GPS = exif.GPS
+		meta.focalPlaneXResolution = exif.FocalPlaneXResolution
+	}
+
+	// The encoded frame size is authoritative over EXIF pixel dimensions
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		meta.Width = cfg.Width
+		meta.Height = cfg.Height
+	}
+
+	return meta, nil
+}
+
+// extractVideo converts MP4 container metadata
+func extractVideo(data []byte) (*CaptureMetadata, error) {
+	mp4, err := ParseMP4(data)
+	if err != nil {
+		return nil, err
+	}
+	return &CaptureMetadata{
+		MediaType:       MediaTypeVideo,
+		CapturedAt:      mp4.CreationTime,
+		TimezoneKnown:   mp4.CreationTime != nil, // MP4 creation times are UTC by spec
+		CameraMake:      mp4.Make,
+		CameraModel:     mp4.Model,
+		Width:           mp4.Width,
+		Height:          mp4.Height,
+		GPS:             mp4.GPS,
+		DurationSeconds: mp4.DurationSeconds,
+		FrameRate:       mp4.FrameRate,
+	}, nil
+}
+
+// EstimateIntrinsics derives pinhole intrinsics in pixels from the capture metadata.
+// Returns nil if the image dimensions are unknown.
+func (m *CaptureMetadata) EstimateIntrinsics() (*models.CameraIntrinsics, string) {
+	w, h := float64(m.Width), float64(m.Height)
+	// EXIF orientations 5-8 are rotated by 90 degrees when displayed
+	if m.Orientation >= 5 && m.Orientation <= 8 {
+		w, h = h, w
+	}
+	if w <= 0 || h <= 0 {
+		return nil, ""
+	}
+
+	var focal float64
+	var source string
+	switch {
+	case m.FocalLength35mm > 0:
+		focal = m.FocalLength35mm * math.Hypot(w, h) / fullFrameDiagonalMM
+		source = IntrinsicsSource35mm
+	case m.FocalLengthMM > 0 && m.focalPlaneXResolution > 0:
+		focal = m.FocalLengthMM * m.focalPlaneXResolution
+		source = IntrinsicsSourceFocalPlane
+	default:
+		focal = (math.Max(w, h) / 2) / math.Tan(defaultHorizontalFOV/2*math.Pi/180)
+		source = IntrinsicsSourceDefaultFOV
+	}
+
+	return &models.CameraIntrinsics{
+		Fx: focal,
+		Fy: focal,
+		Cx: w / 2,
+		Cy: h / 2,
+	}, source
+}
+
+// ToAssetMetadata flattens the capture metadata into Asset.Metadata keys
+func (m *CaptureMetadata) ToAssetMetadata() map[string]interface{} {
+	out := map[string]interface{}{
+		"media_type": m.MediaType,
+	}
+	if m.CapturedAt != nil {
+		out["captured_at"] = m.CapturedAt.Format(time.RFC3339Nano)
+		out["timezone_known"] = m.TimezoneKnown
+	}
+	setString := func(key, v string) {
+		if v != "" {
+			out[key] = v
+		}
+	}
+	setFloat := func(key string, v float64) {
+		if v > 0 {
+			out[key] = v
+		}
+	}
+	setString("camera_make", m.CameraMake)
+	setString("camera_model", m.CameraModel)
+	setString("lens_model", m.LensModel)
+	setFloat("focal_length_mm", m.FocalLengthMM)
+	setFloat("focal_length_35mm", m.FocalLength35mm)
+	setFloat("duration_seconds", m.DurationSeconds)
+	setFloat("frame_rate", m.FrameRate)
+	if m.Width > 0 && m.Height > 0 {
+		out["width"] = m.Width
+		out["height"] = m.Height
+	}
+	if m.Orientation > 0 {
+		out["orientation"] = m.Orientation
+	}
+	if m.GPS != nil {
+		out["gps"] = m.GPS
+	}
+	if m.MediaType == MediaTypeImage {
+		if intr, source := m.EstimateIntrinsics(); intr != nil {
+			out[models.AssetMetadataCameraIntrinsics] = map[string]interface{}{
+				"fx":     intr.Fx,
+				"fy":     intr.Fy,
+				"cx":     intr.Cx,
+				"cy":     intr.Cy,
+				"source": source,
+			}
+		}
+	}
+	return out
+}
+
+// TimelineAnchor returns the capture time as a timeline anchor, if known
+func (m *CaptureMetadata) TimelineAnchor(assetKey string) *models.TimelineAnchor {
+	if m.CapturedAt == nil {
+		return nil
+	}
+	source := models.TimelineAnchorSourceEXIF
+	if m.MediaType == MediaTypeVideo {
+		source = models.TimelineAnchorSourceContainer
+	}
+	return &models.TimelineAnchor{
+		AssetKey:        assetKey,
+		Time:            *m.CapturedAt,
+		DurationSeconds: m.DurationSeconds,
+		Source:          source,
+		Approximate:     !m.TimezoneKnown,
+	}
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// parsePNGEXIF reads the eXIf chunk of a PNG file
+func parsePNGEXIF(data []byte) (*EXIFData, error) {
+	pos := len(pngSignature)
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		typ := string(data[pos+4 : pos+8])
+		if length < 0 || pos+12+length > len(data) {
+			break
+		}
+		switch typ {
+		case "eXIf":
+			return ParseTIFFEXIF(data[pos+8 : pos+8+length])
+		case "IEND":
+			return nil, ErrNoEXIF
+		}
+		pos += 12 + length
+	}
+	return nil, ErrNoEXIF
+}
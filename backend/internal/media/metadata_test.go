@@ -0,0 +1,187 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sherlockos/backend/internal/models"
+)
+
+func TestExtract_JPEG(t *testing.T) {
+	meta, err := Extract(buildTestJPEG(t, 64, 48, sampleTIFF()))
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	if meta.MediaType != MediaTypeImage {
+		t.Errorf("Extract() MediaType = %v, want %v", meta.MediaType, MediaTypeImage)
+	}
+	if meta.Width != 64 || meta.Height != 48 {
+		t.Errorf("Extract() size = %dx%d, want 64x48", meta.Width, meta.Height)
+	}
+	if meta.CameraModel != "iPhone 15 Pro" {
+		t.Errorf("Extract() CameraModel = %q", meta.CameraModel)
+	}
+	if meta.CapturedAt == nil {
+		t.Fatal("Extract() CapturedAt = nil")
+	}
+
+	anchor := meta.TimelineAnchor("cases/x/scans/a.jpg")
+	if anchor == nil {
+		t.Fatal("TimelineAnchor() = nil")
+	}
+	if anchor.Source != models.TimelineAnchorSourceEXIF || anchor.Approximate {
+		t.Errorf("TimelineAnchor() = %+v, want exact exif anchor", anchor)
+	}
+}
+
+func TestExtract_JPEGWithoutEXIF(t *testing.T) {
+	meta, err := Extract(buildTestJPEG(t, 32, 20, nil))
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if meta.Width != 32 || meta.Height != 20 {
+		t.Errorf("Extract() size = %dx%d, want 32x20", meta.Width, meta.Height)
+	}
+	if meta.TimelineAnchor("k") != nil {
+		t.Error("TimelineAnchor() should be nil without a capture time")
+	}
+}
+
+func TestExtract_PNG(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 10, 5)))
+
+	meta, err := Extract(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if meta.Width != 10 || meta.Height != 5 {
+		t.Errorf("Extract() size = %dx%d, want 10x5", meta.Width, meta.Height)
+	}
+}
+
+func TestExtract_MP4(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	meta, err := Extract(buildTestMP4(created))
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if meta.MediaType != MediaTypeVideo {
+		t.Errorf("Extract() MediaType = %v, want %v", meta.MediaType, MediaTypeVideo)
+	}
+
+	anchor := meta.TimelineAnchor("cases/x/scans/walkthrough.mp4")
+	if anchor == nil {
+		t.Fatal("TimelineAnchor() = nil")
+	}
+	if anchor.Source != models.TimelineAnchorSourceContainer || anchor.DurationSeconds != 10 {
+		t.Errorf("TimelineAnchor() = %+v", anchor)
+	}
+
+	// Videos carry no per-frame intrinsics in asset metadata
+	if _, ok := meta.ToAssetMetadata()[models.AssetMetadataCameraIntrinsics]; ok {
+		t.Error("ToAssetMetadata() should not include intrinsics for video")
+	}
+}
+
+func TestExtract_Unsupported(t *testing.T) {
+	if _, err := Extract([]byte("plain text testimony")); err != ErrUnsupportedFormat {
+		t.Errorf("Extract() error = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestCaptureMetadata_EstimateIntrinsics(t *testing.T) {
+	tests := []struct {
+		name       string
+		meta       CaptureMetadata
+		wantFx     float64
+		wantCx     float64
+		wantCy     float64
+		wantSource string
+	}{
+		{
+			name:       "35mm equivalent",
+			meta:       CaptureMetadata{Width: 4032, Height: 3024, FocalLength35mm: 26},
+			wantFx:     26 * math.Hypot(4032, 3024) / math.Hypot(36, 24),
+			wantCx:     2016,
+			wantCy:     1512,
+			wantSource: IntrinsicsSource35mm,
+		},
+		{
+			name:       "focal plane resolution",
+			meta:       CaptureMetadata{Width: 6000, Height: 4000, FocalLengthMM: 35, focalPlaneXResolution: 250},
+			wantFx:     8750,
+			wantCx:     3000,
+			wantCy:     2000,
+			wantSource: IntrinsicsSourceFocalPlane,
+		},
+		{
+			name:       "default field of view",
+			meta:       CaptureMetadata{Width: 1000, Height: 500},
+			wantFx:     500 / math.Tan(32.5*math.Pi/180),
+			wantCx:     500,
+			wantCy:     250,
+			wantSource: IntrinsicsSourceDefaultFOV,
+		},
+		{
+			name:       "rotated orientation swaps axes",
+			meta:       CaptureMetadata{Width: 4000, Height: 3000, Orientation: 6, FocalLength35mm: 28},
+			wantFx:     28 * math.Hypot(4000, 3000) / math.Hypot(36, 24),
+			wantCx:     1500,
+			wantCy:     2000,
+			wantSource: IntrinsicsSource35mm,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intr, source := tt.meta.EstimateIntrinsics()
+			if intr == nil {
+				t.Fatal("EstimateIntrinsics() = nil")
+			}
+			if source != tt.wantSource {
+				t.Errorf("EstimateIntrinsics() source = %v, want %v", source, tt.wantSource)
+			}
+			if math.Abs(intr.Fx-tt.wantFx) > 1e-6 || intr.Fx != intr.Fy {
+				t.Errorf("EstimateIntrinsics() fx/fy = %v/%v, want %v", intr.Fx, intr.Fy, tt.wantFx)
+			}
+			if intr.Cx != tt.wantCx || intr.Cy != tt.wantCy {
+				t.Errorf("EstimateIntrinsics() principal point = %v,%v, want %v,%v", intr.Cx, intr.Cy, tt.wantCx, tt.wantCy)
+			}
+		})
+	}
+
+	var unknown CaptureMetadata
+	if intr, _ := unknown.EstimateIntrinsics(); intr != nil {
+		t.Error("EstimateIntrinsics() should be nil without dimensions")
+	}
+}
+
+func TestCaptureMetadata_ToAssetMetadataRoundTrip(t *testing.T) {
+	meta, err := Extract(buildTestJPEG(t, 64, 48, sampleTIFF()))
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	asset := models.NewAsset(uuid.New(), models.AssetKindScanImage, "cases/x/scans/a.jpg")
+	for k, v := range meta.ToAssetMetadata() {
+		asset.SetMetadata(k, v)
+	}
+
+	intr, ok := asset.CameraIntrinsics()
+	if !ok {
+		t.Fatal("Asset.CameraIntrinsics() not found after ingest")
+	}
+	if intr.Cx != 32 || intr.Cy != 24 {
+		t.Errorf("Asset.CameraIntrinsics() principal point = %v,%v, want 32,24", intr.Cx, intr.Cy)
+	}
+	if asset.Metadata["camera_make"] != "Apple" {
+		t.Errorf("asset metadata camera_make = %v", asset.Metadata["camera_make"])
+	}
+}
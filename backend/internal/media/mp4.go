@@ -0,0 +1,246 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// MP4Data holds container-level metadata from an MP4/QuickTime file
+type MP4Data struct {
+	CreationTime    *time.Time
+	DurationSeconds float64
+	Width           int
+	Height          int
+	FrameRate       float64
+	Make            string
+	Model           string
+	GPS             *GPSLocation
+}
+
+// mp4Epoch is the reference time for ISO BMFF timestamps
+var mp4Epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// iso6709Pattern matches locations such as "+37.3861-122.0839+010.000/"
+var iso6709Pattern = regexp.MustCompile(`^([+-]\d+(?:\.\d+)?)([+-]\d+(?:\.\d+)?)([+-]\d+(?:\.\d+)?)?`)
+
+// mp4Box is a parsed box header with its payload
+type mp4Box struct {
+	typ     string
+	payload []byte
+}
+
+// IsMP4 reports whether data looks like an ISO BMFF (MP4/MOV) file
+func IsMP4(data []byte) bool {
+	if len(data) < 12 {
+		return false
+	}
+	switch string(data[4:8]) {
+	case "ftyp", "moov", "mdat", "wide", "free":
+		return true
+	}
+	return false
+}
+
+// ParseMP4 extracts creation time, duration, dimensions and location from an MP4 file
+func ParseMP4(data []byte) (*MP4Data, error) {
+	if !IsMP4(data) {
+		return nil, errors.New("not an MP4 file")
+	}
+
+	moov := findBox(readBoxes(data), "moov")
+	if moov == nil {
+		return nil, errors.New("MP4 has no moov box")
+	}
+
+	out := &MP4Data{}
+	children := readBoxes(moov.payload)
+
+	if mvhd := findBox(children, "mvhd"); mvhd != nil {
+		created, duration := parseMovieHeader(mvhd.payload)
+		out.CreationTime = created
+		out.DurationSeconds = duration
+	}
+
+	for _, trak := range children {
+		if trak.typ != "trak" {
+			continue
+		}
+		parseVideoTrack(trak.payload, out)
+	}
+
+	if udta := findBox(children, "udta"); udta != nil {
+		for _, b := range readBoxes(udta.payload) {
+			switch b.typ {
+			case "\xa9xyz":
+				out.GPS = parseISO6709(quickTimeString(b.payload))
+			case "\xa9mak":
+				out.Make = quickTimeString(b.payload)
+			case "\xa9mod":
+				out.Model = quickTimeString(b.payload)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// readBoxes splits a buffer into sibling boxes, stopping at the first malformed header
+func readBoxes(data []byte) []mp4Box {
+	var boxes []mp4Box
+	pos := 0
+	for pos+8 <= len(data) {
+		size := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		typ := string(data[pos+4 : pos+8])
+		header := 8
+
+		switch size {
+		case 0:
+			size = len(data) - pos
+		case 1:
+			if pos+16 > len(data) {
+				return boxes
+			}
+			large := binary.BigEndian.Uint64(data[pos+8 : pos+16])
+			if large > uint64(len(data)-pos) {
+				return boxes
+			}
+			size = int(large)
+			header = 16
+		}
+		if size < header || pos+size > len(data) {
+			return boxes
+		}
+
+		boxes = append(boxes, mp4Box{typ: typ, payload: data[pos+header : pos+size]})
+		pos += size
+	}
+	return boxes
+}
+
+// findBox returns the first box of the given type
+func findBox(boxes []mp4Box, typ string) *mp4Box {
+	for i := range boxes {
+		if boxes[i].typ == typ {
+			return &boxes[i]
+		}
+	}
+	return nil
+}
+
+// parseMovieHeader reads creation time and duration from an mvhd payload
+func parseMovieHeader(p []byte) (*time.Time, float64) {
+	if len(p) < 20 {
+		return nil, 0
+	}
+	var created uint64
+	var timescale uint32
+	var duration uint64
+	if p[0] == 1 {
+		if len(p) < 32 {
+			return nil, 0
+		}
+		created = binary.BigEndian.Uint64(p[4:12])
+		timescale = binary.BigEndian.Uint32(p[20:24])
+		duration = binary.BigEndian.Uint64(p[24:32])
+	} else {
+		created = uint64(binary.BigEndian.Uint32(p[4:8]))
+		timescale = binary.BigEndian.Uint32(p[12:16])
+		duration = uint64(binary.BigEndian.Uint32(p[16:20]))
+	}
+
+	var createdAt *time.Time
+	if created > 0 {
+		t := mp4Epoch.Add(time.Duration(created) * time.Second)
+		createdAt = &t
+	}
+	var seconds float64
+	if timescale > 0 {
+		seconds = float64(duration) / float64(timescale)
+	}
+	return createdAt, seconds
+}
+
+// parseVideoTrack fills dimensions and frame rate from the first video track
+func parseVideoTrack(trak []byte, out *MP4Data) {
+	if out.Width > 0 {
+		return
+	}
+	boxes := readBoxes(trak)
+	mdia := findBox(boxes, "mdia")
+	if mdia == nil {
+		return
+	}
+	mdiaBoxes := readBoxes(mdia.payload)
+	hdlr := findBox(mdiaBoxes, "hdlr")
+	if hdlr == nil || len(hdlr.payload) < 12 || string(hdlr.payload[8:12]) != "vide" {
+		return
+	}
+
+	if tkhd := findBox(boxes, "tkhd"); tkhd != nil {
+		p := tkhd.payload
+		// width/height are the last 8 bytes, both 16.16 fixed point
+		if len(p) >= 84 {
+			out.Width = int(binary.BigEndian.Uint32(p[len(p)-8:len(p)-4]) >> 16)
+			out.Height = int(binary.BigEndian.Uint32(p[len(p)-4:]) >> 16)
+		}
+	}
+
+	// Frame rate = sample count / media duration
+	mdhd := findBox(mdiaBoxes, "mdhd")
+	minf := findBox(mdiaBoxes, "minf")
+	if mdhd == nil || minf == nil {
+		return
+	}
+	_, mediaSeconds := parseMovieHeader(mdhd.payload)
+	stbl := findBox(readBoxes(minf.payload), "stbl")
+	if stbl == nil || mediaSeconds <= 0 {
+		return
+	}
+	stts := findBox(readBoxes(stbl.payload), "stts")
+	if stts == nil || len(stts.payload) < 8 {
+		return
+	}
+	entries := int(binary.BigEndian.Uint32(stts.payload[4:8]))
+	var samples uint64
+	for i := 0; i < entries && 8+i*8+8 <= len(stts.payload); i++ {
+		samples += uint64(binary.BigEndian.Uint32(stts.payload[8+i*8 : 12+i*8]))
+	}
+	if samples > 0 {
+		out.FrameRate = float64(samples) / mediaSeconds
+	}
+}
+
+// quickTimeString decodes a QuickTime user-data string (16-bit length, 16-bit language, text)
+func quickTimeString(p []byte) string {
+	if len(p) < 4 {
+		return ""
+	}
+	n := int(binary.BigEndian.Uint16(p[0:2]))
+	if 4+n > len(p) {
+		n = len(p) - 4
+	}
+	return string(p[4 : 4+n])
+}
+
+// parseISO6709 parses an ISO 6709 location string into a GPSLocation
+func parseISO6709(s string) *GPSLocation {
+	m := iso6709Pattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil
+	}
+	lat, err1 := strconv.ParseFloat(m[1], 64)
+	lon, err2 := strconv.ParseFloat(m[2], 64)
+	if err1 != nil || err2 != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return nil
+	}
+	loc := &GPSLocation{Latitude: lat, Longitude: lon}
+	if m[3] != "" {
+		if alt, err := strconv.ParseFloat(m[3], 64); err == nil {
+			loc.Altitude = &alt
+		}
+	}
+	return loc
+}
@@ -0,0 +1,133 @@
+package media
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+// box encodes an MP4 box from its type and concatenated payload parts
+func box(typ string, parts ...[]byte) []byte {
+	var payload []byte
+	for _, p := range parts {
+		payload = append(payload, p...)
+	}
+	out := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(out, uint32(8+len(payload)))
+	copy(out[4:], typ)
+	return append(out, payload...)
+}
+
+func u32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+// buildTestMP4 creates a minimal MP4 with a single 1920x1080 video track of 300 frames over 10s
+func buildTestMP4(created time.Time) []byte {
+	secs := uint32(created.Sub(mp4Epoch) / time.Second)
+
+	mvhd := box("mvhd", u32(0), u32(secs), u32(secs), u32(1000), u32(10000), make([]byte, 80))
+
+	tkhd := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhd[76:], 1920<<16)
+	binary.BigEndian.PutUint32(tkhd[80:], 1080<<16)
+
+	mdhd := box("mdhd", u32(0), u32(secs), u32(secs), u32(30000), u32(300000), make([]byte, 4))
+	hdlr := box("hdlr", u32(0), u32(0), []byte("vide"), make([]byte, 12))
+	stts := box("stts", u32(0), u32(1), u32(300), u32(1000))
+	minf := box("minf", box("stbl", stts))
+	trak := box("trak", box("tkhd", tkhd), box("mdia", mdhd, hdlr, minf))
+
+	loc := "+48.8584+002.2945+035.000/"
+	xyz := append([]byte{0, byte(len(loc)), 0x15, 0xC7}, loc...)
+	mak := append([]byte{0, 5, 0x15, 0xC7}, "Apple"...)
+	udta := box("udta", box("\xa9xyz", xyz), box("\xa9mak", mak))
+
+	ftyp := box("ftyp", []byte("isom"), u32(512), []byte("isomiso2mp41"))
+	moov := box("moov", mvhd, trak, udta)
+	return append(append(ftyp, moov...), box("mdat", make([]byte, 16))...)
+}
+
+func TestParseMP4(t *testing.T) {
+	created := time.Date(2026, 2, 10, 23, 15, 0, 0, time.UTC)
+	meta, err := ParseMP4(buildTestMP4(created))
+	if err != nil {
+		t.Fatalf("ParseMP4() error = %v", err)
+	}
+
+	if meta.CreationTime == nil || !meta.CreationTime.Equal(created) {
+		t.Errorf("ParseMP4() CreationTime = %v, want %v", meta.CreationTime, created)
+	}
+	if meta.DurationSeconds != 10 {
+		t.Errorf("ParseMP4() DurationSeconds = %v, want 10", meta.DurationSeconds)
+	}
+	if meta.Width != 1920 || meta.Height != 1080 {
+		t.Errorf("ParseMP4() size = %dx%d, want 1920x1080", meta.Width, meta.Height)
+	}
+	if math.Abs(meta.FrameRate-30) > 1e-9 {
+		t.Errorf("ParseMP4() FrameRate = %v, want 30", meta.FrameRate)
+	}
+	if meta.Make != "Apple" {
+		t.Errorf("ParseMP4() Make = %q, want Apple", meta.Make)
+	}
+	if meta.GPS == nil || math.Abs(meta.GPS.Latitude-48.8584) > 1e-9 || math.Abs(meta.GPS.Longitude-2.2945) > 1e-9 {
+		t.Errorf("ParseMP4() GPS = %+v, want 48.8584,2.2945", meta.GPS)
+	}
+	if meta.GPS != nil && (meta.GPS.Altitude == nil || *meta.GPS.Altitude != 35) {
+		t.Errorf("ParseMP4() altitude = %v, want 35", meta.GPS.Altitude)
+	}
+}
+
+func TestParseMP4_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not mp4", []byte("this is definitely not a video file")},
+		{"no moov", box("ftyp", []byte("isom"), u32(0))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseMP4(tt.data); err == nil {
+				t.Error("ParseMP4() expected error")
+			}
+		})
+	}
+}
+
+func TestParseISO6709(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *GPSLocation
+		wantAlt bool
+	}{
+		{"+37.3861-122.0839/", &GPSLocation{Latitude: 37.3861, Longitude: -122.0839}, false},
+		{"-33.8688+151.2093+012.5/", &GPSLocation{Latitude: -33.8688, Longitude: 151.2093}, true},
+		{"garbage", nil, false},
+		{"+95.0000+010.0000/", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := parseISO6709(tt.in)
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("parseISO6709() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.Latitude != tt.want.Latitude || got.Longitude != tt.want.Longitude {
+				t.Errorf("parseISO6709() = %+v, want %+v", got, tt.want)
+				return
+			}
+			if (got.Altitude != nil) != tt.wantAlt {
+				t.Errorf("parseISO6709() altitude = %v, wantAlt %v", got.Altitude, tt.wantAlt)
+			}
+		})
+	}
+}
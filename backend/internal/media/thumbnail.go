@@ -0,0 +1,120 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"path"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register WebP decoder for generated images
+)
+
+// Fixed thumbnail dimensions (4:3, cover-cropped)
+const (
+	ThumbnailWidth  = 320
+	ThumbnailHeight = 240
+)
+
+// ThumbnailContentType is the MIME type of encoded thumbnails
+const ThumbnailContentType = "image/jpeg"
+
+// thumbnailQuality is the JPEG quality used for previews
+const thumbnailQuality = 80
+
+// ThumbnailKey derives the storage key of an asset's thumbnail,
+// e.g. cases/x/generated/abc.png -> cases/x/generated/abc_thumb.jpg
+func ThumbnailKey(assetKey string) string {
+	ext := path.Ext(assetKey)
+	return strings.TrimSuffix(assetKey, ext) + "_thumb.jpg"
+}
+
+// GenerateThumbnail decodes an image and produces a fixed-size JPEG preview.
+// JPEG EXIF orientation is applied so previews display upright.
+func GenerateThumbnail(data []byte) ([]byte, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	if format == "jpeg" {
+		if exif, err := ParseJPEGEXIF(data); err == nil {
+			img = applyOrientation(img, exif.Orientation)
+		}
+	}
+
+	return EncodeThumbnail(img)
+}
+
+// EncodeThumbnail scales and center-crops img to the thumbnail size and encodes it as JPEG
+func EncodeThumbnail(img image.Image) ([]byte, error) {
+	src := img.Bounds()
+	if src.Empty() {
+		return nil, fmt.Errorf("image has no pixels")
+	}
+
+	// Crop the largest centered region with the thumbnail aspect ratio
+	crop := src
+	if src.Dx()*ThumbnailHeight > src.Dy()*ThumbnailWidth {
+		w := src.Dy() * ThumbnailWidth / ThumbnailHeight
+		crop.Min.X = src.Min.X + (src.Dx()-w)/2
+		crop.Max.X = crop.Min.X + w
+	} else {
+		h := src.Dx() * ThumbnailHeight / ThumbnailWidth
+		crop.Min.Y = src.Min.Y + (src.Dy()-h)/2
+		crop.Max.Y = crop.Min.Y + h
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, ThumbnailWidth, ThumbnailHeight))
+	// White background so transparent PNGs don't turn black in JPEG
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// applyOrientation rotates/flips an image according to its EXIF orientation tag (1-8)
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	outW, outH := w, h
+	if orientation >= 5 {
+		outW, outH = h, w
+	}
+	out := image.NewRGBA(image.Rect(0, 0, outW, outH))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirror horizontal
+				dx, dy = w-1-x, y
+			case 3: // rotate 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirror vertical
+				dx, dy = x, h-1-y
+			case 5: // transpose
+				dx, dy = y, x
+			case 6: // rotate 90 CW
+				dx, dy = h-1-y, x
+			case 7: // transverse
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotate 90 CCW
+				dx, dy = y, w-1-x
+			}
+			out.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
@@ -0,0 +1,139 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestThumbnailKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"cases/1/generated/abc.png", "cases/1/generated/abc_thumb.jpg"},
+		{"cases/1/scans/b/photo.JPG", "cases/1/scans/b/photo_thumb.jpg"},
+		{"cases/1/replay/clip.mp4", "cases/1/replay/clip_thumb.jpg"},
+		{"noext", "noext_thumb.jpg"},
+	}
+	for _, tt := range tests {
+		if got := ThumbnailKey(tt.in); got != tt.want {
+			t.Errorf("ThumbnailKey(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateThumbnail_FixedSize(t *testing.T) {
+	sizes := []image.Rectangle{
+		image.Rect(0, 0, 1600, 1200), // matching aspect
+		image.Rect(0, 0, 1024, 1024), // square
+		image.Rect(0, 0, 90, 600),    // tall, smaller than thumbnail
+	}
+
+	for _, r := range sizes {
+		var buf bytes.Buffer
+		png.Encode(&buf, image.NewRGBA(r))
+
+		thumb, err := GenerateThumbnail(buf.Bytes())
+		if err != nil {
+			t.Fatalf("GenerateThumbnail(%v) error = %v", r, err)
+		}
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(thumb))
+		if err != nil {
+			t.Fatalf("thumbnail not decodable: %v", err)
+		}
+		if format != "jpeg" || cfg.Width != ThumbnailWidth || cfg.Height != ThumbnailHeight {
+			t.Errorf("GenerateThumbnail(%v) = %s %dx%d, want jpeg %dx%d", r, format, cfg.Width, cfg.Height, ThumbnailWidth, ThumbnailHeight)
+		}
+	}
+}
+
+func TestGenerateThumbnail_InvalidImage(t *testing.T) {
+	if _, err := GenerateThumbnail([]byte("not an image")); err == nil {
+		t.Error("GenerateThumbnail() expected error for invalid data")
+	}
+}
+
+func TestGenerateThumbnail_CropsCenter(t *testing.T) {
+	// Wide image: red side bands, blue center; the 4:3 crop must keep only blue
+	img := image.NewRGBA(image.Rect(0, 0, 800, 300))
+	for y := 0; y < 300; y++ {
+		for x := 0; x < 800; x++ {
+			c := color.RGBA{255, 0, 0, 255}
+			if x >= 200 && x < 600 {
+				c = color.RGBA{0, 0, 255, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+
+	thumb, err := GenerateThumbnail(buf.Bytes())
+	if err != nil {
+		t.Fatalf("GenerateThumbnail() error = %v", err)
+	}
+	out, err := jpeg.Decode(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatalf("jpeg.Decode() error = %v", err)
+	}
+	for _, x := range []int{5, ThumbnailWidth / 2, ThumbnailWidth - 5} {
+		r, _, b, _ := out.At(x, ThumbnailHeight/2).RGBA()
+		if r > b {
+			t.Errorf("pixel at x=%d is red, expected center crop to be blue", x)
+		}
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// 2x1 image: left pixel red, right pixel blue
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	img.Set(0, 0, red)
+	img.Set(1, 0, blue)
+
+	tests := []struct {
+		orientation int
+		wantW       int
+		wantH       int
+		redAt       image.Point
+	}{
+		{1, 2, 1, image.Pt(0, 0)},
+		{2, 2, 1, image.Pt(1, 0)},
+		{3, 2, 1, image.Pt(1, 0)},
+		{6, 1, 2, image.Pt(0, 0)},
+		{8, 1, 2, image.Pt(0, 1)},
+	}
+
+	for _, tt := range tests {
+		out := applyOrientation(img, tt.orientation)
+		b := out.Bounds()
+		if b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+			t.Errorf("applyOrientation(%d) size = %dx%d, want %dx%d", tt.orientation, b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+			continue
+		}
+		if got := color.RGBAModel.Convert(out.At(tt.redAt.X, tt.redAt.Y)); got != red {
+			t.Errorf("applyOrientation(%d) pixel %v = %v, want red", tt.orientation, tt.redAt, got)
+		}
+	}
+}
+
+func TestPosterFrameOffset(t *testing.T) {
+	tests := []struct {
+		duration float64
+		want     float64
+	}{
+		{0, 0},
+		{1, 0.5},
+		{5.2, 1},
+	}
+	for _, tt := range tests {
+		if got := PosterFrameOffset(tt.duration); got != tt.want {
+			t.Errorf("PosterFrameOffset(%v) = %v, want %v", tt.duration, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"errors"
 	"time"
 
@@ -51,6 +52,42 @@ func (a *Asset) SetMetadata(key string, value interface{}) {
 	a.Metadata[key] = value
 }
 
+// AssetMetadataCameraIntrinsics is the metadata key holding intrinsics derived at ingest
+const AssetMetadataCameraIntrinsics = "camera_intrinsics"
+
+// CameraIntrinsics returns the intrinsics derived from the asset's capture metadata, if any
+func (a *Asset) CameraIntrinsics() (*CameraIntrinsics, bool) {
+	raw, ok := a.Metadata[AssetMetadataCameraIntrinsics]
+	if !ok {
+		return nil, false
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, false
+	}
+	var intr CameraIntrinsics
+	if err := json.Unmarshal(data, &intr); err != nil || intr.Fx <= 0 || intr.Fy <= 0 {
+		return nil, false
+	}
+	return &intr, true
+}
+
+// Timeline anchor sources
+const (
+	TimelineAnchorSourceEXIF      = "exif"
+	TimelineAnchorSourceContainer = "container"
+)
+
+// TimelineAnchor is a capture time read from an uploaded asset, usable as a hard
+// time reference when ordering events
+type TimelineAnchor struct {
+	AssetKey        string    `json:"asset_key"`
+	Time            time.Time `json:"time"`
+	DurationSeconds float64   `json:"duration_seconds,omitempty"` // For video, the anchor covers [Time, Time+Duration]
+	Source          string    `json:"source"`
+	Approximate     bool      `json:"approximate"` // True when the capture timezone was not recorded
+}
+
 // SceneSnapshot represents the current state of a scene
 type SceneSnapshot struct {
 	CaseID     uuid.UUID   `json:"case_id"`
@@ -237,3 +237,55 @@ func TestNewSceneSnapshot(t *testing.T) {
 		t.Errorf("NewSceneSnapshot() created invalid snapshot: %v", err)
 	}
 }
+
+func TestAsset_CameraIntrinsics(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		wantOK   bool
+		wantFx   float64
+	}{
+		{
+			name:     "no metadata",
+			metadata: nil,
+			wantOK:   false,
+		},
+		{
+			name: "intrinsics present",
+			metadata: map[string]interface{}{
+				AssetMetadataCameraIntrinsics: map[string]interface{}{
+					"fx": 3000.0, "fy": 3000.0, "cx": 2016.0, "cy": 1512.0, "source": "exif_35mm_equivalent",
+				},
+			},
+			wantOK: true,
+			wantFx: 3000,
+		},
+		{
+			name: "zero focal length rejected",
+			metadata: map[string]interface{}{
+				AssetMetadataCameraIntrinsics: map[string]interface{}{"fx": 0.0, "fy": 0.0},
+			},
+			wantOK: false,
+		},
+		{
+			name: "malformed value",
+			metadata: map[string]interface{}{
+				AssetMetadataCameraIntrinsics: "not an object",
+			},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Asset{Metadata: tt.metadata}
+			intr, ok := a.CameraIntrinsics()
+			if ok != tt.wantOK {
+				t.Errorf("Asset.CameraIntrinsics() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && intr.Fx != tt.wantFx {
+				t.Errorf("Asset.CameraIntrinsics() fx = %v, want %v", intr.Fx, tt.wantFx)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit log limits
+const (
+	DefaultAuditLimit = 100
+	MaxAuditLimit     = 500
+)
+
+// Audit actions recorded for worker commits; API actions are named after
+// their routes (see api.Audit)
+const AuditActionCommitCreate = "commit.create"
+
+// AuditEntry records who did what to a case. API requests are recorded with
+// their route and status; worker commits with the job that wrote them.
+type AuditEntry struct {
+	ID         uuid.UUID      `json:"id"`
+	CaseID     *uuid.UUID     `json:"case_id,omitempty"`
+	ActorType  AuditActorType `json:"actor_type"`
+	ActorID    *uuid.UUID     `json:"actor_id,omitempty"` // nil when authentication is disabled
+	RequestID  string         `json:"request_id,omitempty"`
+	JobID      *uuid.UUID     `json:"job_id,omitempty"`
+	Method     string         `json:"method,omitempty"`
+	Route      string         `json:"route,omitempty"` // route pattern, e.g. /v1/cases/{caseId}/jobs
+	Action     string         `json:"action"`
+	TargetType string         `json:"target_type,omitempty"`
+	TargetID   string         `json:"target_id,omitempty"`
+	Outcome    AuditOutcome   `json:"outcome"`
+	Status     int            `json:"status,omitempty"` // HTTP status of API requests
+	Detail     string         `json:"detail,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// Validate checks if the AuditEntry is valid
+func (e *AuditEntry) Validate() error {
+	if !e.ActorType.IsValid() {
+		return errors.New("actor_type must be user or worker")
+	}
+	if e.Action == "" {
+		return errors.New("action is required")
+	}
+	if !e.Outcome.IsValid() {
+		return errors.New("outcome must be success, denied or failed")
+	}
+	return nil
+}
+
+// NewAuditEntry creates an audit entry with a new ID and timestamp
+func NewAuditEntry(actorType AuditActorType, actorID *uuid.UUID, action string, outcome AuditOutcome) *AuditEntry {
+	return &AuditEntry{
+		ID:        uuid.New(),
+		ActorType: actorType,
+		ActorID:   actorID,
+		Action:    action,
+		Outcome:   outcome,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// SetTarget sets the entity the action was taken on
+func (e *AuditEntry) SetTarget(targetType, targetID string) {
+	e.TargetType = targetType
+	e.TargetID = targetID
+}
+
+// AuditQuery filters a case's audit log, newest first
+type AuditQuery struct {
+	CaseID  uuid.UUID
+	ActorID *uuid.UUID
+	Action  string
+	Outcome AuditOutcome
+	From    *time.Time // entries at or after
+	To      *time.Time // entries before
+	Cursor  *time.Time // entries before, for the next page
+	Limit   int
+}
+
+// Validate checks if the AuditQuery is valid
+func (q *AuditQuery) Validate() error {
+	if q.CaseID == uuid.Nil {
+		return errors.New("case_id is required")
+	}
+	if q.Outcome != "" && !q.Outcome.IsValid() {
+		return errors.New("outcome must be success, denied or failed")
+	}
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		return errors.New("from must be before to")
+	}
+	if q.Limit < 0 || q.Limit > MaxAuditLimit {
+		return errors.New("limit must be between 1 and 500")
+	}
+	return nil
+}
+
+// SetDefaults sets default values for AuditQuery
+func (q *AuditQuery) SetDefaults() {
+	if q.Limit == 0 {
+		q.Limit = DefaultAuditLimit
+	}
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAuditEntry_Validate(t *testing.T) {
+	actor := uuid.New()
+	tests := []struct {
+		name    string
+		entry   *AuditEntry
+		wantErr bool
+	}{
+		{name: "request", entry: NewAuditEntry(AuditActorUser, &actor, "case.update", AuditOutcomeSuccess)},
+		{name: "worker without a user", entry: NewAuditEntry(AuditActorWorker, nil, AuditActionCommitCreate, AuditOutcomeSuccess)},
+		{name: "unknown actor", entry: NewAuditEntry("system", nil, "case.update", AuditOutcomeSuccess), wantErr: true},
+		{name: "no action", entry: NewAuditEntry(AuditActorUser, &actor, "", AuditOutcomeSuccess), wantErr: true},
+		{name: "unknown outcome", entry: NewAuditEntry(AuditActorUser, &actor, "case.update", "partial"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.entry.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuditOutcomeFor(t *testing.T) {
+	tests := []struct {
+		status int
+		want   AuditOutcome
+	}{
+		{200, AuditOutcomeSuccess},
+		{202, AuditOutcomeSuccess},
+		{401, AuditOutcomeDenied},
+		{403, AuditOutcomeDenied},
+		{404, AuditOutcomeFailed},
+		{409, AuditOutcomeFailed},
+		{500, AuditOutcomeFailed},
+	}
+
+	for _, tt := range tests {
+		if got := AuditOutcomeFor(tt.status); got != tt.want {
+			t.Errorf("AuditOutcomeFor(%d) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestAuditQuery_Validate(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+	caseID := uuid.New()
+	tests := []struct {
+		name    string
+		query   AuditQuery
+		wantErr bool
+	}{
+		{name: "case", query: AuditQuery{CaseID: caseID}},
+		{name: "filters", query: AuditQuery{CaseID: caseID, Action: "job.create", Outcome: AuditOutcomeDenied, From: &earlier, To: &now, Limit: 10}},
+		{name: "no case", query: AuditQuery{}, wantErr: true},
+		{name: "unknown outcome", query: AuditQuery{CaseID: caseID, Outcome: "partial"}, wantErr: true},
+		{name: "empty range", query: AuditQuery{CaseID: caseID, From: &now, To: &earlier}, wantErr: true},
+		{name: "limit too large", query: AuditQuery{CaseID: caseID, Limit: 1000}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	q := AuditQuery{CaseID: caseID}
+	q.SetDefaults()
+	if q.Limit != DefaultAuditLimit {
+		t.Errorf("SetDefaults() limit = %d, want %d", q.Limit, DefaultAuditLimit)
+	}
+}
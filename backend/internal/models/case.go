@@ -2,18 +2,26 @@ package models
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// DefaultCaseRetention is how long a deleted case can be restored before it
+// is purged
+const DefaultCaseRetention = 30 * 24 * time.Hour
+
 // Case represents a crime investigation case
 type Case struct {
 	ID          uuid.UUID  `json:"id"`
 	Title       string     `json:"title"`
 	Description string     `json:"description,omitempty"`
+	Status      CaseStatus `json:"status"`
 	CreatedBy   *uuid.UUID `json:"created_by,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"` // set while the case waits to be purged
 }
 
 // Validate checks if the Case is valid
@@ -24,15 +32,76 @@ func (c *Case) Validate() error {
 	if len(c.Title) > 200 {
 		return errors.New("title must be 200 characters or less")
 	}
+	if c.Status != "" && !c.Status.IsValid() {
+		return errors.New("status must be open, under_review, closed or archived")
+	}
 	return nil
 }
 
+// AcceptsChanges reports whether the case takes new jobs and commits. Cases
+// from before statuses existed are open.
+func (c *Case) AcceptsChanges() bool {
+	return c.DeletedAt == nil && (c.Status == "" || c.Status.AcceptsChanges())
+}
+
+// PurgeAfter returns when a deleted case becomes due for purging, or nil if
+// the case isn't deleted
+func (c *Case) PurgeAfter(retention time.Duration) *time.Time {
+	if c.DeletedAt == nil {
+		return nil
+	}
+	t := c.DeletedAt.Add(retention)
+	return &t
+}
+
 // NewCase creates a new Case with generated ID and timestamp
 func NewCase(title, description string) *Case {
+	now := time.Now().UTC()
 	return &Case{
 		ID:          uuid.New(),
 		Title:       title,
 		Description: description,
-		CreatedAt:   time.Now().UTC(),
+		Status:      CaseStatusOpen,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// CaseUpdate is a partial update of a case; nil fields are left unchanged
+type CaseUpdate struct {
+	Title       *string     `json:"title,omitempty"`
+	Description *string     `json:"description,omitempty"`
+	Status      *CaseStatus `json:"status,omitempty"`
+}
+
+// Validate checks if the CaseUpdate is valid
+func (u *CaseUpdate) Validate() error {
+	if u.Title == nil && u.Description == nil && u.Status == nil {
+		return errors.New("nothing to update")
+	}
+	if u.Title != nil {
+		if strings.TrimSpace(*u.Title) == "" {
+			return errors.New("title must not be empty")
+		}
+		if len(*u.Title) > 200 {
+			return errors.New("title must be 200 characters or less")
+		}
+	}
+	if u.Status != nil && !u.Status.IsValid() {
+		return errors.New("status must be open, under_review, closed or archived")
+	}
+	return nil
+}
+
+// Apply copies the update's fields onto c
+func (u *CaseUpdate) Apply(c *Case) {
+	if u.Title != nil {
+		c.Title = *u.Title
+	}
+	if u.Description != nil {
+		c.Description = *u.Description
+	}
+	if u.Status != nil {
+		c.Status = *u.Status
 	}
 }
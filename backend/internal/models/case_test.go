@@ -2,6 +2,7 @@ package models
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -92,6 +93,10 @@ func TestNewCase(t *testing.T) {
 		t.Error("NewCase() should set CreatedAt")
 	}
 
+	if c.Status != CaseStatusOpen {
+		t.Errorf("NewCase() Status = %v, want open", c.Status)
+	}
+
 	// Validate the created case
 	if err := c.Validate(); err != nil {
 		t.Errorf("NewCase() created invalid case: %v", err)
@@ -106,3 +111,66 @@ func TestNewCase_UniqueIDs(t *testing.T) {
 		t.Error("NewCase() should generate unique IDs")
 	}
 }
+
+func TestCase_AcceptsChanges(t *testing.T) {
+	deleted := time.Now()
+	tests := []struct {
+		name string
+		c    Case
+		want bool
+	}{
+		{"open", Case{Status: CaseStatusOpen}, true},
+		{"no status", Case{}, true},
+		{"under review", Case{Status: CaseStatusUnderReview}, true},
+		{"closed", Case{Status: CaseStatusClosed}, false},
+		{"archived", Case{Status: CaseStatusArchived}, false},
+		{"deleted", Case{Status: CaseStatusOpen, DeletedAt: &deleted}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.AcceptsChanges(); got != tt.want {
+				t.Errorf("AcceptsChanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	c := Case{DeletedAt: &deleted}
+	if after := c.PurgeAfter(DefaultCaseRetention); after == nil || !after.Equal(deleted.Add(DefaultCaseRetention)) {
+		t.Errorf("PurgeAfter() = %v", after)
+	}
+	if (&Case{}).PurgeAfter(DefaultCaseRetention) != nil {
+		t.Error("PurgeAfter() should be nil for a case that isn't deleted")
+	}
+}
+
+func TestCaseUpdate(t *testing.T) {
+	title, blank := "Renamed", " "
+	closed, unknown := CaseStatusClosed, CaseStatus("solved")
+	tests := []struct {
+		name    string
+		u       CaseUpdate
+		wantErr bool
+	}{
+		{name: "title", u: CaseUpdate{Title: &title}},
+		{name: "status", u: CaseUpdate{Status: &closed}},
+		{name: "empty", u: CaseUpdate{}, wantErr: true},
+		{name: "blank title", u: CaseUpdate{Title: &blank}, wantErr: true},
+		{name: "unknown status", u: CaseUpdate{Status: &unknown}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.u.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	c := NewCase("Original", "Kept")
+	(&CaseUpdate{Title: &title, Status: &closed}).Apply(c)
+	if c.Title != title || c.Description != "Kept" || c.Status != CaseStatusClosed {
+		t.Errorf("Apply() = %+v", c)
+	}
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ConstraintParams is the typed form of a Constraint's params
+type ConstraintParams interface {
+	Validate() error
+}
+
+// DoorDirectionParams states which way a door swings
+type DoorDirectionParams struct {
+	ObjectID  string        `json:"object_id"`
+	Direction DoorDirection `json:"direction"`
+}
+
+// Validate checks if the DoorDirectionParams are valid
+func (p *DoorDirectionParams) Validate() error {
+	if p.ObjectID == "" {
+		return errors.New("object_id is required")
+	}
+	if !p.Direction.IsValid() {
+		return errors.New("direction must be inward or outward")
+	}
+	return nil
+}
+
+// PassableAreaParams outlines the walkable floor, in [x, z] meters
+type PassableAreaParams struct {
+	Polygon   [][2]float64       `json:"polygon"`
+	Obstacles []PassableObstacle `json:"obstacles,omitempty"`
+	Area      float64            `json:"area,omitempty"`
+	Source    string             `json:"source,omitempty"`
+}
+
+// PassableObstacle is the footprint of an object standing on the passable area
+type PassableObstacle struct {
+	ObjectID string       `json:"object_id,omitempty"`
+	Polygon  [][2]float64 `json:"polygon"`
+}
+
+// Validate checks if the PassableAreaParams are valid
+func (p *PassableAreaParams) Validate() error {
+	if len(p.Polygon) < 3 {
+		return errors.New("polygon needs at least 3 points")
+	}
+	for _, o := range p.Obstacles {
+		if len(o.Polygon) < 3 {
+			return errors.New("obstacle polygon needs at least 3 points")
+		}
+	}
+	return nil
+}
+
+// HeightRangeParams bounds the height of the person who left the evidence
+type HeightRangeParams struct {
+	MinCm float64 `json:"min_cm"`
+	MaxCm float64 `json:"max_cm"`
+}
+
+// Validate checks if the HeightRangeParams are valid
+func (p *HeightRangeParams) Validate() error {
+	if p.MinCm <= 0 || p.MaxCm <= 0 {
+		return errors.New("min_cm and max_cm must be positive")
+	}
+	if p.MinCm > p.MaxCm {
+		return errors.New("min_cm must be less than or equal to max_cm")
+	}
+	return nil
+}
+
+// TimeWindowParams bounds when movement happened. With an object_id, it only
+// applies to movement ending at that object.
+type TimeWindowParams struct {
+	StartISO string `json:"start_iso"`
+	EndISO   string `json:"end_iso"`
+	ObjectID string `json:"object_id,omitempty"`
+}
+
+// Validate checks if the TimeWindowParams are valid
+func (p *TimeWindowParams) Validate() error {
+	start, end, err := p.Window()
+	if err != nil {
+		return err
+	}
+	if end.Before(start) {
+		return errors.New("end_iso must not be before start_iso")
+	}
+	return nil
+}
+
+// Window parses the window's bounds
+func (p *TimeWindowParams) Window() (time.Time, time.Time, error) {
+	start, err := time.Parse(time.RFC3339, p.StartISO)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("start_iso must be an RFC 3339 timestamp")
+	}
+	end, err := time.Parse(time.RFC3339, p.EndISO)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("end_iso must be an RFC 3339 timestamp")
+	}
+	return start, end, nil
+}
+
+// NewConstraint creates a constraint from typed params
+func NewConstraint(id string, ctype ConstraintType, description string, params ConstraintParams, confidence float64) (*Constraint, error) {
+	c := &Constraint{
+		ID:          id,
+		Type:        ctype,
+		Description: description,
+		Confidence:  confidence,
+	}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &c.Params); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// TypedParams decodes Params into the struct for the constraint's type.
+// Custom constraints have no typed form and return nil.
+func (c *Constraint) TypedParams() (ConstraintParams, error) {
+	var params ConstraintParams
+	switch c.Type {
+	case ConstraintTypeDoorDirection:
+		params = &DoorDirectionParams{}
+	case ConstraintTypePassableArea:
+		params = &PassableAreaParams{}
+	case ConstraintTypeHeightRange:
+		params = &HeightRangeParams{}
+	case ConstraintTypeTimeWindow:
+		params = &TimeWindowParams{}
+	default:
+		return nil, nil
+	}
+
+	data, err := json.Marshal(c.Params)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, params); err != nil {
+		return nil, errors.New("params do not match " + string(c.Type))
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
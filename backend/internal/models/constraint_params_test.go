@@ -0,0 +1,164 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestConstraint_TypedParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       Constraint
+		wantErr bool
+	}{
+		{
+			name: "door direction",
+			c: Constraint{ID: "c1", Type: ConstraintTypeDoorDirection, Confidence: 0.8,
+				Params: map[string]interface{}{"object_id": "door-1", "direction": "inward"}},
+		},
+		{
+			name: "door direction without object",
+			c: Constraint{ID: "c1", Type: ConstraintTypeDoorDirection, Confidence: 0.8,
+				Params: map[string]interface{}{"direction": "inward"}},
+			wantErr: true,
+		},
+		{
+			name: "door direction sideways",
+			c: Constraint{ID: "c1", Type: ConstraintTypeDoorDirection, Confidence: 0.8,
+				Params: map[string]interface{}{"object_id": "door-1", "direction": "sideways"}},
+			wantErr: true,
+		},
+		{
+			name: "passable area",
+			c: Constraint{ID: "c2", Type: ConstraintTypePassableArea, Confidence: 0.9,
+				Params: map[string]interface{}{"polygon": []interface{}{
+					[]interface{}{0.0, 0.0}, []interface{}{4.0, 0.0}, []interface{}{4.0, 3.0},
+				}}},
+		},
+		{
+			name: "passable area with two points",
+			c: Constraint{ID: "c2", Type: ConstraintTypePassableArea, Confidence: 0.9,
+				Params: map[string]interface{}{"polygon": [][2]float64{{0, 0}, {4, 0}}}},
+			wantErr: true,
+		},
+		{
+			name: "passable area with a malformed polygon",
+			c: Constraint{ID: "c2", Type: ConstraintTypePassableArea, Confidence: 0.9,
+				Params: map[string]interface{}{"polygon": "the whole floor"}},
+			wantErr: true,
+		},
+		{
+			name: "height range",
+			c: Constraint{ID: "c3", Type: ConstraintTypeHeightRange, Confidence: 0.7,
+				Params: map[string]interface{}{"min_cm": 170, "max_cm": 185}},
+		},
+		{
+			name: "inverted height range",
+			c: Constraint{ID: "c3", Type: ConstraintTypeHeightRange, Confidence: 0.7,
+				Params: map[string]interface{}{"min_cm": 190, "max_cm": 170}},
+			wantErr: true,
+		},
+		{
+			name: "time window",
+			c: Constraint{ID: "c4", Type: ConstraintTypeTimeWindow, Confidence: 0.6,
+				Params: map[string]interface{}{"start_iso": "2024-01-15T22:00:00Z", "end_iso": "2024-01-15T23:30:00Z"}},
+		},
+		{
+			name: "time window without a date",
+			c: Constraint{ID: "c4", Type: ConstraintTypeTimeWindow, Confidence: 0.6,
+				Params: map[string]interface{}{"start_iso": "22:00", "end_iso": "23:30"}},
+			wantErr: true,
+		},
+		{
+			name: "time window ending before it starts",
+			c: Constraint{ID: "c4", Type: ConstraintTypeTimeWindow, Confidence: 0.6,
+				Params: map[string]interface{}{"start_iso": "2024-01-15T23:00:00Z", "end_iso": "2024-01-15T22:00:00Z"}},
+			wantErr: true,
+		},
+		{
+			name: "custom accepts anything",
+			c: Constraint{ID: "c5", Type: ConstraintTypeCustom, Confidence: 0.5,
+				Params: map[string]interface{}{"note": "witness heard a car"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.c.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Constraint.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConstraint_TypedParamsDecodes(t *testing.T) {
+	c := Constraint{ID: "c1", Type: ConstraintTypeHeightRange, Confidence: 0.7,
+		Params: map[string]interface{}{"min_cm": 170.0, "max_cm": 185.0}}
+
+	params, err := c.TypedParams()
+	if err != nil {
+		t.Fatalf("TypedParams() error = %v", err)
+	}
+	hr, ok := params.(*HeightRangeParams)
+	if !ok {
+		t.Fatalf("TypedParams() = %T, want *HeightRangeParams", params)
+	}
+	if hr.MinCm != 170 || hr.MaxCm != 185 {
+		t.Errorf("TypedParams() = %+v", hr)
+	}
+
+	custom := Constraint{ID: "c2", Type: ConstraintTypeCustom, Confidence: 0.5}
+	if params, err := custom.TypedParams(); params != nil || err != nil {
+		t.Errorf("custom TypedParams() = %v, %v, want nil, nil", params, err)
+	}
+}
+
+func TestNewConstraint(t *testing.T) {
+	c, err := NewConstraint("door", ConstraintTypeDoorDirection, "Front door opens inward",
+		&DoorDirectionParams{ObjectID: "door-1", Direction: DoorDirectionInward}, 0.9)
+	if err != nil {
+		t.Fatalf("NewConstraint() error = %v", err)
+	}
+	data, _ := json.Marshal(c)
+	var decoded Constraint
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Params["object_id"] != "door-1" || decoded.Params["direction"] != "inward" {
+		t.Errorf("round-tripped params = %v", decoded.Params)
+	}
+
+	if _, err := NewConstraint("h", ConstraintTypeHeightRange, "", &HeightRangeParams{MinCm: 0, MaxCm: 180}, 0.5); err == nil {
+		t.Error("NewConstraint() with a zero min_cm should fail")
+	}
+}
+
+func TestSceneGraph_ValidateConstraints(t *testing.T) {
+	sg := NewEmptySceneGraph()
+	sg.Constraints = []Constraint{{
+		ID: "bad", Type: ConstraintTypeTimeWindow, Confidence: 0.5,
+		Params: map[string]interface{}{"start_iso": "yesterday"},
+	}}
+	if err := sg.Validate(); err == nil {
+		t.Error("SceneGraph.Validate() should reject invalid constraint params")
+	}
+}
+
+func TestConstraintReport_Score(t *testing.T) {
+	r := NewConstraintReport("trajectory", "t1")
+	if r.Score != 1 {
+		t.Errorf("empty report score = %v, want 1", r.Score)
+	}
+	r.Add(ConstraintResult{ConstraintID: "a", Status: ConstraintStatusSatisfied, Confidence: 0.9})
+	r.Add(ConstraintResult{ConstraintID: "b", Status: ConstraintStatusViolated, Confidence: 0.3})
+	r.Add(ConstraintResult{ConstraintID: "c", Status: ConstraintStatusNotApplicable, Confidence: 0.8})
+	r.Add(ConstraintResult{ConstraintID: "d", Status: ConstraintStatusUnevaluated, Confidence: 0.8})
+
+	if r.Satisfied != 1 || r.Violated != 1 || r.Score != 0.75 {
+		t.Errorf("report = %d satisfied, %d violated, score %v", r.Satisfied, r.Violated, r.Score)
+	}
+	if v := r.Violations(); len(v) != 1 || v[0].ConstraintID != "b" {
+		t.Errorf("Violations() = %v", v)
+	}
+}
@@ -0,0 +1,72 @@
+package models
+
+import "math"
+
+// ConstraintResult is the outcome of checking one constraint against a hypothesis
+type ConstraintResult struct {
+	ConstraintID string           `json:"constraint_id"`
+	Type         ConstraintType   `json:"type"`
+	Status       ConstraintStatus `json:"status"`
+	Detail       string           `json:"detail,omitempty"`
+	Confidence   float64          `json:"confidence"` // the constraint's own confidence
+}
+
+// ConstraintReport collects the results of checking every constraint against
+// one trajectory or suspect hypothesis
+type ConstraintReport struct {
+	SubjectID   string             `json:"subject_id,omitempty"`
+	SubjectType string             `json:"subject_type"` // "trajectory" or "suspect"
+	Results     []ConstraintResult `json:"results"`
+	Satisfied   int                `json:"satisfied"`
+	Violated    int                `json:"violated"`
+	Score       float64            `json:"score"` // confidence-weighted share of satisfied constraints
+}
+
+// NewConstraintReport creates an empty report for a subject
+func NewConstraintReport(subjectType, subjectID string) *ConstraintReport {
+	return &ConstraintReport{
+		SubjectID:   subjectID,
+		SubjectType: subjectType,
+		Results:     []ConstraintResult{},
+		Score:       1,
+	}
+}
+
+// Add records a result and updates the counts and score. Results that are
+// not applicable or could not be evaluated do not affect the score.
+func (r *ConstraintReport) Add(result ConstraintResult) {
+	r.Results = append(r.Results, result)
+
+	var satisfied, total float64
+	r.Satisfied, r.Violated = 0, 0
+	for _, res := range r.Results {
+		weight := res.Confidence
+		if weight <= 0 {
+			weight = 1
+		}
+		switch res.Status {
+		case ConstraintStatusSatisfied:
+			r.Satisfied++
+			satisfied += weight
+			total += weight
+		case ConstraintStatusViolated:
+			r.Violated++
+			total += weight
+		}
+	}
+	r.Score = 1
+	if total > 0 {
+		r.Score = math.Round(satisfied/total*100) / 100
+	}
+}
+
+// Violations returns the results of violated constraints
+func (r *ConstraintReport) Violations() []ConstraintResult {
+	var out []ConstraintResult
+	for _, res := range r.Results {
+		if res.Status == ConstraintStatusViolated {
+			out = append(out, res)
+		}
+	}
+	return out
+}
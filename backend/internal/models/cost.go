@@ -0,0 +1,182 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Image generation prices per image, in USD
+const (
+	ImageCost1K = 0.04  // Nano Banana
+	ImageCost2K = 0.134 // Nano Banana Pro
+	ImageCost4K = 0.24  // Nano Banana Pro
+)
+
+// Replay video prices per frame, in USD, from HY-World GPU time
+const (
+	ReplayFrameCost480p = 0.002
+	ReplayFrameCost720p = 0.004
+)
+
+// jobBaseCosts approximates one run of job types whose cost doesn't depend
+// on their input, in USD
+var jobBaseCosts = map[JobType]float64{
+	JobTypeReconstruction: 0.30, // HunyuanWorld-Mirror GPU time
+	JobTypeAsset3D:        0.20, // Hunyuan3D-2 GPU time
+	JobTypeReasoning:      0.02,
+	JobTypeProfile:        0.01,
+	JobTypeExport:         0,
+}
+
+// sceneAnalysisImageCost is the cost of analysing one image, in USD
+const sceneAnalysisImageCost = 0.005
+
+// ImageCostUSD returns the price of generating one image at a resolution
+// ("1k", "2k" or "4k"; anything else is priced as 1k)
+func ImageCostUSD(resolution string) float64 {
+	switch resolution {
+	case "2k":
+		return ImageCost2K
+	case "4k":
+		return ImageCost4K
+	}
+	return ImageCost1K
+}
+
+// EstimateJobCost estimates what running a job will cost, in USD, from its
+// type and input: image count and resolution for image generation, frame
+// count and resolution for replays, image count for scene analysis, and a
+// flat rate for the rest
+func EstimateJobCost(jobType JobType, input json.RawMessage) (float64, error) {
+	switch jobType {
+	case JobTypeImageGen:
+		var in ImageGenInput
+		if err := unmarshalInput(input, &in); err != nil {
+			return 0, err
+		}
+		images := 1
+		if in.GenType == ImageGenTypeScenePOV {
+			images = len(in.ViewAngles)
+			if images == 0 {
+				images = len(GetDefaultViewAngles())
+			}
+		}
+		return float64(images) * ImageCostUSD(in.Resolution), nil
+
+	case JobTypeReplay:
+		var in ReplayInput
+		if err := unmarshalInput(input, &in); err != nil {
+			return 0, err
+		}
+		in.SetDefaults()
+		perFrame := ReplayFrameCost480p
+		if in.Resolution == "720p" {
+			perFrame = ReplayFrameCost720p
+		}
+		return float64(in.FrameCount) * perFrame, nil
+
+	case JobTypeSceneAnalysis:
+		var in SceneAnalysisInput
+		if err := unmarshalInput(input, &in); err != nil {
+			return 0, err
+		}
+		return float64(len(in.ImageKeys)) * sceneAnalysisImageCost, nil
+	}
+
+	cost, ok := jobBaseCosts[jobType]
+	if !ok {
+		return 0, fmt.Errorf("no price for job type %q", jobType)
+	}
+	return cost, nil
+}
+
+func unmarshalInput(input json.RawMessage, v interface{}) error {
+	if len(input) == 0 {
+		return nil
+	}
+	return json.Unmarshal(input, v)
+}
+
+// BudgetScope is what a spending budget applies to
+type BudgetScope string
+
+const (
+	BudgetScopeCase         BudgetScope = "case"         // everything spent on one case
+	BudgetScopeOrganisation BudgetScope = "organisation" // everything spent in a calendar month (UTC)
+)
+
+// Budgets caps AI spending, in USD. Zero means no cap.
+type Budgets struct {
+	CaseUSD                float64
+	OrganisationMonthlyUSD float64
+}
+
+// BudgetExceededError is returned when a job would take spending past a
+// budget
+type BudgetExceededError struct {
+	Scope       BudgetScope
+	LimitUSD    float64
+	SpentUSD    float64
+	EstimateUSD float64
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%s budget of $%.2f exceeded: $%.2f spent, job estimated at $%.2f",
+		e.Scope, e.LimitUSD, e.SpentUSD, e.EstimateUSD)
+}
+
+// Check returns a *BudgetExceededError if spending estimate on top of
+// caseSpent and orgSpent would go over a budget
+func (b Budgets) Check(caseSpent, orgSpent, estimate float64) error {
+	if b.CaseUSD > 0 && caseSpent+estimate > b.CaseUSD {
+		return &BudgetExceededError{Scope: BudgetScopeCase, LimitUSD: b.CaseUSD, SpentUSD: caseSpent, EstimateUSD: estimate}
+	}
+	if b.OrganisationMonthlyUSD > 0 && orgSpent+estimate > b.OrganisationMonthlyUSD {
+		return &BudgetExceededError{Scope: BudgetScopeOrganisation, LimitUSD: b.OrganisationMonthlyUSD, SpentUSD: orgSpent, EstimateUSD: estimate}
+	}
+	return nil
+}
+
+// Spend is what has been spent on AI jobs, in USD. Jobs count at their
+// actual cost once known and at their estimate until then; failed jobs
+// without a recorded cost count as nothing. Usage charges count toward the
+// month's spending under the job type they are billed as.
+type Spend struct {
+	TotalUSD  float64             `json:"total_usd"`
+	ByJobType map[JobType]float64 `json:"by_job_type"`
+}
+
+// UsageSourcePortraitChat is the source of charges for portrait chat turns
+const UsageSourcePortraitChat = "portrait_chat"
+
+// UsageCharge is AI spending outside jobs, such as a portrait chat turn,
+// billed as a job type. It counts toward the organisation budget only.
+type UsageCharge struct {
+	ID        uuid.UUID  `json:"id"`
+	Type      JobType    `json:"type"`
+	Source    string     `json:"source"`
+	CostUSD   float64    `json:"cost_usd"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NewUsageCharge creates a usage charge with a new ID and timestamp
+func NewUsageCharge(jobType JobType, source string, costUSD float64) *UsageCharge {
+	return &UsageCharge{
+		ID:        uuid.New(),
+		Type:      jobType,
+		Source:    source,
+		CostUSD:   costUSD,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// BudgetPeriodStart returns the start of the calendar month (UTC) the
+// organisation budget for t falls in
+func BudgetPeriodStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestEstimateJobCost(t *testing.T) {
+	tests := []struct {
+		name    string
+		jobType JobType
+		input   string
+		want    float64
+	}{
+		{"portrait at 1k", JobTypeImageGen, `{"gen_type": "portrait"}`, ImageCost1K},
+		{"portrait at 4k", JobTypeImageGen, `{"gen_type": "portrait", "resolution": "4k"}`, ImageCost4K},
+		{"scene POV with angles", JobTypeImageGen, `{"gen_type": "scene_pov", "resolution": "2k", "view_angles": ["front", "back"]}`, 2 * ImageCost2K},
+		{"scene POV with default angles", JobTypeImageGen, `{"gen_type": "scene_pov"}`, 6 * ImageCost1K},
+		{"default replay", JobTypeReplay, `{}`, 125 * ReplayFrameCost480p},
+		{"720p replay", JobTypeReplay, `{"frame_count": 240, "resolution": "720p"}`, 240 * ReplayFrameCost720p},
+		{"scene analysis", JobTypeSceneAnalysis, `{"image_keys": ["a", "b", "c", "d"]}`, 4 * sceneAnalysisImageCost},
+		{"reconstruction", JobTypeReconstruction, `{"scan_asset_keys": ["a"]}`, jobBaseCosts[JobTypeReconstruction]},
+		{"export", JobTypeExport, ``, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EstimateJobCost(tt.jobType, json.RawMessage(tt.input))
+			if err != nil {
+				t.Fatalf("EstimateJobCost() error = %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("EstimateJobCost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := EstimateJobCost("teleport", nil); err == nil {
+		t.Error("EstimateJobCost() of an unknown job type should fail")
+	}
+	if _, err := EstimateJobCost(JobTypeReplay, json.RawMessage(`{"frame_count": "many"}`)); err == nil {
+		t.Error("EstimateJobCost() of malformed input should fail")
+	}
+}
+
+func TestNewJob_EstimatedCost(t *testing.T) {
+	job, err := NewJob(uuid.New(), JobTypeImageGen, map[string]interface{}{"gen_type": "portrait", "resolution": "2k"})
+	if err != nil {
+		t.Fatalf("NewJob() error = %v", err)
+	}
+	if job.EstimatedCost != ImageCost2K || job.ActualCost != nil {
+		t.Errorf("NewJob() costs = %v, %v", job.EstimatedCost, job.ActualCost)
+	}
+}
+
+func TestBudgets_Check(t *testing.T) {
+	if err := (Budgets{}).Check(1000, 1000, 1000); err != nil {
+		t.Errorf("Check() without budgets error = %v", err)
+	}
+
+	b := Budgets{CaseUSD: 10, OrganisationMonthlyUSD: 100}
+	if err := b.Check(9.5, 50, 0.5); err != nil {
+		t.Errorf("Check() up to the budget error = %v", err)
+	}
+
+	var exceeded *BudgetExceededError
+	if err := b.Check(9.9, 50, 0.24); !errors.As(err, &exceeded) || exceeded.Scope != BudgetScopeCase {
+		t.Errorf("Check() over the case budget = %v", err)
+	}
+	err := b.Check(1, 99.9, 0.24)
+	if !errors.As(err, &exceeded) || exceeded.Scope != BudgetScopeOrganisation || exceeded.LimitUSD != 100 {
+		t.Fatalf("Check() over the monthly budget = %v", err)
+	}
+	if want := "organisation budget of $100.00 exceeded: $99.90 spent, job estimated at $0.24"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestNewUsageCharge(t *testing.T) {
+	c := NewUsageCharge(JobTypeImageGen, UsageSourcePortraitChat, ImageCostUSD("1k"))
+	if c.ID == uuid.Nil || c.CreatedAt.IsZero() {
+		t.Errorf("NewUsageCharge() = %+v, want an ID and timestamp", c)
+	}
+	if c.Type != JobTypeImageGen || c.Source != "portrait_chat" || c.CostUSD != ImageCost1K {
+		t.Errorf("NewUsageCharge() = %+v", c)
+	}
+}
+
+func TestBudgetPeriodStart(t *testing.T) {
+	est := time.FixedZone("EST", -5*60*60)
+	got := BudgetPeriodStart(time.Date(2026, 10, 31, 22, 0, 0, 0, est))
+	if want := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("BudgetPeriodStart() = %v, want %v", got, want)
+	}
+}
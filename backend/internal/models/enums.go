@@ -86,6 +86,7 @@ const (
 	AssetKindReport         AssetKind = "report"
 	AssetKindReplayVideo    AssetKind = "replay_video"    // HY-World-1.5 output
 	AssetKindEvidenceModel  AssetKind = "evidence_model"  // Hunyuan3D-2 output (GLB)
+	AssetKindScanVideo      AssetKind = "scan_video"      // Uploaded walkthrough video (MP4)
 )
 
 // IsValid checks if the asset kind is valid
@@ -93,7 +94,7 @@ func (ak AssetKind) IsValid() bool {
 	switch ak {
 	case AssetKindScanImage, AssetKindGeneratedImage, AssetKindMesh,
 		AssetKindPointcloud, AssetKindPortrait, AssetKindReport,
-		AssetKindReplayVideo, AssetKindEvidenceModel:
+		AssetKindReplayVideo, AssetKindEvidenceModel, AssetKindScanVideo:
 		return true
 	}
 	return false
@@ -202,3 +203,291 @@ func (est EvidenceSourceType) IsValid() bool {
 	}
 	return false
 }
+
+// PointCloudFormat identifies the binary encoding of a stored point cloud
+type PointCloudFormat string
+
+const (
+	PointCloudFormatPLY       PointCloudFormat = "ply"  // binary little-endian PLY
+	PointCloudFormatQuantized PointCloudFormat = "sqpc" // 16-bit quantized positions, 8-bit colors
+)
+
+// IsValid checks if the point cloud format is valid
+func (pf PointCloudFormat) IsValid() bool {
+	switch pf {
+	case PointCloudFormatPLY, PointCloudFormatQuantized:
+		return true
+	}
+	return false
+}
+
+// FeasibilityStatus is the outcome of checking a trajectory segment against the scene geometry
+type FeasibilityStatus string
+
+const (
+	FeasibilityStatusFeasible       FeasibilityStatus = "feasible"        // the stated route is walkable
+	FeasibilityStatusDetourRequired FeasibilityStatus = "detour_required" // reachable, but not along the stated route
+	FeasibilityStatusInfeasible     FeasibilityStatus = "infeasible"      // unreachable, or not in the time available
+)
+
+// IsValid checks if the feasibility status is valid
+func (fs FeasibilityStatus) IsValid() bool {
+	switch fs {
+	case FeasibilityStatusFeasible, FeasibilityStatusDetourRequired, FeasibilityStatusInfeasible:
+		return true
+	}
+	return false
+}
+
+// DoorDirection is the side a door swings toward
+type DoorDirection string
+
+const (
+	DoorDirectionInward  DoorDirection = "inward"  // into the room
+	DoorDirectionOutward DoorDirection = "outward" // away from the room
+)
+
+// IsValid checks if the door direction is valid
+func (dd DoorDirection) IsValid() bool {
+	switch dd {
+	case DoorDirectionInward, DoorDirectionOutward:
+		return true
+	}
+	return false
+}
+
+// ConstraintStatus is the outcome of evaluating one constraint against a hypothesis
+type ConstraintStatus string
+
+const (
+	ConstraintStatusSatisfied     ConstraintStatus = "satisfied"
+	ConstraintStatusViolated      ConstraintStatus = "violated"
+	ConstraintStatusNotApplicable ConstraintStatus = "not_applicable" // the hypothesis doesn't touch what the constraint covers
+	ConstraintStatusUnevaluated   ConstraintStatus = "unevaluated"    // custom constraints, or missing data
+)
+
+// IsValid checks if the constraint status is valid
+func (cs ConstraintStatus) IsValid() bool {
+	switch cs {
+	case ConstraintStatusSatisfied, ConstraintStatusViolated,
+		ConstraintStatusNotApplicable, ConstraintStatusUnevaluated:
+		return true
+	}
+	return false
+}
+
+// ExportFormat is the file format an export job renders the case in. Bundle
+// exports the whole case as a zip that can be imported elsewhere, and glTF
+// exports the scene as a GLB file for 3D tools.
+type ExportFormat string
+
+const (
+	ExportFormatHTML   ExportFormat = "html"
+	ExportFormatPDF    ExportFormat = "pdf"
+	ExportFormatBundle ExportFormat = "bundle"
+	ExportFormatGLTF   ExportFormat = "gltf"
+)
+
+// IsValid checks if the export format is valid
+func (ef ExportFormat) IsValid() bool {
+	switch ef {
+	case ExportFormatHTML, ExportFormatPDF, ExportFormatBundle, ExportFormatGLTF:
+		return true
+	}
+	return false
+}
+
+// ReportSection is a part of an exported case report that can be selected
+type ReportSection string
+
+const (
+	ReportSectionTimeline     ReportSection = "timeline"
+	ReportSectionEvidence     ReportSection = "evidence"
+	ReportSectionProfile      ReportSection = "profile"
+	ReportSectionTrajectories ReportSection = "trajectories"
+	ReportSectionParadoxes    ReportSection = "paradoxes"
+	ReportSectionConstraints  ReportSection = "constraints"
+	ReportSectionScene        ReportSection = "scene"
+	ReportSectionCustodyLog   ReportSection = "custody_log"
+	ReportSectionAuditLog     ReportSection = "audit_log"
+)
+
+// ReportSections lists every section in the order reports lay them out
+var ReportSections = []ReportSection{
+	ReportSectionTimeline, ReportSectionEvidence, ReportSectionProfile, ReportSectionTrajectories,
+	ReportSectionParadoxes, ReportSectionConstraints, ReportSectionScene, ReportSectionCustodyLog,
+	ReportSectionAuditLog,
+}
+
+// DefaultReportSections returns the sections a report has unless others are
+// chosen: all of them except the custody and audit logs
+func DefaultReportSections() []ReportSection {
+	var sections []ReportSection
+	for _, s := range ReportSections {
+		if s != ReportSectionCustodyLog && s != ReportSectionAuditLog {
+			sections = append(sections, s)
+		}
+	}
+	return sections
+}
+
+// IsValid checks if the report section is valid
+func (rs ReportSection) IsValid() bool {
+	for _, s := range ReportSections {
+		if rs == s {
+			return true
+		}
+	}
+	return false
+}
+
+// EvidenceTier groups evidence in reports by confidence
+type EvidenceTier string
+
+const (
+	EvidenceTierHigh   EvidenceTier = "high"   // confidence of at least 0.8
+	EvidenceTierMedium EvidenceTier = "medium" // at least 0.5
+	EvidenceTierLow    EvidenceTier = "low"
+)
+
+// IsValid checks if the evidence tier is valid
+func (et EvidenceTier) IsValid() bool {
+	switch et {
+	case EvidenceTierHigh, EvidenceTierMedium, EvidenceTierLow:
+		return true
+	}
+	return false
+}
+
+// EvidenceTierFor returns the tier of evidence with the given confidence
+func EvidenceTierFor(confidence float64) EvidenceTier {
+	switch {
+	case confidence >= 0.8:
+		return EvidenceTierHigh
+	case confidence >= 0.5:
+		return EvidenceTierMedium
+	}
+	return EvidenceTierLow
+}
+
+// SearchSource is where a case search found a match
+type SearchSource string
+
+const (
+	SearchSourceCase      SearchSource = "case"      // title or description
+	SearchSourceCommit    SearchSource = "commit"    // summary or payload
+	SearchSourceStatement SearchSource = "statement" // a witness statement
+	SearchSourceEvidence  SearchSource = "evidence"  // an evidence card in the current scene
+	SearchSourceObject    SearchSource = "object"    // an object label in the current scene
+)
+
+// IsValid checks if the search source is valid
+func (ss SearchSource) IsValid() bool {
+	switch ss {
+	case SearchSourceCase, SearchSourceCommit, SearchSourceStatement, SearchSourceEvidence, SearchSourceObject:
+		return true
+	}
+	return false
+}
+
+// CaseStatus is where a case is in its lifecycle. Closed and archived cases
+// are read-only: they take no new jobs or commits until reopened.
+type CaseStatus string
+
+const (
+	CaseStatusOpen        CaseStatus = "open"
+	CaseStatusUnderReview CaseStatus = "under_review"
+	CaseStatusClosed      CaseStatus = "closed"
+	CaseStatusArchived    CaseStatus = "archived"
+)
+
+// IsValid checks if the case status is valid
+func (cs CaseStatus) IsValid() bool {
+	switch cs {
+	case CaseStatusOpen, CaseStatusUnderReview, CaseStatusClosed, CaseStatusArchived:
+		return true
+	}
+	return false
+}
+
+// AcceptsChanges reports whether a case with this status takes new jobs and
+// commits
+func (cs CaseStatus) AcceptsChanges() bool {
+	return cs == CaseStatusOpen || cs == CaseStatusUnderReview
+}
+
+// CaseRole is what a member may do on a case. Each role includes the ones
+// below it: leads manage the case and its members, investigators add
+// evidence and run jobs, viewers only read.
+type CaseRole string
+
+const (
+	CaseRoleLead         CaseRole = "lead"
+	CaseRoleInvestigator CaseRole = "investigator"
+	CaseRoleViewer       CaseRole = "viewer"
+)
+
+// IsValid checks if the case role is valid
+func (cr CaseRole) IsValid() bool {
+	return cr.rank() > 0
+}
+
+// Includes reports whether the role grants at least what required does
+func (cr CaseRole) Includes(required CaseRole) bool {
+	return cr.IsValid() && cr.rank() >= required.rank()
+}
+
+func (cr CaseRole) rank() int {
+	switch cr {
+	case CaseRoleViewer:
+		return 1
+	case CaseRoleInvestigator:
+		return 2
+	case CaseRoleLead:
+		return 3
+	}
+	return 0
+}
+
+// AuditActorType is who an audit log entry was recorded for
+type AuditActorType string
+
+const (
+	AuditActorUser   AuditActorType = "user"   // an API request
+	AuditActorWorker AuditActorType = "worker" // a job, on behalf of the user who created it
+)
+
+// IsValid checks if the audit actor type is valid
+func (at AuditActorType) IsValid() bool {
+	return at == AuditActorUser || at == AuditActorWorker
+}
+
+// AuditOutcome is how an audited action ended
+type AuditOutcome string
+
+const (
+	AuditOutcomeSuccess AuditOutcome = "success"
+	AuditOutcomeDenied  AuditOutcome = "denied" // refused for lack of access
+	AuditOutcomeFailed  AuditOutcome = "failed"
+)
+
+// IsValid checks if the audit outcome is valid
+func (ao AuditOutcome) IsValid() bool {
+	switch ao {
+	case AuditOutcomeSuccess, AuditOutcomeDenied, AuditOutcomeFailed:
+		return true
+	}
+	return false
+}
+
+// AuditOutcomeFor returns the outcome of a request that got the given
+// HTTP status
+func AuditOutcomeFor(status int) AuditOutcome {
+	switch {
+	case status == 401 || status == 403:
+		return AuditOutcomeDenied
+	case status >= 400:
+		return AuditOutcomeFailed
+	}
+	return AuditOutcomeSuccess
+}
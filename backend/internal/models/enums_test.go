@@ -122,6 +122,7 @@ func TestAssetKind_IsValid(t *testing.T) {
 		AssetKindScanImage, AssetKindGeneratedImage, AssetKindMesh,
 		AssetKindPointcloud, AssetKindPortrait, AssetKindReport,
 		AssetKindReplayVideo, AssetKindEvidenceModel, // New asset kinds
+		AssetKindScanVideo,
 	}
 
 	for _, ak := range validKinds {
@@ -172,3 +173,47 @@ func TestUncertaintyLevel_IsValid(t *testing.T) {
 		})
 	}
 }
+
+func TestConstraintStatus_IsValid(t *testing.T) {
+	tests := []struct {
+		cs   ConstraintStatus
+		want bool
+	}{
+		{ConstraintStatusSatisfied, true},
+		{ConstraintStatusViolated, true},
+		{ConstraintStatusNotApplicable, true},
+		{ConstraintStatusUnevaluated, true},
+		{ConstraintStatus("invalid"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.cs), func(t *testing.T) {
+			if got := tt.cs.IsValid(); got != tt.want {
+				t.Errorf("ConstraintStatus.IsValid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaseRole_Includes(t *testing.T) {
+	tests := []struct {
+		role     CaseRole
+		required CaseRole
+		want     bool
+	}{
+		{CaseRoleLead, CaseRoleViewer, true},
+		{CaseRoleLead, CaseRoleLead, true},
+		{CaseRoleInvestigator, CaseRoleViewer, true},
+		{CaseRoleInvestigator, CaseRoleLead, false},
+		{CaseRoleViewer, CaseRoleInvestigator, false},
+		{CaseRole("owner"), CaseRoleViewer, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role)+"/"+string(tt.required), func(t *testing.T) {
+			if got := tt.role.Includes(tt.required); got != tt.want {
+				t.Errorf("CaseRole.Includes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,6 +21,9 @@ type Job struct {
 	Error          string          `json:"error,omitempty"`
 	IdempotencyKey string          `json:"idempotency_key,omitempty"`
 	RetryCount     int             `json:"retry_count"`
+	CreatedBy      *uuid.UUID      `json:"created_by,omitempty"` // user the job runs as
+	EstimatedCost  float64         `json:"estimated_cost_usd"`   // in USD, see EstimateJobCost
+	ActualCost     *float64        `json:"cost_usd,omitempty"`   // in USD, when the worker reports it
 	CreatedAt      time.Time       `json:"created_at"`
 	UpdatedAt      time.Time       `json:"updated_at"`
 }
@@ -48,17 +52,21 @@ func NewJob(caseID uuid.UUID, jobType JobType, input interface{}) (*Job, error)
 		return nil, err
 	}
 
+	// Unknown job types are estimated at nothing; Validate rejects them
+	estimate, _ := EstimateJobCost(jobType, inputBytes)
+
 	now := time.Now().UTC()
 	return &Job{
-		ID:         uuid.New(),
-		CaseID:     caseID,
-		Type:       jobType,
-		Status:     JobStatusQueued,
-		Progress:   0,
-		Input:      inputBytes,
-		RetryCount: 0,
-		CreatedAt:  now,
-		UpdatedAt:  now,
+		ID:            uuid.New(),
+		CaseID:        caseID,
+		Type:          jobType,
+		Status:        JobStatusQueued,
+		Progress:      0,
+		Input:         inputBytes,
+		RetryCount:    0,
+		EstimatedCost: estimate,
+		CreatedAt:     now,
+		UpdatedAt:     now,
 	}, nil
 }
 
@@ -175,17 +183,29 @@ type CameraIntrinsics struct {
 	Cy float64 `json:"cy"`
 }
 
-// CameraExtrinsics represents camera extrinsic parameters
+// CameraExtrinsics represents camera extrinsic parameters. They map world to
+// camera coordinates (x_cam = R·x_world + t) with +Z forward and +Y down, as in
+// OpenCV. Rotation is a row-major 3×3 matrix or a [w, x, y, z] quaternion.
 type CameraExtrinsics struct {
 	Rotation    []float64 `json:"rotation"`
 	Translation []float64 `json:"translation"`
 }
 
-// PointCloud represents 3D point cloud data from reconstruction
+// PointCloud represents 3D point cloud data from reconstruction.
+// Once stored, the full cloud lives at AssetKey and Positions/Colors hold
+// only a downsampled preview; Count is always the size of the full cloud.
 type PointCloud struct {
-	Positions [][]float64 `json:"positions"` // [[x,y,z], ...]
-	Colors    [][]float64 `json:"colors"`    // [[r,g,b], ...] in 0-1 range
-	Count     int         `json:"count"`
+	Positions [][]float64      `json:"positions,omitempty"` // [[x,y,z], ...]
+	Colors    [][]float64      `json:"colors,omitempty"`    // [[r,g,b], ...] in 0-1 range
+	Count     int              `json:"count"`
+	AssetKey  string           `json:"asset_key,omitempty"`
+	Format    PointCloudFormat `json:"format,omitempty"`
+	Bounds    *BoundingBox     `json:"bounds,omitempty"`
+}
+
+// IsStored reports whether the full cloud is held in storage rather than inline
+func (pc *PointCloud) IsStored() bool {
+	return pc != nil && pc.AssetKey != ""
 }
 
 // ReconstructionOutput represents output from reconstruction jobs
@@ -196,6 +216,9 @@ type ReconstructionOutput struct {
 	PointcloudAssetKey  string                `json:"pointcloud_asset_key,omitempty"`
 	GaussianAssetKey    string                `json:"gaussian_asset_key,omitempty"`
 	UncertaintyRegions  []UncertaintyRegion   `json:"uncertainty_regions"`
+	Constraints         []Constraint          `json:"constraints,omitempty"`
+	RoomBounds          *BoundingBox          `json:"room_bounds,omitempty"` // extent of the extracted walls and floor
+	CameraPoses         []CameraPose          `json:"camera_poses,omitempty"` // poses solved by the reconstruction service
 	ProcessingStats     ProcessingStats       `json:"processing_stats"`
 }
 
@@ -213,6 +236,11 @@ type ProcessingStats struct {
 	InputImages      int   `json:"input_images"`
 	DetectedObjects  int   `json:"detected_objects"`
 	PointCount       int   `json:"point_count,omitempty"`
+	RawPointCount    int   `json:"raw_point_count,omitempty"` // before downsampling and outlier removal
+	OutliersRemoved  int   `json:"outliers_removed,omitempty"`
+	FloorAligned     bool  `json:"floor_aligned,omitempty"` // cloud and objects moved to a Y-up, floor-at-zero frame
+	WallsDetected    int   `json:"walls_detected,omitempty"`
+	ObjectsLifted    int   `json:"objects_lifted,omitempty"` // 2D detections placed in 3D from camera poses
 	ProcessingTimeMs int64 `json:"processing_time_ms"`
 }
 
@@ -240,6 +268,11 @@ func (r *ReasoningInput) Validate() error {
 	if r.MaxTrajectories < 0 {
 		return errors.New("max_trajectories must be non-negative")
 	}
+	for _, c := range r.ConstraintsOverride {
+		if err := c.Validate(); err != nil {
+			return errors.New("constraint " + c.ID + ": " + err.Error())
+		}
+	}
 	return nil
 }
 
@@ -260,6 +293,8 @@ type ReasoningOutput struct {
 	NextStepSuggestions []Suggestion        `json:"next_step_suggestions"`
 	ThinkingSummary     string              `json:"thinking_summary,omitempty"`
 	ModelStats          ModelStats          `json:"model_stats"`
+	Scoring             *ScoringSummary     `json:"scoring,omitempty"`
+	Grounding           *GroundingReport    `json:"grounding,omitempty"`
 }
 
 // Trajectory represents a possible movement path
@@ -268,18 +303,85 @@ type Trajectory struct {
 	Rank              int                `json:"rank"`
 	OverallConfidence float64            `json:"overall_confidence"`
 	Segments          []TrajectorySegment `json:"segments"`
+	ConstraintReport  *ConstraintReport  `json:"constraint_report,omitempty"`
+	Score             *TrajectoryScore   `json:"score,omitempty"`
+	Grounding         *TrajectoryGrounding `json:"grounding,omitempty"`
+}
+
+// TrajectoryScore keeps the model's confidence and rank for a trajectory
+// next to the score computed from the case's evidence and scene
+type TrajectoryScore struct {
+	ModelConfidence    float64          `json:"model_confidence"`
+	ModelRank          int              `json:"model_rank"`
+	ComputedConfidence float64          `json:"computed_confidence"`
+	Components         []ScoreComponent `json:"components"`
+}
+
+// ScoreComponent is one weighted term of a computed trajectory score
+type ScoreComponent struct {
+	Name         string  `json:"name"`  // "evidence", "feasibility" or "constraints"
+	Value        float64 `json:"value"` // 0-1
+	Weight       float64 `json:"weight"`
+	Contribution float64 `json:"contribution"` // value × weight
+	Detail       string  `json:"detail,omitempty"`
+}
+
+// ScoringSummary describes how trajectories were re-scored after reasoning
+type ScoringSummary struct {
+	Weights  map[string]float64 `json:"weights"`
+	Reranked bool               `json:"reranked"` // the computed order differs from the model's
+}
+
+// TrajectoryGrounding counts how many of a trajectory's references resolve to the scene
+type TrajectoryGrounding struct {
+	Ratio      float64 `json:"ratio"` // resolved / references, 0 when nothing is referenced
+	References int     `json:"references"`
+	Resolved   int     `json:"resolved"`
+}
+
+// GroundingReport records how well a reasoning output's references resolve
+// against the input scene, and what was done with those that don't
+type GroundingReport struct {
+	Ratio      float64         `json:"ratio"`
+	References int             `json:"references"`
+	Resolved   int             `json:"resolved"`
+	Threshold  float64         `json:"threshold"`
+	Enforced   bool            `json:"enforced"` // false when the scene has nothing to resolve against
+	Unresolved []UnresolvedRef `json:"unresolved,omitempty"`
+}
+
+// UnresolvedRef is a reference to an evidence card or object that is not in the scene
+type UnresolvedRef struct {
+	Kind         string `json:"kind"` // "evidence" or "object"
+	ID           string `json:"id"`
+	TrajectoryID string `json:"trajectory_id,omitempty"`
+	SegmentID    string `json:"segment_id,omitempty"`
+	Action       string `json:"action"` // "dropped" (the reference) or "cleared" (the ID)
 }
 
 // TrajectorySegment represents a segment of a trajectory
 type TrajectorySegment struct {
-	ID           string         `json:"id"`
-	FromPosition [3]float64     `json:"from_position"`
-	ToPosition   [3]float64     `json:"to_position"`
-	Waypoints    [][3]float64   `json:"waypoints,omitempty"`
-	TimeEstimate *TimeEstimate  `json:"time_estimate,omitempty"`
-	EvidenceRefs []EvidenceRef  `json:"evidence_refs"`
-	Confidence   float64        `json:"confidence"`
-	Explanation  string         `json:"explanation"`
+	ID           string              `json:"id"`
+	FromPosition [3]float64          `json:"from_position"`
+	ToPosition   [3]float64          `json:"to_position"`
+	Waypoints    [][3]float64        `json:"waypoints,omitempty"`
+	TimeEstimate *TimeEstimate       `json:"time_estimate,omitempty"`
+	EvidenceRefs []EvidenceRef       `json:"evidence_refs"`
+	Confidence   float64             `json:"confidence"`
+	Explanation  string              `json:"explanation"`
+	Feasibility  *SegmentFeasibility `json:"feasibility,omitempty"`
+}
+
+// SegmentFeasibility records the physical check of a segment against the scene
+type SegmentFeasibility struct {
+	Status           FeasibilityStatus `json:"status"`
+	StraightDistance float64           `json:"straight_distance"`     // meters, from_position to to_position
+	PathLength       float64           `json:"path_length,omitempty"` // meters, shortest walkable route
+	WalkingSeconds   float64           `json:"walking_seconds,omitempty"`
+	RunningSeconds   float64           `json:"running_seconds,omitempty"`
+	Path             [][3]float64      `json:"path,omitempty"`   // shortest route when a detour is required
+	PriorConfidence  float64           `json:"prior_confidence"` // segment confidence before the check
+	Reason           string            `json:"reason,omitempty"`
 }
 
 // TimeEstimate represents a time window estimate
@@ -631,6 +733,15 @@ type DetectedObject struct {
 	IsSuspicious       bool     `json:"is_suspicious"`
 	Notes              string   `json:"notes,omitempty"`
 	SourceImageKey     string   `json:"source_image_key"`
+	Sources            []DetectionSource `json:"sources,omitempty"` // every image the object was seen in, after normalization
+}
+
+// DetectionSource is one sighting of a detected object
+type DetectionSource struct {
+	ImageKey            string  `json:"image_key"`
+	Confidence          float64 `json:"confidence"`
+	BoundingBox         *BBox   `json:"bounding_box,omitempty"`
+	PositionDescription string  `json:"position_description,omitempty"`
 }
 
 // BBox represents a 2D bounding box for detected objects
@@ -640,3 +751,105 @@ type BBox struct {
 	Width  float64 `json:"width"`  // Width (0-1 normalized)
 	Height float64 `json:"height"` // Height (0-1 normalized)
 }
+
+// ============================================
+// EXPORT JOB
+// ============================================
+
+// ExportInput represents input for export jobs. Everything but the format
+// configures the report and doesn't apply to bundle exports; glTF exports
+// only take the branch or commit to export the scene at.
+type ExportInput struct {
+	Format        ExportFormat    `json:"format,omitempty"`
+	Template      string          `json:"template,omitempty"`       // named HTML template; html only
+	Sections      []ReportSection `json:"sections,omitempty"`       // defaults to every section but the custody log
+	EvidenceTiers []EvidenceTier  `json:"evidence_tiers,omitempty"` // defaults to every tier
+	BranchID      *uuid.UUID      `json:"branch_id,omitempty"`      // report on the branch's latest commit
+	CommitID      *uuid.UUID      `json:"commit_id,omitempty"`      // report on the case as of this commit
+	Redaction     *RedactionRules `json:"redaction,omitempty"`
+}
+
+// RedactionRules controls which names are hidden in a report
+type RedactionRules struct {
+	WitnessNames bool     `json:"witness_names,omitempty"` // replace witness names with "Witness A", "Witness B", ...
+	Names        []string `json:"names,omitempty"`         // further names to hide
+	Replacement  string   `json:"replacement,omitempty"`   // fixed text to use instead of per-witness labels
+}
+
+// Validate checks if the RedactionRules are valid
+func (r *RedactionRules) Validate() error {
+	for _, name := range r.Names {
+		if strings.TrimSpace(name) == "" {
+			return errors.New("redacted names must not be empty")
+		}
+	}
+	if len(r.Replacement) > 100 {
+		return errors.New("replacement must be at most 100 characters")
+	}
+	return nil
+}
+
+// Validate checks if the ExportInput is valid
+func (e *ExportInput) Validate() error {
+	if !e.Format.IsValid() {
+		return errors.New("format must be html, pdf, bundle or gltf")
+	}
+	if e.Format == ExportFormatGLTF {
+		if e.Template != "" || len(e.Sections) > 0 || len(e.EvidenceTiers) > 0 || e.Redaction != nil {
+			return errors.New("report options don't apply to gltf exports")
+		}
+		if e.BranchID != nil && e.CommitID != nil {
+			return errors.New("branch_id and commit_id are mutually exclusive")
+		}
+		return nil
+	}
+	if e.Format == ExportFormatBundle {
+		if e.Template != "" || len(e.Sections) > 0 || len(e.EvidenceTiers) > 0 ||
+			e.BranchID != nil || e.CommitID != nil || e.Redaction != nil {
+			return errors.New("report options don't apply to bundle exports")
+		}
+		return nil
+	}
+	if e.Template != "" {
+		if e.Format != ExportFormatHTML {
+			return errors.New("templates only apply to html exports")
+		}
+		if !IsValidReportTemplateName(e.Template) {
+			return errors.New("invalid template name")
+		}
+	}
+	seen := make(map[ReportSection]bool)
+	for _, s := range e.Sections {
+		if !s.IsValid() {
+			return errors.New("invalid section: " + string(s))
+		}
+		if seen[s] {
+			return errors.New("duplicate section: " + string(s))
+		}
+		seen[s] = true
+	}
+	for _, t := range e.EvidenceTiers {
+		if !t.IsValid() {
+			return errors.New("evidence tier must be high, medium or low")
+		}
+	}
+	if e.BranchID != nil && e.CommitID != nil {
+		return errors.New("branch_id and commit_id are mutually exclusive")
+	}
+	if e.Redaction != nil {
+		if err := e.Redaction.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetDefaults sets default values for ExportInput
+func (e *ExportInput) SetDefaults() {
+	if e.Format == "" {
+		e.Format = ExportFormatHTML
+	}
+	if len(e.Sections) == 0 && e.Format != ExportFormatBundle && e.Format != ExportFormatGLTF {
+		e.Sections = DefaultReportSections()
+	}
+}
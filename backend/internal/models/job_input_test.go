@@ -2,6 +2,8 @@ package models
 
 import (
 	"testing"
+
+	"github.com/google/uuid"
 )
 
 func TestReconstructionInput_Validate(t *testing.T) {
@@ -345,3 +347,46 @@ func TestProfileInput_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestExportInput_Validate(t *testing.T) {
+	commitID := uuid.New()
+	tests := []struct {
+		name    string
+		input   ExportInput
+		wantErr bool
+	}{
+		{name: "html", input: ExportInput{Format: ExportFormatHTML}},
+		{name: "pdf", input: ExportInput{Format: ExportFormatPDF}},
+		{name: "bundle", input: ExportInput{Format: ExportFormatBundle}},
+		{name: "unknown format", input: ExportInput{Format: "docx"}, wantErr: true},
+		{name: "sections and tiers", input: ExportInput{Format: ExportFormatPDF,
+			Sections: []ReportSection{ReportSectionTimeline, ReportSectionCustodyLog}, EvidenceTiers: []EvidenceTier{EvidenceTierHigh}}},
+		{name: "html template", input: ExportInput{Format: ExportFormatHTML, Template: "court-summary"}},
+		{name: "template path", input: ExportInput{Format: ExportFormatHTML, Template: "../secrets"}, wantErr: true},
+		{name: "duplicate section", input: ExportInput{Format: ExportFormatPDF,
+			Sections: []ReportSection{ReportSectionTimeline, ReportSectionTimeline}}, wantErr: true},
+		{name: "unknown tier", input: ExportInput{Format: ExportFormatPDF, EvidenceTiers: []EvidenceTier{"certain"}}, wantErr: true},
+		{name: "empty redacted name", input: ExportInput{Format: ExportFormatPDF, Redaction: &RedactionRules{Names: []string{" "}}}, wantErr: true},
+		{name: "bundle with sections", input: ExportInput{Format: ExportFormatBundle, Sections: []ReportSection{ReportSectionTimeline}}, wantErr: true},
+		{name: "gltf at commit", input: ExportInput{Format: ExportFormatGLTF, CommitID: &commitID}},
+		{name: "gltf with redaction", input: ExportInput{Format: ExportFormatGLTF, Redaction: &RedactionRules{WitnessNames: true}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	var input ExportInput
+	input.SetDefaults()
+	if input.Format != ExportFormatHTML {
+		t.Errorf("SetDefaults() format = %q, want html", input.Format)
+	}
+	if len(input.Sections) != len(ReportSections)-2 {
+		t.Errorf("SetDefaults() sections = %v, want all but the custody and audit logs", input.Sections)
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CaseMember gives a user a role on a case
+type CaseMember struct {
+	CaseID    uuid.UUID  `json:"case_id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Role      CaseRole   `json:"role"`
+	AddedBy   *uuid.UUID `json:"added_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Validate checks if the CaseMember is valid
+func (m *CaseMember) Validate() error {
+	if m.CaseID == uuid.Nil {
+		return errors.New("case_id is required")
+	}
+	if m.UserID == uuid.Nil {
+		return errors.New("user_id is required")
+	}
+	if !m.Role.IsValid() {
+		return errors.New("role must be lead, investigator or viewer")
+	}
+	return nil
+}
+
+// NewCaseMember creates a membership with timestamps
+func NewCaseMember(caseID, userID uuid.UUID, role CaseRole, addedBy *uuid.UUID) *CaseMember {
+	now := time.Now().UTC()
+	return &CaseMember{
+		CaseID:    caseID,
+		UserID:    userID,
+		Role:      role,
+		AddedBy:   addedBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCaseMember_Validate(t *testing.T) {
+	caseID, userID := uuid.New(), uuid.New()
+	tests := []struct {
+		name    string
+		member  *CaseMember
+		wantErr bool
+	}{
+		{name: "valid", member: NewCaseMember(caseID, userID, CaseRoleInvestigator, nil)},
+		{name: "missing case", member: NewCaseMember(uuid.Nil, userID, CaseRoleViewer, nil), wantErr: true},
+		{name: "missing user", member: NewCaseMember(caseID, uuid.Nil, CaseRoleViewer, nil), wantErr: true},
+		{name: "unknown role", member: NewCaseMember(caseID, userID, "owner", nil), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.member.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
+var reportTemplateName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// DefaultReportTemplate is the name of the built-in HTML report template
+const DefaultReportTemplate = "default"
+
+// ReportTemplate is a named HTML template for case reports
+type ReportTemplate struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Body        string    `json:"body"` // Go html/template source
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Validate checks if the ReportTemplate is valid
+func (t *ReportTemplate) Validate() error {
+	if !IsValidReportTemplateName(t.Name) {
+		return errors.New("name must be lowercase letters, digits, '-' or '_'")
+	}
+	if t.Body == "" {
+		return errors.New("body is required")
+	}
+	return nil
+}
+
+// IsValidReportTemplateName reports whether name can identify a template. Names
+// are also file names, so they can't contain path separators.
+func IsValidReportTemplateName(name string) bool {
+	return reportTemplateName.MatchString(name)
+}
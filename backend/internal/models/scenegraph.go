@@ -35,6 +35,11 @@ func (sg *SceneGraph) Validate() error {
 			return errors.New("evidence " + string(rune(i)) + ": " + err.Error())
 		}
 	}
+	for _, c := range sg.Constraints {
+		if err := c.Validate(); err != nil {
+			return errors.New("constraint " + c.ID + ": " + err.Error())
+		}
+	}
 	return nil
 }
 
@@ -183,6 +188,9 @@ func (c *Constraint) Validate() error {
 	if c.Confidence < 0 || c.Confidence > 1 {
 		return errors.New("confidence must be between 0 and 1")
 	}
+	if _, err := c.TypedParams(); err != nil {
+		return errors.New("params: " + err.Error())
+	}
 	return nil
 }
 
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Search limits
+const (
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 200
+	MaxSearchLength    = 200
+)
+
+// Search snippets mark matched words with these
+const (
+	SearchHighlightStart = "«"
+	SearchHighlightStop  = "»"
+)
+
+// SearchQuery is a full-text search across cases. Text uses web search
+// syntax: quoted phrases, "or" and a leading "-" to exclude a word.
+type SearchQuery struct {
+	Text        string
+	CommitTypes []CommitType // only matches in commits of these types
+	From        *time.Time   // matches at or after
+	To          *time.Time   // matches before
+	Limit       int
+	MemberID    *uuid.UUID // only cases this user is a member of
+}
+
+// Validate checks if the SearchQuery is valid
+func (q *SearchQuery) Validate() error {
+	if strings.TrimSpace(q.Text) == "" {
+		return errors.New("q is required")
+	}
+	if len(q.Text) > MaxSearchLength {
+		return errors.New("q must be 200 characters or less")
+	}
+	for _, t := range q.CommitTypes {
+		if !t.IsValid() {
+			return errors.New("invalid commit type: " + string(t))
+		}
+	}
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		return errors.New("from must be before to")
+	}
+	if q.Limit < 0 || q.Limit > MaxSearchLimit {
+		return errors.New("limit must be between 1 and 200")
+	}
+	return nil
+}
+
+// SetDefaults sets default values for SearchQuery
+func (q *SearchQuery) SetDefaults() {
+	if q.Limit == 0 {
+		q.Limit = DefaultSearchLimit
+	}
+}
+
+// SearchHit is one match of a case search. Commit and statement matches
+// are dated by their commit, evidence and object matches by the scene
+// snapshot, and case matches by the case's creation.
+type SearchHit struct {
+	CaseID     uuid.UUID    `json:"-"`
+	CaseTitle  string       `json:"-"`
+	Source     SearchSource `json:"source"`
+	CommitID   *uuid.UUID   `json:"commit_id,omitempty"`
+	CommitType CommitType   `json:"commit_type,omitempty"`
+	RefID      string       `json:"ref_id,omitempty"` // evidence or object ID, or the statement's index in its commit
+	Snippet    string       `json:"snippet"`
+	Rank       float64      `json:"rank"`
+	At         time.Time    `json:"at"`
+}
+
+// CaseSearchResult is the hits of a search in one case
+type CaseSearchResult struct {
+	CaseID uuid.UUID   `json:"case_id"`
+	Title  string      `json:"title"`
+	Rank   float64     `json:"rank"` // of the case's best hit
+	Hits   []SearchHit `json:"hits"`
+}
+
+// GroupSearchHits groups hits by case. Cases keep the order of their first
+// hit, so hits sorted best first give cases sorted by their best hit.
+func GroupSearchHits(hits []*SearchHit) []CaseSearchResult {
+	results := []CaseSearchResult{}
+	index := make(map[uuid.UUID]int)
+	for _, h := range hits {
+		i, ok := index[h.CaseID]
+		if !ok {
+			i = len(results)
+			index[h.CaseID] = i
+			results = append(results, CaseSearchResult{CaseID: h.CaseID, Title: h.CaseTitle, Rank: h.Rank})
+		}
+		results[i].Hits = append(results[i].Hits, *h)
+	}
+	return results
+}
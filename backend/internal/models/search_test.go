@@ -0,0 +1,61 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSearchQuery_Validate(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+	tests := []struct {
+		name    string
+		query   SearchQuery
+		wantErr bool
+	}{
+		{name: "text", query: SearchQuery{Text: "red jacket"}},
+		{name: "filters", query: SearchQuery{Text: "jacket", CommitTypes: []CommitType{CommitTypeWitnessStatement}, From: &earlier, To: &now, Limit: 10}},
+		{name: "blank", query: SearchQuery{Text: "  "}, wantErr: true},
+		{name: "too long", query: SearchQuery{Text: strings.Repeat("a", 201)}, wantErr: true},
+		{name: "unknown commit type", query: SearchQuery{Text: "jacket", CommitTypes: []CommitType{"note"}}, wantErr: true},
+		{name: "empty range", query: SearchQuery{Text: "jacket", From: &now, To: &earlier}, wantErr: true},
+		{name: "limit too large", query: SearchQuery{Text: "jacket", Limit: 500}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	q := SearchQuery{Text: "jacket"}
+	q.SetDefaults()
+	if q.Limit != DefaultSearchLimit {
+		t.Errorf("SetDefaults() limit = %d, want %d", q.Limit, DefaultSearchLimit)
+	}
+}
+
+func TestGroupSearchHits(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	hits := []*SearchHit{
+		{CaseID: a, CaseTitle: "Warehouse", Source: SearchSourceStatement, Rank: 0.9},
+		{CaseID: b, CaseTitle: "Office", Source: SearchSourceCase, Rank: 0.5},
+		{CaseID: a, CaseTitle: "Warehouse", Source: SearchSourceEvidence, Rank: 0.3},
+	}
+	got := GroupSearchHits(hits)
+	if len(got) != 2 || got[0].CaseID != a || got[1].CaseID != b {
+		t.Fatalf("GroupSearchHits() = %+v", got)
+	}
+	if got[0].Title != "Warehouse" || got[0].Rank != 0.9 || len(got[0].Hits) != 2 || got[0].Hits[1].Source != SearchSourceEvidence {
+		t.Errorf("first case = %+v", got[0])
+	}
+	if empty := GroupSearchHits(nil); empty == nil || len(empty) != 0 {
+		t.Errorf("GroupSearchHits(nil) = %#v, want an empty list", empty)
+	}
+}
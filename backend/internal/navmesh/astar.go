@@ -0,0 +1,187 @@
+package navmesh
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+)
+
+var (
+	// ErrBlockedEndpoint is returned when an endpoint is inside an obstacle or
+	// off the floor and no free cell lies within the snap distance
+	ErrBlockedEndpoint = errors.New("endpoint is not on walkable floor")
+	// ErrNoPath is returned when the endpoints are in disconnected regions
+	ErrNoPath = errors.New("no walkable path")
+)
+
+// Snap returns p if it is walkable, otherwise the centre of the nearest free
+// cell within maxDistance
+func (g *Grid) Snap(p [2]float64, maxDistance float64) ([2]float64, error) {
+	c0, r0, _ := g.cellOf(p)
+	if !g.Blocked(p) {
+		return p, nil
+	}
+	reach := int(math.Ceil(maxDistance / g.CellSize))
+	best, bestDist := [2]float64{}, math.Inf(1)
+	for r := r0 - reach; r <= r0+reach; r++ {
+		for c := c0 - reach; c <= c0+reach; c++ {
+			if c < 0 || r < 0 || c >= g.Cols || r >= g.Rows || g.blocked[r*g.Cols+c] {
+				continue
+			}
+			q := g.center(c, r)
+			if d := math.Hypot(q[0]-p[0], q[1]-p[1]); d <= maxDistance && d < bestDist {
+				best, bestDist = q, d
+			}
+		}
+	}
+	if math.IsInf(bestDist, 1) {
+		return p, ErrBlockedEndpoint
+	}
+	return best, nil
+}
+
+// ShortestPath finds the shortest walkable route between two free points
+// with 8-connected A* and shortens it by cutting corners where the line of
+// sight is clear. It returns the route, including both endpoints, and its length.
+func (g *Grid) ShortestPath(from, to [2]float64) ([][2]float64, float64, error) {
+	if g.Blocked(from) || g.Blocked(to) {
+		return nil, 0, ErrBlockedEndpoint
+	}
+	if g.Clear(from, to) {
+		return [][2]float64{from, to}, dist(from, to), nil
+	}
+
+	sc, sr, _ := g.cellOf(from)
+	tc, tr, _ := g.cellOf(to)
+	start, goal := sr*g.Cols+sc, tr*g.Cols+tc
+
+	gScore := make([]float64, len(g.blocked))
+	for i := range gScore {
+		gScore[i] = math.Inf(1)
+	}
+	parent := make([]int32, len(g.blocked))
+	closed := make([]bool, len(g.blocked))
+	gScore[start] = 0
+	parent[start] = -1
+
+	h := func(i int) float64 {
+		dc := math.Abs(float64(i%g.Cols - tc))
+		dr := math.Abs(float64(i/g.Cols - tr))
+		return (math.Max(dc, dr) + (math.Sqrt2-1)*math.Min(dc, dr)) * g.CellSize // octile
+	}
+	open := &nodeHeap{{index: start, f: h(start)}}
+
+	for open.Len() > 0 {
+		cur := heap.Pop(open).(node).index
+		if cur == goal {
+			break
+		}
+		if closed[cur] {
+			continue
+		}
+		closed[cur] = true
+		c, r := cur%g.Cols, cur/g.Cols
+		for _, step := range neighbours {
+			nc, nr := c+step.dc, r+step.dr
+			if nc < 0 || nr < 0 || nc >= g.Cols || nr >= g.Rows {
+				continue
+			}
+			next := nr*g.Cols + nc
+			if g.blocked[next] || closed[next] {
+				continue
+			}
+			// No squeezing diagonally between two blocked cells
+			if step.dc != 0 && step.dr != 0 && (g.blocked[r*g.Cols+nc] || g.blocked[nr*g.Cols+c]) {
+				continue
+			}
+			tentative := gScore[cur] + step.cost*g.CellSize
+			if tentative < gScore[next] {
+				gScore[next] = tentative
+				parent[next] = int32(cur)
+				heap.Push(open, node{index: next, f: tentative + h(next)})
+			}
+		}
+	}
+	if math.IsInf(gScore[goal], 1) {
+		return nil, 0, ErrNoPath
+	}
+
+	var cells [][2]float64
+	for i := goal; i != start; i = int(parent[i]) {
+		cells = append(cells, g.center(i%g.Cols, i/g.Cols))
+	}
+	route := make([][2]float64, 0, len(cells)+2)
+	route = append(route, from)
+	for i := len(cells) - 1; i >= 0; i-- {
+		route = append(route, cells[i])
+	}
+	route = append(route, to)
+
+	route = g.smooth(route)
+	return route, routeLength(route), nil
+}
+
+// Clear reports whether the straight line from a to b stays on walkable cells
+func (g *Grid) Clear(a, b [2]float64) bool {
+	d := dist(a, b)
+	steps := int(math.Ceil(d/(g.CellSize/4))) + 1
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		if g.Blocked([2]float64{a[0] + t*(b[0]-a[0]), a[1] + t*(b[1]-a[1])}) {
+			return false
+		}
+	}
+	return true
+}
+
+// smooth drops route points that can be skipped with a clear line of sight
+func (g *Grid) smooth(route [][2]float64) [][2]float64 {
+	out := [][2]float64{route[0]}
+	anchor := route[0]
+	for i := 1; i < len(route)-1; i++ {
+		if !g.Clear(anchor, route[i+1]) {
+			anchor = route[i]
+			out = append(out, anchor)
+		}
+	}
+	return append(out, route[len(route)-1])
+}
+
+func routeLength(route [][2]float64) float64 {
+	var l float64
+	for i := 1; i < len(route); i++ {
+		l += dist(route[i-1], route[i])
+	}
+	return l
+}
+
+func dist(a, b [2]float64) float64 {
+	return math.Hypot(b[0]-a[0], b[1]-a[1])
+}
+
+var neighbours = []struct {
+	dc, dr int
+	cost   float64
+}{
+	{1, 0, 1}, {-1, 0, 1}, {0, 1, 1}, {0, -1, 1},
+	{1, 1, math.Sqrt2}, {1, -1, math.Sqrt2}, {-1, 1, math.Sqrt2}, {-1, -1, math.Sqrt2},
+}
+
+type node struct {
+	index int
+	f     float64
+}
+
+// nodeHeap is a min-heap of open nodes by f = g + h
+type nodeHeap []node
+
+func (h nodeHeap) Len() int            { return len(h) }
+func (h nodeHeap) Less(i, j int) bool  { return h[i].f < h[j].f }
+func (h nodeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nodeHeap) Push(x interface{}) { *h = append(*h, x.(node)) }
+func (h *nodeHeap) Pop() interface{} {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
@@ -0,0 +1,361 @@
+// Package navmesh rasterizes a SceneGraph's proxy geometry into a 2.5D
+// occupancy grid and finds walkable routes through it.
+package navmesh
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// maxCells caps the grid size; the cell size grows for very large scenes
+const maxCells = 2_000_000
+
+// coverMargin is the room left around points a grid is built to cover, so
+// routes near them can go around obstacles
+const coverMargin = 1.0
+
+// maxObstacleShare drops "obstacles" covering most of the scene, such as a
+// whole-scene proxy object
+const maxObstacleShare = 0.5
+
+// Options describes the walking agent and grid resolution
+type Options struct {
+	CellSize     float64 // grid resolution in meters
+	AgentRadius  float64 // obstacles are inflated by this much
+	AgentHeight  float64 // objects entirely above this don't block
+	StepHeight   float64 // objects entirely below this can be stepped over
+	SnapDistance float64 // endpoints inside obstacles move to the nearest free cell within this
+	WalkingSpeed float64 // m/s
+	RunningSpeed float64 // m/s
+}
+
+// DefaultOptions models an adult moving through a furnished room
+func DefaultOptions() Options {
+	return Options{
+		CellSize:     0.1,
+		AgentRadius:  0.2,
+		AgentHeight:  1.8,
+		StepHeight:   0.2,
+		SnapDistance: 0.5,
+		WalkingSpeed: 1.4,
+		RunningSpeed: 4.0,
+	}
+}
+
+// Grid is an occupancy grid over the floor plane (x, z). A cell is blocked
+// if an agent centred in it would overlap an obstacle or leave the floor.
+// Cells outside the scene are unknown and only blocked by geometry that
+// reaches them.
+type Grid struct {
+	Origin   [2]float64 // x, z of the corner of cell (0, 0)
+	CellSize float64
+	Cols     int // cells along x
+	Rows     int // cells along z
+	blocked  []bool
+	scene    rect // the scene's bounds and floor outlines
+	geometry bool // whether anything but doors was rasterized
+}
+
+// rect is an axis-aligned rectangle on the floor plane
+type rect struct {
+	min, max [2]float64
+}
+
+func (r rect) empty() bool {
+	return r.max[0] <= r.min[0] || r.max[1] <= r.min[1]
+}
+
+func (r rect) contains(p [2]float64) bool {
+	return p[0] >= r.min[0] && p[0] <= r.max[0] && p[1] >= r.min[1] && p[1] <= r.max[1]
+}
+
+// add grows r to take in p, padded by margin
+func (r rect) add(p [2]float64, margin float64) rect {
+	lo := [2]float64{p[0] - margin, p[1] - margin}
+	hi := [2]float64{p[0] + margin, p[1] + margin}
+	if r.empty() {
+		return rect{lo, hi}
+	}
+	return rect{
+		[2]float64{math.Min(r.min[0], lo[0]), math.Min(r.min[1], lo[1])},
+		[2]float64{math.Max(r.max[0], hi[0]), math.Max(r.max[1], hi[1])},
+	}
+}
+
+// Build rasterizes walls, furniture-like objects and passable_area
+// constraints (from the scene and extra) into a grid over the scene bounds
+// and floor outlines, grown to take in the cover points with a margin.
+// Door objects are always left open. Without a passable_area constraint the
+// whole bounds are treated as floor; beyond the scene, only walls and
+// objects block.
+func Build(sg *models.SceneGraph, extra []models.Constraint, opts Options, cover ...[2]float64) *Grid {
+	bounds := sg.Bounds
+
+	// Floor outline and listed obstacles from passable_area constraints
+	var floors, obstacles [][][2]float64
+	constraints := append(append([]models.Constraint(nil), sg.Constraints...), extra...)
+	for _, c := range constraints {
+		if c.Type != models.ConstraintTypePassableArea {
+			continue
+		}
+		if poly, ok := polygonParam(c.Params["polygon"]); ok {
+			floors = append(floors, poly)
+		}
+		var listed []struct {
+			Polygon [][2]float64 `json:"polygon"`
+		}
+		if decodeParam(c.Params["obstacles"], &listed) {
+			for _, o := range listed {
+				if len(o.Polygon) >= 3 {
+					obstacles = append(obstacles, o.Polygon)
+				}
+			}
+		}
+	}
+
+	scene := rect{[2]float64{bounds.Min[0], bounds.Min[2]}, [2]float64{bounds.Max[0], bounds.Max[2]}}
+	if scene.empty() {
+		scene = rect{}
+	}
+	for _, poly := range floors {
+		for _, p := range poly {
+			scene = scene.add(p, 0)
+		}
+	}
+	extent := scene
+	for _, p := range cover {
+		extent = extent.add(p, coverMargin)
+	}
+	if extent.empty() {
+		extent = rect{[2]float64{bounds.Min[0], bounds.Min[2]}, [2]float64{bounds.Max[0], bounds.Max[2]}}
+	}
+
+	cell := opts.CellSize
+	width := extent.max[0] - extent.min[0] + 2*cell
+	depth := extent.max[1] - extent.min[1] + 2*cell
+	if width*depth/(cell*cell) > maxCells {
+		cell = math.Sqrt(width * depth / maxCells)
+	}
+
+	g := &Grid{
+		Origin:   [2]float64{extent.min[0] - cell, extent.min[1] - cell},
+		CellSize: cell,
+		Cols:     int(math.Ceil(width / cell)),
+		Rows:     int(math.Ceil(depth / cell)),
+		scene:    scene,
+		geometry: len(floors) > 0 || len(obstacles) > 0,
+	}
+	g.Cols, g.Rows = max(g.Cols, 1), max(g.Rows, 1)
+	hard := make([]bool, g.Cols*g.Rows)
+	doors := make([]bool, g.Cols*g.Rows)
+
+	for _, poly := range obstacles {
+		g.fill(hard, func(p [2]float64) bool { return pointInPolygon(p, poly) })
+	}
+	if len(floors) > 0 {
+		g.fill(hard, func(p [2]float64) bool {
+			if !g.scene.contains(p) {
+				return false // unknown ground beyond the scene
+			}
+			for _, poly := range floors {
+				if pointInPolygon(p, poly) {
+					return false
+				}
+			}
+			return true
+		})
+	}
+
+	sceneArea := (bounds.Max[0] - bounds.Min[0]) * (bounds.Max[2] - bounds.Min[2])
+	for _, obj := range sg.Objects {
+		if obj.State == models.ObjectStateRemoved || isZeroBox(obj.BBox) {
+			continue
+		}
+		switch obj.Type {
+		case models.ObjectTypeDoor:
+			g.fill(doors, boxContains(obj.BBox, 0))
+		case models.ObjectTypeWall:
+			g.geometry = true
+			if seg, thickness, ok := wallSegment(obj); ok {
+				half := math.Max(thickness/2, g.CellSize/2)
+				g.fill(hard, func(p [2]float64) bool { return segmentDistance(p, seg[0], seg[1]) <= half })
+			} else {
+				g.fill(hard, boxContains(obj.BBox, 0))
+			}
+		case models.ObjectTypeFurniture, models.ObjectTypeVehicle, models.ObjectTypeOther:
+			if obj.BBox.Max[1] < opts.StepHeight || obj.BBox.Min[1] > opts.AgentHeight {
+				continue
+			}
+			size := (obj.BBox.Max[0] - obj.BBox.Min[0]) * (obj.BBox.Max[2] - obj.BBox.Min[2])
+			if sceneArea > 0 && size > maxObstacleShare*sceneArea {
+				continue
+			}
+			g.geometry = true
+			g.fill(hard, boxContains(obj.BBox, 0))
+		}
+	}
+
+	for i := range hard {
+		if doors[i] {
+			hard[i] = false
+		}
+	}
+	g.blocked = g.dilate(hard, opts.AgentRadius)
+	return g
+}
+
+// Blocked reports whether the cell containing p is not walkable. Points
+// outside the grid are blocked, as routes can't be found through them; build
+// the grid to cover points that may lie beyond the scene.
+func (g *Grid) Blocked(p [2]float64) bool {
+	c, r, ok := g.cellOf(p)
+	return !ok || g.blocked[r*g.Cols+c]
+}
+
+// Covers reports whether p lies within the grid
+func (g *Grid) Covers(p [2]float64) bool {
+	_, _, ok := g.cellOf(p)
+	return ok
+}
+
+// HasGeometry reports whether the grid was built from any walls, obstacles
+// or passable_area constraints, rather than being open floor throughout
+func (g *Grid) HasGeometry() bool {
+	return g.geometry
+}
+
+// FreeFraction returns the share of walkable cells
+func (g *Grid) FreeFraction() float64 {
+	free := 0
+	for _, b := range g.blocked {
+		if !b {
+			free++
+		}
+	}
+	return float64(free) / float64(len(g.blocked))
+}
+
+func (g *Grid) cellOf(p [2]float64) (int, int, bool) {
+	c := int(math.Floor((p[0] - g.Origin[0]) / g.CellSize))
+	r := int(math.Floor((p[1] - g.Origin[1]) / g.CellSize))
+	if c < 0 || r < 0 || c >= g.Cols || r >= g.Rows {
+		return c, r, false
+	}
+	return c, r, true
+}
+
+func (g *Grid) center(c, r int) [2]float64 {
+	return [2]float64{g.Origin[0] + (float64(c)+0.5)*g.CellSize, g.Origin[1] + (float64(r)+0.5)*g.CellSize}
+}
+
+// fill sets every cell whose centre satisfies inside
+func (g *Grid) fill(cells []bool, inside func([2]float64) bool) {
+	for r := 0; r < g.Rows; r++ {
+		for c := 0; c < g.Cols; c++ {
+			if !cells[r*g.Cols+c] && inside(g.center(c, r)) {
+				cells[r*g.Cols+c] = true
+			}
+		}
+	}
+}
+
+// dilate grows the set cells by radius with a disc-shaped kernel
+func (g *Grid) dilate(cells []bool, radius float64) []bool {
+	out := append([]bool(nil), cells...)
+	reach := int(math.Ceil(radius/g.CellSize - 1e-9))
+	if reach <= 0 {
+		return out
+	}
+	var offsets [][2]int
+	for dr := -reach; dr <= reach; dr++ {
+		for dc := -reach; dc <= reach; dc++ {
+			if math.Hypot(float64(dc), float64(dr))*g.CellSize <= radius+1e-9 {
+				offsets = append(offsets, [2]int{dc, dr})
+			}
+		}
+	}
+	for r := 0; r < g.Rows; r++ {
+		for c := 0; c < g.Cols; c++ {
+			if !cells[r*g.Cols+c] {
+				continue
+			}
+			for _, o := range offsets {
+				cc, rr := c+o[0], r+o[1]
+				if cc >= 0 && rr >= 0 && cc < g.Cols && rr < g.Rows {
+					out[rr*g.Cols+cc] = true
+				}
+			}
+		}
+	}
+	return out
+}
+
+// wallSegment reads the floor-plan segment and thickness that layout
+// extraction stores on wall proxies
+func wallSegment(obj models.SceneObject) ([2][2]float64, float64, bool) {
+	var seg [2][2]float64
+	if obj.Metadata == nil || !decodeParam(obj.Metadata["segment"], &seg) {
+		return seg, 0, false
+	}
+	var thickness float64
+	decodeParam(obj.Metadata["thickness"], &thickness)
+	return seg, thickness, true
+}
+
+// polygonParam reads an [[x, z], ...] polygon from constraint params, whether
+// it holds Go values or decoded JSON
+func polygonParam(v interface{}) ([][2]float64, bool) {
+	var poly [][2]float64
+	if !decodeParam(v, &poly) || len(poly) < 3 {
+		return nil, false
+	}
+	return poly, true
+}
+
+// decodeParam converts a loosely typed param into dst via its JSON form
+func decodeParam(v interface{}, dst interface{}) bool {
+	if v == nil {
+		return false
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, dst) == nil
+}
+
+func boxContains(bb models.BoundingBox, margin float64) func([2]float64) bool {
+	return func(p [2]float64) bool {
+		return p[0] >= bb.Min[0]-margin && p[0] <= bb.Max[0]+margin &&
+			p[1] >= bb.Min[2]-margin && p[1] <= bb.Max[2]+margin
+	}
+}
+
+func isZeroBox(bb models.BoundingBox) bool {
+	return bb.Min == [3]float64{} && bb.Max == [3]float64{}
+}
+
+func pointInPolygon(p [2]float64, poly [][2]float64) bool {
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a[1] > p[1]) != (b[1] > p[1]) &&
+			p[0] < (b[0]-a[0])*(p[1]-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// segmentDistance returns the distance from p to the segment ab
+func segmentDistance(p, a, b [2]float64) float64 {
+	dx, dz := b[0]-a[0], b[1]-a[1]
+	l2 := dx*dx + dz*dz
+	t := 0.0
+	if l2 > 0 {
+		t = math.Max(0, math.Min(1, ((p[0]-a[0])*dx+(p[1]-a[1])*dz)/l2))
+	}
+	return math.Hypot(p[0]-(a[0]+t*dx), p[1]-(a[1]+t*dz))
+}
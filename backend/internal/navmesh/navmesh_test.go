@@ -0,0 +1,244 @@
+package navmesh
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// twoRooms is a 6 m × 4 m floor split by a wall at x=3 with a 0.9 m door
+// from z=2.5 to z=3.4, and a 1 m table in the left room
+func twoRooms(t *testing.T, withDoor bool) *models.SceneGraph {
+	t.Helper()
+	wall := func(id string, from, to [2]float64) models.SceneObject {
+		return models.SceneObject{
+			ID:   id,
+			Type: models.ObjectTypeWall,
+			BBox: models.BoundingBox{
+				Min: [3]float64{min(from[0], to[0]) - 0.05, 0, min(from[1], to[1]) - 0.05},
+				Max: [3]float64{max(from[0], to[0]) + 0.05, 2.6, max(from[1], to[1]) + 0.05},
+			},
+			Metadata: map[string]interface{}{"segment": [2][2]float64{from, to}, "thickness": 0.1},
+		}
+	}
+
+	sg := models.NewEmptySceneGraph()
+	sg.Bounds = models.BoundingBox{Min: [3]float64{0, 0, 0}, Max: [3]float64{6, 2.6, 4}}
+	sg.Objects = []models.SceneObject{
+		wall("w-left", [2]float64{0, 0}, [2]float64{0, 4}),
+		wall("w-right", [2]float64{6, 0}, [2]float64{6, 4}),
+		wall("w-front", [2]float64{0, 0}, [2]float64{6, 0}),
+		wall("w-back", [2]float64{0, 4}, [2]float64{6, 4}),
+		wall("w-middle", [2]float64{3, 0}, [2]float64{3, 4}),
+		{ID: "table", Type: models.ObjectTypeFurniture, BBox: models.BoundingBox{Min: [3]float64{1, 0, 2}, Max: [3]float64{2, 0.75, 3}}},
+		{ID: "rug", Type: models.ObjectTypeFurniture, BBox: models.BoundingBox{Min: [3]float64{4, 0, 1}, Max: [3]float64{5, 0.02, 2}}},
+		{ID: "scene", Type: models.ObjectTypeOther, BBox: models.BoundingBox{Min: [3]float64{0, 0, 0}, Max: [3]float64{6, 2.6, 4}}},
+	}
+	if withDoor {
+		sg.Objects = append(sg.Objects, models.SceneObject{
+			ID:   "door",
+			Type: models.ObjectTypeDoor,
+			BBox: models.BoundingBox{Min: [3]float64{2.95, 0, 2.5}, Max: [3]float64{3.05, 2, 3.4}},
+		})
+	}
+	sg.Constraints = []models.Constraint{{
+		ID:   "floor",
+		Type: models.ConstraintTypePassableArea,
+		Params: map[string]interface{}{
+			"polygon": [][2]float64{{0, 0}, {6, 0}, {6, 4}, {0, 4}},
+		},
+		Confidence: 0.9,
+	}}
+
+	// Round-trip through JSON so params arrive as they do from the database
+	data, err := json.Marshal(sg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded models.SceneGraph
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return &decoded
+}
+
+func segment(from, to [3]float64) models.TrajectorySegment {
+	return models.TrajectorySegment{ID: "s", FromPosition: from, ToPosition: to, Confidence: 0.8}
+}
+
+func TestBuild_Occupancy(t *testing.T) {
+	g := Build(twoRooms(t, true), nil, DefaultOptions())
+
+	cases := []struct {
+		name    string
+		p       [2]float64
+		blocked bool
+	}{
+		{"open floor", [2]float64{1, 1}, false},
+		{"table", [2]float64{1.5, 2.5}, true},
+		{"next to the table, inside the agent radius", [2]float64{2.1, 2.5}, true},
+		{"rug can be stepped over", [2]float64{4.5, 1.5}, false},
+		{"middle wall", [2]float64{3, 1}, true},
+		{"doorway", [2]float64{3, 2.95}, false},
+		{"outside the floor", [2]float64{-0.5, 1}, true},
+	}
+	for _, tc := range cases {
+		if got := g.Blocked(tc.p); got != tc.blocked {
+			t.Errorf("%s: Blocked(%v) = %v, want %v", tc.name, tc.p, got, tc.blocked)
+		}
+	}
+}
+
+func TestCheckSegment(t *testing.T) {
+	v := NewValidator(twoRooms(t, true), nil, DefaultOptions())
+
+	straight := v.CheckSegment(segment([3]float64{0.5, 0, 0.5}, [3]float64{2.5, 0, 0.5}))
+	if straight.Status != models.FeasibilityStatusFeasible || straight.PathLength != 2 {
+		t.Errorf("straight segment = %+v, want feasible with a 2 m path", straight)
+	}
+	if straight.WalkingSeconds != 1.43 || straight.RunningSeconds != 0.5 {
+		t.Errorf("travel times = %v / %v", straight.WalkingSeconds, straight.RunningSeconds)
+	}
+
+	through := v.CheckSegment(segment([3]float64{1, 0, 1}, [3]float64{5, 0, 1}))
+	if through.Status != models.FeasibilityStatusDetourRequired {
+		t.Fatalf("through-wall segment = %+v, want detour_required", through)
+	}
+	if through.PathLength < 4.5 || through.PathLength > 6 || len(through.Path) < 3 {
+		t.Errorf("detour = %.2f m via %v, want a route through the door", through.PathLength, through.Path)
+	}
+	viaDoor := false
+	for _, p := range through.Path {
+		if p[0] > 2.5 && p[0] < 3.5 && p[2] > 2.5 && p[2] < 3.4 {
+			viaDoor = true
+		}
+	}
+	if !viaDoor {
+		t.Errorf("detour path %v does not pass the door", through.Path)
+	}
+
+	// Stated waypoints that go around through the door keep the segment feasible
+	routed := segment([3]float64{1, 0, 1}, [3]float64{5, 0, 1})
+	routed.Waypoints = [][3]float64{{2.6, 0, 1.5}, {2.6, 0, 2.95}, {3.4, 0, 2.95}}
+	if got := v.CheckSegment(routed); got.Status != models.FeasibilityStatusFeasible {
+		t.Errorf("routed segment = %+v, want feasible", got)
+	}
+
+	inTable := v.CheckSegment(segment([3]float64{0.5, 0, 0.5}, [3]float64{1.5, 0, 2.5}))
+	if inTable.Status != models.FeasibilityStatusInfeasible || inTable.Reason == "" {
+		t.Errorf("segment ending inside the table = %+v, want infeasible", inTable)
+	}
+
+	// Near a wall the endpoint snaps onto free floor
+	nearWall := v.CheckSegment(segment([3]float64{0.05, 0, 1}, [3]float64{2, 0, 1}))
+	if nearWall.Status != models.FeasibilityStatusFeasible {
+		t.Errorf("segment starting against the wall = %+v, want feasible", nearWall)
+	}
+
+	rushed := segment([3]float64{1, 0, 1}, [3]float64{5, 0, 1})
+	rushed.TimeEstimate = &models.TimeEstimate{Start: "2024-01-15T22:00:00Z", End: "2024-01-15T22:00:01Z"}
+	if got := v.CheckSegment(rushed); got.Status != models.FeasibilityStatusInfeasible {
+		t.Errorf("one-second segment = %+v, want infeasible", got)
+	}
+}
+
+func TestCheckSegment_NoDoor(t *testing.T) {
+	v := NewValidator(twoRooms(t, false), nil, DefaultOptions())
+	got := v.CheckSegment(segment([3]float64{1, 0, 1}, [3]float64{5, 0, 1}))
+	if got.Status != models.FeasibilityStatusInfeasible || got.PathLength != 0 {
+		t.Errorf("segment between sealed rooms = %+v, want infeasible", got)
+	}
+
+	_, _, err := v.Grid().ShortestPath([2]float64{1, 1}, [2]float64{5, 1})
+	if !errors.Is(err, ErrNoPath) {
+		t.Errorf("ShortestPath() error = %v, want ErrNoPath", err)
+	}
+}
+
+func TestApply_ScalesConfidenceAndReranks(t *testing.T) {
+	v := NewValidator(twoRooms(t, true), nil, DefaultOptions())
+	trajectories := []models.Trajectory{
+		{ID: "through-wall", Rank: 1, OverallConfidence: 0.9, Segments: []models.TrajectorySegment{
+			segment([3]float64{1, 0, 1}, [3]float64{5, 0, 1}),
+			segment([3]float64{5, 0, 1}, [3]float64{1.5, 0, 2.5}),
+		}},
+		{ID: "plausible", Rank: 2, OverallConfidence: 0.7, Segments: []models.TrajectorySegment{
+			segment([3]float64{0.5, 0, 0.5}, [3]float64{2.5, 0, 0.5}),
+		}},
+	}
+
+	summary := v.Apply(trajectories)
+
+	if summary != (Summary{Feasible: 1, DetourRequired: 1, Infeasible: 1}) {
+		t.Errorf("Apply() summary = %+v", summary)
+	}
+	if trajectories[0].ID != "plausible" || trajectories[0].Rank != 1 || trajectories[1].Rank != 2 {
+		t.Errorf("Apply() order = %s, %s", trajectories[0].ID, trajectories[1].ID)
+	}
+	if got := trajectories[0].OverallConfidence; got != 0.7 {
+		t.Errorf("feasible trajectory confidence = %v, want unchanged", got)
+	}
+	weak := trajectories[1]
+	if weak.OverallConfidence != 0.23 || weak.Segments[0].Confidence != 0.68 || weak.Segments[1].Confidence != 0.2 {
+		t.Errorf("scaled confidences = %v / %v / %v", weak.OverallConfidence, weak.Segments[0].Confidence, weak.Segments[1].Confidence)
+	}
+	if weak.Segments[1].Feasibility == nil || weak.Segments[1].Feasibility.PriorConfidence != 0.8 {
+		t.Errorf("feasibility = %+v, want the prior confidence recorded", weak.Segments[1].Feasibility)
+	}
+}
+
+func TestBuild_WithoutPassableArea(t *testing.T) {
+	sg := models.NewEmptySceneGraph()
+	g := Build(sg, nil, DefaultOptions())
+	if g.FreeFraction() < 0.99 {
+		t.Errorf("empty scene free fraction = %v, want the whole bounds walkable", g.FreeFraction())
+	}
+}
+
+func TestCheckSegment_BeyondScene(t *testing.T) {
+	v := NewValidator(twoRooms(t, true), nil, DefaultOptions())
+
+	outdoors := v.CheckSegment(segment([3]float64{-3, 0, 1}, [3]float64{-1, 0, 3}))
+	if outdoors.Status != models.FeasibilityStatusFeasible || outdoors.PathLength != 2.83 {
+		t.Errorf("segment outside the scene = %+v, want feasible", outdoors)
+	}
+
+	// The outer walls are still known, so a sealed room can't be entered from outside
+	intoRoom := v.CheckSegment(segment([3]float64{-3, 0, 1}, [3]float64{1, 0, 1}))
+	if intoRoom.Status != models.FeasibilityStatusInfeasible || intoRoom.Reason != "no walkable route between the endpoints" {
+		t.Errorf("segment into a sealed room = %+v, want infeasible for want of a route", intoRoom)
+	}
+}
+
+func TestApply_WithoutGeometry(t *testing.T) {
+	placeholder := models.NewEmptySceneGraph() // 10 m bounds, nothing in them
+	zero := models.NewEmptySceneGraph()
+	zero.Bounds = models.BoundingBox{}
+
+	for name, sg := range map[string]*models.SceneGraph{"placeholder bounds": placeholder, "zero bounds": zero} {
+		t.Run(name, func(t *testing.T) {
+			rushed := segment([3]float64{8, 0, 5}, [3]float64{25, 0, 5})
+			rushed.TimeEstimate = &models.TimeEstimate{Start: "22:00:00", End: "22:00:01"}
+			trajectories := []models.Trajectory{{ID: "t", Rank: 1, OverallConfidence: 0.9, Segments: []models.TrajectorySegment{
+				segment([3]float64{5, 0, 5}, [3]float64{14, 0, -2}),
+				rushed,
+			}}}
+
+			v := NewValidator(sg, nil, DefaultOptions())
+			summary := v.Apply(trajectories)
+			if summary != (Summary{Feasible: 1, Infeasible: 1}) {
+				t.Errorf("Apply() summary = %+v, want the door approach feasible", summary)
+			}
+			got := trajectories[0]
+			if got.OverallConfidence != 0.9 || got.Segments[0].Confidence != 0.8 || got.Segments[1].Confidence != 0.8 {
+				t.Errorf("confidences = %v / %v / %v, want unchanged without geometry",
+					got.OverallConfidence, got.Segments[0].Confidence, got.Segments[1].Confidence)
+			}
+			if v.Grid().HasGeometry() {
+				t.Error("an empty scene should have no geometry")
+			}
+		})
+	}
+}
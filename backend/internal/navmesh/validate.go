@@ -0,0 +1,216 @@
+package navmesh
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// Segment confidence is scaled by these factors after the feasibility check
+const (
+	detourConfidenceFactor     = 0.85
+	infeasibleConfidenceFactor = 0.25
+)
+
+// timeLayouts are the time formats accepted in segment time estimates
+var timeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "15:04:05", "15:04"}
+
+// Validator checks trajectory segments against one scene's walkable space
+type Validator struct {
+	sg      *models.SceneGraph
+	extra   []models.Constraint
+	grid    *Grid
+	opts    Options
+	covered [][2]float64 // points beyond the scene the grid was grown to take in
+}
+
+// Summary counts segments by feasibility status
+type Summary struct {
+	Feasible       int `json:"feasible"`
+	DetourRequired int `json:"detour_required"`
+	Infeasible     int `json:"infeasible"`
+}
+
+// NewValidator builds the occupancy grid for a scene. extra constraints, such
+// as a reasoning job's overrides, are applied on top of the scene's own.
+func NewValidator(sg *models.SceneGraph, extra []models.Constraint, opts Options) *Validator {
+	return &Validator{sg: sg, extra: extra, grid: Build(sg, extra, opts), opts: opts}
+}
+
+// cover rebuilds the grid to take in any of points that lie outside it, such
+// as an approach from outdoors or a scene with placeholder bounds
+func (v *Validator) cover(points ...[2]float64) {
+	grow := false
+	for _, p := range points {
+		if !v.grid.Covers(p) {
+			grow = true
+			break
+		}
+	}
+	if !grow {
+		return
+	}
+	v.covered = append(v.covered, points...)
+	v.grid = Build(v.sg, v.extra, v.opts, v.covered...)
+}
+
+// segmentPoints returns a segment's endpoints and waypoints on the floor plane
+func segmentPoints(seg models.TrajectorySegment) [][2]float64 {
+	points := [][2]float64{{seg.FromPosition[0], seg.FromPosition[2]}, {seg.ToPosition[0], seg.ToPosition[2]}}
+	for _, w := range seg.Waypoints {
+		points = append(points, [2]float64{w[0], w[2]})
+	}
+	return points
+}
+
+// Grid returns the validator's occupancy grid
+func (v *Validator) Grid() *Grid {
+	return v.grid
+}
+
+// CheckSegment reports whether a segment's stated route is walkable, the
+// shortest walkable route between its endpoints and how long it takes.
+// Heights are ignored: positions are projected onto the floor plane.
+func (v *Validator) CheckSegment(seg models.TrajectorySegment) models.SegmentFeasibility {
+	v.cover(segmentPoints(seg)...)
+	from := [2]float64{seg.FromPosition[0], seg.FromPosition[2]}
+	to := [2]float64{seg.ToPosition[0], seg.ToPosition[2]}
+	result := models.SegmentFeasibility{
+		StraightDistance: round2(dist(from, to)),
+		PriorConfidence:  seg.Confidence,
+	}
+
+	start, err := v.grid.Snap(from, v.opts.SnapDistance)
+	if err != nil {
+		result.Status = models.FeasibilityStatusInfeasible
+		result.Reason = "start position is not on walkable floor"
+		return result
+	}
+	end, err := v.grid.Snap(to, v.opts.SnapDistance)
+	if err != nil {
+		result.Status = models.FeasibilityStatusInfeasible
+		result.Reason = "end position is not on walkable floor"
+		return result
+	}
+
+	path, length, err := v.grid.ShortestPath(start, end)
+	if err != nil {
+		result.Status = models.FeasibilityStatusInfeasible
+		result.Reason = "no walkable route between the endpoints"
+		return result
+	}
+	result.PathLength = round2(length)
+	result.WalkingSeconds = round2(length / v.opts.WalkingSpeed)
+	result.RunningSeconds = round2(length / v.opts.RunningSpeed)
+
+	if window, ok := timeWindow(seg.TimeEstimate); ok && length/v.opts.RunningSpeed > window.Seconds() {
+		result.Status = models.FeasibilityStatusInfeasible
+		result.Reason = fmt.Sprintf("covering %.1f m takes at least %.0fs running but the time window is %.0fs",
+			length, length/v.opts.RunningSpeed, window.Seconds())
+		return result
+	}
+
+	route := [][2]float64{start}
+	for _, w := range seg.Waypoints {
+		route = append(route, [2]float64{w[0], w[2]})
+	}
+	route = append(route, end)
+	for i := 1; i < len(route); i++ {
+		if !v.grid.Clear(route[i-1], route[i]) {
+			result.Status = models.FeasibilityStatusDetourRequired
+			result.Reason = "stated route crosses a wall or obstacle"
+			result.Path = make([][3]float64, len(path))
+			for j, p := range path {
+				result.Path[j] = [3]float64{round2(p[0]), seg.FromPosition[1], round2(p[1])}
+			}
+			return result
+		}
+	}
+
+	result.Status = models.FeasibilityStatusFeasible
+	return result
+}
+
+// Apply checks every segment, records the result on it and scales its
+// confidence. Each trajectory's overall confidence is scaled by its weakest
+// segment, and trajectories are re-ranked if any confidence dropped. A scene
+// without walls, obstacles or passable areas has nothing to rule routes out,
+// so results are recorded without scaling confidences.
+func (v *Validator) Apply(trajectories []models.Trajectory) Summary {
+	var points [][2]float64
+	for _, t := range trajectories {
+		for _, seg := range t.Segments {
+			points = append(points, segmentPoints(seg)...)
+		}
+	}
+	v.cover(points...)
+	penalize := v.grid.HasGeometry()
+
+	var summary Summary
+	changed := false
+	for i := range trajectories {
+		t := &trajectories[i]
+		weakest := 1.0
+		for j := range t.Segments {
+			seg := &t.Segments[j]
+			result := v.CheckSegment(*seg)
+			seg.Feasibility = &result
+
+			factor := 1.0
+			switch result.Status {
+			case models.FeasibilityStatusFeasible:
+				summary.Feasible++
+			case models.FeasibilityStatusDetourRequired:
+				summary.DetourRequired++
+				factor = detourConfidenceFactor
+			case models.FeasibilityStatusInfeasible:
+				summary.Infeasible++
+				factor = infeasibleConfidenceFactor
+			}
+			if !penalize {
+				factor = 1
+			}
+			seg.Confidence = round2(seg.Confidence * factor)
+			weakest = math.Min(weakest, factor)
+		}
+		if weakest < 1 {
+			t.OverallConfidence = round2(t.OverallConfidence * weakest)
+			changed = true
+		}
+	}
+
+	if changed {
+		sort.SliceStable(trajectories, func(i, j int) bool {
+			return trajectories[i].OverallConfidence > trajectories[j].OverallConfidence
+		})
+		for i := range trajectories {
+			trajectories[i].Rank = i + 1
+		}
+	}
+	return summary
+}
+
+// timeWindow returns the duration of a segment's time estimate, if both ends parse
+func timeWindow(est *models.TimeEstimate) (time.Duration, bool) {
+	if est == nil {
+		return 0, false
+	}
+	for _, layout := range timeLayouts {
+		start, err1 := time.Parse(layout, est.Start)
+		end, err2 := time.Parse(layout, est.End)
+		if err1 == nil && err2 == nil {
+			if d := end.Sub(start); d > 0 {
+				return d, true
+			}
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
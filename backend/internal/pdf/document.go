@@ -0,0 +1,334 @@
+// Package pdf writes PDF documents without external dependencies: text in the
+// standard Helvetica fonts, lines, rectangles, JPEG and PNG images, internal
+// links and a document outline.
+//
+// Page coordinates are in points from the top-left corner, with y growing
+// down the page; text is placed by its baseline.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// A4 page size in points
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Document is a PDF being built in memory
+type Document struct {
+	width, height float64
+	title         string
+	createdAt     time.Time
+	pages         []*Page
+	images        []*Image
+	outline       []bookmark
+}
+
+// Page is one page of a document
+type Page struct {
+	doc     *Document
+	number  int
+	content bytes.Buffer
+	images  []*Image
+	links   []link
+	obj     int
+}
+
+type link struct {
+	x, y, w, h float64
+	target     *Page
+	targetY    float64
+}
+
+type bookmark struct {
+	title string
+	page  *Page
+	y     float64
+}
+
+// NewDocument creates an empty document with pages of the given size
+func NewDocument(width, height float64) *Document {
+	return &Document{width: width, height: height}
+}
+
+// SetInfo sets the title and creation time recorded in the document
+// properties
+func (d *Document) SetInfo(title string, createdAt time.Time) {
+	d.title, d.createdAt = title, createdAt
+}
+
+// Size returns the page width and height
+func (d *Document) Size() (float64, float64) {
+	return d.width, d.height
+}
+
+// AddPage appends a blank page
+func (d *Document) AddPage() *Page {
+	p := &Page{doc: d, number: len(d.pages) + 1}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Pages returns the document's pages in order
+func (d *Document) Pages() []*Page {
+	return d.pages
+}
+
+// AddBookmark adds an outline entry that opens the page at y
+func (d *Document) AddBookmark(title string, page *Page, y float64) {
+	d.outline = append(d.outline, bookmark{title: title, page: page, y: y})
+}
+
+// Number returns the page's 1-based position in the document
+func (p *Page) Number() int {
+	return p.number
+}
+
+// SetFillColor sets the colour of text and filled shapes, components 0–1
+func (p *Page) SetFillColor(r, g, b float64) {
+	fmt.Fprintf(&p.content, "%s %s %s rg\n", num(r), num(g), num(b))
+}
+
+// SetStrokeColor sets the colour of lines and outlines, components 0–1
+func (p *Page) SetStrokeColor(r, g, b float64) {
+	fmt.Fprintf(&p.content, "%s %s %s RG\n", num(r), num(g), num(b))
+}
+
+// Text draws a line of text with its baseline starting at x, y
+func (p *Page) Text(x, y float64, f Font, size float64, s string) {
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td %s Tj ET\n",
+		f.resource(), num(size), num(x), num(p.doc.height-y), literal(encode(s)))
+}
+
+// Line draws a straight line
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n",
+		num(width), num(x1), num(p.doc.height-y1), num(x2), num(p.doc.height-y2))
+}
+
+// Rect draws a rectangle with its top-left corner at x, y, filled with the
+// fill colour or outlined with the stroke colour
+func (p *Page) Rect(x, y, w, h float64, fill bool) {
+	op := "S"
+	if fill {
+		op = "f"
+	}
+	fmt.Fprintf(&p.content, "%s %s %s %s re %s\n", num(x), num(p.doc.height-y-h), num(w), num(h), op)
+}
+
+// Image draws an embedded image into the box with its top-left corner at x, y
+func (p *Page) Image(img *Image, x, y, w, h float64) {
+	p.images = append(p.images, img)
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /%s Do Q\n",
+		num(w), num(h), num(x), num(p.doc.height-y-h), img.name)
+}
+
+// Link makes the box with its top-left corner at x, y a link to another
+// page, opened at targetY
+func (p *Page) Link(x, y, w, h float64, target *Page, targetY float64) {
+	p.links = append(p.links, link{x: x, y: y, w: w, h: h, target: target, targetY: targetY})
+}
+
+// WriteTo writes the finished document
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	pages := d.pages
+	if len(pages) == 0 {
+		pages = []*Page{{doc: d, number: 1}}
+	}
+
+	// Fixed objects first, then images, pages and their content, the outline
+	const (
+		catalogObj = 1
+		pagesObj   = 2
+		regularObj = 3
+		boldObj    = 4
+		infoObj    = 5
+	)
+	next := 6
+	for _, img := range d.images {
+		img.obj = next
+		next++
+	}
+	for _, p := range pages {
+		p.obj = next
+		next += 2 // page, content
+	}
+	outlineObj := 0
+	if len(d.outline) > 0 {
+		outlineObj = next
+		next += 1 + len(d.outline)
+	}
+
+	out := &writer{w: w, offsets: make([]int64, next)}
+	out.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	catalog := "<< /Type /Catalog /Pages 2 0 R"
+	if outlineObj > 0 {
+		catalog += fmt.Sprintf(" /Outlines %d 0 R /PageMode /UseOutlines", outlineObj)
+	}
+	out.object(catalogObj, catalog+" >>")
+
+	kids := ""
+	for _, p := range pages {
+		kids += fmt.Sprintf("%d 0 R ", p.obj)
+	}
+	out.object(pagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>",
+		kids, len(pages), num(d.width), num(d.height)))
+	out.object(regularObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	out.object(boldObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	info := "<< /Producer (SherlockOS)"
+	if d.title != "" {
+		info += " /Title " + textString(d.title)
+	}
+	if !d.createdAt.IsZero() {
+		info += " /CreationDate (D:" + d.createdAt.UTC().Format("20060102150405") + "Z)"
+	}
+	out.object(infoObj, info+" >>")
+
+	for _, img := range d.images {
+		out.stream(img.obj, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8 /Filter /%s",
+			img.Width, img.Height, img.colorSpace, img.filter), img.data)
+	}
+
+	for _, p := range pages {
+		resources := fmt.Sprintf("/Font << /F1 %d 0 R /F2 %d 0 R >>", regularObj, boldObj)
+		if len(p.images) > 0 {
+			resources += " /XObject <<"
+			seen := map[*Image]bool{}
+			for _, img := range p.images {
+				if !seen[img] {
+					seen[img] = true
+					resources += fmt.Sprintf(" /%s %d 0 R", img.name, img.obj)
+				}
+			}
+			resources += " >>"
+		}
+		page := fmt.Sprintf("<< /Type /Page /Parent %d 0 R /Resources << %s >> /Contents %d 0 R", pagesObj, resources, p.obj+1)
+		if len(p.links) > 0 {
+			page += " /Annots ["
+			for _, l := range p.links {
+				page += fmt.Sprintf(" << /Type /Annot /Subtype /Link /Border [0 0 0] /Rect [%s %s %s %s] /Dest [%d 0 R /XYZ 0 %s null] >>",
+					num(l.x), num(d.height-l.y-l.h), num(l.x+l.w), num(d.height-l.y), l.target.obj, num(d.height-l.targetY))
+			}
+			page += " ]"
+		}
+		out.object(p.obj, page+" >>")
+		out.stream(p.obj+1, "", p.content.Bytes())
+	}
+
+	if outlineObj > 0 {
+		first, last := outlineObj+1, outlineObj+len(d.outline)
+		out.object(outlineObj, fmt.Sprintf("<< /Type /Outlines /First %d 0 R /Last %d 0 R /Count %d >>", first, last, len(d.outline)))
+		for i, b := range d.outline {
+			obj := first + i
+			entry := fmt.Sprintf("<< /Title %s /Parent %d 0 R /Dest [%d 0 R /XYZ 0 %s null]",
+				textString(b.title), outlineObj, b.page.obj, num(d.height-b.y))
+			if obj > first {
+				entry += fmt.Sprintf(" /Prev %d 0 R", obj-1)
+			}
+			if obj < last {
+				entry += fmt.Sprintf(" /Next %d 0 R", obj+1)
+			}
+			out.object(obj, entry+" >>")
+		}
+	}
+
+	xref := out.n
+	out.printf("xref\n0 %d\n0000000000 65535 f \n", next)
+	for _, off := range out.offsets[1:] {
+		out.printf("%010d 00000 n \n", off)
+	}
+	out.printf("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", next, catalogObj, infoObj, xref)
+	return out.n, out.err
+}
+
+// Bytes returns the finished document
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	d.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+// writer tracks the byte offset of each object for the cross-reference table
+// and keeps the first write error
+type writer struct {
+	w       io.Writer
+	n       int64
+	err     error
+	offsets []int64
+}
+
+func (w *writer) printf(format string, args ...interface{}) {
+	w.write([]byte(fmt.Sprintf(format, args...)))
+}
+
+func (w *writer) write(b []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(b)
+	w.n += int64(n)
+	w.err = err
+}
+
+func (w *writer) object(id int, body string) {
+	w.offsets[id] = w.n
+	w.printf("%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (w *writer) stream(id int, dict string, data []byte) {
+	w.offsets[id] = w.n
+	w.printf("%d 0 obj\n<< %s /Length %d >>\nstream\n", id, dict, len(data))
+	w.write(data)
+	w.printf("\nendstream\nendobj\n")
+}
+
+// num formats a coordinate with at most two decimals
+func num(v float64) string {
+	s := strings.TrimSuffix(strings.TrimRight(strconv.FormatFloat(v, 'f', 2, 64), "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// literal writes encoded text as a PDF string, escaping delimiters and
+// anything outside printable ASCII
+func literal(b []byte) string {
+	var buf bytes.Buffer
+	buf.WriteByte('(')
+	for _, c := range b {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c < 32 || c > 126:
+			fmt.Fprintf(&buf, "\\%03o", c)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte(')')
+	return buf.String()
+}
+
+// textString encodes document-level text, such as titles and bookmarks, as
+// UTF-16 so any character survives
+func textString(s string) string {
+	var buf bytes.Buffer
+	buf.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&buf, "%04X", u)
+	}
+	buf.WriteByte('>')
+	return buf.String()
+}
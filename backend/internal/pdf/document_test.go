@@ -0,0 +1,151 @@
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testImage(t *testing.T, encode func(*bytes.Buffer, image.Image) error) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		img.Set(x, 0, color.NRGBA{255, 0, 0, 255})
+		img.Set(x, 1, color.NRGBA{0, 0, 255, 0}) // transparent
+	}
+	var buf bytes.Buffer
+	if err := encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// checkXref verifies every cross-reference entry points at its object
+func checkXref(t *testing.T, out []byte) {
+	t.Helper()
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(out)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	start, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(out[start:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", start)
+	}
+	lines := strings.Split(string(out[start:]), "\n")
+	count, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	for id := 1; id < count; id++ {
+		offset, _ := strconv.Atoi(strings.Fields(lines[2+id])[0])
+		if want := strconv.Itoa(id) + " 0 obj\n"; !bytes.HasPrefix(out[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", id, out[offset:offset+10])
+		}
+	}
+}
+
+func TestDocument_WriteTo(t *testing.T) {
+	doc := NewDocument(A4Width, A4Height)
+	doc.SetInfo("Case (report)", time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	pngImg, err := doc.AddImage(testImage(t, func(b *bytes.Buffer, img image.Image) error { return png.Encode(b, img) }))
+	if err != nil {
+		t.Fatalf("AddImage(png) error = %v", err)
+	}
+	jpegImg, err := doc.AddImage(testImage(t, func(b *bytes.Buffer, img image.Image) error { return jpeg.Encode(b, img, nil) }))
+	if err != nil {
+		t.Fatalf("AddImage(jpeg) error = %v", err)
+	}
+	if _, err := doc.AddImage([]byte("not an image")); err == nil {
+		t.Error("AddImage() accepted garbage")
+	}
+
+	first, second := doc.AddPage(), doc.AddPage()
+	first.Text(72, 72, FontBold, 12, `Height 175–190 cm (a\b)`)
+	first.Image(pngImg, 72, 100, 40, 20)
+	first.Image(pngImg, 72, 130, 40, 20)
+	first.Link(72, 60, 100, 14, second, 72)
+	second.Image(jpegImg, 72, 72, 40, 20)
+	second.Line(72, 100, 200, 100, 0.5)
+	second.Rect(72, 120, 50, 50, true)
+	doc.AddBookmark("First", first, 72)
+	doc.AddBookmark("Zweite Seite ü", second, 72)
+
+	out := doc.Bytes()
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) {
+		t.Fatalf("missing header: %q", out[:20])
+	}
+	checkXref(t, out)
+
+	for _, want := range []string{
+		"/Count 2 /MediaBox [0 0 595.28 841.89]",
+		`(Height 175\226190 cm \(a\\b\)) Tj`,
+		"/Filter /FlateDecode",
+		"/Filter /DCTDecode",
+		"/Subtype /Link",
+		"/Type /Outlines",
+		"/CreationDate (D:20260301120000Z)",
+		"72 769.89 Td", // top-left y flipped to PDF's bottom-left origin
+	} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Errorf("output is missing %q", want)
+		}
+	}
+	if n := bytes.Count(out, []byte("/Im1 ")); n != 3 {
+		// One resource entry plus two draws on the first page
+		t.Errorf("/Im1 appears %d times, want 3", n)
+	}
+	if pngImg.Width != 4 || pngImg.Height != 2 || first.Number() != 1 || second.Number() != 2 {
+		t.Errorf("image %dx%d, pages %d %d", pngImg.Width, pngImg.Height, first.Number(), second.Number())
+	}
+
+	// Transparent pixels become white
+	if got := rgb(mustDecode(t, testImage(t, func(b *bytes.Buffer, img image.Image) error { return png.Encode(b, img) }))); !bytes.Equal(got[:3], []byte{255, 0, 0}) || !bytes.Equal(got[12:15], []byte{255, 255, 255}) {
+		t.Errorf("rgb() = %v", got)
+	}
+}
+
+func mustDecode(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func TestDocument_Empty(t *testing.T) {
+	out := NewDocument(A4Width, A4Height).Bytes()
+	if !bytes.Contains(out, []byte("/Count 1")) {
+		t.Error("an empty document should still have one page")
+	}
+	checkXref(t, out)
+}
+
+func TestWrapText(t *testing.T) {
+	if w := TextWidth(FontRegular, 10, "Hi"); w != 7.22+2.22 {
+		t.Errorf("TextWidth() = %v", w)
+	}
+	if TextWidth(FontBold, 10, "Hi") <= TextWidth(FontRegular, 10, "Hi") {
+		t.Error("bold text should be wider")
+	}
+
+	lines := WrapText(FontRegular, 10, "the quick brown fox jumps over the lazy dog\n\nend", 80)
+	for _, line := range lines {
+		if TextWidth(FontRegular, 10, line) > 80 {
+			t.Errorf("line %q is wider than 80", line)
+		}
+	}
+	if strings.Join(lines, " ") != "the quick brown fox jumps over the lazy dog  end" || lines[len(lines)-2] != "" {
+		t.Errorf("WrapText() = %q", lines)
+	}
+
+	long := WrapText(FontRegular, 10, strings.Repeat("x", 50), 40)
+	if len(long) < 2 || strings.Join(long, "") != strings.Repeat("x", 50) {
+		t.Errorf("WrapText() of a long word = %q", long)
+	}
+}
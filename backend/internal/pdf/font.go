@@ -0,0 +1,149 @@
+package pdf
+
+import "strings"
+
+// Font is one of the standard Type 1 fonts every PDF reader provides, so
+// nothing has to be embedded
+type Font string
+
+// Fonts
+const (
+	FontRegular Font = "Helvetica"
+	FontBold    Font = "Helvetica-Bold"
+)
+
+// resource is the font's name in page resource dictionaries
+func (f Font) resource() string {
+	if f == FontBold {
+		return "F2"
+	}
+	return "F1"
+}
+
+// Glyph widths in thousandths of the font size for characters 32–126, from
+// the Adobe font metrics
+var (
+	regularWidths = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	boldWidths = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// winAnsi maps the characters WinAnsiEncoding places in 0x80–0x9F
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// encode converts text to WinAnsiEncoding. Characters outside it become '?'.
+func encode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			out = append(out, ' ')
+		case r >= 32 && r <= 126, r >= 0xA0 && r <= 0xFF:
+			out = append(out, byte(r))
+		default:
+			if b, ok := winAnsi[r]; ok {
+				out = append(out, b)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+// glyphWidth returns the width of an encoded character. Characters past
+// ASCII are given common widths, close enough for wrapping.
+func glyphWidth(f Font, b byte) int {
+	if b >= 32 && b <= 126 {
+		if f == FontBold {
+			return boldWidths[b-32]
+		}
+		return regularWidths[b-32]
+	}
+	switch b {
+	case 0x91, 0x92, 0x82:
+		return 222
+	case 0x95:
+		return 350
+	case 0x85, 0x97:
+		return 1000
+	}
+	return 556
+}
+
+// TextWidth returns the width of text in points when set in the font at size
+func TextWidth(f Font, size float64, s string) float64 {
+	total := 0
+	for _, b := range encode(s) {
+		total += glyphWidth(f, b)
+	}
+	return float64(total) * size / 1000
+}
+
+// WrapText breaks text into lines no wider than width, at spaces where
+// possible. Newlines in the text always start a new line.
+func WrapText(f Font, size float64, s string, width float64) []string {
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		words := strings.Fields(para)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		line := ""
+		for _, word := range words {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if TextWidth(f, size, candidate) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			// A word wider than the line is split wherever it overflows
+			for TextWidth(f, size, word) > width {
+				cut := fit(f, size, word, width)
+				lines = append(lines, word[:cut])
+				word = word[cut:]
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fit returns the longest prefix of s, in bytes, that fits width, and at
+// least one character
+func fit(f Font, size float64, s string, width float64) int {
+	cut := 0
+	for i, r := range s {
+		next := i + len(string(r))
+		if cut > 0 && TextWidth(f, size, s[:next]) > width {
+			break
+		}
+		cut = next
+	}
+	return cut
+}
@@ -0,0 +1,73 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // register the decoders for portraits and photos
+	_ "image/png"
+)
+
+// Image is a raster image embedded once in a document and drawn on any
+// number of pages
+type Image struct {
+	Width, Height int
+
+	name       string
+	colorSpace string
+	filter     string
+	data       []byte
+	obj        int
+}
+
+// AddImage embeds a JPEG or PNG image. JPEGs are stored as they are; other
+// images are decoded and stored as compressed RGB, with any transparency
+// composited over white.
+func (d *Document) AddImage(data []byte) (*Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	img := &Image{Width: cfg.Width, Height: cfg.Height, name: fmt.Sprintf("Im%d", len(d.images)+1)}
+	switch {
+	case format == "jpeg" && cfg.ColorModel == color.GrayModel:
+		img.colorSpace, img.filter, img.data = "DeviceGray", "DCTDecode", data
+	case format == "jpeg" && cfg.ColorModel == color.YCbCrModel:
+		img.colorSpace, img.filter, img.data = "DeviceRGB", "DCTDecode", data
+	default:
+		decoded, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode image: %w", err)
+		}
+		img.colorSpace, img.filter, img.data = "DeviceRGB", "FlateDecode", deflate(rgb(decoded))
+	}
+
+	d.images = append(d.images, img)
+	return img, nil
+}
+
+// rgb flattens an image to 8-bit RGB rows over a white background
+func rgb(img image.Image) []byte {
+	b := img.Bounds()
+	out := make([]byte, 0, b.Dx()*b.Dy()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			// Colours are alpha-premultiplied, so white shows through as 1-a
+			white := 0xffff - a
+			out = append(out, byte((r+white)>>8), byte((g+white)>>8), byte((bl+white)>>8))
+		}
+	}
+	return out
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
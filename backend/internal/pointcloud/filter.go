@@ -0,0 +1,199 @@
+package pointcloud
+
+import (
+	"math"
+	"sort"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// VoxelDownsample replaces all points falling in the same voxel with their
+// centroid (and mean color). Voxels are emitted in order of first occurrence,
+// so the result is deterministic. A non-positive size returns the cloud unchanged.
+func VoxelDownsample(pc *models.PointCloud, voxelSize float64) *models.PointCloud {
+	if voxelSize <= 0 || len(pc.Positions) == 0 {
+		return pc
+	}
+	hasColors := len(pc.Colors) == len(pc.Positions)
+
+	type voxel struct {
+		sum, color [3]float64
+		n          int
+	}
+	index := make(map[[3]int64]int)
+	var voxels []voxel
+
+	for i, p := range pc.Positions {
+		key := [3]int64{
+			int64(math.Floor(p[0] / voxelSize)),
+			int64(math.Floor(p[1] / voxelSize)),
+			int64(math.Floor(p[2] / voxelSize)),
+		}
+		vi, ok := index[key]
+		if !ok {
+			vi = len(voxels)
+			index[key] = vi
+			voxels = append(voxels, voxel{})
+		}
+		v := &voxels[vi]
+		for j := 0; j < 3; j++ {
+			v.sum[j] += p[j]
+			if hasColors {
+				v.color[j] += pc.Colors[i][j]
+			}
+		}
+		v.n++
+	}
+
+	out := &models.PointCloud{Positions: make([][]float64, len(voxels)), Count: len(voxels)}
+	if hasColors {
+		out.Colors = make([][]float64, len(voxels))
+	}
+	for i, v := range voxels {
+		n := float64(v.n)
+		out.Positions[i] = []float64{v.sum[0] / n, v.sum[1] / n, v.sum[2] / n}
+		if hasColors {
+			out.Colors[i] = []float64{v.color[0] / n, v.color[1] / n, v.color[2] / n}
+		}
+	}
+	return out
+}
+
+// RemoveStatisticalOutliers drops points whose mean distance to their k nearest
+// neighbours exceeds the global mean by more than stdRatio standard deviations.
+// Returns the filtered cloud and the number of points removed.
+func RemoveStatisticalOutliers(pc *models.PointCloud, k int, stdRatio float64) (*models.PointCloud, int) {
+	n := len(pc.Positions)
+	if k <= 0 || n <= k {
+		return pc, 0
+	}
+
+	grid := newPointGrid(pc.Positions, k)
+	meanDist := make([]float64, n)
+	var sum, sumSq float64
+	for i, p := range pc.Positions {
+		d := grid.meanNeighborDistance(i, p, k)
+		meanDist[i] = d
+		sum += d
+		sumSq += d * d
+	}
+	mean := sum / float64(n)
+	std := math.Sqrt(math.Max(0, sumSq/float64(n)-mean*mean))
+	limit := mean + stdRatio*std
+
+	hasColors := len(pc.Colors) == n
+	out := &models.PointCloud{Positions: make([][]float64, 0, n)}
+	if hasColors {
+		out.Colors = make([][]float64, 0, n)
+	}
+	for i, d := range meanDist {
+		if d > limit {
+			continue
+		}
+		out.Positions = append(out.Positions, pc.Positions[i])
+		if hasColors {
+			out.Colors = append(out.Colors, pc.Colors[i])
+		}
+	}
+	out.Count = len(out.Positions)
+	return out, n - out.Count
+}
+
+// pointGrid is a uniform hash grid for nearest-neighbour queries
+type pointGrid struct {
+	points [][]float64
+	cell   float64
+	cells  map[[3]int64][]int
+}
+
+// newPointGrid sizes cells so each holds roughly k points on average
+func newPointGrid(points [][]float64, k int) *pointGrid {
+	bounds := Bounds(&models.PointCloud{Positions: points})
+	volume := 1.0
+	dims := 0
+	for i := 0; i < 3; i++ {
+		if extent := bounds.Max[i] - bounds.Min[i]; extent > 0 {
+			volume *= extent
+			dims++
+		}
+	}
+	cell := 1.0
+	if dims > 0 {
+		cell = math.Pow(volume*float64(k)/float64(len(points)), 1/float64(dims))
+	}
+	if cell <= 0 || math.IsNaN(cell) || math.IsInf(cell, 0) {
+		cell = 1
+	}
+
+	g := &pointGrid{points: points, cell: cell, cells: make(map[[3]int64][]int)}
+	for i, p := range points {
+		key := g.key(p)
+		g.cells[key] = append(g.cells[key], i)
+	}
+	return g
+}
+
+func (g *pointGrid) key(p []float64) [3]int64 {
+	return [3]int64{
+		int64(math.Floor(p[0] / g.cell)),
+		int64(math.Floor(p[1] / g.cell)),
+		int64(math.Floor(p[2] / g.cell)),
+	}
+}
+
+// meanNeighborDistance returns the mean distance from point i to its k nearest
+// neighbours, searching outward ring by ring until the k-th distance is settled
+func (g *pointGrid) meanNeighborDistance(i int, p []float64, k int) float64 {
+	center := g.key(p)
+	var dists []float64
+	maxRing := 1 + int(math.Cbrt(float64(len(g.points))))
+
+	for ring := 0; ring <= maxRing; ring++ {
+		for dx := -ring; dx <= ring; dx++ {
+			for dy := -ring; dy <= ring; dy++ {
+				for dz := -ring; dz <= ring; dz++ {
+					if abs(dx) != ring && abs(dy) != ring && abs(dz) != ring {
+						continue // inner cells were visited in earlier rings
+					}
+					for _, j := range g.cells[[3]int64{center[0] + int64(dx), center[1] + int64(dy), center[2] + int64(dz)}] {
+						if j != i {
+							dists = append(dists, distance(p, g.points[j]))
+						}
+					}
+				}
+			}
+		}
+		// Points beyond this ring are at least ring*cell away
+		if len(dists) >= k {
+			sort.Float64s(dists)
+			if dists[k-1] <= float64(ring)*g.cell {
+				break
+			}
+		}
+	}
+
+	sort.Float64s(dists)
+	if len(dists) > k {
+		dists = dists[:k]
+	}
+	if len(dists) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, d := range dists {
+		sum += d
+	}
+	return sum / float64(len(dists))
+}
+
+func distance(a, b []float64) float64 {
+	dx, dy, dz := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,282 @@
+package pointcloud
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// ErrNoFloor is returned when no plane qualifies as the floor
+var ErrNoFloor = errors.New("no floor plane found")
+
+// Plane is the set of points p with Normal·p + D = 0; Normal is unit length
+type Plane struct {
+	Normal [3]float64 `json:"normal"`
+	D      float64    `json:"d"`
+}
+
+// Distance returns the signed distance from p to the plane
+func (pl Plane) Distance(p []float64) float64 {
+	return pl.Normal[0]*p[0] + pl.Normal[1]*p[1] + pl.Normal[2]*p[2] + pl.D
+}
+
+// FloorOptions controls RANSAC floor detection
+type FloorOptions struct {
+	Iterations int     // RANSAC iterations per candidate plane
+	Threshold  float64 // inlier distance; 0 uses 1% of the cloud diagonal
+	Candidates int     // dominant planes to consider
+	MinInliers float64 // minimum fraction of points on the floor plane
+	// UpAxis is the approximate vertical axis of the input frame (sign is
+	// ignored); planes tilted more than MaxTiltDeg from it are rejected
+	UpAxis     [3]float64
+	MaxTiltDeg float64
+	Seed       int64
+}
+
+// DefaultFloorOptions suits reconstructions in either camera (Y-down) or Y-up frames
+func DefaultFloorOptions() FloorOptions {
+	return FloorOptions{
+		Iterations: 300,
+		Candidates: 4,
+		MinInliers: 0.05,
+		UpAxis:     [3]float64{0, 1, 0},
+		MaxTiltDeg: 45,
+		Seed:       1,
+	}
+}
+
+// DetectFloor finds the floor with sequential RANSAC: among the dominant planes
+// that are roughly horizontal and have the bulk of the cloud on one side, the
+// one with the most inliers wins. The returned normal points toward the bulk
+// (i.e. up) and the plane is refit to its inliers by least squares.
+func DetectFloor(pc *models.PointCloud, opts FloorOptions) (Plane, int, error) {
+	points := pc.Positions
+	if len(points) < 3 {
+		return Plane{}, 0, ErrNoFloor
+	}
+
+	threshold := opts.Threshold
+	if threshold <= 0 {
+		bb := Bounds(pc)
+		threshold = 0.01 * distance(bb.Min[:], bb.Max[:])
+		if threshold == 0 {
+			return Plane{}, 0, ErrNoFloor
+		}
+	}
+	up := normalize(opts.UpAxis)
+	minCos := math.Cos(opts.MaxTiltDeg * math.Pi / 180)
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	remaining := make([]int, len(points))
+	for i := range remaining {
+		remaining[i] = i
+	}
+
+	var best Plane
+	bestInliers := 0
+	for c := 0; c < opts.Candidates && len(remaining) >= 3; c++ {
+		plane, inliers := ransacPlane(points, remaining, threshold, opts.Iterations, rng)
+		if len(inliers) < 3 {
+			break
+		}
+		plane = refitPlane(points, inliers, plane)
+
+		horizontal := math.Abs(dot(plane.Normal, up)) >= minCos
+		if horizontal && len(inliers) > bestInliers {
+			if oriented, ok := orientToBulk(plane, points, threshold); ok {
+				best, bestInliers = oriented, len(inliers)
+			}
+		}
+
+		remaining = subtract(remaining, inliers)
+	}
+
+	if bestInliers == 0 || float64(bestInliers) < opts.MinInliers*float64(len(points)) {
+		return Plane{}, 0, ErrNoFloor
+	}
+	return best, bestInliers, nil
+}
+
+// ransacPlane returns the plane through three sampled points with the most inliers
+func ransacPlane(points [][]float64, idx []int, threshold float64, iterations int, rng *rand.Rand) (Plane, []int) {
+	var best Plane
+	bestCount := 0
+	for it := 0; it < iterations; it++ {
+		a, b, c := points[idx[rng.Intn(len(idx))]], points[idx[rng.Intn(len(idx))]], points[idx[rng.Intn(len(idx))]]
+		plane, ok := planeThrough(a, b, c)
+		if !ok {
+			continue
+		}
+		count := 0
+		for _, i := range idx {
+			if math.Abs(plane.Distance(points[i])) <= threshold {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = plane, count
+		}
+	}
+
+	inliers := make([]int, 0, bestCount)
+	if bestCount > 0 {
+		for _, i := range idx {
+			if math.Abs(best.Distance(points[i])) <= threshold {
+				inliers = append(inliers, i)
+			}
+		}
+	}
+	return best, inliers
+}
+
+func planeThrough(a, b, c []float64) (Plane, bool) {
+	u := [3]float64{b[0] - a[0], b[1] - a[1], b[2] - a[2]}
+	v := [3]float64{c[0] - a[0], c[1] - a[1], c[2] - a[2]}
+	n := cross(u, v)
+	if dot(n, n) < 1e-18 {
+		return Plane{}, false
+	}
+	n = normalize(n)
+	return Plane{Normal: n, D: -(n[0]*a[0] + n[1]*a[1] + n[2]*a[2])}, true
+}
+
+// refitPlane fits a least-squares plane to the inliers: its normal is the
+// eigenvector of the inlier covariance with the smallest eigenvalue
+func refitPlane(points [][]float64, inliers []int, fallback Plane) Plane {
+	var centroid [3]float64
+	for _, i := range inliers {
+		for j := 0; j < 3; j++ {
+			centroid[j] += points[i][j]
+		}
+	}
+	n := float64(len(inliers))
+	for j := 0; j < 3; j++ {
+		centroid[j] /= n
+	}
+
+	var cov [3][3]float64
+	for _, i := range inliers {
+		d := [3]float64{points[i][0] - centroid[0], points[i][1] - centroid[1], points[i][2] - centroid[2]}
+		for r := 0; r < 3; r++ {
+			for c := 0; c < 3; c++ {
+				cov[r][c] += d[r] * d[c]
+			}
+		}
+	}
+
+	normal, ok := smallestEigenvector(cov)
+	if !ok {
+		return fallback
+	}
+	if dot(normal, fallback.Normal) < 0 {
+		normal = scale(normal, -1)
+	}
+	return Plane{Normal: normal, D: -dot(normal, centroid)}
+}
+
+// orientToBulk flips the plane so most off-plane points lie on its positive
+// side. Returns false if the plane cuts through the cloud rather than bounding it.
+func orientToBulk(plane Plane, points [][]float64, threshold float64) (Plane, bool) {
+	above, below := 0, 0
+	for _, p := range points {
+		switch d := plane.Distance(p); {
+		case d > threshold:
+			above++
+		case d < -threshold:
+			below++
+		}
+	}
+	if below > above {
+		plane = Plane{Normal: scale(plane.Normal, -1), D: -plane.D}
+		above, below = below, above
+	}
+	// A floor has (almost) nothing beneath it
+	if above == 0 || float64(below) > 0.1*float64(above+below) {
+		return plane, false
+	}
+	return plane, true
+}
+
+// smallestEigenvector diagonalizes a symmetric 3x3 matrix with Jacobi rotations
+func smallestEigenvector(a [3][3]float64) ([3]float64, bool) {
+	v := [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	for sweep := 0; sweep < 50; sweep++ {
+		off := a[0][1]*a[0][1] + a[0][2]*a[0][2] + a[1][2]*a[1][2]
+		if off < 1e-20 {
+			break
+		}
+		for p := 0; p < 2; p++ {
+			for q := p + 1; q < 3; q++ {
+				if math.Abs(a[p][q]) < 1e-30 {
+					continue
+				}
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < 3; k++ {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p], a[k][q] = c*akp-s*akq, s*akp+c*akq
+				}
+				for k := 0; k < 3; k++ {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k], a[q][k] = c*apk-s*aqk, s*apk+c*aqk
+				}
+				for k := 0; k < 3; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p], v[k][q] = c*vkp-s*vkq, s*vkp+c*vkq
+				}
+			}
+		}
+	}
+
+	min := 0
+	for i := 1; i < 3; i++ {
+		if a[i][i] < a[min][min] {
+			min = i
+		}
+	}
+	vec := [3]float64{v[0][min], v[1][min], v[2][min]}
+	if dot(vec, vec) < 1e-18 {
+		return vec, false
+	}
+	return normalize(vec), true
+}
+
+// subtract returns idx without the (sorted) members of remove
+func subtract(idx, remove []int) []int {
+	out := idx[:0:0]
+	r := 0
+	for _, i := range idx {
+		for r < len(remove) && remove[r] < i {
+			r++
+		}
+		if r < len(remove) && remove[r] == i {
+			continue
+		}
+		out = append(out, i)
+	}
+	return out
+}
+
+func dot(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+func scale(a [3]float64, s float64) [3]float64 {
+	return [3]float64{a[0] * s, a[1] * s, a[2] * s}
+}
+
+func normalize(a [3]float64) [3]float64 {
+	l := math.Sqrt(dot(a, a))
+	if l == 0 {
+		return a
+	}
+	return scale(a, 1/l)
+}
@@ -0,0 +1,328 @@
+package pointcloud
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// maxPLYHeader bounds the header size read before giving up on a file
+const maxPLYHeader = 64 * 1024
+
+// maxPLYPoints bounds how many vertices ReadPLY will allocate for
+const maxPLYPoints = 50_000_000
+
+// maxPreallocPoints bounds the room reserved up front for points a header
+// claims; clouds with more grow as their points are read, so a short file
+// with a large count can't allocate much
+const maxPreallocPoints = 1 << 16
+
+// preallocPoints returns how many points to reserve room for when a header
+// claims count
+func preallocPoints(count int) int {
+	if count > maxPreallocPoints {
+		return maxPreallocPoints
+	}
+	return count
+}
+
+// WritePLY writes the cloud as binary little-endian PLY with float32 positions
+// and, when present, uchar colors
+func WritePLY(w io.Writer, pc *models.PointCloud) error {
+	hasColors, err := validate(pc)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "ply\nformat binary_little_endian 1.0\ncomment SherlockOS point cloud\nelement vertex %d\n", len(pc.Positions))
+	bw.WriteString("property float x\nproperty float y\nproperty float z\n")
+	if hasColors {
+		bw.WriteString("property uchar red\nproperty uchar green\nproperty uchar blue\n")
+	}
+	bw.WriteString("end_header\n")
+
+	record := make([]byte, 12, 15)
+	for i, p := range pc.Positions {
+		record = record[:12]
+		binary.LittleEndian.PutUint32(record[0:], math.Float32bits(float32(p[0])))
+		binary.LittleEndian.PutUint32(record[4:], math.Float32bits(float32(p[1])))
+		binary.LittleEndian.PutUint32(record[8:], math.Float32bits(float32(p[2])))
+		if hasColors {
+			c := pc.Colors[i]
+			record = append(record, colorToByte(c[0]), colorToByte(c[1]), colorToByte(c[2]))
+		}
+		if _, err := bw.Write(record); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// plyProperty is a scalar vertex property declared in a PLY header
+type plyProperty struct {
+	name string
+	typ  string
+	size int
+}
+
+// plySizes maps PLY scalar types (both naming styles) to their byte sizes
+var plySizes = map[string]int{
+	"char": 1, "uchar": 1, "int8": 1, "uint8": 1,
+	"short": 2, "ushort": 2, "int16": 2, "uint16": 2,
+	"int": 4, "uint": 4, "int32": 4, "uint32": 4,
+	"float": 4, "float32": 4,
+	"double": 8, "float64": 8,
+}
+
+// plyHeader is the parsed part of a PLY header needed to read vertices
+type plyHeader struct {
+	format      string
+	vertexCount int
+	props       []plyProperty
+	stride      int
+	skipBefore  int // bytes of fixed-size elements preceding the vertex element (binary)
+	linesBefore int // records of elements preceding the vertex element (ascii)
+}
+
+// ReadPLY reads x/y/z and optional red/green/blue vertex properties from an
+// ascii or binary (little- or big-endian) PLY file. Colors stored as integers
+// are scaled from 0-255 to 0-1.
+func ReadPLY(r io.Reader) (*models.PointCloud, error) {
+	br := bufio.NewReader(r)
+	h, err := readPLYHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := map[string]int{}
+	for i, p := range h.props {
+		idx[p.name] = i
+	}
+	for _, axis := range []string{"x", "y", "z"} {
+		if _, ok := idx[axis]; !ok {
+			return nil, fmt.Errorf("ply: vertex element has no %s property", axis)
+		}
+	}
+	_, hasRed := idx["red"]
+	_, hasGreen := idx["green"]
+	_, hasBlue := idx["blue"]
+	hasColors := hasRed && hasGreen && hasBlue
+
+	pc := &models.PointCloud{
+		Positions: make([][]float64, 0, preallocPoints(h.vertexCount)),
+		Count:     h.vertexCount,
+	}
+	if hasColors {
+		pc.Colors = make([][]float64, 0, preallocPoints(h.vertexCount))
+	}
+
+	values := make([]float64, len(h.props))
+	next, err := h.vertexReader(br, values)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < h.vertexCount; i++ {
+		if err := next(); err != nil {
+			return nil, fmt.Errorf("ply: vertex %d: %w", i, err)
+		}
+		pc.Positions = append(pc.Positions, []float64{values[idx["x"]], values[idx["y"]], values[idx["z"]]})
+		if hasColors {
+			pc.Colors = append(pc.Colors, []float64{
+				h.colorValue(idx["red"], values),
+				h.colorValue(idx["green"], values),
+				h.colorValue(idx["blue"], values),
+			})
+		}
+	}
+	return pc, nil
+}
+
+func readPLYHeader(br *bufio.Reader) (*plyHeader, error) {
+	// Elements in declaration order; stride is -1 for elements with list properties
+	type element struct {
+		name   string
+		count  int
+		stride int
+	}
+	var elements []element
+
+	h := &plyHeader{}
+	read := 0
+	for lineNo := 0; ; lineNo++ {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("ply: truncated header: %w", err)
+		}
+		read += len(line)
+		if read > maxPLYHeader {
+			return nil, errors.New("ply: header too large")
+		}
+		fields := strings.Fields(line)
+		if lineNo == 0 {
+			if len(fields) != 1 || fields[0] != "ply" {
+				return nil, errors.New("ply: missing magic")
+			}
+			continue
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "format":
+			if len(fields) < 2 {
+				return nil, errors.New("ply: malformed format line")
+			}
+			h.format = fields[1]
+		case "comment", "obj_info":
+		case "element":
+			if len(fields) != 3 {
+				return nil, errors.New("ply: malformed element line")
+			}
+			n, err := strconv.Atoi(fields[2])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("ply: invalid element count %q", fields[2])
+			}
+			elements = append(elements, element{name: fields[1], count: n})
+		case "property":
+			if len(elements) == 0 {
+				return nil, errors.New("ply: property outside an element")
+			}
+			el := &elements[len(elements)-1]
+			if len(fields) >= 2 && fields[1] == "list" {
+				if el.name == "vertex" {
+					return nil, errors.New("ply: list properties on vertices are not supported")
+				}
+				el.stride = -1
+				continue
+			}
+			if len(fields) != 3 {
+				return nil, errors.New("ply: malformed property line")
+			}
+			size, ok := plySizes[fields[1]]
+			if !ok {
+				return nil, fmt.Errorf("ply: unknown property type %q", fields[1])
+			}
+			if el.stride >= 0 {
+				el.stride += size
+			}
+			if el.name == "vertex" {
+				h.props = append(h.props, plyProperty{name: fields[2], typ: fields[1], size: size})
+			}
+		case "end_header":
+			switch h.format {
+			case "ascii", "binary_little_endian", "binary_big_endian":
+			default:
+				return nil, fmt.Errorf("ply: unsupported format %q", h.format)
+			}
+			for _, el := range elements {
+				if el.name == "vertex" {
+					if el.count > maxPLYPoints {
+						return nil, fmt.Errorf("ply: %d vertices exceeds limit of %d", el.count, maxPLYPoints)
+					}
+					h.vertexCount, h.stride = el.count, el.stride
+					return h, nil
+				}
+				if h.format != "ascii" && el.count > 0 && el.stride < 0 {
+					return nil, errors.New("ply: cannot skip variable-size elements before vertices")
+				}
+				h.skipBefore += el.count * el.stride
+				h.linesBefore += el.count
+			}
+			return nil, errors.New("ply: no vertex element")
+		default:
+			return nil, fmt.Errorf("ply: unexpected header line %q", strings.TrimSpace(line))
+		}
+	}
+}
+
+// vertexReader returns a function that reads the next vertex's properties into values
+func (h *plyHeader) vertexReader(br *bufio.Reader, values []float64) (func() error, error) {
+	if h.format == "ascii" {
+		for i := 0; i < h.linesBefore; i++ {
+			if _, err := br.ReadString('\n'); err != nil {
+				return nil, fmt.Errorf("ply: truncated file: %w", err)
+			}
+		}
+		return func() error {
+			line, err := br.ReadString('\n')
+			if err != nil && (err != io.EOF || line == "") {
+				return err
+			}
+			fields := strings.Fields(line)
+			if len(fields) < len(values) {
+				return fmt.Errorf("expected %d values, got %d", len(values), len(fields))
+			}
+			for i := range values {
+				v, err := strconv.ParseFloat(fields[i], 64)
+				if err != nil {
+					return err
+				}
+				values[i] = v
+			}
+			return nil
+		}, nil
+	}
+
+	if _, err := br.Discard(h.skipBefore); err != nil {
+		return nil, fmt.Errorf("ply: truncated file: %w", err)
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if h.format == "binary_big_endian" {
+		order = binary.BigEndian
+	}
+	record := make([]byte, h.stride)
+	return func() error {
+		if _, err := io.ReadFull(br, record); err != nil {
+			return err
+		}
+		off := 0
+		for i, p := range h.props {
+			values[i] = decodePLYScalar(record[off:off+p.size], p.typ, order)
+			off += p.size
+		}
+		return nil
+	}, nil
+}
+
+func decodePLYScalar(b []byte, typ string, order binary.ByteOrder) float64 {
+	switch typ {
+	case "char", "int8":
+		return float64(int8(b[0]))
+	case "uchar", "uint8":
+		return float64(b[0])
+	case "short", "int16":
+		return float64(int16(order.Uint16(b)))
+	case "ushort", "uint16":
+		return float64(order.Uint16(b))
+	case "int", "int32":
+		return float64(int32(order.Uint32(b)))
+	case "uint", "uint32":
+		return float64(order.Uint32(b))
+	case "float", "float32":
+		return float64(math.Float32frombits(order.Uint32(b)))
+	default: // double, float64
+		return math.Float64frombits(order.Uint64(b))
+	}
+}
+
+// colorValue normalizes a color property to 0-1
+func (h *plyHeader) colorValue(i int, values []float64) float64 {
+	switch h.props[i].typ {
+	case "float", "float32", "double", "float64":
+		return values[i]
+	case "ushort", "uint16":
+		return values[i] / 65535
+	default:
+		return values[i] / 255
+	}
+}
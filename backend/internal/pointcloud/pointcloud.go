@@ -0,0 +1,147 @@
+// Package pointcloud encodes reconstruction point clouds to binary files so
+// they can be kept in object storage instead of inline in the SceneGraph.
+package pointcloud
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// DefaultPreviewPoints is the number of points kept inline in the SceneGraph
+// when the full cloud is moved to storage
+const DefaultPreviewPoints = 2000
+
+// ErrEmpty is returned when encoding a cloud with no points
+var ErrEmpty = errors.New("point cloud has no points")
+
+// Encode writes the cloud in the given format
+func Encode(w io.Writer, pc *models.PointCloud, format models.PointCloudFormat) error {
+	switch format {
+	case models.PointCloudFormatPLY:
+		return WritePLY(w, pc)
+	case models.PointCloudFormatQuantized:
+		return WriteQuantized(w, pc)
+	default:
+		return fmt.Errorf("unsupported point cloud format: %s", format)
+	}
+}
+
+// Decode reads a cloud in the given format
+func Decode(r io.Reader, format models.PointCloudFormat) (*models.PointCloud, error) {
+	switch format {
+	case models.PointCloudFormatPLY:
+		return ReadPLY(r)
+	case models.PointCloudFormatQuantized:
+		return ReadQuantized(r)
+	default:
+		return nil, fmt.Errorf("unsupported point cloud format: %s", format)
+	}
+}
+
+// Marshal encodes the cloud into a byte slice
+func Marshal(pc *models.PointCloud, format models.PointCloudFormat) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, pc, format); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ContentType returns the MIME type used when storing or serving a format
+func ContentType(format models.PointCloudFormat) string {
+	if format == models.PointCloudFormatPLY {
+		return "application/ply"
+	}
+	return "application/octet-stream"
+}
+
+// FileExtension returns the file extension for a format, without the dot
+func FileExtension(format models.PointCloudFormat) string {
+	return string(format)
+}
+
+// Bounds returns the axis-aligned bounds of the cloud's positions
+func Bounds(pc *models.PointCloud) models.BoundingBox {
+	var bb models.BoundingBox
+	first := true
+	for _, p := range pc.Positions {
+		if len(p) < 3 {
+			continue
+		}
+		for i := 0; i < 3; i++ {
+			if first || p[i] < bb.Min[i] {
+				bb.Min[i] = p[i]
+			}
+			if first || p[i] > bb.Max[i] {
+				bb.Max[i] = p[i]
+			}
+		}
+		first = false
+	}
+	return bb
+}
+
+// Preview returns a copy of the cloud reduced to at most maxPoints by taking
+// evenly spaced points, so repeated runs yield the same preview
+func Preview(pc *models.PointCloud, maxPoints int) *models.PointCloud {
+	n := len(pc.Positions)
+	if maxPoints <= 0 || n == 0 {
+		return &models.PointCloud{Count: pc.Count}
+	}
+
+	keep := n
+	if keep > maxPoints {
+		keep = maxPoints
+	}
+	hasColors := len(pc.Colors) == n
+
+	out := &models.PointCloud{
+		Positions: make([][]float64, 0, keep),
+		Count:     pc.Count,
+	}
+	if hasColors {
+		out.Colors = make([][]float64, 0, keep)
+	}
+	for i := 0; i < keep; i++ {
+		src := i * n / keep
+		out.Positions = append(out.Positions, pc.Positions[src])
+		if hasColors {
+			out.Colors = append(out.Colors, pc.Colors[src])
+		}
+	}
+	return out
+}
+
+// validate checks the cloud can be encoded and reports whether it has per-point colors
+func validate(pc *models.PointCloud) (hasColors bool, err error) {
+	if pc == nil || len(pc.Positions) == 0 {
+		return false, ErrEmpty
+	}
+	for i, p := range pc.Positions {
+		if len(p) < 3 {
+			return false, fmt.Errorf("point %d has %d coordinates, want 3", i, len(p))
+		}
+	}
+	if len(pc.Colors) == 0 {
+		return false, nil
+	}
+	if len(pc.Colors) != len(pc.Positions) {
+		return false, fmt.Errorf("point cloud has %d colors for %d points", len(pc.Colors), len(pc.Positions))
+	}
+	for i, c := range pc.Colors {
+		if len(c) < 3 {
+			return false, fmt.Errorf("color %d has %d channels, want 3", i, len(c))
+		}
+	}
+	return true, nil
+}
+
+// colorToByte converts a 0-1 color channel to 0-255
+func colorToByte(v float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
@@ -0,0 +1,200 @@
+package pointcloud
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+func testCloud(n int) *models.PointCloud {
+	pc := &models.PointCloud{Count: n}
+	for i := 0; i < n; i++ {
+		f := float64(i)
+		pc.Positions = append(pc.Positions, []float64{f * 0.5, -f, 2 + f*0.25})
+		pc.Colors = append(pc.Colors, []float64{f / float64(n), 0.5, 1})
+	}
+	return pc
+}
+
+func assertClose(t *testing.T, what string, got, want [][]float64, tol float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %d entries, want %d", what, len(got), len(want))
+	}
+	for i := range want {
+		for j := 0; j < 3; j++ {
+			if math.Abs(got[i][j]-want[i][j]) > tol {
+				t.Fatalf("%s[%d] = %v, want %v (±%v)", what, i, got[i], want[i], tol)
+			}
+		}
+	}
+}
+
+func TestPLY_RoundTrip(t *testing.T) {
+	pc := testCloud(100)
+
+	data, err := Marshal(pc, models.PointCloudFormatPLY)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("ply\nformat binary_little_endian 1.0\n")) {
+		t.Errorf("PLY header = %q", data[:40])
+	}
+
+	got, err := ReadPLY(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadPLY() error = %v", err)
+	}
+	if got.Count != 100 {
+		t.Errorf("Count = %d, want 100", got.Count)
+	}
+	assertClose(t, "positions", got.Positions, pc.Positions, 1e-5)
+	assertClose(t, "colors", got.Colors, pc.Colors, 1.0/255)
+}
+
+func TestPLY_WithoutColors(t *testing.T) {
+	pc := &models.PointCloud{Positions: [][]float64{{1, 2, 3}}}
+
+	data, _ := Marshal(pc, models.PointCloudFormatPLY)
+	if bytes.Contains(data, []byte("red")) {
+		t.Error("header should not declare colors for an uncolored cloud")
+	}
+	got, err := ReadPLY(bytes.NewReader(data))
+	if err != nil || got.Colors != nil {
+		t.Errorf("ReadPLY() = %+v, %v", got, err)
+	}
+}
+
+func TestReadPLY_ExternalFiles(t *testing.T) {
+	ascii := "ply\nformat ascii 1.0\nelement camera 1\nproperty float fov\nelement vertex 2\n" +
+		"property double x\nproperty double y\nproperty double z\nproperty uchar red\nproperty uchar green\nproperty uchar blue\n" +
+		"element face 0\nproperty list uchar int vertex_indices\nend_header\n" +
+		"60\n1 2 3 255 0 0\n-1.5 0 4 0 0 255\n"
+
+	got, err := ReadPLY(strings.NewReader(ascii))
+	if err != nil {
+		t.Fatalf("ReadPLY(ascii) error = %v", err)
+	}
+	assertClose(t, "ascii positions", got.Positions, [][]float64{{1, 2, 3}, {-1.5, 0, 4}}, 0)
+	assertClose(t, "ascii colors", got.Colors, [][]float64{{1, 0, 0}, {0, 0, 1}}, 0)
+
+	// Big-endian with extra vertex properties
+	var buf bytes.Buffer
+	buf.WriteString("ply\nformat binary_big_endian 1.0\nelement vertex 1\nproperty float x\nproperty float nx\nproperty float y\nproperty float z\nend_header\n")
+	for _, v := range []float32{1, 9, 2, 3} {
+		binary.Write(&buf, binary.BigEndian, v)
+	}
+	got, err = ReadPLY(&buf)
+	if err != nil {
+		t.Fatalf("ReadPLY(big endian) error = %v", err)
+	}
+	assertClose(t, "big-endian positions", got.Positions, [][]float64{{1, 2, 3}}, 0)
+}
+
+func TestReadPLY_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"missing magic":  "obj\n",
+		"no vertices":    "ply\nformat ascii 1.0\nelement face 1\nproperty float a\nend_header\n",
+		"no z":           "ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nproperty float y\nend_header\n1 2\n",
+		"truncated body": "ply\nformat binary_little_endian 1.0\nelement vertex 2\nproperty float x\nproperty float y\nproperty float z\nend_header\n\x00\x00",
+		"vertex lists":   "ply\nformat ascii 1.0\nelement vertex 1\nproperty list uchar float x\nend_header\n",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ReadPLY(strings.NewReader(data)); err == nil {
+				t.Error("ReadPLY() expected error")
+			}
+		})
+	}
+}
+
+func TestRead_ClaimedCountDoesNotAllocate(t *testing.T) {
+	const claimed = 40_000_000
+	ply := fmt.Sprintf("ply\nformat binary_little_endian 1.0\nelement vertex %d\nproperty float x\nproperty float y\nproperty float z\nproperty uchar red\nproperty uchar green\nproperty uchar blue\nend_header\n\x00\x00", claimed)
+	sqpc := make([]byte, quantizedHeaderLen)
+	copy(sqpc, quantizedMagic)
+	sqpc[4] = quantizedVersion
+	sqpc[5] = quantizedHasColors
+	binary.LittleEndian.PutUint32(sqpc[8:], claimed)
+
+	for name, read := range map[string]func() error{
+		"ply":  func() error { _, err := ReadPLY(strings.NewReader(ply)); return err },
+		"sqpc": func() error { _, err := ReadQuantized(bytes.NewReader(sqpc)); return err },
+	} {
+		t.Run(name, func(t *testing.T) {
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			if err := read(); err == nil {
+				t.Fatal("a truncated file should fail")
+			}
+			runtime.ReadMemStats(&after)
+			if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 32<<20 {
+				t.Errorf("allocated %d MB for %d claimed points in a tiny file", allocated>>20, claimed)
+			}
+		})
+	}
+}
+
+func TestQuantized_RoundTrip(t *testing.T) {
+	pc := testCloud(200)
+
+	ply, _ := Marshal(pc, models.PointCloudFormatPLY)
+	data, err := Marshal(pc, models.PointCloudFormatQuantized)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if len(data) >= len(ply) {
+		t.Errorf("quantized size %d should be smaller than PLY %d", len(data), len(ply))
+	}
+
+	got, err := Decode(bytes.NewReader(data), models.PointCloudFormatQuantized)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	// Largest extent is 199 m on the y axis
+	assertClose(t, "positions", got.Positions, pc.Positions, 199.0/65535)
+	assertClose(t, "colors", got.Colors, pc.Colors, 1.0/255)
+
+	if _, err := ReadQuantized(bytes.NewReader(data[:10])); err == nil {
+		t.Error("ReadQuantized() should reject a truncated header")
+	}
+}
+
+func TestEncode_Invalid(t *testing.T) {
+	if err := WritePLY(&bytes.Buffer{}, &models.PointCloud{}); err != ErrEmpty {
+		t.Errorf("WritePLY(empty) error = %v, want ErrEmpty", err)
+	}
+	mismatched := &models.PointCloud{Positions: [][]float64{{0, 0, 0}}, Colors: [][]float64{{1, 1, 1}, {0, 0, 0}}}
+	if err := WriteQuantized(&bytes.Buffer{}, mismatched); err == nil {
+		t.Error("WriteQuantized() should reject mismatched colors")
+	}
+	if _, err := Marshal(testCloud(1), "xyz"); err == nil {
+		t.Error("Marshal() should reject unknown formats")
+	}
+}
+
+func TestBoundsAndPreview(t *testing.T) {
+	pc := testCloud(10)
+
+	bb := Bounds(pc)
+	if bb.Min != [3]float64{0, -9, 2} || bb.Max != [3]float64{4.5, 0, 4.25} {
+		t.Errorf("Bounds() = %+v", bb)
+	}
+
+	preview := Preview(pc, 4)
+	if len(preview.Positions) != 4 || len(preview.Colors) != 4 || preview.Count != 10 {
+		t.Fatalf("Preview() = %d points, %d colors, count %d", len(preview.Positions), len(preview.Colors), preview.Count)
+	}
+	if preview.Positions[0][0] != 0 || preview.Positions[3][1] != -7 {
+		t.Errorf("Preview() should take evenly spaced points, got %v", preview.Positions)
+	}
+	if len(Preview(pc, 50).Positions) != 10 {
+		t.Error("Preview() should keep every point of a small cloud")
+	}
+}
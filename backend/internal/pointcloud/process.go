@@ -0,0 +1,97 @@
+package pointcloud
+
+import (
+	"errors"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// ProcessOptions configures the post-reconstruction cleanup pipeline
+type ProcessOptions struct {
+	VoxelSize        float64 // 0 skips downsampling
+	OutlierNeighbors int     // 0 skips outlier removal
+	OutlierStdRatio  float64
+	AlignFloor       bool
+	Floor            FloorOptions
+}
+
+// DefaultProcessOptions downsamples to 2 cm voxels, removes points more than
+// two standard deviations from their 16 nearest neighbours and aligns the floor
+func DefaultProcessOptions() ProcessOptions {
+	return ProcessOptions{
+		VoxelSize:        0.02,
+		OutlierNeighbors: 16,
+		OutlierStdRatio:  2.0,
+		AlignFloor:       true,
+		Floor:            DefaultFloorOptions(),
+	}
+}
+
+// ProcessResult reports what the pipeline did
+type ProcessResult struct {
+	InputPoints     int       `json:"input_points"`
+	OutputPoints    int       `json:"output_points"`
+	OutliersRemoved int       `json:"outliers_removed"`
+	Floor           *Plane    `json:"floor,omitempty"`
+	FloorInliers    int       `json:"floor_inliers,omitempty"`
+	Transform       Transform `json:"transform"` // identity when no floor was found
+}
+
+// FloorAligned reports whether the cloud was moved into a Y-up, floor-at-zero frame
+func (r *ProcessResult) FloorAligned() bool {
+	return r.Floor != nil
+}
+
+// Process downsamples, denoises and floor-aligns a cloud, returning a new
+// cloud. If no floor is found the cloud is cleaned but left in its input frame.
+func Process(pc *models.PointCloud, opts ProcessOptions) (*models.PointCloud, *ProcessResult, error) {
+	if pc == nil || len(pc.Positions) == 0 {
+		return nil, nil, ErrEmpty
+	}
+	if _, err := validate(pc); err != nil {
+		return nil, nil, err
+	}
+
+	result := &ProcessResult{InputPoints: len(pc.Positions), Transform: IdentityTransform()}
+
+	out := copyCloud(pc)
+	out = VoxelDownsample(out, opts.VoxelSize)
+	out, result.OutliersRemoved = RemoveStatisticalOutliers(out, opts.OutlierNeighbors, opts.OutlierStdRatio)
+
+	if opts.AlignFloor {
+		floor, inliers, err := DetectFloor(out, opts.Floor)
+		switch {
+		case err == nil:
+			result.Floor = &floor
+			result.FloorInliers = inliers
+			result.Transform = FloorAlignment(floor)
+			result.Transform.ApplyCloud(out)
+		case !errors.Is(err, ErrNoFloor):
+			return nil, nil, err
+		}
+	}
+
+	out.Count = len(out.Positions)
+	bounds := Bounds(out)
+	out.Bounds = &bounds
+	result.OutputPoints = out.Count
+	return out, result, nil
+}
+
+// copyCloud deep-copies positions and colors so in-place transforms don't alias the input
+func copyCloud(pc *models.PointCloud) *models.PointCloud {
+	out := &models.PointCloud{
+		Positions: make([][]float64, len(pc.Positions)),
+		Count:     len(pc.Positions),
+	}
+	for i, p := range pc.Positions {
+		out.Positions[i] = []float64{p[0], p[1], p[2]}
+	}
+	if len(pc.Colors) > 0 {
+		out.Colors = make([][]float64, len(pc.Colors))
+		for i, c := range pc.Colors {
+			out.Colors[i] = []float64{c[0], c[1], c[2]}
+		}
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+package pointcloud
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// syntheticRoom builds a 4 m × 3 m room (floor at y=0, one wall at z=0, a box on
+// the floor) plus a handful of far-away outliers, expressed in a camera-style
+// frame: Y down, tilted 20° about X and offset by 1.5 m.
+func syntheticRoom(t *testing.T) (*models.PointCloud, Transform) {
+	t.Helper()
+	rng := rand.New(rand.NewSource(7))
+	var world [][3]float64
+	for i := 0; i < 3000; i++ { // floor
+		world = append(world, [3]float64{rng.Float64() * 4, rng.NormFloat64() * 0.003, rng.Float64() * 3})
+	}
+	for i := 0; i < 1200; i++ { // back wall
+		world = append(world, [3]float64{rng.Float64() * 4, rng.Float64() * 2.5, rng.NormFloat64() * 0.003})
+	}
+	for i := 0; i < 600; i++ { // box top at 0.8 m
+		world = append(world, [3]float64{1 + rng.Float64()*0.5, 0.8, 1 + rng.Float64()*0.5})
+	}
+	for i := 0; i < 20; i++ { // floaters above the room
+		world = append(world, [3]float64{rng.Float64() * 4, 6 + rng.Float64()*4, rng.Float64() * 3})
+	}
+
+	// world → camera: flip Y, tilt, offset
+	tilt := 20 * math.Pi / 180
+	flip := Transform{Rotation: [4]float64{0, 1, 0, 0}}
+	toCamera := Transform{
+		Rotation:    normalizeQuat(multiplyQuat([4]float64{math.Cos(tilt / 2), math.Sin(tilt / 2), 0, 0}, flip.Rotation)),
+		Translation: [3]float64{-2, 1.5, 4},
+	}
+
+	pc := &models.PointCloud{}
+	for _, p := range world {
+		c := toCamera.Apply(p)
+		pc.Positions = append(pc.Positions, []float64{c[0], c[1], c[2]})
+		pc.Colors = append(pc.Colors, []float64{0.5, 0.5, 0.5})
+	}
+	pc.Count = len(pc.Positions)
+	return pc, toCamera
+}
+
+func TestProcess_AlignsFloorAndRemovesOutliers(t *testing.T) {
+	pc, toCamera := syntheticRoom(t)
+	original := pc.Positions[0][0]
+
+	out, result, err := Process(pc, DefaultProcessOptions())
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if pc.Positions[0][0] != original {
+		t.Error("Process() must not modify its input")
+	}
+
+	if !result.FloorAligned() || result.FloorInliers < 1000 {
+		t.Fatalf("Process() floor = %+v (%d inliers)", result.Floor, result.FloorInliers)
+	}
+	if result.OutliersRemoved < 15 {
+		t.Errorf("OutliersRemoved = %d, want the floaters removed", result.OutliersRemoved)
+	}
+	if result.OutputPoints >= result.InputPoints || out.Count != len(out.Positions) || len(out.Colors) != out.Count {
+		t.Errorf("Process() points %d → %d (count %d, colors %d)", result.InputPoints, result.OutputPoints, out.Count, len(out.Colors))
+	}
+
+	// Floor at y=0, nothing meaningfully below it, room height preserved
+	if out.Bounds.Min[1] < -0.03 {
+		t.Errorf("min y = %v, want ≈ 0", out.Bounds.Min[1])
+	}
+	if math.Abs(out.Bounds.Max[1]-2.5) > 0.05 {
+		t.Errorf("max y = %v, want ≈ 2.5 (wall height)", out.Bounds.Max[1])
+	}
+
+	// An object on the box top in the camera frame ends up 0.8 m above the floor
+	obj := models.SceneObject{Pose: models.NewDefaultPose()}
+	obj.Pose.Position = toCamera.Apply([3]float64{1.25, 0.8, 1.25})
+	result.Transform.ApplyObject(&obj)
+	if math.Abs(obj.Pose.Position[1]-0.8) > 0.02 {
+		t.Errorf("object height = %v, want 0.8", obj.Pose.Position[1])
+	}
+}
+
+func TestProcess_NoFloorKeepsFrame(t *testing.T) {
+	// A vertical wall only: no horizontal plane qualifies
+	pc := &models.PointCloud{}
+	for i := 0; i < 400; i++ {
+		pc.Positions = append(pc.Positions, []float64{float64(i%20) * 0.1, float64(i/20) * 0.1, 0})
+	}
+
+	out, result, err := Process(pc, DefaultProcessOptions())
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if result.FloorAligned() || result.Transform != IdentityTransform() {
+		t.Errorf("Process() should not align without a floor, got %+v", result.Transform)
+	}
+	if out.Bounds.Max[1] < 1.8 {
+		t.Errorf("Process() moved a cloud with no floor: %+v", out.Bounds)
+	}
+}
+
+func TestVoxelDownsample(t *testing.T) {
+	pc := &models.PointCloud{
+		Positions: [][]float64{{0.01, 0.01, 0.01}, {0.03, 0.03, 0.03}, {0.5, 0, 0}},
+		Colors:    [][]float64{{1, 0, 0}, {0, 0, 1}, {0, 1, 0}},
+	}
+	out := VoxelDownsample(pc, 0.1)
+	if out.Count != 2 {
+		t.Fatalf("VoxelDownsample() = %d points, want 2", out.Count)
+	}
+	if math.Abs(out.Positions[0][0]-0.02) > 1e-9 || out.Colors[0][0] != 0.5 || out.Colors[0][2] != 0.5 {
+		t.Errorf("first voxel = %v / %v, want centroid and mean color", out.Positions[0], out.Colors[0])
+	}
+}
+
+func TestTransform_Pose(t *testing.T) {
+	// 90° about Z maps +X to +Y
+	rot := rotationBetween([3]float64{1, 0, 0}, [3]float64{0, 1, 0})
+	tr := Transform{Rotation: rot, Translation: [3]float64{0, 0, 1}}
+
+	pose := tr.ApplyPose(models.NewDefaultPose())
+	if pose.Position != [3]float64{0, 0, 1} {
+		t.Errorf("position = %v", pose.Position)
+	}
+	forward := rotateVector(pose.Rotation, [3]float64{1, 0, 0})
+	if math.Abs(forward[1]-1) > 1e-9 {
+		t.Errorf("rotated +X = %v, want +Y", forward)
+	}
+
+	// Opposite vectors still produce a valid rotation
+	flip := rotationBetween([3]float64{0, -1, 0}, [3]float64{0, 1, 0})
+	if up := rotateVector(flip, [3]float64{0, -1, 0}); math.Abs(up[1]-1) > 1e-9 {
+		t.Errorf("flip rotation maps -Y to %v", up)
+	}
+
+	box := tr.ApplyBox(models.BoundingBox{Min: [3]float64{0, 0, 0}, Max: [3]float64{2, 1, 1}})
+	if math.Abs(box.Min[0]+1) > 1e-9 || math.Abs(box.Max[1]-2) > 1e-9 || math.Abs(box.Max[2]-2) > 1e-9 {
+		t.Errorf("ApplyBox() = %+v", box)
+	}
+}
@@ -0,0 +1,142 @@
+package pointcloud
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// Quantized (.sqpc) layout, little-endian:
+//
+//	magic   [4]byte  "SQPC"
+//	version uint8    1
+//	flags   uint8    bit 0: per-point colors present
+//	_       [2]byte  reserved
+//	count   uint32
+//	min     [3]float32  bounds used for dequantization
+//	max     [3]float32
+//	points  count × [3]uint16   positions scaled to 0..65535 within bounds
+//	colors  count × [3]uint8    only when flag bit 0 is set
+//
+// Positions lose at most extent/65535 per axis (under 0.2 mm for a 10 m room)
+// at half the size of float32 PLY.
+const (
+	quantizedMagic     = "SQPC"
+	quantizedVersion   = 1
+	quantizedHasColors = 1 << 0
+	quantizedHeaderLen = 4 + 1 + 1 + 2 + 4 + 24
+	quantizedSteps     = 65535
+)
+
+// WriteQuantized writes the cloud in the 16-bit quantized format
+func WriteQuantized(w io.Writer, pc *models.PointCloud) error {
+	hasColors, err := validate(pc)
+	if err != nil {
+		return err
+	}
+	bounds := Bounds(pc)
+
+	header := make([]byte, quantizedHeaderLen)
+	copy(header, quantizedMagic)
+	header[4] = quantizedVersion
+	if hasColors {
+		header[5] = quantizedHasColors
+	}
+	binary.LittleEndian.PutUint32(header[8:], uint32(len(pc.Positions)))
+	for i := 0; i < 3; i++ {
+		binary.LittleEndian.PutUint32(header[12+4*i:], math.Float32bits(float32(bounds.Min[i])))
+		binary.LittleEndian.PutUint32(header[24+4*i:], math.Float32bits(float32(bounds.Max[i])))
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.Write(header)
+
+	var record [6]byte
+	for _, p := range pc.Positions {
+		for i := 0; i < 3; i++ {
+			binary.LittleEndian.PutUint16(record[2*i:], quantize(p[i], bounds.Min[i], bounds.Max[i]))
+		}
+		if _, err := bw.Write(record[:]); err != nil {
+			return err
+		}
+	}
+	if hasColors {
+		for _, c := range pc.Colors {
+			bw.Write([]byte{colorToByte(c[0]), colorToByte(c[1]), colorToByte(c[2])})
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadQuantized reads a cloud written by WriteQuantized
+func ReadQuantized(r io.Reader) (*models.PointCloud, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, quantizedHeaderLen)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("sqpc: truncated header: %w", err)
+	}
+	if string(header[:4]) != quantizedMagic {
+		return nil, errors.New("sqpc: missing magic")
+	}
+	if header[4] != quantizedVersion {
+		return nil, fmt.Errorf("sqpc: unsupported version %d", header[4])
+	}
+	hasColors := header[5]&quantizedHasColors != 0
+	count := int(binary.LittleEndian.Uint32(header[8:]))
+	if count > maxPLYPoints {
+		return nil, fmt.Errorf("sqpc: %d points exceeds limit of %d", count, maxPLYPoints)
+	}
+
+	var min, max [3]float64
+	for i := 0; i < 3; i++ {
+		min[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(header[12+4*i:])))
+		max[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(header[24+4*i:])))
+	}
+
+	pc := &models.PointCloud{
+		Positions: make([][]float64, 0, preallocPoints(count)),
+		Count:     count,
+	}
+	var record [6]byte
+	for n := 0; n < count; n++ {
+		if _, err := io.ReadFull(br, record[:]); err != nil {
+			return nil, fmt.Errorf("sqpc: point %d: %w", n, err)
+		}
+		p := make([]float64, 3)
+		for i := 0; i < 3; i++ {
+			p[i] = dequantize(binary.LittleEndian.Uint16(record[2*i:]), min[i], max[i])
+		}
+		pc.Positions = append(pc.Positions, p)
+	}
+
+	if hasColors {
+		pc.Colors = make([][]float64, 0, preallocPoints(count))
+		var rgb [3]byte
+		for n := 0; n < count; n++ {
+			if _, err := io.ReadFull(br, rgb[:]); err != nil {
+				return nil, fmt.Errorf("sqpc: color %d: %w", n, err)
+			}
+			pc.Colors = append(pc.Colors, []float64{float64(rgb[0]) / 255, float64(rgb[1]) / 255, float64(rgb[2]) / 255})
+		}
+	}
+	return pc, nil
+}
+
+func quantize(v, min, max float64) uint16 {
+	extent := max - min
+	if extent <= 0 {
+		return 0
+	}
+	t := (v - min) / extent
+	return uint16(math.Round(math.Max(0, math.Min(1, t)) * quantizedSteps))
+}
+
+func dequantize(q uint16, min, max float64) float64 {
+	return min + float64(q)/quantizedSteps*(max-min)
+}
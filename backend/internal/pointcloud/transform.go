@@ -0,0 +1,138 @@
+package pointcloud
+
+import (
+	"math"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// Transform is a rigid transform: rotate by a unit quaternion, then translate
+type Transform struct {
+	Rotation    [4]float64 `json:"rotation"` // quaternion [w, x, y, z], as in models.Pose
+	Translation [3]float64 `json:"translation"`
+}
+
+// IdentityTransform leaves points unchanged
+func IdentityTransform() Transform {
+	return Transform{Rotation: [4]float64{1, 0, 0, 0}}
+}
+
+// FloorAlignment returns the transform that rotates the floor normal onto +Y
+// and moves the floor plane to y = 0
+func FloorAlignment(floor Plane) Transform {
+	t := Transform{Rotation: rotationBetween(floor.Normal, [3]float64{0, 1, 0})}
+	// After rotation a floor point has y = Normal·p = -D
+	t.Translation[1] = floor.D
+	return t
+}
+
+// Apply transforms a point
+func (t Transform) Apply(p [3]float64) [3]float64 {
+	r := rotateVector(t.Rotation, p)
+	return [3]float64{r[0] + t.Translation[0], r[1] + t.Translation[1], r[2] + t.Translation[2]}
+}
+
+// ApplyCloud transforms every point in place
+func (t Transform) ApplyCloud(pc *models.PointCloud) {
+	for _, p := range pc.Positions {
+		q := t.Apply([3]float64{p[0], p[1], p[2]})
+		p[0], p[1], p[2] = q[0], q[1], q[2]
+	}
+	if pc.Bounds != nil {
+		bb := t.ApplyBox(*pc.Bounds)
+		pc.Bounds = &bb
+	}
+}
+
+// ApplyPose moves a pose's position and composes its rotation
+func (t Transform) ApplyPose(pose models.Pose) models.Pose {
+	pose.Position = t.Apply(pose.Position)
+	rot := pose.Rotation
+	if rot == [4]float64{} {
+		rot = [4]float64{1, 0, 0, 0}
+	}
+	pose.Rotation = normalizeQuat(multiplyQuat(t.Rotation, rot))
+	return pose
+}
+
+// ApplyBox returns the axis-aligned box enclosing the transformed corners of a
+// world-space box. A zero box is left as is.
+func (t Transform) ApplyBox(bb models.BoundingBox) models.BoundingBox {
+	if bb.Min == [3]float64{} && bb.Max == [3]float64{} {
+		return bb
+	}
+	var out models.BoundingBox
+	for i := 0; i < 8; i++ {
+		corner := [3]float64{bb.Min[0], bb.Min[1], bb.Min[2]}
+		for axis := 0; axis < 3; axis++ {
+			if i&(1<<axis) != 0 {
+				corner[axis] = bb.Max[axis]
+			}
+		}
+		p := t.Apply(corner)
+		for axis := 0; axis < 3; axis++ {
+			if i == 0 || p[axis] < out.Min[axis] {
+				out.Min[axis] = p[axis]
+			}
+			if i == 0 || p[axis] > out.Max[axis] {
+				out.Max[axis] = p[axis]
+			}
+		}
+	}
+	return out
+}
+
+// ApplyObject transforms a scene object's pose and bounding box
+func (t Transform) ApplyObject(obj *models.SceneObject) {
+	obj.Pose = t.ApplyPose(obj.Pose)
+	obj.BBox = t.ApplyBox(obj.BBox)
+}
+
+// rotationBetween returns the shortest-arc rotation taking unit vector a onto b
+func rotationBetween(a, b [3]float64) [4]float64 {
+	a, b = normalize(a), normalize(b)
+	d := dot(a, b)
+	if d > 1-1e-12 {
+		return [4]float64{1, 0, 0, 0}
+	}
+	if d < -1+1e-12 {
+		// Opposite vectors: rotate 180° about any axis perpendicular to a
+		axis := cross(a, [3]float64{1, 0, 0})
+		if dot(axis, axis) < 1e-12 {
+			axis = cross(a, [3]float64{0, 0, 1})
+		}
+		axis = normalize(axis)
+		return [4]float64{0, axis[0], axis[1], axis[2]}
+	}
+	c := cross(a, b)
+	return normalizeQuat([4]float64{1 + d, c[0], c[1], c[2]})
+}
+
+func rotateVector(q [4]float64, v [3]float64) [3]float64 {
+	// v' = v + 2w(u×v) + 2u×(u×v) with u the vector part of q
+	u := [3]float64{q[1], q[2], q[3]}
+	uv := cross(u, v)
+	uuv := cross(u, uv)
+	return [3]float64{
+		v[0] + 2*(q[0]*uv[0]+uuv[0]),
+		v[1] + 2*(q[0]*uv[1]+uuv[1]),
+		v[2] + 2*(q[0]*uv[2]+uuv[2]),
+	}
+}
+
+func multiplyQuat(a, b [4]float64) [4]float64 {
+	return [4]float64{
+		a[0]*b[0] - a[1]*b[1] - a[2]*b[2] - a[3]*b[3],
+		a[0]*b[1] + a[1]*b[0] + a[2]*b[3] - a[3]*b[2],
+		a[0]*b[2] - a[1]*b[3] + a[2]*b[0] + a[3]*b[1],
+		a[0]*b[3] + a[1]*b[2] - a[2]*b[1] + a[3]*b[0],
+	}
+}
+
+func normalizeQuat(q [4]float64) [4]float64 {
+	l := math.Sqrt(q[0]*q[0] + q[1]*q[1] + q[2]*q[2] + q[3]*q[3])
+	if l == 0 {
+		return [4]float64{1, 0, 0, 0}
+	}
+	return [4]float64{q[0] / l, q[1] / l, q[2] / l, q[3] / l}
+}
@@ -55,6 +55,7 @@ func (q *MemoryQueue) Enqueue(ctx context.Context, job *models.Job) error {
 		Input:      job.Input,
 		EnqueuedAt: time.Now().UTC(),
 		Attempts:   0,
+		CreatedBy:  job.CreatedBy,
 	}
 
 	select {
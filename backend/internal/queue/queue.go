@@ -76,6 +76,7 @@ type JobMessage struct {
 	EnqueuedAt  time.Time       `json:"enqueued_at"`
 	Attempts    int             `json:"attempts"`
 	LastAttempt *time.Time      `json:"last_attempt,omitempty"`
+	CreatedBy   *uuid.UUID      `json:"created_by,omitempty"` // user the job runs as
 }
 
 // Enqueue adds a job to the appropriate queue
@@ -89,6 +90,7 @@ func (q *Queue) Enqueue(ctx context.Context, job *models.Job) error {
 		Input:      job.Input,
 		EnqueuedAt: time.Now().UTC(),
 		Attempts:   0,
+		CreatedBy:  job.CreatedBy,
 	}
 
 	data, err := json.Marshal(msg)
@@ -145,6 +147,7 @@ func (q *Queue) Ack(ctx context.Context, msg *JobMessage) error {
 		Input:      msg.Input,
 		EnqueuedAt: msg.EnqueuedAt,
 		Attempts:   msg.Attempts - 1, // Original attempts before this processing
+		CreatedBy:  msg.CreatedBy,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to marshal job message for ack: %w", err)
@@ -170,6 +173,7 @@ func (q *Queue) Nack(ctx context.Context, msg *JobMessage, maxRetries int) error
 		Input:      msg.Input,
 		EnqueuedAt: msg.EnqueuedAt,
 		Attempts:   msg.Attempts - 1,
+		CreatedBy:  msg.CreatedBy,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to marshal job message for nack: %w", err)
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	l := NewLimiter(6, 3) // a token every 10s
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("user-a"); !ok {
+			t.Fatalf("request %d within the burst was refused", i+1)
+		}
+	}
+	ok, wait := l.Allow("user-a")
+	if ok || wait != 10*time.Second {
+		t.Errorf("Allow() past the burst = %v, %v; want false, 10s", ok, wait)
+	}
+	if ok, _ := l.Allow("user-b"); !ok {
+		t.Error("another key should have its own bucket")
+	}
+
+	now = now.Add(4 * time.Second)
+	if _, wait := l.Allow("user-a"); wait != 6*time.Second {
+		t.Errorf("wait after 4s = %v, want 6s", wait)
+	}
+	now = now.Add(6 * time.Second)
+	if ok, _ := l.Allow("user-a"); !ok {
+		t.Error("a token should have refilled after 10s")
+	}
+
+	// Refilling stops at the burst
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		l.Allow("user-a")
+	}
+	if ok, _ := l.Allow("user-a"); ok {
+		t.Error("an idle bucket should refill to the burst, not beyond")
+	}
+}
+
+func TestLimiter_Disabled(t *testing.T) {
+	var l *Limiter = NewLimiter(0, 10)
+	if l != nil {
+		t.Fatal("NewLimiter() with no rate should return nil")
+	}
+	for i := 0; i < 100; i++ {
+		if ok, _ := l.Allow("anyone"); !ok {
+			t.Fatal("a nil limiter should allow everything")
+		}
+	}
+}
+
+func TestLimiter_Prune(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	l := NewLimiter(60, 1)
+	l.now = func() time.Time { return now }
+	for i := 0; i < maxIdleBuckets; i++ {
+		l.Allow(fmt.Sprint(i))
+	}
+
+	now = now.Add(time.Second)
+	l.Allow("new")
+	if len(l.buckets) != 1 {
+		t.Errorf("%d buckets after pruning, want 1", len(l.buckets))
+	}
+}
@@ -0,0 +1,62 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// CustodyEntry is one event in the chain of custody of a stored file
+type CustodyEntry struct {
+	Time       time.Time
+	StorageKey string
+	Kind       models.AssetKind
+	Action     string // "stored" or "referenced"
+	Detail     string
+	By         *uuid.UUID // who made the referencing commit, when recorded
+}
+
+// Custody builds the chain of custody for the case's files: when each was
+// stored, then every timeline entry that refers to it by asset ID or storage
+// key, in time order
+func Custody(assets []models.Asset, timeline []models.Commit) []CustodyEntry {
+	var out []CustodyEntry
+	for _, a := range assets {
+		var detail []string
+		if ct, ok := a.Metadata["content_type"].(string); ok {
+			detail = append(detail, ct)
+		}
+		if size, ok := a.Metadata["size_bytes"].(float64); ok {
+			detail = append(detail, fmt.Sprintf("%.0f bytes", size))
+		}
+		out = append(out, CustodyEntry{
+			Time: a.CreatedAt, StorageKey: a.StorageKey, Kind: a.Kind, Action: "stored",
+			Detail: strings.Join(detail, ", "),
+		})
+
+		id := []byte(a.ID.String())
+		key := []byte(a.StorageKey)
+		for _, c := range timeline {
+			if !bytes.Contains(c.Payload, id) && !bytes.Contains(c.Payload, key) {
+				continue
+			}
+			out = append(out, CustodyEntry{
+				Time: c.CreatedAt, StorageKey: a.StorageKey, Kind: a.Kind, Action: "referenced",
+				Detail: fmt.Sprintf("%s: %s", c.Type, c.Summary), By: c.CreatedBy,
+			})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].Time.Equal(out[j].Time) {
+			return out[i].Time.Before(out[j].Time)
+		}
+		return out[i].StorageKey < out[j].StorageKey
+	})
+	return out
+}
@@ -0,0 +1,109 @@
+package report
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sherlockos/backend/internal/floorplan"
+	"github.com/sherlockos/backend/internal/models"
+)
+
+//go:embed templates/default.html.tmpl
+var defaultTemplate string
+
+// TemplateExt is the file extension of report templates on disk
+const TemplateExt = ".html.tmpl"
+
+// htmlPlanWidth is the width in pixels of the floor plan in HTML reports
+const htmlPlanWidth = 900
+
+// ErrTemplateNotFound is returned when no template has the requested name
+var ErrTemplateNotFound = errors.New("report template not found")
+
+// templateFuncs are available to every report template. has and floorplan
+// are bound to the report being rendered.
+var templateFuncs = template.FuncMap{
+	"has":       func(string) bool { return false },
+	"floorplan": func() *planFigure { return nil },
+	"mul":       func(a, b float64) float64 { return a * b },
+	"percent":   percent,
+	"date":      func(t time.Time) string { return t.Format("January 2, 2006 3:04 PM") },
+	"join":      strings.Join,
+	"title": func(s string) string {
+		if s == "" {
+			return s
+		}
+		return strings.ToUpper(s[:1]) + s[1:]
+	},
+}
+
+// planFigure is the floor plan as templates see it: the inline SVG and the
+// key to its evidence markers
+type planFigure struct {
+	SVG     template.HTML
+	Markers []floorplan.Marker
+}
+
+// ParseTemplate parses a report template. Templates are executed with the
+// *Report and can call has "section" to check whether a section was chosen,
+// and floorplan for the scene's floor plan, which is nil when there is none.
+func ParseTemplate(name, body string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// DefaultTemplate returns the built-in report template
+func DefaultTemplate() *template.Template {
+	return template.Must(ParseTemplate(models.DefaultReportTemplate, defaultTemplate))
+}
+
+// LoadTemplateFile reads the named template from dir, returning
+// ErrTemplateNotFound if there is no such file
+func LoadTemplateFile(dir, name string) (string, error) {
+	if dir == "" || !models.IsValidReportTemplateName(name) {
+		return "", ErrTemplateNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name+TemplateExt))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrTemplateNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// RenderHTML executes a report template
+func RenderHTML(r *Report, tmpl *template.Template) ([]byte, error) {
+	tmpl, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	tmpl.Funcs(template.FuncMap{
+		"has": func(section string) bool { return r.Has(models.ReportSection(section)) },
+		"floorplan": func() *planFigure {
+			plan := r.FloorPlan(htmlPlanWidth)
+			if plan == nil {
+				return nil
+			}
+			// The SVG is generated here with all text escaped
+			return &planFigure{SVG: template.HTML(plan.SVG()), Markers: plan.Markers}
+		},
+	})
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, r); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,597 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/sherlockos/backend/internal/floorplan"
+	"github.com/sherlockos/backend/internal/models"
+	"github.com/sherlockos/backend/internal/pdf"
+)
+
+// Page layout in points
+const (
+	margin       = 56.0
+	marginTop    = 64.0
+	marginBottom = 72.0
+	footerY      = 32.0 // footer baseline, from the bottom edge
+
+	portraitWidth     = 150.0
+	portraitMaxHeight = 200.0
+
+	planPixels    = 1200  // floor plan image width, about 180 dpi across the page
+	planMaxHeight = 460.0 // points
+)
+
+type colour [3]float64
+
+var (
+	ink    = colour{0.1, 0.1, 0.12}
+	muted  = colour{0.4, 0.4, 0.45}
+	accent = colour{0.39, 0.4, 0.95}
+	green  = colour{0.09, 0.6, 0.29}
+	amber  = colour{0.8, 0.5, 0.04}
+	red    = colour{0.8, 0.18, 0.18}
+	rule   = colour{0.8, 0.8, 0.84}
+)
+
+const disclaimer = "This report is for investigative purposes only. AI-generated hypotheses should be verified with physical evidence."
+
+// section is a numbered part of the report listed in the table of contents
+type section struct {
+	title  string
+	render func(*layout, *Report)
+	page   *pdf.Page
+	y      float64
+}
+
+// pdfSections gives each section its heading and layout
+var pdfSections = map[models.ReportSection]section{
+	models.ReportSectionTimeline:     {title: "Timeline", render: renderTimeline},
+	models.ReportSectionEvidence:     {title: "Evidence", render: renderEvidence},
+	models.ReportSectionProfile:      {title: "Suspect Profile", render: renderProfile},
+	models.ReportSectionTrajectories: {title: "Trajectories", render: renderTrajectories},
+	models.ReportSectionParadoxes:    {title: "Paradoxes", render: renderParadoxes},
+	models.ReportSectionConstraints:  {title: "Constraint Checks", render: renderConstraints},
+	models.ReportSectionScene:        {title: "Scene Summary", render: renderScene},
+	models.ReportSectionCustodyLog:   {title: "Custody Log", render: renderCustody},
+	models.ReportSectionAuditLog:     {title: "Audit Log", render: renderAudit},
+}
+
+// RenderPDF lays the report out on A4 pages: a title page with the case
+// header and a linked table of contents, then each section, with the case
+// and page number in every footer
+func RenderPDF(r *Report) []byte {
+	doc := pdf.NewDocument(pdf.A4Width, pdf.A4Height)
+	title := "Case report"
+	if r.Case != nil {
+		title = r.Case.Title
+	}
+	doc.SetInfo(title, r.GeneratedAt)
+
+	var sections []*section
+	for _, s := range models.ReportSections {
+		if r.Has(s) {
+			sec := pdfSections[s]
+			sections = append(sections, &sec)
+		}
+	}
+
+	l := &layout{doc: doc}
+	l.newPage()
+	renderHeader(l, r, title)
+
+	// Reserve the contents now; page numbers are only known once the
+	// sections are laid out
+	reserved := float64(len(sections)) * 18
+	l.gap(12)
+	l.ensure(reserved + 24)
+	l.line(margin, pdf.FontBold, 14, ink, "Contents")
+	l.gap(4)
+	tocPage, tocY := l.page, l.y
+	l.y += reserved
+
+	l.newPage()
+	for _, s := range sections {
+		l.ensure(60)
+		if l.y > marginTop {
+			l.gap(14)
+		}
+		s.page, s.y = l.page, l.y
+		doc.AddBookmark(s.title, s.page, s.y)
+		l.line(margin, pdf.FontBold, 15, accent, s.title)
+		l.page.SetStrokeColor(rule[0], rule[1], rule[2])
+		l.page.Line(margin, l.y, l.width-margin, l.y, 0.5)
+		l.gap(8)
+		s.render(l, r)
+	}
+	l.gap(18)
+	l.paragraph(0, pdf.FontRegular, 8, muted, disclaimer)
+
+	renderContents(tocPage, tocY, l.width, sections)
+	renderFooters(doc, title)
+	return doc.Bytes()
+}
+
+func renderHeader(l *layout, r *Report, title string) {
+	l.paragraph(0, pdf.FontBold, 22, ink, title)
+	l.gap(4)
+	if r.Case != nil {
+		l.line(margin, pdf.FontRegular, 9, muted, "Case ID: "+r.Case.ID.String())
+		l.line(margin, pdf.FontRegular, 9, muted, "Opened: "+r.Case.CreatedAt.Format("January 2, 2006 3:04 PM"))
+	}
+	l.line(margin, pdf.FontRegular, 9, muted, "Generated: "+r.GeneratedAt.Format("January 2, 2006 3:04 PM"))
+	if r.Scope != "" {
+		l.line(margin, pdf.FontRegular, 9, muted, "Scope: "+r.Scope)
+	}
+	if r.Redacted {
+		l.line(margin, pdf.FontRegular, 9, muted, "Names in this report have been redacted.")
+	}
+	if r.Case != nil && r.Case.Description != "" {
+		l.gap(8)
+		l.paragraph(0, pdf.FontRegular, 11, ink, r.Case.Description)
+	}
+	l.gap(8)
+	l.paragraph(0, pdf.FontRegular, 10, muted, fmt.Sprintf(
+		"%d timeline events, %d evidence items, %d trajectory hypotheses, %d paradoxes.",
+		len(r.Timeline), len(r.Evidence), len(r.Trajectories), len(r.Paradoxes)))
+}
+
+// renderContents fills the reserved contents with dot leaders, page numbers
+// and links to each section
+func renderContents(page *pdf.Page, y, width float64, sections []*section) {
+	const size = 11
+	for i, s := range sections {
+		label := fmt.Sprintf("%d.  %s", i+1, s.title)
+		number := fmt.Sprint(s.page.Number())
+		right := width - margin - pdf.TextWidth(pdf.FontRegular, size, number)
+		dots := ""
+		for left := margin + pdf.TextWidth(pdf.FontRegular, size, label+" "); left+pdf.TextWidth(pdf.FontRegular, size, dots+". ") < right; {
+			dots += "."
+		}
+
+		page.SetFillColor(ink[0], ink[1], ink[2])
+		page.Text(margin, y+size, pdf.FontRegular, size, label)
+		page.SetFillColor(muted[0], muted[1], muted[2])
+		page.Text(width-margin-pdf.TextWidth(pdf.FontRegular, size, dots+" "+number), y+size, pdf.FontRegular, size, dots)
+		page.SetFillColor(ink[0], ink[1], ink[2])
+		page.Text(right, y+size, pdf.FontRegular, size, number)
+		page.Link(margin, y, width-2*margin, 16, s.page, s.y)
+		y += 18
+	}
+}
+
+func renderFooters(doc *pdf.Document, title string) {
+	width, height := doc.Size()
+	pages := doc.Pages()
+	for _, p := range pages {
+		y := height - footerY
+		number := fmt.Sprintf("Page %d of %d", p.Number(), len(pages))
+		numberWidth := pdf.TextWidth(pdf.FontRegular, 8, number)
+
+		left := "SherlockOS case report: " + title
+		if room := width - 2*margin - numberWidth - 24; pdf.TextWidth(pdf.FontRegular, 8, left) > room {
+			left = strings.TrimSpace(pdf.WrapText(pdf.FontRegular, 8, left, room-8)[0]) + "…"
+		}
+
+		p.SetStrokeColor(rule[0], rule[1], rule[2])
+		p.Line(margin, y-12, width-margin, y-12, 0.5)
+		p.SetFillColor(muted[0], muted[1], muted[2])
+		p.Text(margin, y, pdf.FontRegular, 8, left)
+		p.Text(width-margin-numberWidth, y, pdf.FontRegular, 8, number)
+	}
+}
+
+func renderTimeline(l *layout, r *Report) {
+	if len(r.Timeline) == 0 {
+		l.line(margin, pdf.FontRegular, 10, muted, "No events recorded.")
+		return
+	}
+	for _, c := range r.Timeline {
+		l.ensure(30)
+		l.row(pdf.FontBold, 9, ink, c.CreatedAt.Format("Jan 2, 2006 3:04 PM"), muted, string(c.Type))
+		l.paragraph(12, pdf.FontRegular, 10, ink, c.Summary)
+		l.gap(4)
+	}
+}
+
+func renderEvidence(l *layout, r *Report) {
+	groups := r.EvidenceByTier()
+	if len(groups) == 0 {
+		l.line(margin, pdf.FontRegular, 10, muted, "No evidence recorded.")
+		return
+	}
+	for _, g := range groups {
+		l.ensure(60)
+		label := strings.ToUpper(string(g.Tier[:1])) + string(g.Tier[1:])
+		l.line(margin, pdf.FontBold, 12, confidenceColour(tierConfidence[g.Tier]), fmt.Sprintf("%s confidence (%d)", label, len(g.Evidence)))
+		l.gap(4)
+		renderEvidenceCards(l, g.Evidence)
+	}
+}
+
+// tierConfidence is a confidence within each tier, for picking its colour
+var tierConfidence = map[models.EvidenceTier]float64{
+	models.EvidenceTierHigh:   0.8,
+	models.EvidenceTierMedium: 0.5,
+	models.EvidenceTierLow:    0,
+}
+
+func renderEvidenceCards(l *layout, evidence []models.EvidenceCard) {
+	for _, ev := range evidence {
+		l.ensure(40)
+		l.row(pdf.FontBold, 11, ink, ev.Title, confidenceColour(ev.Confidence), percent(ev.Confidence)+" confidence")
+		if ev.Description != "" {
+			l.paragraph(0, pdf.FontRegular, 10, ink, ev.Description)
+		}
+		if len(ev.Sources) > 0 {
+			l.paragraph(0, pdf.FontRegular, 8, muted, "Sources: "+describeSources(ev.Sources))
+		}
+		if len(ev.Conflicts) > 0 {
+			l.paragraph(0, pdf.FontRegular, 8, red, "Conflicts: "+describeSources(ev.Conflicts))
+		}
+		l.gap(8)
+	}
+}
+
+func describeSources(sources []models.EvidenceSource) string {
+	var parts []string
+	for _, s := range sources {
+		part := string(s.Type)
+		if s.Description != "" {
+			part += " (" + s.Description + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func renderProfile(l *layout, r *Report) {
+	p := r.Profile
+	if p == nil {
+		l.line(margin, pdf.FontRegular, 10, muted, "No suspect profile on file.")
+		return
+	}
+
+	if len(p.Portrait) > 0 {
+		if img, err := l.doc.AddImage(p.Portrait); err == nil {
+			h := portraitWidth * float64(img.Height) / float64(img.Width)
+			w := portraitWidth
+			if h > portraitMaxHeight {
+				w, h = w*portraitMaxHeight/h, portraitMaxHeight
+			}
+			l.ensure(h + 24)
+			l.page.Image(img, margin, l.y, w, h)
+			l.page.SetStrokeColor(rule[0], rule[1], rule[2])
+			l.page.Rect(margin, l.y, w, h, false)
+			l.y += h + 4
+			l.line(margin, pdf.FontRegular, 8, muted, "Generated composite from witness descriptions, not a photograph.")
+		} else {
+			l.line(margin, pdf.FontRegular, 9, muted, "The portrait on file could not be embedded.")
+		}
+		l.gap(6)
+	} else if p.PortraitKey != "" {
+		l.line(margin, pdf.FontRegular, 9, muted, "The portrait on file could not be retrieved.")
+		l.gap(6)
+	}
+
+	if len(p.Attributes) == 0 {
+		l.line(margin, pdf.FontRegular, 10, muted, "No attributes recorded.")
+	}
+	for _, a := range p.Attributes {
+		l.ensure(20)
+		top := l.y
+		l.line(margin, pdf.FontBold, 10, ink, a.Name)
+		l.y = top
+		l.row(pdf.FontRegular, 10, ink, "", confidenceColour(a.Confidence), percent(a.Confidence))
+		l.y = top
+		l.paragraph(130, pdf.FontRegular, 10, ink, a.Value)
+		if len(a.Conflicts) > 0 {
+			l.paragraph(130, pdf.FontRegular, 8, red, "Disputed by "+strings.Join(a.Conflicts, ", "))
+		}
+		l.gap(3)
+	}
+	if !p.UpdatedAt.IsZero() {
+		l.gap(4)
+		l.line(margin, pdf.FontRegular, 8, muted, "Profile updated "+p.UpdatedAt.Format("January 2, 2006 3:04 PM"))
+	}
+}
+
+func renderTrajectories(l *layout, r *Report) {
+	if len(r.Trajectories) == 0 {
+		l.line(margin, pdf.FontRegular, 10, muted, "No trajectory hypotheses generated.")
+		return
+	}
+	for _, t := range r.Trajectories {
+		l.ensure(50)
+		l.row(pdf.FontBold, 11, ink, fmt.Sprintf("Trajectory %d", t.Rank),
+			confidenceColour(t.OverallConfidence), percent(t.OverallConfidence)+" confidence")
+		var notes []string
+		if t.Score != nil {
+			notes = append(notes, fmt.Sprintf("model confidence %s, rank %d", percent(t.Score.ModelConfidence), t.Score.ModelRank))
+		}
+		if c := t.ConstraintReport; c != nil {
+			notes = append(notes, fmt.Sprintf("%d constraints satisfied, %d violated", c.Satisfied, c.Violated))
+		}
+		if g := t.Grounding; g != nil {
+			notes = append(notes, fmt.Sprintf("%s of evidence references grounded", percent(g.Ratio)))
+		}
+		if len(notes) > 0 {
+			l.paragraph(0, pdf.FontRegular, 8, muted, strings.Join(notes, " · "))
+		}
+
+		for i, seg := range t.Segments {
+			l.ensure(30)
+			heading := fmt.Sprintf("%d.  %s to %s", i+1, position(seg.FromPosition), position(seg.ToPosition))
+			if seg.TimeEstimate != nil {
+				heading += fmt.Sprintf(", %s – %s", seg.TimeEstimate.Start, seg.TimeEstimate.End)
+			}
+			l.row(pdf.FontBold, 9, ink, heading, confidenceColour(seg.Confidence), percent(seg.Confidence))
+			if seg.Explanation != "" {
+				l.paragraph(14, pdf.FontRegular, 9, ink, seg.Explanation)
+			}
+			if f := seg.Feasibility; f != nil {
+				text := "Feasibility: " + strings.ReplaceAll(string(f.Status), "_", " ")
+				if f.Reason != "" {
+					text += ". " + f.Reason
+				}
+				l.paragraph(14, pdf.FontRegular, 8, feasibilityColour(f.Status), text)
+			}
+			l.gap(2)
+		}
+		l.gap(8)
+	}
+}
+
+func renderParadoxes(l *layout, r *Report) {
+	if len(r.Paradoxes) == 0 {
+		l.line(margin, pdf.FontRegular, 10, muted, "No contradictions found.")
+		return
+	}
+	for _, p := range r.Paradoxes {
+		l.ensure(28)
+		label := strings.ToUpper(string(p.Kind[:1])) + string(p.Kind[1:])
+		l.row(pdf.FontBold, 10, ink, p.Subject, red, label)
+		l.paragraph(0, pdf.FontRegular, 9, ink, p.Detail)
+		l.gap(5)
+	}
+}
+
+func renderConstraints(l *layout, r *Report) {
+	c := r.Constraints
+	if c == nil {
+		l.line(margin, pdf.FontRegular, 10, muted, "No scene constraints recorded.")
+		return
+	}
+	l.line(margin, pdf.FontRegular, 10, muted, fmt.Sprintf("Suspect profile: %d satisfied, %d violated", c.Satisfied, c.Violated))
+	l.gap(4)
+	for _, res := range c.Results {
+		l.ensure(24)
+		status := strings.ReplaceAll(string(res.Status), "_", " ")
+		l.row(pdf.FontBold, 10, ink, fmt.Sprintf("%s  (%s)", res.Type, res.ConstraintID), statusColour(res.Status), status)
+		if res.Detail != "" {
+			l.paragraph(0, pdf.FontRegular, 9, muted, res.Detail)
+		}
+		l.gap(4)
+	}
+}
+
+func renderScene(l *layout, r *Report) {
+	if plan := r.FloorPlan(planPixels); plan != nil {
+		renderFloorPlan(l, plan)
+	}
+	l.line(margin, pdf.FontRegular, 10, muted, fmt.Sprintf("Objects detected: %d", len(r.Objects)))
+	l.gap(4)
+	for _, obj := range r.Objects {
+		l.row(pdf.FontRegular, 10, ink, obj.Label, muted, string(obj.Type))
+	}
+}
+
+// renderFloorPlan embeds the plan across the content width, followed by the
+// key to its evidence markers
+func renderFloorPlan(l *layout, plan *floorplan.Plan) {
+	data, err := plan.PNG()
+	if err != nil {
+		return
+	}
+	img, err := l.doc.AddImage(data)
+	if err != nil {
+		l.line(margin, pdf.FontRegular, 9, muted, "The floor plan could not be embedded.")
+		l.gap(6)
+		return
+	}
+	w := l.width - 2*margin
+	h := w * float64(img.Height) / float64(img.Width)
+	if h > planMaxHeight {
+		w, h = w*planMaxHeight/h, planMaxHeight
+	}
+	l.ensure(h + 20)
+	l.page.Image(img, margin, l.y, w, h)
+	l.page.SetStrokeColor(rule[0], rule[1], rule[2])
+	l.page.Rect(margin, l.y, w, h, false)
+	l.y += h + 4
+	l.line(margin, pdf.FontRegular, 8, muted, "Floor plan from the reconstructed scene, viewed from above.")
+	for _, m := range plan.Markers {
+		l.row(pdf.FontRegular, 9, ink, fmt.Sprintf("%d. %s", m.Number, m.Title), confidenceColour(tierConfidence[m.Tier]), string(m.Tier))
+	}
+	l.gap(8)
+}
+
+func renderCustody(l *layout, r *Report) {
+	if len(r.Custody) == 0 {
+		l.line(margin, pdf.FontRegular, 10, muted, "No files on record.")
+		return
+	}
+	for _, e := range r.Custody {
+		l.ensure(30)
+		colour := accent
+		if e.Action == "stored" {
+			colour = green
+		}
+		l.row(pdf.FontBold, 9, ink, e.Time.Format("Jan 2, 2006 3:04 PM"), colour, e.Action)
+		l.paragraph(12, pdf.FontRegular, 9, ink, e.StorageKey)
+		detail := e.Detail
+		if e.By != nil {
+			detail = strings.TrimSpace(detail + " (by " + e.By.String() + ")")
+		}
+		if detail != "" {
+			l.paragraph(12, pdf.FontRegular, 8, muted, detail)
+		}
+		l.gap(3)
+	}
+}
+
+func renderAudit(l *layout, r *Report) {
+	if len(r.Audit) == 0 {
+		l.line(margin, pdf.FontRegular, 10, muted, "No actions on record.")
+		return
+	}
+	for _, e := range r.Audit {
+		l.ensure(30)
+		l.row(pdf.FontBold, 9, ink, e.CreatedAt.Format("Jan 2, 2006 3:04:05 PM")+"  "+e.Action, outcomeColour(e.Outcome), string(e.Outcome))
+		if target := auditTarget(e); target != "" {
+			l.paragraph(12, pdf.FontRegular, 9, ink, target)
+		}
+		l.paragraph(12, pdf.FontRegular, 8, muted, auditDetail(e))
+		l.gap(3)
+	}
+}
+
+// auditTarget describes what an audited action was taken on
+func auditTarget(e models.AuditEntry) string {
+	if e.TargetType == "" {
+		return e.Detail
+	}
+	target := e.TargetType + " " + e.TargetID
+	if e.Detail != "" {
+		target += ": " + e.Detail
+	}
+	return target
+}
+
+// auditDetail describes who took an audited action and how
+func auditDetail(e models.AuditEntry) string {
+	actor := "unauthenticated"
+	if e.ActorID != nil {
+		actor = e.ActorID.String()
+	}
+	parts := []string{string(e.ActorType) + " " + actor}
+	if e.JobID != nil {
+		parts = append(parts, "job "+e.JobID.String())
+	}
+	if e.Route != "" {
+		parts = append(parts, fmt.Sprintf("%s %s (%d)", e.Method, e.Route, e.Status))
+	}
+	if e.RequestID != "" {
+		parts = append(parts, "request "+e.RequestID)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func outcomeColour(o models.AuditOutcome) colour {
+	switch o {
+	case models.AuditOutcomeSuccess:
+		return green
+	case models.AuditOutcomeDenied:
+		return red
+	}
+	return amber
+}
+
+func percent(v float64) string {
+	return fmt.Sprintf("%.0f%%", v*100)
+}
+
+func position(p [3]float64) string {
+	return fmt.Sprintf("(%.1f, %.1f, %.1f)", p[0], p[1], p[2])
+}
+
+func confidenceColour(v float64) colour {
+	switch {
+	case v >= 0.8:
+		return green
+	case v >= 0.5:
+		return amber
+	}
+	return muted
+}
+
+func statusColour(s models.ConstraintStatus) colour {
+	switch s {
+	case models.ConstraintStatusSatisfied:
+		return green
+	case models.ConstraintStatusViolated:
+		return red
+	}
+	return muted
+}
+
+func feasibilityColour(s models.FeasibilityStatus) colour {
+	switch s {
+	case models.FeasibilityStatusFeasible:
+		return green
+	case models.FeasibilityStatusDetourRequired:
+		return amber
+	}
+	return red
+}
+
+// layout flows content down the pages, starting a new page when the next
+// block would run into the bottom margin. y is the top of the free space.
+type layout struct {
+	doc           *pdf.Document
+	page          *pdf.Page
+	y             float64
+	width, height float64
+}
+
+func (l *layout) newPage() {
+	l.width, l.height = l.doc.Size()
+	l.page = l.doc.AddPage()
+	l.y = marginTop
+}
+
+// ensure starts a new page unless h points fit above the bottom margin
+func (l *layout) ensure(h float64) {
+	if l.y+h > l.height-marginBottom {
+		l.newPage()
+	}
+}
+
+func (l *layout) gap(h float64) {
+	l.y += h
+}
+
+// line draws one line of text at x
+func (l *layout) line(x float64, f pdf.Font, size float64, c colour, s string) {
+	lead := math.Ceil(size * 1.4)
+	l.ensure(lead)
+	l.page.SetFillColor(c[0], c[1], c[2])
+	l.page.Text(x, l.y+size, f, size, s)
+	l.y += lead
+}
+
+// paragraph wraps text to the content width, less the indent
+func (l *layout) paragraph(indent float64, f pdf.Font, size float64, c colour, s string) {
+	for _, text := range pdf.WrapText(f, size, s, l.width-2*margin-indent) {
+		l.line(margin+indent, f, size, c, text)
+	}
+}
+
+// row draws text on the left and a short note right-aligned on the same line,
+// shortening the left text if they would overlap
+func (l *layout) row(f pdf.Font, size float64, c colour, left string, noteColour colour, note string) {
+	noteWidth := pdf.TextWidth(pdf.FontRegular, size, note)
+	room := l.width - 2*margin - noteWidth - 12
+	if pdf.TextWidth(f, size, left) > room {
+		lines := pdf.WrapText(f, size, left, room)
+		left = strings.TrimSpace(lines[0]) + "…"
+	}
+
+	lead := math.Ceil(size * 1.4)
+	l.ensure(lead)
+	l.page.SetFillColor(noteColour[0], noteColour[1], noteColour[2])
+	l.page.Text(l.width-margin-noteWidth, l.y+size, pdf.FontRegular, size, note)
+	l.line(margin, f, size, c, left)
+}
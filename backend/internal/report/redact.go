@@ -0,0 +1,208 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// redactedName replaces names given explicitly when no replacement is set
+const redactedName = "[redacted]"
+
+// redaction replaces one name wherever it appears as a whole word
+type redaction struct {
+	pattern     *regexp.Regexp // matches the name in plain text
+	jsonPattern *regexp.Regexp // matches the name as escaped inside a JSON string
+	to          string
+	jsonTo      string
+}
+
+// Redact hides names throughout the report according to the rules. Witnesses
+// named in witness statements or as sources of profile attributes become
+// "Witness A", "Witness B", ... in the order they first appear, unless the
+// rules give a fixed replacement. The report's case is copied, not modified.
+func (r *Report) Redact(rules *models.RedactionRules) {
+	if rules == nil {
+		return
+	}
+
+	replacements := map[string]string{}
+	var names []string
+	add := func(name, to string) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return
+		}
+		if _, ok := replacements[strings.ToLower(name)]; ok {
+			return
+		}
+		if rules.Replacement != "" {
+			to = rules.Replacement
+		}
+		replacements[strings.ToLower(name)] = to
+		names = append(names, name)
+	}
+	if rules.WitnessNames {
+		for _, name := range r.witnessNames() {
+			add(name, witnessLabel(len(names)))
+		}
+	}
+	for _, name := range rules.Names {
+		add(name, redactedName)
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	// Longer names first, so a full name wins over a first name it contains
+	sort.SliceStable(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	var rs []redaction
+	for _, name := range names {
+		to := replacements[strings.ToLower(name)]
+		rs = append(rs, redaction{
+			pattern:     wordPattern(name),
+			jsonPattern: wordPattern(jsonEscape(name)),
+			to:          to,
+			jsonTo:      jsonEscape(to),
+		})
+	}
+	text := func(s string) string {
+		for _, rd := range rs {
+			s = rd.pattern.ReplaceAllLiteralString(s, rd.to)
+		}
+		return s
+	}
+	// Structured values are redacted in their JSON form and decoded into a
+	// fresh value, so data shared with the caller isn't changed
+	structured := func(v interface{}) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return
+		}
+		for _, rd := range rs {
+			data = rd.jsonPattern.ReplaceAllLiteral(data, []byte(rd.jsonTo))
+		}
+		target := reflect.ValueOf(v).Elem()
+		fresh := reflect.New(target.Type())
+		if err := json.Unmarshal(data, fresh.Interface()); err == nil {
+			target.Set(fresh.Elem())
+		}
+	}
+
+	if r.Case != nil {
+		c := *r.Case
+		c.Title, c.Description = text(c.Title), text(c.Description)
+		r.Case = &c
+	}
+	r.Scope = text(r.Scope)
+	timeline := make([]models.Commit, len(r.Timeline))
+	for i, c := range r.Timeline {
+		c.Summary = text(c.Summary)
+		payload := []byte(c.Payload)
+		for _, rd := range rs {
+			payload = rd.jsonPattern.ReplaceAllLiteral(payload, []byte(rd.jsonTo))
+		}
+		c.Payload = payload
+		timeline[i] = c
+	}
+	r.Timeline = timeline
+	structured(&r.Evidence)
+	structured(&r.Objects)
+	structured(&r.Uncertainty)
+	structured(&r.Trajectories)
+	structured(&r.Constraints)
+	if r.Profile != nil {
+		p := *r.Profile
+		p.Attributes = make([]Attribute, len(r.Profile.Attributes))
+		for i, a := range r.Profile.Attributes {
+			a.Value = text(a.Value)
+			var conflicts []string
+			for _, c := range a.Conflicts {
+				conflicts = append(conflicts, text(c))
+			}
+			a.Conflicts = conflicts
+			p.Attributes[i] = a
+		}
+		r.Profile = &p
+	}
+	paradoxes := make([]Paradox, len(r.Paradoxes))
+	for i, p := range r.Paradoxes {
+		paradoxes[i] = Paradox{Kind: p.Kind, Subject: text(p.Subject), Detail: text(p.Detail)}
+	}
+	r.Paradoxes = paradoxes
+	custody := make([]CustodyEntry, len(r.Custody))
+	for i, e := range r.Custody {
+		e.Detail = text(e.Detail)
+		custody[i] = e
+	}
+	r.Custody = custody
+	audit := make([]models.AuditEntry, len(r.Audit))
+	for i, e := range r.Audit {
+		e.Detail = text(e.Detail)
+		audit[i] = e
+	}
+	r.Audit = audit
+	r.Redacted = true
+}
+
+// witnessNames lists witnesses in the order they appear: in witness
+// statements on the timeline, then as sources of profile attributes
+func (r *Report) witnessNames() []string {
+	var names []string
+	for _, c := range r.Timeline {
+		if c.Type != models.CommitTypeWitnessStatement {
+			continue
+		}
+		var payload struct {
+			Statements []models.WitnessStatementInput `json:"statements"`
+		}
+		if err := json.Unmarshal(c.Payload, &payload); err != nil {
+			continue
+		}
+		for _, s := range payload.Statements {
+			names = append(names, s.SourceName)
+		}
+	}
+	if r.Profile != nil {
+		for _, a := range r.Profile.Attributes {
+			names = append(names, a.Conflicts...)
+		}
+	}
+	return names
+}
+
+// witnessLabel names the i-th witness: Witness A to Witness Z, then numbers
+func witnessLabel(i int) string {
+	if i < 26 {
+		return "Witness " + string(rune('A'+i))
+	}
+	return fmt.Sprintf("Witness %d", i+1)
+}
+
+// wordPattern matches s case-insensitively, but not as part of a longer word
+func wordPattern(s string) *regexp.Regexp {
+	p := regexp.QuoteMeta(s)
+	if isWordByte(s[0]) {
+		p = `\b` + p
+	}
+	if isWordByte(s[len(s)-1]) {
+		p += `\b`
+	}
+	return regexp.MustCompile(`(?i)` + p)
+}
+
+// isWordByte reports whether \b treats c as part of a word
+func isWordByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// jsonEscape returns s as it appears inside a JSON string
+func jsonEscape(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data[1 : len(data)-1])
+}
@@ -0,0 +1,341 @@
+// Package report gathers a case's timeline, scene, suspect profile and
+// reasoning results into the contents of an exported case report, and lays
+// them out as a paginated PDF or through a named HTML template. Sections can
+// be chosen per report, and witness names redacted. The scene section
+// includes a floor plan of the scene.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sherlockos/backend/internal/constraints"
+	"github.com/sherlockos/backend/internal/floorplan"
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// ParadoxKind groups contradictions by what disagrees
+type ParadoxKind string
+
+// Paradox kinds
+const (
+	ParadoxTemporal  ParadoxKind = "temporal"  // a route can't be covered in the time available
+	ParadoxSpatial   ParadoxKind = "spatial"   // a route or claim conflicts with the scene geometry
+	ParadoxEvidence  ParadoxKind = "evidence"  // sources disagree about an item, or evidence contradicts a route
+	ParadoxTestimony ParadoxKind = "testimony" // witness accounts disagree with each other or with the scene
+)
+
+// Paradox is one contradiction found in the case
+type Paradox struct {
+	Kind    ParadoxKind
+	Subject string
+	Detail  string
+}
+
+// Attribute is one row of the suspect profile
+type Attribute struct {
+	Name       string
+	Value      string
+	Confidence float64
+	Conflicts  []string // sources whose accounts disagree
+}
+
+// Profile is the suspect profile section
+type Profile struct {
+	Attributes  []Attribute
+	PortraitKey string
+	Portrait    []byte // image data, when it could be fetched
+	UpdatedAt   time.Time
+}
+
+// Report is everything an exported case report contains
+type Report struct {
+	Case         *models.Case
+	GeneratedAt  time.Time
+	Scope        string                 // what the report covers when it isn't the whole case, e.g. one branch
+	Sections     []models.ReportSection // the sections to include
+	Redacted     bool                   // names were redacted
+	Timeline     []models.Commit        // oldest first
+	Evidence     []models.EvidenceCard
+	Objects      []models.SceneObject
+	Uncertainty  []models.UncertaintyRegion
+	Profile      *Profile
+	Trajectories []models.Trajectory // from the latest reasoning result
+	Paradoxes    []Paradox
+	Constraints  *models.ConstraintReport // the suspect profile checked against the scene's constraints
+	Custody      []CustodyEntry
+	Audit        []models.AuditEntry // the case's audit trail, oldest first
+
+	// EvidenceTiers limits the evidence section to these tiers; empty shows all
+	EvidenceTiers []models.EvidenceTier
+}
+
+// EvidenceGroup is the evidence in one confidence tier
+type EvidenceGroup struct {
+	Tier     models.EvidenceTier
+	Evidence []models.EvidenceCard
+}
+
+// Build collects the report contents. Commits may be in any order; profile and
+// snapshot may be nil.
+func Build(caseData *models.Case, commits []models.Commit, snapshot *models.SceneSnapshot, profile *models.SuspectProfile, now time.Time) *Report {
+	r := &Report{Case: caseData, GeneratedAt: now, Sections: models.DefaultReportSections()}
+
+	r.Timeline = append(r.Timeline, commits...)
+	sort.SliceStable(r.Timeline, func(i, j int) bool {
+		return r.Timeline[i].CreatedAt.Before(r.Timeline[j].CreatedAt)
+	})
+	r.Trajectories = LatestTrajectories(r.Timeline)
+
+	var sg *models.SceneGraph
+	if snapshot != nil {
+		sg = snapshot.Scenegraph
+	}
+	if sg != nil {
+		r.Evidence = sg.Evidence
+		r.Objects = sg.Objects
+		r.Uncertainty = sg.UncertaintyRegions
+	}
+
+	var attrs *models.SuspectAttributes
+	if profile != nil {
+		attrs = profile.Attributes
+		r.Profile = &Profile{
+			Attributes:  attributes(attrs),
+			PortraitKey: profile.PortraitAssetKey,
+			UpdatedAt:   profile.UpdatedAt,
+		}
+	}
+	if sg != nil && len(sg.Constraints) > 0 {
+		r.Constraints = constraints.NewEvaluator(sg, nil).EvaluateSuspect(attrs)
+	}
+
+	r.Paradoxes = r.paradoxes()
+	return r
+}
+
+// Has reports whether the section is included
+func (r *Report) Has(section models.ReportSection) bool {
+	for _, s := range r.Sections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// FloorPlan lays out the floor plan of the report's scene at the given
+// width, with the trajectories when that section is included. It returns
+// nil when the scene has nothing to draw.
+func (r *Report) FloorPlan(width int) *floorplan.Plan {
+	sg := &models.SceneGraph{Objects: r.Objects, Evidence: r.Evidence, UncertaintyRegions: r.Uncertainty}
+	opts := floorplan.DefaultOptions()
+	opts.Width = width
+	opts.Trajectories = r.Has(models.ReportSectionTrajectories)
+	plan, err := floorplan.Build(sg, r.Trajectories, opts)
+	if err != nil {
+		return nil
+	}
+	return plan
+}
+
+// EvidenceByTier groups the evidence from the highest tier down, leaving out
+// empty tiers and any not selected
+func (r *Report) EvidenceByTier() []EvidenceGroup {
+	var groups []EvidenceGroup
+	for _, tier := range []models.EvidenceTier{models.EvidenceTierHigh, models.EvidenceTierMedium, models.EvidenceTierLow} {
+		if !r.showsTier(tier) {
+			continue
+		}
+		group := EvidenceGroup{Tier: tier}
+		for _, ev := range r.Evidence {
+			if models.EvidenceTierFor(ev.Confidence) == tier {
+				group.Evidence = append(group.Evidence, ev)
+			}
+		}
+		if len(group.Evidence) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+func (r *Report) showsTier(tier models.EvidenceTier) bool {
+	if len(r.EvidenceTiers) == 0 {
+		return true
+	}
+	for _, t := range r.EvidenceTiers {
+		if t == tier {
+			return true
+		}
+	}
+	return false
+}
+
+// LatestTrajectories returns the trajectories of the newest reasoning result
+// on a timeline ordered oldest first, in rank order
+func LatestTrajectories(timeline []models.Commit) []models.Trajectory {
+	for i := len(timeline) - 1; i >= 0; i-- {
+		if timeline[i].Type != models.CommitTypeReasoningResult {
+			continue
+		}
+		var payload struct {
+			Trajectories []models.Trajectory `json:"trajectories"`
+		}
+		if err := json.Unmarshal(timeline[i].Payload, &payload); err != nil {
+			continue
+		}
+		sort.SliceStable(payload.Trajectories, func(a, b int) bool {
+			return payload.Trajectories[a].Rank < payload.Trajectories[b].Rank
+		})
+		return payload.Trajectories
+	}
+	return nil
+}
+
+// attributes flattens the suspect attributes into rows, in a fixed order
+func attributes(a *models.SuspectAttributes) []Attribute {
+	if a == nil {
+		return nil
+	}
+	var rows []Attribute
+	rangeRow := func(name, unit string, r *models.RangeAttribute) {
+		if r != nil {
+			rows = append(rows, Attribute{
+				Name: name, Value: fmt.Sprintf("%g–%g%s", r.Min, r.Max, unit),
+				Confidence: r.Confidence, Conflicts: sourceNames(r.ConflictSources),
+			})
+		}
+	}
+	stringRow := func(name string, s *models.StringAttribute) {
+		if s != nil && s.Value != "" {
+			rows = append(rows, Attribute{
+				Name: name, Value: s.Value, Confidence: s.Confidence, Conflicts: sourceNames(s.ConflictSources),
+			})
+		}
+	}
+
+	rangeRow("Age", " years", a.AgeRange)
+	rangeRow("Height", " cm", a.HeightRangeCm)
+	stringRow("Build", a.Build)
+	stringRow("Skin tone", a.SkinTone)
+	if a.Hair != nil {
+		rows = append(rows, Attribute{
+			Name: "Hair", Value: strings.TrimSpace(a.Hair.Color + " " + a.Hair.Style),
+			Confidence: a.Hair.Confidence, Conflicts: sourceNames(a.Hair.ConflictSources),
+		})
+	}
+	stringRow("Facial hair", a.FacialHair)
+	stringRow("Glasses", a.Glasses)
+	for _, f := range a.DistinctiveFeatures {
+		rows = append(rows, Attribute{Name: "Distinctive feature", Value: f.Description, Confidence: f.Confidence})
+	}
+	return rows
+}
+
+func sourceNames(sources []models.AttributeSource) []string {
+	var names []string
+	for _, s := range sources {
+		names = append(names, s.SourceName)
+	}
+	return names
+}
+
+// paradoxes gathers every contradiction the other sections record: infeasible
+// and contradicted route segments, violated constraints, and disagreeing
+// sources
+func (r *Report) paradoxes() []Paradox {
+	var out []Paradox
+
+	for _, t := range r.Trajectories {
+		for i, seg := range t.Segments {
+			subject := fmt.Sprintf("Trajectory %d, segment %d", t.Rank, i+1)
+			if f := seg.Feasibility; f != nil && f.Status == models.FeasibilityStatusInfeasible {
+				kind := ParadoxSpatial
+				if f.PathLength > 0 {
+					// Reachable, so it's the time window that rules it out
+					kind = ParadoxTemporal
+				}
+				out = append(out, Paradox{Kind: kind, Subject: subject, Detail: orDefault(f.Reason, "route is not physically possible")})
+			}
+			for _, ref := range seg.EvidenceRefs {
+				if ref.Relevance == "contradicts" {
+					out = append(out, Paradox{
+						Kind: ParadoxEvidence, Subject: subject,
+						Detail: fmt.Sprintf("contradicted by evidence %s", r.evidenceTitle(ref.EvidenceID)),
+					})
+				}
+			}
+		}
+		if t.ConstraintReport != nil {
+			for _, res := range t.ConstraintReport.Results {
+				if res.Status != models.ConstraintStatusViolated {
+					continue
+				}
+				kind := ParadoxSpatial
+				if res.Type == models.ConstraintTypeTimeWindow {
+					kind = ParadoxTemporal
+				}
+				out = append(out, Paradox{
+					Kind: kind, Subject: fmt.Sprintf("Trajectory %d", t.Rank),
+					Detail: orDefault(res.Detail, fmt.Sprintf("violates %s constraint %s", res.Type, res.ConstraintID)),
+				})
+			}
+		}
+	}
+
+	if r.Constraints != nil {
+		for _, res := range r.Constraints.Results {
+			if res.Status == models.ConstraintStatusViolated {
+				out = append(out, Paradox{
+					Kind: ParadoxTestimony, Subject: "Suspect profile",
+					Detail: orDefault(res.Detail, fmt.Sprintf("violates %s constraint %s", res.Type, res.ConstraintID)),
+				})
+			}
+		}
+	}
+	if r.Profile != nil {
+		for _, a := range r.Profile.Attributes {
+			if len(a.Conflicts) > 0 {
+				out = append(out, Paradox{
+					Kind: ParadoxTestimony, Subject: "Suspect " + strings.ToLower(a.Name),
+					Detail: fmt.Sprintf("%s disputed by %s", a.Value, strings.Join(a.Conflicts, ", ")),
+				})
+			}
+		}
+	}
+
+	for _, ev := range r.Evidence {
+		if len(ev.Conflicts) == 0 {
+			continue
+		}
+		var accounts []string
+		for _, c := range ev.Conflicts {
+			accounts = append(accounts, orDefault(c.Description, string(c.Type)))
+		}
+		out = append(out, Paradox{
+			Kind: ParadoxEvidence, Subject: ev.Title,
+			Detail: "conflicting sources: " + strings.Join(accounts, "; "),
+		})
+	}
+	return out
+}
+
+func (r *Report) evidenceTitle(id string) string {
+	for _, ev := range r.Evidence {
+		if ev.ID == id {
+			return fmt.Sprintf("%q", ev.Title)
+		}
+	}
+	return id
+}
+
+func orDefault(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
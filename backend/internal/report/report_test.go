@@ -0,0 +1,393 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+var now = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func commit(t *testing.T, typ models.CommitType, at time.Time, payload interface{}) models.Commit {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return models.Commit{ID: uuid.New(), Type: typ, Summary: string(typ), Payload: data, CreatedAt: at}
+}
+
+func testCase(t *testing.T) (*models.Case, []models.Commit, *models.SceneSnapshot, *models.SuspectProfile) {
+	t.Helper()
+	height, err := models.NewConstraint("height", models.ConstraintTypeHeightRange, "Height from shoe prints",
+		&models.HeightRangeParams{MinCm: 175, MaxCm: 190}, 0.7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sg := models.NewEmptySceneGraph()
+	sg.Constraints = []models.Constraint{*height}
+	sg.Objects = []models.SceneObject{{ID: "knife", Type: models.ObjectTypeWeapon, Label: "Kitchen knife"}}
+	sg.Evidence = []models.EvidenceCard{
+		{ID: "ev_knife", ObjectIDs: []string{"knife"}, Title: "Knife", Confidence: 0.9, Sources: []models.EvidenceSource{{Type: models.EvidenceSourceTypeUpload}}},
+		{ID: "ev_door", Title: "Back door", Confidence: 0.4,
+			Conflicts: []models.EvidenceSource{{Type: models.EvidenceSourceTypeWitness, Description: "neighbour says it was locked"}}},
+	}
+
+	older := []models.Trajectory{{ID: "old", Rank: 1}}
+	latest := []models.Trajectory{
+		{ID: "second", Rank: 2, OverallConfidence: 0.4},
+		{ID: "first", Rank: 1, OverallConfidence: 0.8, Segments: []models.TrajectorySegment{
+			{ID: "s1", Explanation: "Through the back door",
+				EvidenceRefs: []models.EvidenceRef{{EvidenceID: "ev_door", Relevance: "contradicts"}},
+				Feasibility:  &models.SegmentFeasibility{Status: models.FeasibilityStatusInfeasible, PathLength: 12, Reason: "needs 9 s running, 4 s available"}},
+			{ID: "s2", Feasibility: &models.SegmentFeasibility{Status: models.FeasibilityStatusInfeasible, Reason: "no walkable route"}},
+		}},
+	}
+	// Newest first, as the repository returns them
+	commits := []models.Commit{
+		commit(t, models.CommitTypeReasoningResult, now.Add(-time.Hour), map[string]interface{}{"trajectories": latest}),
+		commit(t, models.CommitTypeReasoningResult, now.Add(-2*time.Hour), map[string]interface{}{"trajectories": older}),
+		commit(t, models.CommitTypeUploadScan, now.Add(-3*time.Hour), map[string]interface{}{}),
+	}
+
+	profile := &models.SuspectProfile{
+		PortraitAssetKey: "cases/x/portrait.png",
+		Attributes: &models.SuspectAttributes{
+			HeightRangeCm: &models.RangeAttribute{Min: 160, Max: 168, Confidence: 0.6,
+				ConflictSources: []models.AttributeSource{{SourceName: "Witness B"}}},
+			Build: &models.StringAttribute{Value: "slim", Confidence: 0.7},
+			Hair:  &models.HairAttribute{Style: "short", Color: "dark", Confidence: 0.5},
+		},
+	}
+	return &models.Case{ID: uuid.New(), Title: "Warehouse break-in", CreatedAt: now.Add(-24 * time.Hour)},
+		commits, &models.SceneSnapshot{Scenegraph: sg}, profile
+}
+
+func build(t *testing.T) *Report {
+	c, commits, snapshot, profile := testCase(t)
+	return Build(c, commits, snapshot, profile, now)
+}
+
+func TestBuild(t *testing.T) {
+	r := build(t)
+	if len(r.Evidence) != 2 || len(r.Objects) != 1 {
+		t.Errorf("Evidence = %d items, Objects = %d", len(r.Evidence), len(r.Objects))
+	}
+	if r.Timeline[0].Type != models.CommitTypeUploadScan || r.Timeline[2].Type != models.CommitTypeReasoningResult {
+		t.Errorf("Timeline should be oldest first, got %s … %s", r.Timeline[0].Type, r.Timeline[2].Type)
+	}
+	if len(r.Trajectories) != 2 || r.Trajectories[0].ID != "first" {
+		t.Errorf("Trajectories should come from the latest reasoning result in rank order, got %+v", r.Trajectories)
+	}
+
+	var names []string
+	for _, a := range r.Profile.Attributes {
+		names = append(names, a.Name+"="+a.Value)
+	}
+	if got := strings.Join(names, ", "); got != "Height=160–168 cm, Build=slim, Hair=dark short" {
+		t.Errorf("Attributes = %s", got)
+	}
+	if r.Constraints == nil || r.Constraints.Violated != 1 {
+		t.Errorf("Constraints = %+v", r.Constraints)
+	}
+
+	var kinds []string
+	for _, p := range r.Paradoxes {
+		kinds = append(kinds, fmt.Sprintf("%s:%s", p.Kind, p.Subject))
+	}
+	want := []string{
+		"temporal:Trajectory 1, segment 1",
+		"evidence:Trajectory 1, segment 1",
+		"spatial:Trajectory 1, segment 2",
+		"testimony:Suspect profile",
+		"testimony:Suspect height",
+		"evidence:Back door",
+	}
+	if strings.Join(kinds, "; ") != strings.Join(want, "; ") {
+		t.Errorf("Paradoxes = %v, want %v", kinds, want)
+	}
+	if d := r.Paradoxes[1].Detail; d != `contradicted by evidence "Back door"` {
+		t.Errorf("evidence paradox detail = %q", d)
+	}
+}
+
+func TestBuild_Empty(t *testing.T) {
+	r := Build(&models.Case{Title: "Empty"}, nil, nil, nil, now)
+	if r.Profile != nil || r.Constraints != nil || len(r.Trajectories) != 0 || len(r.Paradoxes) != 0 {
+		t.Errorf("Build() of an empty case = %+v", r)
+	}
+	if out := RenderPDF(r); !bytes.Contains(out, []byte("(No events recorded.)")) {
+		t.Error("empty report should say there are no events")
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	r := build(t)
+	var portrait bytes.Buffer
+	png.Encode(&portrait, image.NewGray(image.Rect(0, 0, 30, 40)))
+	r.Profile.Portrait = portrait.Bytes()
+
+	// Enough timeline to run past the first section page
+	for i := 0; i < 60; i++ {
+		r.Timeline = append(r.Timeline, models.Commit{Type: models.CommitTypeWitnessStatement, Summary: "Statement", CreatedAt: now})
+	}
+
+	out := RenderPDF(r)
+	if !bytes.HasPrefix(out, []byte("%PDF-")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatal("output is not a PDF")
+	}
+	pages := bytes.Count(out, []byte("/Type /Page /Parent"))
+	if pages < 3 {
+		t.Fatalf("got %d pages, want a title page and at least two more", pages)
+	}
+
+	for _, want := range []string{
+		"(Warehouse break-in) Tj",
+		"(Contents) Tj",
+		"(1.  Timeline) Tj",
+		"(7.  Scene Summary) Tj",
+		fmt.Sprintf("(Page 1 of %d) Tj", pages),
+		fmt.Sprintf("(Page %d of %d) Tj", pages, pages),
+		"/Subtype /Image /Width 30 /Height 40",
+		"/Im1 Do",
+		"/Im2 Do", // the floor plan
+		"(1. Knife) Tj",
+		"(Temporal) Tj",
+		"(Disputed by Witness B) Tj",
+		"/Count 7 >>", // one bookmark per section
+	} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Errorf("PDF is missing %q", want)
+		}
+	}
+	if links := bytes.Count(out, []byte("/Subtype /Link")); links != 7 {
+		t.Errorf("contents has %d links, want 7", links)
+	}
+
+	// A portrait that can't be decoded is noted rather than failing the export
+	r.Profile.Portrait = []byte("not an image")
+	if out := RenderPDF(r); !bytes.Contains(out, []byte("(The portrait on file could not be embedded.)")) {
+		t.Error("missing note for an undecodable portrait")
+	}
+}
+
+func TestRenderPDF_Sections(t *testing.T) {
+	r := build(t)
+	r.Sections = []models.ReportSection{models.ReportSectionCustodyLog, models.ReportSectionTimeline}
+	r.Custody = []CustodyEntry{{Time: now, StorageKey: "cases/x/scan.jpg", Action: "stored", Detail: "image/jpeg"}}
+	r.Scope = "Branch \"alt\""
+
+	out := RenderPDF(r)
+	for _, want := range []string{"(1.  Timeline) Tj", "(2.  Custody Log) Tj", "(cases/x/scan.jpg) Tj", "(Scope: Branch \"alt\") Tj"} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Errorf("PDF is missing %q", want)
+		}
+	}
+	if bytes.Contains(out, []byte("(Evidence) Tj")) || bytes.Count(out, []byte("/Subtype /Link")) != 2 {
+		t.Error("PDF should only have the chosen sections, in report order")
+	}
+}
+
+func TestFloorPlan(t *testing.T) {
+	r := build(t)
+	plan := r.FloorPlan(400)
+	if plan == nil || plan.Width != 400 || len(plan.Markers) != 1 || plan.Markers[0].EvidenceID != "ev_knife" {
+		t.Fatalf("FloorPlan() = %+v", plan)
+	}
+	if !bytes.Contains(plan.SVG(), []byte(`class="trajectory rank-1"`)) {
+		t.Error("FloorPlan() should draw trajectories when the section is included")
+	}
+	r.Sections = []models.ReportSection{models.ReportSectionScene}
+	if plan := r.FloorPlan(400); plan == nil || bytes.Contains(plan.SVG(), []byte(`class="trajectory`)) {
+		t.Error("FloorPlan() should leave trajectories out with their section")
+	}
+
+	r.Objects = nil
+	if plan := r.FloorPlan(400); plan != nil {
+		t.Errorf("FloorPlan() of an empty scene = %+v, want nil", plan)
+	}
+}
+
+func TestEvidenceByTier(t *testing.T) {
+	r := build(t)
+	r.Evidence = append(r.Evidence, models.EvidenceCard{ID: "ev_cup", Title: "Cup", Confidence: 0.6})
+	var got []string
+	for _, g := range r.EvidenceByTier() {
+		got = append(got, fmt.Sprintf("%s:%d", g.Tier, len(g.Evidence)))
+	}
+	if strings.Join(got, " ") != "high:1 medium:1 low:1" {
+		t.Errorf("EvidenceByTier() = %v", got)
+	}
+
+	r.EvidenceTiers = []models.EvidenceTier{models.EvidenceTierLow}
+	if groups := r.EvidenceByTier(); len(groups) != 1 || groups[0].Evidence[0].ID != "ev_door" {
+		t.Errorf("EvidenceByTier() with only low = %+v", groups)
+	}
+}
+
+func TestCustody(t *testing.T) {
+	asset := models.Asset{ID: uuid.New(), Kind: models.AssetKindScanImage, StorageKey: "cases/x/scan.jpg",
+		Metadata: map[string]interface{}{"content_type": "image/jpeg", "size_bytes": float64(2048)}, CreatedAt: now.Add(-time.Hour)}
+	by := uuid.New()
+	timeline := []models.Commit{
+		{Type: models.CommitTypeUploadScan, Summary: "Ingested 1 scan file(s)", CreatedBy: &by, CreatedAt: now,
+			Payload: json.RawMessage(`{"assets":[{"asset_id":"` + asset.ID.String() + `"}]}`)},
+		{Type: models.CommitTypeWitnessStatement, Summary: "Statement", CreatedAt: now, Payload: json.RawMessage(`{}`)},
+	}
+
+	entries := Custody([]models.Asset{asset}, timeline)
+	if len(entries) != 2 {
+		t.Fatalf("Custody() = %+v", entries)
+	}
+	if entries[0].Action != "stored" || entries[0].Detail != "image/jpeg, 2048 bytes" {
+		t.Errorf("first entry = %+v", entries[0])
+	}
+	if entries[1].Action != "referenced" || entries[1].By == nil || *entries[1].By != by ||
+		entries[1].Detail != "upload_scan: Ingested 1 scan file(s)" {
+		t.Errorf("second entry = %+v", entries[1])
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	r := build(t)
+	r.Sections = []models.ReportSection{models.ReportSectionAuditLog}
+	actor, job := uuid.New(), uuid.New()
+	search := models.NewAuditEntry(models.AuditActorUser, &actor, "search", models.AuditOutcomeSuccess)
+	search.Method, search.Route, search.Status, search.RequestID = "GET", "/v1/search", 200, "host/abc-000001"
+	search.Detail = "warehouse knife"
+	denied := models.NewAuditEntry(models.AuditActorUser, &actor, "case.delete", models.AuditOutcomeDenied)
+	denied.SetTarget("case", r.Case.ID.String())
+	worker := models.NewAuditEntry(models.AuditActorWorker, &actor, models.AuditActionCommitCreate, models.AuditOutcomeSuccess)
+	worker.JobID = &job
+	worker.SetTarget("commit", "c1")
+	r.Audit = []models.AuditEntry{*search, *denied, *worker}
+
+	out := RenderPDF(r)
+	for _, want := range []string{"(1.  Audit Log) Tj", "(denied) Tj", "(warehouse knife) Tj", "(commit c1) Tj", "job " + job.String()} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Errorf("PDF is missing %q", want)
+		}
+	}
+
+	html, err := RenderHTML(r, DefaultTemplate())
+	if err != nil {
+		t.Fatalf("RenderHTML() error = %v", err)
+	}
+	for _, want := range []string{"Audit Log (3 entries)", `<span class="badge badge-red">denied</span>`, "GET /v1/search (200), request host/abc-000001: warehouse knife"} {
+		if !strings.Contains(string(html), want) {
+			t.Errorf("HTML is missing %q", want)
+		}
+	}
+
+	r.Redact(&models.RedactionRules{Names: []string{"warehouse"}})
+	if r.Audit[0].Detail != "[redacted] knife" || search.Detail != "warehouse knife" {
+		t.Errorf("redacted detail = %q", r.Audit[0].Detail)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	c, commits, snapshot, profile := testCase(t)
+	c.Description = "Reported by Mary Jones."
+	commits = append(commits, commit(t, models.CommitTypeWitnessStatement, now.Add(-4*time.Hour), map[string]interface{}{
+		"statements": []models.WitnessStatementInput{{SourceName: "Mary Jones", Content: "Saw a tall man"}},
+	}))
+	const conflict = "her neighbour Mary Jones says it was locked"
+	snapshot.Scenegraph.Evidence[1].Conflicts[0].Description = conflict
+	r := Build(c, commits, snapshot, profile, now)
+
+	r.Redact(&models.RedactionRules{WitnessNames: true, Names: []string{"warehouse"}})
+	if !r.Redacted {
+		t.Error("report should be marked redacted")
+	}
+	if r.Case.Description != "Reported by Witness A." || r.Case.Title != "[redacted] break-in" {
+		t.Errorf("case = %q, %q", r.Case.Title, r.Case.Description)
+	}
+	if c.Description != "Reported by Mary Jones." || snapshot.Scenegraph.Evidence[1].Conflicts[0].Description != conflict {
+		t.Error("Redact() changed the data the report was built from")
+	}
+
+	var text []string
+	for _, cm := range r.Timeline {
+		text = append(text, string(cm.Payload))
+	}
+	for _, p := range r.Paradoxes {
+		text = append(text, p.Detail)
+	}
+	text = append(text, r.Evidence[1].Conflicts[0].Description)
+	all := strings.Join(text, "\n")
+	if strings.Contains(all, "Mary Jones") {
+		t.Errorf("report still names the witness:\n%s", all)
+	}
+	if d := r.Evidence[1].Conflicts[0].Description; d != "her neighbour Witness A says it was locked" {
+		t.Errorf("evidence conflict = %q", d)
+	}
+	// Profile sources are labelled after the witnesses on the timeline
+	if got := r.Profile.Attributes[0].Conflicts[0]; got != "Witness B" {
+		t.Errorf("profile conflict source = %q", got)
+	}
+
+	fixed := Build(c, commits, snapshot, profile, now)
+	fixed.Redact(&models.RedactionRules{WitnessNames: true, Replacement: "[witness]"})
+	if fixed.Case.Description != "Reported by [witness]." {
+		t.Errorf("description with a fixed replacement = %q", fixed.Case.Description)
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	r := build(t)
+	r.Sections = []models.ReportSection{models.ReportSectionEvidence, models.ReportSectionParadoxes}
+	out, err := RenderHTML(r, DefaultTemplate())
+	if err != nil {
+		t.Fatalf("RenderHTML() error = %v", err)
+	}
+	html := string(out)
+	for _, want := range []string{"<h3>High confidence (1)</h3>", "<h3>Low confidence (1)</h3>", "Paradoxes (6)"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML is missing %q", want)
+		}
+	}
+	if strings.Contains(html, "Timeline (") || strings.Contains(html, "Suspect Profile") || strings.Contains(html, "<svg") {
+		t.Error("HTML includes sections that weren't chosen")
+	}
+
+	r.Sections = []models.ReportSection{models.ReportSectionScene}
+	out, err = RenderHTML(r, DefaultTemplate())
+	if err != nil {
+		t.Fatalf("RenderHTML() error = %v", err)
+	}
+	html = string(out)
+	if !strings.Contains(html, `<svg xmlns="http://www.w3.org/2000/svg" width="900"`) || !strings.Contains(html, `<span class="badge badge-green">1</span> Knife`) {
+		t.Error("scene section is missing the inline floor plan and its key")
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "brief"+TemplateExt), []byte(`{{.Case.Title}}{{if has "timeline"}} with timeline{{end}}`), 0o644)
+	body, err := LoadTemplateFile(dir, "brief")
+	if err != nil {
+		t.Fatalf("LoadTemplateFile() error = %v", err)
+	}
+	tmpl, err := ParseTemplate("brief", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := RenderHTML(r, tmpl); string(out) != "Warehouse break-in" {
+		t.Errorf("custom template rendered %q", out)
+	}
+	if _, err := LoadTemplateFile(dir, "missing"); err != ErrTemplateNotFound {
+		t.Errorf("LoadTemplateFile() of a missing template error = %v", err)
+	}
+	if _, err := LoadTemplateFile(dir, "../brief"); err != ErrTemplateNotFound {
+		t.Errorf("LoadTemplateFile() outside the directory error = %v", err)
+	}
+}
@@ -0,0 +1,268 @@
+// Package scoring recomputes trajectory confidences deterministically from
+// the case's evidence, the scene's walkable space and its constraints, so the
+// final ranking doesn't rest on the model's self-reported confidence alone.
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// Component names
+const (
+	ComponentEvidence    = "evidence"
+	ComponentFeasibility = "feasibility"
+	ComponentConstraints = "constraints"
+)
+
+// Relevance values on evidence references
+const (
+	RelevanceSupports    = "supports"
+	RelevanceContradicts = "contradicts"
+	RelevanceNeutral     = "neutral"
+)
+
+// boundsMargin is how far, in meters, a position may lie outside the scene
+// bounds before its segment counts as physically impossible
+const boundsMargin = 0.5
+
+// Weights sets how much each component contributes to the computed score
+type Weights struct {
+	Evidence    float64
+	Feasibility float64
+	Constraints float64
+}
+
+// DefaultWeights returns the weights used by the reasoning worker
+func DefaultWeights() Weights {
+	return Weights{Evidence: 0.5, Feasibility: 0.3, Constraints: 0.2}
+}
+
+// Map returns the weights keyed by component name
+func (w Weights) Map() map[string]float64 {
+	return map[string]float64{
+		ComponentEvidence:    w.Evidence,
+		ComponentFeasibility: w.Feasibility,
+		ComponentConstraints: w.Constraints,
+	}
+}
+
+// Scorer scores trajectories against one scene
+type Scorer struct {
+	evidence map[string]models.EvidenceCard
+	bounds   *models.BoundingBox
+	weights  Weights
+}
+
+// NewScorer indexes the scene's evidence. Scenes without objects only have
+// placeholder bounds and skip the bounds check.
+func NewScorer(sg *models.SceneGraph, weights Weights) *Scorer {
+	s := &Scorer{evidence: map[string]models.EvidenceCard{}, weights: weights}
+	if sg == nil {
+		return s
+	}
+	for _, ev := range sg.Evidence {
+		s.evidence[ev.ID] = ev
+	}
+	if len(sg.Objects) > 0 && sg.Bounds.Min != sg.Bounds.Max {
+		b := sg.Bounds
+		s.bounds = &b
+	}
+	return s
+}
+
+// RecordModelScores keeps each trajectory's confidence and rank as returned
+// by the model, before any server-side adjustment
+func RecordModelScores(trajectories []models.Trajectory) {
+	for i := range trajectories {
+		t := &trajectories[i]
+		t.Score = &models.TrajectoryScore{ModelConfidence: t.OverallConfidence, ModelRank: t.Rank}
+	}
+}
+
+// Score computes a trajectory's confidence with its breakdown. The model's
+// confidence and rank are kept from RecordModelScores, or taken from the
+// trajectory if they weren't recorded.
+func (s *Scorer) Score(t models.Trajectory) *models.TrajectoryScore {
+	score := &models.TrajectoryScore{ModelConfidence: t.OverallConfidence, ModelRank: t.Rank}
+	if t.Score != nil {
+		score.ModelConfidence, score.ModelRank = t.Score.ModelConfidence, t.Score.ModelRank
+	}
+
+	evidence, evidenceDetail := s.evidenceScore(t)
+	feasibility, feasibilityDetail := s.feasibilityScore(t)
+	constraints, constraintsDetail := constraintScore(t)
+
+	score.Components = []models.ScoreComponent{
+		component(ComponentEvidence, evidence, s.weights.Evidence, evidenceDetail),
+		component(ComponentFeasibility, feasibility, s.weights.Feasibility, feasibilityDetail),
+		component(ComponentConstraints, constraints, s.weights.Constraints, constraintsDetail),
+	}
+
+	var total, weights float64
+	for _, c := range score.Components {
+		total += c.Value * c.Weight
+		weights += c.Weight
+	}
+	if weights > 0 {
+		score.ComputedConfidence = round2(total / weights)
+	}
+	return score
+}
+
+// Apply scores every trajectory, replaces its overall confidence with the
+// computed one and re-ranks. Ties keep the model's order. It reports whether
+// the computed order differs from the model's.
+func (s *Scorer) Apply(trajectories []models.Trajectory) *models.ScoringSummary {
+	for i := range trajectories {
+		t := &trajectories[i]
+		t.Score = s.Score(*t)
+		t.OverallConfidence = t.Score.ComputedConfidence
+	}
+
+	sort.SliceStable(trajectories, func(i, j int) bool {
+		a, b := trajectories[i], trajectories[j]
+		if a.OverallConfidence != b.OverallConfidence {
+			return a.OverallConfidence > b.OverallConfidence
+		}
+		return a.Score.ModelRank < b.Score.ModelRank
+	})
+
+	summary := &models.ScoringSummary{Weights: s.weights.Map()}
+	for i := range trajectories {
+		trajectories[i].Rank = i + 1
+		if trajectories[i].Score.ModelRank != i+1 {
+			summary.Reranked = true
+		}
+	}
+	return summary
+}
+
+// evidenceScore averages per-segment support. A segment's support is the
+// share of its weighted references that support it, scaled down when the
+// total weight behind it is below one.
+func (s *Scorer) evidenceScore(t models.Trajectory) (float64, string) {
+	if len(t.Segments) == 0 {
+		return 0, "no segments"
+	}
+	var sum float64
+	refs, missing := 0, 0
+	for _, seg := range t.Segments {
+		var support, against float64
+		for _, ref := range seg.EvidenceRefs {
+			refs++
+			card, ok := s.evidence[ref.EvidenceID]
+			if !ok {
+				missing++
+				continue
+			}
+			w := ref.Weight * card.Confidence * sourceReliability(card)
+			switch ref.Relevance {
+			case RelevanceSupports:
+				support += w
+			case RelevanceContradicts:
+				against += w
+			}
+		}
+		if total := support + against; total > 0 {
+			sum += support / total * math.Min(1, total)
+		}
+	}
+
+	detail := fmt.Sprintf("%d evidence references across %d segments", refs, len(t.Segments))
+	if missing > 0 {
+		detail += fmt.Sprintf(", %d not found in the scene", missing)
+	}
+	return round2(sum / float64(len(t.Segments))), detail
+}
+
+// sourceReliability rates evidence by its strongest source: uploaded
+// material outranks witness statements, which outrank model inferences
+func sourceReliability(card models.EvidenceCard) float64 {
+	best := 0.0
+	for _, src := range card.Sources {
+		var r float64
+		switch src.Type {
+		case models.EvidenceSourceTypeUpload:
+			r = 1
+		case models.EvidenceSourceTypeWitness:
+			r = 0.6
+			if src.Credibility > 0 {
+				r = 0.8 * src.Credibility
+			}
+		case models.EvidenceSourceTypeInference:
+			r = 0.4
+		}
+		best = math.Max(best, r)
+	}
+	if len(card.Sources) == 0 {
+		return 0.5
+	}
+	return best
+}
+
+// feasibilityScore is set by the weakest segment: positions outside the
+// scene bounds or an infeasible route make the trajectory impossible, and a
+// required detour weakens it
+func (s *Scorer) feasibilityScore(t models.Trajectory) (float64, string) {
+	score := 1.0
+	detail := "all segments walkable"
+	checked := 0
+	for _, seg := range t.Segments {
+		if !s.inBounds(seg.FromPosition) || !s.inBounds(seg.ToPosition) {
+			return 0, fmt.Sprintf("segment %s leaves the scene bounds", seg.ID)
+		}
+		if seg.Feasibility == nil {
+			continue
+		}
+		checked++
+		switch seg.Feasibility.Status {
+		case models.FeasibilityStatusInfeasible:
+			return 0, fmt.Sprintf("segment %s is infeasible: %s", seg.ID, seg.Feasibility.Reason)
+		case models.FeasibilityStatusDetourRequired:
+			if score > 0.7 {
+				score = 0.7
+				detail = fmt.Sprintf("segment %s requires a detour", seg.ID)
+			}
+		}
+	}
+	if checked == 0 && len(t.Segments) > 0 {
+		detail = "within scene bounds; routes not checked"
+	}
+	return score, detail
+}
+
+func (s *Scorer) inBounds(p [3]float64) bool {
+	if s.bounds == nil {
+		return true
+	}
+	return p[0] >= s.bounds.Min[0]-boundsMargin && p[0] <= s.bounds.Max[0]+boundsMargin &&
+		p[2] >= s.bounds.Min[2]-boundsMargin && p[2] <= s.bounds.Max[2]+boundsMargin
+}
+
+// constraintScore uses the trajectory's constraint report. Without one,
+// nothing is known to be violated.
+func constraintScore(t models.Trajectory) (float64, string) {
+	r := t.ConstraintReport
+	if r == nil {
+		return 1, "not evaluated"
+	}
+	return r.Score, fmt.Sprintf("%d satisfied, %d violated", r.Satisfied, r.Violated)
+}
+
+func component(name string, value, weight float64, detail string) models.ScoreComponent {
+	return models.ScoreComponent{
+		Name:         name,
+		Value:        value,
+		Weight:       weight,
+		Contribution: round2(value * weight),
+		Detail:       detail,
+	}
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,148 @@
+package scoring
+
+import (
+	"testing"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+func scene() *models.SceneGraph {
+	sg := models.NewEmptySceneGraph()
+	sg.Objects = []models.SceneObject{{ID: "desk", Type: models.ObjectTypeFurniture}}
+	sg.Evidence = []models.EvidenceCard{
+		{ID: "footprint", Title: "Footprint", Confidence: 0.9, Sources: []models.EvidenceSource{{Type: models.EvidenceSourceTypeUpload}}},
+		{ID: "witness", Title: "Neighbour", Confidence: 0.8, Sources: []models.EvidenceSource{{Type: models.EvidenceSourceTypeWitness, Credibility: 0.5}}},
+		{ID: "guess", Title: "Inferred entry", Confidence: 0.6, Sources: []models.EvidenceSource{{Type: models.EvidenceSourceTypeInference}}},
+	}
+	return sg
+}
+
+func ref(id, relevance string, weight float64) models.EvidenceRef {
+	return models.EvidenceRef{EvidenceID: id, Relevance: relevance, Weight: weight}
+}
+
+func seg(id string, refs ...models.EvidenceRef) models.TrajectorySegment {
+	return models.TrajectorySegment{
+		ID: id, FromPosition: [3]float64{1, 0, 1}, ToPosition: [3]float64{4, 0, 4},
+		EvidenceRefs: refs, Confidence: 0.8,
+	}
+}
+
+func components(score *models.TrajectoryScore) map[string]float64 {
+	out := map[string]float64{}
+	for _, c := range score.Components {
+		out[c.Name] = c.Value
+	}
+	return out
+}
+
+func TestScore_Evidence(t *testing.T) {
+	s := NewScorer(scene(), DefaultWeights())
+
+	tests := []struct {
+		name string
+		seg  models.TrajectorySegment
+		want float64
+	}{
+		{"uploaded evidence", seg("s", ref("footprint", RelevanceSupports, 1)), 0.9},
+		{"witness counts less than upload", seg("s", ref("witness", RelevanceSupports, 1)), 0.32},
+		{"inference counts least", seg("s", ref("guess", RelevanceSupports, 1)), 0.24},
+		{"contradiction halves support", seg("s", ref("footprint", RelevanceSupports, 1), ref("footprint", RelevanceContradicts, 1)), 0.5},
+		{"neutral references add nothing", seg("s", ref("footprint", RelevanceNeutral, 1)), 0},
+		{"unknown evidence adds nothing", seg("s", ref("missing", RelevanceSupports, 1)), 0},
+		{"no references", seg("s"), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traj := models.Trajectory{ID: "t", Rank: 1, OverallConfidence: 0.9, Segments: []models.TrajectorySegment{tt.seg}}
+			if got := components(s.Score(traj))[ComponentEvidence]; got != tt.want {
+				t.Errorf("evidence = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScore_Feasibility(t *testing.T) {
+	s := NewScorer(scene(), DefaultWeights())
+
+	detour := seg("s1")
+	detour.Feasibility = &models.SegmentFeasibility{Status: models.FeasibilityStatusDetourRequired}
+	infeasible := seg("s2")
+	infeasible.Feasibility = &models.SegmentFeasibility{Status: models.FeasibilityStatusInfeasible, Reason: "blocked"}
+	outside := seg("s3")
+	outside.ToPosition = [3]float64{14, 0, 4}
+
+	tests := []struct {
+		name     string
+		segments []models.TrajectorySegment
+		want     float64
+	}{
+		{"unchecked inside bounds", []models.TrajectorySegment{seg("s0")}, 1},
+		{"detour", []models.TrajectorySegment{seg("s0"), detour}, 0.7},
+		{"infeasible", []models.TrajectorySegment{detour, infeasible}, 0},
+		{"outside the scene", []models.TrajectorySegment{outside}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traj := models.Trajectory{ID: "t", Rank: 1, Segments: tt.segments}
+			if got := components(s.Score(traj))[ComponentFeasibility]; got != tt.want {
+				t.Errorf("feasibility = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Without objects the bounds are a placeholder and aren't enforced
+	empty := NewScorer(models.NewEmptySceneGraph(), DefaultWeights())
+	traj := models.Trajectory{ID: "t", Segments: []models.TrajectorySegment{outside}}
+	if got := components(empty.Score(traj))[ComponentFeasibility]; got != 1 {
+		t.Errorf("feasibility in an empty scene = %v, want 1", got)
+	}
+}
+
+func TestApply_RerankKeepsModelScores(t *testing.T) {
+	violated := models.NewConstraintReport("trajectory", "confident")
+	violated.Add(models.ConstraintResult{ConstraintID: "c", Status: models.ConstraintStatusViolated, Confidence: 0.9})
+
+	trajectories := []models.Trajectory{
+		{ID: "confident", Rank: 1, OverallConfidence: 0.95, ConstraintReport: violated,
+			Segments: []models.TrajectorySegment{seg("s1", ref("guess", RelevanceSupports, 0.5))}},
+		{ID: "grounded", Rank: 2, OverallConfidence: 0.6,
+			Segments: []models.TrajectorySegment{seg("s1", ref("footprint", RelevanceSupports, 1))}},
+		{ID: "tied", Rank: 3, OverallConfidence: 0.5,
+			Segments: []models.TrajectorySegment{seg("s1", ref("footprint", RelevanceSupports, 1))}},
+	}
+	RecordModelScores(trajectories)
+	// A later stage may change the overall confidence; the model's is kept
+	trajectories[0].OverallConfidence = 0.4
+
+	summary := NewScorer(scene(), DefaultWeights()).Apply(trajectories)
+
+	if !summary.Reranked || summary.Weights[ComponentEvidence] != 0.5 {
+		t.Errorf("Apply() summary = %+v", summary)
+	}
+	order := []string{trajectories[0].ID, trajectories[1].ID, trajectories[2].ID}
+	if order[0] != "grounded" || order[1] != "tied" || order[2] != "confident" {
+		t.Errorf("Apply() order = %v, want grounded, tied, confident", order)
+	}
+	for i, traj := range trajectories {
+		if traj.Rank != i+1 {
+			t.Errorf("%s rank = %d, want %d", traj.ID, traj.Rank, i+1)
+		}
+	}
+
+	grounded := trajectories[0]
+	if grounded.Score.ModelConfidence != 0.6 || grounded.Score.ModelRank != 2 {
+		t.Errorf("grounded model score = %+v", grounded.Score)
+	}
+	// 0.5 × 0.9 + 0.3 × 1 + 0.2 × 1
+	if grounded.OverallConfidence != 0.95 || grounded.Score.ComputedConfidence != 0.95 {
+		t.Errorf("grounded computed confidence = %v", grounded.OverallConfidence)
+	}
+	confident := trajectories[2]
+	if confident.Score.ModelConfidence != 0.95 || confident.Score.ModelRank != 1 {
+		t.Errorf("confident model score = %+v", confident.Score)
+	}
+	if len(confident.Score.Components) != 3 || confident.Score.Components[2].Value != 0 {
+		t.Errorf("confident components = %+v", confident.Score.Components)
+	}
+}
@@ -0,0 +1,59 @@
+package spatial
+
+import "github.com/sherlockos/backend/internal/models"
+
+// Filter restricts query results. Empty fields match everything; Types and
+// States only match objects, since other items have neither. Exclude drops
+// items by ID, such as the object a query is centred on.
+type Filter struct {
+	Kinds   []ItemKind
+	Types   []models.ObjectType
+	States  []models.ObjectState
+	Exclude []string
+}
+
+// Match reports whether an item passes the filter
+func (f Filter) Match(item Item) bool {
+	for _, id := range f.Exclude {
+		if item.ID == id {
+			return false
+		}
+	}
+	if len(f.Kinds) > 0 && !f.hasKind(item.Kind) {
+		return false
+	}
+	if len(f.Types) > 0 && !f.hasType(item.Type) {
+		return false
+	}
+	if len(f.States) > 0 && !f.hasState(item.State) {
+		return false
+	}
+	return true
+}
+
+func (f Filter) hasKind(k ItemKind) bool {
+	for _, x := range f.Kinds {
+		if x == k {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Filter) hasType(t models.ObjectType) bool {
+	for _, x := range f.Types {
+		if x == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Filter) hasState(s models.ObjectState) bool {
+	for _, x := range f.States {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
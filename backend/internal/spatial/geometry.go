@@ -0,0 +1,88 @@
+package spatial
+
+import (
+	"math"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// PointBox returns the zero-size box at p
+func PointBox(p [3]float64) models.BoundingBox {
+	return models.BoundingBox{Min: p, Max: p}
+}
+
+// Centre returns the centre of a box
+func Centre(b models.BoundingBox) [3]float64 {
+	return centre(b)
+}
+
+func centre(b models.BoundingBox) [3]float64 {
+	return [3]float64{(b.Min[0] + b.Max[0]) / 2, (b.Min[1] + b.Max[1]) / 2, (b.Min[2] + b.Max[2]) / 2}
+}
+
+// normalizeBox orders each axis so Min ≤ Max
+func normalizeBox(b models.BoundingBox) models.BoundingBox {
+	for a := 0; a < 3; a++ {
+		if b.Min[a] > b.Max[a] {
+			b.Min[a], b.Max[a] = b.Max[a], b.Min[a]
+		}
+	}
+	return b
+}
+
+func union(a, b models.BoundingBox) models.BoundingBox {
+	for i := 0; i < 3; i++ {
+		a.Min[i] = math.Min(a.Min[i], b.Min[i])
+		a.Max[i] = math.Max(a.Max[i], b.Max[i])
+	}
+	return a
+}
+
+func overlaps(a, b models.BoundingBox) bool {
+	for i := 0; i < 3; i++ {
+		if a.Max[i] < b.Min[i] || b.Max[i] < a.Min[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// boxDistance is the shortest distance between two boxes, zero if they overlap
+func boxDistance(a, b models.BoundingBox) float64 {
+	var sum float64
+	for i := 0; i < 3; i++ {
+		var gap float64
+		switch {
+		case a.Max[i] < b.Min[i]:
+			gap = b.Min[i] - a.Max[i]
+		case b.Max[i] < a.Min[i]:
+			gap = a.Min[i] - b.Max[i]
+		}
+		sum += gap * gap
+	}
+	return math.Sqrt(sum)
+}
+
+// rayEntry returns how far along a unit ray it enters a box, zero if it
+// starts inside. Boxes entered beyond maxDist or behind the origin are missed.
+func rayEntry(origin, dir [3]float64, b models.BoundingBox, maxDist float64) (float64, bool) {
+	near, far := 0.0, maxDist
+	for i := 0; i < 3; i++ {
+		if dir[i] == 0 {
+			if origin[i] < b.Min[i] || origin[i] > b.Max[i] {
+				return 0, false
+			}
+			continue
+		}
+		t1 := (b.Min[i] - origin[i]) / dir[i]
+		t2 := (b.Max[i] - origin[i]) / dir[i]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		near, far = math.Max(near, t1), math.Min(far, t2)
+		if near > far {
+			return 0, false
+		}
+	}
+	return near, true
+}
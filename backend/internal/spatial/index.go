@@ -0,0 +1,303 @@
+// Package spatial indexes a scene's objects, evidence anchors and uncertainty
+// regions in a bounding volume hierarchy and answers radius, box, ray and
+// nearest-neighbour queries over it.
+package spatial
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+// ItemKind is the kind of scene element an item stands for
+type ItemKind string
+
+// Item kinds
+const (
+	KindObject            ItemKind = "object"
+	KindEvidence          ItemKind = "evidence"
+	KindUncertaintyRegion ItemKind = "uncertainty_region"
+)
+
+// IsValid checks if the item kind is valid
+func (k ItemKind) IsValid() bool {
+	switch k {
+	case KindObject, KindEvidence, KindUncertaintyRegion:
+		return true
+	}
+	return false
+}
+
+// leafSize is the most items a BVH leaf holds
+const leafSize = 4
+
+// Item is an indexed scene element. Type and State are set for objects only.
+type Item struct {
+	Kind  ItemKind           `json:"kind"`
+	ID    string             `json:"id"`
+	Label string             `json:"label,omitempty"`
+	Type  models.ObjectType  `json:"type,omitempty"`
+	State models.ObjectState `json:"state,omitempty"`
+	BBox  models.BoundingBox `json:"bbox"`
+}
+
+// Hit is an item matched by a query with its distance from the query: the gap
+// between boxes for radius and nearest queries, the entry distance along a ray
+type Hit struct {
+	Item
+	Distance float64 `json:"distance"`
+}
+
+// Index is a bounding volume hierarchy over a scene's items
+type Index struct {
+	items []Item
+	root  *node
+}
+
+type node struct {
+	box         models.BoundingBox
+	left, right *node
+	items       []int
+}
+
+// NewIndex indexes the scene's objects, its uncertainty regions and every
+// evidence card anchored to at least one object, placed at the box around its
+// objects
+func NewIndex(sg *models.SceneGraph) *Index {
+	idx := &Index{}
+	if sg == nil {
+		return idx
+	}
+
+	boxes := map[string]models.BoundingBox{}
+	for _, obj := range sg.Objects {
+		box := normalizeBox(obj.BBox)
+		boxes[obj.ID] = box
+		idx.items = append(idx.items, Item{
+			Kind: KindObject, ID: obj.ID, Label: obj.Label, Type: obj.Type, State: obj.State, BBox: box,
+		})
+	}
+	for _, ev := range sg.Evidence {
+		var anchor models.BoundingBox
+		anchored := false
+		for _, id := range ev.ObjectIDs {
+			box, ok := boxes[id]
+			if !ok {
+				continue
+			}
+			if anchored {
+				anchor = union(anchor, box)
+			} else {
+				anchor, anchored = box, true
+			}
+		}
+		if anchored {
+			idx.items = append(idx.items, Item{Kind: KindEvidence, ID: ev.ID, Label: ev.Title, BBox: anchor})
+		}
+	}
+	for _, region := range sg.UncertaintyRegions {
+		idx.items = append(idx.items, Item{
+			Kind: KindUncertaintyRegion, ID: region.ID, Label: region.Reason, BBox: normalizeBox(region.BBox),
+		})
+	}
+
+	order := make([]int, len(idx.items))
+	for i := range order {
+		order[i] = i
+	}
+	idx.root = idx.build(order)
+	return idx
+}
+
+// Len returns the number of indexed items
+func (idx *Index) Len() int {
+	return len(idx.items)
+}
+
+// Lookup returns the indexed item with the given kind and ID
+func (idx *Index) Lookup(kind ItemKind, id string) (Item, bool) {
+	for _, item := range idx.items {
+		if item.Kind == kind && item.ID == id {
+			return item, true
+		}
+	}
+	return Item{}, false
+}
+
+// build splits items at the median of the longest axis of their centres
+func (idx *Index) build(items []int) *node {
+	if len(items) == 0 {
+		return nil
+	}
+	n := &node{box: idx.items[items[0]].BBox}
+	for _, i := range items[1:] {
+		n.box = union(n.box, idx.items[i].BBox)
+	}
+	if len(items) <= leafSize {
+		n.items = items
+		return n
+	}
+
+	axis, widest := 0, -1.0
+	for a := 0; a < 3; a++ {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, i := range items {
+			c := centre(idx.items[i].BBox)[a]
+			lo, hi = math.Min(lo, c), math.Max(hi, c)
+		}
+		if hi-lo > widest {
+			axis, widest = a, hi-lo
+		}
+	}
+	sort.Slice(items, func(a, b int) bool {
+		return centre(idx.items[items[a]].BBox)[axis] < centre(idx.items[items[b]].BBox)[axis]
+	})
+	mid := len(items) / 2
+	n.left = idx.build(items[:mid])
+	n.right = idx.build(items[mid:])
+	return n
+}
+
+// Radius returns the items whose boxes come within r of the query box, nearest
+// first. A point is a box with Min == Max.
+func (idx *Index) Radius(query models.BoundingBox, r float64, f Filter) []Hit {
+	var hits []Hit
+	idx.visit(func(box models.BoundingBox) bool {
+		return boxDistance(query, box) <= r
+	}, func(item Item) {
+		if d := boxDistance(query, item.BBox); d <= r && f.Match(item) {
+			hits = append(hits, Hit{Item: item, Distance: round3(d)})
+		}
+	})
+	sortHits(hits)
+	return hits
+}
+
+// Box returns the items whose boxes overlap the query box
+func (idx *Index) Box(query models.BoundingBox, f Filter) []Hit {
+	var hits []Hit
+	idx.visit(func(box models.BoundingBox) bool {
+		return overlaps(query, box)
+	}, func(item Item) {
+		if overlaps(query, item.BBox) && f.Match(item) {
+			hits = append(hits, Hit{Item: item})
+		}
+	})
+	sortHits(hits)
+	return hits
+}
+
+// Ray returns the items a ray passes through within maxDist, in the order it
+// enters them. A maxDist of zero or less means no limit.
+func (idx *Index) Ray(origin, dir [3]float64, maxDist float64, f Filter) []Hit {
+	l := math.Sqrt(dir[0]*dir[0] + dir[1]*dir[1] + dir[2]*dir[2])
+	if l == 0 {
+		return nil
+	}
+	dir = [3]float64{dir[0] / l, dir[1] / l, dir[2] / l}
+	if maxDist <= 0 {
+		maxDist = math.Inf(1)
+	}
+
+	var hits []Hit
+	idx.visit(func(box models.BoundingBox) bool {
+		_, ok := rayEntry(origin, dir, box, maxDist)
+		return ok
+	}, func(item Item) {
+		if t, ok := rayEntry(origin, dir, item.BBox, maxDist); ok && f.Match(item) {
+			hits = append(hits, Hit{Item: item, Distance: round3(t)})
+		}
+	})
+	sortHits(hits)
+	return hits
+}
+
+// Nearest returns the k matching items closest to the query box, nearest first
+func (idx *Index) Nearest(query models.BoundingBox, k int, f Filter) []Hit {
+	if idx.root == nil || k <= 0 {
+		return nil
+	}
+
+	// Best-first search: nodes and items share one queue ordered by distance,
+	// so an item popped from it is nearer than anything still unexplored
+	queue := &entryQueue{{node: idx.root, dist: boxDistance(query, idx.root.box)}}
+	var hits []Hit
+	for queue.Len() > 0 && len(hits) < k {
+		e := heap.Pop(queue).(entry)
+		if e.node == nil {
+			hits = append(hits, Hit{Item: idx.items[e.item], Distance: round3(e.dist)})
+			continue
+		}
+		for _, i := range e.node.items {
+			if f.Match(idx.items[i]) {
+				heap.Push(queue, entry{item: i, dist: boxDistance(query, idx.items[i].BBox)})
+			}
+		}
+		for _, child := range []*node{e.node.left, e.node.right} {
+			if child != nil {
+				heap.Push(queue, entry{node: child, dist: boxDistance(query, child.box)})
+			}
+		}
+	}
+	return hits
+}
+
+// visit walks the nodes whose boxes pass enter and calls each item in them
+func (idx *Index) visit(enter func(models.BoundingBox) bool, each func(Item)) {
+	stack := []*node{idx.root}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if n == nil || !enter(n.box) {
+			continue
+		}
+		for _, i := range n.items {
+			each(idx.items[i])
+		}
+		stack = append(stack, n.left, n.right)
+	}
+}
+
+// entry is a node or an item waiting in the nearest-neighbour queue
+type entry struct {
+	node *node
+	item int
+	dist float64
+}
+
+type entryQueue []entry
+
+func (q entryQueue) Len() int { return len(q) }
+func (q entryQueue) Less(i, j int) bool {
+	if q[i].dist != q[j].dist {
+		return q[i].dist < q[j].dist
+	}
+	// Items before nodes at equal distance, so ties resolve deterministically
+	return q[i].node == nil && q[j].node != nil
+}
+func (q entryQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *entryQueue) Push(x interface{}) { *q = append(*q, x.(entry)) }
+func (q *entryQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+func sortHits(hits []Hit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Distance != hits[j].Distance {
+			return hits[i].Distance < hits[j].Distance
+		}
+		if hits[i].Kind != hits[j].Kind {
+			return hits[i].Kind < hits[j].Kind
+		}
+		return hits[i].ID < hits[j].ID
+	})
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,172 @@
+package spatial
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/sherlockos/backend/internal/models"
+)
+
+func box(x0, y0, z0, x1, y1, z1 float64) models.BoundingBox {
+	return models.BoundingBox{Min: [3]float64{x0, y0, z0}, Max: [3]float64{x1, y1, z1}}
+}
+
+func object(id string, t models.ObjectType, state models.ObjectState, bb models.BoundingBox) models.SceneObject {
+	return models.SceneObject{ID: id, Type: t, Label: id, State: state, BBox: bb, Pose: models.NewDefaultPose()}
+}
+
+func scene() *models.SceneGraph {
+	sg := models.NewEmptySceneGraph()
+	sg.Objects = []models.SceneObject{
+		object("body", models.ObjectTypePersonMarker, models.ObjectStateVisible, box(0, 0, 0, 1.8, 0.3, 0.5)),
+		object("knife", models.ObjectTypeWeapon, models.ObjectStateSuspicious, box(2, 0, 0, 2.3, 0.05, 0.1)),
+		object("table", models.ObjectTypeFurniture, models.ObjectStateVisible, box(4, 0, 0, 5, 0.8, 1)),
+		object("door", models.ObjectTypeDoor, models.ObjectStateVisible, box(0, 0, 5, 1, 2, 5.1)),
+		object("window", models.ObjectTypeWindow, models.ObjectStateVisible, box(6, 0.8, 5, 7, 2, 5.1)),
+		object("chair", models.ObjectTypeFurniture, models.ObjectStateVisible, box(3, 0, 4.9, 3.5, 1.5, 5.3)),
+	}
+	sg.Evidence = []models.EvidenceCard{
+		{ID: "ev_knife", Title: "Knife", ObjectIDs: []string{"knife"}},
+		{ID: "ev_unanchored", Title: "Witness"},
+	}
+	sg.UncertaintyRegions = []models.UncertaintyRegion{{ID: "shadow", BBox: box(-1, 0, -1, 0, 2, 0)}}
+	return sg
+}
+
+func ids(hits []Hit) []string {
+	out := make([]string, len(hits))
+	for i, h := range hits {
+		out[i] = h.ID
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func TestNewIndex(t *testing.T) {
+	idx := NewIndex(scene())
+	// 6 objects, 1 anchored evidence card, 1 uncertainty region
+	if idx.Len() != 8 {
+		t.Errorf("Len() = %d, want 8", idx.Len())
+	}
+	if item, ok := idx.Lookup(KindEvidence, "ev_knife"); !ok || item.BBox != box(2, 0, 0, 2.3, 0.05, 0.1) {
+		t.Errorf("evidence anchor = %+v, %v", item, ok)
+	}
+	if _, ok := idx.Lookup(KindEvidence, "ev_unanchored"); ok {
+		t.Error("evidence without objects should not be indexed")
+	}
+	if NewIndex(nil).Len() != 0 || len(NewIndex(nil).Nearest(PointBox([3]float64{}), 3, Filter{})) != 0 {
+		t.Error("empty index should return nothing")
+	}
+}
+
+func TestIndex_Radius(t *testing.T) {
+	idx := NewIndex(scene())
+	body, _ := idx.Lookup(KindObject, "body")
+
+	// What is within 2 m of the body
+	hits := idx.Radius(body.BBox, 2, Filter{Kinds: []ItemKind{KindObject}, Exclude: []string{"body"}})
+	if !equal(ids(hits), []string{"knife"}) || hits[0].Distance != 0.2 {
+		t.Errorf("Radius() = %+v", hits)
+	}
+
+	hits = idx.Radius(body.BBox, 2, Filter{Exclude: []string{"body"}})
+	if !equal(ids(hits), []string{"shadow", "ev_knife", "knife"}) {
+		t.Errorf("Radius() all kinds = %v", ids(hits))
+	}
+
+	hits = idx.Radius(PointBox([3]float64{4.5, 0.5, 0.5}), 0.1, Filter{})
+	if !equal(ids(hits), []string{"table"}) || hits[0].Distance != 0 {
+		t.Errorf("Radius() from a point = %+v", hits)
+	}
+}
+
+func TestIndex_BoxAndFilters(t *testing.T) {
+	idx := NewIndex(scene())
+
+	hits := idx.Box(box(0, 0, 0, 5, 2, 1), Filter{Types: []models.ObjectType{models.ObjectTypeFurniture, models.ObjectTypeWeapon}})
+	if !equal(ids(hits), []string{"knife", "table"}) {
+		t.Errorf("Box() = %v", ids(hits))
+	}
+	hits = idx.Box(box(0, 0, 0, 5, 2, 1), Filter{States: []models.ObjectState{models.ObjectStateSuspicious}})
+	if !equal(ids(hits), []string{"knife"}) {
+		t.Errorf("Box() suspicious = %v", ids(hits))
+	}
+}
+
+func TestIndex_Ray(t *testing.T) {
+	idx := NewIndex(scene())
+	door, _ := idx.Lookup(KindObject, "door")
+	window, _ := idx.Lookup(KindObject, "window")
+
+	// Which objects lie between the door and the window
+	from, to := Centre(door.BBox), Centre(window.BBox)
+	dir := [3]float64{to[0] - from[0], to[1] - from[1], to[2] - from[2]}
+	hits := idx.Ray(from, dir, 6.01, Filter{Exclude: []string{"door", "window"}})
+	if !equal(ids(hits), []string{"chair"}) || hits[0].Distance < 2 || hits[0].Distance > 3 {
+		t.Errorf("Ray() between door and window = %+v", hits)
+	}
+
+	// Along the floor from the origin: body (inside), knife, table
+	hits = idx.Ray([3]float64{0.1, 0.01, 0.05}, [3]float64{1, 0, 0}, 0, Filter{Kinds: []ItemKind{KindObject}})
+	if !equal(ids(hits), []string{"body", "knife", "table"}) || hits[0].Distance != 0 || hits[2].Distance != 3.9 {
+		t.Errorf("Ray() along the floor = %+v", hits)
+	}
+	if hits := idx.Ray([3]float64{}, [3]float64{}, 0, Filter{}); hits != nil {
+		t.Errorf("Ray() with zero direction = %v", hits)
+	}
+}
+
+func TestIndex_NearestMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	sg := models.NewEmptySceneGraph()
+	for i := 0; i < 300; i++ {
+		x, y, z := rng.Float64()*50, rng.Float64()*3, rng.Float64()*50
+		t := models.ObjectTypeFurniture
+		if i%3 == 0 {
+			t = models.ObjectTypeEvidenceItem
+		}
+		sg.Objects = append(sg.Objects, object(fmt.Sprintf("o%03d", i), t, models.ObjectStateVisible,
+			box(x, y, z, x+rng.Float64(), y+rng.Float64(), z+rng.Float64())))
+	}
+	idx := NewIndex(sg)
+	filter := Filter{Types: []models.ObjectType{models.ObjectTypeEvidenceItem}}
+
+	for trial := 0; trial < 20; trial++ {
+		query := PointBox([3]float64{rng.Float64() * 50, 1, rng.Float64() * 50})
+
+		var want []Hit
+		for _, obj := range sg.Objects {
+			item, _ := idx.Lookup(KindObject, obj.ID)
+			if filter.Match(item) {
+				want = append(want, Hit{Item: item, Distance: round3(boxDistance(query, item.BBox))})
+			}
+		}
+		sortHits(want)
+
+		got := idx.Nearest(query, 7, filter)
+		if len(got) != 7 {
+			t.Fatalf("Nearest() returned %d hits, want 7", len(got))
+		}
+		for i := range got {
+			if got[i].Distance != want[i].Distance {
+				t.Fatalf("Nearest()[%d] = %s at %v, want %s at %v", i, got[i].ID, got[i].Distance, want[i].ID, want[i].Distance)
+			}
+		}
+
+		// Halfway between the 11th and 12th nearest, clear of rounding
+		radius := (want[10].Distance + want[11].Distance) / 2
+		var inRadius []string
+		for _, h := range want {
+			if h.Distance <= radius {
+				inRadius = append(inRadius, h.ID)
+			}
+		}
+		if got := ids(idx.Radius(query, radius, filter)); !equal(got, inRadius) {
+			t.Fatalf("Radius() = %v, want %v", got, inRadius)
+		}
+	}
+}
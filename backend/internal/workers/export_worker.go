@@ -4,28 +4,46 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
 	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/bundle"
 	"github.com/sherlockos/backend/internal/clients"
 	"github.com/sherlockos/backend/internal/db"
+	"github.com/sherlockos/backend/internal/gltf"
 	"github.com/sherlockos/backend/internal/models"
 	"github.com/sherlockos/backend/internal/queue"
+	"github.com/sherlockos/backend/internal/report"
 )
 
+// portraitBucket is where generated suspect portraits and other case files
+// are stored
+const portraitBucket = "case-assets"
+
 // ExportWorker handles JobTypeExport jobs
 type ExportWorker struct {
 	*BaseWorker
-	storage clients.StorageClient
+	storage     clients.StorageClient
+	templateDir string // where named HTML report templates are found when not in the database
 }
 
 // NewExportWorker creates a new export worker
 func NewExportWorker(database *db.DB, q queue.JobQueue, storage clients.StorageClient) *ExportWorker {
+	return NewExportWorkerWithTemplates(database, q, storage, "")
+}
+
+// NewExportWorkerWithTemplates creates an export worker that also looks for
+// report templates as <name>.html.tmpl files in templateDir
+func NewExportWorkerWithTemplates(database *db.DB, q queue.JobQueue, storage clients.StorageClient, templateDir string) *ExportWorker {
 	return &ExportWorker{
-		BaseWorker: NewBaseWorker(database, q),
-		storage:    storage,
+		BaseWorker:  NewBaseWorker(database, q),
+		storage:     storage,
+		templateDir: templateDir,
 	}
 }
 
@@ -34,15 +52,18 @@ func (w *ExportWorker) Type() models.JobType {
 	return models.JobTypeExport
 }
 
-// Process generates an HTML report for the case
+// Process generates an HTML or PDF report for the case, a bundle of the
+// whole case, or a GLB file of its scene
 func (w *ExportWorker) Process(ctx context.Context, job *queue.JobMessage) error {
 	// Parse input
-	var input struct {
-		Format string `json:"format"`
-	}
+	var input models.ExportInput
 	if err := json.Unmarshal(job.Input, &input); err != nil {
 		return NewFatalError(fmt.Errorf("failed to parse input: %w", err))
 	}
+	input.SetDefaults()
+	if err := input.Validate(); err != nil {
+		return NewFatalError(fmt.Errorf("invalid input: %w", err))
+	}
 
 	// Update progress
 	w.UpdateJobProgress(ctx, job.JobID, 10)
@@ -52,24 +73,13 @@ func (w *ExportWorker) Process(ctx context.Context, job *queue.JobMessage) error
 	if err != nil {
 		return NewFatalError(fmt.Errorf("failed to get case: %w", err))
 	}
-
-	// Get commits (timeline)
-	commitPtrs, err := w.repo.GetCommitsByCase(ctx, job.CaseID, 100, nil)
-	if err != nil {
-		return NewRetryableError(fmt.Errorf("failed to get commits: %w", err))
-	}
-	// Convert to value slice
-	commits := make([]models.Commit, len(commitPtrs))
-	for i, c := range commitPtrs {
-		commits[i] = *c
+	if caseData == nil {
+		return NewFatalError(fmt.Errorf("case %s not found", job.CaseID))
 	}
 
-	// Update progress
-	w.UpdateJobProgress(ctx, job.JobID, 30)
-
 	// Get scene snapshot
 	snapshot, err := w.repo.GetSceneSnapshot(ctx, job.CaseID)
-	if err != nil {
+	if err != nil || snapshot == nil {
 		// Snapshot might not exist yet, continue with empty
 		snapshot = &models.SceneSnapshot{
 			CaseID:     job.CaseID,
@@ -85,23 +95,67 @@ func (w *ExportWorker) Process(ctx context.Context, job *queue.JobMessage) error
 	}
 
 	// Update progress
-	w.UpdateJobProgress(ctx, job.JobID, 50)
+	w.UpdateJobProgress(ctx, job.JobID, 30)
 
-	// Generate HTML report
-	reportHTML, err := generateHTMLReport(caseData, commits, snapshot, profile)
-	if err != nil {
-		return NewRetryableError(fmt.Errorf("failed to generate report: %w", err))
+	// Generate the report
+	var data []byte
+	contentType := "text/html"
+	extension := string(input.Format)
+	var sceneCommit uuid.UUID
+	switch input.Format {
+	case models.ExportFormatGLTF:
+		data, sceneCommit, err = w.exportScene(ctx, &input, job.CaseID, snapshot)
+		if err != nil {
+			return err
+		}
+		contentType = gltf.ContentType
+		extension = gltf.FileExtension
+	case models.ExportFormatBundle:
+		b, missing, err := w.collectBundle(ctx, caseData, snapshot, profile)
+		if err != nil {
+			return NewRetryableError(err)
+		}
+		var buf bytes.Buffer
+		if err := b.Write(&buf, time.Now().UTC(), missing); err != nil {
+			return NewFatalError(fmt.Errorf("failed to write bundle: %w", err))
+		}
+		data = buf.Bytes()
+		contentType = "application/zip"
+		extension = "zip"
+	default:
+		rep, err := w.buildReport(ctx, &input, caseData, snapshot, profile)
+		if err != nil {
+			return err
+		}
+		w.UpdateJobProgress(ctx, job.JobID, 50)
+
+		if input.Format == models.ExportFormatPDF {
+			if rep.Has(models.ReportSectionProfile) && rep.Profile != nil && rep.Profile.PortraitKey != "" {
+				rep.Profile.Portrait = w.fetchPortrait(ctx, rep.Profile.PortraitKey)
+			}
+			data = report.RenderPDF(rep)
+			contentType = "application/pdf"
+		} else {
+			tmpl, err := w.loadTemplate(ctx, input.Template)
+			if err != nil {
+				return err
+			}
+			data, err = report.RenderHTML(rep, tmpl)
+			if err != nil {
+				return NewFatalError(fmt.Errorf("failed to generate report: %w", err))
+			}
+		}
 	}
 
 	// Update progress
 	w.UpdateJobProgress(ctx, job.JobID, 80)
 
 	// Upload report to storage
-	storageKey := fmt.Sprintf("cases/%s/reports/report_%s.html", job.CaseID, time.Now().Format("20060102_150405"))
+	storageKey := fmt.Sprintf("cases/%s/reports/report_%s.%s", job.CaseID, time.Now().Format("20060102_150405"), extension)
 	uploadSucceeded := false
 
 	if w.storage != nil {
-		err = w.storage.Upload(ctx, "assets", storageKey, []byte(reportHTML), "text/html")
+		err = w.storage.Upload(ctx, "assets", storageKey, data, contentType)
 		if err != nil {
 			// Log warning but don't fail - report was generated successfully
 			log.Printf("Warning: failed to upload report to storage: %v (report generated locally)", err)
@@ -112,9 +166,20 @@ func (w *ExportWorker) Process(ctx context.Context, job *queue.JobMessage) error
 
 	// Mark job as done
 	output := map[string]interface{}{
-		"format":       "html",
+		"format":       input.Format,
 		"generated_at": time.Now().Format(time.RFC3339),
 	}
+	switch input.Format {
+	case models.ExportFormatGLTF:
+		if sceneCommit != uuid.Nil {
+			output["commit_id"] = sceneCommit
+		}
+	case models.ExportFormatHTML, models.ExportFormatPDF:
+		output["sections"] = input.Sections
+		if input.Template != "" {
+			output["template"] = input.Template
+		}
+	}
 	if uploadSucceeded {
 		output["report_asset_key"] = storageKey
 	}
@@ -125,158 +190,312 @@ func (w *ExportWorker) Process(ctx context.Context, job *queue.JobMessage) error
 	return nil
 }
 
-func generateHTMLReport(
+// buildReport gathers the report contents for the case, or for one branch or
+// commit of it, keeping the chosen sections and applying the redaction rules
+func (w *ExportWorker) buildReport(
+	ctx context.Context,
+	input *models.ExportInput,
 	caseData *models.Case,
-	commits []models.Commit,
 	snapshot *models.SceneSnapshot,
 	profile *models.SuspectProfile,
-) (string, error) {
-	const reportTemplate = `<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Case Report: {{.Case.Title}}</title>
-    <style>
-        * { margin: 0; padding: 0; box-sizing: border-box; }
-        body {
-            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif;
-            background: #0a0a0c;
-            color: #f0f0f2;
-            line-height: 1.6;
-            padding: 2rem;
-        }
-        .container { max-width: 900px; margin: 0 auto; }
-        header {
-            border-bottom: 1px solid #2a2a32;
-            padding-bottom: 1.5rem;
-            margin-bottom: 2rem;
-        }
-        h1 { font-size: 2rem; color: #f0f0f2; }
-        h2 { font-size: 1.25rem; color: #a0a0a8; margin: 2rem 0 1rem; border-bottom: 1px solid #1e1e24; padding-bottom: 0.5rem; }
-        h3 { font-size: 1rem; color: #8b5cf6; margin: 1rem 0 0.5rem; }
-        .meta { color: #606068; font-size: 0.875rem; margin-top: 0.5rem; }
-        .section { background: #111114; border: 1px solid #1e1e24; border-radius: 8px; padding: 1.5rem; margin-bottom: 1rem; }
-        .badge { display: inline-block; padding: 0.25rem 0.75rem; border-radius: 9999px; font-size: 0.75rem; font-weight: 500; }
-        .badge-blue { background: rgba(59, 130, 246, 0.1); color: #3b82f6; }
-        .badge-green { background: rgba(34, 197, 94, 0.1); color: #22c55e; }
-        .badge-amber { background: rgba(245, 158, 11, 0.1); color: #f59e0b; }
-        .badge-purple { background: rgba(139, 92, 246, 0.1); color: #8b5cf6; }
-        .timeline { list-style: none; }
-        .timeline li { position: relative; padding: 1rem 0 1rem 2rem; border-left: 2px solid #2a2a32; }
-        .timeline li::before { content: ''; position: absolute; left: -5px; top: 1.25rem; width: 8px; height: 8px; border-radius: 50%; background: #3b82f6; }
-        .grid { display: grid; grid-template-columns: repeat(2, 1fr); gap: 1rem; }
-        .attribute { display: flex; justify-content: space-between; padding: 0.5rem 0; border-bottom: 1px solid #1e1e24; }
-        .attribute-label { color: #606068; }
-        .attribute-value { color: #f0f0f2; }
-        .evidence-card { background: #1f1f24; padding: 1rem; border-radius: 6px; margin-bottom: 0.75rem; }
-        .evidence-title { font-weight: 500; margin-bottom: 0.5rem; }
-        .evidence-desc { color: #a0a0a8; font-size: 0.875rem; }
-        footer { margin-top: 3rem; padding-top: 1.5rem; border-top: 1px solid #2a2a32; text-align: center; color: #606068; font-size: 0.75rem; }
-        .logo { display: flex; align-items: center; gap: 0.5rem; margin-bottom: 0.5rem; justify-content: center; }
-        .logo-icon { width: 24px; height: 24px; background: linear-gradient(135deg, #6366f1, #8b5cf6); border-radius: 6px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <header>
-            <h1>{{.Case.Title}}</h1>
-            <p class="meta">Case ID: {{.Case.ID}} | Generated: {{.GeneratedAt}}</p>
-            {{if .Case.Description}}<p style="margin-top: 0.5rem; color: #a0a0a8;">{{.Case.Description}}</p>{{end}}
-        </header>
-
-        <h2>Timeline ({{len .Commits}} events)</h2>
-        <ul class="timeline">
-            {{range .Commits}}
-            <li>
-                <div style="display: flex; justify-content: space-between; align-items: center;">
-                    <span class="badge badge-blue">{{.Type}}</span>
-                    <span class="meta">{{.CreatedAt.Format "Jan 2, 2006 3:04 PM"}}</span>
-                </div>
-                <p style="margin-top: 0.5rem;">{{.Summary}}</p>
-            </li>
-            {{end}}
-        </ul>
-
-        {{if .HasProfile}}
-        <h2>Suspect Profile</h2>
-        <div class="section">
-            <p style="color: #a0a0a8;">Profile attributes on file.</p>
-        </div>
-        {{end}}
-
-        <h2>Evidence ({{len .Evidence}} items)</h2>
-        {{range .Evidence}}
-        <div class="evidence-card">
-            <div class="evidence-title">{{.Title}}</div>
-            <div class="evidence-desc">{{.Description}}</div>
-            <div style="margin-top: 0.5rem;">
-                <span class="badge badge-{{if ge .Confidence 0.8}}green{{else if ge .Confidence 0.5}}amber{{else}}blue{{end}}">{{printf "%.0f%%" (mul .Confidence 100)}} confidence</span>
-            </div>
-        </div>
-        {{end}}
-
-        <h2>Scene Summary</h2>
-        <div class="section">
-            <p style="color: #a0a0a8;">Objects detected: {{len .Objects}}</p>
-            {{range .Objects}}
-            <div style="padding: 0.5rem 0; border-bottom: 1px solid #1e1e24;">
-                <span class="badge badge-blue">{{.Type}}</span>
-                <span style="margin-left: 0.5rem;">{{.Label}}</span>
-            </div>
-            {{end}}
-        </div>
-
-        <footer>
-            <div class="logo">
-                <div class="logo-icon"></div>
-                <span>SherlockOS</span>
-            </div>
-            <p>This report is for investigative purposes only. AI-generated hypotheses should be verified with physical evidence.</p>
-            <p style="margin-top: 0.25rem;">Generated by SherlockOS v0.1 | {{.GeneratedAt}}</p>
-        </footer>
-    </div>
-</body>
-</html>`
-
-	// Create template functions
-	funcMap := template.FuncMap{
-		"mul": func(a, b float64) float64 { return a * b },
-	}
-
-	tmpl, err := template.New("report").Funcs(funcMap).Parse(reportTemplate)
+) (*report.Report, error) {
+	var commits []models.Commit
+	target, scope, err := w.reportTarget(ctx, input, caseData.ID)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
+		return nil, err
 	}
+	if target == nil {
+		if commits, err = w.allCommits(ctx, caseData.ID); err != nil {
+			return nil, NewRetryableError(err)
+		}
+	} else {
+		chain, err := w.repo.GetCommitChain(ctx, caseData.ID, *target)
+		if err != nil {
+			return nil, NewRetryableError(fmt.Errorf("failed to get commit history: %w", err))
+		}
+		inChain := make(map[uuid.UUID]bool)
+		for _, c := range chain {
+			commits = append(commits, *c)
+			inChain[c.ID] = true
+		}
 
-	// Build template data
-	var evidence []models.EvidenceCard
-	var objects []models.SceneObject
-	if snapshot != nil && snapshot.Scenegraph != nil {
-		evidence = snapshot.Scenegraph.Evidence
-		objects = snapshot.Scenegraph.Objects
+		// The stored snapshot and profile are only current for the case's
+		// latest commit; earlier scenes are replayed, and a profile made
+		// after the target is left out
+		if snapshot.CommitID != *target {
+			sg, err := w.repo.ReplayToCommit(ctx, caseData.ID, *target)
+			if err != nil {
+				return nil, NewRetryableError(fmt.Errorf("failed to replay scene: %w", err))
+			}
+			snapshot = &models.SceneSnapshot{CaseID: caseData.ID, CommitID: *target, Scenegraph: sg}
+		}
+		if profile != nil && !inChain[profile.CommitID] {
+			profile = nil
+		}
 	}
 
-	data := struct {
-		Case        *models.Case
-		Commits     []models.Commit
-		HasProfile  bool
-		Evidence    []models.EvidenceCard
-		Objects     []models.SceneObject
-		GeneratedAt string
-	}{
-		Case:        caseData,
-		Commits:     commits,
-		HasProfile:  profile != nil,
-		Evidence:    evidence,
-		Objects:     objects,
-		GeneratedAt: time.Now().Format("January 2, 2006 3:04 PM"),
+	rep := report.Build(caseData, commits, snapshot, profile, time.Now())
+	rep.Scope = scope
+	rep.Sections = input.Sections
+	rep.EvidenceTiers = input.EvidenceTiers
+	if rep.Has(models.ReportSectionCustodyLog) {
+		assets, err := w.repo.GetAssetsByCase(ctx, caseData.ID, nil)
+		if err != nil {
+			return nil, NewRetryableError(fmt.Errorf("failed to get assets: %w", err))
+		}
+		var list []models.Asset
+		for _, a := range assets {
+			list = append(list, *a)
+		}
+		rep.Custody = report.Custody(list, rep.Timeline)
+	}
+	if rep.Has(models.ReportSectionAuditLog) {
+		entries, err := w.repo.GetAuditTrail(ctx, caseData.ID, rep.GeneratedAt)
+		if err != nil {
+			return nil, NewRetryableError(fmt.Errorf("failed to get audit log: %w", err))
+		}
+		for _, e := range entries {
+			rep.Audit = append(rep.Audit, *e)
+		}
 	}
+	rep.Redact(input.Redaction)
+	return rep, nil
+}
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("failed to execute template: %w", err)
+// exportScene encodes the case's scene, or the scene at the requested branch
+// or commit, as GLB with the trajectories of the latest reasoning result on
+// that history and references to the case's generated models
+func (w *ExportWorker) exportScene(
+	ctx context.Context,
+	input *models.ExportInput,
+	caseID uuid.UUID,
+	snapshot *models.SceneSnapshot,
+) ([]byte, uuid.UUID, error) {
+	target, _, err := w.reportTarget(ctx, input, caseID)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	sg, commitID := snapshot.Scenegraph, snapshot.CommitID
+	if target != nil && *target != snapshot.CommitID {
+		if sg, err = w.repo.ReplayToCommit(ctx, caseID, *target); err != nil {
+			return nil, uuid.Nil, NewRetryableError(fmt.Errorf("failed to replay scene: %w", err))
+		}
+		commitID = *target
 	}
 
-	return buf.String(), nil
+	var trajectories []models.Trajectory
+	if commitID != uuid.Nil {
+		chain, err := w.repo.GetCommitChain(ctx, caseID, commitID)
+		if err != nil {
+			return nil, uuid.Nil, NewRetryableError(fmt.Errorf("failed to get commit history: %w", err))
+		}
+		commits := make([]models.Commit, len(chain))
+		for i, c := range chain {
+			commits[i] = *c
+		}
+		trajectories = report.LatestTrajectories(commits)
+	}
+
+	kind := models.AssetKindEvidenceModel
+	assets, err := w.repo.GetAssetsByCase(ctx, caseID, &kind)
+	if err != nil {
+		return nil, uuid.Nil, NewRetryableError(fmt.Errorf("failed to get assets: %w", err))
+	}
+	var evidenceModels []models.Asset
+	for _, a := range assets {
+		evidenceModels = append(evidenceModels, *a)
+	}
+
+	data, err := gltf.Encode(gltf.Input{Scene: sg, CommitID: commitID, Trajectories: trajectories, Models: evidenceModels})
+	if err != nil {
+		return nil, uuid.Nil, NewFatalError(err)
+	}
+	return data, commitID, nil
+}
+
+// reportTarget resolves the commit a report is scoped to, or nil for the
+// whole case, along with a description of the scope
+func (w *ExportWorker) reportTarget(ctx context.Context, input *models.ExportInput, caseID uuid.UUID) (*uuid.UUID, string, error) {
+	switch {
+	case input.CommitID != nil:
+		c, err := w.repo.GetCommit(ctx, *input.CommitID)
+		if err != nil {
+			return nil, "", NewRetryableError(fmt.Errorf("failed to get commit: %w", err))
+		}
+		if c == nil || c.CaseID != caseID {
+			return nil, "", NewFatalError(fmt.Errorf("commit %s not found in case", *input.CommitID))
+		}
+		return &c.ID, fmt.Sprintf("As of commit %s (%s)", c.ID, c.CreatedAt.Format("January 2, 2006 3:04 PM")), nil
+
+	case input.BranchID != nil:
+		b, err := w.repo.GetBranch(ctx, *input.BranchID)
+		if err != nil {
+			return nil, "", NewRetryableError(fmt.Errorf("failed to get branch: %w", err))
+		}
+		if b == nil || b.CaseID != caseID {
+			return nil, "", NewFatalError(fmt.Errorf("branch %s not found in case", *input.BranchID))
+		}
+		head, err := w.repo.GetBranchHead(ctx, b.ID)
+		if err != nil {
+			return nil, "", NewRetryableError(fmt.Errorf("failed to get branch head: %w", err))
+		}
+		// A branch with no commits of its own reports on its base
+		target := b.BaseCommitID
+		if head != nil {
+			target = head.ID
+		}
+		if target == uuid.Nil {
+			return nil, "", NewFatalError(fmt.Errorf("branch %s has no commits", b.ID))
+		}
+		return &target, fmt.Sprintf("Branch %q at commit %s", b.Name, target), nil
+	}
+	return nil, "", nil
+}
+
+// loadTemplate finds a named report template, first in the database and then
+// in the template directory. An empty name or "default" is the built-in one.
+func (w *ExportWorker) loadTemplate(ctx context.Context, name string) (*template.Template, error) {
+	if name == "" || name == models.DefaultReportTemplate {
+		return report.DefaultTemplate(), nil
+	}
+
+	t, err := w.repo.GetReportTemplate(ctx, name)
+	if err != nil {
+		return nil, NewRetryableError(fmt.Errorf("failed to get report template: %w", err))
+	}
+	var body string
+	if t != nil {
+		body = t.Body
+	} else {
+		body, err = report.LoadTemplateFile(w.templateDir, name)
+		if errors.Is(err, report.ErrTemplateNotFound) {
+			return nil, NewFatalError(fmt.Errorf("unknown report template %q", name))
+		}
+		if err != nil {
+			return nil, NewRetryableError(fmt.Errorf("failed to read report template: %w", err))
+		}
+	}
+
+	tmpl, err := report.ParseTemplate(name, body)
+	if err != nil {
+		return nil, NewFatalError(err)
+	}
+	return tmpl, nil
+}
+
+// allCommits pages through every commit in the case, newest first
+func (w *ExportWorker) allCommits(ctx context.Context, caseID uuid.UUID) ([]models.Commit, error) {
+	commits, err := pageCommits(commitPageSize, func(after *db.CommitCursor) ([]*models.Commit, error) {
+		return w.repo.GetCommitsPage(ctx, caseID, commitPageSize, after)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commits: %w", err)
+	}
+	return commits, nil
+}
+
+// pageCommits reads pages of pageSize commits from fetch, each after the
+// last commit of the one before, until a short page
+func pageCommits(pageSize int, fetch func(after *db.CommitCursor) ([]*models.Commit, error)) ([]models.Commit, error) {
+	var commits []models.Commit
+	var after *db.CommitCursor
+	for {
+		page, err := fetch(after)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range page {
+			commits = append(commits, *c)
+		}
+		if len(page) < pageSize {
+			return commits, nil
+		}
+		last := page[len(page)-1]
+		after = &db.CommitCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+// commitPageSize is how many commits are read at a time when exporting a case
+const commitPageSize = 500
+
+// collectBundle gathers everything in a case for a bundle export: every
+// commit, the branches, snapshot, profile, asset records and the stored files
+// behind them. Files that can't be downloaded are returned as missing rather
+// than failing the export.
+func (w *ExportWorker) collectBundle(
+	ctx context.Context,
+	caseData *models.Case,
+	snapshot *models.SceneSnapshot,
+	profile *models.SuspectProfile,
+) (*bundle.Bundle, []string, error) {
+	b := &bundle.Bundle{Case: *caseData, Profile: profile}
+	// A snapshot that was never stored has no commit and isn't exported
+	if snapshot != nil && snapshot.CommitID != uuid.Nil {
+		b.Snapshot = snapshot
+	}
+
+	commits, err := w.allCommits(ctx, caseData.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	b.Commits = bundle.SortCommits(commits)
+
+	branches, err := w.repo.GetBranchesByCase(ctx, caseData.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get branches: %w", err)
+	}
+	for _, br := range branches {
+		b.Branches = append(b.Branches, *br)
+	}
+
+	assets, err := w.repo.GetAssetsByCase(ctx, caseData.ID, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get assets: %w", err)
+	}
+	var keys []string
+	for _, a := range assets {
+		b.Assets = append(b.Assets, *a)
+		keys = append(keys, a.StorageKey)
+		if thumb, ok := a.Metadata["thumbnail_key"].(string); ok && thumb != "" {
+			keys = append(keys, thumb)
+		}
+	}
+	if profile != nil && profile.PortraitAssetKey != "" {
+		keys = append(keys, profile.PortraitAssetKey)
+	}
+
+	var missing []string
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if w.storage == nil {
+			missing = append(missing, key)
+			continue
+		}
+		data, contentType, err := w.storage.Download(ctx, portraitBucket, key)
+		if err != nil {
+			log.Printf("Warning: failed to download %s for bundle: %v", key, err)
+			missing = append(missing, key)
+			continue
+		}
+		b.Objects = append(b.Objects, bundle.Object{StorageKey: key, ContentType: contentType, Data: data})
+	}
+	return b, missing, nil
+}
+
+// fetchPortrait downloads the suspect portrait for embedding in a PDF. The
+// report is still produced without it if the download fails.
+func (w *ExportWorker) fetchPortrait(ctx context.Context, key string) []byte {
+	if w.storage == nil {
+		return nil
+	}
+	data, _, err := w.storage.Download(ctx, portraitBucket, key)
+	if err != nil {
+		log.Printf("Warning: failed to download portrait %s: %v", key, err)
+		return nil
+	}
+	return data
 }
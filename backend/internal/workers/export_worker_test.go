@@ -0,0 +1,58 @@
+package workers
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/db"
+	"github.com/sherlockos/backend/internal/models"
+)
+
+func TestPageCommits_TiedTimestamps(t *testing.T) {
+	// Seven commits, four of them written in the same instant, read three at a
+	// time so the tie straddles a page boundary
+	base := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	var all []*models.Commit
+	for i, offset := range []time.Duration{3, 2, 1, 1, 1, 1, 0} {
+		all = append(all, &models.Commit{ID: uuid.New(), Summary: string(rune('a' + i)), CreatedAt: base.Add(offset * time.Second)})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if !all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].CreatedAt.After(all[j].CreatedAt)
+		}
+		return bytes.Compare(all[i].ID[:], all[j].ID[:]) > 0
+	})
+
+	// fetch mirrors GetCommitsPage's (created_at, id) ordering
+	const pageSize = 3
+	fetch := func(after *db.CommitCursor) ([]*models.Commit, error) {
+		var page []*models.Commit
+		for _, c := range all {
+			if after != nil && !(c.CreatedAt.Before(after.CreatedAt) ||
+				c.CreatedAt.Equal(after.CreatedAt) && bytes.Compare(c.ID[:], after.ID[:]) < 0) {
+				continue
+			}
+			if page = append(page, c); len(page) == pageSize {
+				break
+			}
+		}
+		return page, nil
+	}
+
+	commits, err := pageCommits(pageSize, fetch)
+	if err != nil {
+		t.Fatalf("pageCommits() error = %v", err)
+	}
+	if len(commits) != len(all) {
+		t.Fatalf("pageCommits() returned %d commits, want %d", len(commits), len(all))
+	}
+	for i := range all {
+		if commits[i].ID != all[i].ID {
+			t.Errorf("commit %d = %s, want %s", i, commits[i].Summary, all[i].Summary)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package workers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -10,21 +11,38 @@ import (
 	"github.com/google/uuid"
 	"github.com/sherlockos/backend/internal/clients"
 	"github.com/sherlockos/backend/internal/db"
+	"github.com/sherlockos/backend/internal/floorplan"
 	"github.com/sherlockos/backend/internal/models"
 	"github.com/sherlockos/backend/internal/queue"
+	"github.com/sherlockos/backend/internal/report"
 )
 
+// floorPlanBucket is the storage bucket that holds rendered floor plans
+const floorPlanBucket = "case-assets"
+
+// floorPlanWidth is the width in pixels of floor plans given to the image model
+const floorPlanWidth = 1024
+
 // ImageGenWorker handles image generation jobs
 type ImageGenWorker struct {
 	*BaseWorker
-	client clients.ImageGenClient
+	client  clients.ImageGenClient
+	storage clients.StorageClient
 }
 
 // NewImageGenWorker creates a new image generation worker
 func NewImageGenWorker(database *db.DB, q queue.JobQueue, client clients.ImageGenClient) *ImageGenWorker {
+	return NewImageGenWorkerWithStorage(database, q, client, nil)
+}
+
+// NewImageGenWorkerWithStorage creates an image generation worker that
+// renders the scene's floor plan as the reference image of evidence boards
+// that don't name one
+func NewImageGenWorkerWithStorage(database *db.DB, q queue.JobQueue, client clients.ImageGenClient, storage clients.StorageClient) *ImageGenWorker {
 	return &ImageGenWorker{
 		BaseWorker: NewBaseWorker(database, q),
 		client:     client,
+		storage:    storage,
 	}
 }
 
@@ -49,6 +67,16 @@ func (w *ImageGenWorker) Process(ctx context.Context, job *queue.JobMessage) err
 	// Update progress: starting
 	w.UpdateJobProgress(ctx, job.JobID, 10)
 
+	// Lay evidence boards out over the scene's floor plan
+	if input.GenType == models.ImageGenTypeEvidenceBoard && input.ReferenceImageKey == "" {
+		caseID, _ := uuid.Parse(input.CaseID)
+		key, err := w.floorPlanReference(ctx, caseID)
+		if err != nil {
+			fmt.Printf("Warning: failed to render floor plan for evidence board: %v\n", err)
+		}
+		input.ReferenceImageKey = key
+	}
+
 	// Generate image
 	output, err := w.client.Generate(ctx, input)
 	if err != nil {
@@ -56,6 +84,10 @@ func (w *ImageGenWorker) Process(ctx context.Context, job *queue.JobMessage) err
 		return NewRetryableError(fmt.Errorf("image generation failed: %w", err))
 	}
 
+	if err := w.RecordJobCost(ctx, job.JobID, output.CostUSD); err != nil {
+		fmt.Printf("Warning: failed to record job cost: %v\n", err)
+	}
+
 	// Update progress: generation complete
 	w.UpdateJobProgress(ctx, job.JobID, 70)
 
@@ -87,12 +119,83 @@ func (w *ImageGenWorker) Process(ctx context.Context, job *queue.JobMessage) err
 		"generation_time": output.GenerationTime,
 		"cost_usd":        output.CostUSD,
 	}
+	if input.ReferenceImageKey != "" {
+		outputWithAsset["reference_image_key"] = input.ReferenceImageKey
+	}
 
 	w.MarkJobDone(ctx, job.JobID, outputWithAsset)
 
 	return nil
 }
 
+// floorPlanReference renders the floor plan of the case's current scene, with
+// the trajectories of the latest reasoning result behind it, and stores it.
+// It returns "" when there is no storage, no scene or nothing to draw.
+func (w *ImageGenWorker) floorPlanReference(ctx context.Context, caseID uuid.UUID) (string, error) {
+	if w.repo == nil || w.storage == nil {
+		return "", nil
+	}
+	snapshot, err := w.repo.GetSceneSnapshot(ctx, caseID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get snapshot: %w", err)
+	}
+	if snapshot == nil || snapshot.Scenegraph == nil {
+		return "", nil
+	}
+
+	var trajectories []models.Trajectory
+	if snapshot.CommitID != uuid.Nil {
+		chain, err := w.repo.GetCommitChain(ctx, caseID, snapshot.CommitID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get commit history: %w", err)
+		}
+		commits := make([]models.Commit, len(chain))
+		for i, c := range chain {
+			commits[i] = *c
+		}
+		trajectories = report.LatestTrajectories(commits)
+	}
+	return w.storeFloorPlan(ctx, caseID, snapshot.Scenegraph, trajectories)
+}
+
+// storeFloorPlan renders a floor plan as PNG, uploads it and records it as
+// an asset of the case, returning its storage key
+func (w *ImageGenWorker) storeFloorPlan(ctx context.Context, caseID uuid.UUID, sg *models.SceneGraph, trajectories []models.Trajectory) (string, error) {
+	opts := floorplan.DefaultOptions()
+	opts.Width = floorPlanWidth
+	plan, err := floorplan.Build(sg, trajectories, opts)
+	if errors.Is(err, floorplan.ErrEmptyScene) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	data, err := plan.PNG()
+	if err != nil {
+		return "", fmt.Errorf("failed to encode floor plan: %w", err)
+	}
+
+	key := fmt.Sprintf("cases/%s/floorplans/%s.png", caseID, uuid.New())
+	if err := w.storage.Upload(ctx, floorPlanBucket, key, data, floorplan.PNGContentType); err != nil {
+		return "", fmt.Errorf("failed to upload floor plan: %w", err)
+	}
+
+	if w.repo != nil {
+		asset := models.NewAsset(caseID, models.AssetKindGeneratedImage, key)
+		asset.Metadata = map[string]interface{}{
+			"width":        plan.Width,
+			"height":       plan.Height,
+			"content_type": floorplan.PNGContentType,
+			"size_bytes":   len(data),
+			"purpose":      "floor_plan",
+		}
+		if err := w.repo.CreateAsset(ctx, asset); err != nil {
+			fmt.Printf("Warning: failed to create asset for floor plan: %v\n", err)
+		}
+	}
+	return key, nil
+}
+
 // createAssetRecord creates an asset record in the database
 func (w *ImageGenWorker) createAssetRecord(ctx context.Context, caseID uuid.UUID, input models.ImageGenInput, output *models.ImageGenOutput) (string, error) {
 	if w.repo == nil {
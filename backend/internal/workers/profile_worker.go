@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sherlockos/backend/internal/auth"
 	"github.com/sherlockos/backend/internal/clients"
 	"github.com/sherlockos/backend/internal/db"
 	"github.com/sherlockos/backend/internal/models"
@@ -85,6 +86,9 @@ func (w *ProfileWorker) Process(ctx context.Context, job *queue.JobMessage) erro
 	// Create profile update commit
 	commitID, err := w.createProfileCommit(ctx, caseID, job.JobID, mergedAttrs, conflicts)
 	if err != nil {
+		if fatal := caseClosedError(err); fatal != nil {
+			return fatal
+		}
 		fmt.Printf("Warning: failed to create profile commit: %v\n", err)
 	}
 
@@ -101,6 +105,9 @@ func (w *ProfileWorker) Process(ctx context.Context, job *queue.JobMessage) erro
 		if err == nil {
 			imageGenTriggered = true
 			imageGenJobID = jobID
+		} else {
+			// The profile stands without a portrait, e.g. once over budget
+			fmt.Printf("Warning: portrait generation not started: %v\n", err)
 		}
 	}
 
@@ -291,8 +298,9 @@ func (w *ProfileWorker) triggerImageGenJob(ctx context.Context, caseID uuid.UUID
 	if err != nil {
 		return "", err
 	}
+	job.CreatedBy = auth.UserID(ctx)
 
-	if err := w.repo.CreateJob(ctx, job); err != nil {
+	if err := w.CreateJob(ctx, job); err != nil {
 		return "", err
 	}
 
@@ -321,6 +329,7 @@ func (w *ProfileWorker) createProfileCommit(ctx context.Context, caseID, jobID u
 	if err != nil {
 		return uuid.Nil, err
 	}
+	commit.CreatedBy = auth.UserID(ctx)
 
 	// Get latest commit as parent
 	latestCommit, _ := w.repo.GetLatestCommit(ctx, caseID)
@@ -328,7 +337,7 @@ func (w *ProfileWorker) createProfileCommit(ctx context.Context, caseID, jobID u
 		commit.SetParent(latestCommit.ID)
 	}
 
-	if err := w.repo.CreateCommit(ctx, commit); err != nil {
+	if err := w.SaveCommit(ctx, jobID, commit); err != nil {
 		return uuid.Nil, err
 	}
 
@@ -6,26 +6,45 @@ import (
 	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/sherlockos/backend/internal/auth"
 	"github.com/sherlockos/backend/internal/clients"
+	"github.com/sherlockos/backend/internal/constraints"
 	"github.com/sherlockos/backend/internal/db"
+	"github.com/sherlockos/backend/internal/grounding"
 	"github.com/sherlockos/backend/internal/models"
+	"github.com/sherlockos/backend/internal/navmesh"
 	"github.com/sherlockos/backend/internal/queue"
+	"github.com/sherlockos/backend/internal/scoring"
 )
 
 // ReasoningWorker handles trajectory reasoning jobs
 type ReasoningWorker struct {
 	*BaseWorker
-	client clients.ReasoningClient
+	client       clients.ReasoningClient
+	navOptions   navmesh.Options
+	scoreWeights scoring.Weights
+	minGrounding float64
 }
 
 // NewReasoningWorker creates a new reasoning worker
 func NewReasoningWorker(database *db.DB, q queue.JobQueue, client clients.ReasoningClient) *ReasoningWorker {
 	return &ReasoningWorker{
-		BaseWorker: NewBaseWorker(database, q),
-		client:     client,
+		BaseWorker:   NewBaseWorker(database, q),
+		client:       client,
+		navOptions:   navmesh.DefaultOptions(),
+		scoreWeights: scoring.DefaultWeights(),
+		minGrounding: grounding.DefaultThreshold,
 	}
 }
 
+// NewReasoningWorkerWithGrounding creates a reasoning worker that requires at
+// least minGrounding of the model's references to resolve; 0 disables the check
+func NewReasoningWorkerWithGrounding(database *db.DB, q queue.JobQueue, client clients.ReasoningClient, minGrounding float64) *ReasoningWorker {
+	w := NewReasoningWorker(database, q, client)
+	w.minGrounding = minGrounding
+	return w
+}
+
 // Type returns the job type this worker handles
 func (w *ReasoningWorker) Type() models.JobType {
 	return models.JobTypeReasoning
@@ -55,12 +74,37 @@ func (w *ReasoningWorker) Process(ctx context.Context, job *queue.JobMessage) er
 		return NewRetryableError(fmt.Errorf("reasoning failed: %w", err))
 	}
 
+	// Drop references to evidence and objects that aren't in the scene
+	output.Grounding = grounding.Resolve(input.Scenegraph, output, w.minGrounding)
+	if !grounding.Passed(output.Grounding) {
+		err := fmt.Errorf("reasoning output is poorly grounded: %d of %d references resolve (ratio %.2f, minimum %.2f)",
+			output.Grounding.Resolved, output.Grounding.References, output.Grounding.Ratio, output.Grounding.Threshold)
+		w.MarkJobFailed(ctx, job.JobID, err)
+		return NewRetryableError(err)
+	}
+
+	// Update progress: reasoning complete
+	w.UpdateJobProgress(ctx, job.JobID, 70)
+	scoring.RecordModelScores(output.Trajectories)
+
+	// Check the trajectories against walls and obstacles
+	feasibility := w.checkFeasibility(job.JobID, input, output)
+
+	// Report which constraints each trajectory satisfies or violates
+	w.evaluateConstraints(job.JobID, input, output)
+
+	// Recompute confidences from evidence, feasibility and constraints and re-rank
+	w.scoreTrajectories(job.JobID, input, output)
+
 	// Update progress: processing complete
 	w.UpdateJobProgress(ctx, job.JobID, 80)
 
 	// Create commit with reasoning_result type
 	caseID, _ := uuid.Parse(input.CaseID)
-	if err := w.createReasoningCommit(ctx, caseID, job.JobID, input, output); err != nil {
+	if err := w.createReasoningCommit(ctx, caseID, job.JobID, input, output, feasibility); err != nil {
+		if fatal := caseClosedError(err); fatal != nil {
+			return fatal
+		}
 		fmt.Printf("Warning: failed to create reasoning commit: %v\n", err)
 	}
 
@@ -71,8 +115,40 @@ func (w *ReasoningWorker) Process(ctx context.Context, job *queue.JobMessage) er
 	return nil
 }
 
+// checkFeasibility marks every trajectory segment feasible, detour-required or
+// infeasible on the scene's occupancy grid, scales confidences accordingly
+// and re-ranks the trajectories
+func (w *ReasoningWorker) checkFeasibility(jobID uuid.UUID, input models.ReasoningInput, output *models.ReasoningOutput) navmesh.Summary {
+	validator := navmesh.NewValidator(input.Scenegraph, input.ConstraintsOverride, w.navOptions)
+	summary := validator.Apply(output.Trajectories)
+	fmt.Printf("Reasoning job %s: %d feasible, %d detour-required, %d infeasible segments\n",
+		jobID, summary.Feasible, summary.DetourRequired, summary.Infeasible)
+	return summary
+}
+
+// evaluateConstraints attaches a per-constraint report to every trajectory
+func (w *ReasoningWorker) evaluateConstraints(jobID uuid.UUID, input models.ReasoningInput, output *models.ReasoningOutput) {
+	evaluator := constraints.NewEvaluator(input.Scenegraph, input.ConstraintsOverride)
+	if len(evaluator.Constraints()) == 0 {
+		return
+	}
+	violating := evaluator.Apply(output.Trajectories)
+	fmt.Printf("Reasoning job %s: %d of %d trajectories violate a constraint\n",
+		jobID, violating, len(output.Trajectories))
+}
+
+// scoreTrajectories replaces each trajectory's confidence with the computed
+// score, keeping the model's score alongside, and re-ranks
+func (w *ReasoningWorker) scoreTrajectories(jobID uuid.UUID, input models.ReasoningInput, output *models.ReasoningOutput) {
+	scorer := scoring.NewScorer(input.Scenegraph, w.scoreWeights)
+	output.Scoring = scorer.Apply(output.Trajectories)
+	if output.Scoring.Reranked {
+		fmt.Printf("Reasoning job %s: trajectories re-ranked by computed score\n", jobID)
+	}
+}
+
 // createReasoningCommit creates a commit for reasoning results
-func (w *ReasoningWorker) createReasoningCommit(ctx context.Context, caseID, jobID uuid.UUID, input models.ReasoningInput, output *models.ReasoningOutput) error {
+func (w *ReasoningWorker) createReasoningCommit(ctx context.Context, caseID, jobID uuid.UUID, input models.ReasoningInput, output *models.ReasoningOutput, feasibility navmesh.Summary) error {
 	if w.repo == nil {
 		return nil
 	}
@@ -86,6 +162,9 @@ func (w *ReasoningWorker) createReasoningCommit(ctx context.Context, caseID, job
 		"model_stats":         output.ModelStats,
 		"thinking_budget":     input.ThinkingBudget,
 		"max_trajectories":    input.MaxTrajectories,
+		"feasibility":         feasibility,
+		"scoring":             output.Scoring,
+		"grounding":           output.Grounding,
 	}
 
 	// Add branch ID if present
@@ -97,11 +176,15 @@ func (w *ReasoningWorker) createReasoningCommit(ctx context.Context, caseID, job
 	if len(output.Trajectories) > 0 {
 		summary += fmt.Sprintf(" (top confidence: %.1f%%)", output.Trajectories[0].OverallConfidence*100)
 	}
+	if output.Grounding != nil && len(output.Grounding.Unresolved) > 0 {
+		summary += fmt.Sprintf("; %d unresolved references removed", len(output.Grounding.Unresolved))
+	}
 
 	commit, err := models.NewCommit(caseID, models.CommitTypeReasoningResult, summary, payload)
 	if err != nil {
 		return err
 	}
+	commit.CreatedBy = auth.UserID(ctx)
 
 	// Get latest commit as parent
 	latestCommit, _ := w.repo.GetLatestCommit(ctx, caseID)
@@ -117,5 +200,5 @@ func (w *ReasoningWorker) createReasoningCommit(ctx context.Context, caseID, job
 		}
 	}
 
-	return w.repo.CreateCommit(ctx, commit)
+	return w.SaveCommit(ctx, jobID, commit)
 }
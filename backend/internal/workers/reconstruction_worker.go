@@ -7,23 +7,46 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sherlockos/backend/internal/auth"
 	"github.com/sherlockos/backend/internal/clients"
 	"github.com/sherlockos/backend/internal/db"
+	"github.com/sherlockos/backend/internal/detection"
+	"github.com/sherlockos/backend/internal/layout"
+	"github.com/sherlockos/backend/internal/lifting"
 	"github.com/sherlockos/backend/internal/models"
+	"github.com/sherlockos/backend/internal/pointcloud"
 	"github.com/sherlockos/backend/internal/queue"
 )
 
+// pointCloudBucket is the storage bucket that holds full reconstruction point clouds
+const pointCloudBucket = "case-assets"
+
 // ReconstructionWorker handles scene reconstruction jobs
 type ReconstructionWorker struct {
 	*BaseWorker
-	client clients.ReconstructionClient
+	client         clients.ReconstructionClient
+	storage        clients.StorageClient
+	processOptions pointcloud.ProcessOptions
+	layoutOptions  layout.Options
+	liftOptions    lifting.Options
 }
 
-// NewReconstructionWorker creates a new reconstruction worker
+// NewReconstructionWorker creates a new reconstruction worker. Without storage,
+// point clouds stay inline in the SceneGraph.
 func NewReconstructionWorker(database *db.DB, q queue.JobQueue, client clients.ReconstructionClient) *ReconstructionWorker {
+	return NewReconstructionWorkerWithStorage(database, q, client, nil)
+}
+
+// NewReconstructionWorkerWithStorage creates a reconstruction worker that writes
+// point clouds to storage as binary PLY and keeps only a preview in the SceneGraph
+func NewReconstructionWorkerWithStorage(database *db.DB, q queue.JobQueue, client clients.ReconstructionClient, storage clients.StorageClient) *ReconstructionWorker {
 	return &ReconstructionWorker{
-		BaseWorker: NewBaseWorker(database, q),
-		client:     client,
+		BaseWorker:     NewBaseWorker(database, q),
+		client:         client,
+		storage:        storage,
+		processOptions: pointcloud.DefaultProcessOptions(),
+		layoutOptions:  layout.DefaultOptions(),
+		liftOptions:    lifting.DefaultOptions(),
 	}
 }
 
@@ -45,6 +68,11 @@ func (w *ReconstructionWorker) Process(ctx context.Context, job *queue.JobMessag
 		return NewFatalError(fmt.Errorf("invalid input: %w", err))
 	}
 
+	// Seed camera intrinsics from ingest metadata when the caller supplied none
+	if len(input.CameraPoses) == 0 {
+		input.CameraPoses = w.cameraPosesFromAssets(ctx, input.ScanAssetKeys)
+	}
+
 	// Check if preprocessing is requested but POV images not yet generated
 	if input.EnablePreprocess && len(input.GeneratedPOVKeys) == 0 {
 		fmt.Printf("Reconstruction job %s: preprocessing enabled, generating POV images first\n", job.JobID)
@@ -80,8 +108,11 @@ func (w *ReconstructionWorker) Process(ctx context.Context, job *queue.JobMessag
 		return NewRetryableError(fmt.Errorf("reconstruction failed: %w", err))
 	}
 
-	// Update progress: processing complete
-	w.UpdateJobProgress(ctx, job.JobID, 60)
+	// Clean the raw cloud and move it and the proposed objects into a Y-up, floor-at-zero frame
+	frame := w.processPointCloud(job.JobID, output)
+
+	// Derive walls, openings and the walkable floor while the full cloud is in memory
+	w.extractLayout(job.JobID, output)
 
 	// Get existing SceneGraph or create empty one
 	existingSG := input.ExistingScenegraph
@@ -89,6 +120,20 @@ func (w *ReconstructionWorker) Process(ctx context.Context, job *queue.JobMessag
 		existingSG = models.NewEmptySceneGraph()
 	}
 
+	// Place the scene's 2D detections in 3D from the camera poses and the full cloud
+	w.liftDetections(job.JobID, existingSG, &input, output, frame)
+
+	// Move the full point cloud to storage before it is copied into the SceneGraph,
+	// commit payload and job output
+	caseID, _ := uuid.Parse(input.CaseID)
+	if err := w.storePointCloud(ctx, caseID, job.JobID, output); err != nil {
+		w.MarkJobFailed(ctx, job.JobID, err)
+		return NewRetryableError(fmt.Errorf("failed to store point cloud: %w", err))
+	}
+
+	// Update progress: processing complete
+	w.UpdateJobProgress(ctx, job.JobID, 60)
+
 	// Merge reconstruction output into SceneGraph
 	newSG := w.mergeReconstructionOutput(existingSG, output)
 
@@ -96,14 +141,19 @@ func (w *ReconstructionWorker) Process(ctx context.Context, job *queue.JobMessag
 	w.UpdateJobProgress(ctx, job.JobID, 80)
 
 	// Create commit with reconstruction_update type
-	caseID, _ := uuid.Parse(input.CaseID)
 	if err := w.createReconstructionCommit(ctx, caseID, job.JobID, &input, output, newSG); err != nil {
+		if fatal := caseClosedError(err); fatal != nil {
+			return fatal
+		}
 		// Log but don't fail - reconstruction succeeded
 		fmt.Printf("Warning: failed to create commit: %v\n", err)
 	}
 
 	// Update scene_snapshot with new SceneGraph
 	if err := w.updateSceneSnapshot(ctx, caseID, newSG); err != nil {
+		if fatal := caseClosedError(err); fatal != nil {
+			return fatal
+		}
 		fmt.Printf("Warning: failed to update scene snapshot: %v\n", err)
 	}
 
@@ -114,6 +164,182 @@ func (w *ReconstructionWorker) Process(ctx context.Context, job *queue.JobMessag
 	return nil
 }
 
+// processPointCloud downsamples and denoises the raw reconstruction, then
+// re-orients the cloud and every proposed object and uncertainty region so the
+// detected floor lies at y = 0 with +Y up. It returns the transform applied,
+// which is the identity when the floor isn't found. Failures leave the output
+// untouched.
+func (w *ReconstructionWorker) processPointCloud(jobID uuid.UUID, output *models.ReconstructionOutput) pointcloud.Transform {
+	pc := output.PointCloud
+	if pc == nil || pc.IsStored() || len(pc.Positions) == 0 {
+		return pointcloud.IdentityTransform()
+	}
+
+	processed, result, err := pointcloud.Process(pc, w.processOptions)
+	if err != nil {
+		fmt.Printf("Warning: point cloud processing failed for job %s: %v\n", jobID, err)
+		return pointcloud.IdentityTransform()
+	}
+
+	output.PointCloud = processed
+	output.ProcessingStats.RawPointCount = result.InputPoints
+	output.ProcessingStats.PointCount = result.OutputPoints
+	output.ProcessingStats.OutliersRemoved = result.OutliersRemoved
+	output.ProcessingStats.FloorAligned = result.FloorAligned()
+
+	if !result.FloorAligned() {
+		fmt.Printf("Reconstruction job %s: no floor plane found, keeping reconstruction frame\n", jobID)
+		return pointcloud.IdentityTransform()
+	}
+	for i := range output.Objects {
+		if obj := output.Objects[i].Object; obj != nil {
+			result.Transform.ApplyObject(obj)
+		}
+	}
+	for i := range output.UncertaintyRegions {
+		output.UncertaintyRegions[i].BBox = result.Transform.ApplyBox(output.UncertaintyRegions[i].BBox)
+	}
+	fmt.Printf("Reconstruction job %s: %d → %d points, %d outliers removed, floor aligned\n",
+		jobID, result.InputPoints, result.OutputPoints, result.OutliersRemoved)
+	return result.Transform
+}
+
+// extractLayout derives Tier 0 proxy geometry from the floor-aligned cloud and
+// adds it to the output as wall, door and window proposals, a passable_area
+// constraint and the room bounds. Unaligned clouds are skipped because wall
+// extraction assumes +Y is up.
+func (w *ReconstructionWorker) extractLayout(jobID uuid.UUID, output *models.ReconstructionOutput) {
+	pc := output.PointCloud
+	if !output.ProcessingStats.FloorAligned || pc == nil || len(pc.Positions) == 0 {
+		return
+	}
+
+	detected := make([]models.SceneObject, 0, len(output.Objects))
+	for _, p := range output.Objects {
+		if p.Object != nil && p.Action != "remove" {
+			detected = append(detected, *p.Object)
+		}
+	}
+
+	room, err := layout.Extract(pc, detected, w.layoutOptions)
+	if err != nil {
+		fmt.Printf("Warning: layout extraction failed for job %s: %v\n", jobID, err)
+		return
+	}
+
+	for _, obj := range room.SceneObjects() {
+		obj := obj
+		output.Objects = append(output.Objects, models.SceneObjectProposal{
+			ID:         obj.ID,
+			Action:     "create",
+			Object:     &obj,
+			Confidence: obj.Confidence,
+		})
+	}
+	if constraint, ok := room.PassableArea(detected); ok {
+		if err := constraint.Validate(); err != nil {
+			fmt.Printf("Warning: dropping passable area for job %s: %v\n", jobID, err)
+		} else {
+			output.Constraints = append(output.Constraints, constraint)
+		}
+	}
+	output.RoomBounds = room.Bounds
+	output.ProcessingStats.WallsDetected = len(room.Walls)
+	fmt.Printf("Reconstruction job %s: %d walls, %d openings, %.1f m² floor\n",
+		jobID, len(room.Walls), len(room.Openings), room.FloorArea())
+}
+
+// liftDetections places scene objects detected in 2D by scene analysis using
+// the input and solved camera poses, moved into the cloud's frame, and adds
+// the placed objects to the output as updates. Solved poses are rewritten in
+// the cloud's frame so they line up with it downstream.
+func (w *ReconstructionWorker) liftDetections(jobID uuid.UUID, sg *models.SceneGraph, input *models.ReconstructionInput, output *models.ReconstructionOutput, frame pointcloud.Transform) {
+	if output.ProcessingStats.FloorAligned {
+		for i, pose := range output.CameraPoses {
+			if cam, ok := lifting.NewCamera(pose); ok {
+				output.CameraPoses[i] = cam.Transformed(frame).Pose()
+			}
+		}
+		// Already moved; only input poses still need the transform
+		frame = pointcloud.IdentityTransform()
+	}
+	cameras := lifting.NewCameras(frame, input.CameraPoses)
+	for key, cam := range lifting.NewCameras(pointcloud.IdentityTransform(), output.CameraPoses) {
+		cameras[key] = cam
+	}
+	if len(cameras) == 0 || len(sg.Objects) == 0 {
+		return
+	}
+
+	var cloud [][]float64
+	if pc := output.PointCloud; pc != nil && !pc.IsStored() {
+		cloud = pc.Positions
+	}
+
+	objects := make(map[string]models.SceneObject, len(sg.Objects))
+	for _, obj := range sg.Objects {
+		objects[obj.ID] = obj
+	}
+	results := lifting.Lift(sg.Objects, cameras, cloud, w.liftOptions)
+	for _, r := range results {
+		obj := objects[r.ObjectID]
+		r.Apply(&obj)
+		output.Objects = append(output.Objects, models.SceneObjectProposal{
+			ID:           obj.ID,
+			Action:       "update",
+			Object:       &obj,
+			Confidence:   r.Confidence,
+			SourceImages: detection.ImageKeys(models.DetectedObject{Sources: detection.Sources(obj)}),
+		})
+	}
+	output.ProcessingStats.ObjectsLifted = len(results)
+	if len(results) > 0 {
+		fmt.Printf("Reconstruction job %s: %d detections lifted into 3D from %d camera poses\n", jobID, len(results), len(cameras))
+	}
+}
+
+// storePointCloud uploads the reconstructed point cloud as binary PLY and replaces
+// the inline points with a reference, bounds and a downsampled preview
+func (w *ReconstructionWorker) storePointCloud(ctx context.Context, caseID, jobID uuid.UUID, output *models.ReconstructionOutput) error {
+	pc := output.PointCloud
+	if w.storage == nil || pc == nil || pc.IsStored() || len(pc.Positions) == 0 {
+		return nil
+	}
+
+	format := models.PointCloudFormatPLY
+	data, err := pointcloud.Marshal(pc, format)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("cases/%s/pointclouds/%s.%s", caseID, jobID, pointcloud.FileExtension(format))
+	if err := w.storage.Upload(ctx, pointCloudBucket, key, data, pointcloud.ContentType(format)); err != nil {
+		return err
+	}
+
+	bounds := pointcloud.Bounds(pc)
+	stored := pointcloud.Preview(pc, pointcloud.DefaultPreviewPoints)
+	stored.Count = len(pc.Positions)
+	stored.AssetKey = key
+	stored.Format = format
+	stored.Bounds = &bounds
+
+	output.PointCloud = stored
+	output.PointcloudAssetKey = key
+	output.ProcessingStats.PointCount = stored.Count
+
+	if w.repo != nil {
+		asset := models.NewAsset(caseID, models.AssetKindPointcloud, key)
+		asset.SetMetadata("format", string(format))
+		asset.SetMetadata("point_count", stored.Count)
+		asset.SetMetadata("size_bytes", len(data))
+		if err := w.repo.CreateAsset(ctx, asset); err != nil {
+			fmt.Printf("Warning: failed to record point cloud asset: %v\n", err)
+		}
+	}
+	return nil
+}
+
 // mergeReconstructionOutput merges reconstruction output into existing SceneGraph
 func (w *ReconstructionWorker) mergeReconstructionOutput(existing *models.SceneGraph, output *models.ReconstructionOutput) *models.SceneGraph {
 	// Create a copy
@@ -122,15 +348,21 @@ func (w *ReconstructionWorker) mergeReconstructionOutput(existing *models.SceneG
 		Bounds:             existing.Bounds,
 		Objects:            make([]models.SceneObject, 0, len(existing.Objects)),
 		Evidence:           make([]models.EvidenceCard, len(existing.Evidence)),
-		Constraints:        make([]models.Constraint, len(existing.Constraints)),
+		Constraints:        make([]models.Constraint, 0, len(existing.Constraints)+len(output.Constraints)),
 		UncertaintyRegions: output.UncertaintyRegions,
 		PointCloud:         output.PointCloud, // Pass through point cloud from reconstruction
 		GaussianAssetKey:   output.GaussianAssetKey,
 	}
 
+	// A new layout replaces the one derived from the previous reconstruction
+	replaceLayout := output.RoomBounds != nil
+
 	// Copy existing objects into map for lookup
 	objectMap := make(map[string]models.SceneObject)
 	for _, obj := range existing.Objects {
+		if replaceLayout && layout.IsDerived(obj) {
+			continue
+		}
 		objectMap[obj.ID] = obj
 	}
 
@@ -157,92 +389,26 @@ func (w *ReconstructionWorker) mergeReconstructionOutput(existing *models.SceneG
 
 	// Copy evidence and constraints
 	copy(result.Evidence, existing.Evidence)
-	copy(result.Constraints, existing.Constraints)
-
-	// Compute bounds from all objects
-	result.Bounds = w.computeBoundsFromObjects(result.Objects)
-
-	return result
-}
-
-// computeBoundsFromObjects calculates the scene bounds from all object bounding boxes
-func (w *ReconstructionWorker) computeBoundsFromObjects(objects []models.SceneObject) models.BoundingBox {
-	if len(objects) == 0 {
-		// Default room bounds if no objects
-		return models.BoundingBox{
-			Min: [3]float64{-7, 0, -6},
-			Max: [3]float64{7, 4, 6},
+	for _, c := range existing.Constraints {
+		if replaceLayout && layout.IsDerivedConstraint(c) {
+			continue
 		}
+		result.Constraints = append(result.Constraints, c)
 	}
+	result.Constraints = append(result.Constraints, output.Constraints...)
 
-	// Initialize with extreme values
-	minX, minY, minZ := 1e9, 0.0, 1e9
-	maxX, maxY, maxZ := -1e9, 4.0, -1e9 // minY starts at floor, maxY at typical ceiling
-
-	for _, obj := range objects {
-		// Use object position
-		pos := obj.Pose.Position
-
-		// Consider object bounding box if available
-		if obj.BBox.Min != [3]float64{0, 0, 0} || obj.BBox.Max != [3]float64{0, 0, 0} {
-			if obj.BBox.Min[0]+pos[0] < minX {
-				minX = obj.BBox.Min[0] + pos[0]
-			}
-			if obj.BBox.Min[2]+pos[2] < minZ {
-				minZ = obj.BBox.Min[2] + pos[2]
-			}
-			if obj.BBox.Max[0]+pos[0] > maxX {
-				maxX = obj.BBox.Max[0] + pos[0]
-			}
-			if obj.BBox.Max[1]+pos[1] > maxY {
-				maxY = obj.BBox.Max[1] + pos[1]
-			}
-			if obj.BBox.Max[2]+pos[2] > maxZ {
-				maxZ = obj.BBox.Max[2] + pos[2]
-			}
-		} else {
-			// Use position directly with some margin
-			if pos[0]-1 < minX {
-				minX = pos[0] - 1
-			}
-			if pos[2]-1 < minZ {
-				minZ = pos[2] - 1
-			}
-			if pos[0]+1 > maxX {
-				maxX = pos[0] + 1
-			}
-			if pos[2]+1 > maxZ {
-				maxZ = pos[2] + 1
-			}
+	// Bound the scene by its walls and floor, falling back to the point cloud or objects
+	if output.RoomBounds != nil {
+		result.Bounds = *output.RoomBounds
+	} else {
+		var cloudBounds *models.BoundingBox
+		if output.PointCloud != nil {
+			cloudBounds = output.PointCloud.Bounds
 		}
+		result.Bounds = layout.SceneBounds(result.Objects, cloudBounds)
 	}
 
-	// Add margins for room walls (2m around objects)
-	margin := 2.0
-	minX -= margin
-	minZ -= margin
-	maxX += margin
-	maxZ += margin
-
-	// Ensure minimum room size
-	if maxX-minX < 8 {
-		center := (maxX + minX) / 2
-		minX = center - 4
-		maxX = center + 4
-	}
-	if maxZ-minZ < 8 {
-		center := (maxZ + minZ) / 2
-		minZ = center - 4
-		maxZ = center + 4
-	}
-	if maxY < 3 {
-		maxY = 3.5
-	}
-
-	return models.BoundingBox{
-		Min: [3]float64{minX, minY, minZ},
-		Max: [3]float64{maxX, maxY, maxZ},
-	}
+	return result
 }
 
 // createReconstructionCommit creates a commit for the reconstruction update
@@ -271,6 +437,9 @@ func (w *ReconstructionWorker) createReconstructionCommit(ctx context.Context, c
 		"raw_image_count": len(input.ScanAssetKeys),
 		"raw_image_keys":  input.ScanAssetKeys,
 	}
+	if len(input.CameraPoses) > 0 {
+		inputSources["camera_pose_count"] = len(input.CameraPoses)
+	}
 	if len(input.GeneratedPOVKeys) > 0 {
 		inputSources["pov_image_count"] = len(input.GeneratedPOVKeys)
 		inputSources["pov_image_keys"] = input.GeneratedPOVKeys
@@ -299,6 +468,7 @@ func (w *ReconstructionWorker) createReconstructionCommit(ctx context.Context, c
 	if err != nil {
 		return err
 	}
+	commit.CreatedBy = auth.UserID(ctx)
 
 	// Get latest commit as parent
 	latestCommit, _ := w.repo.GetLatestCommit(ctx, caseID)
@@ -306,7 +476,30 @@ func (w *ReconstructionWorker) createReconstructionCommit(ctx context.Context, c
 		commit.SetParent(latestCommit.ID)
 	}
 
-	return w.repo.CreateCommit(ctx, commit)
+	return w.SaveCommit(ctx, jobID, commit)
+}
+
+// cameraPosesFromAssets builds initial camera poses from the intrinsics derived at
+// ingest time. Extrinsics are left empty for the reconstruction service to solve.
+func (w *ReconstructionWorker) cameraPosesFromAssets(ctx context.Context, assetKeys []string) []models.CameraPose {
+	if w.repo == nil {
+		return nil
+	}
+
+	var poses []models.CameraPose
+	for _, key := range assetKeys {
+		asset, err := w.repo.GetAssetByStorageKey(ctx, key)
+		if err != nil || asset == nil {
+			continue
+		}
+		if intr, ok := asset.CameraIntrinsics(); ok {
+			poses = append(poses, models.CameraPose{
+				AssetKey:   key,
+				Intrinsics: *intr,
+			})
+		}
+	}
+	return poses
 }
 
 // updateSceneSnapshot updates the scene snapshot with new SceneGraph
@@ -366,9 +559,11 @@ func (w *ReconstructionWorker) generatePOVImages(ctx context.Context, parentJobI
 	if err != nil {
 		return nil, fmt.Errorf("failed to create POV job: %w", err)
 	}
+	povJob.CreatedBy = auth.UserID(ctx)
 
 	// Save job to database
-	if err := w.repo.CreateJob(ctx, povJob); err != nil {
+	// Over budget this fails and reconstruction goes on with the raw images
+	if err := w.CreateJob(ctx, povJob); err != nil {
 		return nil, fmt.Errorf("failed to save POV job: %w", err)
 	}
 
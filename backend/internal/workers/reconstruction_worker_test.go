@@ -1,13 +1,17 @@
 package workers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/sherlockos/backend/internal/clients"
+	"github.com/sherlockos/backend/internal/layout"
 	"github.com/sherlockos/backend/internal/models"
+	"github.com/sherlockos/backend/internal/pointcloud"
 	"github.com/sherlockos/backend/internal/queue"
 )
 
@@ -259,50 +263,281 @@ func TestReconstructionWorker_MergeReconstructionOutput(t *testing.T) {
 	}
 }
 
-func TestReconstructionWorker_ComputeBoundsFromObjects(t *testing.T) {
+func TestReconstructionWorker_MergeUsesRoomBounds(t *testing.T) {
 	worker := NewReconstructionWorker(nil, nil, nil)
 
-	tests := []struct {
-		name    string
-		objects []models.SceneObject
-		wantMin [3]float64
-		wantMax [3]float64
-	}{
-		{
-			name:    "no objects - default bounds",
-			objects: []models.SceneObject{},
-			wantMin: [3]float64{-7, 0, -6},
-			wantMax: [3]float64{7, 4, 6},
+	existing := models.NewEmptySceneGraph()
+	existing.Objects = []models.SceneObject{
+		{ID: "old-wall", Type: models.ObjectTypeWall, Metadata: map[string]interface{}{"source": layout.Source}},
+		{ID: "chair", Type: models.ObjectTypeFurniture},
+	}
+	existing.Constraints = []models.Constraint{
+		{ID: "old-area", Type: models.ConstraintTypePassableArea, Params: map[string]interface{}{"source": layout.Source}},
+		{ID: "door-dir", Type: models.ConstraintTypeDoorDirection},
+	}
+
+	room := models.BoundingBox{Min: [3]float64{0, 0, 0}, Max: [3]float64{5, 2.6, 4}}
+	wall := &models.SceneObject{ID: "new-wall", Type: models.ObjectTypeWall, Metadata: map[string]interface{}{"source": layout.Source}}
+	output := &models.ReconstructionOutput{
+		Objects:     []models.SceneObjectProposal{{ID: "new-wall", Action: "create", Object: wall}},
+		Constraints: []models.Constraint{{ID: "new-area", Type: models.ConstraintTypePassableArea, Params: map[string]interface{}{"source": layout.Source}}},
+		RoomBounds:  &room,
+	}
+
+	result := worker.mergeReconstructionOutput(existing, output)
+
+	if result.Bounds != room {
+		t.Errorf("Bounds = %+v, want the room bounds", result.Bounds)
+	}
+	ids := map[string]bool{}
+	for _, obj := range result.Objects {
+		ids[obj.ID] = true
+	}
+	for _, c := range result.Constraints {
+		ids[c.ID] = true
+	}
+	if ids["old-wall"] || ids["old-area"] || !ids["new-wall"] || !ids["new-area"] || !ids["chair"] || !ids["door-dir"] {
+		t.Errorf("merged ids = %v, want the old layout replaced and everything else kept", ids)
+	}
+}
+
+func TestReconstructionWorker_StoresPointCloud(t *testing.T) {
+	positions := make([][]float64, 5000)
+	for i := range positions {
+		positions[i] = []float64{float64(i) * 0.001, 0, -1}
+	}
+	mockClient := &clients.MockReconstructionClient{
+		ReconstructFunc: func(ctx context.Context, input models.ReconstructionInput) (*models.ReconstructionOutput, error) {
+			return &models.ReconstructionOutput{
+				PointCloud: &models.PointCloud{Positions: positions, Count: len(positions)},
+			}, nil
 		},
-		{
-			name: "single object",
-			objects: []models.SceneObject{
-				{
-					ID:   "obj1",
-					Pose: models.Pose{Position: [3]float64{0, 0, 0}},
-					BBox: models.BoundingBox{
-						Min: [3]float64{-1, 0, -1},
-						Max: [3]float64{1, 1, 1},
-					},
-				},
-			},
-			// Position + BBox + margin (2m)
-			wantMin: [3]float64{-4, 0, -4}, // -1 - 2 margin, clamped to min 8m
-			wantMax: [3]float64{4, 3.5, 4}, // 1 + 2 margin, clamped to min 8m
+	}
+
+	var uploadedKey, uploadedType string
+	var uploaded []byte
+	storage := &clients.MockStorageClient{
+		UploadFunc: func(ctx context.Context, bucket, key string, data []byte, contentType string) error {
+			uploadedKey, uploaded, uploadedType = key, data, contentType
+			return nil
 		},
 	}
+	worker := NewReconstructionWorkerWithStorage(nil, nil, mockClient, storage)
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := worker.computeBoundsFromObjects(tt.objects)
+	caseID := uuid.New()
+	jobID := uuid.New()
+	output, _ := mockClient.Reconstruct(context.Background(), models.ReconstructionInput{})
+	if err := worker.storePointCloud(context.Background(), caseID, jobID, output); err != nil {
+		t.Fatalf("storePointCloud() error = %v", err)
+	}
 
-			// Check bounds are reasonable (not testing exact values due to complexity)
-			if result.Min[1] != 0 {
-				t.Errorf("Expected floor at Y=0, got %f", result.Min[1])
-			}
-			if result.Max[1] < 3 {
-				t.Errorf("Expected ceiling at least 3m, got %f", result.Max[1])
-			}
-		})
+	wantKey := "cases/" + caseID.String() + "/pointclouds/" + jobID.String() + ".ply"
+	if uploadedKey != wantKey || uploadedType != "application/ply" {
+		t.Errorf("uploaded %q (%s), want %q", uploadedKey, uploadedType, wantKey)
+	}
+	stored, err := pointcloud.ReadPLY(bytes.NewReader(uploaded))
+	if err != nil || len(stored.Positions) != 5000 {
+		t.Fatalf("uploaded PLY = %v points, err %v", len(stored.Positions), err)
+	}
+
+	pc := output.PointCloud
+	if pc.AssetKey != wantKey || pc.Format != models.PointCloudFormatPLY || pc.Count != 5000 {
+		t.Errorf("PointCloud reference = %+v", pc)
+	}
+	if len(pc.Positions) != pointcloud.DefaultPreviewPoints {
+		t.Errorf("preview points = %d, want %d", len(pc.Positions), pointcloud.DefaultPreviewPoints)
+	}
+	if pc.Bounds == nil || pc.Bounds.Max[0] != 4.999 {
+		t.Errorf("PointCloud bounds = %+v", pc.Bounds)
+	}
+	if output.PointcloudAssetKey != wantKey || output.ProcessingStats.PointCount != 5000 {
+		t.Errorf("output asset key / point count = %q / %d", output.PointcloudAssetKey, output.ProcessingStats.PointCount)
+	}
+
+	// The SceneGraph carries the reference, not the full cloud
+	sg := worker.mergeReconstructionOutput(models.NewEmptySceneGraph(), output)
+	sgJSON, _ := json.Marshal(sg)
+	if len(sgJSON) > 100_000 {
+		t.Errorf("scenegraph JSON is %d bytes, want the full cloud kept out of it", len(sgJSON))
+	}
+}
+
+func TestReconstructionWorker_PointCloudUploadFailureRetries(t *testing.T) {
+	mockClient := &clients.MockReconstructionClient{
+		ReconstructFunc: func(ctx context.Context, input models.ReconstructionInput) (*models.ReconstructionOutput, error) {
+			return &models.ReconstructionOutput{
+				PointCloud: &models.PointCloud{Positions: [][]float64{{0, 0, 0}}, Count: 1},
+			}, nil
+		},
+	}
+	storage := &clients.MockStorageClient{
+		UploadFunc: func(ctx context.Context, bucket, key string, data []byte, contentType string) error {
+			return errors.New("storage unavailable")
+		},
+	}
+	worker := NewReconstructionWorkerWithStorage(nil, nil, mockClient, storage)
+
+	input, _ := json.Marshal(models.ReconstructionInput{
+		CaseID:        uuid.New().String(),
+		ScanAssetKeys: []string{"cases/test/scans/image1.jpg"},
+	})
+	err := worker.Process(context.Background(), &queue.JobMessage{JobID: uuid.New(), Type: models.JobTypeReconstruction, Input: input})
+
+	var workerErr *WorkerError
+	if !errors.As(err, &workerErr) || workerErr.Type != ErrorTypeRetryable {
+		t.Errorf("Process() error = %v, want retryable", err)
+	}
+}
+
+func TestReconstructionWorker_ProcessPointCloudAlignsFloor(t *testing.T) {
+	// Camera-style frame: Y points down, floor 1.5 m below the camera at y = +1.5
+	var positions [][]float64
+	for x := 0.0; x < 4; x += 0.05 {
+		for z := 0.0; z < 3; z += 0.05 {
+			positions = append(positions, []float64{x, 1.5, z})
+		}
+	}
+	for x := 0.0; x < 4; x += 0.05 {
+		for y := -1.0; y < 1.5; y += 0.05 {
+			positions = append(positions, []float64{x, y, 3})
+		}
+	}
+
+	table := &models.SceneObject{
+		ID:    "table",
+		Pose:  models.NewDefaultPose(),
+		BBox:  models.BoundingBox{Min: [3]float64{1, 0.7, 1}, Max: [3]float64{2, 1.5, 2}},
+		State: models.ObjectStateVisible,
+	}
+	table.Pose.Position = [3]float64{1.5, 1.5, 1.5}
+	output := &models.ReconstructionOutput{
+		Objects:    []models.SceneObjectProposal{{ID: "p1", Action: "create", Object: table}},
+		PointCloud: &models.PointCloud{Positions: positions, Count: len(positions)},
+	}
+
+	worker := NewReconstructionWorker(nil, nil, nil)
+	worker.processPointCloud(uuid.New(), output)
+
+	stats := output.ProcessingStats
+	if !stats.FloorAligned || stats.RawPointCount != len(positions) || stats.PointCount != output.PointCloud.Count {
+		t.Fatalf("ProcessingStats = %+v", stats)
+	}
+	if b := output.PointCloud.Bounds; b == nil || b.Min[1] < -0.01 || b.Max[1] < 2.4 {
+		t.Errorf("processed bounds = %+v, want floor at 0 and the wall above it", b)
+	}
+	if y := table.Pose.Position[1]; y < -0.01 || y > 0.01 {
+		t.Errorf("table position y = %v, want on the floor", y)
+	}
+	if table.BBox.Min[1] < -0.01 || table.BBox.Max[1] < 0.79 || table.BBox.Max[1] > 0.81 {
+		t.Errorf("table bbox = %+v, want 0..0.8 m in y", table.BBox)
+	}
+}
+
+func TestReconstructionWorker_ExtractLayout(t *testing.T) {
+	// Floor-aligned 4 m × 3 m room with 2.5 m walls
+	var positions [][]float64
+	for x := 0.0; x <= 4; x += 0.05 {
+		for z := 0.0; z <= 3; z += 0.05 {
+			positions = append(positions, []float64{x, 0, z})
+		}
+	}
+	for y := 0.0; y <= 2.5; y += 0.05 {
+		for x := 0.0; x <= 4; x += 0.05 {
+			positions = append(positions, []float64{x, y, 0}, []float64{x, y, 3})
+		}
+		for z := 0.0; z <= 3; z += 0.05 {
+			positions = append(positions, []float64{0, y, z}, []float64{4, y, z})
+		}
+	}
+	table := &models.SceneObject{
+		ID:    "table",
+		Type:  models.ObjectTypeFurniture,
+		Label: "Table",
+		BBox:  models.BoundingBox{Min: [3]float64{1, 0, 1}, Max: [3]float64{2, 0.8, 2}},
+		State: models.ObjectStateVisible,
+	}
+	output := &models.ReconstructionOutput{
+		Objects:         []models.SceneObjectProposal{{ID: "table", Action: "create", Object: table}},
+		PointCloud:      &models.PointCloud{Positions: positions, Count: len(positions)},
+		ProcessingStats: models.ProcessingStats{FloorAligned: true},
+	}
+
+	worker := NewReconstructionWorker(nil, nil, nil)
+	worker.extractLayout(uuid.New(), output)
+
+	walls := 0
+	for _, p := range output.Objects {
+		if p.Object.Type == models.ObjectTypeWall && layout.IsDerived(*p.Object) {
+			walls++
+		}
+	}
+	if walls != 4 || output.ProcessingStats.WallsDetected != 4 {
+		t.Errorf("extractLayout() added %d walls (stats %d), want 4", walls, output.ProcessingStats.WallsDetected)
+	}
+	if len(output.Constraints) != 1 || output.Constraints[0].Type != models.ConstraintTypePassableArea {
+		t.Fatalf("Constraints = %+v, want one passable_area", output.Constraints)
+	}
+	if b := output.RoomBounds; b == nil || b.Max[0]-b.Min[0] > 4.2 || b.Max[2]-b.Min[2] > 3.2 {
+		t.Errorf("RoomBounds = %+v, want the 4 × 3 m room", b)
+	}
+
+	// Unaligned clouds are left alone
+	unaligned := &models.ReconstructionOutput{PointCloud: &models.PointCloud{Positions: positions}}
+	worker.extractLayout(uuid.New(), unaligned)
+	if len(unaligned.Objects) != 0 || unaligned.RoomBounds != nil {
+		t.Error("extractLayout() should skip clouds that are not floor-aligned")
+	}
+}
+
+func TestReconstructionWorker_LiftDetections(t *testing.T) {
+	// A 0.4 m box 3 m in front of a camera at the origin looking along +Z
+	var positions [][]float64
+	for x := -0.2; x <= 0.2; x += 0.05 {
+		for y := -0.2; y <= 0.2; y += 0.05 {
+			positions = append(positions, []float64{x, y, 2.8}, []float64{x, y, 3.2})
+		}
+	}
+	pose := models.CameraPose{
+		AssetKey:   "cases/test/scans/front.jpg",
+		Intrinsics: models.CameraIntrinsics{Fx: 500, Fy: 500, Cx: 320, Cy: 240},
+		Extrinsics: models.CameraExtrinsics{Rotation: []float64{1, 0, 0, 0}, Translation: []float64{0, 0, 0}},
+	}
+	sg := models.NewEmptySceneGraph()
+	sg.Objects = []models.SceneObject{{
+		ID:    "obj_box",
+		Type:  models.ObjectTypeEvidenceItem,
+		Pose:  models.NewDefaultPose(),
+		BBox:  models.BoundingBox{Max: [3]float64{1, 1, 1}},
+		State: models.ObjectStateVisible,
+		Metadata: map[string]interface{}{"sources": []interface{}{map[string]interface{}{
+			"image_key":    pose.AssetKey,
+			"bounding_box": map[string]interface{}{"x": 0.4, "y": 0.35, "width": 0.2, "height": 0.3},
+		}}},
+	}}
+	output := &models.ReconstructionOutput{
+		PointCloud:  &models.PointCloud{Positions: positions, Count: len(positions)},
+		CameraPoses: []models.CameraPose{pose},
+	}
+
+	worker := NewReconstructionWorker(nil, nil, nil)
+	worker.liftDetections(uuid.New(), sg, &models.ReconstructionInput{}, output, pointcloud.IdentityTransform())
+
+	if output.ProcessingStats.ObjectsLifted != 1 || len(output.Objects) != 1 {
+		t.Fatalf("liftDetections() proposals = %+v", output.Objects)
+	}
+	p := output.Objects[0]
+	if p.Action != "update" || p.Object.ID != "obj_box" || len(p.SourceImages) != 1 {
+		t.Errorf("proposal = %+v", p)
+	}
+	if z := p.Object.Pose.Position[2]; z < 2.9 || z > 3.1 {
+		t.Errorf("lifted position = %v, want 3 m in front of the camera", p.Object.Pose.Position)
+	}
+	if sg.Objects[0].Pose.Position != [3]float64{} {
+		t.Error("liftDetections() should not modify the existing scene")
+	}
+
+	merged := worker.mergeReconstructionOutput(sg, output)
+	if len(merged.Objects) != 1 || merged.Objects[0].Pose.Position != p.Object.Pose.Position {
+		t.Errorf("merged objects = %+v", merged.Objects)
 	}
 }
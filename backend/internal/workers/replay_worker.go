@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sherlockos/backend/internal/auth"
 	"github.com/sherlockos/backend/internal/clients"
 	"github.com/sherlockos/backend/internal/db"
 	"github.com/sherlockos/backend/internal/models"
@@ -79,6 +80,9 @@ func (w *ReplayWorker) Process(ctx context.Context, job *queue.JobMessage) error
 	// Create commit for the replay generation
 	commitID, err := w.createCommit(ctx, caseID, job.JobID, input, output)
 	if err != nil {
+		if fatal := caseClosedError(err); fatal != nil {
+			return fatal
+		}
 		fmt.Printf("Warning: failed to create commit: %v\n", err)
 	}
 
@@ -158,8 +162,9 @@ func (w *ReplayWorker) createCommit(ctx context.Context, caseID uuid.UUID, jobID
 	if err != nil {
 		return "", err
 	}
+	commit.CreatedBy = auth.UserID(ctx)
 
-	if err := w.repo.CreateCommit(ctx, commit); err != nil {
+	if err := w.SaveCommit(ctx, jobID, commit); err != nil {
 		return "", err
 	}
 
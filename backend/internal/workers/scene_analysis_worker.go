@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sherlockos/backend/internal/auth"
 	"github.com/sherlockos/backend/internal/clients"
 	"github.com/sherlockos/backend/internal/db"
+	"github.com/sherlockos/backend/internal/detection"
 	"github.com/sherlockos/backend/internal/models"
 	"github.com/sherlockos/backend/internal/queue"
 )
@@ -59,14 +61,23 @@ func (w *SceneAnalysisWorker) Process(ctx context.Context, job *queue.JobMessage
 	// Update progress: analysis complete
 	w.UpdateJobProgress(ctx, job.JobID, 80)
 
+	// Map labels onto the scene's types and merge sightings of the same object
+	output.DetectedObjects = detection.Normalize(output.DetectedObjects)
+
 	// Create commit with scene analysis results
 	caseID, _ := uuid.Parse(input.CaseID)
 	if err := w.createSceneAnalysisCommit(ctx, caseID, job.JobID, output); err != nil {
+		if fatal := caseClosedError(err); fatal != nil {
+			return fatal
+		}
 		fmt.Printf("Warning: failed to create scene analysis commit: %v\n", err)
 	}
 
 	// Update scene snapshot with detected objects
 	if err := w.updateSceneSnapshot(ctx, caseID, output); err != nil {
+		if fatal := caseClosedError(err); fatal != nil {
+			return fatal
+		}
 		fmt.Printf("Warning: failed to update scene snapshot: %v\n", err)
 	}
 
@@ -114,6 +125,7 @@ func (w *SceneAnalysisWorker) createSceneAnalysisCommit(ctx context.Context, cas
 	if err != nil {
 		return err
 	}
+	commit.CreatedBy = auth.UserID(ctx)
 
 	// Get latest commit as parent
 	latestCommit, _ := w.repo.GetLatestCommit(ctx, caseID)
@@ -121,7 +133,7 @@ func (w *SceneAnalysisWorker) createSceneAnalysisCommit(ctx context.Context, cas
 		commit.SetParent(latestCommit.ID)
 	}
 
-	return w.repo.CreateCommit(ctx, commit)
+	return w.SaveCommit(ctx, jobID, commit)
 }
 
 // updateSceneSnapshot converts detected objects to SceneGraph and updates the snapshot
@@ -139,78 +151,22 @@ func (w *SceneAnalysisWorker) updateSceneSnapshot(ctx context.Context, caseID uu
 		sg = models.NewEmptySceneGraph()
 	}
 
-	// Convert DetectedObjects to SceneObjects
-	for _, detected := range output.DetectedObjects {
-		sceneObj := models.SceneObject{
-			ID:         detected.ID,
-			Type:       models.ObjectType(detected.Type),
-			Label:      detected.Label,
-			State:      "detected",
-			Confidence: detected.Confidence,
-			Pose: models.Pose{
-				Position: [3]float64{0, 0, 0}, // Will be set by reconstruction
-				Rotation: [4]float64{0, 0, 0, 1},
-			},
-			BBox: models.BoundingBox{
-				Min: [3]float64{0, 0, 0},
-				Max: [3]float64{1, 1, 1},
-			},
-			Metadata: map[string]interface{}{
-				"notes":                detected.Notes,
-				"is_suspicious":        detected.IsSuspicious,
-				"position_description": detected.PositionDescription,
-				"source_image_key":     detected.SourceImageKey,
-			},
-		}
-
-		// Check if object already exists, update if so
-		found := false
-		for i, existing := range sg.Objects {
-			if existing.ID == sceneObj.ID {
-				sg.Objects[i] = sceneObj
-				found = true
-				break
-			}
-		}
-		if !found {
-			sg.Objects = append(sg.Objects, sceneObj)
-		}
-	}
-
-	// Convert potential evidence to EvidenceCards
-	for i, evidence := range output.PotentialEvidence {
-		card := models.EvidenceCard{
-			ID:          fmt.Sprintf("evidence_%d", i+1),
-			Title:       evidence,
-			Description: fmt.Sprintf("Potential evidence: %s", evidence),
-			Confidence:  0.8,
-			CreatedAt:   time.Now().UTC().Format(time.RFC3339),
-		}
-
-		// Check if evidence already exists
-		found := false
-		for j, existing := range sg.Evidence {
-			if existing.Title == card.Title {
-				sg.Evidence[j] = card
-				found = true
-				break
-			}
-		}
-		if !found {
-			sg.Evidence = append(sg.Evidence, card)
-		}
-	}
-
-	// Compute initial bounds from objects (will be refined by reconstruction)
-	sg.Bounds = computeInitialBounds(sg.Objects)
-
 	// Get latest commit for this case to use as commit_id
 	latestCommit, _ := w.repo.GetLatestCommit(ctx, caseID)
 	var commitID uuid.UUID
+	var source string
 	if latestCommit != nil {
 		commitID = latestCommit.ID
+		source = commitID.String()
 	}
 
+	// Merge detections and evidence into the scene by their stable IDs
+	detection.MergeObjects(sg, output.DetectedObjects, source)
+	detection.MergeEvidence(sg, output.PotentialEvidence, source, time.Now())
+
+	// Compute initial bounds from objects (will be refined by reconstruction)
+	sg.Bounds = computeInitialBounds(sg.Objects)
+
 	// Update snapshot
 	snapshot := &models.SceneSnapshot{
 		CaseID:     caseID,
@@ -9,6 +9,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/sherlockos/backend/internal/auth"
 	"github.com/sherlockos/backend/internal/db"
 	"github.com/sherlockos/backend/internal/models"
 	"github.com/sherlockos/backend/internal/queue"
@@ -101,12 +102,19 @@ func (c RetryConfig) CalculateBackoff(attempt int) time.Duration {
 	return backoff
 }
 
+// caseStore looks up the case a job belongs to
+type caseStore interface {
+	GetCase(ctx context.Context, id uuid.UUID) (*models.Case, error)
+}
+
 // Manager manages worker lifecycle
 type Manager struct {
 	repo        *db.Repository
+	cases       caseStore
 	queue       queue.JobQueue
 	workers     map[models.JobType]Worker
 	retryConfig RetryConfig
+	budgets     models.Budgets
 	wg          sync.WaitGroup
 	shutdown    chan struct{}
 
@@ -120,6 +128,9 @@ type ManagerConfig struct {
 	RetryConfig       RetryConfig
 	HeartbeatInterval time.Duration
 	ZombieTimeout     time.Duration
+
+	// Budgets caps the estimated cost of jobs that workers spawn
+	Budgets models.Budgets
 }
 
 // DefaultManagerConfig returns the default manager configuration
@@ -138,19 +149,27 @@ func NewManager(database *db.DB, q queue.JobQueue, config ManagerConfig) *Manage
 		repo = db.NewRepository(database)
 	}
 
-	return &Manager{
+	m := &Manager{
 		repo:              repo,
 		queue:             q,
 		workers:           make(map[models.JobType]Worker),
 		retryConfig:       config.RetryConfig,
+		budgets:           config.Budgets,
 		heartbeatInterval: config.HeartbeatInterval,
 		zombieTimeout:     config.ZombieTimeout,
 		shutdown:          make(chan struct{}),
 	}
+	if repo != nil {
+		m.cases = repo
+	}
+	return m
 }
 
 // Register adds a worker for a specific job type
 func (m *Manager) Register(w Worker) {
+	if b, ok := w.(interface{ setBudgets(models.Budgets) }); ok {
+		b.setBudgets(m.budgets)
+	}
 	m.workers[w.Type()] = w
 	// Also register in the global registry so API handlers can validate requests
 	GetGlobalRegistry().Register(w.Type())
@@ -212,6 +231,14 @@ func (m *Manager) runWorker(ctx context.Context, jobType models.JobType, w Worke
 func (m *Manager) processJob(ctx context.Context, w Worker, job *queue.JobMessage) {
 	log.Printf("Processing %s job %s (attempt %d)", job.Type, job.JobID, job.Attempts)
 
+	// A case closed or deleted while the job was queued won't reopen, so
+	// the job fails for good instead of running
+	if err := m.checkCaseOpen(ctx, job); err != nil {
+		log.Printf("Skipping %s job %s: %v", job.Type, job.JobID, err)
+		m.handleJobError(ctx, job, err)
+		return
+	}
+
 	// Update job status to running
 	if m.repo != nil {
 		if err := m.repo.UpdateJobStatus(ctx, job.JobID, models.JobStatusRunning, 0); err != nil {
@@ -219,9 +246,12 @@ func (m *Manager) processJob(ctx context.Context, w Worker, job *queue.JobMessag
 		}
 	}
 
-	// Create a context with cancellation for heartbeat
+	// Create a context with cancellation for heartbeat, running as the job's user
 	jobCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
+	if job.CreatedBy != nil {
+		jobCtx = auth.WithUser(jobCtx, &auth.User{ID: *job.CreatedBy})
+	}
 
 	// Start heartbeat goroutine
 	heartbeatDone := make(chan struct{})
@@ -242,6 +272,24 @@ func (m *Manager) processJob(ctx context.Context, w Worker, job *queue.JobMessag
 	}
 }
 
+// checkCaseOpen returns a fatal error when the job would change a case that
+// no longer accepts changes. Exports only read the case, so they still run.
+func (m *Manager) checkCaseOpen(ctx context.Context, job *queue.JobMessage) error {
+	if m.cases == nil || job.Type == models.JobTypeExport {
+		return nil
+	}
+	c, err := m.cases.GetCase(ctx, job.CaseID)
+	if err != nil {
+		// The worker's own writes check the case again
+		log.Printf("Failed to look up case %s for job %s: %v", job.CaseID, job.JobID, err)
+		return nil
+	}
+	if c == nil || !c.AcceptsChanges() {
+		return NewFatalError(db.ErrCaseClosed)
+	}
+	return nil
+}
+
 // runHeartbeat updates the job timestamp periodically
 func (m *Manager) runHeartbeat(ctx context.Context, jobID uuid.UUID, done chan struct{}) {
 	defer close(done)
@@ -384,8 +432,9 @@ func (m *Manager) recoverZombieJobs(ctx context.Context) {
 
 // BaseWorker provides common functionality for workers
 type BaseWorker struct {
-	repo  *db.Repository
-	queue queue.JobQueue
+	repo    *db.Repository
+	queue   queue.JobQueue
+	budgets models.Budgets
 }
 
 // NewBaseWorker creates a new base worker
@@ -400,6 +449,18 @@ func NewBaseWorker(database *db.DB, q queue.JobQueue) *BaseWorker {
 	}
 }
 
+// setBudgets sets the budgets that jobs the worker spawns are checked against
+func (w *BaseWorker) setBudgets(b models.Budgets) {
+	w.budgets = b
+}
+
+// CreateJob saves a job the worker spawns, refusing it with a
+// *models.BudgetExceededError if its estimated cost would take spending past
+// a budget
+func (w *BaseWorker) CreateJob(ctx context.Context, job *models.Job) error {
+	return w.repo.CreateJobWithinBudget(ctx, job, w.budgets)
+}
+
 // UpdateJobProgress updates the job progress in the database
 func (w *BaseWorker) UpdateJobProgress(ctx context.Context, jobID uuid.UUID, progress int) error {
 	if w.repo == nil {
@@ -414,6 +475,36 @@ func (w *BaseWorker) UpdateJobProgress(ctx context.Context, jobID uuid.UUID, pro
 	return nil
 }
 
+// caseClosedError returns err as a fatal error if it says the case no longer
+// accepts changes, since retrying won't reopen it, and nil otherwise
+func caseClosedError(err error) error {
+	if errors.Is(err, db.ErrCaseClosed) {
+		return NewFatalError(err)
+	}
+	return nil
+}
+
+// SaveCommit writes a commit the job produced together with its audit log
+// entry, attributed to the job and the user it runs as
+func (w *BaseWorker) SaveCommit(ctx context.Context, jobID uuid.UUID, c *models.Commit) error {
+	entry := models.NewAuditEntry(models.AuditActorWorker, c.CreatedBy, models.AuditActionCommitCreate, models.AuditOutcomeSuccess)
+	entry.CaseID = &c.CaseID
+	entry.JobID = &jobID
+	entry.SetTarget("commit", c.ID.String())
+	entry.Detail = string(c.Type)
+	return w.repo.CreateCommitWithAudit(ctx, c, entry)
+}
+
+// RecordJobCost records what a job actually cost, in USD, in place of its
+// estimate
+func (w *BaseWorker) RecordJobCost(ctx context.Context, jobID uuid.UUID, costUSD float64) error {
+	if w.repo == nil {
+		log.Printf("Job %s cost: $%.4f (no db)", jobID, costUSD)
+		return nil
+	}
+	return w.repo.UpdateJobCost(ctx, jobID, costUSD)
+}
+
 // MarkJobDone marks a job as completed with output
 func (w *BaseWorker) MarkJobDone(ctx context.Context, jobID uuid.UUID, output interface{}) error {
 	if w.repo == nil {
@@ -4,12 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sherlockos/backend/internal/clients"
 	"github.com/sherlockos/backend/internal/models"
 	"github.com/sherlockos/backend/internal/queue"
+	"github.com/sherlockos/backend/internal/report"
+	"github.com/sherlockos/backend/internal/scoring"
 )
 
 func TestReconstructionWorker_MergeOutput(t *testing.T) {
@@ -105,7 +109,7 @@ func TestReconstructionWorker_Type(t *testing.T) {
 	}
 }
 
-func TestReconstructionWorker_ComputeBounds(t *testing.T) {
+func TestReconstructionWorker_MergeBoundsWithoutLayout(t *testing.T) {
 	worker := &ReconstructionWorker{
 		BaseWorker: NewBaseWorker(nil, nil),
 	}
@@ -113,59 +117,48 @@ func TestReconstructionWorker_ComputeBounds(t *testing.T) {
 	tests := []struct {
 		name    string
 		objects []models.SceneObject
+		cloud   *models.BoundingBox
+		want    models.BoundingBox
 	}{
 		{
 			name:    "empty objects returns default bounds",
 			objects: []models.SceneObject{},
+			want:    models.BoundingBox{Min: [3]float64{-7, 0, -6}, Max: [3]float64{7, 4, 6}},
 		},
 		{
-			name: "objects with positions expand bounds",
+			name: "objects with positions give tight bounds",
 			objects: []models.SceneObject{
-				{
-					ID:   "obj-1",
-					Pose: models.Pose{Position: [3]float64{-3, 0, -2}},
-				},
-				{
-					ID:   "obj-2",
-					Pose: models.Pose{Position: [3]float64{4, 0, 3}},
-				},
+				{ID: "obj-1", Pose: models.Pose{Position: [3]float64{-3, 0, -2}}},
+				{ID: "obj-2", Pose: models.Pose{Position: [3]float64{4, 2, 3}}},
 			},
+			want: models.BoundingBox{Min: [3]float64{-3, 0, -2}, Max: [3]float64{4, 2, 3}},
 		},
 		{
-			name: "objects with bounding boxes",
+			name: "point cloud bounds take precedence over objects",
 			objects: []models.SceneObject{
-				{
-					ID:   "obj-1",
-					Pose: models.Pose{Position: [3]float64{0, 0, 0}},
-					BBox: models.BoundingBox{Min: [3]float64{-2, 0, -2}, Max: [3]float64{2, 3, 2}},
-				},
+				{ID: "obj-1", BBox: models.BoundingBox{Min: [3]float64{-20, 0, -20}, Max: [3]float64{20, 3, 20}}},
 			},
+			cloud: &models.BoundingBox{Min: [3]float64{0, 0, 0}, Max: [3]float64{6, 2.7, 5}},
+			want:  models.BoundingBox{Min: [3]float64{0, 0, 0}, Max: [3]float64{6, 2.7, 5}},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			bounds := worker.computeBoundsFromObjects(tt.objects)
-
-			// Verify bounds are valid
-			if bounds.Min[0] >= bounds.Max[0] {
-				t.Errorf("Invalid X bounds: min=%f >= max=%f", bounds.Min[0], bounds.Max[0])
+			output := &models.ReconstructionOutput{}
+			for i := range tt.objects {
+				output.Objects = append(output.Objects, models.SceneObjectProposal{ID: tt.objects[i].ID, Action: "create", Object: &tt.objects[i]})
 			}
-			if bounds.Min[1] >= bounds.Max[1] {
-				t.Errorf("Invalid Y bounds: min=%f >= max=%f", bounds.Min[1], bounds.Max[1])
-			}
-			if bounds.Min[2] >= bounds.Max[2] {
-				t.Errorf("Invalid Z bounds: min=%f >= max=%f", bounds.Min[2], bounds.Max[2])
+			if tt.cloud != nil {
+				output.PointCloud = &models.PointCloud{Bounds: tt.cloud}
 			}
 
-			// Verify minimum room size
-			width := bounds.Max[0] - bounds.Min[0]
-			depth := bounds.Max[2] - bounds.Min[2]
-			if width < 8 {
-				t.Errorf("Room width too small: %f", width)
+			bounds := worker.mergeReconstructionOutput(models.NewEmptySceneGraph(), output).Bounds
+			if err := bounds.Validate(); err != nil {
+				t.Errorf("invalid bounds %+v: %v", bounds, err)
 			}
-			if depth < 8 {
-				t.Errorf("Room depth too small: %f", depth)
+			if bounds != tt.want {
+				t.Errorf("Bounds = %+v, want %+v", bounds, tt.want)
 			}
 		})
 	}
@@ -330,6 +323,155 @@ func TestReasoningWorker_ProcessWithMock(t *testing.T) {
 	}
 }
 
+func TestReasoningWorker_ProcessRejectsUngroundedOutput(t *testing.T) {
+	// The mock cites ev_001, which this scene doesn't have
+	sg := models.NewEmptySceneGraph()
+	sg.Evidence = []models.EvidenceCard{{ID: "ev_other", Title: "Glove", Confidence: 0.8}}
+	input := models.ReasoningInput{CaseID: uuid.New().String(), Scenegraph: sg}
+	inputJSON, _ := json.Marshal(input)
+	job := &queue.JobMessage{JobID: uuid.New(), CaseID: uuid.New(), Type: models.JobTypeReasoning, Input: inputJSON}
+
+	err := NewReasoningWorker(nil, nil, &clients.MockReasoningClient{}).Process(context.Background(), job)
+	if err == nil || !IsRetryable(err) {
+		t.Errorf("Process() error = %v, want a retryable grounding error", err)
+	}
+
+	// With the check disabled the job completes
+	worker := NewReasoningWorkerWithGrounding(nil, nil, &clients.MockReasoningClient{}, 0)
+	if err := worker.Process(context.Background(), job); err != nil {
+		t.Errorf("Process() with grounding disabled error = %v", err)
+	}
+}
+
+func TestReasoningWorker_CheckFeasibility(t *testing.T) {
+	worker := NewReasoningWorker(nil, nil, &clients.MockReasoningClient{})
+
+	sg := models.NewEmptySceneGraph()
+	sg.Objects = []models.SceneObject{{
+		ID:       "wall",
+		Type:     models.ObjectTypeWall,
+		BBox:     models.BoundingBox{Min: [3]float64{4.95, 0, 0}, Max: [3]float64{5.05, 3, 10}},
+		Metadata: map[string]interface{}{"segment": [][]float64{{5, 0}, {5, 10}}, "thickness": 0.1},
+	}}
+	output := &models.ReasoningOutput{Trajectories: []models.Trajectory{
+		{ID: "t1", Rank: 1, OverallConfidence: 0.8, Segments: []models.TrajectorySegment{
+			{ID: "s1", FromPosition: [3]float64{2, 0, 5}, ToPosition: [3]float64{8, 0, 5}, Confidence: 0.8},
+		}},
+	}}
+
+	summary := worker.checkFeasibility(uuid.New(), models.ReasoningInput{Scenegraph: sg}, output)
+
+	seg := output.Trajectories[0].Segments[0]
+	if summary.Infeasible != 1 || seg.Feasibility == nil || seg.Feasibility.Status != models.FeasibilityStatusInfeasible {
+		t.Fatalf("checkFeasibility() = %+v, segment %+v", summary, seg.Feasibility)
+	}
+	if seg.Confidence >= 0.8 || output.Trajectories[0].OverallConfidence >= 0.8 {
+		t.Errorf("confidence not reduced: segment %v, overall %v", seg.Confidence, output.Trajectories[0].OverallConfidence)
+	}
+}
+
+func TestReasoningWorker_EvaluateConstraints(t *testing.T) {
+	worker := NewReasoningWorker(nil, nil, &clients.MockReasoningClient{})
+
+	floor, err := models.NewConstraint("floor", models.ConstraintTypePassableArea, "Room floor",
+		&models.PassableAreaParams{Polygon: [][2]float64{{0, 0}, {10, 0}, {10, 10}, {0, 10}}}, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := models.ReasoningInput{Scenegraph: models.NewEmptySceneGraph(), ConstraintsOverride: []models.Constraint{*floor}}
+	output := &models.ReasoningOutput{Trajectories: []models.Trajectory{
+		{ID: "t1", Rank: 1, OverallConfidence: 0.8, Segments: []models.TrajectorySegment{
+			{ID: "s1", FromPosition: [3]float64{2, 0, 5}, ToPosition: [3]float64{14, 0, 5}, Confidence: 0.8},
+		}},
+	}}
+
+	worker.evaluateConstraints(uuid.New(), input, output)
+
+	report := output.Trajectories[0].ConstraintReport
+	if report == nil || report.Violated != 1 || report.Results[0].ConstraintID != "floor" {
+		t.Fatalf("evaluateConstraints() report = %+v", report)
+	}
+}
+
+func TestReasoningWorker_ScoreTrajectories(t *testing.T) {
+	worker := NewReasoningWorker(nil, nil, &clients.MockReasoningClient{})
+
+	sg := models.NewEmptySceneGraph()
+	sg.Evidence = []models.EvidenceCard{{
+		ID: "ev-1", Title: "Footprint", Confidence: 1,
+		Sources: []models.EvidenceSource{{Type: models.EvidenceSourceTypeUpload}},
+	}}
+	output := &models.ReasoningOutput{Trajectories: []models.Trajectory{
+		{ID: "unsupported", Rank: 1, OverallConfidence: 0.9, Segments: []models.TrajectorySegment{
+			{ID: "s1", FromPosition: [3]float64{1, 0, 1}, ToPosition: [3]float64{3, 0, 3}},
+		}},
+		{ID: "supported", Rank: 2, OverallConfidence: 0.5, Segments: []models.TrajectorySegment{
+			{ID: "s1", FromPosition: [3]float64{1, 0, 1}, ToPosition: [3]float64{3, 0, 3},
+				EvidenceRefs: []models.EvidenceRef{{EvidenceID: "ev-1", Relevance: "supports", Weight: 1}}},
+		}},
+	}}
+
+	scoring.RecordModelScores(output.Trajectories)
+	worker.scoreTrajectories(uuid.New(), models.ReasoningInput{Scenegraph: sg}, output)
+
+	top := output.Trajectories[0]
+	if top.ID != "supported" || top.Rank != 1 || output.Scoring == nil || !output.Scoring.Reranked {
+		t.Fatalf("scoreTrajectories() top = %s (rank %d), scoring %+v", top.ID, top.Rank, output.Scoring)
+	}
+	if top.Score.ModelConfidence != 0.5 || top.Score.ModelRank != 2 || top.OverallConfidence != 1 {
+		t.Errorf("supported score = %+v, overall %v", top.Score, top.OverallConfidence)
+	}
+}
+
+func TestGenerateHTMLReport_ConstraintChecks(t *testing.T) {
+	height, err := models.NewConstraint("height", models.ConstraintTypeHeightRange, "Height from shoe prints",
+		&models.HeightRangeParams{MinCm: 175, MaxCm: 190}, 0.7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sg := models.NewEmptySceneGraph()
+	sg.Constraints = []models.Constraint{*height}
+	profile := &models.SuspectProfile{Attributes: &models.SuspectAttributes{
+		HeightRangeCm: &models.RangeAttribute{Min: 160, Max: 168, Confidence: 0.6},
+	}}
+
+	rep := report.Build(&models.Case{Title: "Test"}, nil, &models.SceneSnapshot{Scenegraph: sg}, profile, time.Now())
+	html, err := report.RenderHTML(rep, report.DefaultTemplate())
+	if err != nil {
+		t.Fatalf("RenderHTML() error = %v", err)
+	}
+	for _, want := range []string{"Constraint Checks (0 satisfied, 1 violated)", "badge-red", "outside 175–190 cm"} {
+		if !strings.Contains(string(html), want) {
+			t.Errorf("report is missing %q", want)
+		}
+	}
+}
+
+func TestExportWorker_FetchPortrait(t *testing.T) {
+	var bucket, key string
+	storage := &clients.MockStorageClient{
+		DownloadFunc: func(ctx context.Context, b, k string) ([]byte, string, error) {
+			bucket, key = b, k
+			return []byte("png"), "image/png", nil
+		},
+	}
+	worker := NewExportWorker(nil, nil, storage)
+	if data := worker.fetchPortrait(context.Background(), "cases/1/portrait.png"); string(data) != "png" {
+		t.Errorf("fetchPortrait() = %q", data)
+	}
+	if bucket != "case-assets" || key != "cases/1/portrait.png" {
+		t.Errorf("downloaded %s/%s", bucket, key)
+	}
+
+	// A failed download leaves the report without a portrait
+	storage.DownloadFunc = func(ctx context.Context, b, k string) ([]byte, string, error) {
+		return nil, "", errors.New("not found")
+	}
+	if data := worker.fetchPortrait(context.Background(), "missing.png"); data != nil {
+		t.Errorf("fetchPortrait() of a missing portrait = %q", data)
+	}
+}
+
 func TestProfileWorker_ProcessWithMock(t *testing.T) {
 	mockClient := &clients.MockProfileClient{}
 	worker := NewProfileWorker(nil, nil, mockClient)
@@ -406,3 +548,33 @@ func TestWorkerError_InvalidInput(t *testing.T) {
 	}
 }
 
+
+func TestImageGenWorker_StoreFloorPlan(t *testing.T) {
+	var bucket, key, contentType string
+	storage := &clients.MockStorageClient{
+		UploadFunc: func(ctx context.Context, b, k string, data []byte, ct string) error {
+			bucket, key, contentType = b, k, ct
+			return nil
+		},
+	}
+	worker := NewImageGenWorkerWithStorage(nil, nil, &clients.MockImageGenClient{}, storage)
+	caseID := uuid.New()
+
+	sg := models.NewEmptySceneGraph()
+	sg.Objects = []models.SceneObject{{ID: "table", Type: models.ObjectTypeFurniture, Label: "Table",
+		BBox: models.BoundingBox{Min: [3]float64{0, 0, 0}, Max: [3]float64{2, 1, 1}}}}
+	got, err := worker.storeFloorPlan(context.Background(), caseID, sg, nil)
+	if err != nil {
+		t.Fatalf("storeFloorPlan() error = %v", err)
+	}
+	if got != key || bucket != floorPlanBucket || contentType != "image/png" || !strings.HasPrefix(key, "cases/"+caseID.String()+"/floorplans/") {
+		t.Errorf("storeFloorPlan() = %q, uploaded %s/%s as %s", got, bucket, key, contentType)
+	}
+
+	// Nothing to draw is not an error; the board is generated without a plan
+	key = ""
+	got, err = worker.storeFloorPlan(context.Background(), caseID, models.NewEmptySceneGraph(), nil)
+	if err != nil || got != "" || key != "" {
+		t.Errorf("storeFloorPlan() of an empty scene = %q, %v", got, err)
+	}
+}
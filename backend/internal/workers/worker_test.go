@@ -1,9 +1,17 @@
 package workers
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sherlockos/backend/internal/db"
+	"github.com/sherlockos/backend/internal/models"
+	"github.com/sherlockos/backend/internal/queue"
 )
 
 func TestDefaultRetryConfig(t *testing.T) {
@@ -143,3 +151,98 @@ func TestRetryConfig_CustomValues(t *testing.T) {
 
 // Note: Integration tests for Manager require running Redis
 // These would test actual job processing with mock workers
+
+// fakeCases serves cases from memory
+type fakeCases map[uuid.UUID]*models.Case
+
+func (f fakeCases) GetCase(ctx context.Context, id uuid.UUID) (*models.Case, error) {
+	return f[id], nil
+}
+
+// recordingWorker counts the jobs it processes
+type recordingWorker struct {
+	jobType   models.JobType
+	processed int
+}
+
+func (w *recordingWorker) Type() models.JobType { return w.jobType }
+
+func (w *recordingWorker) Process(ctx context.Context, job *queue.JobMessage) error {
+	w.processed++
+	return nil
+}
+
+func TestProcessJob_CaseClosedWhileQueued(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		jobType models.JobType
+		close   func(c *models.Case)
+		runs    bool
+	}{
+		{"closed", models.JobTypeReasoning, func(c *models.Case) { c.Status = models.CaseStatusClosed }, false},
+		{"archived", models.JobTypeProfile, func(c *models.Case) { c.Status = models.CaseStatusArchived }, false},
+		{"deleted", models.JobTypeReconstruction, func(c *models.Case) { c.DeletedAt = &now }, false},
+		{"export of closed case", models.JobTypeExport, func(c *models.Case) { c.Status = models.CaseStatusClosed }, true},
+		{"still open", models.JobTypeReasoning, func(c *models.Case) {}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			q := queue.NewMemoryQueue()
+			c := &models.Case{ID: uuid.New(), Status: models.CaseStatusOpen}
+
+			job, err := models.NewJob(c.ID, tt.jobType, map[string]interface{}{"case_id": c.ID.String()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := q.Enqueue(ctx, job); err != nil {
+				t.Fatal(err)
+			}
+			tt.close(c)
+
+			m := NewManager(nil, q, DefaultManagerConfig())
+			m.cases = fakeCases{c.ID: c}
+			w := &recordingWorker{jobType: tt.jobType}
+
+			msg, err := q.Dequeue(ctx, tt.jobType, time.Second)
+			if err != nil || msg == nil {
+				t.Fatalf("Dequeue() = %v, %v", msg, err)
+			}
+			m.processJob(ctx, w, msg)
+
+			if ran := w.processed > 0; ran != tt.runs {
+				t.Errorf("job ran = %v, want %v", ran, tt.runs)
+			}
+			// A skipped job fails for good rather than going back on the queue
+			if n, _ := q.QueueLength(ctx, tt.jobType); n != 0 {
+				t.Errorf("QueueLength() = %d, want 0", n)
+			}
+		})
+	}
+}
+
+func TestCaseClosedError(t *testing.T) {
+	err := caseClosedError(fmt.Errorf("saving commit: %w", db.ErrCaseClosed))
+	if err == nil || IsRetryable(err) {
+		t.Errorf("caseClosedError(ErrCaseClosed) = %v, want a fatal error", err)
+	}
+	if err := caseClosedError(errors.New("connection reset")); err != nil {
+		t.Errorf("caseClosedError(other) = %v, want nil", err)
+	}
+}
+
+func TestManager_RegisterSetsBudgets(t *testing.T) {
+	budgets := models.Budgets{CaseUSD: 5, OrganisationMonthlyUSD: 100}
+	config := DefaultManagerConfig()
+	config.Budgets = budgets
+	m := NewManager(nil, queue.NewMemoryQueue(), config)
+
+	// Jobs the worker spawns (portraits) are checked against the manager's budgets
+	w := NewProfileWorker(nil, nil, nil)
+	m.Register(w)
+	if w.budgets != budgets {
+		t.Errorf("worker budgets = %+v, want %+v", w.budgets, budgets)
+	}
+}
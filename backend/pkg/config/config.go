@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -19,6 +20,17 @@ type Config struct {
 	SupabaseAnonKey   string
 	SupabaseSecretKey string
 
+	// Authentication: "supabase" verifies Supabase Auth tokens with the
+	// project's JWT secret, "local" verifies tokens signed with a local
+	// secret (offline installs), "disabled" leaves every route open
+	AuthMode          string
+	SupabaseJWTSecret string
+	AuthLocalSecret   string
+
+	// AuthBootstrapLead is a user ID made lead of every case without members
+	// at startup, so that cases from before authentication stay reachable
+	AuthBootstrapLead string
+
 	// Redis
 	RedisURL string
 
@@ -27,6 +39,35 @@ type Config struct {
 	HunyuanEndpoint   string
 	ReplicateAPIToken string
 
+	// LLM providers per job type (text / vision generation)
+	LLMReasoning     LLMConfig
+	LLMProfile       LLMConfig
+	LLMSceneAnalysis LLMConfig
+
+	// ReasoningMinGrounding is the share of a reasoning output's evidence and
+	// object references that must resolve to the scene; 0 disables the check
+	ReasoningMinGrounding float64
+
+	// ReportTemplateDir holds named HTML report templates (<name>.html.tmpl),
+	// used when a template isn't found in the database
+	ReportTemplateDir string
+
+	// CaseRetentionDays is how long a deleted case can be restored before it
+	// and its stored files are purged
+	CaseRetentionDays int
+
+	// Job creation rate limits per user and per case, in jobs a minute with
+	// bursts of up to the burst size; a rate of 0 disables the limit
+	JobRateUserPerMinute float64
+	JobRateUserBurst     int
+	JobRateCasePerMinute float64
+	JobRateCaseBurst     int
+
+	// AI spending budgets in USD, per case and per calendar month across all
+	// cases; 0 means no budget
+	CaseBudgetUSD    float64
+	MonthlyBudgetUSD float64
+
 	// Modal Services (self-hosted AI)
 	ModalMirrorURL    string // HunyuanWorld-Mirror for reconstruction
 	ModalWorldPlayURL string // HY-World-1.5 for video generation
@@ -35,8 +76,38 @@ type Config struct {
 	EnableRealtime bool
 }
 
+// LLM provider names
+const (
+	LLMProviderGemini = "gemini"
+	LLMProviderOpenAI = "openai" // any OpenAI-compatible chat completions server
+)
+
+// LLMConfig selects the provider, model and thinking budget for one job type
+type LLMConfig struct {
+	Provider string // "gemini" (default) or "openai" for OpenAI-compatible servers
+	Model    string // empty uses the client's Gemini default, so required for "openai"
+	Endpoint string // base URL, required for "openai" (e.g. http://localhost:8000/v1)
+	APIKey   string // falls back to GEMINI_API_KEY for the gemini provider
+
+	// ThinkingBudget caps thinking tokens; 0 keeps the job's budget, -1 disables thinking
+	ThinkingBudget int
+}
+
+// Enabled reports whether the provider has the settings it needs
+func (c LLMConfig) Enabled() bool {
+	switch c.Provider {
+	case LLMProviderOpenAI:
+		// The jobs' default models are Gemini's, which local servers don't serve
+		return c.Endpoint != "" && c.Model != ""
+	default:
+		return c.APIKey != ""
+	}
+}
+
 // Load reads configuration from environment variables
 func Load() *Config {
+	geminiAPIKey := getEnv("GEMINI_API_KEY", "")
+
 	return &Config{
 		// Server
 		Port:           getEnv("PORT", "8080"),
@@ -50,14 +121,42 @@ func Load() *Config {
 		SupabaseAnonKey:   getEnv("SUPABASE_ANON_KEY", ""),
 		SupabaseSecretKey: getEnv("SUPABASE_SECRET_KEY", ""),
 
+		// Authentication
+		AuthMode:          authMode(),
+		SupabaseJWTSecret: getEnv("SUPABASE_JWT_SECRET", ""),
+		AuthLocalSecret:   getEnv("AUTH_LOCAL_SECRET", ""),
+		AuthBootstrapLead: getEnv("AUTH_BOOTSTRAP_LEAD", ""),
+
 		// Redis
 		RedisURL: getEnv("REDIS_URL", "redis://localhost:6379"),
 
 		// AI Services
-		GeminiAPIKey:      getEnv("GEMINI_API_KEY", ""),
+		GeminiAPIKey:      geminiAPIKey,
 		HunyuanEndpoint:   getEnv("HUNYUAN_ENDPOINT", ""),
 		ReplicateAPIToken: getEnv("REPLICATE_API_TOKEN", ""),
 
+		// LLM providers
+		LLMReasoning:     loadLLMConfig("REASONING", geminiAPIKey),
+		LLMProfile:       loadLLMConfig("PROFILE", geminiAPIKey),
+		LLMSceneAnalysis: loadLLMConfig("SCENE_ANALYSIS", geminiAPIKey),
+
+		// Reasoning output checks
+		ReasoningMinGrounding: getEnvFloat("REASONING_MIN_GROUNDING", 0.5),
+
+		// Exports
+		ReportTemplateDir: getEnv("REPORT_TEMPLATE_DIR", ""),
+
+		// Case lifecycle
+		CaseRetentionDays: getEnvInt("CASE_RETENTION_DAYS", 30),
+
+		// Rate limits and budgets
+		JobRateUserPerMinute: getEnvFloat("JOB_RATE_USER_PER_MINUTE", 10),
+		JobRateUserBurst:     getEnvInt("JOB_RATE_USER_BURST", 20),
+		JobRateCasePerMinute: getEnvFloat("JOB_RATE_CASE_PER_MINUTE", 20),
+		JobRateCaseBurst:     getEnvInt("JOB_RATE_CASE_BURST", 40),
+		CaseBudgetUSD:        getEnvFloat("CASE_BUDGET_USD", 0),
+		MonthlyBudgetUSD:     getEnvFloat("MONTHLY_BUDGET_USD", 0),
+
 		// Modal Services
 		ModalMirrorURL:    getEnv("MODAL_MIRROR_URL", "https://ykzou1214--sherlock-mirror"),
 		ModalWorldPlayURL: getEnv("MODAL_WORLDPLAY_URL", "https://ykzou1214--hy-worldplay-simple"),
@@ -67,6 +166,65 @@ func Load() *Config {
 	}
 }
 
+// authMode reads AUTH_MODE. Unset, it follows whichever secret is configured
+// and is disabled without one.
+func authMode() string {
+	if mode := getEnv("AUTH_MODE", ""); mode != "" {
+		return strings.ToLower(mode)
+	}
+	switch {
+	case getEnv("SUPABASE_JWT_SECRET", "") != "":
+		return "supabase"
+	case getEnv("AUTH_LOCAL_SECRET", "") != "":
+		return "local"
+	}
+	return "disabled"
+}
+
+// loadLLMConfig reads LLM_<JOB>_* settings. Unset values fall back to the shared
+// LLM_* settings unless the job overrides the provider.
+func loadLLMConfig(job, geminiAPIKey string) LLMConfig {
+	sharedProvider := strings.ToLower(getEnv("LLM_PROVIDER", LLMProviderGemini))
+	provider := strings.ToLower(getEnv("LLM_"+job+"_PROVIDER", sharedProvider))
+
+	get := func(name string) string {
+		if provider != sharedProvider {
+			return getEnv("LLM_"+job+"_"+name, "")
+		}
+		return getEnv("LLM_"+job+"_"+name, getEnv("LLM_"+name, ""))
+	}
+
+	cfg := LLMConfig{
+		Provider:       provider,
+		Model:          get("MODEL"),
+		Endpoint:       get("ENDPOINT"),
+		APIKey:         get("API_KEY"),
+		ThinkingBudget: getEnvInt("LLM_"+job+"_THINKING_BUDGET", getEnvInt("LLM_THINKING_BUDGET", 0)),
+	}
+	if cfg.APIKey == "" && cfg.Provider == LLMProviderGemini {
+		cfg.APIKey = geminiAPIKey
+	}
+	return cfg
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -29,6 +29,10 @@ func TestLoad_Defaults(t *testing.T) {
 	if cfg.DatabaseURL != "" {
 		t.Errorf("Load() DatabaseURL should be empty by default, got %v", cfg.DatabaseURL)
 	}
+
+	if cfg.ReasoningMinGrounding != 0.5 {
+		t.Errorf("Load() ReasoningMinGrounding = %v, want 0.5", cfg.ReasoningMinGrounding)
+	}
 }
 
 func TestLoad_FromEnv(t *testing.T) {
@@ -123,3 +127,115 @@ func TestGetEnv(t *testing.T) {
 		t.Errorf("getEnv() with empty value = %v, want default", got)
 	}
 }
+
+func TestLoad_LLMConfig(t *testing.T) {
+	os.Setenv("GEMINI_API_KEY", "test-gemini-key")
+	os.Setenv("LLM_PROVIDER", "openai")
+	os.Setenv("LLM_ENDPOINT", "http://localhost:8000/v1")
+	os.Setenv("LLM_REASONING_MODEL", "qwen3-32b")
+	os.Setenv("LLM_REASONING_THINKING_BUDGET", "2048")
+	os.Setenv("LLM_SCENE_ANALYSIS_PROVIDER", "gemini")
+
+	defer func() {
+		os.Unsetenv("GEMINI_API_KEY")
+		os.Unsetenv("LLM_PROVIDER")
+		os.Unsetenv("LLM_ENDPOINT")
+		os.Unsetenv("LLM_REASONING_MODEL")
+		os.Unsetenv("LLM_REASONING_THINKING_BUDGET")
+		os.Unsetenv("LLM_SCENE_ANALYSIS_PROVIDER")
+	}()
+
+	cfg := Load()
+
+	if cfg.LLMReasoning.Provider != LLMProviderOpenAI || cfg.LLMReasoning.Endpoint != "http://localhost:8000/v1" {
+		t.Errorf("Load() LLMReasoning = %+v", cfg.LLMReasoning)
+	}
+	if cfg.LLMReasoning.Model != "qwen3-32b" || cfg.LLMReasoning.ThinkingBudget != 2048 {
+		t.Errorf("Load() LLMReasoning model/budget = %v/%v", cfg.LLMReasoning.Model, cfg.LLMReasoning.ThinkingBudget)
+	}
+	if !cfg.LLMReasoning.Enabled() {
+		t.Error("LLMReasoning.Enabled() should be true with an endpoint")
+	}
+
+	// Profile inherits the shared settings but not reasoning-specific ones
+	if cfg.LLMProfile.Provider != LLMProviderOpenAI || cfg.LLMProfile.Model != "" || cfg.LLMProfile.ThinkingBudget != 0 {
+		t.Errorf("Load() LLMProfile = %+v", cfg.LLMProfile)
+	}
+	if cfg.LLMProfile.APIKey != "" {
+		t.Errorf("Load() LLMProfile.APIKey = %v, gemini key should not leak to openai provider", cfg.LLMProfile.APIKey)
+	}
+	if cfg.LLMProfile.Enabled() {
+		t.Error("LLMProfile.Enabled() should be false for openai without a model")
+	}
+
+	// Scene analysis overrides the provider and falls back to the Gemini key
+	if cfg.LLMSceneAnalysis.Provider != LLMProviderGemini || cfg.LLMSceneAnalysis.APIKey != "test-gemini-key" || cfg.LLMSceneAnalysis.Endpoint != "" {
+		t.Errorf("Load() LLMSceneAnalysis = %+v", cfg.LLMSceneAnalysis)
+	}
+}
+
+func TestLLMConfig_Enabled(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  LLMConfig
+		want bool
+	}{
+		{"gemini with key", LLMConfig{Provider: LLMProviderGemini, APIKey: "k"}, true},
+		{"gemini without key", LLMConfig{Provider: LLMProviderGemini}, false},
+		{"openai with endpoint and model", LLMConfig{Provider: LLMProviderOpenAI, Endpoint: "http://localhost:8080/v1", Model: "qwen3-32b"}, true},
+		{"openai without endpoint", LLMConfig{Provider: LLMProviderOpenAI, Model: "qwen3-32b", APIKey: "k"}, false},
+		{"openai without model", LLMConfig{Provider: LLMProviderOpenAI, Endpoint: "http://localhost:8080/v1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.Enabled(); got != tt.want {
+				t.Errorf("Enabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_AuthMode(t *testing.T) {
+	for _, key := range []string{"AUTH_MODE", "SUPABASE_JWT_SECRET", "AUTH_LOCAL_SECRET"} {
+		t.Setenv(key, "")
+	}
+	if got := Load().AuthMode; got != "disabled" {
+		t.Errorf("AuthMode without secrets = %v, want disabled", got)
+	}
+
+	t.Setenv("AUTH_LOCAL_SECRET", "offline")
+	if got := Load().AuthMode; got != "local" {
+		t.Errorf("AuthMode with a local secret = %v, want local", got)
+	}
+
+	t.Setenv("SUPABASE_JWT_SECRET", "project")
+	if got := Load().AuthMode; got != "supabase" {
+		t.Errorf("AuthMode with a Supabase secret = %v, want supabase", got)
+	}
+
+	t.Setenv("AUTH_MODE", "Local")
+	if got := Load().AuthMode; got != "local" {
+		t.Errorf("AuthMode set explicitly = %v, want local", got)
+	}
+
+	t.Setenv("AUTH_BOOTSTRAP_LEAD", "6f1c2a3e-0000-4000-8000-000000000001")
+	if got := Load().AuthBootstrapLead; got != "6f1c2a3e-0000-4000-8000-000000000001" {
+		t.Errorf("AuthBootstrapLead = %v, want the configured user", got)
+	}
+}
+
+func TestLoad_JobLimits(t *testing.T) {
+	cfg := Load()
+	if cfg.JobRateUserPerMinute != 10 || cfg.JobRateCaseBurst != 40 || cfg.CaseBudgetUSD != 0 {
+		t.Errorf("defaults = %v/min per user, case burst %d, case budget %v", cfg.JobRateUserPerMinute, cfg.JobRateCaseBurst, cfg.CaseBudgetUSD)
+	}
+
+	t.Setenv("JOB_RATE_USER_PER_MINUTE", "0")
+	t.Setenv("CASE_BUDGET_USD", "25")
+	t.Setenv("MONTHLY_BUDGET_USD", "500.50")
+	cfg = Load()
+	if cfg.JobRateUserPerMinute != 0 || cfg.CaseBudgetUSD != 25 || cfg.MonthlyBudgetUSD != 500.5 {
+		t.Errorf("from env = %v/min per user, budgets %v and %v", cfg.JobRateUserPerMinute, cfg.CaseBudgetUSD, cfg.MonthlyBudgetUSD)
+	}
+}
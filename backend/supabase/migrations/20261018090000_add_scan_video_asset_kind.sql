@@ -0,0 +1,26 @@
+-- SherlockOS Database Schema Update
+-- Migration: 004_add_scan_video_asset_kind
+-- Description: Add asset kind for uploaded walkthrough videos
+--   - scan_video: MP4 scans registered through the ingest endpoint
+
+-- ============================================
+-- ADD NEW ASSET KINDS
+-- ============================================
+
+-- Add 'scan_video' asset kind for uploaded MP4 scans
+ALTER TYPE asset_kind ADD VALUE IF NOT EXISTS 'scan_video';
+
+-- ============================================
+-- COMMENTS
+-- ============================================
+
+COMMENT ON TYPE asset_kind IS 'Asset types:
+  - scan_image: Uploaded crime scene scan images
+  - scan_video: Uploaded crime scene walkthrough videos (MP4)
+  - generated_image: Nano Banana generated images
+  - mesh: 3D mesh files (GLB/OBJ)
+  - pointcloud: Point cloud data
+  - portrait: Suspect portrait images
+  - report: Generated HTML/PDF reports
+  - replay_video: HY-World-1.5 trajectory animation videos
+  - evidence_model: Hunyuan3D-2 evidence 3D models (GLB)';
@@ -0,0 +1,29 @@
+-- SherlockOS Database Schema Update
+-- Migration: 005_add_report_templates
+-- Description: Named HTML templates for exported case reports
+--   - report_templates: looked up by name before the template directory
+
+-- ============================================
+-- REPORT TEMPLATES
+-- ============================================
+
+CREATE TABLE report_templates (
+  name         text PRIMARY KEY,
+  description  text,
+  body         text NOT NULL,
+  created_at   timestamptz NOT NULL DEFAULT now(),
+  updated_at   timestamptz NOT NULL DEFAULT now(),
+
+  CONSTRAINT report_templates_name_format CHECK (name ~ '^[a-z0-9][a-z0-9_-]{0,63}$')
+);
+
+CREATE TRIGGER report_templates_updated_at
+  BEFORE UPDATE ON report_templates
+  FOR EACH ROW
+  EXECUTE FUNCTION update_updated_at_column();
+
+-- ============================================
+-- COMMENTS
+-- ============================================
+
+COMMENT ON TABLE report_templates IS 'Named Go html/template sources for HTML case reports';
@@ -0,0 +1,24 @@
+-- SherlockOS Database Schema Update
+-- Migration: 006_add_search_indexes
+-- Description: Full-text search indexes for GET /v1/search
+--   - cases: title and description
+--   - commits: summary and every string in the payload, including witness statements
+--   Evidence cards and object labels are searched in scene snapshots, one row per case
+
+-- ============================================
+-- SEARCH INDEXES
+-- ============================================
+
+-- The expressions must match the search query in the repository exactly
+CREATE INDEX idx_cases_search ON cases
+  USING gin (to_tsvector('english', title || ' ' || coalesce(description, '')));
+
+CREATE INDEX idx_commits_search ON commits
+  USING gin ((to_tsvector('english', summary) || jsonb_to_tsvector('english', payload, '["string"]')));
+
+-- ============================================
+-- COMMENTS
+-- ============================================
+
+COMMENT ON INDEX idx_cases_search IS 'Full-text search over case titles and descriptions';
+COMMENT ON INDEX idx_commits_search IS 'Full-text search over commit summaries and payload strings';
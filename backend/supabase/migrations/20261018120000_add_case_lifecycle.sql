@@ -0,0 +1,43 @@
+-- SherlockOS Database Schema Update
+-- Migration: 007_add_case_lifecycle
+-- Description: Case status, updates and soft delete
+--   - status: open, under_review, closed or archived; closed and archived cases are read-only
+--   - updated_at: maintained by trigger
+--   - deleted_at: set on soft delete; the case is purged once the retention window passes
+
+-- ============================================
+-- CASE STATUS
+-- ============================================
+
+CREATE TYPE case_status AS ENUM (
+  'open',
+  'under_review',
+  'closed',
+  'archived'
+);
+
+ALTER TABLE cases
+  ADD COLUMN status      case_status NOT NULL DEFAULT 'open',
+  ADD COLUMN updated_at  timestamptz NOT NULL DEFAULT now(),
+  ADD COLUMN deleted_at  timestamptz;
+
+UPDATE cases SET updated_at = created_at;
+
+CREATE INDEX idx_cases_status ON cases(status) WHERE deleted_at IS NULL;
+CREATE INDEX idx_cases_deleted_at ON cases(deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE TRIGGER cases_updated_at
+  BEFORE UPDATE ON cases
+  FOR EACH ROW
+  EXECUTE FUNCTION update_updated_at_column();
+
+-- ============================================
+-- COMMENTS
+-- ============================================
+
+COMMENT ON TYPE case_status IS 'Case lifecycle:
+  - open: active investigation
+  - under_review: active, awaiting review
+  - closed: read-only; no new jobs or commits
+  - archived: read-only and kept for records';
+COMMENT ON COLUMN cases.deleted_at IS 'Soft delete time; the case and its stored files are purged after the retention window';
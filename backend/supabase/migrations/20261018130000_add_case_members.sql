@@ -0,0 +1,53 @@
+-- SherlockOS Database Schema Update
+-- Migration: 008_add_case_members
+-- Description: Case membership and roles for authenticated users
+--   - case_members: a user's role on a case (lead, investigator or viewer)
+--   - jobs.created_by: the user a job runs as, so its commits are attributed
+--   - existing cases with a creator make that user their lead
+
+-- ============================================
+-- CASE MEMBERS
+-- ============================================
+
+CREATE TYPE case_role AS ENUM (
+  'lead',
+  'investigator',
+  'viewer'
+);
+
+CREATE TABLE case_members (
+  case_id     uuid NOT NULL REFERENCES cases(id) ON DELETE CASCADE,
+  user_id     uuid NOT NULL,  -- Reference to auth.users (Supabase Auth) or a local token subject
+  role        case_role NOT NULL,
+  added_by    uuid,
+  created_at  timestamptz NOT NULL DEFAULT now(),
+  updated_at  timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (case_id, user_id)
+);
+
+CREATE INDEX idx_case_members_user ON case_members(user_id);
+
+CREATE TRIGGER case_members_updated_at
+  BEFORE UPDATE ON case_members
+  FOR EACH ROW
+  EXECUTE FUNCTION update_updated_at_column();
+
+INSERT INTO case_members (case_id, user_id, role, added_by)
+SELECT id, created_by, 'lead', created_by FROM cases WHERE created_by IS NOT NULL;
+
+-- ============================================
+-- JOB ATTRIBUTION
+-- ============================================
+
+ALTER TABLE jobs ADD COLUMN created_by uuid;
+
+-- ============================================
+-- COMMENTS
+-- ============================================
+
+COMMENT ON TABLE case_members IS 'Who may access a case and what they may do';
+COMMENT ON TYPE case_role IS 'Case access, each role including the ones below it:
+  - lead: update, delete and restore the case, manage members
+  - investigator: upload, submit statements, create branches and run jobs
+  - viewer: read only';
+COMMENT ON COLUMN jobs.created_by IS 'User the job was created by; commits written by the job are attributed to them';
@@ -0,0 +1,67 @@
+-- SherlockOS Database Schema Update
+-- Migration: 009_add_audit_log
+-- Description: Audit log of API requests and worker commits
+--   - audit_log: actor, request ID, route, case, action, target and outcome of every
+--     mutating or evidence-reading request, and of every commit a worker writes
+--   - entries are append-only and outlive the case they describe
+
+-- ============================================
+-- AUDIT LOG
+-- ============================================
+
+CREATE TYPE audit_actor_type AS ENUM (
+  'user',
+  'worker'
+);
+
+CREATE TYPE audit_outcome AS ENUM (
+  'success',
+  'denied',
+  'failed'
+);
+
+CREATE TABLE audit_log (
+  id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  case_id      uuid,  -- No foreign key: entries are kept after the case is purged
+  actor_type   audit_actor_type NOT NULL,
+  actor_id     uuid,
+  request_id   text,
+  job_id       uuid,
+  method       text,
+  route        text,
+  action       text NOT NULL,
+  target_type  text,
+  target_id    text,
+  outcome      audit_outcome NOT NULL,
+  status       int,
+  detail       text,
+  created_at   timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_audit_log_case ON audit_log(case_id, created_at DESC);
+CREATE INDEX idx_audit_log_actor ON audit_log(actor_id, created_at DESC);
+
+-- ============================================
+-- FUNCTIONS
+-- ============================================
+
+CREATE OR REPLACE FUNCTION reject_audit_log_change()
+RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+  BEFORE UPDATE OR DELETE ON audit_log
+  FOR EACH ROW
+  EXECUTE FUNCTION reject_audit_log_change();
+
+-- ============================================
+-- COMMENTS
+-- ============================================
+
+COMMENT ON TABLE audit_log IS 'Who did what to which case, from API requests and worker commits';
+COMMENT ON COLUMN audit_log.actor_id IS 'User the request or job ran as; NULL when authentication is disabled';
+COMMENT ON COLUMN audit_log.route IS 'Route pattern of the request, e.g. /v1/cases/{caseId}/jobs';
+COMMENT ON COLUMN audit_log.outcome IS 'success, denied (no access) or failed (any other error)';
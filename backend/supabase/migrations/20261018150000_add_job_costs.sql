@@ -0,0 +1,23 @@
+-- SherlockOS Database Schema Update
+-- Migration: 010_add_job_costs
+-- Description: AI job cost accounting for spending budgets
+--   - estimated_cost_usd: estimate from the job type and input, set when the job is created
+--   - cost_usd: actual cost, when the worker reports it
+--   - spending counts each job at its actual cost, else its estimate unless it failed
+
+-- ============================================
+-- JOB COSTS
+-- ============================================
+
+ALTER TABLE jobs
+  ADD COLUMN estimated_cost_usd  numeric(12, 4) NOT NULL DEFAULT 0,
+  ADD COLUMN cost_usd            numeric(12, 4);
+
+CREATE INDEX idx_jobs_created_at ON jobs(created_at);
+
+-- ============================================
+-- COMMENTS
+-- ============================================
+
+COMMENT ON COLUMN jobs.estimated_cost_usd IS 'Estimated cost in USD from the job type and input (resolution, image or frame count)';
+COMMENT ON COLUMN jobs.cost_usd IS 'Actual cost in USD as reported by the worker; NULL until known';
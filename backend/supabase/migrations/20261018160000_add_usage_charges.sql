@@ -0,0 +1,29 @@
+-- SherlockOS Database Schema Update
+-- Migration: 011_add_usage_charges
+-- Description: AI spending outside jobs, counted toward the monthly budget
+--   - usage_charges: one row per paid call made directly by the API (portrait chat)
+--   - charges are billed as a job type so that spend reports group them with jobs
+
+-- ============================================
+-- USAGE CHARGES
+-- ============================================
+
+CREATE TABLE usage_charges (
+  id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  type        job_type NOT NULL,
+  source      text NOT NULL,
+  cost_usd    numeric(12, 4) NOT NULL,
+  created_by  uuid,
+  created_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_usage_charges_created_at ON usage_charges(created_at);
+
+-- ============================================
+-- COMMENTS
+-- ============================================
+
+COMMENT ON TABLE usage_charges IS 'AI spending outside jobs; counts toward the monthly organisation budget';
+COMMENT ON COLUMN usage_charges.type IS 'Job type the charge is billed as, e.g. imagegen for portrait chat';
+COMMENT ON COLUMN usage_charges.source IS 'What made the call, e.g. portrait_chat';
+COMMENT ON COLUMN usage_charges.created_by IS 'User the charge was made for; NULL when authentication is disabled';
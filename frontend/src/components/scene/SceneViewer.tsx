@@ -877,18 +877,21 @@ function generateRandomPointCloud(count: number, bounds: { min: number[]; max: n
 // Convert API point cloud data to Float32Arrays for Three.js
 // Scales the point cloud to fit within the target bounds
 function convertPointCloudData(
-  pointCloud: { positions: number[][]; colors?: number[][]; count: number },
+  pointCloud: { positions?: number[][]; colors?: number[][]; count: number },
   targetBounds?: { min: number[]; max: number[] }
 ) {
-  const positions = new Float32Array(pointCloud.count * 3);
-  const colors = new Float32Array(pointCloud.count * 3);
+  // positions may be a downsampled preview of a larger stored cloud
+  const points = pointCloud.positions ?? [];
+  const pointCount = points.length;
+  const positions = new Float32Array(pointCount * 3);
+  const colors = new Float32Array(pointCount * 3);
 
   // Calculate source bounds from point cloud
   let srcMinX = Infinity, srcMinY = Infinity, srcMinZ = Infinity;
   let srcMaxX = -Infinity, srcMaxY = -Infinity, srcMaxZ = -Infinity;
 
-  for (let i = 0; i < pointCloud.count; i++) {
-    const pos = pointCloud.positions[i];
+  for (let i = 0; i < pointCount; i++) {
+    const pos = points[i];
     srcMinX = Math.min(srcMinX, pos[0]);
     srcMinY = Math.min(srcMinY, pos[1]);
     srcMinZ = Math.min(srcMinZ, pos[2]);
@@ -924,9 +927,9 @@ function convertPointCloudData(
 
   console.log(`Point cloud scale: ${scale.toFixed(2)}, src size: ${srcSizeX.toFixed(2)}x${srcSizeY.toFixed(2)}x${srcSizeZ.toFixed(2)}`);
 
-  for (let i = 0; i < pointCloud.count; i++) {
+  for (let i = 0; i < pointCount; i++) {
     const i3 = i * 3;
-    const pos = pointCloud.positions[i];
+    const pos = points[i];
 
     // Scale and center the point cloud
     positions[i3] = (pos[0] - srcCenterX) * scale + tgtCenterX;
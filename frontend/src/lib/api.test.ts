@@ -4,6 +4,8 @@ import {
   getCase,
   createCase,
   getTimeline,
+  getAuditLog,
+  getCaseSpend,
   getSnapshot,
   getUploadIntent,
   uploadFile,
@@ -18,6 +20,7 @@ import {
   getSuspectProfile,
   getAssetUrl,
   getExportResult,
+  setAuthTokenProvider,
   ApiError,
 } from './api';
 
@@ -81,6 +84,27 @@ describe('API Client', () => {
     });
   });
 
+  describe('auth token', () => {
+    afterEach(() => {
+      setAuthTokenProvider(() => null);
+    });
+
+    it('sends the provided token as a bearer token', async () => {
+      setAuthTokenProvider(async () => 'token-123');
+      mockFetch.mockResolvedValueOnce({
+        json: () => Promise.resolve({ success: true, data: [] }),
+      });
+
+      await getCases();
+      expect(mockFetch).toHaveBeenCalledWith(
+        expect.stringContaining('/cases'),
+        expect.objectContaining({
+          headers: { 'Content-Type': 'application/json', Authorization: 'Bearer token-123' },
+        })
+      );
+    });
+  });
+
   describe('getCase', () => {
     it('returns single case by ID', async () => {
       const caseData = { id: 'case-123', title: 'Test Case' };
@@ -170,6 +194,45 @@ describe('API Client', () => {
     });
   });
 
+  describe('getAuditLog', () => {
+    it('passes filters as query parameters', async () => {
+      const entries = [{ id: 'a1', action: 'case.update', outcome: 'success' }];
+      mockFetch.mockResolvedValueOnce({
+        json: () => Promise.resolve({ success: true, data: entries }),
+      });
+
+      const result = await getAuditLog('case-123', { outcome: 'denied', limit: 20 });
+      expect(result).toEqual(entries);
+      expect(mockFetch).toHaveBeenCalledWith(
+        expect.stringContaining('/cases/case-123/audit?outcome=denied&limit=20'),
+        expect.any(Object)
+      );
+    });
+  });
+
+  describe('getCaseSpend', () => {
+    it('returns case and monthly spend with budgets', async () => {
+      const spend = {
+        case: { spend: { total_usd: 0.48, by_job_type: { imagegen: 0.48 } }, budget_usd: 25 },
+        organisation: {
+          spend: { total_usd: 3.1, by_job_type: { imagegen: 2.8, reconstruction: 0.3 } },
+          budget_usd: 0,
+          period_start: '2026-10-01T00:00:00Z',
+        },
+      };
+      mockFetch.mockResolvedValueOnce({
+        json: () => Promise.resolve({ success: true, data: spend }),
+      });
+
+      const result = await getCaseSpend('case-123');
+      expect(result).toEqual(spend);
+      expect(mockFetch).toHaveBeenCalledWith(
+        expect.stringContaining('/cases/case-123/spend'),
+        expect.any(Object)
+      );
+    });
+  });
+
   describe('getSnapshot', () => {
     it('returns scene snapshot', async () => {
       const snapshot = {
@@ -1,14 +1,34 @@
 import type {
+  AuditEntry,
+  AuditOptions,
   Case,
+  CaseDeletion,
+  CaseMember,
+  CaseRole,
+  CaseSpend,
+  CaseStatus,
+  CaseUpdate,
   Commit,
   Job,
   SceneGraph,
   ApiResponse,
   JobType,
+  SearchOptions,
+  SearchResult,
 } from './types';
 
 const API_BASE = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080/v1';
 
+// Supplies the bearer token sent with API requests (a Supabase session's
+// access token, or a token from cmd/auth-token on offline installs)
+type TokenProvider = () => string | null | Promise<string | null>;
+
+let tokenProvider: TokenProvider = () => process.env.NEXT_PUBLIC_API_TOKEN || null;
+
+export function setAuthTokenProvider(provider: TokenProvider): void {
+  tokenProvider = provider;
+}
+
 class ApiError extends Error {
   code: string;
   details?: Record<string, unknown>;
@@ -26,11 +46,13 @@ async function request<T>(
   options: RequestInit = {}
 ): Promise<T> {
   const url = `${API_BASE}${endpoint}`;
+  const token = await tokenProvider();
 
   const response = await fetch(url, {
     ...options,
     headers: {
       'Content-Type': 'application/json',
+      ...(token ? { Authorization: `Bearer ${token}` } : {}),
       ...options.headers,
     },
   });
@@ -49,8 +71,8 @@ async function request<T>(
 }
 
 // Cases
-export async function getCases(): Promise<Case[]> {
-  return request<Case[]>('/cases');
+export async function getCases(status?: CaseStatus): Promise<Case[]> {
+  return request<Case[]>(status ? `/cases?status=${status}` : '/cases');
 }
 
 export async function getCase(caseId: string): Promise<Case> {
@@ -64,6 +86,64 @@ export async function createCase(title: string, description?: string): Promise<C
   });
 }
 
+export async function updateCase(caseId: string, update: CaseUpdate): Promise<Case> {
+  return request<Case>(`/cases/${caseId}`, {
+    method: 'PATCH',
+    body: JSON.stringify(update),
+  });
+}
+
+// Soft delete; the case can be restored until purge_after
+export async function deleteCase(caseId: string): Promise<CaseDeletion> {
+  return request<CaseDeletion>(`/cases/${caseId}`, { method: 'DELETE' });
+}
+
+export async function restoreCase(caseId: string): Promise<Case> {
+  return request<Case>(`/cases/${caseId}/restore`, { method: 'POST' });
+}
+
+export async function getCaseMembers(caseId: string): Promise<CaseMember[]> {
+  return request<CaseMember[]>(`/cases/${caseId}/members`);
+}
+
+export async function setCaseMember(caseId: string, userId: string, role: CaseRole): Promise<CaseMember> {
+  return request<CaseMember>(`/cases/${caseId}/members/${userId}`, {
+    method: 'PUT',
+    body: JSON.stringify({ role }),
+  });
+}
+
+export async function removeCaseMember(caseId: string, userId: string): Promise<void> {
+  await request(`/cases/${caseId}/members/${userId}`, { method: 'DELETE' });
+}
+
+// Search across cases, commits, witness statements, evidence and objects
+export async function searchCases(query: string, options: SearchOptions = {}): Promise<SearchResult> {
+  const params = new URLSearchParams({ q: query });
+  if (options.commitTypes?.length) params.set('commit_type', options.commitTypes.join(','));
+  if (options.from) params.set('from', options.from);
+  if (options.to) params.set('to', options.to);
+  if (options.limit) params.set('limit', String(options.limit));
+  return request<SearchResult>(`/search?${params}`);
+}
+
+// Audit log
+export async function getAuditLog(caseId: string, options: AuditOptions = {}): Promise<AuditEntry[]> {
+  const params = new URLSearchParams();
+  for (const key of ['actor', 'action', 'outcome', 'from', 'to', 'cursor'] as const) {
+    const value = options[key];
+    if (value) params.set(key, value);
+  }
+  if (options.limit) params.set('limit', String(options.limit));
+  const query = params.toString();
+  return request<AuditEntry[]>(`/cases/${caseId}/audit${query ? `?${query}` : ''}`);
+}
+
+// Spending
+export async function getCaseSpend(caseId: string): Promise<CaseSpend> {
+  return request<CaseSpend>(`/cases/${caseId}/spend`);
+}
+
 // Timeline
 export async function getTimeline(
   caseId: string,
@@ -269,6 +349,35 @@ export function getAssetUrl(storageKey: string): string {
   return `${supabaseUrl}/storage/v1/object/public/assets/${storageKey}`;
 }
 
+// Floor plan rendering options
+export interface FloorPlanOptions {
+  format?: 'svg' | 'png';
+  width?: number;         // 200-4096 px
+  labels?: boolean;
+  trajectories?: boolean;
+  commitId?: string;      // scene as of this commit
+}
+
+// Get the URL of a case's top-down floor plan, for use as an image source
+export function getFloorPlanUrl(caseId: string, options: FloorPlanOptions = {}): string {
+  const params = new URLSearchParams();
+  if (options.width) params.set('width', String(options.width));
+  if (options.labels === false) params.set('labels', 'false');
+  if (options.trajectories === false) params.set('trajectories', 'false');
+  if (options.commitId) params.set('commit_id', options.commitId);
+  const query = params.toString();
+  return `${API_BASE}/cases/${caseId}/floorplan.${options.format || 'svg'}${query ? `?${query}` : ''}`;
+}
+
+// Get the URL of a case's scene as a glTF binary, for download or a 3D viewer
+export function getSceneGlbUrl(caseId: string, options: { commitId?: string; trajectories?: boolean } = {}): string {
+  const params = new URLSearchParams();
+  if (options.trajectories === false) params.set('trajectories', 'false');
+  if (options.commitId) params.set('commit_id', options.commitId);
+  const query = params.toString();
+  return `${API_BASE}/cases/${caseId}/scene.glb${query ? `?${query}` : ''}`;
+}
+
 // Get export result (after job completes)
 export async function getExportResult(jobId: string): Promise<{
   report_asset_key?: string;
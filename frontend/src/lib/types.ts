@@ -1,10 +1,71 @@
 // Core domain types matching backend models
 
+export type CaseStatus = 'open' | 'under_review' | 'closed' | 'archived';
+
 export interface Case {
   id: string;
   title: string;
   description?: string;
+  status?: CaseStatus;
+  created_by?: string;
+  created_at: string;
+  updated_at?: string;
+  deleted_at?: string;
+  purge_after?: string;
+}
+
+export interface CaseUpdate {
+  title?: string;
+  description?: string;
+  status?: CaseStatus;
+}
+
+export type CaseRole = 'lead' | 'investigator' | 'viewer';
+
+export interface CaseMember {
+  user_id: string;
+  role: CaseRole;
+  added_by?: string;
   created_at: string;
+  updated_at: string;
+}
+
+export type AuditOutcome = 'success' | 'denied' | 'failed';
+
+// One entry of a case's audit log: an API request, or a commit written by a
+// worker on behalf of the user who queued its job
+export interface AuditEntry {
+  id: string;
+  case_id?: string;
+  actor_type: 'user' | 'worker';
+  actor_id?: string;    // absent when authentication is disabled
+  request_id?: string;
+  job_id?: string;
+  method?: string;
+  route?: string;       // e.g. /v1/cases/{caseId}/jobs
+  action: string;       // e.g. case.update, job.create, scene.read, commit.create
+  target_type?: string;
+  target_id?: string;
+  outcome: AuditOutcome;
+  status?: number;      // HTTP status of API requests
+  detail?: string;
+  created_at: string;
+}
+
+export interface AuditOptions {
+  actor?: string;       // user ID
+  action?: string;
+  outcome?: AuditOutcome;
+  from?: string;        // RFC 3339 time or YYYY-MM-DD
+  to?: string;          // a date includes the whole day
+  cursor?: string;
+  limit?: number;       // 1-500, default 100
+}
+
+export interface CaseDeletion {
+  id: string;
+  deleted_at: string;
+  purge_after: string;
 }
 
 export interface Commit {
@@ -37,10 +98,31 @@ export interface Job {
   input: Record<string, unknown>;
   output?: Record<string, unknown>;
   error?: string;
+  estimated_cost_usd?: number;
+  cost_usd?: number;    // actual cost, once recorded
   created_at: string;
   updated_at: string;
 }
 
+// Spending on AI jobs, in USD
+export interface Spend {
+  total_usd: number;
+  by_job_type: Partial<Record<JobType, number>>;
+}
+
+// Budgets of 0 are unlimited
+export interface CaseSpend {
+  case: {
+    spend: Spend;
+    budget_usd: number;
+  };
+  organisation: {
+    spend: Spend;       // this calendar month (UTC)
+    budget_usd: number;
+    period_start: string;
+  };
+}
+
 export type JobType =
   | 'reconstruction'
   | 'imagegen'
@@ -90,12 +172,97 @@ export interface ReconstructionInput {
   room_type?: string;                  // "office", "bedroom", etc.
 }
 
+// Export job input; the API defaults to PDF. 'bundle' exports the whole case as a zip,
+// 'gltf' the scene as GLB
+export type ExportFormat = 'html' | 'pdf' | 'bundle' | 'gltf';
+
+export type ReportSection =
+  | 'timeline'
+  | 'evidence'
+  | 'profile'
+  | 'trajectories'
+  | 'paradoxes'
+  | 'constraints'
+  | 'scene'
+  | 'custody_log'
+  | 'audit_log';
+
+export type EvidenceTier = 'high' | 'medium' | 'low'; // confidence >= 0.8, >= 0.5, below
+
+export interface RedactionRules {
+  witness_names?: boolean; // "Witness A", "Witness B", ...
+  names?: string[];
+  replacement?: string;    // fixed text instead of per-witness labels
+}
+
+// Report options don't apply to bundle exports; gltf exports take only branch_id or commit_id
+export interface ExportInput {
+  format?: ExportFormat;
+  template?: string;          // named HTML template; implies html
+  sections?: ReportSection[]; // defaults to all but custody_log and audit_log
+  evidence_tiers?: EvidenceTier[];
+  branch_id?: string;         // report on the branch's latest commit
+  commit_id?: string;         // or on the case as of this commit
+  redaction?: RedactionRules;
+}
+
+// GET /v1/search; snippets mark matched words with « and »
+export type SearchSource = 'case' | 'commit' | 'statement' | 'evidence' | 'object';
+
+export interface SearchHit {
+  source: SearchSource;
+  commit_id?: string;
+  commit_type?: CommitType;
+  ref_id?: string;    // evidence or object ID, or the statement's index in its commit
+  snippet: string;
+  rank: number;
+  at: string;
+}
+
+export interface CaseSearchResult {
+  case_id: string;
+  title: string;
+  rank: number;       // of the case's best hit
+  hits: SearchHit[];
+}
+
+export interface SearchResult {
+  query: string;
+  total: number;
+  cases: CaseSearchResult[];
+}
+
+export interface SearchOptions {
+  commitTypes?: CommitType[];
+  from?: string;      // RFC 3339 time or YYYY-MM-DD
+  to?: string;        // a date includes the whole day
+  limit?: number;     // 1-200, default 50
+}
+
+// Result of POST /v1/cases/import
+export interface CaseImportResult {
+  case_id: string;
+  source_case_id: string;
+  commits: number;
+  branches: number;
+  assets: number;
+  files: number;
+  missing_objects?: string[];     // files the export couldn't include
+  remapping: {
+    ids: Record<string, string>;          // bundle ID -> new ID
+    storage_keys: Record<string, string>; // bundle key -> new key
+  };
+}
+
 export type JobStatus = 'queued' | 'running' | 'done' | 'failed' | 'canceled';
 
 export interface PointCloud {
-  positions: number[][]; // [[x,y,z], ...]
-  colors?: number[][];   // [[r,g,b], ...] in 0-1 range
-  count: number;
+  positions?: number[][]; // [[x,y,z], ...]; a downsampled preview when asset_key is set
+  colors?: number[][];    // [[r,g,b], ...] in 0-1 range
+  count: number;          // points in the full cloud
+  asset_key?: string;     // storage key of the full binary cloud
+  format?: 'ply' | 'sqpc';
+  bounds?: BoundingBox;
 }
 
 export interface SceneGraph {
@@ -182,6 +349,25 @@ export type ConstraintType =
   | 'time_window'
   | 'custom';
 
+export type ConstraintStatus = 'satisfied' | 'violated' | 'not_applicable' | 'unevaluated';
+
+export interface ConstraintResult {
+  constraint_id: string;
+  type: ConstraintType;
+  status: ConstraintStatus;
+  detail?: string;
+  confidence: number;
+}
+
+export interface ConstraintReport {
+  subject_id?: string;
+  subject_type: 'trajectory' | 'suspect';
+  results: ConstraintResult[];
+  satisfied: number;
+  violated: number;
+  score: number;
+}
+
 export interface UncertaintyRegion {
   id: string;
   bbox: BoundingBox;
@@ -189,11 +375,54 @@ export interface UncertaintyRegion {
   reason: string;
 }
 
+export type SceneQueryMode = 'radius' | 'box' | 'ray' | 'nearest';
+
+export interface SceneQueryHit {
+  kind: 'object' | 'evidence' | 'uncertainty_region';
+  id: string;
+  label?: string;
+  type?: ObjectType;
+  state?: ObjectState;
+  bbox: BoundingBox;
+  distance: number;
+}
+
+export interface SceneQueryResult {
+  mode: SceneQueryMode;
+  commit_id?: string;
+  count: number;
+  hits: SceneQueryHit[];
+}
+
 export interface Trajectory {
   id: string;
   rank: number;
   overall_confidence: number;
   segments: TrajectorySegment[];
+  constraint_report?: ConstraintReport;
+  score?: TrajectoryScore;
+  grounding?: TrajectoryGrounding;
+}
+
+export interface TrajectoryGrounding {
+  ratio: number;
+  references: number;
+  resolved: number;
+}
+
+export interface TrajectoryScore {
+  model_confidence: number;
+  model_rank: number;
+  computed_confidence: number;
+  components: ScoreComponent[];
+}
+
+export interface ScoreComponent {
+  name: 'evidence' | 'feasibility' | 'constraints';
+  value: number;
+  weight: number;
+  contribution: number;
+  detail?: string;
 }
 
 export interface TrajectorySegment {
@@ -205,6 +434,18 @@ export interface TrajectorySegment {
   evidence_refs: EvidenceRef[];
   confidence: number;
   explanation: string;
+  feasibility?: SegmentFeasibility;
+}
+
+export interface SegmentFeasibility {
+  status: 'feasible' | 'detour_required' | 'infeasible';
+  straight_distance: number;
+  path_length?: number;
+  walking_seconds?: number;
+  running_seconds?: number;
+  path?: [number, number, number][];
+  prior_confidence: number;
+  reason?: string;
 }
 
 export interface EvidenceRef {